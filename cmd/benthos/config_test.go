@@ -23,6 +23,8 @@ package main
 import (
 	"reflect"
 	"testing"
+
+	"github.com/Jeffail/benthos/lib/util/config"
 )
 
 func CheckTagsOfType(v reflect.Type, checkedTypes map[string]struct{}, t *testing.T) {
@@ -58,3 +60,51 @@ func TestConfigTags(t *testing.T) {
 	checkedTypes := map[string]struct{}{}
 	CheckTagsOfType(v, checkedTypes, t)
 }
+
+func TestConfigOverrides(t *testing.T) {
+	conf := NewConfig()
+	if err := config.Override(&conf, "input.type", "kafka"); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Override(&conf, "input.kafka.topic", "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "kafka", conf.Input.Type; exp != act {
+		t.Errorf("Wrong input type: %v != %v", act, exp)
+	}
+	if exp, act := "foo", conf.Input.Kafka.Topic; exp != act {
+		t.Errorf("Wrong kafka topic: %v != %v", act, exp)
+	}
+	if err := config.Override(&conf, "input.kafka.tpoic", "foo"); err == nil {
+		t.Error("Expected error from unknown field")
+	}
+}
+
+func TestConfigFromEnvProcessors(t *testing.T) {
+	conf := NewConfig()
+	if err := config.OverrideFromEnv(&conf, "BENTHOS_", []string{
+		"BENTHOS_PIPELINE_PROCESSORS_0=jmespath",
+		"BENTHOS_PIPELINE_PROCESSORS_0_JMESPATH_QUERY=foo",
+		"BENTHOS_PIPELINE_PROCESSORS_1=text",
+		"BENTHOS_PIPELINE_PROCESSORS_1_TEXT_OPERATOR=to_upper",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	procs := conf.Pipeline.Processors
+	if exp, act := 2, len(procs); exp != act {
+		t.Fatalf("Wrong count of processors: %v != %v", act, exp)
+	}
+	if exp, act := "jmespath", procs[0].Type; exp != act {
+		t.Errorf("Wrong processor type: %v != %v", act, exp)
+	}
+	if exp, act := "foo", procs[0].JMESPath.Query; exp != act {
+		t.Errorf("Wrong jmespath query: %v != %v", act, exp)
+	}
+	if exp, act := "text", procs[1].Type; exp != act {
+		t.Errorf("Wrong processor type: %v != %v", act, exp)
+	}
+	if exp, act := "to_upper", procs[1].Text.Operator; exp != act {
+		t.Errorf("Wrong text operator: %v != %v", act, exp)
+	}
+}
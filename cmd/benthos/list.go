@@ -0,0 +1,92 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/buffer"
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/input"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/ratelimit"
+	"github.com/Jeffail/benthos/lib/util/config"
+)
+
+//------------------------------------------------------------------------------
+
+// componentSpecs maps each category of component to a function returning the
+// specs of all registered types within it.
+var componentSpecs = map[string]func() []config.ComponentSpec{
+	"buffers":     buffer.Specs,
+	"caches":      cache.Specs,
+	"conditions":  condition.Specs,
+	"inputs":      input.Specs,
+	"metrics":     metrics.Specs,
+	"outputs":     output.Specs,
+	"processors":  processor.Specs,
+	"rate-limits": ratelimit.Specs,
+}
+
+// listComponents prints a JSON object containing the specs of each registered
+// component, keyed by category. If categories are provided then only those are
+// printed.
+func listComponents(categories []string) error {
+	if len(categories) == 0 {
+		for k := range componentSpecs {
+			categories = append(categories, k)
+		}
+		sort.Strings(categories)
+	}
+
+	specs := map[string][]config.ComponentSpec{}
+	for _, c := range categories {
+		specsFn, exists := componentSpecs[c]
+		if !exists {
+			valid := []string{}
+			for k := range componentSpecs {
+				valid = append(valid, k)
+			}
+			sort.Strings(valid)
+			return fmt.Errorf(
+				"component category '%v' not recognised, expected one of: %v",
+				c, strings.Join(valid, ", "),
+			)
+		}
+		specs[c] = specsFn()
+	}
+
+	specsJSON, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stdout, string(specsJSON))
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestComponentSpecs(t *testing.T) {
+	for category, specsFn := range componentSpecs {
+		specs := specsFn()
+		if len(specs) == 0 {
+			t.Errorf("No specs for category: %v", category)
+		}
+		for i, spec := range specs {
+			if len(spec.Name) == 0 {
+				t.Errorf("Empty name in category: %v", category)
+			}
+			if len(spec.Description) == 0 {
+				t.Errorf("Empty description for %v: %v", category, spec.Name)
+			}
+			if i > 0 && specs[i-1].Name >= spec.Name {
+				t.Errorf("Specs of %v not ordered: %v >= %v", category, specs[i-1].Name, spec.Name)
+			}
+		}
+	}
+
+	if err := listComponents([]string{"not_a_category"}); err == nil {
+		t.Error("Expected error from bad category")
+	}
+}
@@ -30,6 +30,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
 	"time"
 
@@ -43,10 +45,13 @@ import (
 	"github.com/Jeffail/benthos/lib/pipeline"
 	"github.com/Jeffail/benthos/lib/processor"
 	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/ratelimit"
 	"github.com/Jeffail/benthos/lib/stream"
 	strmmgr "github.com/Jeffail/benthos/lib/stream/manager"
+	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/config"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/test"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -62,12 +67,20 @@ var (
 
 // Config is the benthos configuration struct.
 type Config struct {
-	HTTP                 api.Config `json:"http" yaml:"http"`
-	stream.Config        `json:",inline" yaml:",inline"`
-	Manager              manager.Config   `json:"resources" yaml:"resources"`
-	Logger               log.LoggerConfig `json:"logger" yaml:"logger"`
-	Metrics              metrics.Config   `json:"metrics" yaml:"metrics"`
-	SystemCloseTimeoutMS int              `json:"sys_exit_timeout_ms" yaml:"sys_exit_timeout_ms"`
+	HTTP            api.Config `json:"http" yaml:"http"`
+	stream.Config   `json:",inline" yaml:",inline"`
+	Manager         manager.Config               `json:"resources" yaml:"resources"`
+	Logger          log.LoggerConfig             `json:"logger" yaml:"logger"`
+	Metrics         metrics.Config               `json:"metrics" yaml:"metrics"`
+	Shutdown        stream.ShutdownConfig        `json:"shutdown" yaml:"shutdown"`
+	Acknowledgement stream.AcknowledgementConfig `json:"acknowledgement" yaml:"acknowledgement"`
+	Correlation     stream.CorrelationConfig     `json:"correlation" yaml:"correlation"`
+	Backpressure    stream.BackpressureConfig    `json:"backpressure" yaml:"backpressure"`
+	Streams         strmmgr.ConfigSet            `json:"streams,omitempty" yaml:"streams,omitempty"`
+
+	// SystemCloseTimeoutMS is deprecated in favour of Shutdown.TimeoutMS, and
+	// when set overrides it.
+	SystemCloseTimeoutMS int `json:"sys_exit_timeout_ms,omitempty" yaml:"sys_exit_timeout_ms,omitempty"`
 }
 
 // NewConfig returns a new configuration with default values.
@@ -76,12 +89,16 @@ func NewConfig() Config {
 	metricsConf.Prefix = "benthos"
 
 	return Config{
-		HTTP:                 api.NewConfig(),
-		Config:               stream.NewConfig(),
-		Manager:              manager.NewConfig(),
-		Logger:               log.NewLoggerConfig(),
-		Metrics:              metricsConf,
-		SystemCloseTimeoutMS: 20000,
+		HTTP:            api.NewConfig(),
+		Config:          stream.NewConfig(),
+		Manager:         manager.NewConfig(),
+		Logger:          log.NewLoggerConfig(),
+		Metrics:         metricsConf,
+		Shutdown:        stream.NewShutdownConfig(),
+		Acknowledgement: stream.NewAcknowledgementConfig(),
+		Correlation:     stream.NewCorrelationConfig(),
+		Backpressure:    stream.NewBackpressureConfig(),
+		Streams:         strmmgr.ConfigSet{},
 	}
 }
 
@@ -106,6 +123,32 @@ func (c Config) Sanitised() (interface{}, error) {
 		return nil, err
 	}
 
+	var errOutConf interface{}
+	if c.OnError != nil {
+		if errOutConf, err = output.SanitiseConfig(*c.OnError); err != nil {
+			return nil, err
+		}
+	}
+
+	var sizeConf interface{}
+	if c.MessageSize != nil {
+		if sizeConf, err = c.MessageSize.Sanitised(); err != nil {
+			return nil, err
+		}
+	}
+
+	var priorityConf interface{}
+	if c.Priority != nil {
+		if priorityConf, err = c.Priority.Sanitised(); err != nil {
+			return nil, err
+		}
+	}
+
+	var dedupeConf interface{}
+	if c.Dedupe != nil {
+		dedupeConf = c.Dedupe
+	}
+
 	var bufConf interface{}
 	bufConf, err = buffer.SanitiseConfig(c.Buffer)
 	if err != nil {
@@ -118,26 +161,52 @@ func (c Config) Sanitised() (interface{}, error) {
 		return nil, err
 	}
 
+	var streamsConf map[string]interface{}
+	if len(c.Streams) > 0 {
+		streamsConf = map[string]interface{}{}
+		for id, strmConf := range c.Streams {
+			if streamsConf[id], err = strmConf.Sanitised(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return struct {
-		HTTP                 interface{} `json:"http" yaml:"http"`
-		Input                interface{} `json:"input" yaml:"input"`
-		Buffer               interface{} `json:"buffer" yaml:"buffer"`
-		Pipeline             interface{} `json:"pipeline" yaml:"pipeline"`
-		Output               interface{} `json:"output" yaml:"output"`
-		Manager              interface{} `json:"resources" yaml:"resources"`
-		Logger               interface{} `json:"logger" yaml:"logger"`
-		Metrics              interface{} `json:"metrics" yaml:"metrics"`
-		SystemCloseTimeoutMS interface{} `json:"sys_exit_timeout_ms" yaml:"sys_exit_timeout_ms"`
+		HTTP            interface{} `json:"http" yaml:"http"`
+		Input           interface{} `json:"input" yaml:"input"`
+		Buffer          interface{} `json:"buffer" yaml:"buffer"`
+		Pipeline        interface{} `json:"pipeline" yaml:"pipeline"`
+		Output          interface{} `json:"output" yaml:"output"`
+		OnError         interface{} `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+		MessageSize     interface{} `json:"message_size,omitempty" yaml:"message_size,omitempty"`
+		Priority        interface{} `json:"priority,omitempty" yaml:"priority,omitempty"`
+		Dedupe          interface{} `json:"dedupe,omitempty" yaml:"dedupe,omitempty"`
+		Manager         interface{} `json:"resources" yaml:"resources"`
+		Logger          interface{} `json:"logger" yaml:"logger"`
+		Metrics         interface{} `json:"metrics" yaml:"metrics"`
+		Shutdown        interface{} `json:"shutdown" yaml:"shutdown"`
+		Acknowledgement interface{} `json:"acknowledgement" yaml:"acknowledgement"`
+		Correlation     interface{} `json:"correlation" yaml:"correlation"`
+		Backpressure    interface{} `json:"backpressure" yaml:"backpressure"`
+		Streams         interface{} `json:"streams,omitempty" yaml:"streams,omitempty"`
 	}{
-		HTTP:                 c.HTTP,
-		Input:                inConf,
-		Buffer:               bufConf,
-		Pipeline:             pipeConf,
-		Output:               outConf,
-		Manager:              c.Manager,
-		Logger:               c.Logger,
-		Metrics:              metConf,
-		SystemCloseTimeoutMS: c.SystemCloseTimeoutMS,
+		HTTP:            c.HTTP,
+		Input:           inConf,
+		Buffer:          bufConf,
+		Pipeline:        pipeConf,
+		Output:          outConf,
+		OnError:         errOutConf,
+		MessageSize:     sizeConf,
+		Priority:        priorityConf,
+		Dedupe:          dedupeConf,
+		Manager:         c.Manager,
+		Logger:          c.Logger,
+		Metrics:         metConf,
+		Shutdown:        c.Shutdown,
+		Acknowledgement: c.Acknowledgement,
+		Correlation:     c.Correlation,
+		Backpressure:    c.Backpressure,
+		Streams:         streamsConf,
 	}, nil
 }
 
@@ -163,6 +232,13 @@ var (
 	configPath = flag.String(
 		"c", "", "Path to a configuration file",
 	)
+	envConfig = flag.Bool(
+		"env-config", false,
+		"Ignore config files and instead build the config from the defaults"+
+			" and environment variables prefixed with BENTHOS_, where"+
+			" BENTHOS_INPUT=kafka sets the input type and"+
+			" BENTHOS_INPUT_KAFKA_TOPIC=foo sets the field input.kafka.topic",
+	)
 	swapEnvs = flag.Bool(
 		"swap-envs", true,
 		"Swap ${FOO} patterns in config file with environment variables",
@@ -191,6 +267,40 @@ var (
 		"list-caches", false,
 		"Print a list of available cache options, then exit",
 	)
+	printRateLimits = flag.Bool(
+		"list-rate-limits", false,
+		"Print a list of available rate limit options, then exit",
+	)
+	checkConnections = flag.Bool(
+		"check-connections", false,
+		"Attempt to dial the targets of the configured inputs and outputs"+
+			" without consuming or producing any data, print the status of"+
+			" each component, then exit",
+	)
+	benchMode = flag.Bool(
+		"bench", false,
+		"Drive the processing layers of the config (input and output"+
+			" processors, buffer and pipeline) with generated messages, print"+
+			" a JSON report of the measured throughput and latencies, then"+
+			" exit. The configured input and output are not used.",
+	)
+	benchDuration = flag.Duration(
+		"bench-duration", time.Second*10,
+		"The duration of a benchmark run when using --bench",
+	)
+	benchMessageSize = flag.Int(
+		"bench-message-size", 1024,
+		"The size in bytes of each generated message part when using --bench",
+	)
+	benchParts = flag.Int(
+		"bench-parts", 1,
+		"The number of parts of each generated message when using --bench",
+	)
+	benchInFlight = flag.Int(
+		"bench-in-flight", 1,
+		"The number of generated messages kept in flight at any given time"+
+			" when using --bench",
+	)
 	streamsMode = flag.Bool(
 		"streams", false,
 		"Run Benthos in streams mode, where streams can be created, updated"+
@@ -208,6 +318,29 @@ var (
 	)
 )
 
+// stringListFlag is a flag value that can be set multiple times.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+var configOverrides stringListFlag
+
+func init() {
+	flag.Var(
+		&configOverrides, "set",
+		"Override a field of the loaded config in the form path.to.field=value,"+
+			" where the value is parsed as YAML. Can be specified multiple times,"+
+			" e.g. --set input.kafka.topic=foo --set pipeline.threads=4",
+	)
+}
+
 //------------------------------------------------------------------------------
 
 // bootstrap reads cmd args and either parses and config file or prints helper
@@ -225,6 +358,7 @@ func bootstrap() Config {
 	// Override default help printing
 	flag.Usage = func() {
 		fmt.Fprintln(os.Stderr, "Usage: benthos [flags...]")
+		fmt.Fprintln(os.Stderr, "       benthos list [inputs|outputs|buffers|processors|conditions|caches|rate-limits|metrics...]")
 		fmt.Fprintln(os.Stderr, "Flags:")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr,
@@ -241,7 +375,21 @@ func bootstrap() Config {
 		os.Exit(0)
 	}
 
-	if len(*configPath) > 0 {
+	// If the user wants to list components we print them and exit.
+	if flag.Arg(0) == "list" {
+		if err := listComponents(flag.Args()[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "List error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if *envConfig {
+		if err := config.OverrideFromEnv(&conf, "BENTHOS_", os.Environ()); err != nil {
+			fmt.Fprintf(os.Stderr, "Environment configuration error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if len(*configPath) > 0 {
 		if err := config.Read(*configPath, *swapEnvs, &conf); err != nil {
 			fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
 			os.Exit(1)
@@ -261,6 +409,25 @@ func bootstrap() Config {
 		}
 	}
 
+	// Apply any overrides specified via --set flags.
+	for _, override := range configOverrides {
+		path, value, err := config.ParseOverride(override)
+		if err == nil {
+			err = config.Override(&conf, path, value)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration override error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Map the deprecated exit timeout onto the shutdown config.
+	if conf.SystemCloseTimeoutMS > 0 {
+		fmt.Fprintln(os.Stderr, "The field sys_exit_timeout_ms is deprecated, please use shutdown.timeout_ms instead")
+		conf.Shutdown.TimeoutMS = conf.SystemCloseTimeoutMS
+		conf.SystemCloseTimeoutMS = 0
+	}
+
 	// If the user wants the configuration to be printed we do so and then exit.
 	if *showConfigJSON || *showConfigYAML {
 		var outConf interface{} = conf
@@ -289,7 +456,7 @@ func bootstrap() Config {
 	}
 
 	// If we only want to print our inputs or outputs we should exit afterwards
-	if *printInputs || *printOutputs || *printBuffers || *printProcessors || *printConditions || *printCaches {
+	if *printInputs || *printOutputs || *printBuffers || *printProcessors || *printConditions || *printCaches || *printRateLimits {
 		if *printInputs {
 			fmt.Println(input.Descriptions())
 		}
@@ -308,16 +475,130 @@ func bootstrap() Config {
 		if *printCaches {
 			fmt.Println(cache.Descriptions())
 		}
+		if *printRateLimits {
+			fmt.Println(ratelimit.Descriptions())
+		}
 		os.Exit(0)
 	}
 
 	return conf
 }
 
+// checkConnectionsTimeout is the maximum period of time to wait for each
+// component to be dialled when running with --check-connections.
+var checkConnectionsTimeout = time.Second * 10
+
+// connectionStatuses attempts to dial each input and output of a config and
+// returns their statuses. When the config declares streams each of them is
+// checked instead, with the paths of its components prefixed by its ID.
+func connectionStatuses(conf Config, logger log.Modular, stats metrics.Type) []types.ConnectionStatus {
+	if len(conf.Streams) == 0 {
+		statuses := input.CheckConnections(conf.Input, checkConnectionsTimeout, logger, stats)
+		return append(statuses, output.CheckConnections(
+			conf.Output, checkConnectionsTimeout, logger, stats,
+		)...)
+	}
+
+	ids := make([]string, 0, len(conf.Streams))
+	for id := range conf.Streams {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var statuses []types.ConnectionStatus
+	for _, id := range ids {
+		strmConf := conf.Streams[id]
+		strmStatuses := input.CheckConnections(strmConf.Input, checkConnectionsTimeout, logger, stats)
+		strmStatuses = append(strmStatuses, output.CheckConnections(
+			strmConf.Output, checkConnectionsTimeout, logger, stats,
+		)...)
+		for _, s := range strmStatuses {
+			s.Path = id + "." + s.Path
+			statuses = append(statuses, s)
+		}
+	}
+	return statuses
+}
+
+// runConnectionChecks attempts to dial each input and output of a config,
+// prints the status of each, and returns false if any failed. Components that
+// do not support connection checks are skipped.
+func runConnectionChecks(conf Config, logger log.Modular, stats metrics.Type) bool {
+	statuses := connectionStatuses(conf, logger, stats)
+
+	success := true
+	for _, s := range statuses {
+		if s.Err == types.ErrCheckNotSupported {
+			fmt.Printf("%v: SKIPPED: %v\n", s.Path, s.Err)
+		} else if s.Err != nil {
+			success = false
+			fmt.Printf("%v: FAILED: %v\n", s.Path, s.Err)
+		} else {
+			fmt.Printf("%v: OK\n", s.Path)
+		}
+	}
+	return success
+}
+
+// usesStdout returns true if the output of the root stream, or of any of the
+// streams declared within a config, is stdout.
+func usesStdout(conf Config) bool {
+	if len(conf.Streams) == 0 {
+		return conf.Output.Type == "stdout"
+	}
+	for _, strmConf := range conf.Streams {
+		if strmConf.Output.Type == "stdout" {
+			return true
+		}
+	}
+	return false
+}
+
+// runBenchmark executes a benchmark run against the processing layers of a
+// config and prints the report as JSON.
+func runBenchmark(conf Config, mgr types.Manager, logger log.Modular, stats metrics.Type) error {
+	benchConf := test.NewBenchConfig()
+	benchConf.Duration = *benchDuration
+	benchConf.MessageSize = *benchMessageSize
+	benchConf.Parts = *benchParts
+	benchConf.InFlight = *benchInFlight
+
+	report, err := test.RunBenchmark(benchConf, conf.Config, mgr, logger, stats)
+	if err != nil {
+		return err
+	}
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(reportJSON))
+	return nil
+}
+
 type stoppableStreams interface {
 	Stop(timeout time.Duration) error
 }
 
+// superviseStreams blocks until any of the streams declared within a config
+// has terminated and then closes the provided channel, which shuts down the
+// service. Streams declared together are expected to form a single topology,
+// which is broken once any of them stops.
+func superviseStreams(mgr *strmmgr.Type, confs map[string]stream.Config, closedChan chan<- struct{}) {
+	for {
+		<-time.After(time.Millisecond * 100)
+		for id := range confs {
+			status, err := mgr.Read(id)
+			if err != nil {
+				return
+			}
+			if !status.Active {
+				close(closedChan)
+				return
+			}
+		}
+	}
+}
+
 func main() {
 	// Bootstrap by reading cmd flags and configuration file.
 	config := bootstrap()
@@ -325,8 +606,9 @@ func main() {
 	// Logging and stats aggregation.
 	var logger log.Modular
 
-	// Note: Only log to Stderr if one of our outputs is stdout.
-	if config.Output.Type == "stdout" {
+	// Note: Only log to Stderr if one of our outputs is stdout, or if we are
+	// printing a benchmark report.
+	if usesStdout(config) || *benchMode {
 		logger = log.NewLogger(os.Stderr, config.Logger)
 	} else {
 		logger = log.NewLogger(os.Stdout, config.Logger)
@@ -340,6 +622,13 @@ func main() {
 	}
 	defer stats.Close()
 
+	if *checkConnections {
+		if !runConnectionChecks(config, logger, stats) {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	// Create HTTP API with a sanitised service config.
 	sanConf, err := config.Sanitised()
 	if err != nil {
@@ -354,32 +643,53 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *benchMode {
+		if err = runBenchmark(config, manager, logger, stats); err != nil {
+			logger.Errorf("Benchmark error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
 	var dataStream stoppableStreams
 	dataStreamClosedChan := make(chan struct{})
 
 	// Create data streams.
-	if *streamsMode {
+	if *streamsMode || len(config.Streams) > 0 {
 		streamMgr := strmmgr.New(
 			strmmgr.OptSetAPITimeout(time.Duration(config.HTTP.ReadTimeoutMS)*time.Millisecond),
 			strmmgr.OptSetLogger(logger),
 			strmmgr.OptSetManager(manager),
 			strmmgr.OptSetStats(stats),
+			strmmgr.OptSetShutdown(config.Shutdown),
+			strmmgr.OptSetAcknowledgement(config.Acknowledgement),
+			strmmgr.OptSetCorrelation(config.Correlation),
+			strmmgr.OptSetBackpressure(config.Backpressure),
 		)
-		var streamConfs map[string]stream.Config
-		if streamConfs, err = strmmgr.LoadStreamConfigsFromDirectory(true, *streamsDir); err != nil {
-			logger.Errorf("Failed to load stream configs: %v\n", err)
-			os.Exit(1)
+		streamConfs := map[string]stream.Config(config.Streams)
+		if *streamsMode {
+			if streamConfs, err = strmmgr.LoadStreamConfigsFromDirectory(true, *streamsDir); err != nil {
+				logger.Errorf("Failed to load stream configs: %v\n", err)
+				os.Exit(1)
+			}
 		}
 		dataStream = streamMgr
 		for id, conf := range streamConfs {
 			if err = streamMgr.Create(id, conf); err != nil {
 				logger.Errorf("Failed to create stream (%v): %v\n", id, err)
+				streamMgr.Stop(time.Second)
 				os.Exit(1)
 			}
 		}
-		logger.Infoln("Launching benthos in streams mode, use CTRL+C to close.")
-		if lStreams := len(streamConfs); lStreams > 0 {
-			logger.Infof("Created %v streams from directory: %v\n", lStreams, *streamsDir)
+		if *streamsMode {
+			logger.Infoln("Launching benthos in streams mode, use CTRL+C to close.")
+			if lStreams := len(streamConfs); lStreams > 0 {
+				logger.Infof("Created %v streams from directory: %v\n", lStreams, *streamsDir)
+			}
+		} else {
+			logger.Infof("Launching a benthos instance with %v streams, use CTRL+C to close.\n", len(streamConfs))
+			logger.Infoln("The root input, buffer, pipeline and output fields are ignored when streams are declared.")
+			go superviseStreams(streamMgr, streamConfs, dataStreamClosedChan)
 		}
 	} else {
 		if dataStream, err = stream.New(
@@ -387,6 +697,10 @@ func main() {
 			stream.OptSetLogger(logger),
 			stream.OptSetStats(stats),
 			stream.OptSetManager(manager),
+			stream.OptSetShutdown(config.Shutdown),
+			stream.OptSetAcknowledgement(config.Acknowledgement),
+			stream.OptSetCorrelation(config.Correlation),
+			stream.OptSetBackpressure(config.Backpressure),
 			stream.OptOnClose(func() {
 				close(dataStreamClosedChan)
 			}),
@@ -413,7 +727,7 @@ func main() {
 
 	// Defer clean up.
 	defer func() {
-		tout := time.Millisecond * time.Duration(config.SystemCloseTimeoutMS)
+		tout := time.Millisecond * time.Duration(config.Shutdown.TimeoutMS)
 
 		go func() {
 			httpServer.Shutdown(context.Background())
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/stream"
+	strmmgr "github.com/Jeffail/benthos/lib/stream/manager"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+func TestConnectionStatusesStreams(t *testing.T) {
+	fooConf := stream.NewConfig()
+	fooConf.Input.Type = "stdin"
+	fooConf.Output.Type = "stdout"
+
+	barConf := stream.NewConfig()
+	barConf.Input.Type = "not_exist"
+	barConf.Output.Type = "stdout"
+
+	conf := NewConfig()
+	conf.Input.Type = "also_not_exist"
+	conf.Streams = strmmgr.ConfigSet{
+		"foo": fooConf,
+		"bar": barConf,
+	}
+
+	logger := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	statuses := connectionStatuses(conf, logger, metrics.DudType{})
+
+	paths := []string{}
+	for _, s := range statuses {
+		paths = append(paths, s.Path)
+	}
+	exp := []string{
+		"bar.input.not_exist",
+		"bar.output.stdout",
+		"foo.input.stdin",
+		"foo.output.stdout",
+	}
+	if len(exp) != len(paths) {
+		t.Fatalf("Wrong paths: %v != %v", paths, exp)
+	}
+	for i, p := range exp {
+		if paths[i] != p {
+			t.Errorf("Wrong path at %v: %v != %v", i, paths[i], p)
+		}
+	}
+	if statuses[0].Err == nil {
+		t.Error("Expected error from invalid input type")
+	}
+}
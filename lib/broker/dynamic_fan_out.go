@@ -317,7 +317,7 @@ func (d *DynamicFanOut) loop() {
 						d.removeOutput(k, time.Second)
 						delete(remainingTargets, k)
 					} else if res.Error() != nil {
-						d.log.Errorf("Failed to dispatch dynamic fan out message: %v\n", res.Error())
+						d.log.Errorf("Failed to dispatch dynamic fan out message%v: %v\n", types.CorrelationLogTag(ts.Payload), res.Error())
 						mOutputErr.Incr(1)
 						if !d.throt.Retry() {
 							return
@@ -144,7 +144,7 @@ func (o *FanOut) loop() {
 				case res := <-o.outputResChans[i]:
 					if res.Error() != nil {
 						newTargets = append(newTargets, i)
-						o.logger.Errorf("Failed to dispatch fan out message: %v\n", res.Error())
+						o.logger.Errorf("Failed to dispatch fan out message%v: %v\n", types.CorrelationLogTag(ts.Payload), res.Error())
 						mOutputErr.Incr(1)
 						if !o.throt.Retry() {
 							return
@@ -0,0 +1,320 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/throttle"
+)
+
+//------------------------------------------------------------------------------
+
+// Policies for handling messages when the queue of an output of a FanOutAsync
+// broker is full.
+const (
+	QueueFullBlock      = "block"
+	QueueFullDrop       = "drop"
+	QueueFullDeadLetter = "dead_letter"
+)
+
+//------------------------------------------------------------------------------
+
+// FanOutAsync is a broker that implements types.Consumer and broadcasts each
+// message out to an array of outputs, where each output has its own bounded
+// queue of messages so that slow outputs do not throttle the others.
+//
+// Messages are acknowledged once they have been queued for all outputs, and
+// therefore messages that are queued when the service is shut down are lost.
+type FanOutAsync struct {
+	running int32
+
+	logger log.Modular
+	stats  metrics.Type
+
+	policy string
+
+	transactions <-chan types.Transaction
+
+	queues         []chan types.Message
+	outputTsChans  []chan types.Transaction
+	outputResChans []chan types.Response
+	outputs        []types.Output
+
+	deadLetter        types.Output
+	deadLetterTsChan  chan types.Transaction
+	deadLetterResChan chan types.Response
+	deadLetterThrot   *throttle.Type
+
+	outputsWG  sync.WaitGroup
+	closedChan chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewFanOutAsync creates a new FanOutAsync type by providing outputs, the
+// maximum number of messages queued per output, and a policy for when a queue
+// is full. The dead letter output is only required for the dead_letter policy.
+func NewFanOutAsync(
+	outputs []types.Output,
+	queueSize int,
+	policy string,
+	deadLetter types.Output,
+	logger log.Modular,
+	stats metrics.Type,
+) (*FanOutAsync, error) {
+	switch policy {
+	case QueueFullBlock, QueueFullDrop:
+	case QueueFullDeadLetter:
+		if deadLetter == nil {
+			return nil, fmt.Errorf("queue full policy '%v' requires a dead letter output", policy)
+		}
+	default:
+		return nil, fmt.Errorf("queue full policy not recognised: %v", policy)
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+
+	o := &FanOutAsync{
+		running:      1,
+		stats:        stats,
+		logger:       logger.NewModule(".broker.fan_out_async"),
+		policy:       policy,
+		transactions: nil,
+		outputs:      outputs,
+		closedChan:   make(chan struct{}),
+		closeChan:    make(chan struct{}),
+	}
+
+	o.queues = make([]chan types.Message, len(o.outputs))
+	o.outputTsChans = make([]chan types.Transaction, len(o.outputs))
+	o.outputResChans = make([]chan types.Response, len(o.outputs))
+	for i := range o.outputTsChans {
+		o.queues[i] = make(chan types.Message, queueSize)
+		o.outputTsChans[i] = make(chan types.Transaction)
+		o.outputResChans[i] = make(chan types.Response)
+		if err := o.outputs[i].StartReceiving(o.outputTsChans[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if policy == QueueFullDeadLetter {
+		o.deadLetter = deadLetter
+		o.deadLetterTsChan = make(chan types.Transaction)
+		o.deadLetterResChan = make(chan types.Response)
+		o.deadLetterThrot = throttle.New(throttle.OptCloseChan(o.closeChan))
+		if err := deadLetter.StartReceiving(o.deadLetterTsChan); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+//------------------------------------------------------------------------------
+
+// StartReceiving assigns a new transactions channel for the broker to read.
+func (o *FanOutAsync) StartReceiving(transactions <-chan types.Transaction) error {
+	if o.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	o.transactions = transactions
+
+	for i := range o.outputs {
+		o.outputsWG.Add(1)
+		go o.outputLoop(i)
+	}
+	go o.loop()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// outputLoop sends the messages of a queue to an output, retrying until each
+// message is successfully sent or the broker is closed.
+func (o *FanOutAsync) outputLoop(i int) {
+	defer o.outputsWG.Done()
+
+	var (
+		mOutputErr = o.stats.GetCounter("broker.fan_out_async.output.error")
+		mMsgsSnt   = o.stats.GetCounter("broker.fan_out_async.messages.sent")
+		throt      = throttle.New(throttle.OptCloseChan(o.closeChan))
+	)
+
+	for {
+		var msg types.Message
+		var open bool
+		select {
+		case msg, open = <-o.queues[i]:
+			if !open {
+				return
+			}
+		case <-o.closeChan:
+			return
+		}
+
+		for {
+			select {
+			case o.outputTsChans[i] <- types.NewTransaction(msg, o.outputResChans[i]):
+			case <-o.closeChan:
+				return
+			}
+			var res types.Response
+			select {
+			case res = <-o.outputResChans[i]:
+			case <-o.closeChan:
+				return
+			}
+			if res.Error() == nil {
+				throt.Reset()
+				mMsgsSnt.Incr(1)
+				break
+			}
+			mOutputErr.Incr(1)
+			o.logger.Errorf("Failed to dispatch fan out message%v: %v\n", types.CorrelationLogTag(msg), res.Error())
+			if !throt.Retry() {
+				return
+			}
+		}
+	}
+}
+
+// sendDeadLetter sends a message to the dead letter output, retrying until it
+// succeeds. Returns false if the broker was closed before the message was
+// sent.
+func (o *FanOutAsync) sendDeadLetter(msg types.Message) bool {
+	for {
+		select {
+		case o.deadLetterTsChan <- types.NewTransaction(msg, o.deadLetterResChan):
+		case <-o.closeChan:
+			return false
+		}
+		var res types.Response
+		select {
+		case res = <-o.deadLetterResChan:
+		case <-o.closeChan:
+			return false
+		}
+		if res.Error() == nil {
+			o.deadLetterThrot.Reset()
+			return true
+		}
+		o.logger.Errorf("Failed to dispatch dead letter message%v: %v\n", types.CorrelationLogTag(msg), res.Error())
+		if !o.deadLetterThrot.Retry() {
+			return false
+		}
+	}
+}
+
+// loop is an internal loop that queues incoming messages for many outputs.
+func (o *FanOutAsync) loop() {
+	defer func() {
+		for _, q := range o.queues {
+			close(q)
+		}
+		o.outputsWG.Wait()
+		for _, c := range o.outputTsChans {
+			close(c)
+		}
+		if o.deadLetterTsChan != nil {
+			close(o.deadLetterTsChan)
+		}
+		close(o.closedChan)
+	}()
+
+	var (
+		mMsgsRcvd       = o.stats.GetCounter("broker.fan_out_async.messages.received")
+		mMsgsDropped    = o.stats.GetCounter("broker.fan_out_async.messages.dropped")
+		mMsgsDeadLetter = o.stats.GetCounter("broker.fan_out_async.messages.dead_letter")
+	)
+
+	for atomic.LoadInt32(&o.running) == 1 {
+		var ts types.Transaction
+		var open bool
+
+		select {
+		case ts, open = <-o.transactions:
+			if !open {
+				return
+			}
+		case <-o.closeChan:
+			return
+		}
+		mMsgsRcvd.Incr(1)
+
+		for i := range o.queues {
+			// Perform a copy here as it could be dangerous to release the
+			// same message to parallel processor pipelines.
+			msgCopy := ts.Payload.ShallowCopy()
+			if o.policy == QueueFullBlock {
+				select {
+				case o.queues[i] <- msgCopy:
+				case <-o.closeChan:
+					return
+				}
+				continue
+			}
+			select {
+			case o.queues[i] <- msgCopy:
+				continue
+			default:
+			}
+			if o.policy == QueueFullDrop {
+				mMsgsDropped.Incr(1)
+				continue
+			}
+			if !o.sendDeadLetter(msgCopy) {
+				return
+			}
+			mMsgsDeadLetter.Incr(1)
+		}
+
+		select {
+		case ts.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-o.closeChan:
+			return
+		}
+	}
+}
+
+// CloseAsync shuts down the FanOutAsync broker and stops processing requests.
+func (o *FanOutAsync) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&o.running, 1, 0) {
+		close(o.closeChan)
+	}
+}
+
+// WaitForClose blocks until the FanOutAsync broker has closed down.
+func (o *FanOutAsync) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
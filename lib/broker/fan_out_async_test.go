@@ -0,0 +1,213 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestFanOutAsyncInterfaces(t *testing.T) {
+	f := &FanOutAsync{}
+	if types.Consumer(f) == nil {
+		t.Errorf("FanOutAsync: nil types.Consumer")
+	}
+	if types.Closable(f) == nil {
+		t.Errorf("FanOutAsync: nil types.Closable")
+	}
+}
+
+func TestFanOutAsyncBadPolicy(t *testing.T) {
+	logger := log.NewLogger(os.Stdout, logConfig)
+	if _, err := NewFanOutAsync(nil, 10, "nope", nil, logger, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad policy")
+	}
+	if _, err := NewFanOutAsync(nil, 10, QueueFullDeadLetter, nil, logger, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing dead letter output")
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func sendFanOutAsync(t *testing.T, readChan chan types.Transaction, content string) {
+	resChan := make(chan types.Response)
+	select {
+	case readChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Errorf("Received unexpected errors from broker: %v", res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+}
+
+func expectFanOutAsync(t *testing.T, tChan <-chan types.Transaction, content string, resErr error) {
+	select {
+	case ts := <-tChan:
+		if act := string(ts.Payload.Get(0)); act != content {
+			t.Errorf("Wrong content returned %v != %v", act, content)
+		}
+		select {
+		case ts.ResponseChan <- types.NewSimpleResponse(resErr):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out responding to broker")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+}
+
+func TestFanOutAsyncSlowOutput(t *testing.T) {
+	fast, slow := &MockOutputType{}, &MockOutputType{}
+
+	oTM, err := NewFanOutAsync(
+		[]types.Output{fast, slow}, 10, QueueFullBlock, nil,
+		log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readChan := make(chan types.Transaction)
+	if err = oTM.StartReceiving(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	// The slow output does not consume, but the fast output should still
+	// receive messages until the slow queue fills up.
+	for _, c := range []string{"foo", "bar", "baz"} {
+		sendFanOutAsync(t, readChan, c)
+		expectFanOutAsync(t, fast.TChan, c, nil)
+	}
+
+	// Failed messages are retried.
+	expectFanOutAsync(t, slow.TChan, "foo", errors.New("test err"))
+	expectFanOutAsync(t, slow.TChan, "foo", nil)
+	expectFanOutAsync(t, slow.TChan, "bar", nil)
+	expectFanOutAsync(t, slow.TChan, "baz", nil)
+
+	oTM.CloseAsync()
+	if err = oTM.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFanOutAsyncDrop(t *testing.T) {
+	fast, slow := &MockOutputType{}, &MockOutputType{}
+
+	oTM, err := NewFanOutAsync(
+		[]types.Output{fast, slow}, 1, QueueFullDrop, nil,
+		log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readChan := make(chan types.Transaction)
+	if err = oTM.StartReceiving(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first message is taken from the slow queue by its output loop and
+	// the second fills the queue, leaving the rest to be dropped.
+	for i, c := range []string{"foo", "bar", "baz", "qux"} {
+		sendFanOutAsync(t, readChan, c)
+		expectFanOutAsync(t, fast.TChan, c, nil)
+		if i == 0 {
+			<-time.After(time.Millisecond * 50)
+		}
+	}
+
+	expectFanOutAsync(t, slow.TChan, "foo", nil)
+	expectFanOutAsync(t, slow.TChan, "bar", nil)
+	select {
+	case <-slow.TChan:
+		t.Error("Expected remaining messages to be dropped")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	oTM.CloseAsync()
+	if err = oTM.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFanOutAsyncDeadLetter(t *testing.T) {
+	fast, slow, dl := &MockOutputType{}, &MockOutputType{}, &MockOutputType{}
+
+	oTM, err := NewFanOutAsync(
+		[]types.Output{fast, slow}, 1, QueueFullDeadLetter, dl,
+		log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readChan := make(chan types.Transaction)
+	if err = oTM.StartReceiving(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	// Give the slow output loop time to take the first message from its
+	// queue, after which the queue holds the second.
+	for _, c := range []string{"foo", "bar"} {
+		sendFanOutAsync(t, readChan, c)
+		expectFanOutAsync(t, fast.TChan, c, nil)
+		<-time.After(time.Millisecond * 50)
+	}
+
+	resChan := make(chan types.Response)
+	select {
+	case readChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("baz")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+	expectFanOutAsync(t, fast.TChan, "baz", nil)
+	expectFanOutAsync(t, dl.TChan, "baz", nil)
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Errorf("Received unexpected errors from broker: %v", res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+
+	expectFanOutAsync(t, slow.TChan, "foo", nil)
+	expectFanOutAsync(t, slow.TChan, "bar", nil)
+
+	oTM.CloseAsync()
+	if err = oTM.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -29,8 +29,9 @@ import (
 //------------------------------------------------------------------------------
 
 // Greedy is a broker that implements types.Consumer and sends each message
-// out to a single consumer chosen from an array in round-robin fashion.
-// Consumers that apply backpressure will block all consumers.
+// out to a single consumer, which is whichever consumer is first ready to
+// receive it. Consumers that apply backpressure therefore receive fewer
+// messages without blocking the others.
 type Greedy struct {
 	outputs []types.Output
 }
@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package broker
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestTryInterfaces(t *testing.T) {
+	f := &Try{}
+	if types.Consumer(f) == nil {
+		t.Errorf("Try: nil types.Consumer")
+	}
+	if types.Closable(f) == nil {
+		t.Errorf("Try: nil types.Closable")
+	}
+}
+
+func TestTryDoubleClose(t *testing.T) {
+	oTM, err := NewTry([]types.Output{}, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// This shouldn't cause a panic
+	oTM.CloseAsync()
+	oTM.CloseAsync()
+}
+
+//------------------------------------------------------------------------------
+
+func TestTryFallback(t *testing.T) {
+	mockOutputs := []*MockOutputType{{}, {}, {}}
+	outputs := []types.Output{}
+	for _, o := range mockOutputs {
+		outputs = append(outputs, o)
+	}
+
+	readChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	oTM, err := NewTry(outputs, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = oTM.StartReceiving(readChan); err != nil {
+		t.Fatal(err)
+	}
+
+	errTest := errors.New("test err")
+
+	tests := []struct {
+		results []error
+		exp     error
+	}{
+		{results: []error{nil}, exp: nil},
+		{results: []error{errTest, nil}, exp: nil},
+		{results: []error{errTest, errTest, nil}, exp: nil},
+		{results: []error{errTest, errTest, errTest}, exp: errTest},
+	}
+
+	for _, test := range tests {
+		select {
+		case readChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("hello world")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for broker send")
+		}
+
+		for i, res := range test.results {
+			var ts types.Transaction
+			select {
+			case ts = <-mockOutputs[i].TChan:
+				if exp, act := "hello world", string(ts.Payload.Get(0)); exp != act {
+					t.Errorf("Wrong content returned %s != %s", act, exp)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for broker propagate")
+			}
+			select {
+			case ts.ResponseChan <- types.NewSimpleResponse(res):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out responding to broker")
+			}
+		}
+
+		select {
+		case res := <-resChan:
+			if act := res.Error(); act != test.exp {
+				t.Errorf("Wrong response: %v != %v", act, test.exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	oTM.CloseAsync()
+	if err := oTM.WaitForClose(time.Second * 10); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -20,8 +20,67 @@
 
 package buffer
 
-import "github.com/Jeffail/benthos/lib/util/service/log"
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
 
 var logConfig = log.LoggerConfig{
 	LogLevel: "NONE",
 }
+
+// checkPendingMessages writes messages to a started buffer and checks that the
+// count of pending messages follows the writes and acknowledgements.
+func checkPendingMessages(t *testing.T, b Type, tChan chan<- types.Transaction) {
+	p, ok := b.(Pending)
+	if !ok {
+		t.Fatal("Buffer does not implement Pending")
+	}
+
+	waitForPending := func(exp int) {
+		act := p.PendingMessages()
+		for i := 0; i < 100 && act != exp; i++ {
+			<-time.After(time.Millisecond * 10)
+			act = p.PendingMessages()
+		}
+		if exp != act {
+			t.Errorf("Wrong count of pending messages: %v != %v", act, exp)
+		}
+	}
+
+	resChan := make(chan types.Response)
+	for _, content := range []string{"foo", "bar", "baz"} {
+		select {
+		case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+	waitForPending(3)
+
+	for i := 2; i >= 0; i-- {
+		var outTr types.Transaction
+		select {
+		case outTr = <-b.TransactionChan():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case outTr.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		waitForPending(i)
+	}
+}
@@ -120,6 +120,16 @@ different options and their qualities:
 | Memory    | Lost       | Lost      | Lost               |
 | Mmap File | Persisted  | Lost      | Lost               |`
 
+// Specs returns a specification of each buffer type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
 // Descriptions returns a formatted string of collated descriptions of each type.
 func Descriptions() string {
 	// Order our buffer types alphabetically
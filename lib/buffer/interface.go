@@ -33,3 +33,11 @@ type Type interface {
 	// empty it will shut down.
 	StopConsuming()
 }
+
+// Pending is an interface implemented by buffer types that are able to report
+// the number of messages they currently hold.
+type Pending interface {
+	// PendingMessages returns the number of messages that were written to the
+	// buffer and are yet to be read and acknowledged.
+	PendingMessages() int
+}
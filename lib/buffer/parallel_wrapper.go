@@ -68,6 +68,7 @@ type ParallelWrapper struct {
 
 	running   int32
 	consuming int32
+	pending   int64
 
 	messagesIn  <-chan types.Transaction
 	messagesOut chan types.Transaction
@@ -129,6 +130,7 @@ func (m *ParallelWrapper) inputLoop() {
 		}
 		backlog, err := m.buffer.PushMessage(tr.Payload)
 		if err == nil {
+			atomic.AddInt64(&m.pending, 1)
 			mWriteCount.Incr(1)
 			mWriteBacklog.Gauge(int64(backlog))
 		} else {
@@ -201,6 +203,9 @@ func (m *ParallelWrapper) outputLoop() {
 					m.log.Errorf("Failed to ack buffer message: %v\n", ackErr)
 				}
 			} else {
+				if doAck {
+					atomic.AddInt64(&m.pending, -1)
+				}
 				mBacklog.Gauge(int64(blog))
 			}
 		}(resChan, ackFunc)
@@ -246,6 +251,16 @@ func (m *ParallelWrapper) StopConsuming() {
 	}
 }
 
+// PendingMessages returns the number of messages that were written to the
+// buffer and are yet to be read and acknowledged. Messages that were persisted
+// by a previous run of the buffer are not counted.
+func (m *ParallelWrapper) PendingMessages() int {
+	if pending := atomic.LoadInt64(&m.pending); pending > 0 {
+		return int(pending)
+	}
+	return 0
+}
+
 // WaitForClose blocks until the ParallelWrapper output has closed down.
 func (m *ParallelWrapper) WaitForClose(timeout time.Duration) error {
 	select {
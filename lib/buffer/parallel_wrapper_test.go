@@ -332,4 +332,24 @@ func BenchmarkParallelMem(b *testing.B) {
 	buffer.WaitForClose(time.Second)
 }
 
+func TestParallelBufferPendingMessages(t *testing.T) {
+	tChan := make(chan types.Transaction)
+
+	conf := NewConfig()
+	b := NewParallelWrapper(
+		conf, parallel.NewMemory(1000000),
+		log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+	if err := b.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	checkPendingMessages(t, b, tChan)
+
+	b.CloseAsync()
+	if err := b.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -65,6 +65,27 @@ func TestMemoryBasic(t *testing.T) {
 	}
 }
 
+func TestMemoryMetadata(t *testing.T) {
+	block := NewMemory(MemoryConfig{Limit: 100000})
+
+	msg := types.NewMessage([][]byte{[]byte("hello"), []byte("world")})
+	msg.GetMetadata(0).Set("foo", "bar")
+	if _, err := block.PushMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "hello", string(m.Get(0)); exp != act {
+		t.Errorf("Wrong message part: %v != %v", act, exp)
+	}
+	if exp, act := "bar", m.GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+}
+
 func TestMemoryBacklogCounter(t *testing.T) {
 	block := NewMemory(MemoryConfig{Limit: 100000})
 
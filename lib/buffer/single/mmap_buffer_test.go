@@ -94,6 +94,41 @@ func TestMmapBufferBasic(t *testing.T) {
 	}
 }
 
+func TestMmapBufferMetadata(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_test_")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUpMmapDir(dir)
+
+	conf := NewMmapBufferConfig()
+	conf.FileSize = 100000
+	conf.Path = dir
+
+	block, err := NewMmapBuffer(conf, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer block.Close()
+
+	msg := types.NewMessage([][]byte{[]byte("hello"), []byte("world")})
+	msg.GetMetadata(1).Set("foo", "bar")
+	if _, err = block.PushMessage(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := block.NextMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "world", string(m.Get(1)); exp != act {
+		t.Errorf("Wrong message part: %v != %v", act, exp)
+	}
+	if exp, act := "bar", m.GetMetadata(1).Get("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+}
+
 func TestMmapBufferBacklogCounter(t *testing.T) {
 	dir, err := ioutil.TempDir("", "benthos_test_")
 	if err != nil {
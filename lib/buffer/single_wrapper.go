@@ -71,6 +71,7 @@ type SingleWrapper struct {
 
 	running   int32
 	consuming int32
+	pending   int64
 
 	messagesIn   <-chan types.Transaction
 	messagesOut  chan types.Transaction
@@ -135,6 +136,7 @@ func (m *SingleWrapper) inputLoop() {
 		}
 		backlog, err := m.buffer.PushMessage(tr.Payload)
 		if err == nil {
+			atomic.AddInt64(&m.pending, 1)
 			mWriteCount.Incr(1)
 			mWriteBacklog.Gauge(int64(backlog))
 		} else {
@@ -205,6 +207,7 @@ func (m *SingleWrapper) outputLoop() {
 				mLatency.Timing(time.Since(msg.CreatedAt()).Nanoseconds())
 				msg = nil
 				backlog, _ := m.buffer.ShiftMessage()
+				atomic.AddInt64(&m.pending, -1)
 				mBacklog.Gauge(int64(backlog))
 				mSendSuccess.Incr(1)
 			} else {
@@ -253,6 +256,16 @@ func (m *SingleWrapper) StopConsuming() {
 	}
 }
 
+// PendingMessages returns the number of messages that were written to the
+// buffer and are yet to be read and acknowledged. Messages that were persisted
+// by a previous run of the buffer are not counted.
+func (m *SingleWrapper) PendingMessages() int {
+	if pending := atomic.LoadInt64(&m.pending); pending > 0 {
+		return int(pending)
+	}
+	return 0
+}
+
 // WaitForClose blocks until the SingleWrapper output has closed down.
 func (m *SingleWrapper) WaitForClose(timeout time.Duration) error {
 	select {
@@ -394,4 +394,21 @@ func BenchmarkSingleMmap(b *testing.B) {
 	buffer.WaitForClose(time.Second)
 }
 
+func TestBufferPendingMessages(t *testing.T) {
+	tChan := make(chan types.Transaction)
+
+	conf := NewConfig()
+	b := NewSingleWrapper(conf, single.NewMemory(single.NewMemoryConfig()), log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err := b.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	checkPendingMessages(t, b, tChan)
+
+	b.CloseAsync()
+	if err := b.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -29,6 +29,7 @@ import (
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/config"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 )
 
@@ -50,6 +51,7 @@ type Config struct {
 	Type      string          `json:"type" yaml:"type"`
 	Memcached MemcachedConfig `json:"memcached" yaml:"memcached"`
 	Memory    MemoryConfig    `json:"memory" yaml:"memory"`
+	Redis     RedisConfig     `json:"redis" yaml:"redis"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -58,6 +60,7 @@ func NewConfig() Config {
 		Type:      "memory",
 		Memcached: NewMemcachedConfig(),
 		Memory:    NewMemoryConfig(),
+		Redis:     NewRedisConfig(),
 	}
 }
 
@@ -136,6 +139,16 @@ by the dedupe processors of both the 'foo' and 'bar' inputs. A message received
 from both 'foo' and 'bar' would therefore be detected and removed since the
 cache is the same for both inputs.`
 
+// Specs returns a specification of each cache type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
 // Descriptions returns a formatted string of descriptions for each type.
 func Descriptions() string {
 	// Order our cache types alphabetically
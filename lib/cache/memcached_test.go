@@ -21,9 +21,15 @@
 package cache
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
@@ -160,3 +166,179 @@ func testMemcachedGetAndSet(addrs []string, t *testing.T) {
 		t.Error(err)
 	}
 }
+
+//------------------------------------------------------------------------------
+
+// fakeMemcached is a minimal memcached server supporting the gets, set, add and
+// delete commands of the text protocol.
+type fakeMemcached struct {
+	ln net.Listener
+
+	sync.Mutex
+	items    map[string][]byte
+	commands []string
+
+	// failAdds is a count of add commands to fail with a server error.
+	failAdds int
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeMemcached{
+		ln:    ln,
+		items: map[string][]byte{},
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeMemcached) serve(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args := strings.Fields(line)
+		if len(args) < 2 {
+			return
+		}
+
+		f.Lock()
+		f.commands = append(f.commands, args[0])
+		switch args[0] {
+		case "gets":
+			for _, k := range args[1:] {
+				if v, exists := f.items[k]; exists {
+					fmt.Fprintf(rw, "VALUE %s 0 %d 0\r\n%s\r\n", k, len(v), v)
+				}
+			}
+			rw.WriteString("END\r\n")
+		case "set", "add":
+			var size int
+			fmt.Sscanf(args[4], "%d", &size)
+			value := make([]byte, size+2)
+			if _, err = io.ReadFull(rw, value); err != nil {
+				f.Unlock()
+				return
+			}
+			_, exists := f.items[args[1]]
+			if args[0] == "add" && f.failAdds > 0 {
+				f.failAdds--
+				rw.WriteString("SERVER_ERROR out of memory\r\n")
+			} else if args[0] == "add" && exists {
+				rw.WriteString("NOT_STORED\r\n")
+			} else {
+				f.items[args[1]] = value[:size]
+				rw.WriteString("STORED\r\n")
+			}
+		case "delete":
+			if _, exists := f.items[args[1]]; exists {
+				delete(f.items, args[1])
+				rw.WriteString("DELETED\r\n")
+			} else {
+				rw.WriteString("NOT_FOUND\r\n")
+			}
+		default:
+			rw.WriteString("ERROR\r\n")
+		}
+		f.Unlock()
+		rw.Flush()
+	}
+}
+
+func (f *fakeMemcached) countCommands(name string) int {
+	f.Lock()
+	defer f.Unlock()
+	n := 0
+	for _, c := range f.commands {
+		if c == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestMemcachedMissNotRetried(t *testing.T) {
+	server := newFakeMemcached(t)
+	defer server.ln.Close()
+
+	conf := NewConfig()
+	conf.Memcached.Addresses = []string{server.ln.Addr().String()}
+	conf.Memcached.Retries = 3
+	conf.Memcached.RetryPeriodMS = 100
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	c, err := NewMemcached(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tStarted := time.Now()
+	if _, err = c.Get("foo"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+	if dur := time.Since(tStarted); dur >= time.Millisecond*100 {
+		t.Errorf("Cache miss was retried: %v", dur)
+	}
+	if exp, act := 1, server.countCommands("gets"); exp != act {
+		t.Errorf("Wrong count of get commands: %v != %v", act, exp)
+	}
+
+	if err = c.Set("foo", []byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+	act, err := c.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "bar"; string(act) != exp {
+		t.Errorf("Wrong value returned: %s != %v", act, exp)
+	}
+
+	if err = c.Delete("foo"); err != nil {
+		t.Error(err)
+	}
+	if err = c.Delete("foo"); err != nil {
+		t.Errorf("Expected no error from deleting missing key: %v", err)
+	}
+}
+
+func TestMemcachedAddRetries(t *testing.T) {
+	server := newFakeMemcached(t)
+	defer server.ln.Close()
+	server.failAdds = 1
+
+	conf := NewConfig()
+	conf.Memcached.Addresses = []string{server.ln.Addr().String()}
+	conf.Memcached.Retries = 3
+	conf.Memcached.RetryPeriodMS = 1
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	c, err := NewMemcached(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Add("foo", []byte("bar")); err != nil {
+		t.Errorf("Expected add to succeed after retry: %v", err)
+	}
+	if exp, act := 2, server.countCommands("add"); exp != act {
+		t.Errorf("Wrong count of add commands: %v != %v", act, exp)
+	}
+	if err = c.Add("foo", []byte("baz")); err != types.ErrKeyAlreadyExists {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyAlreadyExists)
+	}
+}
@@ -42,7 +42,9 @@ during the next compaction.
 
 A compaction only occurs during a write where the time since the last compaction
 is above the compaction interval. It is therefore possible to obtain values of
-keys that have expired between compactions.`,
+keys that have expired between compactions. However, an add operation always
+succeeds for a key that has expired, which allows the cache to be used for
+deduplicating within a window of the TTL.`,
 	}
 }
 
@@ -126,7 +128,7 @@ func (m *Memory) Set(key string, value []byte) error {
 // and returns an error if the key already exists.
 func (m *Memory) Add(key string, value []byte) error {
 	m.Lock()
-	if _, exists := m.items[key]; exists {
+	if k, exists := m.items[key]; exists && time.Since(k.ts) < m.ttl {
 		m.Unlock()
 		return types.ErrKeyAlreadyExists
 	}
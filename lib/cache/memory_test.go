@@ -23,6 +23,7 @@ package cache
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
@@ -142,3 +143,43 @@ func TestMemoryCacheCompaction(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestMemoryCacheAddExpired(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Type = "memory"
+	conf.Memory.TTL = 1
+	conf.Memory.CompactionIntervalS = 60
+
+	c, err := New(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Add("foo", []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Add("foo", []byte("2")); err != types.ErrKeyAlreadyExists {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyAlreadyExists)
+	}
+
+	// Expire the key without waiting for the TTL.
+	mem := c.(*Memory)
+	mem.Lock()
+	item := mem.items["foo"]
+	item.ts = item.ts.Add(-time.Second * 2)
+	mem.items["foo"] = item
+	mem.Unlock()
+
+	if err = c.Add("foo", []byte("3")); err != nil {
+		t.Errorf("Expected add of expired key to succeed: %v", err)
+	}
+
+	exp := "3"
+	if act, err := c.Get("foo"); err != nil {
+		t.Error(err)
+	} else if string(act) != exp {
+		t.Errorf("Wrong result: %v != %v", string(act), exp)
+	}
+}
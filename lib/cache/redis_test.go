@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// fakeRedis is a minimal redis server supporting the GET, SET and DEL commands.
+type fakeRedis struct {
+	ln net.Listener
+
+	sync.Mutex
+	items    map[string]string
+	commands [][]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{
+		ln:    ln,
+		items: map[string]string{},
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func readRESPArray(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected line: %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, size+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPArray(r)
+		if err != nil || len(args) < 2 {
+			return
+		}
+
+		f.Lock()
+		f.commands = append(f.commands, args)
+		var reply string
+		switch strings.ToLower(args[0]) {
+		case "get":
+			if v, exists := f.items[args[1]]; exists {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+			} else {
+				reply = "$-1\r\n"
+			}
+		case "set":
+			nx := false
+			for _, opt := range args[3:] {
+				if strings.ToLower(opt) == "nx" {
+					nx = true
+				}
+			}
+			if _, exists := f.items[args[1]]; exists && nx {
+				reply = "$-1\r\n"
+			} else {
+				f.items[args[1]] = args[2]
+				reply = "+OK\r\n"
+			}
+		case "del":
+			n := 0
+			for _, k := range args[1:] {
+				if _, exists := f.items[k]; exists {
+					delete(f.items, k)
+					n++
+				}
+			}
+			reply = fmt.Sprintf(":%d\r\n", n)
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		f.Unlock()
+
+		if _, err = conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) countCommands(name string) int {
+	f.Lock()
+	defer f.Unlock()
+	n := 0
+	for _, c := range f.commands {
+		if strings.ToLower(c[0]) == name {
+			n++
+		}
+	}
+	return n
+}
+
+func TestRedisCacheBasic(t *testing.T) {
+	server := newFakeRedis(t)
+	defer server.ln.Close()
+
+	conf := NewConfig()
+	conf.Redis.URL = "tcp://" + server.ln.Addr().String()
+	conf.Redis.Prefix = "benthos_"
+	conf.Redis.TTL = 60
+	conf.Redis.RetryPeriodMS = 1
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	c, err := NewRedis(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = c.Get("foo"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+	if exp, act := 1, server.countCommands("get"); exp != act {
+		t.Errorf("Cache miss was retried: %v != %v", act, exp)
+	}
+
+	if err = c.Add("foo", []byte("bar")); err != nil {
+		t.Error(err)
+	}
+	if err = c.Add("foo", []byte("baz")); err != types.ErrKeyAlreadyExists {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyAlreadyExists)
+	}
+
+	act, err := c.Get("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "bar"; string(act) != exp {
+		t.Errorf("Wrong value returned: %s != %v", act, exp)
+	}
+
+	if err = c.Set("foo", []byte("baz")); err != nil {
+		t.Error(err)
+	}
+	server.Lock()
+	stored := server.items["benthos_foo"]
+	server.Unlock()
+	if exp := "baz"; stored != exp {
+		t.Errorf("Wrong value stored under prefix: %v != %v", stored, exp)
+	}
+
+	if err = c.Delete("foo"); err != nil {
+		t.Error(err)
+	}
+	if _, err = c.Get("foo"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+}
+
+func TestRedisCacheBadURL(t *testing.T) {
+	conf := NewConfig()
+	conf.Redis.URL = "://nope"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	if _, err := NewRedis(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad URL")
+	}
+}
+
+//------------------------------------------------------------------------------
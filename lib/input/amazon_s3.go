@@ -37,9 +37,14 @@ func init() {
 		description: `
 Downloads objects in an Amazon S3 bucket, optionally filtered by a prefix. If an
 SQS queue has been configured then only object keys read from the queue will be
-downloaded. Otherwise, the entire list of objects found when this input is
-created will be downloaded. Note that the prefix configuration is only used when
-downloading objects without SQS configured.
+downloaded, and the input continues to consume events from the queue
+indefinitely. Otherwise, the entire list of objects found when this input is
+created will be downloaded, after which the input closes. In both cases only
+objects with keys that match the prefix are downloaded.
+
+Objects are downloaded as a single message part each, and SQS events are only
+deleted once all objects of the event have been successfully delivered by the
+outputs of the stream. Object keys are URL decoded when read from events.
 
 If your bucket is configured to send events directly to an SQS queue then you
 need to set the 'sqs_body_path' field to where the object key is found in the
@@ -50,7 +55,19 @@ topic which sends enveloped events to SQS, in which case you must also set the
 Here is a guide for setting up an SQS queue that receives events for new S3
 bucket objects:
 
-https://docs.aws.amazon.com/AmazonS3/latest/dev/ways-to-add-notification-config-to-bucket.html`,
+https://docs.aws.amazon.com/AmazonS3/latest/dev/ways-to-add-notification-config-to-bucket.html
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- s3_bucket
+- s3_key
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
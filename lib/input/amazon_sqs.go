@@ -34,7 +34,24 @@ func init() {
 		constructor: NewAmazonSQS,
 		description: `
 Receive messages from an Amazon SQS URL, only the body is extracted into
-messages.`,
+messages.
+
+Messages are received by long polling for up to ` + "`timeout_s`" + ` seconds,
+and up to ` + "`max_number_of_messages`" + ` (between 1 and 10) received
+messages are combined as a batch. Messages are only deleted from the queue once
+they have been successfully delivered by the outputs of the stream.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- sqs_message_id
+- All string message attributes
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
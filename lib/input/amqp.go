@@ -36,7 +36,52 @@ func init() {
 AMQP (0.91) is the underlying messaging protocol that is used by various message
 brokers, including RabbitMQ.
 
-Exchange type options are: direct|fanout|topic|x-custom`,
+Exchange type options are: direct|fanout|topic|x-custom
+
+Messages are consumed with manual acknowledgements, a message is only acked
+once it has been successfully delivered by the outputs of the stream, otherwise
+it is nacked and requeued. The number of unacknowledged messages held by the
+input at any given time is capped by ` + "`prefetch_count`" + `.
+
+When ` + "`queue_declare.enabled`" + ` is set to ` + "`true`" + ` the queue is
+declared with the specified options before consuming. If ` + "`exchange`" + ` is
+also non-empty then the exchange is declared and the queue is bound to it with
+the binding ` + "`key`" + `. Disable it in order to consume from queues that
+already exist or that you do not have permissions to declare.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- amqp_content_type
+- amqp_content_encoding
+- amqp_delivery_mode
+- amqp_priority
+- amqp_correlation_id
+- amqp_reply_to
+- amqp_expiration
+- amqp_message_id
+- amqp_timestamp
+- amqp_type
+- amqp_user_id
+- amqp_app_id
+- amqp_consumer_tag
+- amqp_delivery_tag
+- amqp_redelivered
+- amqp_exchange
+- amqp_routing_key
+- source_id
+- source_offset
+- All existing message headers
+` + "```" + `
+
+The field ` + "`source_id`" + ` identifies the queue and ` + "`source_offset`" + ` is the message ID
+set by the publisher, which is omitted when no ID was set. They are used by
+default to key [deduplication](../pipeline.md#deduplication).
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["azure_event_hubs"] = TypeSpec{
+		constructor: NewAzureEventHubs,
+		description: `
+Receives events from the partitions of an Azure Event Hub as a member of a
+consumer group. The event hub is identified by the entity path of the
+` + "`connection_string`" + `. By default all partitions of the hub are
+consumed, which can be restricted with ` + "`partition_ids`" + `.
+
+The offset of each partition is checkpointed once its events have been
+successfully delivered by the outputs of the stream. Checkpoints are kept in
+memory unless a ` + "`checkpoint_dir`" + ` is specified, in which case they
+are persisted as files within it and consumption resumes from them after a
+restart. Partitions without a checkpoint are consumed from the oldest event
+when ` + "`start_from_oldest`" + ` is true, and otherwise from the newest.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- eventhub_partition_id
+- eventhub_offset
+- eventhub_sequence_number
+- eventhub_partition_key
+- All event properties
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureEventHubs creates a new Azure Event Hubs input type.
+func NewAzureEventHubs(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewAzureEventHubs(conf.AzureEventHubs, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("azure_event_hubs", reader.NewPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["azure_service_bus"] = TypeSpec{
+		constructor: NewAzureServiceBus,
+		description: `
+Receives messages from an Azure Service Bus queue, or from the subscription of
+a topic when ` + "`topic`" + ` and ` + "`subscription`" + ` are set instead of
+` + "`queue`" + `.
+
+Messages are completed once they have been successfully delivered by the
+outputs of the stream, and are otherwise abandoned so that they are
+redelivered.
+
+Session enabled entities are consumed by setting ` + "`use_sessions`" + ` to
+true, in which case the session identified by ` + "`session_id`" + ` is
+locked, or the next available session when it is left empty.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- servicebus_message_id
+- servicebus_session_id
+- servicebus_delivery_count
+- servicebus_sequence_number
+- All user properties
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureServiceBus creates a new Azure Service Bus input type.
+func NewAzureServiceBus(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewAzureServiceBus(conf.AzureServiceBus, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("azure_service_bus", reader.NewPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,222 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/probe"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// connectionChecker dials the targets of an input config without connecting
+// with the input itself, which avoids side effects such as starting consumers
+// or joining consumer groups.
+type connectionChecker func(conf Config, timeout time.Duration) error
+
+// connectionCheckers is a map of input types to their connection checkers.
+// Every input type must either have a checker, be a composite of other inputs,
+// or be listed within checksNotSupported.
+var connectionCheckers = map[string]connectionChecker{
+	"amazon_s3": func(c Config, t time.Duration) error {
+		if len(c.AmazonS3.SQSURL) > 0 {
+			if err := probe.DialURLs(t, c.AmazonS3.SQSURL); err != nil {
+				return err
+			}
+		}
+		return probe.DialAWS(t, "s3", c.AmazonS3.Region)
+	},
+	"amazon_sqs": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.AmazonSQS.URL)
+	},
+	"amqp": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.AMQP.URL)
+	},
+	"azure_event_hubs": func(c Config, t time.Duration) error {
+		return probe.DialAzure(t, c.AzureEventHubs.ConnectionString)
+	},
+	"azure_service_bus": func(c Config, t time.Duration) error {
+		return probe.DialAzure(t, c.AzureServiceBus.ConnectionString)
+	},
+	"file": func(c Config, t time.Duration) error {
+		return probe.PathExists(c.File.Path)
+	},
+	"file_transfer": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.FileTransfer.URL)
+	},
+	"files": func(c Config, t time.Duration) error {
+		return probe.PathExists(c.Files.Path)
+	},
+	"gcp_pubsub": func(c Config, t time.Duration) error {
+		if host := os.Getenv("PUBSUB_EMULATOR_HOST"); len(host) > 0 {
+			return probe.Dial(t, "tcp", "", host)
+		}
+		return probe.Dial(t, "tcp", "443", "pubsub.googleapis.com")
+	},
+	"http_client": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.HTTPClient.URL)
+	},
+	"kafka": func(c Config, t time.Duration) error {
+		return probe.Dial(t, "tcp", "9092", c.Kafka.Addresses...)
+	},
+	"kafka_balanced": func(c Config, t time.Duration) error {
+		return probe.Dial(t, "tcp", "9092", c.KafkaBalanced.Addresses...)
+	},
+	"mqtt": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.MQTT.URLs...)
+	},
+	"nats": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.NATS.URLs...)
+	},
+	"nats_stream": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.NATSStream.URLs...)
+	},
+	"nsq": func(c Config, t time.Duration) error {
+		if err := probe.Dial(t, "tcp", "", c.NSQ.Addresses...); err != nil {
+			return err
+		}
+		return probe.Dial(t, "tcp", "", c.NSQ.LookupAddresses...)
+	},
+	"redis_list": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.RedisList.URL)
+	},
+	"redis_pubsub": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.RedisPubSub.URL)
+	},
+	"redis_streams": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.RedisStreams.URL)
+	},
+	"scalability_protocols": func(c Config, t time.Duration) error {
+		if c.ScaleProto.Bind {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.ScaleProto.URLs...)
+	},
+	"sql": func(c Config, t time.Duration) error {
+		return probe.DialSQL(t, c.SQL.Driver, c.SQL.DSN)
+	},
+	"websocket": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.Websocket.URL)
+	},
+	"zmq4n": func(c Config, t time.Duration) error {
+		if c.ZMQ4N.Bind {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.ZMQ4N.URLs...)
+	},
+}
+
+// checksNotSupported is the set of input types that do not connect to a remote
+// target, or that cannot be checked without connecting with the input itself.
+var checksNotSupported = map[string]struct{}{
+	"dynamic":       {},
+	"generate":      {},
+	"grpc":          {},
+	"http_server":   {},
+	"inproc":        {},
+	"socket_server": {},
+	"stdin":         {},
+	"syslog":        {},
+}
+
+//------------------------------------------------------------------------------
+
+// CheckConnections attempts to dial the targets of each input within a config
+// (including the children of brokers) without connecting with the inputs
+// themselves or consuming any data, and returns the status of each attempt.
+// Input types that do not support connection checks are reported with the
+// error types.ErrCheckNotSupported.
+func CheckConnections(
+	conf Config,
+	timeout time.Duration,
+	log log.Modular,
+	stats metrics.Type,
+) []types.ConnectionStatus {
+	return checkConnections("input", conf, timeout, log, stats)
+}
+
+func checkConnections(
+	path string,
+	conf Config,
+	timeout time.Duration,
+	log log.Modular,
+	stats metrics.Type,
+) []types.ConnectionStatus {
+	switch conf.Type {
+	case "broker":
+		statuses := []types.ConnectionStatus{}
+		for i, c := range conf.Broker.Inputs {
+			statuses = append(statuses, checkConnections(
+				fmt.Sprintf("%v.broker.inputs.%v", path, i), c, timeout, log, stats,
+			)...)
+		}
+		return statuses
+	case "read_until":
+		if conf.ReadUntil.Input == nil {
+			break
+		}
+		return checkConnections(
+			path+".read_until.input", *conf.ReadUntil.Input, timeout, log, stats,
+		)
+	case "sequence":
+		statuses := []types.ConnectionStatus{}
+		for i, c := range conf.Sequence.Inputs {
+			statuses = append(statuses, checkConnections(
+				fmt.Sprintf("%v.sequence.inputs.%v", path, i), c, timeout, log, stats,
+			)...)
+		}
+		return statuses
+	case "schedule":
+		if conf.Schedule.Input == nil {
+			break
+		}
+		return checkConnections(
+			path+".schedule.input", *conf.Schedule.Input, timeout, log, stats,
+		)
+	}
+	return []types.ConnectionStatus{{
+		Path: path + "." + conf.Type,
+		Err:  checkConnection(conf, timeout, log),
+	}}
+}
+
+func checkConnection(conf Config, timeout time.Duration, log log.Modular) error {
+	if _, exists := Constructors[conf.Type]; !exists {
+		return types.ErrInvalidInputType
+	}
+	if _, exists := checksNotSupported[conf.Type]; exists {
+		return types.ErrCheckNotSupported
+	}
+	checker, exists := connectionCheckers[conf.Type]
+	if !exists {
+		return fmt.Errorf("no connection check exists for input type: %v", conf.Type)
+	}
+	log.Debugf("Checking connection of input type: %v\n", conf.Type)
+	return checker(conf, timeout)
+}
+
+//------------------------------------------------------------------------------
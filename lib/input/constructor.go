@@ -61,59 +61,87 @@ var Constructors = map[string]TypeSpec{}
 // that some configs are empty structs, as the type has no optional values but
 // we want to list it as an option.
 type Config struct {
-	Type          string                     `json:"type" yaml:"type"`
-	AmazonS3      reader.AmazonS3Config      `json:"amazon_s3" yaml:"amazon_s3"`
-	AmazonSQS     reader.AmazonSQSConfig     `json:"amazon_sqs" yaml:"amazon_sqs"`
-	AMQP          reader.AMQPConfig          `json:"amqp" yaml:"amqp"`
-	Broker        BrokerConfig               `json:"broker" yaml:"broker"`
-	Dynamic       DynamicConfig              `json:"dynamic" yaml:"dynamic"`
-	File          FileConfig                 `json:"file" yaml:"file"`
-	Files         reader.FilesConfig         `json:"files" yaml:"files"`
-	HTTPClient    HTTPClientConfig           `json:"http_client" yaml:"http_client"`
-	HTTPServer    HTTPServerConfig           `json:"http_server" yaml:"http_server"`
-	Kafka         reader.KafkaConfig         `json:"kafka" yaml:"kafka"`
-	KafkaBalanced reader.KafkaBalancedConfig `json:"kafka_balanced" yaml:"kafka_balanced"`
-	MQTT          reader.MQTTConfig          `json:"mqtt" yaml:"mqtt"`
-	NATS          reader.NATSConfig          `json:"nats" yaml:"nats"`
-	NATSStream    reader.NATSStreamConfig    `json:"nats_stream" yaml:"nats_stream"`
-	NSQ           reader.NSQConfig           `json:"nsq" yaml:"nsq"`
-	ReadUntil     ReadUntilConfig            `json:"read_until" yaml:"read_until"`
-	RedisList     reader.RedisListConfig     `json:"redis_list" yaml:"redis_list"`
-	RedisPubSub   reader.RedisPubSubConfig   `json:"redis_pubsub" yaml:"redis_pubsub"`
-	ScaleProto    reader.ScaleProtoConfig    `json:"scalability_protocols" yaml:"scalability_protocols"`
-	STDIN         STDINConfig                `json:"stdin" yaml:"stdin"`
-	Websocket     reader.WebsocketConfig     `json:"websocket" yaml:"websocket"`
-	ZMQ4          *reader.ZMQ4Config         `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
-	Processors    []processor.Config         `json:"processors" yaml:"processors"`
+	Type            string                       `json:"type" yaml:"type"`
+	AmazonS3        reader.AmazonS3Config        `json:"amazon_s3" yaml:"amazon_s3"`
+	AmazonSQS       reader.AmazonSQSConfig       `json:"amazon_sqs" yaml:"amazon_sqs"`
+	AMQP            reader.AMQPConfig            `json:"amqp" yaml:"amqp"`
+	AzureEventHubs  reader.AzureEventHubsConfig  `json:"azure_event_hubs" yaml:"azure_event_hubs"`
+	AzureServiceBus reader.AzureServiceBusConfig `json:"azure_service_bus" yaml:"azure_service_bus"`
+	Broker          BrokerConfig                 `json:"broker" yaml:"broker"`
+	Dynamic         DynamicConfig                `json:"dynamic" yaml:"dynamic"`
+	File            FileConfig                   `json:"file" yaml:"file"`
+	FileTransfer    reader.FileTransferConfig    `json:"file_transfer" yaml:"file_transfer"`
+	Files           reader.FilesConfig           `json:"files" yaml:"files"`
+	GCPPubSub       reader.GCPPubSubConfig       `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	Generate        reader.GenerateConfig        `json:"generate" yaml:"generate"`
+	GRPC            GRPCConfig                   `json:"grpc" yaml:"grpc"`
+	HTTPClient      HTTPClientConfig             `json:"http_client" yaml:"http_client"`
+	HTTPServer      HTTPServerConfig             `json:"http_server" yaml:"http_server"`
+	Inproc          InprocConfig                 `json:"inproc" yaml:"inproc"`
+	Kafka           reader.KafkaConfig           `json:"kafka" yaml:"kafka"`
+	KafkaBalanced   reader.KafkaBalancedConfig   `json:"kafka_balanced" yaml:"kafka_balanced"`
+	MQTT            reader.MQTTConfig            `json:"mqtt" yaml:"mqtt"`
+	NATS            reader.NATSConfig            `json:"nats" yaml:"nats"`
+	NATSStream      reader.NATSStreamConfig      `json:"nats_stream" yaml:"nats_stream"`
+	NSQ             reader.NSQConfig             `json:"nsq" yaml:"nsq"`
+	ReadUntil       ReadUntilConfig              `json:"read_until" yaml:"read_until"`
+	RedisList       reader.RedisListConfig       `json:"redis_list" yaml:"redis_list"`
+	RedisPubSub     reader.RedisPubSubConfig     `json:"redis_pubsub" yaml:"redis_pubsub"`
+	RedisStreams    reader.RedisStreamsConfig    `json:"redis_streams" yaml:"redis_streams"`
+	ScaleProto      reader.ScaleProtoConfig      `json:"scalability_protocols" yaml:"scalability_protocols"`
+	Schedule        ScheduleConfig               `json:"schedule" yaml:"schedule"`
+	Sequence        SequenceConfig               `json:"sequence" yaml:"sequence"`
+	SocketServer    reader.SocketServerConfig    `json:"socket_server" yaml:"socket_server"`
+	SQL             reader.SQLConfig             `json:"sql" yaml:"sql"`
+	STDIN           STDINConfig                  `json:"stdin" yaml:"stdin"`
+	Syslog          reader.SyslogConfig          `json:"syslog" yaml:"syslog"`
+	Websocket       reader.WebsocketConfig       `json:"websocket" yaml:"websocket"`
+	ZMQ4            *reader.ZMQ4Config           `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	ZMQ4N           reader.ZMQ4NConfig           `json:"zmq4n" yaml:"zmq4n"`
+	Processors      []processor.Config           `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:          "stdin",
-		AmazonS3:      reader.NewAmazonS3Config(),
-		AmazonSQS:     reader.NewAmazonSQSConfig(),
-		AMQP:          reader.NewAMQPConfig(),
-		Broker:        NewBrokerConfig(),
-		Dynamic:       NewDynamicConfig(),
-		File:          NewFileConfig(),
-		Files:         reader.NewFilesConfig(),
-		HTTPClient:    NewHTTPClientConfig(),
-		HTTPServer:    NewHTTPServerConfig(),
-		Kafka:         reader.NewKafkaConfig(),
-		KafkaBalanced: reader.NewKafkaBalancedConfig(),
-		MQTT:          reader.NewMQTTConfig(),
-		NATS:          reader.NewNATSConfig(),
-		NATSStream:    reader.NewNATSStreamConfig(),
-		NSQ:           reader.NewNSQConfig(),
-		ReadUntil:     NewReadUntilConfig(),
-		RedisList:     reader.NewRedisListConfig(),
-		RedisPubSub:   reader.NewRedisPubSubConfig(),
-		ScaleProto:    reader.NewScaleProtoConfig(),
-		STDIN:         NewSTDINConfig(),
-		Websocket:     reader.NewWebsocketConfig(),
-		ZMQ4:          reader.NewZMQ4Config(),
-		Processors:    []processor.Config{processor.NewConfig()},
+		Type:            "stdin",
+		AmazonS3:        reader.NewAmazonS3Config(),
+		AmazonSQS:       reader.NewAmazonSQSConfig(),
+		AMQP:            reader.NewAMQPConfig(),
+		AzureEventHubs:  reader.NewAzureEventHubsConfig(),
+		AzureServiceBus: reader.NewAzureServiceBusConfig(),
+		Broker:          NewBrokerConfig(),
+		Dynamic:         NewDynamicConfig(),
+		File:            NewFileConfig(),
+		FileTransfer:    reader.NewFileTransferConfig(),
+		Files:           reader.NewFilesConfig(),
+		GCPPubSub:       reader.NewGCPPubSubConfig(),
+		Generate:        reader.NewGenerateConfig(),
+		GRPC:            NewGRPCConfig(),
+		HTTPClient:      NewHTTPClientConfig(),
+		HTTPServer:      NewHTTPServerConfig(),
+		Inproc:          NewInprocConfig(),
+		Kafka:           reader.NewKafkaConfig(),
+		KafkaBalanced:   reader.NewKafkaBalancedConfig(),
+		MQTT:            reader.NewMQTTConfig(),
+		NATS:            reader.NewNATSConfig(),
+		NATSStream:      reader.NewNATSStreamConfig(),
+		NSQ:             reader.NewNSQConfig(),
+		ReadUntil:       NewReadUntilConfig(),
+		RedisList:       reader.NewRedisListConfig(),
+		RedisPubSub:     reader.NewRedisPubSubConfig(),
+		RedisStreams:    reader.NewRedisStreamsConfig(),
+		ScaleProto:      reader.NewScaleProtoConfig(),
+		Schedule:        NewScheduleConfig(),
+		Sequence:        NewSequenceConfig(),
+		SocketServer:    reader.NewSocketServerConfig(),
+		SQL:             reader.NewSQLConfig(),
+		STDIN:           NewSTDINConfig(),
+		Syslog:          reader.NewSyslogConfig(),
+		Websocket:       reader.NewWebsocketConfig(),
+		ZMQ4:            reader.NewZMQ4Config(),
+		ZMQ4N:           reader.NewZMQ4NConfig(),
+		Processors:      []processor.Config{processor.NewConfig()},
 	}
 }
 
@@ -147,6 +175,18 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 			"copies": conf.Broker.Copies,
 			"inputs": inSlice,
 		}
+	} else if t == "sequence" {
+		inSlice := []interface{}{}
+		for _, input := range conf.Sequence.Inputs {
+			var sanInput interface{}
+			if sanInput, err = SanitiseConfig(input); err != nil {
+				return nil, err
+			}
+			inSlice = append(inSlice, sanInput)
+		}
+		outputMap["sequence"] = map[string]interface{}{
+			"inputs": inSlice,
+		}
 	} else {
 		outputMap[t] = hashMap[t]
 	}
@@ -234,6 +274,16 @@ Note that in this example we have specified a processor at the broker level
 which will be applied to _all_ inputs, and we also have a processor at the baz
 level which is only applied to messages from the baz input.`
 
+// Specs returns a specification of each input type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
 // Descriptions returns a formatted string of descriptions for each type.
 func Descriptions() string {
 	// Order our input types alphabetically
@@ -23,6 +23,7 @@ package input
 import (
 	"io"
 	"os"
+	"time"
 
 	"github.com/Jeffail/benthos/lib/input/reader"
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -40,7 +41,21 @@ The file type reads input from a file. If multipart is set to false each line
 is read as a separate message. If multipart is set to true each line is read as
 a message part, and an empty line indicates the end of a message.
 
-If the delimiter field is left empty then line feed (\n) is used.`,
+If the delimiter field is left empty then line feed (\n) is used.
+
+### Tail
+
+When ` + "`tail`" + ` is true the file is followed as it is written to, in the
+same way as ` + "`tail -F`" + `, rather than being read once. Whenever the end
+of the file is reached the input waits for ` + "`poll_interval_ms`" + `
+milliseconds before checking for new lines. If the file is rotated (replaced by
+a new file at the same path) or truncated it is read again from the start, and
+if it does not yet exist the input waits for it to be created.
+
+When ` + "`checkpoint_path`" + ` is set the offset of the last successfully
+delivered message is written to that file, and when the input restarts it
+resumes reading from that offset rather than from the start of the file. If
+the file is smaller than the stored offset it is read from the start.`,
 	}
 }
 
@@ -48,19 +63,25 @@ If the delimiter field is left empty then line feed (\n) is used.`,
 
 // FileConfig is configuration values for the File input type.
 type FileConfig struct {
-	Path      string `json:"path" yaml:"path"`
-	Multipart bool   `json:"multipart" yaml:"multipart"`
-	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
-	Delim     string `json:"delimiter" yaml:"delimiter"`
+	Path           string `json:"path" yaml:"path"`
+	Multipart      bool   `json:"multipart" yaml:"multipart"`
+	MaxBuffer      int    `json:"max_buffer" yaml:"max_buffer"`
+	Delim          string `json:"delimiter" yaml:"delimiter"`
+	Tail           bool   `json:"tail" yaml:"tail"`
+	PollIntervalMS int64  `json:"poll_interval_ms" yaml:"poll_interval_ms"`
+	CheckpointPath string `json:"checkpoint_path" yaml:"checkpoint_path"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
 	return FileConfig{
-		Path:      "",
-		Multipart: false,
-		MaxBuffer: 1000000,
-		Delim:     "",
+		Path:           "",
+		Multipart:      false,
+		MaxBuffer:      1000000,
+		Delim:          "",
+		Tail:           false,
+		PollIntervalMS: 1000,
+		CheckpointPath: "",
 	}
 }
 
@@ -68,15 +89,31 @@ func NewFileConfig() FileConfig {
 
 // NewFile creates a new File input type.
 func NewFile(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	file, err := os.Open(conf.File.Path)
-	if err != nil {
-		return nil, err
-	}
 	delim := conf.File.Delim
 	if len(delim) == 0 {
 		delim = "\n"
 	}
 
+	if conf.File.Tail {
+		rdr, err := reader.NewTail(
+			conf.File.Path, log, stats,
+			reader.OptTailSetDelimiter(delim),
+			reader.OptTailSetMaxBuffer(conf.File.MaxBuffer),
+			reader.OptTailSetMultipart(conf.File.Multipart),
+			reader.OptTailSetPollInterval(time.Millisecond*time.Duration(conf.File.PollIntervalMS)),
+			reader.OptTailSetCheckpointPath(conf.File.CheckpointPath),
+		)
+		if err != nil {
+			return nil, err
+		}
+		return NewReader("file", reader.NewPreserver(rdr), log, stats)
+	}
+
+	file, err := os.Open(conf.File.Path)
+	if err != nil {
+		return nil, err
+	}
+
 	rdr, err := reader.NewLines(
 		func() (io.Reader, error) {
 			// Swap so this only works once since we don't want to read the file
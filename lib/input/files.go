@@ -36,7 +36,45 @@ func init() {
 Reads files from a path, where each discrete file will be consumed as a single
 message payload. The path can either point to a single file (resulting in only a
 single message) or a directory, in which case the directory will be walked and
-each file found will become a message.`,
+each file found will become a message.
+
+### Patterns
+
+When reading a directory the files consumed can be filtered with a list of glob
+` + "`patterns`" + `, where a file is read if it matches any of them. Patterns
+that contain a path separator are matched against the path of a file relative
+to the directory, otherwise they are matched against the file name. When
+` + "`recursive`" + ` is false only the files directly within the directory are
+read.
+
+### Codecs
+
+The ` + "`codec`" + ` field determines how the contents of each file are
+consumed. With ` + "`all-bytes`" + ` the entire file is a single message, and
+with ` + "`lines`" + ` each line of the file separated by ` + "`delimiter`" + `
+(a newline by default) is a message.
+
+With ` + "`csv`" + ` each row of the file is a message containing a JSON
+object, where the keys are taken from ` + "`csv.columns`" + ` when set and
+otherwise from the first row of the file. All values are strings, and fields
+are separated by the single character ` + "`csv.delimiter`" + `.
+
+### Post-processing
+
+Once all messages of a file have been acknowledged downstream the file can be
+moved into the directory ` + "`move_to_dir`" + `, or deleted if
+` + "`delete_files`" + ` is true.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- path
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
@@ -44,7 +82,7 @@ each file found will become a message.`,
 
 // NewFiles creates a new Files input type.
 func NewFiles(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	f, err := reader.NewFiles(conf.Files)
+	f, err := reader.NewFiles(conf.Files, log, stats)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["gcp_pubsub"] = TypeSpec{
+		constructor: NewGCPPubSub,
+		description: `
+Consumes messages from a GCP Pub/Sub subscription. Credentials are taken from
+the environment as described in the
+[GCP documentation](https://cloud.google.com/docs/authentication/production).
+
+Messages are only acknowledged once they have been successfully delivered by
+the outputs of the stream, and are otherwise nacked for redelivery. The fields
+` + "`max_outstanding_messages`" + ` and ` + "`max_outstanding_bytes`" + ` limit
+the amount of messages that are received but not yet acknowledged at any given
+time.
+
+The attributes of each message are added as metadata.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGCPPubSub creates a new GCP Pub/Sub input type.
+func NewGCPPubSub(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewGCPPubSub(conf.GCPPubSub, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("gcp_pubsub", reader.NewPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
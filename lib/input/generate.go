@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["generate"] = TypeSpec{
+		constructor: NewGenerate,
+		description: `
+Produces synthetic messages at a configured ` + "`rate`" + ` of messages per
+second, which is useful for load testing pipelines and outputs without an
+external producer. A rate of zero produces messages as fast as they can be
+consumed.
+
+Each message has ` + "`parts`" + ` parts, where the ` + "`content`" + ` of each
+part supports [function interpolations](../config_interpolation.md#functions),
+allowing messages to contain counters, timestamps or UUIDs. When
+` + "`max_size`" + ` is greater than zero each part is padded with random
+alphanumeric characters, or truncated, to a random size between
+` + "`min_size`" + ` and ` + "`max_size`" + ` bytes.
+
+If ` + "`count`" + ` is greater than zero the input closes after generating that
+many messages, otherwise messages are generated until the pipeline is shut
+down.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGenerate creates a new Generate input type.
+func NewGenerate(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	g, err := reader.NewGenerate(conf.Generate, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("generate", g, log, stats)
+}
+
+//------------------------------------------------------------------------------
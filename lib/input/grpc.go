@@ -0,0 +1,245 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pb"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["grpc"] = TypeSpec{
+		constructor: NewGRPC,
+		description: `
+Serves a gRPC service with a bidirectional streaming RPC, through which producers
+can push messages into Benthos. The service is defined by the protobuf file
+` + "`lib/util/pb/benthos.proto`" + `, and the ` + "`grpc`" + ` output is a
+client of it.
+
+Each message sent over a stream is answered with a response once it has been
+processed by the pipeline, which contains an error if it could not be delivered.
+The next message of a stream is not consumed until the response has been sent,
+and therefore each stream has its own backpressure. TLS is enabled when key and
+cert files are specified.
+
+### Metadata
+
+The metadata of each message part is set from the metadata field of the part
+sent over the stream.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GRPCConfig is configuration for the GRPC input type.
+type GRPCConfig struct {
+	Address  string `json:"address" yaml:"address"`
+	CertFile string `json:"cert_file" yaml:"cert_file"`
+	KeyFile  string `json:"key_file" yaml:"key_file"`
+}
+
+// NewGRPCConfig creates a new GRPCConfig with default values.
+func NewGRPCConfig() GRPCConfig {
+	return GRPCConfig{
+		Address:  "0.0.0.0:4197",
+		CertFile: "",
+		KeyFile:  "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GRPC is an input type that serves a gRPC streaming service.
+type GRPC struct {
+	running int32
+
+	conf  GRPCConfig
+	stats metrics.Type
+	log   log.Modular
+
+	listener net.Listener
+	server   *grpc.Server
+
+	transactions chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+
+	mCount  metrics.StatCounter
+	mCountF metrics.StatCounter
+	mErr    metrics.StatCounter
+	mErrF   metrics.StatCounter
+	mSucc   metrics.StatCounter
+	mSuccF  metrics.StatCounter
+}
+
+// NewGRPC creates a new GRPC input type.
+func NewGRPC(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	var opts []grpc.ServerOption
+	if len(conf.GRPC.KeyFile) > 0 || len(conf.GRPC.CertFile) > 0 {
+		creds, err := credentials.NewServerTLSFromFile(conf.GRPC.CertFile, conf.GRPC.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", conf.GRPC.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &GRPC{
+		running:      1,
+		conf:         conf.GRPC,
+		stats:        stats,
+		log:          log.NewModule(".input.grpc"),
+		listener:     listener,
+		server:       grpc.NewServer(opts...),
+		transactions: make(chan types.Transaction),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+
+		mCount:  stats.GetCounter("input.grpc.count"),
+		mCountF: stats.GetCounter("input.count"),
+		mErr:    stats.GetCounter("input.grpc.send.error"),
+		mErrF:   stats.GetCounter("input.send.error"),
+		mSucc:   stats.GetCounter("input.grpc.send.success"),
+		mSuccF:  stats.GetCounter("input.send.success"),
+	}
+	pb.RegisterBenthosServer(g.server, g)
+
+	go g.loop()
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Send implements the Benthos gRPC service by reading messages from a stream
+// and responding to each once its transaction is resolved.
+func (g *GRPC) Send(stream pb.Benthos_SendServer) error {
+	resChan := make(chan types.Response)
+	for atomic.LoadInt32(&g.running) == 1 {
+		pbMsg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		g.mCount.Incr(1)
+		g.mCountF.Incr(1)
+
+		select {
+		case g.transactions <- types.NewTransaction(pb.ToMessage(pbMsg), resChan):
+		case <-g.closeChan:
+			return status.Error(codes.Unavailable, "server closing")
+		}
+
+		var res types.Response
+		var open bool
+		select {
+		case res, open = <-resChan:
+			if !open {
+				return status.Error(codes.Unavailable, "server closing")
+			}
+		case <-g.closeChan:
+			return status.Error(codes.Unavailable, "server closing")
+		}
+
+		pbRes := &pb.Response{}
+		if err = res.Error(); err != nil {
+			g.mErr.Incr(1)
+			g.mErrF.Incr(1)
+			pbRes.Error = err.Error()
+		} else {
+			g.mSucc.Incr(1)
+			g.mSuccF.Incr(1)
+		}
+		if err = stream.Send(pbRes); err != nil {
+			return err
+		}
+	}
+	return status.Error(codes.Unavailable, "server closing")
+}
+
+//------------------------------------------------------------------------------
+
+func (g *GRPC) loop() {
+	mRunning := g.stats.GetCounter("input.grpc.running")
+
+	defer func() {
+		atomic.StoreInt32(&g.running, 0)
+
+		g.server.Stop()
+		mRunning.Decr(1)
+
+		close(g.transactions)
+		close(g.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	go func() {
+		g.log.Infof("Receiving gRPC messages at: %v\n", g.listener.Addr())
+		if err := g.server.Serve(g.listener); err != nil && err != grpc.ErrServerStopped {
+			g.log.Errorf("Server error: %v\n", err)
+		}
+	}()
+
+	<-g.closeChan
+}
+
+// TransactionChan returns the transactions channel.
+func (g *GRPC) TransactionChan() <-chan types.Transaction {
+	return g.transactions
+}
+
+// CloseAsync shuts down the GRPC input and stops processing requests.
+func (g *GRPC) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&g.running, 1, 0) {
+		close(g.closeChan)
+	}
+}
+
+// WaitForClose blocks until the GRPC input has closed down.
+func (g *GRPC) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-g.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
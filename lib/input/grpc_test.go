@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pb"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"google.golang.org/grpc"
+)
+
+func TestGRPCBasic(t *testing.T) {
+	t.Parallel()
+
+	conf := NewConfig()
+	conf.GRPC.Address = "localhost:0"
+
+	in, err := NewGRPC(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		in.CloseAsync()
+		if err := in.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	conn, err := grpc.DialContext(
+		ctx, in.(*GRPC).listener.Addr().String(), grpc.WithInsecure(), grpc.WithBlock(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewBenthosClient(conn).Send(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expErr := range []error{nil, errors.New("nope")} {
+		if err = stream.Send(&pb.Message{
+			Parts: []*pb.Part{
+				{Content: []byte("foo"), Metadata: map[string]string{"bar": "baz"}},
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		var ts types.Transaction
+		select {
+		case ts = <-in.TransactionChan():
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		if exp, act := "foo", string(ts.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message contents: %v != %v", act, exp)
+		}
+		if exp, act := "baz", ts.Payload.GetMetadata(0).Get("bar"); exp != act {
+			t.Errorf("Wrong metadata: %v != %v", act, exp)
+		}
+
+		select {
+		case ts.ResponseChan <- types.NewSimpleResponse(expErr):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expErr == nil {
+			if len(res.Error) > 0 {
+				t.Errorf("Unexpected error: %v", res.Error)
+			}
+		} else if exp, act := expErr.Error(), res.Error; exp != act {
+			t.Errorf("Wrong error: %v != %v", act, exp)
+		}
+	}
+
+	if err = stream.CloseSend(); err != nil {
+		t.Error(err)
+	}
+}
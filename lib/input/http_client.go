@@ -23,6 +23,7 @@ package input
 import (
 	"bytes"
 	"crypto/tls"
+	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
@@ -36,6 +37,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
+	"github.com/Jeffail/benthos/lib/util/http/pool"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 	"github.com/Jeffail/benthos/lib/util/throttle"
 )
@@ -86,6 +88,8 @@ type HTTPClientConfig struct {
 	RetryMS        int64        `json:"retry_period_ms" yaml:"retry_period_ms"`
 	MaxBackoffMS   int64        `json:"max_retry_backoff_ms" yaml:"max_retry_backoff_ms"`
 	SkipCertVerify bool         `json:"skip_cert_verify" yaml:"skip_cert_verify"`
+	RateLimit      string       `json:"rate_limit" yaml:"rate_limit"`
+	ConnectionPool pool.Config  `json:"connection_pool" yaml:"connection_pool"`
 	auth.Config    `json:",inline" yaml:",inline"`
 }
 
@@ -107,6 +111,8 @@ func NewHTTPClientConfig() HTTPClientConfig {
 		RetryMS:        1000,
 		MaxBackoffMS:   300000,
 		SkipCertVerify: false,
+		RateLimit:      "",
+		ConnectionPool: pool.NewConfig(),
 		Config:         auth.NewConfig(),
 	}
 }
@@ -125,6 +131,7 @@ type HTTPClient struct {
 	buffer *bytes.Buffer
 	client http.Client
 
+	rateLimit     types.RateLimit
 	retryThrottle *throttle.Type
 	transactions  chan types.Transaction
 
@@ -145,6 +152,13 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 		closedChan:   make(chan struct{}),
 	}
 
+	if rl := conf.HTTPClient.RateLimit; len(rl) > 0 {
+		var err error
+		if h.rateLimit, err = mgr.GetRateLimit(rl); err != nil {
+			return nil, fmt.Errorf("unable to locate rate_limit resource '%v': %v", rl, err)
+		}
+	}
+
 	h.retryThrottle = throttle.New(
 		throttle.OptMaxUnthrottledRetries(0),
 		throttle.OptCloseChan(h.closeChan),
@@ -152,11 +166,11 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 		throttle.OptMaxExponentPeriod(time.Millisecond*time.Duration(conf.HTTPClient.MaxBackoffMS)),
 	)
 
+	var tlsConf *tls.Config
 	if h.conf.HTTPClient.SkipCertVerify {
-		h.client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+		tlsConf = &tls.Config{InsecureSkipVerify: true}
 	}
+	h.client.Transport = h.conf.HTTPClient.ConnectionPool.Transport(tlsConf)
 
 	if !h.conf.HTTPClient.Stream.Enabled {
 		// Timeout should be left at zero if we are streaming.
@@ -273,11 +287,37 @@ func (h *HTTPClient) createRequest() (req *http.Request, err error) {
 	return
 }
 
+// waitForAccess blocks until the configured rate limit, if any, allows a
+// request. Returns false if the input was closed whilst waiting.
+func (h *HTTPClient) waitForAccess() bool {
+	if h.rateLimit == nil {
+		return true
+	}
+	for {
+		period, err := h.rateLimit.Access()
+		if err != nil {
+			h.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(period):
+		case <-h.closeChan:
+			return false
+		}
+	}
+}
+
 func (h *HTTPClient) doRequest() (*http.Response, error) {
 	var req *http.Request
 	var res *http.Response
 	var err error
 
+	if !h.waitForAccess() {
+		return nil, types.ErrTypeClosed
+	}
 	if req, err = h.createRequest(); err != nil {
 		return nil, err
 	}
@@ -385,6 +425,9 @@ func (h *HTTPClient) loop() {
 			var err error
 
 			if res, err = h.doRequest(); err != nil {
+				if err == types.ErrTypeClosed {
+					return
+				}
 				if strings.Contains(err.Error(), "(Client.Timeout exceeded while awaiting headers)") {
 					// Hate this ^
 					mReqTimedOut.Incr(1)
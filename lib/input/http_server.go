@@ -21,18 +21,23 @@
 package input
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/roundtrip"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 	"github.com/Jeffail/benthos/lib/util/throttle"
 	"github.com/gorilla/websocket"
@@ -48,7 +53,40 @@ Receive messages POSTed over HTTP(S). HTTP 2.0 is supported when using TLS,
 which is enabled when key and cert files are specified.
 
 You can leave the 'address' config field blank in order to use the instance wide
-HTTP server.`,
+HTTP server.
+
+Requests with a multipart content type (such as ` + "`multipart/form-data`" + `)
+are consumed as a message with a part for each part of the request. Request
+bodies encoded with ` + "`Content-Encoding: gzip`" + ` are decompressed, and
+requests with any other encoding are rejected with a 415 status code.
+
+When ` + "`max_body_size`" + ` is greater than zero it limits the size in bytes
+of the (decompressed) body of each request, and larger requests are rejected
+with a 413 status code.
+
+### Synchronous Responses
+
+By default a request is responded to with an empty body once its message has
+been acknowledged downstream. When ` + "`sync_response`" + ` is true the
+response body is instead made up of the messages that reach a
+` + "`sync_response`" + ` output, which allows Benthos to act as a
+request/reply transformation service. When a single message part is returned it
+is written as the raw body of the response, and multiple parts are written as a
+` + "`multipart/mixed`" + ` body. The ` + "`Content-Type`" + ` metadata field of
+a part, if set, is used as its content type.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- All headers (only first values are taken)
+- The headers of each part of a multipart request, which override request
+  headers of the same name
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
@@ -56,23 +94,27 @@ HTTP server.`,
 
 // HTTPServerConfig is configuration for the HTTPServer input type.
 type HTTPServerConfig struct {
-	Address   string `json:"address" yaml:"address"`
-	Path      string `json:"path" yaml:"path"`
-	WSPath    string `json:"ws_path" yaml:"ws_path"`
-	TimeoutMS int64  `json:"timeout_ms" yaml:"timeout_ms"`
-	CertFile  string `json:"cert_file" yaml:"cert_file"`
-	KeyFile   string `json:"key_file" yaml:"key_file"`
+	Address      string `json:"address" yaml:"address"`
+	Path         string `json:"path" yaml:"path"`
+	WSPath       string `json:"ws_path" yaml:"ws_path"`
+	TimeoutMS    int64  `json:"timeout_ms" yaml:"timeout_ms"`
+	MaxBodySize  int64  `json:"max_body_size" yaml:"max_body_size"`
+	SyncResponse bool   `json:"sync_response" yaml:"sync_response"`
+	CertFile     string `json:"cert_file" yaml:"cert_file"`
+	KeyFile      string `json:"key_file" yaml:"key_file"`
 }
 
 // NewHTTPServerConfig creates a new HTTPServerConfig with default values.
 func NewHTTPServerConfig() HTTPServerConfig {
 	return HTTPServerConfig{
-		Address:   "",
-		Path:      "/post",
-		WSPath:    "/post/ws",
-		TimeoutMS: 5000,
-		CertFile:  "",
-		KeyFile:   "",
+		Address:      "",
+		Path:         "/post",
+		WSPath:       "/post/ws",
+		TimeoutMS:    5000,
+		MaxBodySize:  0,
+		SyncResponse: false,
+		CertFile:     "",
+		KeyFile:      "",
 	}
 }
 
@@ -177,46 +219,18 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	msg := types.NewMessage(nil)
-	var err error
-
-	defer func() {
-		if err != nil {
-			http.Error(w, "Bad request", http.StatusBadRequest)
-			h.log.Warnf("Request read failed: %v\n", err)
-			return
-		}
-	}()
-
-	var mediaType string
-	var params map[string]string
-	if mediaType, params, err = mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil {
+	msg, status, err := h.readMessage(r)
+	if err != nil {
+		http.Error(w, http.StatusText(status), status)
+		h.log.Warnf("Request read failed: %v\n", err)
 		return
 	}
 
-	if strings.HasPrefix(mediaType, "multipart/") {
-		mr := multipart.NewReader(r.Body, params["boundary"])
-		for {
-			var p *multipart.Part
-			if p, err = mr.NextPart(); err != nil {
-				if err == io.EOF {
-					err = nil
-					break
-				}
-				return
-			}
-			var msgBytes []byte
-			if msgBytes, err = ioutil.ReadAll(p); err != nil {
-				return
-			}
-			msg.Append(msgBytes)
-		}
-	} else {
-		var msgBytes []byte
-		if msgBytes, err = ioutil.ReadAll(r.Body); err != nil {
-			return
-		}
-		msg.Append(msgBytes)
+	var store roundtrip.ResultStore
+	if h.conf.HTTPServer.SyncResponse {
+		var release func()
+		store, release = roundtrip.AddResultStore(msg)
+		defer release()
 	}
 
 	resChan := make(chan types.Response)
@@ -244,6 +258,9 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		h.mSucc.Incr(1)
 		h.mSuccF.Incr(1)
+		if store != nil {
+			h.writeResults(w, store.Get())
+		}
 	case <-time.After(time.Millisecond * time.Duration(h.conf.HTTPServer.TimeoutMS)):
 		h.mTimeout.Incr(1)
 		http.Error(w, "Request timed out", http.StatusRequestTimeout)
@@ -262,6 +279,167 @@ func (h *HTTPServer) postHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// errBodyTooLarge is returned when a request body exceeds the max_body_size.
+var errBodyTooLarge = errors.New("request body exceeds max_body_size")
+
+// limitedReader reads from an underlying reader until a limit is exceeded, at
+// which point it returns errBodyTooLarge.
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if l.remaining -= int64(n); l.remaining < 0 {
+		return n, errBodyTooLarge
+	}
+	return n, err
+}
+
+// readMessage reads a message from the body of a POST request, returning the
+// HTTP status code appropriate for the error when the request is rejected.
+func (h *HTTPServer) readMessage(r *http.Request) (types.Message, int, error) {
+	var body io.Reader = r.Body
+
+	switch encoding := strings.ToLower(r.Header.Get("Content-Encoding")); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("content encoding not supported: %v", encoding)
+	}
+
+	var limited *limitedReader
+	if h.conf.HTTPServer.MaxBodySize > 0 {
+		limited = &limitedReader{r: body, remaining: h.conf.HTTPServer.MaxBodySize}
+		body = limited
+	}
+
+	readErr := func(err error) (types.Message, int, error) {
+		// The error might be wrapped by the multipart reader, and therefore
+		// the limit is checked directly.
+		if limited != nil && limited.remaining < 0 {
+			return nil, http.StatusRequestEntityTooLarge, err
+		}
+		return nil, http.StatusBadRequest, err
+	}
+
+	var mediaType string
+	var params map[string]string
+	if contentType := r.Header.Get("Content-Type"); len(contentType) > 0 {
+		var err error
+		if mediaType, params, err = mime.ParseMediaType(contentType); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	msg := types.NewMessage(nil)
+	var partHeaders []map[string][]string
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				if err == io.EOF {
+					break
+				}
+				return readErr(err)
+			}
+			msgBytes, err := ioutil.ReadAll(p)
+			if err != nil {
+				return readErr(err)
+			}
+			msg.Append(msgBytes)
+			partHeaders = append(partHeaders, p.Header)
+		}
+	} else {
+		msgBytes, err := ioutil.ReadAll(body)
+		if err != nil {
+			return readErr(err)
+		}
+		msg.Append(msgBytes)
+	}
+
+	meta := types.NewMetadata()
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			meta.Set(k, v[0])
+		}
+	}
+	msg.SetMetadata(meta)
+
+	for i, headers := range partHeaders {
+		partMeta := msg.GetMetadata(i)
+		for k, v := range headers {
+			if len(v) > 0 {
+				partMeta.Set(k, v[0])
+			}
+		}
+	}
+	return msg, http.StatusOK, nil
+}
+
+// writeResults writes the parts of synchronous response messages as the body
+// of a response.
+func (h *HTTPServer) writeResults(w http.ResponseWriter, results []types.Message) {
+	var parts [][]byte
+	var contentTypes []string
+	for _, res := range results {
+		for i := 0; i < res.Len(); i++ {
+			parts = append(parts, res.Get(i))
+			contentTypes = append(contentTypes, res.GetMetadata(i).Get("Content-Type"))
+		}
+	}
+
+	if len(parts) == 0 {
+		return
+	}
+	if len(parts) == 1 {
+		if len(contentTypes[0]) > 0 {
+			w.Header().Set("Content-Type", contentTypes[0])
+		}
+		if _, err := w.Write(parts[0]); err != nil {
+			h.log.Warnf("Failed to write response: %v\n", err)
+		}
+		return
+	}
+
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	for i, part := range parts {
+		contentType := contentTypes[i]
+		if len(contentType) == 0 {
+			contentType = "application/octet-stream"
+		}
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type": []string{contentType},
+		})
+		if err == nil {
+			_, err = pw.Write(part)
+		}
+		if err != nil {
+			h.log.Warnf("Failed to write response: %v\n", err)
+			return
+		}
+	}
+	if err := mw.Close(); err != nil {
+		h.log.Warnf("Failed to write response: %v\n", err)
+	}
+}
+
 func (h *HTTPServer) wsHandler(w http.ResponseWriter, r *http.Request) {
 	var err error
 	defer func() {
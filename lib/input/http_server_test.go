@@ -22,7 +22,11 @@ package input
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"testing"
@@ -30,6 +34,7 @@ import (
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/roundtrip"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 )
 
@@ -209,3 +214,220 @@ func TestHTTPTimeout(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestHTTPRequestBodies(t *testing.T) {
+	t.Parallel()
+
+	conf := NewConfig()
+	conf.HTTPServer.Address = "localhost:1234"
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.MaxBodySize = 1000
+
+	h, err := NewHTTPServer(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.CloseAsync()
+
+	<-time.After(time.Millisecond * 1000)
+
+	post := func(body []byte, contentType, encoding string) <-chan int {
+		codeChan := make(chan int, 1)
+		go func() {
+			req, err := http.NewRequest("POST", "http://localhost:1234/testpost", bytes.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				codeChan <- 0
+				return
+			}
+			if len(contentType) > 0 {
+				req.Header.Set("Content-Type", contentType)
+			}
+			if len(encoding) > 0 {
+				req.Header.Set("Content-Encoding", encoding)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Error(err)
+				codeChan <- 0
+				return
+			}
+			res.Body.Close()
+			codeChan <- res.StatusCode
+		}()
+		return codeChan
+	}
+
+	receive := func() types.Message {
+		select {
+		case ts := <-h.TransactionChan():
+			select {
+			case ts.ResponseChan <- types.NewSimpleResponse(nil):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for response")
+			}
+			return ts.Payload
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+		return nil
+	}
+
+	// Gzip encoded body.
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	codeChan := post(gzipped.Bytes(), "", "gzip")
+	if exp, act := "hello", string(receive().Get(0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := http.StatusOK, <-codeChan; exp != act {
+		t.Errorf("Unexpected status code: %v != %v", act, exp)
+	}
+
+	// Multipart form data.
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	fw, _ := mw.CreateFormField("foo")
+	fw.Write([]byte("first"))
+	fw, _ = mw.CreateFormFile("bar", "bar.txt")
+	fw.Write([]byte("second"))
+	mw.Close()
+
+	codeChan = post(form.Bytes(), mw.FormDataContentType(), "")
+	msg := receive()
+	if exp, act := 2, msg.Len(); exp != act {
+		t.Fatalf("Wrong number of parts: %v != %v", act, exp)
+	}
+	if exp, act := "first", string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "second", string(msg.Get(1)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := `form-data; name="bar"; filename="bar.txt"`, msg.GetMetadata(1).Get("Content-Disposition"); exp != act {
+		t.Errorf("Wrong part metadata: %v != %v", act, exp)
+	}
+	if exp, act := "application/octet-stream", msg.GetMetadata(1).Get("Content-Type"); exp != act {
+		t.Errorf("Wrong part metadata: %v != %v", act, exp)
+	}
+	if exp, act := http.StatusOK, <-codeChan; exp != act {
+		t.Errorf("Unexpected status code: %v != %v", act, exp)
+	}
+
+	// Rejected requests.
+	if exp, act := http.StatusRequestEntityTooLarge, <-post(bytes.Repeat([]byte("a"), 1001), "text/plain", ""); exp != act {
+		t.Errorf("Unexpected status code: %v != %v", act, exp)
+	}
+	if exp, act := http.StatusUnsupportedMediaType, <-post([]byte("hello"), "text/plain", "br"); exp != act {
+		t.Errorf("Unexpected status code: %v != %v", act, exp)
+	}
+	if exp, act := http.StatusBadRequest, <-post([]byte("hello"), "text/plain", "gzip"); exp != act {
+		t.Errorf("Unexpected status code: %v != %v", act, exp)
+	}
+}
+
+func TestHTTPSyncResponse(t *testing.T) {
+	t.Parallel()
+
+	conf := NewConfig()
+	conf.HTTPServer.Address = "localhost:1235"
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.SyncResponse = true
+
+	h, err := NewHTTPServer(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.CloseAsync()
+
+	<-time.After(time.Millisecond * 1000)
+
+	type response struct {
+		res  *http.Response
+		body []byte
+	}
+	post := func() <-chan response {
+		resChan := make(chan response, 1)
+		go func() {
+			res, err := http.Post(
+				"http://localhost:1235/testpost",
+				"text/plain",
+				bytes.NewBuffer([]byte("hello world")),
+			)
+			if err != nil {
+				t.Error(err)
+				resChan <- response{}
+				return
+			}
+			body, err := ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil {
+				t.Error(err)
+			}
+			resChan <- response{res: res, body: body}
+		}()
+		return resChan
+	}
+
+	respond := func(parts ...string) {
+		select {
+		case ts := <-h.TransactionChan():
+			result := types.NewMessage(nil)
+			for _, p := range parts {
+				result.Append(bytes.ToUpper([]byte(p)))
+			}
+			result.SetMetadata(ts.Payload.GetMetadata(0))
+			roundtrip.SetAsResponse(result)
+			select {
+			case ts.ResponseChan <- types.NewSimpleResponse(nil):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for response")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message")
+		}
+	}
+
+	resChan := post()
+	respond("foo")
+	res := <-resChan
+	if res.res == nil {
+		t.FailNow()
+	}
+	if exp, act := "FOO", string(res.body); exp != act {
+		t.Errorf("Wrong response body: %v != %v", act, exp)
+	}
+	if exp, act := "text/plain", res.res.Header.Get("Content-Type"); exp != act {
+		t.Errorf("Wrong content type: %v != %v", act, exp)
+	}
+
+	resChan = post()
+	respond("foo", "bar")
+	if res = <-resChan; res.res == nil {
+		t.FailNow()
+	}
+	mediaType, params, err := mime.ParseMediaType(res.res.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "multipart/mixed", mediaType; exp != act {
+		t.Errorf("Wrong content type: %v != %v", act, exp)
+	}
+	mr := multipart.NewReader(bytes.NewReader(res.body), params["boundary"])
+	for _, exp := range []string{"FOO", "BAR"} {
+		p, err := mr.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		act, err := ioutil.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp != string(act) {
+			t.Errorf("Wrong response part: %s != %v", act, exp)
+		}
+	}
+}
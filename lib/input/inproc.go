@@ -0,0 +1,186 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["inproc"] = TypeSpec{
+		constructor: NewInproc,
+		description: `
+Directly connect to an output within a Benthos process by referencing it by a
+chosen ID. This allows you to hook up isolated streams whilst running Benthos in
+[streams mode](../streams_mode.md) or with multiple streams within a single
+config. It is NOT recommended that you connect the inputs of a stream with an
+output of the same stream, as feedback loops can lead to deadlocks in your
+message flow.
+
+It is possible to connect multiple inputs to the same inproc ID, in which case
+messages are distributed between them.
+
+If an output is not yet bound to the ID, or the bound output is closed, the
+input waits until a new output binds to it.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// InprocConfig is a configuration type for the inproc input, which is the ID
+// of the pipe to connect to.
+type InprocConfig string
+
+// NewInprocConfig creates a new inproc input config.
+func NewInprocConfig() InprocConfig {
+	return InprocConfig("")
+}
+
+//------------------------------------------------------------------------------
+
+// Inproc is an input type that reads from a named pipe, which could be the
+// output of a separate Benthos stream of the same process.
+type Inproc struct {
+	running int32
+
+	pipe string
+	mgr  types.Manager
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactions chan types.Transaction
+
+	closedChan chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewInproc creates a new Inproc input type.
+func NewInproc(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if len(conf.Inproc) == 0 {
+		return nil, errors.New("inproc input requires an ID")
+	}
+	i := &Inproc{
+		running:      1,
+		pipe:         string(conf.Inproc),
+		mgr:          mgr,
+		log:          log.NewModule(".input.inproc"),
+		stats:        stats,
+		transactions: make(chan types.Transaction),
+		closedChan:   make(chan struct{}),
+		closeChan:    make(chan struct{}),
+	}
+	go i.loop()
+	return i, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (i *Inproc) loop() {
+	var (
+		mRunning  = i.stats.GetCounter("input.inproc.running")
+		mCount    = i.stats.GetCounter("input.inproc.count")
+		mConn     = i.stats.GetCounter("input.inproc.connection.up")
+		mLostConn = i.stats.GetCounter("input.inproc.connection.lost")
+	)
+
+	defer func() {
+		mRunning.Decr(1)
+		close(i.transactions)
+		close(i.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	var inprocChan <-chan types.Transaction
+
+messageLoop:
+	for atomic.LoadInt32(&i.running) == 1 {
+		if inprocChan == nil {
+			for {
+				var err error
+				if inprocChan, err = i.mgr.GetPipe(i.pipe); err == nil {
+					break
+				}
+				select {
+				case <-time.After(time.Millisecond * 100):
+				case <-i.closeChan:
+					return
+				}
+			}
+			mConn.Incr(1)
+			i.log.Infof("Receiving inproc messages from ID: %s\n", i.pipe)
+		}
+
+		var t types.Transaction
+		var open bool
+		select {
+		case t, open = <-inprocChan:
+			if !open {
+				mLostConn.Incr(1)
+				i.log.Infof("Lost inproc connection to ID: %s\n", i.pipe)
+				inprocChan = nil
+				continue messageLoop
+			}
+			mCount.Incr(1)
+		case <-i.closeChan:
+			return
+		}
+
+		select {
+		case i.transactions <- t:
+		case <-i.closeChan:
+			return
+		}
+	}
+}
+
+// TransactionChan returns a transactions channel for consuming messages from
+// this input type.
+func (i *Inproc) TransactionChan() <-chan types.Transaction {
+	return i.transactions
+}
+
+// CloseAsync shuts down the Inproc input and stops processing requests.
+func (i *Inproc) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&i.running, 1, 0) {
+		close(i.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Inproc input has closed down.
+func (i *Inproc) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-i.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
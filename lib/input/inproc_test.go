@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestInprocReconnect(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	mgr, err := manager.New(manager.NewConfig(), nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Type = "inproc"
+	conf.Inproc = "foo"
+
+	ip, err := NewInproc(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"first", "second"} {
+		pipe := make(chan types.Transaction)
+		mgr.SetPipe("foo", pipe)
+
+		resChan := make(chan types.Response, 1)
+		select {
+		case pipe <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case tran := <-ip.TransactionChan():
+			if exp, act := content, string(tran.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong message: %v != %v", act, exp)
+			}
+			select {
+			case tran.ResponseChan <- types.NewSimpleResponse(nil):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		mgr.UnsetPipe("foo", pipe)
+		close(pipe)
+	}
+
+	ip.CloseAsync()
+	if err = ip.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -41,7 +41,29 @@ you wish to balance partitions across a consumer group look at the
 The target version by default will be the oldest supported, as it is expected
 that the server will be backwards compatible. In order to support newer client
 features you should increase this version up to the known version of the target
-server.`,
+server.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- kafka_key
+- kafka_topic
+- kafka_partition
+- kafka_offset
+- kafka_timestamp_unix
+- source_id
+- source_offset
+- All existing message headers (version 0.11+)
+` + "```" + `
+
+The fields ` + "`source_id`" + ` and ` + "`source_offset`" + ` identify the topic partition and
+offset of a message, and are used by default to key
+[deduplication](../pipeline.md#deduplication).
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
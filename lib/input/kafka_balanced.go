@@ -21,6 +21,9 @@
 package input
 
 import (
+	"sync"
+	"time"
+
 	"github.com/Jeffail/benthos/lib/input/reader"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
@@ -35,7 +38,38 @@ func init() {
 		description: `
 Connects to a kafka (0.9+) server. Offsets are managed within kafka as per the
 consumer group (set via config), and partitions are automatically balanced
-across any members of the consumer group.`,
+across any members of the consumer group.
+
+By default messages of all assigned partitions are read one at a time, and the
+offset of each message is committed once it has been delivered. When
+` + "`concurrent_partitions`" + ` is set to true each assigned partition is
+instead consumed concurrently, where messages of a partition are delivered in
+order but independently of other partitions. In this mode the offsets of
+delivered messages are tracked per partition and committed every
+` + "`commit_period_ms`" + ` milliseconds, as well as when partitions are
+rebalanced.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- kafka_key
+- kafka_topic
+- kafka_partition
+- kafka_offset
+- kafka_timestamp_unix
+- source_id
+- source_offset
+- All existing message headers (version 0.11+)
+` + "```" + `
+
+The fields ` + "`source_id`" + ` and ` + "`source_offset`" + ` identify the topic partition and
+offset of a message, and are used by default to key
+[deduplication](../pipeline.md#deduplication).
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
 	}
 }
 
@@ -43,6 +77,9 @@ across any members of the consumer group.`,
 
 // NewKafkaBalanced creates a new KafkaBalanced input type.
 func NewKafkaBalanced(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if conf.KafkaBalanced.ConcurrentPartitions {
+		return newKafkaBalancedPartitions(conf.KafkaBalanced, log, stats)
+	}
 	k, err := reader.NewKafkaBalanced(conf.KafkaBalanced, log, stats)
 	if err != nil {
 		return nil, err
@@ -51,3 +88,163 @@ func NewKafkaBalanced(conf Config, mgr types.Manager, log log.Modular, stats met
 }
 
 //------------------------------------------------------------------------------
+
+// partitionConsumer is a consumer group member that provides a reader for each
+// partition assigned to it.
+type partitionConsumer interface {
+	Connect() error
+	NextPartition() (reader.Type, error)
+	CloseAsync()
+}
+
+// kafkaBalancedPartitions is an input type that consumes each partition
+// assigned to a consumer group member with its own reader, and merges the
+// transactions of all partitions.
+type kafkaBalancedPartitions struct {
+	consumer partitionConsumer
+
+	readers    map[int]Type
+	readersMut sync.Mutex
+	readersWG  sync.WaitGroup
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactions chan types.Transaction
+
+	closeOnce  sync.Once
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newKafkaBalancedPartitions(
+	conf reader.KafkaBalancedConfig, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c, err := reader.NewKafkaBalancedPartitions(conf, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return newPartitionsInput(c, log, stats), nil
+}
+
+// newPartitionsInput creates an input that consumes the partitions provided by
+// a consumer group member.
+func newPartitionsInput(
+	c partitionConsumer, log log.Modular, stats metrics.Type,
+) *kafkaBalancedPartitions {
+	k := &kafkaBalancedPartitions{
+		consumer:     c,
+		readers:      map[int]Type{},
+		stats:        stats,
+		log:          log.NewModule(".input.kafka_balanced"),
+		transactions: make(chan types.Transaction),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}
+	go k.loop()
+	return k
+}
+
+//------------------------------------------------------------------------------
+
+// addPartition creates a reader for a partition and forwards its transactions
+// until it is closed.
+func (k *kafkaBalancedPartitions) addPartition(id int, r reader.Type) error {
+	rdr, err := NewReader("kafka_balanced", reader.NewPreserver(r), k.log, k.stats)
+	if err != nil {
+		return err
+	}
+
+	k.readersMut.Lock()
+	k.readers[id] = rdr
+	k.readersMut.Unlock()
+
+	k.readersWG.Add(1)
+	go func() {
+		defer func() {
+			k.readersMut.Lock()
+			delete(k.readers, id)
+			k.readersMut.Unlock()
+			k.readersWG.Done()
+		}()
+		for tran := range rdr.TransactionChan() {
+			select {
+			case k.transactions <- tran:
+			case <-k.closeChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (k *kafkaBalancedPartitions) loop() {
+	defer func() {
+		k.consumer.CloseAsync()
+
+		k.readersMut.Lock()
+		for _, r := range k.readers {
+			r.CloseAsync()
+		}
+		k.readersMut.Unlock()
+		k.readersWG.Wait()
+
+		close(k.transactions)
+		close(k.closedChan)
+	}()
+
+	for id := 0; ; {
+		if err := k.consumer.Connect(); err != nil {
+			if err == types.ErrTypeClosed {
+				return
+			}
+			k.log.Errorf("Failed to connect to kafka_balanced: %v\n", err)
+			select {
+			case <-time.After(time.Second):
+			case <-k.closeChan:
+				return
+			}
+			continue
+		}
+
+		r, err := k.consumer.NextPartition()
+		if err != nil {
+			if err == types.ErrTypeClosed {
+				return
+			}
+			continue
+		}
+
+		if err = k.addPartition(id, r); err != nil {
+			k.log.Errorf("Failed to create partition reader: %v\n", err)
+			r.CloseAsync()
+			continue
+		}
+		id++
+	}
+}
+
+// TransactionChan returns the transactions channel.
+func (k *kafkaBalancedPartitions) TransactionChan() <-chan types.Transaction {
+	return k.transactions
+}
+
+// CloseAsync shuts down the input and stops processing requests.
+func (k *kafkaBalancedPartitions) CloseAsync() {
+	k.closeOnce.Do(func() {
+		close(k.closeChan)
+		k.consumer.CloseAsync()
+	})
+}
+
+// WaitForClose blocks until the input has closed down.
+func (k *kafkaBalancedPartitions) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-k.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
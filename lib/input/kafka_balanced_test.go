@@ -0,0 +1,246 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+type fakePartitionReader struct {
+	msgs chan types.Message
+	acks chan error
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func newFakePartitionReader() *fakePartitionReader {
+	return &fakePartitionReader{
+		msgs:      make(chan types.Message),
+		acks:      make(chan error, 10),
+		closeChan: make(chan struct{}),
+	}
+}
+
+func (f *fakePartitionReader) Connect() error {
+	return nil
+}
+func (f *fakePartitionReader) Read() (types.Message, error) {
+	select {
+	case msg := <-f.msgs:
+		return msg, nil
+	case <-f.closeChan:
+	}
+	return nil, types.ErrTypeClosed
+}
+func (f *fakePartitionReader) Acknowledge(err error) error {
+	f.acks <- err
+	return nil
+}
+func (f *fakePartitionReader) CloseAsync() {
+	f.closeOnce.Do(func() {
+		close(f.closeChan)
+	})
+}
+func (f *fakePartitionReader) WaitForClose(time.Duration) error {
+	return nil
+}
+
+type fakePartitionConsumer struct {
+	partitions chan reader.Type
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func newFakePartitionConsumer() *fakePartitionConsumer {
+	return &fakePartitionConsumer{
+		partitions: make(chan reader.Type),
+		closeChan:  make(chan struct{}),
+	}
+}
+
+func (f *fakePartitionConsumer) Connect() error {
+	select {
+	case <-f.closeChan:
+		return types.ErrTypeClosed
+	default:
+	}
+	return nil
+}
+func (f *fakePartitionConsumer) NextPartition() (reader.Type, error) {
+	select {
+	case r := <-f.partitions:
+		return r, nil
+	case <-f.closeChan:
+	}
+	return nil, types.ErrTypeClosed
+}
+func (f *fakePartitionConsumer) CloseAsync() {
+	f.closeOnce.Do(func() {
+		close(f.closeChan)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+func TestKafkaBalancedPartitionsConcurrent(t *testing.T) {
+	consumer := newFakePartitionConsumer()
+	k := newPartitionsInput(
+		consumer, log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+
+	partitions := []*fakePartitionReader{
+		newFakePartitionReader(),
+		newFakePartitionReader(),
+	}
+	for _, p := range partitions {
+		select {
+		case consumer.partitions <- p:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// Each partition is read concurrently, and therefore a message of one
+	// partition must not block those of another.
+	var trans []types.Transaction
+	for i, p := range partitions {
+		content := []byte{byte('a' + i)}
+		select {
+		case p.msgs <- types.NewMessage([][]byte{content}):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case tran := <-k.TransactionChan():
+			if exp, act := string(content), string(tran.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong message: %v != %v", act, exp)
+			}
+			trans = append(trans, tran)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// A failed message is redelivered from its own partition.
+	select {
+	case trans[0].ResponseChan <- types.NewSimpleResponse(errors.New("failed")):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case tran := <-k.TransactionChan():
+		if exp, act := "a", string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		trans[0] = tran
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	for i, tran := range trans {
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case err := <-partitions[i].acks:
+			if err != nil {
+				t.Errorf("Wrong ack for partition %v: %v", i, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	k.CloseAsync()
+	if err := k.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range partitions {
+		select {
+		case <-p.closeChan:
+		default:
+			t.Errorf("Partition %v was not closed", i)
+		}
+	}
+	if _, open := <-k.TransactionChan(); open {
+		t.Error("Transaction chan not closed")
+	}
+}
+
+func TestKafkaBalancedPartitionsReleased(t *testing.T) {
+	consumer := newFakePartitionConsumer()
+	k := newPartitionsInput(
+		consumer, log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+
+	p := newFakePartitionReader()
+	select {
+	case consumer.partitions <- p:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// A released partition closes its reader, which must not close the input.
+	p.CloseAsync()
+
+	p = newFakePartitionReader()
+	select {
+	case consumer.partitions <- p:
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case p.msgs <- types.NewMessage([][]byte{[]byte("foo")}):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case tran := <-k.TransactionChan():
+		if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		tran.ResponseChan <- types.NewSimpleResponse(nil)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	k.CloseAsync()
+	if err := k.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//------------------------------------------------------------------------------
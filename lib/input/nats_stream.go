@@ -39,7 +39,13 @@ semantics.
 
 Tracking and persisting offsets through a durable name is also optional and
 works with or without a queue. If a durable name is not provided then subjects
-are consumed from the most recently published message.`,
+are consumed from the most recently published message.
+
+Messages are only acknowledged once they have been successfully delivered by
+the outputs of the stream, and are otherwise redelivered by the server once
+` + "`ack_wait_ms`" + ` has elapsed. The maximum number of unacknowledged
+messages that the server sends to this input at any given time is set with
+` + "`max_inflight`" + `.`,
 	}
 }
 
@@ -33,7 +33,30 @@ func init() {
 	Constructors["nsq"] = TypeSpec{
 		constructor: NewNSQ,
 		description: `
-Subscribe to an NSQ instance topic and channel.`,
+Subscribe to an NSQ instance topic and channel. When ` + "`ephemeral`" + ` is
+true the channel is suffixed with ` + "`#ephemeral`" + `, and is therefore
+removed by NSQ once the last client disconnects.
+
+Messages that fail to be delivered are requeued with a delay calculated from
+` + "`requeue_delay_ms`" + ` according to the ` + "`requeue_strategy`" + `,
+which can be ` + "`fixed`" + `, ` + "`linear`" + ` (multiplied by the number of
+attempts) or ` + "`exponential`" + ` (doubled with each attempt), and is capped
+at ` + "`max_requeue_delay_ms`" + `. Messages that exceed
+` + "`max_attempts`" + ` are discarded by the client, setting it to zero allows
+unlimited attempts.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- nsq_attempts
+- nsq_timestamp_unix
+- nsq_timestamp_unix_nano
+` + "```" + `
+
+The number of attempts can be used to route poison messages elsewhere before
+they are discarded.`,
 	}
 }
 
@@ -46,7 +46,34 @@ define inputs where the stream should end once a certain message appears.
 Sometimes inputs close themselves. For example, when the ` + "`file`" + ` input
 type reaches the end of a file it will shut down. By default this type will also
 shut down. If you wish for the input type to be restarted every time it shuts
-down until the condition is met then set ` + "`restart_input` to `true`.",
+down until the condition is met then set ` + "`restart_input` to `true`." + `
+
+When this input closes the stream it belongs to shuts down cleanly once all
+pending messages have been delivered, which makes it suitable for batch jobs
+that are driven by stream data.
+
+For example, in order to read until a message with the content ` + "`END`" + `
+is found the following condition can be used:
+
+` + "``` yaml" + `
+condition:
+  type: content
+  content:
+    operator: equals_cs
+    arg: END
+` + "```" + `
+
+And in order to read a fixed number of messages (1000 here) the ` + "`count`" + `
+condition can be used inverted, as it returns true until its target is reached:
+
+` + "``` yaml" + `
+condition:
+  type: not
+  not:
+    type: count
+    count:
+      arg: 1000
+` + "```" + ``,
 	}
 }
 
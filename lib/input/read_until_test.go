@@ -72,6 +72,9 @@ baz`)
 	t.Run("ReadUntilInputCloseRestart", func(te *testing.T) {
 		testReadUntilInputCloseRestart(inconf, te)
 	})
+	t.Run("ReadUntilCount", func(te *testing.T) {
+		testReadUntilCount(inconf, te)
+	})
 }
 
 func testReadUntilBasic(inConf Config, t *testing.T) {
@@ -258,6 +261,68 @@ func testReadUntilEarlyClose(inConf Config, t *testing.T) {
 	}
 }
 
+func testReadUntilCount(inConf Config, t *testing.T) {
+	countCond := condition.NewConfig()
+	countCond.Type = "count"
+	countCond.Count.Arg = 2
+
+	cond := condition.NewConfig()
+	cond.Type = "not"
+	cond.Not.Config = &countCond
+
+	rConf := NewConfig()
+	rConf.Type = "read_until"
+	rConf.ReadUntil.Input = &inConf
+	rConf.ReadUntil.Condition = cond
+
+	in, err := New(rConf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expMsgs := []string{
+		"foo",
+		"bar",
+	}
+
+	for _, exp := range expMsgs {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-in.TransactionChan():
+			if !open {
+				t.Fatal("transaction chan closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		if act := string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message contents: %v != %v", act, exp)
+		}
+
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// Should close automatically now
+	select {
+	case _, open := <-in.TransactionChan():
+		if open {
+			t.Fatal("transaction chan not closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err = in.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func testReadUntilInputClose(inConf Config, t *testing.T) {
 	cond := condition.NewConfig()
 	cond.Type = "content"
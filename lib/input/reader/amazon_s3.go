@@ -22,6 +22,7 @@ package reader
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
@@ -173,15 +174,17 @@ func (a *AmazonS3) Connect() error {
 		if len(a.conf.Prefix) > 0 {
 			listInput.Prefix = aws.String(a.conf.Prefix)
 		}
-		objList, err := sThree.ListObjects(listInput)
-		if err != nil {
+		if err := sThree.ListObjectsPages(listInput, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+			for _, obj := range page.Contents {
+				a.targetKeys = append(a.targetKeys, objKey{
+					s3Key: *obj.Key,
+				})
+			}
+			return true
+		}); err != nil {
+			a.targetKeys = nil
 			return fmt.Errorf("failed to list objects: %v", err)
 		}
-		for _, obj := range objList.Contents {
-			a.targetKeys = append(a.targetKeys, objKey{
-				s3Key: *obj.Key,
-			})
-		}
 	} else {
 		a.sqs = sqs.New(sess)
 	}
@@ -242,6 +245,7 @@ messageLoop:
 
 		switch t := gObj.S(a.sqsBodyPath...).Data().(type) {
 		case string:
+			t = decodeS3Key(t)
 			if strings.HasPrefix(t, a.conf.Prefix) {
 				a.targetKeys = append(a.targetKeys, objKey{
 					s3Key:     t,
@@ -252,7 +256,7 @@ messageLoop:
 			newTargets := []string{}
 			for _, jStr := range t {
 				if p, ok := jStr.(string); ok {
-					if strings.HasPrefix(p, a.conf.Prefix) {
+					if p = decodeS3Key(p); strings.HasPrefix(p, a.conf.Prefix) {
 						newTargets = append(newTargets, p)
 					}
 				}
@@ -271,11 +275,22 @@ messageLoop:
 	}
 
 	// Discard any SQS messages not associated with a target file.
-	a.sqs.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
-		QueueUrl: aws.String(a.conf.SQSURL),
-		Entries:  dudMessageHandles,
-	})
-	return types.ErrTimeout
+	if len(dudMessageHandles) > 0 {
+		a.sqs.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(a.conf.SQSURL),
+			Entries:  dudMessageHandles,
+		})
+	}
+	return nil
+}
+
+// decodeS3Key decodes an object key from an S3 event notification, where keys
+// are URL encoded.
+func decodeS3Key(key string) string {
+	if decoded, err := url.QueryUnescape(key); err == nil {
+		return decoded
+	}
+	return key
 }
 
 // Read attempts to read a new message from the target S3 bucket.
@@ -317,7 +332,11 @@ func (a *AmazonS3) Read() (types.Message, error) {
 	}
 	a.readKeys = append(a.readKeys, target)
 
-	return types.NewMessage([][]byte{buff.Bytes()}), nil
+	msg := types.NewMessage([][]byte{buff.Bytes()})
+	msg.GetMetadata(0).
+		Set("s3_bucket", a.conf.Bucket).
+		Set("s3_key", target.s3Key)
+	return msg, nil
 }
 
 // Acknowledge confirms whether or not our unacknowledged messages have been
@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -41,6 +42,7 @@ type AmazonSQSConfig struct {
 	URL         string                     `json:"url" yaml:"url"`
 	Credentials AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
 	TimeoutS    int64                      `json:"timeout_s" yaml:"timeout_s"`
+	MaxMessages int64                      `json:"max_number_of_messages" yaml:"max_number_of_messages"`
 }
 
 // NewAmazonSQSConfig creates a new Config with default values.
@@ -54,7 +56,8 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 			Token:  "",
 			Role:   "",
 		},
-		TimeoutS: 5,
+		TimeoutS:    5,
+		MaxMessages: 1,
 	}
 }
 
@@ -130,9 +133,10 @@ func (a *AmazonSQS) Read() (types.Message, error) {
 	}
 
 	output, err := a.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
-		QueueUrl:            aws.String(a.conf.URL),
-		MaxNumberOfMessages: aws.Int64(1),
-		WaitTimeSeconds:     aws.Int64(a.conf.TimeoutS),
+		QueueUrl:              aws.String(a.conf.URL),
+		MaxNumberOfMessages:   aws.Int64(a.conf.MaxMessages),
+		WaitTimeSeconds:       aws.Int64(a.conf.TimeoutS),
+		MessageAttributeNames: []*string{aws.String("All")},
 	})
 	if err != nil {
 		return nil, err
@@ -153,7 +157,8 @@ func (a *AmazonSQS) Read() (types.Message, error) {
 		}
 
 		if sqsMsg.Body != nil {
-			msg.Append([]byte(*sqsMsg.Body))
+			index := msg.Append([]byte(*sqsMsg.Body))
+			setSQSMetadata(msg.GetMetadata(index), sqsMsg)
 		}
 	}
 
@@ -164,14 +169,46 @@ func (a *AmazonSQS) Read() (types.Message, error) {
 	return msg, nil
 }
 
+// setSQSMetadata populates the metadata of a message part with the ID and the
+// string message attributes of an SQS message.
+func setSQSMetadata(meta types.Metadata, sqsMsg *sqs.Message) {
+	if sqsMsg.MessageId != nil {
+		meta.Set("sqs_message_id", *sqsMsg.MessageId)
+	}
+	for k, v := range sqsMsg.MessageAttributes {
+		if v.StringValue != nil {
+			meta.Set(k, *v.StringValue)
+		}
+	}
+}
+
 // Acknowledge confirms whether or not our unacknowledged messages have been
-// successfully propagated or not.
+// successfully propagated or not. Messages are only deleted from the queue
+// once they have been propagated, otherwise they become visible to consumers
+// again once their visibility timeout has elapsed.
 func (a *AmazonSQS) Acknowledge(err error) error {
-	if _, err := a.sqs.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
-		QueueUrl: aws.String(a.conf.URL),
-		Entries:  a.pendingHandles,
-	}); err != nil {
-		return err
+	if err != nil {
+		a.pendingHandles = nil
+		return nil
+	}
+
+	// The SQS API limits batch deletes to ten entries.
+	for len(a.pendingHandles) > 0 {
+		entries := a.pendingHandles
+		if len(entries) > 10 {
+			entries = entries[:10]
+		}
+		res, err := a.sqs.DeleteMessageBatch(&sqs.DeleteMessageBatchInput{
+			QueueUrl: aws.String(a.conf.URL),
+			Entries:  entries,
+		})
+		if err != nil {
+			return err
+		}
+		a.pendingHandles = a.pendingHandles[len(entries):]
+		if nFailed := len(res.Failed); nFailed > 0 {
+			return fmt.Errorf("failed to delete %v messages", nFailed)
+		}
 	}
 
 	a.pendingHandles = nil
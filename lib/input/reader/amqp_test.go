@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sync"
@@ -74,6 +75,9 @@ func TestAMQPIntegration(t *testing.T) {
 	t.Run("TestAMQPDisconnect", func(te *testing.T) {
 		testAMQPDisconnect(url, te)
 	})
+	t.Run("TestAMQPNack", func(te *testing.T) {
+		testAMQPNack(url, te)
+	})
 }
 
 func testAMQPConnect(url string, t *testing.T) {
@@ -186,3 +190,71 @@ func testAMQPDisconnect(url string, t *testing.T) {
 
 	wg.Wait()
 }
+
+func testAMQPNack(url string, t *testing.T) {
+	conf := NewAMQPConfig()
+	conf.URL = url
+	conf.Queue = "benthos-nack-queue"
+	conf.BindingKey = "benthos-nack-key"
+
+	m, err := NewAMQP(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		m.CloseAsync()
+		if cErr := m.WaitForClose(time.Second); cErr != nil {
+			t.Error(cErr)
+		}
+	}()
+
+	var mIn *amqp.Connection
+	if mIn, err = amqp.Dial(url); err != nil {
+		t.Fatal(err)
+	}
+
+	var mChan *amqp.Channel
+	if mChan, err = mIn.Channel(); err != nil {
+		t.Fatalf("AMQP Channel: %s", err)
+	}
+
+	defer func() {
+		mChan.Close()
+		mIn.Close()
+	}()
+
+	if err = mChan.Publish(conf.Exchange, conf.BindingKey, false, false, amqp.Publishing{
+		Body: []byte("hello world"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var msg types.Message
+	if msg, err = m.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "false", msg.GetMetadata(0).Get("amqp_redelivered"); exp != act {
+		t.Errorf("Wrong redelivered flag: %v != %v", act, exp)
+	}
+	if err = m.Acknowledge(errors.New("nope")); err != nil {
+		t.Error(err)
+	}
+
+	if msg, err = m.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "hello world", string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+	if exp, act := "true", msg.GetMetadata(0).Get("amqp_redelivered"); exp != act {
+		t.Errorf("Wrong redelivered flag: %v != %v", act, exp)
+	}
+	if err = m.Acknowledge(nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	eventhub "github.com/Azure/azure-event-hubs-go/v3"
+	"github.com/Azure/azure-event-hubs-go/v3/persist"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubsConfig contains configuration values for the input type.
+type AzureEventHubsConfig struct {
+	ConnectionString string   `json:"connection_string" yaml:"connection_string"`
+	ConsumerGroup    string   `json:"consumer_group" yaml:"consumer_group"`
+	PartitionIDs     []string `json:"partition_ids" yaml:"partition_ids"`
+	CheckpointDir    string   `json:"checkpoint_dir" yaml:"checkpoint_dir"`
+	StartFromOldest  bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
+}
+
+// NewAzureEventHubsConfig creates a new Config with default values.
+func NewAzureEventHubsConfig() AzureEventHubsConfig {
+	return AzureEventHubsConfig{
+		ConnectionString: "",
+		ConsumerGroup:    eventhub.DefaultConsumerGroup,
+		PartitionIDs:     []string{},
+		CheckpointDir:    "",
+		StartFromOldest:  true,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// azureEventHubsPending is an event that has been received from a partition and
+// is awaiting acknowledgement.
+type azureEventHubsPending struct {
+	partitionID string
+	event       *eventhub.Event
+	resChan     chan error
+}
+
+// AzureEventHubs is a benthos reader.Type implementation that reads events
+// from the partitions of an Azure Event Hub.
+type AzureEventHubs struct {
+	conf AzureEventHubsConfig
+
+	hub       *eventhub.Hub
+	eventChan chan azureEventHubsPending
+	lostChan  chan struct{}
+	connCtx   context.Context
+	closeFunc context.CancelFunc
+	closed    bool
+	cMut      sync.Mutex
+
+	unAckEvent *azureEventHubsPending
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureEventHubs creates a new Azure Event Hubs reader.Type.
+func NewAzureEventHubs(
+	conf AzureEventHubsConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AzureEventHubs, error) {
+	if len(conf.ConnectionString) == 0 {
+		return nil, errors.New("a connection_string must be specified")
+	}
+	return &AzureEventHubs{
+		conf:  conf,
+		log:   log.NewModule(".input.azure_event_hubs"),
+		stats: stats,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// handler returns an event handler that passes events to the reader and only
+// returns once they are acknowledged. Events are checkpointed when the handler
+// returns without an error, and therefore the offset of a partition is only
+// committed once its event has been delivered.
+func (a *AzureEventHubs) handler(
+	partitionID string,
+	eventChan chan<- azureEventHubsPending,
+	connCtx context.Context,
+) eventhub.Handler {
+	return func(ctx context.Context, event *eventhub.Event) error {
+		resChan := make(chan error, 1)
+		select {
+		case eventChan <- azureEventHubsPending{
+			partitionID: partitionID,
+			event:       event,
+			resChan:     resChan,
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-connCtx.Done():
+			return connCtx.Err()
+		}
+		select {
+		case err := <-resChan:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-connCtx.Done():
+			return connCtx.Err()
+		}
+	}
+}
+
+// Connect attempts to establish a connection to the partitions of the target
+// event hub.
+func (a *AzureEventHubs) Connect() error {
+	a.cMut.Lock()
+	defer a.cMut.Unlock()
+
+	if a.closed {
+		return types.ErrTypeClosed
+	}
+	if a.hub != nil {
+		return nil
+	}
+
+	var persister persist.CheckpointPersister = persist.NewMemoryPersister()
+	if len(a.conf.CheckpointDir) > 0 {
+		var err error
+		if persister, err = persist.NewFilePersister(a.conf.CheckpointDir); err != nil {
+			return err
+		}
+	}
+
+	hub, err := eventhub.NewHubFromConnectionString(
+		a.conf.ConnectionString, eventhub.HubWithOffsetPersistence(persister),
+	)
+	if err != nil {
+		return err
+	}
+
+	partitionIDs := a.conf.PartitionIDs
+	if len(partitionIDs) == 0 {
+		info, err := hub.GetRuntimeInformation(context.Background())
+		if err != nil {
+			hub.Close(context.Background())
+			return err
+		}
+		partitionIDs = info.PartitionIDs
+	}
+
+	opts := []eventhub.ReceiveOption{
+		eventhub.ReceiveWithConsumerGroup(a.conf.ConsumerGroup),
+	}
+	// A starting offset is only used when a partition has no checkpoint.
+	if !a.conf.StartFromOldest {
+		opts = append(opts, eventhub.ReceiveWithLatestOffset())
+	}
+
+	connCtx, cancel := context.WithCancel(context.Background())
+	eventChan := make(chan azureEventHubsPending)
+	lostChan := make(chan struct{})
+	var lostOnce sync.Once
+
+	for _, id := range partitionIDs {
+		handle, err := hub.Receive(
+			context.Background(), id, a.handler(id, eventChan, connCtx), opts...,
+		)
+		if err != nil {
+			cancel()
+			hub.Close(context.Background())
+			return err
+		}
+		go func(id string) {
+			select {
+			case <-handle.Done():
+				if connCtx.Err() == nil {
+					a.log.Errorf("Lost receiver of partition '%v': %v\n", id, handle.Err())
+					lostOnce.Do(func() {
+						close(lostChan)
+					})
+				}
+			case <-connCtx.Done():
+			}
+		}(id)
+	}
+
+	a.hub = hub
+	a.eventChan = eventChan
+	a.lostChan = lostChan
+	a.connCtx = connCtx
+	a.closeFunc = cancel
+
+	a.log.Infof("Receiving Azure Event Hubs events from partitions %v\n", partitionIDs)
+	return nil
+}
+
+// disconnect cancels all pending handlers and closes the hub.
+func (a *AzureEventHubs) disconnect() {
+	if a.hub == nil {
+		return
+	}
+	a.closeFunc()
+	a.hub.Close(context.Background())
+	a.hub = nil
+	a.eventChan = nil
+	a.lostChan = nil
+	a.connCtx = nil
+	a.closeFunc = nil
+	a.unAckEvent = nil
+}
+
+// Read attempts to read a new event from the target event hub.
+func (a *AzureEventHubs) Read() (types.Message, error) {
+	a.cMut.Lock()
+	eventChan, lostChan, connCtx, closed := a.eventChan, a.lostChan, a.connCtx, a.closed
+	a.cMut.Unlock()
+
+	if closed {
+		return nil, types.ErrTypeClosed
+	}
+	if eventChan == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	var pending azureEventHubsPending
+	select {
+	case pending = <-eventChan:
+	case <-lostChan:
+		a.cMut.Lock()
+		a.disconnect()
+		a.cMut.Unlock()
+		return nil, types.ErrNotConnected
+	case <-connCtx.Done():
+		return nil, types.ErrNotConnected
+	}
+
+	a.cMut.Lock()
+	a.unAckEvent = &pending
+	a.cMut.Unlock()
+
+	msg := types.NewMessage([][]byte{pending.event.Data})
+	meta := msg.GetMetadata(0)
+	for k, v := range pending.event.Properties {
+		meta.Set(k, fmt.Sprintf("%v", v))
+	}
+	meta.Set("eventhub_partition_id", pending.partitionID)
+	if sp := pending.event.SystemProperties; sp != nil {
+		if sp.Offset != nil {
+			meta.Set("eventhub_offset", strconv.FormatInt(*sp.Offset, 10))
+		}
+		if sp.SequenceNumber != nil {
+			meta.Set("eventhub_sequence_number", strconv.FormatInt(*sp.SequenceNumber, 10))
+		}
+		if sp.PartitionKey != nil {
+			meta.Set("eventhub_partition_key", *sp.PartitionKey)
+		}
+	}
+	return msg, nil
+}
+
+// Acknowledge releases the handler of the last read event, which checkpoints
+// its offset when the event was successfully propagated.
+func (a *AzureEventHubs) Acknowledge(err error) error {
+	a.cMut.Lock()
+	pending := a.unAckEvent
+	a.unAckEvent = nil
+	a.cMut.Unlock()
+
+	if pending != nil {
+		pending.resChan <- err
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (a *AzureEventHubs) CloseAsync() {
+	go func() {
+		a.cMut.Lock()
+		a.closed = true
+		a.disconnect()
+		a.cMut.Unlock()
+	}()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (a *AzureEventHubs) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,261 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBusConfig contains configuration values for the input type.
+type AzureServiceBusConfig struct {
+	ConnectionString string `json:"connection_string" yaml:"connection_string"`
+	Queue            string `json:"queue" yaml:"queue"`
+	Topic            string `json:"topic" yaml:"topic"`
+	Subscription     string `json:"subscription" yaml:"subscription"`
+	UseSessions      bool   `json:"use_sessions" yaml:"use_sessions"`
+	SessionID        string `json:"session_id" yaml:"session_id"`
+}
+
+// NewAzureServiceBusConfig creates a new Config with default values.
+func NewAzureServiceBusConfig() AzureServiceBusConfig {
+	return AzureServiceBusConfig{
+		ConnectionString: "",
+		Queue:            "",
+		Topic:            "",
+		Subscription:     "",
+		UseSessions:      false,
+		SessionID:        "",
+	}
+}
+
+// azureServiceBusEntityPath returns the path of the queue or subscription to
+// receive messages from.
+func azureServiceBusEntityPath(conf AzureServiceBusConfig) (string, error) {
+	if len(conf.Queue) > 0 {
+		if len(conf.Topic) > 0 || len(conf.Subscription) > 0 {
+			return "", errors.New("a queue cannot be specified alongside a topic or subscription")
+		}
+		return conf.Queue, nil
+	}
+	if len(conf.Topic) == 0 || len(conf.Subscription) == 0 {
+		return "", errors.New("either a queue or a topic and subscription must be specified")
+	}
+	return conf.Topic + "/Subscriptions/" + conf.Subscription, nil
+}
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBus is a benthos reader.Type implementation that reads messages
+// from an Azure Service Bus queue or topic subscription.
+type AzureServiceBus struct {
+	conf       AzureServiceBusConfig
+	entityPath string
+
+	receiver  *servicebus.Receiver
+	closeCtx  context.Context
+	closeFunc context.CancelFunc
+	rMut      sync.Mutex
+
+	unAckMsg *servicebus.Message
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureServiceBus creates a new Azure Service Bus reader.Type.
+func NewAzureServiceBus(
+	conf AzureServiceBusConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AzureServiceBus, error) {
+	if len(conf.ConnectionString) == 0 {
+		return nil, errors.New("a connection_string must be specified")
+	}
+	if len(conf.SessionID) > 0 && !conf.UseSessions {
+		return nil, errors.New("a session_id requires use_sessions to be enabled")
+	}
+	entityPath, err := azureServiceBusEntityPath(conf)
+	if err != nil {
+		return nil, err
+	}
+	closeCtx, closeFunc := context.WithCancel(context.Background())
+	return &AzureServiceBus{
+		conf:       conf,
+		entityPath: entityPath,
+		closeCtx:   closeCtx,
+		closeFunc:  closeFunc,
+		log:        log.NewModule(".input.azure_service_bus"),
+		stats:      stats,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to the target queue or
+// subscription.
+func (a *AzureServiceBus) Connect() error {
+	a.rMut.Lock()
+	defer a.rMut.Unlock()
+
+	if a.closeCtx.Err() != nil {
+		return types.ErrTypeClosed
+	}
+	if a.receiver != nil {
+		return nil
+	}
+
+	ns, err := servicebus.NewNamespace(
+		servicebus.NamespaceWithConnectionString(a.conf.ConnectionString),
+	)
+	if err != nil {
+		return err
+	}
+
+	var opts []servicebus.ReceiverOption
+	if a.conf.UseSessions {
+		// A nil session ID accepts the next available session.
+		var sessionID *string
+		if len(a.conf.SessionID) > 0 {
+			sessionID = &a.conf.SessionID
+		}
+		opts = append(opts, servicebus.ReceiverWithSession(sessionID))
+	}
+
+	receiver, err := ns.NewReceiver(a.closeCtx, a.entityPath, opts...)
+	if err != nil {
+		return err
+	}
+
+	a.receiver = receiver
+	a.log.Infof("Receiving Azure Service Bus messages from '%v'\n", a.entityPath)
+	return nil
+}
+
+// disconnect closes the receiver, which abandons the lock of any message that
+// has not been acknowledged.
+func (a *AzureServiceBus) disconnect() {
+	if a.receiver == nil {
+		return
+	}
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	a.receiver.Close(ctx)
+	done()
+	a.receiver = nil
+	a.unAckMsg = nil
+}
+
+// Read attempts to read a new message from the target queue or subscription.
+func (a *AzureServiceBus) Read() (types.Message, error) {
+	a.rMut.Lock()
+	receiver := a.receiver
+	a.rMut.Unlock()
+
+	if receiver == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	var sbMsg *servicebus.Message
+	err := receiver.ReceiveOne(a.closeCtx, servicebus.HandlerFunc(
+		func(ctx context.Context, m *servicebus.Message) error {
+			sbMsg = m
+			return nil
+		},
+	))
+	if err != nil || sbMsg == nil {
+		if a.closeCtx.Err() != nil {
+			return nil, types.ErrTypeClosed
+		}
+		a.log.Errorf("Failed to receive message: %v\n", err)
+		a.rMut.Lock()
+		a.disconnect()
+		a.rMut.Unlock()
+		return nil, types.ErrNotConnected
+	}
+
+	a.rMut.Lock()
+	a.unAckMsg = sbMsg
+	a.rMut.Unlock()
+
+	msg := types.NewMessage([][]byte{sbMsg.Data})
+	meta := msg.GetMetadata(0)
+	for k, v := range sbMsg.UserProperties {
+		meta.Set(k, fmt.Sprintf("%v", v))
+	}
+	meta.Set("servicebus_message_id", sbMsg.ID)
+	meta.Set("servicebus_delivery_count", strconv.FormatUint(uint64(sbMsg.DeliveryCount), 10))
+	if sbMsg.SessionID != nil {
+		meta.Set("servicebus_session_id", *sbMsg.SessionID)
+	}
+	if sp := sbMsg.SystemProperties; sp != nil && sp.SequenceNumber != nil {
+		meta.Set("servicebus_sequence_number", strconv.FormatInt(*sp.SequenceNumber, 10))
+	}
+	return msg, nil
+}
+
+// Acknowledge completes the last read message if it was successfully
+// propagated, and otherwise abandons it so that it is redelivered.
+func (a *AzureServiceBus) Acknowledge(err error) error {
+	a.rMut.Lock()
+	sbMsg := a.unAckMsg
+	a.unAckMsg = nil
+	a.rMut.Unlock()
+
+	if sbMsg == nil {
+		return nil
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	if err != nil {
+		return sbMsg.Abandon(ctx)
+	}
+	return sbMsg.Complete(ctx)
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (a *AzureServiceBus) CloseAsync() {
+	a.closeFunc()
+	go func() {
+		a.rMut.Lock()
+		a.disconnect()
+		a.rMut.Unlock()
+	}()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (a *AzureServiceBus) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import "testing"
+
+//------------------------------------------------------------------------------
+
+func TestAzureServiceBusEntityPath(t *testing.T) {
+	type testCase struct {
+		queue, topic, subscription string
+		path                       string
+		err                        bool
+	}
+
+	tests := []testCase{
+		{queue: "foo", path: "foo"},
+		{topic: "foo", subscription: "bar", path: "foo/Subscriptions/bar"},
+		{err: true},
+		{topic: "foo", err: true},
+		{subscription: "bar", err: true},
+		{queue: "foo", topic: "bar", subscription: "baz", err: true},
+	}
+
+	for i, test := range tests {
+		conf := NewAzureServiceBusConfig()
+		conf.Queue = test.queue
+		conf.Topic = test.topic
+		conf.Subscription = test.subscription
+
+		path, err := azureServiceBusEntityPath(conf)
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected error from test %v", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error from test %v: %v", i, err)
+		}
+		if exp, act := test.path, path; exp != act {
+			t.Errorf("Wrong path from test %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
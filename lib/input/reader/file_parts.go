@@ -0,0 +1,226 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+//------------------------------------------------------------------------------
+
+// CSVConfig contains configuration fields for the csv codec of file inputs.
+type CSVConfig struct {
+	Delimiter string   `json:"delimiter" yaml:"delimiter"`
+	Columns   []string `json:"columns" yaml:"columns"`
+}
+
+// NewCSVConfig creates a new CSVConfig with default values.
+func NewCSVConfig() CSVConfig {
+	return CSVConfig{
+		Delimiter: ",",
+		Columns:   []string{},
+	}
+}
+
+// csvDelimiter returns the field delimiter of a CSVConfig, which must be a
+// single character.
+func csvDelimiter(conf CSVConfig) (rune, error) {
+	if utf8.RuneCountInString(conf.Delimiter) != 1 {
+		return 0, fmt.Errorf("csv delimiter must be a single character, got: '%v'", conf.Delimiter)
+	}
+	r, _ := utf8.DecodeRuneInString(conf.Delimiter)
+	return r, nil
+}
+
+// validateCodec returns an error if a codec is not recognised or its
+// configuration is invalid.
+func validateCodec(codec string, csvConf CSVConfig) error {
+	switch codec {
+	case "all-bytes", "lines":
+	case "csv":
+		if _, err := csvDelimiter(csvConf); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("codec not recognised: %v", codec)
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// fileParts reads the contents of a file as message parts according to a
+// codec, which is either all-bytes, where the whole file is a single part,
+// lines, where each non-empty line of the file is a part, or csv, where each
+// row of the file is a part converted into a JSON object.
+//
+// Parts are read ahead by one so that it is known whether the last part of the
+// file has been read, which allows a file to be acknowledged as a whole once
+// its final part is delivered. An error reading ahead is returned by the call
+// following the delivery of the part before it.
+type fileParts struct {
+	handle    io.ReadCloser
+	scan      func() ([]byte, error)
+	nextPart  []byte
+	nextErr   error
+	exhausted bool
+}
+
+func newFileParts(
+	handle io.ReadCloser,
+	codec string,
+	delim []byte,
+	maxBuffer int,
+	csvConf CSVConfig,
+) *fileParts {
+	p := &fileParts{
+		handle: handle,
+	}
+	switch codec {
+	case "lines":
+		p.scan = newLineScanner(handle, delim, maxBuffer)
+	case "csv":
+		p.scan = newCSVScanner(handle, csvConf)
+	}
+	return p
+}
+
+// newLineScanner returns a function that reads the next non-empty line of a
+// file.
+func newLineScanner(handle io.Reader, delim []byte, maxBuffer int) func() ([]byte, error) {
+	scanner := bufio.NewScanner(handle)
+	scanner.Buffer([]byte{}, maxBuffer)
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[0:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	return func() ([]byte, error) {
+		for scanner.Scan() {
+			if len(scanner.Bytes()) > 0 {
+				line := make([]byte, len(scanner.Bytes()))
+				copy(line, scanner.Bytes())
+				return line, nil
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+}
+
+// newCSVScanner returns a function that reads the next row of a CSV file as a
+// JSON object, where the keys are taken from the configured columns or
+// otherwise the first row of the file.
+func newCSVScanner(handle io.Reader, conf CSVConfig) func() ([]byte, error) {
+	r := csv.NewReader(handle)
+	r.Comma, _ = csvDelimiter(conf)
+
+	var columns []string
+	if len(conf.Columns) > 0 {
+		columns = conf.Columns
+		r.FieldsPerRecord = len(columns)
+	}
+	return func() ([]byte, error) {
+		if columns == nil {
+			header, err := r.Read()
+			if err != nil {
+				return nil, err
+			}
+			columns = header
+		}
+		record, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		obj := make(map[string]string, len(columns))
+		for i, col := range columns {
+			obj[col] = record[i]
+		}
+		return json.Marshal(obj)
+	}
+}
+
+// Next reads the next part of the file, returning a nil part if the file
+// contains no parts.
+func (p *fileParts) Next() ([]byte, error) {
+	if p.scan == nil {
+		b, err := ioutil.ReadAll(p.handle)
+		if err != nil {
+			return nil, err
+		}
+		p.exhausted = true
+		return b, nil
+	}
+
+	if err := p.nextErr; err != nil {
+		p.nextErr = nil
+		return nil, err
+	}
+
+	part := p.nextPart
+	if part == nil {
+		var err error
+		if part, err = p.scan(); err != nil {
+			if err == io.EOF {
+				p.exhausted = true
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	next, err := p.scan()
+	if err != nil && err != io.EOF {
+		p.nextPart, p.nextErr = nil, err
+		return part, nil
+	}
+	p.nextPart = next
+	p.exhausted = err == io.EOF
+	return part, nil
+}
+
+// Exhausted returns true once the last part of the file has been read.
+func (p *fileParts) Exhausted() bool {
+	return p.exhausted
+}
+
+// Close closes the file.
+func (p *fileParts) Close() error {
+	return p.handle.Close()
+}
+
+//------------------------------------------------------------------------------
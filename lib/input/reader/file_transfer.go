@@ -0,0 +1,379 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
+)
+
+//------------------------------------------------------------------------------
+
+// FileTransferConfig is configuration for the FileTransfer input type.
+type FileTransferConfig struct {
+	URL               string      `json:"url" yaml:"url"`
+	Glob              string      `json:"glob" yaml:"glob"`
+	Codec             string      `json:"codec" yaml:"codec"`
+	Delimiter         string      `json:"delimiter" yaml:"delimiter"`
+	CSV               CSVConfig   `json:"csv" yaml:"csv"`
+	MaxBuffer         int         `json:"max_buffer" yaml:"max_buffer"`
+	PollIntervalMS    int64       `json:"poll_interval_ms" yaml:"poll_interval_ms"`
+	DeleteFiles       bool        `json:"delete_files" yaml:"delete_files"`
+	MoveToDir         string      `json:"move_to_dir" yaml:"move_to_dir"`
+	KeyFile           string      `json:"key_file" yaml:"key_file"`
+	KnownHostsFile    string      `json:"known_hosts_file" yaml:"known_hosts_file"`
+	SkipHostKeyVerify bool        `json:"skip_host_key_verify" yaml:"skip_host_key_verify"`
+	TLS               btls.Config `json:"tls" yaml:"tls"`
+	TimeoutMS         int64       `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewFileTransferConfig creates a new FileTransferConfig with default values.
+func NewFileTransferConfig() FileTransferConfig {
+	return FileTransferConfig{
+		URL:               "sftp://localhost:22",
+		Glob:              "/*",
+		Codec:             "all-bytes",
+		Delimiter:         "",
+		CSV:               NewCSVConfig(),
+		MaxBuffer:         1000000,
+		PollIntervalMS:    10000,
+		DeleteFiles:       false,
+		MoveToDir:         "",
+		KeyFile:           "",
+		KnownHostsFile:    "",
+		SkipHostKeyVerify: false,
+		TLS:               btls.NewConfig(),
+		TimeoutMS:         5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// remoteFile is a file listed on a file transfer server.
+type remoteFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// fileTransferClient is a client of a file transfer server.
+type fileTransferClient interface {
+	// List returns the regular files within a directory.
+	List(dir string) ([]remoteFile, error)
+
+	// Open opens a file for reading.
+	Open(path string) (io.ReadCloser, error)
+
+	// Rename moves a file.
+	Rename(from, to string) error
+
+	// Remove deletes a file.
+	Remove(path string) error
+
+	// Close closes the connection to the server.
+	Close() error
+}
+
+//------------------------------------------------------------------------------
+
+// FileTransfer is an input type that polls an SFTP, FTP or FTPS server for
+// files.
+type FileTransfer struct {
+	conf      FileTransferConfig
+	url       *url.URL
+	dir       string
+	pattern   string
+	delimiter []byte
+	interval  time.Duration
+
+	dial func() (fileTransferClient, error)
+
+	client   fileTransferClient
+	lastPoll time.Time
+	queue    []remoteFile
+	consumed map[string]remoteFile
+
+	current *remoteFile
+	parts   *fileParts
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewFileTransfer creates a new FileTransfer input type.
+func NewFileTransfer(
+	conf FileTransferConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*FileTransfer, error) {
+	u, err := url.Parse(conf.URL)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateCodec(conf.Codec, conf.CSV); err != nil {
+		return nil, err
+	}
+	if strings.ContainsAny(path.Dir(conf.Glob), "*?[") {
+		return nil, errors.New("wildcards are only supported in the file name of the glob")
+	}
+	if _, err = path.Match(conf.Glob, ""); err != nil {
+		return nil, err
+	}
+	delim := conf.Delimiter
+	if len(delim) == 0 {
+		delim = "\n"
+	}
+
+	f := &FileTransfer{
+		conf:      conf,
+		url:       u,
+		dir:       path.Dir(conf.Glob),
+		pattern:   path.Base(conf.Glob),
+		delimiter: []byte(delim),
+		interval:  time.Millisecond * time.Duration(conf.PollIntervalMS),
+		consumed:  map[string]remoteFile{},
+		closeChan: make(chan struct{}),
+		log:       log.NewModule(".input.file_transfer"),
+		stats:     stats,
+	}
+
+	switch u.Scheme {
+	case "sftp":
+		if len(conf.KnownHostsFile) == 0 && !conf.SkipHostKeyVerify {
+			return nil, errors.New("sftp requires a known_hosts_file unless skip_host_key_verify is set")
+		}
+		f.dial = f.dialSFTP
+	case "ftp", "ftps":
+		f.dial = f.dialFTP
+	default:
+		return nil, fmt.Errorf("url scheme not recognised: %v", u.Scheme)
+	}
+	return f, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection to the server.
+func (f *FileTransfer) Connect() error {
+	if f.client != nil {
+		return nil
+	}
+	client, err := f.dial()
+	if err != nil {
+		return err
+	}
+	f.client = client
+	f.log.Infof("Polling files matching %v from: %v://%v\n", f.conf.Glob, f.url.Scheme, f.url.Host)
+	return nil
+}
+
+// disconnect closes the connection to the server, any file being read is read
+// again from the start once reconnected.
+func (f *FileTransfer) disconnect() {
+	f.closeFile()
+	if f.current != nil {
+		f.queue = append([]remoteFile{*f.current}, f.queue...)
+		f.current = nil
+	}
+	if f.client != nil {
+		f.client.Close()
+		f.client = nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// poll lists the files of the target directory that match the glob and have
+// not already been consumed, ordered by their modification time.
+func (f *FileTransfer) poll() error {
+	files, err := f.client.List(f.dir)
+	if err != nil {
+		return err
+	}
+	f.lastPoll = time.Now()
+
+	listed := map[string]struct{}{}
+	for _, file := range files {
+		if matched, _ := path.Match(f.pattern, path.Base(file.path)); !matched {
+			continue
+		}
+		listed[file.path] = struct{}{}
+		if prev, exists := f.consumed[file.path]; exists &&
+			prev.size == file.size && prev.modTime.Equal(file.modTime) {
+			continue
+		}
+		f.queue = append(f.queue, file)
+	}
+
+	// Forget consumed files that no longer exist so that they are read again
+	// if they reappear.
+	for p := range f.consumed {
+		if _, exists := listed[p]; !exists {
+			delete(f.consumed, p)
+		}
+	}
+
+	sort.SliceStable(f.queue, func(i, j int) bool {
+		return f.queue[i].modTime.Before(f.queue[j].modTime)
+	})
+	return nil
+}
+
+// nextFile opens the next file in the queue, polling the server for files when
+// the queue is empty and the poll interval has elapsed.
+func (f *FileTransfer) nextFile() error {
+	if len(f.queue) == 0 {
+		if wait := f.interval - time.Since(f.lastPoll); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-f.closeChan:
+				return types.ErrTypeClosed
+			}
+		}
+		if err := f.poll(); err != nil {
+			return err
+		}
+		if len(f.queue) == 0 {
+			return types.ErrTimeout
+		}
+	}
+
+	file := f.queue[0]
+	handle, err := f.client.Open(file.path)
+	if err != nil {
+		return err
+	}
+	f.queue = f.queue[1:]
+	f.current = &file
+	f.parts = newFileParts(handle, f.conf.Codec, f.delimiter, f.conf.MaxBuffer, f.conf.CSV)
+	return nil
+}
+
+func (f *FileTransfer) closeFile() {
+	if f.parts != nil {
+		f.parts.Close()
+		f.parts = nil
+	}
+}
+
+// finishFile moves or deletes the current file once all of its contents have
+// been acknowledged.
+func (f *FileTransfer) finishFile() {
+	file := *f.current
+	f.closeFile()
+	f.current = nil
+
+	if len(f.conf.MoveToDir) > 0 {
+		target := path.Join(f.conf.MoveToDir, path.Base(file.path))
+		if err := f.client.Rename(file.path, target); err != nil {
+			f.log.Errorf("Failed to move consumed file '%v': %v\n", file.path, err)
+		}
+	} else if f.conf.DeleteFiles {
+		if err := f.client.Remove(file.path); err != nil {
+			f.log.Errorf("Failed to delete consumed file '%v': %v\n", file.path, err)
+		}
+	}
+	f.consumed[file.path] = file
+}
+
+//------------------------------------------------------------------------------
+
+// Read attempts to read a new message from the server.
+func (f *FileTransfer) Read() (types.Message, error) {
+	if f.client == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	for {
+		if f.current == nil {
+			if err := f.nextFile(); err != nil {
+				switch err {
+				case types.ErrTimeout:
+				case types.ErrTypeClosed:
+					f.disconnect()
+				default:
+					f.log.Errorf("Failed to open next file: %v\n", err)
+					f.disconnect()
+					err = types.ErrNotConnected
+				}
+				return nil, err
+			}
+		}
+
+		part, err := f.parts.Next()
+		if err != nil {
+			f.log.Errorf("Failed to read file '%v': %v\n", f.current.path, err)
+			f.disconnect()
+			return nil, types.ErrNotConnected
+		}
+		if part == nil {
+			// Nothing to send from an empty file.
+			f.finishFile()
+			continue
+		}
+
+		msg := types.NewMessage([][]byte{part})
+		msg.GetMetadata(0).
+			Set("file_transfer_path", f.current.path).
+			Set("file_transfer_mod_time", f.current.modTime.Format(time.RFC3339))
+		return msg, nil
+	}
+}
+
+// Acknowledge instructs whether messages read since the last acknowledgement
+// have been successfully propagated. Once all messages of a file have been
+// acknowledged the file is moved or deleted.
+func (f *FileTransfer) Acknowledge(err error) error {
+	if err == nil && f.current != nil && f.parts.Exhausted() {
+		f.finishFile()
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (f *FileTransfer) CloseAsync() {
+	f.closeOnce.Do(func() {
+		close(f.closeChan)
+	})
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (f *FileTransfer) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
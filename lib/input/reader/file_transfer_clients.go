@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"path"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+//------------------------------------------------------------------------------
+
+// sftpClient is a fileTransferClient for SFTP servers.
+type sftpClient struct {
+	ssh  *ssh.Client
+	sftp *sftp.Client
+}
+
+func (f *FileTransfer) dialSFTP() (fileTransferClient, error) {
+	sshConf := &ssh.ClientConfig{
+		Timeout: time.Millisecond * time.Duration(f.conf.TimeoutMS),
+	}
+	if f.url.User != nil {
+		sshConf.User = f.url.User.Username()
+		if pass, exists := f.url.User.Password(); exists {
+			sshConf.Auth = append(sshConf.Auth, ssh.Password(pass))
+		}
+	}
+	if len(f.conf.KeyFile) > 0 {
+		keyBytes, err := ioutil.ReadFile(f.conf.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		sshConf.Auth = append(sshConf.Auth, ssh.PublicKeys(signer))
+	}
+	if f.conf.SkipHostKeyVerify {
+		sshConf.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+	} else {
+		callback, err := knownhosts.New(f.conf.KnownHostsFile)
+		if err != nil {
+			return nil, err
+		}
+		sshConf.HostKeyCallback = callback
+	}
+
+	host := f.url.Host
+	if len(f.url.Port()) == 0 {
+		host = net.JoinHostPort(host, "22")
+	}
+	sshClient, err := ssh.Dial("tcp", host, sshConf)
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+	return &sftpClient{ssh: sshClient, sftp: client}, nil
+}
+
+func (s *sftpClient) List(dir string) ([]remoteFile, error) {
+	infos, err := s.sftp.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []remoteFile
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, remoteFile{
+			path:    path.Join(dir, info.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+func (s *sftpClient) Open(path string) (io.ReadCloser, error) {
+	return s.sftp.Open(path)
+}
+
+func (s *sftpClient) Rename(from, to string) error {
+	return s.sftp.Rename(from, to)
+}
+
+func (s *sftpClient) Remove(path string) error {
+	return s.sftp.Remove(path)
+}
+
+func (s *sftpClient) Close() error {
+	s.sftp.Close()
+	return s.ssh.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// ftpClient is a fileTransferClient for FTP and FTPS servers.
+type ftpClient struct {
+	conn *ftp.ServerConn
+}
+
+func (f *FileTransfer) dialFTP() (fileTransferClient, error) {
+	opts := []ftp.DialOption{
+		ftp.DialWithTimeout(time.Millisecond * time.Duration(f.conf.TimeoutMS)),
+	}
+	if f.url.Scheme == "ftps" {
+		tlsConf, err := f.conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		if len(tlsConf.ServerName) == 0 {
+			tlsConf.ServerName = f.url.Hostname()
+		}
+		opts = append(opts, ftp.DialWithExplicitTLS(tlsConf))
+	}
+
+	host := f.url.Host
+	if len(f.url.Port()) == 0 {
+		host = net.JoinHostPort(host, "21")
+	}
+	conn, err := ftp.Dial(host, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	user, pass := "anonymous", "anonymous"
+	if f.url.User != nil {
+		user = f.url.User.Username()
+		pass, _ = f.url.User.Password()
+	}
+	if err = conn.Login(user, pass); err != nil {
+		conn.Quit()
+		return nil, err
+	}
+	return &ftpClient{conn: conn}, nil
+}
+
+func (c *ftpClient) List(dir string) ([]remoteFile, error) {
+	entries, err := c.conn.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []remoteFile
+	for _, e := range entries {
+		if e.Type != ftp.EntryTypeFile {
+			continue
+		}
+		files = append(files, remoteFile{
+			path:    path.Join(dir, e.Name),
+			size:    int64(e.Size),
+			modTime: e.Time,
+		})
+	}
+	return files, nil
+}
+
+func (c *ftpClient) Open(path string) (io.ReadCloser, error) {
+	return c.conn.Retr(path)
+}
+
+func (c *ftpClient) Rename(from, to string) error {
+	return c.conn.Rename(from, to)
+}
+
+func (c *ftpClient) Remove(path string) error {
+	return c.conn.Delete(path)
+}
+
+func (c *ftpClient) Close() error {
+	return c.conn.Quit()
+}
+
+//------------------------------------------------------------------------------
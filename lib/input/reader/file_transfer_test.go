@@ -0,0 +1,269 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+type mockFileTransferFile struct {
+	content []byte
+	modTime time.Time
+}
+
+type mockFileTransferClient struct {
+	files   map[string]mockFileTransferFile
+	removed []string
+	renamed map[string]string
+}
+
+func newMockFileTransferClient() *mockFileTransferClient {
+	return &mockFileTransferClient{
+		files:   map[string]mockFileTransferFile{},
+		renamed: map[string]string{},
+	}
+}
+
+func (m *mockFileTransferClient) List(dir string) ([]remoteFile, error) {
+	var files []remoteFile
+	for p, f := range m.files {
+		if path.Dir(p) == dir {
+			files = append(files, remoteFile{
+				path:    p,
+				size:    int64(len(f.content)),
+				modTime: f.modTime,
+			})
+		}
+	}
+	return files, nil
+}
+
+func (m *mockFileTransferClient) Open(path string) (io.ReadCloser, error) {
+	f, exists := m.files[path]
+	if !exists {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(bytes.NewReader(f.content)), nil
+}
+
+func (m *mockFileTransferClient) Rename(from, to string) error {
+	m.renamed[from] = to
+	m.files[to] = m.files[from]
+	delete(m.files, from)
+	return nil
+}
+
+func (m *mockFileTransferClient) Remove(path string) error {
+	m.removed = append(m.removed, path)
+	delete(m.files, path)
+	return nil
+}
+
+func (m *mockFileTransferClient) Close() error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func newTestFileTransfer(t *testing.T, conf FileTransferConfig, client fileTransferClient) *FileTransfer {
+	t.Helper()
+
+	conf.URL = "sftp://localhost"
+	conf.SkipHostKeyVerify = true
+	conf.PollIntervalMS = 1
+	f, err := NewFileTransfer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.dial = func() (fileTransferClient, error) {
+		return client, nil
+	}
+	if err = f.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func readFileTransfer(t *testing.T, f *FileTransfer, exp, expPath string) {
+	t.Helper()
+
+	msg, err := f.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong message contents: %v != %v", act, exp)
+	}
+	if act := msg.GetMetadata(0).Get("file_transfer_path"); expPath != act {
+		t.Errorf("Wrong path metadata: %v != %v", act, expPath)
+	}
+}
+
+func TestFileTransferAllBytesDelete(t *testing.T) {
+	now := time.Now()
+
+	client := newMockFileTransferClient()
+	client.files["/in/b.csv"] = mockFileTransferFile{content: []byte("bar"), modTime: now}
+	client.files["/in/a.csv"] = mockFileTransferFile{content: []byte("foo"), modTime: now.Add(-time.Minute)}
+	client.files["/in/c.txt"] = mockFileTransferFile{content: []byte("baz"), modTime: now}
+	client.files["/other/d.csv"] = mockFileTransferFile{content: []byte("qux"), modTime: now}
+
+	conf := NewFileTransferConfig()
+	conf.Glob = "/in/*.csv"
+	conf.DeleteFiles = true
+	f := newTestFileTransfer(t, conf, client)
+
+	readFileTransfer(t, f, "foo", "/in/a.csv")
+	if len(client.removed) > 0 {
+		t.Errorf("File removed before acknowledgement: %v", client.removed)
+	}
+	if err := f.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+	readFileTransfer(t, f, "bar", "/in/b.csv")
+	if err := f.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := []string{"/in/a.csv", "/in/b.csv"}, client.removed; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong removed files: %v != %v", act, exp)
+	}
+	if _, err := f.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+}
+
+func TestFileTransferLinesMove(t *testing.T) {
+	now := time.Now()
+
+	client := newMockFileTransferClient()
+	client.files["/in/a.csv"] = mockFileTransferFile{content: []byte("foo\nbar\n\nbaz"), modTime: now}
+	client.files["/in/b.csv"] = mockFileTransferFile{content: []byte("\n"), modTime: now.Add(time.Second)}
+
+	conf := NewFileTransferConfig()
+	conf.Glob = "/in/*.csv"
+	conf.Codec = "lines"
+	conf.MoveToDir = "/done"
+	f := newTestFileTransfer(t, conf, client)
+
+	for _, exp := range []string{"foo", "bar", "baz"} {
+		if len(client.renamed) > 0 {
+			t.Errorf("File moved before all lines were acknowledged: %v", client.renamed)
+		}
+		readFileTransfer(t, f, exp, "/in/a.csv")
+		if err := f.Acknowledge(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if exp, act := map[string]string{"/in/a.csv": "/done/a.csv"}, client.renamed; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong moved files: %v != %v", act, exp)
+	}
+
+	// The empty file is moved without producing messages.
+	if _, err := f.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+	if exp, act := "/done/b.csv", client.renamed["/in/b.csv"]; exp != act {
+		t.Errorf("Wrong moved file: %v != %v", act, exp)
+	}
+}
+
+func TestFileTransferNoRereads(t *testing.T) {
+	client := newMockFileTransferClient()
+	client.files["/in/a"] = mockFileTransferFile{content: []byte("foo"), modTime: time.Now()}
+
+	conf := NewFileTransferConfig()
+	conf.Glob = "/in/*"
+	f := newTestFileTransfer(t, conf, client)
+
+	readFileTransfer(t, f, "foo", "/in/a")
+	if err := f.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+
+	client.files["/in/a"] = mockFileTransferFile{content: []byte("bar"), modTime: time.Now().Add(time.Second)}
+	readFileTransfer(t, f, "bar", "/in/a")
+	if err := f.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for p := range client.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if exp, act := []string{"/in/a"}, paths; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong remaining files: %v != %v", act, exp)
+	}
+}
+
+func TestFileTransferBadConfig(t *testing.T) {
+	tests := map[string]func(c *FileTransferConfig){
+		"bad scheme": func(c *FileTransferConfig) { c.URL = "http://localhost" },
+		"bad codec":  func(c *FileTransferConfig) { c.Codec = "nope" },
+		"bad glob":   func(c *FileTransferConfig) { c.Glob = "/*/foo" },
+		"no host key verification": func(c *FileTransferConfig) {
+			c.SkipHostKeyVerify = false
+		},
+	}
+	for name, fn := range tests {
+		conf := NewFileTransferConfig()
+		conf.SkipHostKeyVerify = true
+		fn(&conf)
+		if _, err := NewFileTransfer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestFileTransferHostKeyConfig(t *testing.T) {
+	tests := map[string]func(c *FileTransferConfig){
+		"known hosts":   func(c *FileTransferConfig) { c.KnownHostsFile = "/home/foo/.ssh/known_hosts" },
+		"skip host key": func(c *FileTransferConfig) { c.SkipHostKeyVerify = true },
+		"ftp":           func(c *FileTransferConfig) { c.URL = "ftp://localhost" },
+	}
+	for name, fn := range tests {
+		conf := NewFileTransferConfig()
+		fn(&conf)
+		if _, err := NewFileTransfer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err != nil {
+			t.Errorf("%v: unexpected error: %v", name, err)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
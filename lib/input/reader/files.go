@@ -22,25 +22,43 @@ package reader
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
 )
 
 //------------------------------------------------------------------------------
 
 // FilesConfig is configuration for the Files input type.
 type FilesConfig struct {
-	Path string `json:"path" yaml:"path"`
+	Path        string    `json:"path" yaml:"path"`
+	Patterns    []string  `json:"patterns" yaml:"patterns"`
+	Recursive   bool      `json:"recursive" yaml:"recursive"`
+	Codec       string    `json:"codec" yaml:"codec"`
+	Delimiter   string    `json:"delimiter" yaml:"delimiter"`
+	CSV         CSVConfig `json:"csv" yaml:"csv"`
+	MaxBuffer   int       `json:"max_buffer" yaml:"max_buffer"`
+	DeleteFiles bool      `json:"delete_files" yaml:"delete_files"`
+	MoveToDir   string    `json:"move_to_dir" yaml:"move_to_dir"`
 }
 
 // NewFilesConfig creates a new FilesConfig with default values.
 func NewFilesConfig() FilesConfig {
 	return FilesConfig{
-		Path: "",
+		Path:        "",
+		Patterns:    []string{},
+		Recursive:   true,
+		Codec:       "all-bytes",
+		Delimiter:   "",
+		CSV:         NewCSVConfig(),
+		MaxBuffer:   1000000,
+		DeleteFiles: false,
+		MoveToDir:   "",
 	}
 }
 
@@ -48,12 +66,37 @@ func NewFilesConfig() FilesConfig {
 
 // Files is an input type that reads file contents at a path as messages.
 type Files struct {
+	conf      FilesConfig
+	delimiter []byte
+
 	targets []string
+	current string
+	parts   *fileParts
+
+	log   log.Modular
+	stats metrics.Type
 }
 
 // NewFiles creates a new Files input type.
-func NewFiles(conf FilesConfig) (Type, error) {
-	f := Files{}
+func NewFiles(conf FilesConfig, log log.Modular, stats metrics.Type) (Type, error) {
+	if err := validateCodec(conf.Codec, conf.CSV); err != nil {
+		return nil, err
+	}
+	for _, pattern := range conf.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("failed to parse pattern '%v': %v", pattern, err)
+		}
+	}
+
+	f := Files{
+		conf:      conf,
+		delimiter: []byte("\n"),
+		log:       log,
+		stats:     stats,
+	}
+	if len(conf.Delimiter) > 0 {
+		f.delimiter = []byte(conf.Delimiter)
+	}
 
 	if info, err := os.Stat(conf.Path); err != nil {
 		return nil, err
@@ -67,9 +110,14 @@ func NewFiles(conf FilesConfig) (Type, error) {
 			return werr
 		}
 		if info.IsDir() {
+			if !conf.Recursive && path != conf.Path {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-		f.targets = append(f.targets, path)
+		if f.matches(path) {
+			f.targets = append(f.targets, path)
+		}
 		return nil
 	})
 
@@ -78,6 +126,59 @@ func NewFiles(conf FilesConfig) (Type, error) {
 
 //------------------------------------------------------------------------------
 
+// matches returns true if a file path matches any of the configured patterns,
+// or if there are no patterns. Patterns containing a path separator are
+// matched against the path of the file relative to the target directory,
+// otherwise they are matched against the file name.
+func (f *Files) matches(path string) bool {
+	if len(f.conf.Patterns) == 0 {
+		return true
+	}
+	rel, err := filepath.Rel(f.conf.Path, path)
+	if err != nil {
+		rel = path
+	}
+	for _, pattern := range f.conf.Patterns {
+		target := filepath.Base(path)
+		if strings.ContainsRune(pattern, filepath.Separator) {
+			target = rel
+		}
+		if matched, _ := filepath.Match(pattern, target); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// closeFile closes the current file.
+func (f *Files) closeFile() {
+	f.parts.Close()
+	f.parts = nil
+	f.current = ""
+}
+
+// finishFile closes the current file and moves or deletes it if configured to
+// do so.
+func (f *Files) finishFile() {
+	path := f.current
+	f.closeFile()
+
+	if len(f.conf.MoveToDir) > 0 {
+		target := filepath.Join(f.conf.MoveToDir, filepath.Base(path))
+		if err := os.MkdirAll(f.conf.MoveToDir, 0755); err != nil {
+			f.log.Errorf("Failed to create directory '%v': %v\n", f.conf.MoveToDir, err)
+		} else if err = os.Rename(path, target); err != nil {
+			f.log.Errorf("Failed to move consumed file '%v': %v\n", path, err)
+		}
+	} else if f.conf.DeleteFiles {
+		if err := os.Remove(path); err != nil {
+			f.log.Errorf("Failed to delete consumed file '%v': %v\n", path, err)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // Connect establishes a connection.
 func (f *Files) Connect() (err error) {
 	return nil
@@ -87,31 +188,53 @@ func (f *Files) Connect() (err error) {
 
 // Read a new Files message.
 func (f *Files) Read() (types.Message, error) {
-	if len(f.targets) == 0 {
-		return nil, types.ErrTypeClosed
+	if f.parts != nil && f.parts.Exhausted() {
+		// The last message of the file was never acknowledged, therefore the
+		// file is left in place.
+		f.closeFile()
 	}
 
-	path := f.targets[0]
-	f.targets = f.targets[1:]
+	for {
+		if f.parts == nil {
+			if len(f.targets) == 0 {
+				return nil, types.ErrTypeClosed
+			}
+
+			path := f.targets[0]
+			f.targets = f.targets[1:]
+
+			file, openerr := os.Open(path)
+			if openerr != nil {
+				return nil, fmt.Errorf("failed to read file '%v': %v", path, openerr)
+			}
+			f.current = path
+			f.parts = newFileParts(file, f.conf.Codec, f.delimiter, f.conf.MaxBuffer, f.conf.CSV)
+		}
 
-	file, openerr := os.Open(path)
-	if openerr != nil {
-		return nil, fmt.Errorf("failed to read file '%v': %v", path, openerr)
-	}
-	defer file.Close()
+		part, readerr := f.parts.Next()
+		if readerr != nil {
+			path := f.current
+			f.closeFile()
+			return nil, fmt.Errorf("failed to read file '%v': %v", path, readerr)
+		}
+		if part == nil {
+			// Nothing to send from an empty file.
+			f.finishFile()
+			continue
+		}
 
-	msgBytes, readerr := ioutil.ReadAll(file)
-	if readerr != nil {
-		return nil, readerr
+		msg := types.NewMessage([][]byte{part})
+		msg.GetMetadata(0).Set("path", f.current)
+		return msg, nil
 	}
-
-	msg := types.NewMessage([][]byte{msgBytes})
-	return msg, nil
 }
 
 // Acknowledge instructs whether unacknowledged messages have been successfully
 // propagated.
 func (f *Files) Acknowledge(err error) error {
+	if err == nil && f.parts != nil && f.parts.Exhausted() {
+		f.finishFile()
+	}
 	return nil
 }
 
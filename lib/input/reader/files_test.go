@@ -23,10 +23,13 @@ package reader
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
 )
 
 //------------------------------------------------------------------------------
@@ -74,7 +77,7 @@ func TestFilesDirectory(t *testing.T) {
 	conf.Path = tmpDir
 
 	var f Type
-	if f, err = NewFiles(conf); err != nil {
+	if f, err = NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -132,7 +135,7 @@ func TestFilesFile(t *testing.T) {
 	conf.Path = tmpFile.Name()
 
 	var f Type
-	if f, err = NewFiles(conf); err != nil {
+	if f, err = NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err != nil {
 		t.Fatal(err)
 	}
 
@@ -163,9 +166,282 @@ func TestFilesBadPath(t *testing.T) {
 	conf := NewFilesConfig()
 	conf.Path = "fdgdfkte34%#@$%#$%KL@#K$@:L#$23k;32l;23"
 
-	if _, err := NewFiles(conf); err == nil {
+	if _, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
 		t.Error("Expected error from bad path")
 	}
 }
 
+func TestFilesPatterns(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_file_input_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err = os.MkdirAll(filepath.Join(tmpDir, "inner", "deeper"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"a.json":              "a",
+		"b.txt":               "b",
+		"inner/c.json":        "c",
+		"inner/d.txt":         "d",
+		"inner/deeper/e.json": "e",
+	} {
+		if err = ioutil.WriteFile(filepath.Join(tmpDir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		recursive bool
+		exp       map[string]struct{}
+	}{
+		{
+			name:      "no patterns",
+			recursive: true,
+			exp:       map[string]struct{}{"a": {}, "b": {}, "c": {}, "d": {}, "e": {}},
+		},
+		{
+			name:      "name pattern",
+			patterns:  []string{"*.json"},
+			recursive: true,
+			exp:       map[string]struct{}{"a": {}, "c": {}, "e": {}},
+		},
+		{
+			name:      "name pattern not recursive",
+			patterns:  []string{"*.json"},
+			recursive: false,
+			exp:       map[string]struct{}{"a": {}},
+		},
+		{
+			name:      "path pattern",
+			patterns:  []string{filepath.FromSlash("inner/*"), "b.txt"},
+			recursive: true,
+			exp:       map[string]struct{}{"b": {}, "c": {}, "d": {}},
+		},
+	}
+
+	for _, test := range tests {
+		conf := NewFilesConfig()
+		conf.Path = tmpDir
+		conf.Patterns = test.patterns
+		conf.Recursive = test.recursive
+
+		f, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act := map[string]struct{}{}
+		for {
+			msg, err := f.Read()
+			if err == types.ErrTypeClosed {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			act[string(msg.Get(0))] = struct{}{}
+			if err = f.Acknowledge(nil); err != nil {
+				t.Error(err)
+			}
+		}
+		if !reflect.DeepEqual(test.exp, act) {
+			t.Errorf("Wrong result for '%v': %v != %v", test.name, act, test.exp)
+		}
+	}
+}
+
+func TestFilesLinesPostProcessing(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_file_input_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	inDir := filepath.Join(tmpDir, "in")
+	archiveDir := filepath.Join(tmpDir, "archive")
+	if err = os.MkdirAll(inDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(inDir, "foo.txt")
+	if err = ioutil.WriteFile(filePath, []byte("foo\n\nbar\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	emptyPath := filepath.Join(inDir, "empty.txt")
+	if err = ioutil.WriteFile(emptyPath, []byte{}, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewFilesConfig()
+	conf.Path = inDir
+	conf.Codec = "lines"
+	conf.MoveToDir = archiveDir
+
+	f, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"foo", "bar"} {
+		msg, err := f.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if act := msg.GetMetadata(0).Get("path"); filePath != act {
+			t.Errorf("Wrong path metadata: %v != %v", act, filePath)
+		}
+		if _, err = os.Stat(filePath); err != nil {
+			t.Errorf("Expected file to remain before acknowledgement: %v", err)
+		}
+		if err = f.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err = f.Read(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTypeClosed)
+	}
+
+	for _, p := range []string{filePath, emptyPath} {
+		if _, err = os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("Expected file '%v' to be moved: %v", p, err)
+		}
+		if _, err = os.Stat(filepath.Join(archiveDir, filepath.Base(p))); err != nil {
+			t.Errorf("Expected file '%v' in archive: %v", p, err)
+		}
+	}
+}
+
+func TestFilesDelete(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "f1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err = tmpFile.Write([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewFilesConfig()
+	conf.Path = tmpFile.Name()
+	conf.DeleteFiles = true
+
+	f, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := f.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "foo", string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+	if err = f.Acknowledge(types.ErrTimeout); err != nil {
+		t.Error(err)
+	}
+	if _, err = os.Stat(tmpFile.Name()); err != nil {
+		t.Errorf("Expected file to remain after failed acknowledgement: %v", err)
+	}
+	if err = f.Acknowledge(nil); err != nil {
+		t.Error(err)
+	}
+	if _, err = os.Stat(tmpFile.Name()); !os.IsNotExist(err) {
+		t.Errorf("Expected file to be deleted: %v", err)
+	}
+}
+
+func TestFilesBadCodec(t *testing.T) {
+	conf := NewFilesConfig()
+	conf.Path = os.TempDir()
+	conf.Codec = "nope"
+
+	if _, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad codec")
+	}
+
+	conf.Codec = "csv"
+	conf.CSV.Delimiter = "::"
+	if _, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad csv delimiter")
+	}
+}
+
+func TestFilesCSV(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_file_input_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	headerPath := filepath.Join(tmpDir, "a.csv")
+	if err = ioutil.WriteFile(headerPath, []byte("name,age\nfoo,10\n\n\"bar, baz\",20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewFilesConfig()
+	conf.Path = headerPath
+	conf.Codec = "csv"
+
+	f, err := NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{
+		`{"age":"10","name":"foo"}`,
+		`{"age":"20","name":"bar, baz"}`,
+	} {
+		msg, err := f.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+		if err = f.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, err = f.Read(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTypeClosed)
+	}
+
+	columnsPath := filepath.Join(tmpDir, "b.csv")
+	if err = ioutil.WriteFile(columnsPath, []byte("foo|10\nbar|20|30\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf.Path = columnsPath
+	conf.CSV.Delimiter = "|"
+	conf.CSV.Columns = []string{"name", "age"}
+
+	if f, err = NewFiles(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := f.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := `{"age":"10","name":"foo"}`, string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+	if _, err = f.Read(); err == nil {
+		t.Error("Expected error from row with too many fields")
+	}
+}
+
 //------------------------------------------------------------------------------
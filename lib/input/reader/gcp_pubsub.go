@@ -0,0 +1,209 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// GCPPubSubConfig contains configuration values for the input type.
+type GCPPubSubConfig struct {
+	ProjectID              string `json:"project" yaml:"project"`
+	SubscriptionID         string `json:"subscription" yaml:"subscription"`
+	MaxOutstandingMessages int    `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
+	MaxOutstandingBytes    int    `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+}
+
+// NewGCPPubSubConfig creates a new Config with default values.
+func NewGCPPubSubConfig() GCPPubSubConfig {
+	return GCPPubSubConfig{
+		ProjectID:              "",
+		SubscriptionID:         "",
+		MaxOutstandingMessages: pubsub.DefaultReceiveSettings.MaxOutstandingMessages,
+		MaxOutstandingBytes:    pubsub.DefaultReceiveSettings.MaxOutstandingBytes,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GCPPubSub is a benthos reader.Type implementation that reads messages from
+// a GCP Pub/Sub subscription.
+type GCPPubSub struct {
+	conf GCPPubSubConfig
+
+	client    *pubsub.Client
+	msgsChan  chan *pubsub.Message
+	closeFunc context.CancelFunc
+	closed    bool
+	subMut    sync.Mutex
+
+	unAckMsgs []*pubsub.Message
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGCPPubSub creates a new GCP Pub/Sub reader.Type.
+func NewGCPPubSub(
+	conf GCPPubSubConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*GCPPubSub, error) {
+	return &GCPPubSub{
+		conf:  conf,
+		log:   log.NewModule(".input.gcp_pubsub"),
+		stats: stats,
+	}, nil
+}
+
+// Connect attempts to establish a connection to the target subscription.
+func (c *GCPPubSub) Connect() error {
+	c.subMut.Lock()
+	defer c.subMut.Unlock()
+
+	if c.closed {
+		return types.ErrTypeClosed
+	}
+	if c.msgsChan != nil {
+		return nil
+	}
+
+	if c.client == nil {
+		client, err := pubsub.NewClient(context.Background(), c.conf.ProjectID)
+		if err != nil {
+			return err
+		}
+		c.client = client
+	}
+
+	sub := c.client.Subscription(c.conf.SubscriptionID)
+	sub.ReceiveSettings.MaxOutstandingMessages = c.conf.MaxOutstandingMessages
+	sub.ReceiveSettings.MaxOutstandingBytes = c.conf.MaxOutstandingBytes
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	msgsChan := make(chan *pubsub.Message, 1)
+
+	c.msgsChan = msgsChan
+	c.closeFunc = cancel
+
+	go func() {
+		// Receive only returns once all outstanding calls of the handler have
+		// returned, and therefore the channel can be safely closed after.
+		rerr := sub.Receive(subCtx, func(ctx context.Context, m *pubsub.Message) {
+			select {
+			case msgsChan <- m:
+			case <-ctx.Done():
+				m.Nack()
+			}
+		})
+		if rerr != nil && rerr != context.Canceled {
+			c.log.Errorf("Subscription error: %v\n", rerr)
+		}
+
+		c.subMut.Lock()
+		close(msgsChan)
+		c.msgsChan = nil
+		c.closeFunc = nil
+		if c.closed && c.client != nil {
+			c.client.Close()
+			c.client = nil
+		}
+		c.subMut.Unlock()
+		cancel()
+	}()
+
+	c.log.Infof("Receiving GCP Pub/Sub messages from project '%v' and subscription '%v'\n", c.conf.ProjectID, c.conf.SubscriptionID)
+	return nil
+}
+
+// Read attempts to read a new message from the target subscription.
+func (c *GCPPubSub) Read() (types.Message, error) {
+	c.subMut.Lock()
+	msgsChan, closed := c.msgsChan, c.closed
+	c.subMut.Unlock()
+
+	if closed {
+		return nil, types.ErrTypeClosed
+	}
+	if msgsChan == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	gmsg, open := <-msgsChan
+	if !open {
+		return nil, types.ErrNotConnected
+	}
+	c.unAckMsgs = append(c.unAckMsgs, gmsg)
+
+	msg := types.NewMessage([][]byte{gmsg.Data})
+	meta := msg.GetMetadata(0)
+	for k, v := range gmsg.Attributes {
+		meta.Set(k, v)
+	}
+	return msg, nil
+}
+
+// Acknowledge confirms whether or not our unacknowledged messages have been
+// successfully propagated or not, where messages that were not propagated are
+// nacked for redelivery.
+func (c *GCPPubSub) Acknowledge(err error) error {
+	for _, msg := range c.unAckMsgs {
+		if err == nil {
+			msg.Ack()
+		} else {
+			msg.Nack()
+		}
+	}
+	c.unAckMsgs = nil
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this reader asynchronously.
+func (c *GCPPubSub) CloseAsync() {
+	c.subMut.Lock()
+	if !c.closed {
+		c.closed = true
+		if c.closeFunc != nil {
+			c.closeFunc()
+		} else if c.client != nil {
+			c.client.Close()
+			c.client = nil
+		}
+	}
+	c.subMut.Unlock()
+}
+
+// WaitForClose will block until either the reader is closed or a specified
+// timeout occurs.
+func (c *GCPPubSub) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
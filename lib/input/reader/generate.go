@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// GenerateConfig is configuration for the Generate input type.
+type GenerateConfig struct {
+	Content string  `json:"content" yaml:"content"`
+	Parts   int     `json:"parts" yaml:"parts"`
+	Rate    float64 `json:"rate" yaml:"rate"`
+	Count   int     `json:"count" yaml:"count"`
+	MinSize int     `json:"min_size" yaml:"min_size"`
+	MaxSize int     `json:"max_size" yaml:"max_size"`
+}
+
+// NewGenerateConfig creates a new GenerateConfig with default values.
+func NewGenerateConfig() GenerateConfig {
+	return GenerateConfig{
+		Content: `{"id":${!count:generate},"timestamp":${!timestamp_unix_nano}}`,
+		Parts:   1,
+		Rate:    1,
+		Count:   0,
+		MinSize: 0,
+		MaxSize: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+const generatePadding = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Generate is an input type that produces synthetic messages at a fixed rate.
+type Generate struct {
+	conf  GenerateConfig
+	stats metrics.Type
+	log   log.Modular
+
+	content   []byte
+	templated bool
+	interval  time.Duration
+	next      time.Time
+	generated int
+	rand      *rand.Rand
+
+	closeChan chan struct{}
+}
+
+// NewGenerate creates a new Generate input type.
+func NewGenerate(conf GenerateConfig, log log.Modular, stats metrics.Type) (*Generate, error) {
+	if conf.Parts < 1 {
+		return nil, errors.New("parts must be at least one")
+	}
+	if conf.MaxSize > 0 && conf.MinSize > conf.MaxSize {
+		return nil, errors.New("min_size must not be greater than max_size")
+	}
+	g := &Generate{
+		conf:      conf,
+		stats:     stats,
+		log:       log.NewModule(".input.generate"),
+		content:   []byte(conf.Content),
+		templated: text.ContainsFunctionVariables([]byte(conf.Content)),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		closeChan: make(chan struct{}),
+	}
+	if conf.Rate > 0 {
+		g.interval = time.Duration(float64(time.Second) / conf.Rate)
+	}
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect is a noop for the Generate input.
+func (g *Generate) Connect() error {
+	return nil
+}
+
+// createPart produces the contents of a single message part, resolving any
+// function interpolations and then padding or truncating it to a random size
+// within the configured range.
+func (g *Generate) createPart() []byte {
+	part := g.content
+	if g.templated {
+		part = text.ReplaceFunctionVariables(part)
+	} else {
+		part = append([]byte(nil), part...)
+	}
+	if g.conf.MaxSize <= 0 {
+		return part
+	}
+	size := g.conf.MinSize
+	if g.conf.MaxSize > g.conf.MinSize {
+		size += g.rand.Intn(g.conf.MaxSize - g.conf.MinSize + 1)
+	}
+	if len(part) > size {
+		return part[:size]
+	}
+	for len(part) < size {
+		part = append(part, generatePadding[g.rand.Intn(len(generatePadding))])
+	}
+	return part
+}
+
+// Read generates a new message, blocking until the configured rate allows it.
+func (g *Generate) Read() (types.Message, error) {
+	if g.conf.Count > 0 && g.generated >= g.conf.Count {
+		return nil, types.ErrTypeClosed
+	}
+
+	if g.interval > 0 {
+		now := time.Now()
+		if g.next.Before(now) {
+			g.next = now
+		}
+		select {
+		case <-time.After(g.next.Sub(now)):
+		case <-g.closeChan:
+			return nil, types.ErrTypeClosed
+		}
+		g.next = g.next.Add(g.interval)
+	} else {
+		select {
+		case <-g.closeChan:
+			return nil, types.ErrTypeClosed
+		default:
+		}
+	}
+
+	parts := make([][]byte, g.conf.Parts)
+	for i := range parts {
+		parts[i] = g.createPart()
+	}
+	g.generated++
+	return types.NewMessage(parts), nil
+}
+
+// Acknowledge is a noop for the Generate input.
+func (g *Generate) Acknowledge(err error) error {
+	return nil
+}
+
+// CloseAsync shuts down the Generate input.
+func (g *Generate) CloseAsync() {
+	close(g.closeChan)
+}
+
+// WaitForClose blocks until the Generate input has closed down.
+func (g *Generate) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
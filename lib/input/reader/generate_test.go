@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+func TestGenerateCount(t *testing.T) {
+	conf := NewGenerateConfig()
+	conf.Content = "foo ${!count:generate_test_count}"
+	conf.Parts = 2
+	conf.Rate = 0
+	conf.Count = 3
+
+	g, err := NewGenerate(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = g.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]string{
+		{"foo 1", "foo 2"},
+		{"foo 3", "foo 4"},
+		{"foo 5", "foo 6"},
+	}
+	for _, e := range exp {
+		msg, err := g.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Len() != len(e) {
+			t.Fatalf("Wrong count of parts: %v != %v", msg.Len(), len(e))
+		}
+		for i, p := range e {
+			if act := string(msg.Get(i)); act != p {
+				t.Errorf("Wrong content: %v != %v", act, p)
+			}
+		}
+		if err = g.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err = g.Read(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+}
+
+func TestGenerateSizes(t *testing.T) {
+	conf := NewGenerateConfig()
+	conf.Content = "hello world"
+	conf.Rate = 0
+	conf.MinSize = 5
+	conf.MaxSize = 20
+
+	g, err := NewGenerate(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		msg, err := g.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		part := string(msg.Get(0))
+		if len(part) < 5 || len(part) > 20 {
+			t.Errorf("Wrong size of part: %v", len(part))
+		}
+		if len(part) >= 11 && part[:11] != "hello world" {
+			t.Errorf("Wrong content: %v", part)
+		}
+	}
+
+	conf.MinSize = 30
+	if _, err = NewGenerate(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad size range")
+	}
+}
+
+func TestGenerateRate(t *testing.T) {
+	conf := NewGenerateConfig()
+	conf.Rate = 100
+
+	g, err := NewGenerate(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		if _, err = g.Read(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dur := time.Since(start); dur < time.Millisecond*100 {
+		t.Errorf("Messages generated too quickly: %v", dur)
+	}
+
+	g.CloseAsync()
+	if _, err = g.Read(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+	if err = g.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -21,6 +21,7 @@
 package reader
 
 import (
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -247,7 +248,25 @@ func (k *Kafka) Read() (types.Message, error) {
 		return nil, types.ErrTypeClosed
 	}
 	k.offset = data.Offset + 1
-	return types.NewMessage([][]byte{data.Value}), nil
+
+	msg := types.NewMessage([][]byte{data.Value})
+	setKafkaMetadata(msg.GetMetadata(0), data)
+	return msg, nil
+}
+
+// setKafkaMetadata populates the metadata of a message part with the fields of
+// a consumed Kafka message.
+func setKafkaMetadata(meta types.Metadata, data *sarama.ConsumerMessage) {
+	meta.Set("kafka_key", string(data.Key)).
+		Set("kafka_topic", data.Topic).
+		Set("kafka_partition", strconv.Itoa(int(data.Partition))).
+		Set("kafka_offset", strconv.FormatInt(data.Offset, 10)).
+		Set("kafka_timestamp_unix", strconv.FormatInt(data.Timestamp.Unix(), 10)).
+		Set(types.SourceIDKey, "kafka:"+data.Topic+":"+strconv.Itoa(int(data.Partition))).
+		Set(types.SourceOffsetKey, strconv.FormatInt(data.Offset, 10))
+	for _, hdr := range data.Headers {
+		meta.Set(string(hdr.Key), string(hdr.Value))
+	}
 }
 
 // Acknowledge instructs whether the current offset should be committed.
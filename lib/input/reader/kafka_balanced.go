@@ -36,21 +36,25 @@ import (
 
 // KafkaBalancedConfig is configuration for the KafkaBalanced input type.
 type KafkaBalancedConfig struct {
-	Addresses       []string `json:"addresses" yaml:"addresses"`
-	ClientID        string   `json:"client_id" yaml:"client_id"`
-	ConsumerGroup   string   `json:"consumer_group" yaml:"consumer_group"`
-	Topics          []string `json:"topics" yaml:"topics"`
-	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
+	Addresses            []string `json:"addresses" yaml:"addresses"`
+	ClientID             string   `json:"client_id" yaml:"client_id"`
+	ConsumerGroup        string   `json:"consumer_group" yaml:"consumer_group"`
+	Topics               []string `json:"topics" yaml:"topics"`
+	StartFromOldest      bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
+	ConcurrentPartitions bool     `json:"concurrent_partitions" yaml:"concurrent_partitions"`
+	CommitPeriodMS       int      `json:"commit_period_ms" yaml:"commit_period_ms"`
 }
 
 // NewKafkaBalancedConfig creates a new KafkaBalancedConfig with default values.
 func NewKafkaBalancedConfig() KafkaBalancedConfig {
 	return KafkaBalancedConfig{
-		Addresses:       []string{"localhost:9092"},
-		ClientID:        "benthos_kafka_input",
-		ConsumerGroup:   "benthos_consumer_group",
-		Topics:          []string{"benthos_stream"},
-		StartFromOldest: true,
+		Addresses:            []string{"localhost:9092"},
+		ClientID:             "benthos_kafka_input",
+		ConsumerGroup:        "benthos_consumer_group",
+		Topics:               []string{"benthos_stream"},
+		StartFromOldest:      true,
+		ConcurrentPartitions: false,
+		CommitPeriodMS:       1000,
 	}
 }
 
@@ -185,7 +189,10 @@ func (k *KafkaBalanced) Read() (types.Message, error) {
 		return nil, types.ErrNotConnected
 	}
 	consumer.MarkOffset(data, "")
-	return types.NewMessage([][]byte{data.Value}), nil
+
+	msg := types.NewMessage([][]byte{data.Value})
+	setKafkaMetadata(msg.GetMetadata(0), data)
+	return msg, nil
 }
 
 // Acknowledge instructs whether the current offset should be committed.
@@ -0,0 +1,261 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Shopify/sarama"
+	cluster "github.com/bsm/sarama-cluster"
+)
+
+//------------------------------------------------------------------------------
+
+// KafkaBalancedPartitions is a member of a Kafka consumer group that provides a
+// reader.Type for each partition assigned to it, allowing the partitions to be
+// consumed concurrently.
+//
+// Acknowledged offsets are marked per partition and committed periodically
+// by the consumer, as well as when partitions are released during a rebalance.
+type KafkaBalancedPartitions struct {
+	consumer *cluster.Consumer
+	closed   bool
+	cMut     sync.Mutex
+
+	addresses []string
+	conf      KafkaBalancedConfig
+	stats     metrics.Type
+	log       log.Modular
+}
+
+// NewKafkaBalancedPartitions creates a new KafkaBalancedPartitions type.
+func NewKafkaBalancedPartitions(
+	conf KafkaBalancedConfig, log log.Modular, stats metrics.Type,
+) (*KafkaBalancedPartitions, error) {
+	k := KafkaBalancedPartitions{
+		conf:  conf,
+		stats: stats,
+		log:   log.NewModule(".input.kafka_balanced"),
+	}
+	for _, addr := range conf.Addresses {
+		for _, splitAddr := range strings.Split(addr, ",") {
+			if len(splitAddr) > 0 {
+				k.addresses = append(k.addresses, splitAddr)
+			}
+		}
+	}
+	return &k, nil
+}
+
+//------------------------------------------------------------------------------
+
+// closeClients closes the kafka clients, which ends the consumption of all
+// partitions.
+func (k *KafkaBalancedPartitions) closeClients() {
+	k.cMut.Lock()
+	defer k.cMut.Unlock()
+	if k.consumer != nil {
+		// Partitions must be consumed until the consumer has shut down, as it
+		// blocks whilst emitting them.
+		go func(partitions <-chan cluster.PartitionConsumer) {
+			for p := range partitions {
+				p.AsyncClose()
+			}
+		}(k.consumer.Partitions())
+
+		k.consumer.Close()
+
+		// Drain all channels
+		for range k.consumer.Notifications() {
+		}
+		for range k.consumer.Errors() {
+		}
+
+		k.consumer = nil
+	}
+}
+
+// Connect establishes a connection to the consumer group.
+func (k *KafkaBalancedPartitions) Connect() error {
+	k.cMut.Lock()
+	defer k.cMut.Unlock()
+
+	if k.closed {
+		return types.ErrTypeClosed
+	}
+	if k.consumer != nil {
+		return nil
+	}
+
+	config := cluster.NewConfig()
+	config.ClientID = k.conf.ClientID
+	config.Net.DialTimeout = time.Second
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.CommitInterval = time.Duration(k.conf.CommitPeriodMS) * time.Millisecond
+	config.Group.Return.Notifications = true
+	config.Group.Mode = cluster.ConsumerModePartitions
+
+	if k.conf.StartFromOldest {
+		config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+
+	consumer, err := cluster.NewConsumer(
+		k.addresses,
+		k.conf.ConsumerGroup,
+		k.conf.Topics,
+		config,
+	)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case err, open := <-consumer.Errors():
+				if !open {
+					return
+				}
+				if err != nil {
+					k.log.Errorf("KafkaBalanced message recv error: %v\n", err)
+					k.stats.Incr("input.kafka_balanced.recv.error", 1)
+				}
+			case _, open := <-consumer.Notifications():
+				if !open {
+					return
+				}
+				k.stats.Incr("input.kafka_balanced.rebalanced", 1)
+			}
+		}
+	}()
+
+	k.consumer = consumer
+	k.log.Infof("Receiving KafkaBalanced partitions from addresses: %s\n", k.addresses)
+	return nil
+}
+
+// NextPartition blocks until a partition is assigned to this consumer and
+// returns a reader.Type for consuming it. The reader is closed when the
+// partition is released.
+func (k *KafkaBalancedPartitions) NextPartition() (Type, error) {
+	k.cMut.Lock()
+	consumer, closed := k.consumer, k.closed
+	k.cMut.Unlock()
+
+	if closed {
+		return nil, types.ErrTypeClosed
+	}
+	if consumer == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	partition, open := <-consumer.Partitions()
+	if !open {
+		k.closeClients()
+
+		k.cMut.Lock()
+		closed = k.closed
+		k.cMut.Unlock()
+
+		if closed {
+			return nil, types.ErrTypeClosed
+		}
+		return nil, types.ErrNotConnected
+	}
+
+	k.log.Infof("Consuming partition %v of topic '%v'\n", partition.Partition(), partition.Topic())
+	return &kafkaPartition{
+		consumer:  consumer,
+		partition: partition,
+	}, nil
+}
+
+// CloseAsync shuts down the consumer, which also ends the consumption of all
+// partitions.
+func (k *KafkaBalancedPartitions) CloseAsync() {
+	k.cMut.Lock()
+	k.closed = true
+	k.cMut.Unlock()
+	go k.closeClients()
+}
+
+//------------------------------------------------------------------------------
+
+// offsetMarker marks the offsets of consumed messages to be committed.
+type offsetMarker interface {
+	MarkOffset(msg *sarama.ConsumerMessage, metadata string)
+}
+
+// kafkaPartition is a reader.Type implementation that reads messages from a
+// single partition assigned to a consumer group member.
+type kafkaPartition struct {
+	consumer  offsetMarker
+	partition cluster.PartitionConsumer
+
+	unAckMsg *sarama.ConsumerMessage
+}
+
+// Connect does nothing as the partition is consumed from the moment it is
+// assigned.
+func (k *kafkaPartition) Connect() error {
+	return nil
+}
+
+// Read attempts to read a message from the partition.
+func (k *kafkaPartition) Read() (types.Message, error) {
+	data, open := <-k.partition.Messages()
+	if !open {
+		return nil, types.ErrTypeClosed
+	}
+	k.unAckMsg = data
+
+	msg := types.NewMessage([][]byte{data.Value})
+	setKafkaMetadata(msg.GetMetadata(0), data)
+	return msg, nil
+}
+
+// Acknowledge marks the offset of the last read message of the partition to
+// be committed.
+func (k *kafkaPartition) Acknowledge(err error) error {
+	if err != nil || k.unAckMsg == nil {
+		return nil
+	}
+	k.consumer.MarkOffset(k.unAckMsg, "")
+	k.unAckMsg = nil
+	return nil
+}
+
+// CloseAsync stops consuming the partition.
+func (k *kafkaPartition) CloseAsync() {
+	k.partition.AsyncClose()
+}
+
+// WaitForClose blocks until the partition has stopped being consumed.
+func (k *kafkaPartition) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Shopify/sarama"
+	cluster "github.com/bsm/sarama-cluster"
+)
+
+//------------------------------------------------------------------------------
+
+type fakeClusterPartition struct {
+	cluster.PartitionConsumer
+
+	msgs   chan *sarama.ConsumerMessage
+	closed bool
+}
+
+func (f *fakeClusterPartition) Messages() <-chan *sarama.ConsumerMessage {
+	return f.msgs
+}
+func (f *fakeClusterPartition) AsyncClose() {
+	f.closed = true
+}
+
+type fakeOffsetMarker struct {
+	marked []int64
+}
+
+func (f *fakeOffsetMarker) MarkOffset(msg *sarama.ConsumerMessage, metadata string) {
+	f.marked = append(f.marked, msg.Offset)
+}
+
+//------------------------------------------------------------------------------
+
+func TestKafkaBalancedPartitionsNotConnected(t *testing.T) {
+	k, err := NewKafkaBalancedPartitions(
+		NewKafkaBalancedConfig(),
+		log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = k.NextPartition(); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+
+	k.CloseAsync()
+	if _, err = k.NextPartition(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+	if err = k.Connect(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+}
+
+func TestKafkaPartitionReadAck(t *testing.T) {
+	partition := &fakeClusterPartition{
+		msgs: make(chan *sarama.ConsumerMessage, 2),
+	}
+	marker := &fakeOffsetMarker{}
+	k := &kafkaPartition{
+		consumer:  marker,
+		partition: partition,
+	}
+
+	if err := k.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offset := range []int64{5, 6} {
+		partition.msgs <- &sarama.ConsumerMessage{
+			Topic:     "foo",
+			Partition: 3,
+			Offset:    offset,
+			Value:     []byte("bar"),
+		}
+	}
+
+	msg, err := k.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "bar", string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+	meta := msg.GetMetadata(0)
+	if exp, act := "kafka:foo:3", meta.Get(types.SourceIDKey); exp != act {
+		t.Errorf("Wrong source ID: %v != %v", act, exp)
+	}
+	if exp, act := "5", meta.Get(types.SourceOffsetKey); exp != act {
+		t.Errorf("Wrong source offset: %v != %v", act, exp)
+	}
+
+	// Failed messages are not marked.
+	if err = k.Acknowledge(errors.New("failed")); err != nil {
+		t.Error(err)
+	}
+	if len(marker.marked) != 0 {
+		t.Errorf("Unexpected marked offsets: %v", marker.marked)
+	}
+
+	if _, err = k.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if err = k.Acknowledge(nil); err != nil {
+		t.Error(err)
+	}
+	if err = k.Acknowledge(nil); err != nil {
+		t.Error(err)
+	}
+	if exp, act := []int64{6}, marker.marked; len(act) != 1 || act[0] != exp[0] {
+		t.Errorf("Wrong marked offsets: %v != %v", act, exp)
+	}
+
+	k.CloseAsync()
+	if !partition.closed {
+		t.Error("Partition was not closed")
+	}
+	close(partition.msgs)
+	if _, err = k.Read(); err != types.ErrTypeClosed {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTypeClosed)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -21,7 +21,9 @@
 package reader
 
 import (
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -54,7 +56,9 @@ func NewMQTTConfig() MQTTConfig {
 
 // MQTT is an input type that reads MQTT Pub/Sub messages.
 type MQTT struct {
-	client mqtt.Client
+	client  mqtt.Client
+	cMut    sync.Mutex
+	closing bool
 
 	conf MQTTConfig
 
@@ -94,6 +98,12 @@ func NewMQTT(
 
 // Connect establishes a connection to an MQTT server.
 func (m *MQTT) Connect() error {
+	m.cMut.Lock()
+	defer m.cMut.Unlock()
+
+	if m.closing {
+		return types.ErrTypeClosed
+	}
 	if m.client != nil {
 		return nil
 	}
@@ -101,6 +111,9 @@ func (m *MQTT) Connect() error {
 	conf := mqtt.NewClientOptions().
 		SetAutoReconnect(true).
 		SetClientID(m.conf.ClientID).
+		SetConnectionLostHandler(func(client mqtt.Client, reason error) {
+			m.log.Errorf("Connection lost due to: %v\n", reason)
+		}).
 		SetOnConnectHandler(func(c mqtt.Client) {
 			for _, topic := range m.conf.Topics {
 				tok := c.Subscribe(topic, byte(m.conf.QoS), m.msgHandler)
@@ -124,6 +137,7 @@ func (m *MQTT) Connect() error {
 	}
 
 	m.client = client
+	m.log.Infof("Receiving MQTT messages from topics: %v\n", m.conf.Topics)
 	return nil
 }
 
@@ -138,7 +152,14 @@ func (m *MQTT) msgHandler(c mqtt.Client, msg mqtt.Message) {
 func (m *MQTT) Read() (types.Message, error) {
 	select {
 	case msg := <-m.msgChan:
-		return types.NewMessage([][]byte{[]byte(msg.Payload())}), nil
+		message := types.NewMessage([][]byte{[]byte(msg.Payload())})
+		message.GetMetadata(0).
+			Set("mqtt_duplicate", strconv.FormatBool(msg.Duplicate())).
+			Set("mqtt_qos", strconv.Itoa(int(msg.Qos()))).
+			Set("mqtt_retained", strconv.FormatBool(msg.Retained())).
+			Set("mqtt_topic", msg.Topic()).
+			Set("mqtt_message_id", strconv.Itoa(int(msg.MessageID())))
+		return message, nil
 	case <-m.interruptChan:
 	}
 	return nil, types.ErrTypeClosed
@@ -151,11 +172,16 @@ func (m *MQTT) Acknowledge(err error) error {
 
 // CloseAsync shuts down the MQTT input and stops processing requests.
 func (m *MQTT) CloseAsync() {
-	if m.client != nil {
-		m.client.Disconnect(0)
-		m.client = nil
+	m.cMut.Lock()
+	if !m.closing {
+		m.closing = true
 		close(m.interruptChan)
+		if m.client != nil {
+			m.client.Disconnect(0)
+			m.client = nil
+		}
 	}
+	m.cMut.Unlock()
 }
 
 // WaitForClose blocks until the MQTT input has closed down.
@@ -157,6 +157,9 @@ func testMQTTConnect(urls []string, t *testing.T) {
 				t.Errorf("Unexpected message: %v", act)
 			}
 			delete(testMsgs, act)
+			if exp, act := "test_input_1", actM.GetMetadata(0).Get("mqtt_topic"); exp != act {
+				t.Errorf("Wrong topic metadata: %v != %v", act, exp)
+			}
 		}
 		lMsgs = len(testMsgs)
 	}
@@ -43,6 +43,8 @@ type NATSStreamConfig struct {
 	DurableName     string   `json:"durable_name" yaml:"durable_name"`
 	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
 	Subject         string   `json:"subject" yaml:"subject"`
+	MaxInflight     int      `json:"max_inflight" yaml:"max_inflight"`
+	AckWaitMS       int      `json:"ack_wait_ms" yaml:"ack_wait_ms"`
 }
 
 // NewNATSStreamConfig creates a new NATSStreamConfig with default values.
@@ -55,6 +57,8 @@ func NewNATSStreamConfig() NATSStreamConfig {
 		DurableName:     "benthos_offset",
 		StartFromOldest: true,
 		Subject:         "benthos_messages",
+		MaxInflight:     stan.DefaultMaxInflight,
+		AckWaitMS:       int(stan.DefaultAckWait / time.Millisecond),
 	}
 }
 
@@ -138,6 +142,12 @@ func (n *NATSStream) Connect() error {
 	options := []stan.SubscriptionOption{
 		stan.SetManualAckMode(),
 	}
+	if n.conf.MaxInflight > 0 {
+		options = append(options, stan.MaxInflight(n.conf.MaxInflight))
+	}
+	if n.conf.AckWaitMS > 0 {
+		options = append(options, stan.AckWait(time.Duration(n.conf.AckWaitMS)*time.Millisecond))
+	}
 	if len(n.conf.DurableName) > 0 {
 		options = append(options, stan.DurableName(n.conf.DurableName))
 	}
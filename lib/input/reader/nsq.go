@@ -21,8 +21,10 @@
 package reader
 
 import (
+	"fmt"
 	"io/ioutil"
 	llog "log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +45,11 @@ type NSQConfig struct {
 	Channel         string   `json:"channel" yaml:"channel"`
 	UserAgent       string   `json:"user_agent" yaml:"user_agent"`
 	MaxInFlight     int      `json:"max_in_flight" yaml:"max_in_flight"`
+	Ephemeral       bool     `json:"ephemeral" yaml:"ephemeral"`
+	MaxAttempts     uint16   `json:"max_attempts" yaml:"max_attempts"`
+	RequeueStrategy string   `json:"requeue_strategy" yaml:"requeue_strategy"`
+	RequeueDelayMS  int      `json:"requeue_delay_ms" yaml:"requeue_delay_ms"`
+	MaxRequeueMS    int      `json:"max_requeue_delay_ms" yaml:"max_requeue_delay_ms"`
 }
 
 // NewNSQConfig creates a new NSQConfig with default values.
@@ -54,6 +61,11 @@ func NewNSQConfig() NSQConfig {
 		Channel:         "benthos_stream",
 		UserAgent:       "benthos_consumer",
 		MaxInFlight:     100,
+		Ephemeral:       false,
+		MaxAttempts:     5,
+		RequeueStrategy: "linear",
+		RequeueDelayMS:  90000,
+		MaxRequeueMS:    900000,
 	}
 }
 
@@ -68,6 +80,9 @@ type NSQ struct {
 
 	addresses       []string
 	lookupAddresses []string
+	channel         string
+	requeueDelay    time.Duration
+	maxRequeue      time.Duration
 	conf            NSQConfig
 	stats           metrics.Type
 	log             log.Modular
@@ -84,6 +99,17 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (Type, error) {
 		log:              log.NewModule(".input.nsq"),
 		internalMessages: make(chan *nsq.Message),
 		interruptChan:    make(chan struct{}),
+		channel:          conf.Channel,
+		requeueDelay:     time.Duration(conf.RequeueDelayMS) * time.Millisecond,
+		maxRequeue:       time.Duration(conf.MaxRequeueMS) * time.Millisecond,
+	}
+	switch conf.RequeueStrategy {
+	case "fixed", "linear", "exponential":
+	default:
+		return nil, fmt.Errorf("requeue strategy not recognised: %v", conf.RequeueStrategy)
+	}
+	if conf.Ephemeral && !strings.HasSuffix(n.channel, "#ephemeral") {
+		n.channel = n.channel + "#ephemeral"
 	}
 	for _, addr := range conf.Addresses {
 		for _, splitAddr := range strings.Split(addr, ",") {
@@ -105,6 +131,28 @@ func NewNSQ(conf NSQConfig, log log.Modular, stats metrics.Type) (Type, error) {
 
 //------------------------------------------------------------------------------
 
+// requeueDelayFor returns the delay to apply when requeuing a message, which
+// grows with the number of delivery attempts according to the configured
+// strategy, and is capped at the configured maximum.
+func (n *NSQ) requeueDelayFor(attempts uint16) time.Duration {
+	delay := n.requeueDelay
+	switch n.conf.RequeueStrategy {
+	case "linear":
+		delay = delay * time.Duration(attempts)
+	case "exponential":
+		for i := uint16(1); i < attempts && i < 32; i++ {
+			if n.maxRequeue > 0 && delay >= n.maxRequeue {
+				break
+			}
+			delay = delay * 2
+		}
+	}
+	if n.maxRequeue > 0 && delay > n.maxRequeue {
+		delay = n.maxRequeue
+	}
+	return delay
+}
+
 // HandleMessage handles an NSQ message.
 func (n *NSQ) HandleMessage(message *nsq.Message) error {
 	message.DisableAutoResponse()
@@ -131,9 +179,11 @@ func (n *NSQ) Connect() (err error) {
 	cfg := nsq.NewConfig()
 	cfg.UserAgent = n.conf.UserAgent
 	cfg.MaxInFlight = n.conf.MaxInFlight
+	cfg.MaxAttempts = n.conf.MaxAttempts
+	cfg.MaxRequeueDelay = n.maxRequeue
 
 	var consumer *nsq.Consumer
-	if consumer, err = nsq.NewConsumer(n.conf.Topic, n.conf.Channel, cfg); err != nil {
+	if consumer, err = nsq.NewConsumer(n.conf.Topic, n.channel, cfg); err != nil {
 		return
 	}
 
@@ -183,19 +233,28 @@ func (n *NSQ) Read() (types.Message, error) {
 		n.disconnect()
 		return nil, types.ErrTypeClosed
 	}
-	return types.NewMessage([][]byte{msg.Body}), nil
+	bMsg := types.NewMessage([][]byte{msg.Body})
+	setNSQMetadata(bMsg.GetMetadata(0), msg)
+	return bMsg, nil
+}
+
+// setNSQMetadata populates the metadata of a message part with the fields of
+// a consumed NSQ message.
+func setNSQMetadata(meta types.Metadata, msg *nsq.Message) {
+	meta.Set("nsq_attempts", strconv.Itoa(int(msg.Attempts))).
+		Set("nsq_timestamp_unix", strconv.FormatInt(msg.Timestamp/int64(time.Second), 10)).
+		Set("nsq_timestamp_unix_nano", strconv.FormatInt(msg.Timestamp, 10))
 }
 
 // Acknowledge instructs whether unacknowledged messages have been successfully
 // propagated.
 func (n *NSQ) Acknowledge(err error) error {
-	if err != nil {
-		for _, m := range n.unAckMsgs {
-			m.Requeue(-1)
-		}
-	}
 	for _, m := range n.unAckMsgs {
-		m.Finish()
+		if err != nil {
+			m.Requeue(n.requeueDelayFor(m.Attempts))
+		} else {
+			m.Finish()
+		}
 	}
 	n.unAckMsgs = nil
 	return nil
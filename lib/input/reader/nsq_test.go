@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	nsq "github.com/nsqio/go-nsq"
+)
+
+func TestNSQRequeueDelay(t *testing.T) {
+	tests := map[string][]time.Duration{
+		"fixed":       {time.Second, time.Second, time.Second, time.Second},
+		"linear":      {time.Second, time.Second * 2, time.Second * 3, time.Second * 4},
+		"exponential": {time.Second, time.Second * 2, time.Second * 4, time.Second * 5},
+	}
+
+	for strategy, exp := range tests {
+		conf := NewNSQConfig()
+		conf.RequeueStrategy = strategy
+		conf.RequeueDelayMS = 1000
+		conf.MaxRequeueMS = 5000
+
+		r, err := NewNSQ(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := r.(*NSQ)
+		for i, e := range exp {
+			if act := n.requeueDelayFor(uint16(i + 1)); act != e {
+				t.Errorf("Wrong delay for %v attempt %v: %v != %v", strategy, i+1, act, e)
+			}
+		}
+	}
+
+	conf := NewNSQConfig()
+	conf.RequeueStrategy = "nope"
+	if _, err := NewNSQ(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad strategy")
+	}
+}
+
+func TestNSQEphemeral(t *testing.T) {
+	conf := NewNSQConfig()
+	conf.Channel = "foo"
+	conf.Ephemeral = true
+
+	r, err := NewNSQ(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "foo#ephemeral", r.(*NSQ).channel; exp != act {
+		t.Errorf("Wrong channel: %v != %v", act, exp)
+	}
+}
+
+func TestNSQMetadata(t *testing.T) {
+	nMsg := nsq.NewMessage(nsq.MessageID{}, []byte("foo"))
+	nMsg.Attempts = 3
+	nMsg.Timestamp = int64(time.Second*10) + 5
+
+	msg := types.NewMessage([][]byte{nMsg.Body})
+	setNSQMetadata(msg.GetMetadata(0), nMsg)
+
+	exp := map[string]string{
+		"nsq_attempts":            "3",
+		"nsq_timestamp_unix":      "10",
+		"nsq_timestamp_unix_nano": "10000000005",
+	}
+	for k, v := range exp {
+		if act := msg.GetMetadata(0).Get(k); act != v {
+			t.Errorf("Wrong metadata %v: %v != %v", k, act, v)
+		}
+	}
+}
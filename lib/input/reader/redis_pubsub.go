@@ -35,15 +35,17 @@ import (
 
 // RedisPubSubConfig is configuration for the RedisPubSub input type.
 type RedisPubSubConfig struct {
-	URL      string   `json:"url" yaml:"url"`
-	Channels []string `json:"channels" yaml:"channels"`
+	URL         string   `json:"url" yaml:"url"`
+	Channels    []string `json:"channels" yaml:"channels"`
+	UsePatterns bool     `json:"use_patterns" yaml:"use_patterns"`
 }
 
 // NewRedisPubSubConfig creates a new RedisPubSubConfig with default values.
 func NewRedisPubSubConfig() RedisPubSubConfig {
 	return RedisPubSubConfig{
-		URL:      "tcp://localhost:6379",
-		Channels: []string{"benthos_chan"},
+		URL:         "tcp://localhost:6379",
+		Channels:    []string{"benthos_chan"},
+		UsePatterns: false,
 	}
 }
 
@@ -109,7 +111,11 @@ func (r *RedisPubSub) Connect() error {
 	r.log.Infof("Receiving Redis pub/sub messages from URL: %s\n", r.conf.URL)
 
 	r.client = client
-	r.pubsub = r.client.Subscribe(r.conf.Channels...)
+	if r.conf.UsePatterns {
+		r.pubsub = r.client.PSubscribe(r.conf.Channels...)
+	} else {
+		r.pubsub = r.client.Subscribe(r.conf.Channels...)
+	}
 	return nil
 }
 
@@ -131,7 +137,12 @@ func (r *RedisPubSub) Read() (types.Message, error) {
 		return nil, types.ErrTypeClosed
 	}
 
-	return types.NewMessage([][]byte{[]byte(rMsg.Payload)}), nil
+	msg := types.NewMessage([][]byte{[]byte(rMsg.Payload)})
+	meta := msg.GetMetadata(0).Set("redis_pubsub_channel", rMsg.Channel)
+	if len(rMsg.Pattern) > 0 {
+		meta.Set("redis_pubsub_pattern", rMsg.Pattern)
+	}
+	return msg, nil
 }
 
 // Acknowledge instructs whether messages have been successfully propagated.
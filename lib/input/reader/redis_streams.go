@@ -0,0 +1,346 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/go-redis/redis"
+)
+
+//------------------------------------------------------------------------------
+
+// RedisStreamsConfig is configuration for the RedisStreams input type.
+type RedisStreamsConfig struct {
+	URL             string   `json:"url" yaml:"url"`
+	BodyKey         string   `json:"body_key" yaml:"body_key"`
+	Streams         []string `json:"streams" yaml:"streams"`
+	ConsumerGroup   string   `json:"consumer_group" yaml:"consumer_group"`
+	ClientID        string   `json:"client_id" yaml:"client_id"`
+	CreateStreams   bool     `json:"create_streams" yaml:"create_streams"`
+	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
+	ClaimMinIdleMS  int      `json:"claim_min_idle_ms" yaml:"claim_min_idle_ms"`
+	Limit           int64    `json:"limit" yaml:"limit"`
+	TimeoutMS       int      `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
+func NewRedisStreamsConfig() RedisStreamsConfig {
+	return RedisStreamsConfig{
+		URL:             "tcp://localhost:6379",
+		BodyKey:         "body",
+		Streams:         []string{"benthos_stream"},
+		ConsumerGroup:   "benthos_group",
+		ClientID:        "benthos_consumer",
+		CreateStreams:   true,
+		StartFromOldest: true,
+		ClaimMinIdleMS:  0,
+		Limit:           10,
+		TimeoutMS:       5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RedisStreams is an input type that reads Redis Streams messages as a member
+// of a consumer group.
+type RedisStreams struct {
+	client *redis.Client
+	cMut   sync.Mutex
+
+	// backlogs contains the streams that may have entries pending for this
+	// consumer, which are read before any new entries.
+	backlogs map[string]struct{}
+	unAcks   map[string][]string
+
+	url     *url.URL
+	conf    RedisStreamsConfig
+	streams []string
+	timeout time.Duration
+
+	stats metrics.Type
+	log   log.Modular
+}
+
+// NewRedisStreams creates a new RedisStreams input type.
+func NewRedisStreams(
+	conf RedisStreamsConfig, log log.Modular, stats metrics.Type,
+) (*RedisStreams, error) {
+	r := &RedisStreams{
+		conf:     conf,
+		backlogs: map[string]struct{}{},
+		unAcks:   map[string][]string{},
+		timeout:  time.Millisecond * time.Duration(conf.TimeoutMS),
+		stats:    stats,
+		log:      log.NewModule(".input.redis_streams"),
+	}
+
+	for _, s := range conf.Streams {
+		for _, splitS := range strings.Split(s, ",") {
+			if len(splitS) > 0 {
+				r.streams = append(r.streams, splitS)
+			}
+		}
+	}
+	if len(r.streams) == 0 {
+		return nil, errors.New("at least one stream must be specified")
+	}
+	if conf.Limit < 1 {
+		return nil, errors.New("limit must be at least 1")
+	}
+
+	var err error
+	r.url, err = url.Parse(r.conf.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to a Redis server, creates the consumer
+// group of each stream when it does not yet exist and claims pending entries
+// of other consumers that have been idle for too long.
+func (r *RedisStreams) Connect() error {
+	r.cMut.Lock()
+	defer r.cMut.Unlock()
+
+	if r.client != nil {
+		return nil
+	}
+
+	var pass string
+	if r.url.User != nil {
+		pass, _ = r.url.User.Password()
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     r.url.Host,
+		Network:  r.url.Scheme,
+		Password: pass,
+	})
+
+	if _, err := client.Ping().Result(); err != nil {
+		return err
+	}
+
+	offset := "$"
+	if r.conf.StartFromOldest {
+		offset = "0"
+	}
+	for _, s := range r.streams {
+		var err error
+		if r.conf.CreateStreams {
+			err = client.XGroupCreateMkStream(s, r.conf.ConsumerGroup, offset).Err()
+		} else {
+			err = client.XGroupCreate(s, r.conf.ConsumerGroup, offset).Err()
+		}
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			client.Close()
+			return fmt.Errorf("failed to create consumer group for stream '%v': %v", s, err)
+		}
+		if r.conf.ClaimMinIdleMS > 0 {
+			if err = r.claimPending(client, s); err != nil {
+				client.Close()
+				return fmt.Errorf("failed to claim pending entries of stream '%v': %v", s, err)
+			}
+		}
+		r.backlogs[s] = struct{}{}
+	}
+
+	r.log.Infof("Receiving messages from Redis streams %v at URL: %s\n", r.streams, r.conf.URL)
+
+	r.client = client
+	return nil
+}
+
+// claimPending takes ownership of the entries of a stream that are pending for
+// other consumers of the group and have been idle for at least the configured
+// period, which are then read as part of the backlog of this consumer.
+func (r *RedisStreams) claimPending(client *redis.Client, stream string) error {
+	minIdle := time.Millisecond * time.Duration(r.conf.ClaimMinIdleMS)
+	start := "-"
+	for {
+		pending, err := client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  r.conf.ConsumerGroup,
+			Start:  start,
+			End:    "+",
+			Count:  100,
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		var ids []string
+		for _, p := range pending {
+			if p.Consumer != r.conf.ClientID && p.Idle >= minIdle {
+				ids = append(ids, p.Id)
+			}
+		}
+		if len(ids) > 0 {
+			claimed, err := client.XClaimJustID(&redis.XClaimArgs{
+				Stream:   stream,
+				Group:    r.conf.ConsumerGroup,
+				Consumer: r.conf.ClientID,
+				MinIdle:  minIdle,
+				Messages: ids,
+			}).Result()
+			if err != nil {
+				return err
+			}
+			if len(claimed) > 0 {
+				r.log.Infof("Claimed %v pending entries of stream '%v'\n", len(claimed), stream)
+			}
+		}
+
+		if len(pending) < 100 {
+			return nil
+		}
+		start = "(" + pending[len(pending)-1].Id
+	}
+}
+
+// Read attempts to read a batch of entries from the streams, where entries
+// that are pending for this consumer are read before new entries.
+func (r *RedisStreams) Read() (types.Message, error) {
+	var client *redis.Client
+
+	r.cMut.Lock()
+	client = r.client
+	r.cMut.Unlock()
+
+	if client == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	ids := make([]string, len(r.streams))
+	for i, s := range r.streams {
+		if _, exists := r.backlogs[s]; exists {
+			ids[i] = "0"
+		} else {
+			ids[i] = ">"
+		}
+	}
+
+	res, err := client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    r.conf.ConsumerGroup,
+		Consumer: r.conf.ClientID,
+		Streams:  append(append([]string{}, r.streams...), ids...),
+		Count:    r.conf.Limit,
+		Block:    r.timeout,
+	}).Result()
+
+	if err != nil && err != redis.Nil {
+		r.disconnect()
+		r.log.Errorf("Error from redis: %v\n", err)
+		return nil, types.ErrNotConnected
+	}
+
+	msg := types.NewMessage(nil)
+	for _, strRes := range res {
+		if _, exists := r.backlogs[strRes.Stream]; exists && len(strRes.Messages) == 0 {
+			delete(r.backlogs, strRes.Stream)
+		}
+		for _, xMsg := range strRes.Messages {
+			var body []byte
+			if v, exists := xMsg.Values[r.conf.BodyKey]; exists {
+				body = []byte(fmt.Sprintf("%v", v))
+			}
+			part := msg.Append(body)
+			meta := msg.GetMetadata(part)
+			for k, v := range xMsg.Values {
+				if k != r.conf.BodyKey {
+					meta.Set(k, fmt.Sprintf("%v", v))
+				}
+			}
+			meta.Set("redis_stream", strRes.Stream)
+			meta.Set("redis_stream_id", xMsg.ID)
+			r.unAcks[strRes.Stream] = append(r.unAcks[strRes.Stream], xMsg.ID)
+		}
+	}
+
+	if msg.Len() == 0 {
+		return nil, types.ErrTimeout
+	}
+	return msg, nil
+}
+
+// Acknowledge acknowledges the entries read since the last call within their
+// consumer group, and otherwise leaves them pending.
+func (r *RedisStreams) Acknowledge(err error) error {
+	if err != nil {
+		return nil
+	}
+
+	var client *redis.Client
+
+	r.cMut.Lock()
+	client = r.client
+	r.cMut.Unlock()
+
+	if client == nil {
+		return types.ErrNotConnected
+	}
+
+	for stream, ids := range r.unAcks {
+		if err := client.XAck(stream, r.conf.ConsumerGroup, ids...).Err(); err != nil {
+			r.log.Errorf("Failed to acknowledge stream entries: %v\n", err)
+			return err
+		}
+		delete(r.unAcks, stream)
+	}
+	return nil
+}
+
+// disconnect safely closes a connection to a Redis server.
+func (r *RedisStreams) disconnect() error {
+	r.cMut.Lock()
+	defer r.cMut.Unlock()
+
+	var err error
+	if r.client != nil {
+		err = r.client.Close()
+		r.client = nil
+	}
+	return err
+}
+
+// CloseAsync shuts down the RedisStreams input and stops processing requests.
+func (r *RedisStreams) CloseAsync() {
+	r.disconnect()
+}
+
+// WaitForClose blocks until the RedisStreams input has closed down.
+func (r *RedisStreams) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
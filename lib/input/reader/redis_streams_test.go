@@ -0,0 +1,283 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// fakeRedis is a minimal Redis server that records the commands it receives
+// and replies with the raw RESP returned by a handler.
+type fakeRedis struct {
+	listener net.Listener
+	handler  func(args []string) string
+
+	cmds    [][]string
+	cmdsMut sync.Mutex
+}
+
+func newFakeRedis(t *testing.T, handler func(args []string) string) *fakeRedis {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{listener: l, handler: handler}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPArray(r)
+		if err != nil {
+			return
+		}
+		f.cmdsMut.Lock()
+		f.cmds = append(f.cmds, args)
+		f.cmdsMut.Unlock()
+
+		res := "+OK\r\n"
+		if strings.ToUpper(args[0]) == "PING" {
+			res = "+PONG\r\n"
+		} else if f.handler != nil {
+			res = f.handler(args)
+		}
+		if _, err = io.WriteString(conn, res); err != nil {
+			return
+		}
+	}
+}
+
+// commands returns the commands received so far, excluding PING.
+func (f *fakeRedis) commands() [][]string {
+	f.cmdsMut.Lock()
+	defer f.cmdsMut.Unlock()
+	var cmds [][]string
+	for _, c := range f.cmds {
+		if strings.ToUpper(c[0]) != "PING" {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+func (f *fakeRedis) Close() {
+	f.listener.Close()
+}
+
+func readRESPArray(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected request: %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func respBulk(s string) string {
+	return fmt.Sprintf("$%v\r\n%v\r\n", len(s), s)
+}
+
+func respArray(elems ...string) string {
+	return fmt.Sprintf("*%v\r\n%v", len(elems), strings.Join(elems, ""))
+}
+
+//------------------------------------------------------------------------------
+
+func TestRedisStreamsClaimReadAck(t *testing.T) {
+	reads := []string{
+		// The backlog of this consumer, containing the claimed entry.
+		respArray(respArray(respBulk("foo"), respArray(
+			respArray(respBulk("1-0"), respArray(
+				respBulk("body"), respBulk("hello world"),
+				respBulk("baz"), respBulk("qux"),
+			)),
+		))),
+		// The backlog is exhausted.
+		respArray(respArray(respBulk("foo"), respArray())),
+		// No new entries.
+		"*-1\r\n",
+	}
+	var readsMut sync.Mutex
+
+	f := newFakeRedis(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "XPENDING":
+			return respArray(
+				respArray(respBulk("1-0"), respBulk("c2"), ":5000\r\n", ":1\r\n"),
+				respArray(respBulk("2-0"), respBulk("c1"), ":5000\r\n", ":1\r\n"),
+				respArray(respBulk("3-0"), respBulk("c2"), ":10\r\n", ":1\r\n"),
+			)
+		case "XCLAIM":
+			return respArray(respBulk("1-0"))
+		case "XREADGROUP":
+			readsMut.Lock()
+			defer readsMut.Unlock()
+			res := reads[0]
+			if len(reads) > 1 {
+				reads = reads[1:]
+			}
+			return res
+		case "XACK":
+			return fmt.Sprintf(":%v\r\n", len(args)-3)
+		}
+		return "+OK\r\n"
+	})
+	defer f.Close()
+
+	conf := NewRedisStreamsConfig()
+	conf.URL = "tcp://" + f.listener.Addr().String()
+	conf.Streams = []string{"foo"}
+	conf.ClientID = "c1"
+	conf.ClaimMinIdleMS = 1000
+	conf.TimeoutMS = 100
+
+	r, err := NewRedisStreams(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.CloseAsync()
+
+	if err = r.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := [][]byte{[]byte("hello world")}, msg.GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong message contents: %s != %s", act, exp)
+	}
+	meta := msg.GetMetadata(0)
+	if exp, act := "qux", meta.Get("baz"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "1-0", meta.Get("redis_stream_id"); exp != act {
+		t.Errorf("Wrong stream ID: %v != %v", act, exp)
+	}
+
+	// Failed messages are not acknowledged.
+	if err = r.Acknowledge(errors.New("failed")); err != nil {
+		t.Error(err)
+	}
+	if err = r.Acknowledge(nil); err != nil {
+		t.Error(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err = r.Read(); err != types.ErrTimeout {
+			t.Errorf("Wrong error from read %v: %v != %v", i, err, types.ErrTimeout)
+		}
+	}
+
+	exp := [][]string{
+		{"xgroup", "create", "foo", "benthos_group", "0", "mkstream"},
+		{"xpending", "foo", "benthos_group", "-", "+", "100"},
+		{"xclaim", "foo", "benthos_group", "c1", "1000", "1-0", "justid"},
+		{"xreadgroup", "group", "benthos_group", "c1", "count", "10", "block", "100", "streams", "foo", "0"},
+		{"xack", "foo", "benthos_group", "1-0"},
+		{"xreadgroup", "group", "benthos_group", "c1", "count", "10", "block", "100", "streams", "foo", "0"},
+		{"xreadgroup", "group", "benthos_group", "c1", "count", "10", "block", "100", "streams", "foo", ">"},
+	}
+	act := f.commands()
+	for _, c := range act {
+		for i := range c {
+			c[i] = strings.ToLower(c[i])
+		}
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong commands:\n%v\n!=\n%v", act, exp)
+	}
+}
+
+func TestRedisStreamsBusyGroup(t *testing.T) {
+	f := newFakeRedis(t, func(args []string) string {
+		if strings.ToUpper(args[0]) == "XGROUP" {
+			return "-BUSYGROUP Consumer Group name already exists\r\n"
+		}
+		return "+OK\r\n"
+	})
+	defer f.Close()
+
+	conf := NewRedisStreamsConfig()
+	conf.URL = "tcp://" + f.listener.Addr().String()
+
+	r, err := NewRedisStreams(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.CloseAsync()
+
+	if err = r.Connect(); err != nil {
+		t.Errorf("Expected existing group to be tolerated: %v", err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,377 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/socket"
+)
+
+//------------------------------------------------------------------------------
+
+// SocketServerConfig is configuration for the SocketServer input type.
+type SocketServerConfig struct {
+	Network   string `json:"network" yaml:"network"`
+	Address   string `json:"address" yaml:"address"`
+	Framing   string `json:"framing" yaml:"framing"`
+	Delimiter string `json:"delimiter" yaml:"delimiter"`
+	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
+}
+
+// NewSocketServerConfig creates a new SocketServerConfig with default values.
+func NewSocketServerConfig() SocketServerConfig {
+	return SocketServerConfig{
+		Network:   "tcp",
+		Address:   "0.0.0.0:4198",
+		Framing:   "lines",
+		Delimiter: "",
+		MaxBuffer: 1000000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SocketServer is an input type that listens for raw data over TCP, UDP or unix
+// domain sockets.
+type SocketServer struct {
+	cMut      sync.Mutex
+	closing   bool
+	packetCon net.PacketConn
+	listener  net.Listener
+	conns     map[net.Conn]struct{}
+
+	conf      SocketServerConfig
+	network   string
+	address   string
+	delimiter []byte
+
+	msgChan       chan types.Message
+	interruptChan chan struct{}
+	loopsWG       sync.WaitGroup
+
+	stats metrics.Type
+	log   log.Modular
+}
+
+// NewSocketServer creates a new SocketServer input type.
+func NewSocketServer(
+	conf SocketServerConfig, log log.Modular, stats metrics.Type,
+) (*SocketServer, error) {
+	network, address, err := socket.Resolve(conf.Network, conf.Address)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Framing {
+	case "lines", "length_prefixed":
+	default:
+		return nil, fmt.Errorf("socket framing not recognised: %v", conf.Framing)
+	}
+	delim := conf.Delimiter
+	if len(delim) == 0 {
+		delim = "\n"
+	}
+	return &SocketServer{
+		conf:          conf,
+		network:       network,
+		address:       address,
+		delimiter:     []byte(delim),
+		conns:         map[net.Conn]struct{}{},
+		msgChan:       make(chan types.Message),
+		interruptChan: make(chan struct{}),
+		stats:         stats,
+		log:           log.NewModule(".input.socket_server"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect starts listening for data.
+func (s *SocketServer) Connect() error {
+	s.cMut.Lock()
+	defer s.cMut.Unlock()
+
+	if s.closing {
+		return types.ErrTypeClosed
+	}
+	if s.packetCon != nil || s.listener != nil {
+		return nil
+	}
+
+	if err := s.removeStaleSocket(); err != nil {
+		return err
+	}
+
+	if socket.IsPacket(s.network) {
+		conn, err := net.ListenPacket(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.packetCon = conn
+		s.loopsWG.Add(1)
+		go s.packetLoop(conn)
+		s.log.Infof("Receiving %v datagrams at: %v\n", s.network, conn.LocalAddr())
+		return nil
+	}
+
+	listener, err := net.Listen(s.network, s.address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.loopsWG.Add(1)
+	go s.acceptLoop(listener)
+	s.log.Infof("Receiving %v connections at: %v\n", s.network, listener.Addr())
+	return nil
+}
+
+// removeStaleSocket removes an existing unix domain socket file at the address,
+// which would otherwise prevent listening on it.
+func (s *SocketServer) removeStaleSocket() error {
+	if s.network != "unix" && s.network != "unixgram" {
+		return nil
+	}
+	info, err := os.Stat(s.address)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("address is not a unix domain socket: %v", s.address)
+	}
+	return os.Remove(s.address)
+}
+
+//------------------------------------------------------------------------------
+
+func (s *SocketServer) send(b []byte) bool {
+	select {
+	case s.msgChan <- types.NewMessage([][]byte{b}):
+		return true
+	case <-s.interruptChan:
+	}
+	return false
+}
+
+func (s *SocketServer) packetLoop(conn net.PacketConn) {
+	defer s.loopsWG.Done()
+
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.interruptChan:
+			default:
+				s.log.Errorf("Failed to read datagram: %v\n", err)
+			}
+			return
+		}
+		b := buf[:n]
+		if s.conf.Framing == "lines" {
+			b = bytes.TrimSuffix(b, s.delimiter)
+		}
+		msgBytes := make([]byte, len(b))
+		copy(msgBytes, b)
+		if !s.send(msgBytes) {
+			return
+		}
+	}
+}
+
+func (s *SocketServer) acceptLoop(listener net.Listener) {
+	defer s.loopsWG.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.interruptChan:
+			default:
+				s.log.Errorf("Failed to accept connection: %v\n", err)
+			}
+			return
+		}
+
+		s.cMut.Lock()
+		if s.closing {
+			s.cMut.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.loopsWG.Add(1)
+		s.cMut.Unlock()
+
+		go s.connLoop(conn)
+	}
+}
+
+func (s *SocketServer) connLoop(conn net.Conn) {
+	defer func() {
+		s.cMut.Lock()
+		delete(s.conns, conn)
+		s.cMut.Unlock()
+		conn.Close()
+		s.loopsWG.Done()
+	}()
+
+	var err error
+	if s.conf.Framing == "length_prefixed" {
+		err = s.readLengthPrefixed(conn)
+	} else {
+		err = s.readLines(conn)
+	}
+	if err != nil {
+		select {
+		case <-s.interruptChan:
+		default:
+			s.log.Errorf("Failed to read from connection: %v\n", err)
+		}
+	}
+}
+
+// readLines reads messages from a connection that are each followed by the
+// delimiter until the connection is closed.
+func (s *SocketServer) readLines(conn net.Conn) error {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer([]byte{}, s.conf.MaxBuffer)
+	scanner.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, s.delimiter); i >= 0 {
+			return i + len(s.delimiter), data[0:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		msgBytes := make([]byte, len(scanner.Bytes()))
+		copy(msgBytes, scanner.Bytes())
+		if !s.send(msgBytes) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// readLengthPrefixed reads messages from a connection that are each prefixed
+// with their length as a four byte big endian integer until the connection is
+// closed.
+func (s *SocketServer) readLengthPrefixed(conn net.Conn) error {
+	r := bufio.NewReader(conn)
+	for {
+		var size uint32
+		if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if int64(size) > int64(s.conf.MaxBuffer) {
+			return errors.New("message length exceeds max buffer")
+		}
+		msgBytes := make([]byte, size)
+		if _, err := io.ReadFull(r, msgBytes); err != nil {
+			return err
+		}
+		if !s.send(msgBytes) {
+			return nil
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Read attempts to read a new message from the socket.
+func (s *SocketServer) Read() (types.Message, error) {
+	select {
+	case msg := <-s.msgChan:
+		return msg, nil
+	case <-s.interruptChan:
+	}
+	return nil, types.ErrTypeClosed
+}
+
+// Acknowledge instructs whether messages have been successfully propagated.
+func (s *SocketServer) Acknowledge(err error) error {
+	return nil
+}
+
+// CloseAsync shuts down the SocketServer input and stops processing requests.
+func (s *SocketServer) CloseAsync() {
+	s.cMut.Lock()
+	if !s.closing {
+		s.closing = true
+		close(s.interruptChan)
+		if s.packetCon != nil {
+			s.packetCon.Close()
+			if s.network == "unixgram" {
+				os.Remove(s.address)
+			}
+		}
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		for conn := range s.conns {
+			conn.Close()
+		}
+	}
+	s.cMut.Unlock()
+}
+
+// WaitForClose blocks until the SocketServer input has closed down.
+func (s *SocketServer) WaitForClose(timeout time.Duration) error {
+	doneChan := make(chan struct{})
+	go func() {
+		s.loopsWG.Wait()
+		close(doneChan)
+	}()
+	select {
+	case <-doneChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func testSocketServer(t *testing.T, conf SocketServerConfig, input [][]byte, exp []string) {
+	if len(conf.Address) == 0 {
+		conf.Address = "localhost:0"
+	}
+
+	r, err := NewSocketServer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		r.CloseAsync()
+		if err := r.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if err = r.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var addr string
+	if r.packetCon != nil {
+		addr = r.packetCon.LocalAddr().String()
+	} else {
+		addr = r.listener.Addr().String()
+	}
+	go func() {
+		conn, err := net.Dial(r.network, addr)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for _, b := range input {
+			if _, err = conn.Write(b); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for _, e := range exp {
+		msg, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); e != act {
+			t.Errorf("Wrong message contents: %v != %v", act, e)
+		}
+	}
+}
+
+func TestSocketServerTCPLines(t *testing.T) {
+	conf := NewSocketServerConfig()
+	testSocketServer(t, conf, [][]byte{
+		[]byte("foo\nbar\n"),
+		[]byte("\nba"),
+		[]byte("z"),
+	}, []string{"foo", "bar", "baz"})
+}
+
+func TestSocketServerTCPCustomDelim(t *testing.T) {
+	conf := NewSocketServerConfig()
+	conf.Delimiter = "||"
+	testSocketServer(t, conf, [][]byte{
+		[]byte("foo\n||bar||baz"),
+	}, []string{"foo\n", "bar", "baz"})
+}
+
+func TestSocketServerTCPLengthPrefixed(t *testing.T) {
+	conf := NewSocketServerConfig()
+	conf.Framing = "length_prefixed"
+	testSocketServer(t, conf, [][]byte{
+		[]byte("\x00\x00\x00\x04foo\n\x00\x00"),
+		[]byte("\x00\x03bar"),
+	}, []string{"foo\n", "bar"})
+}
+
+func TestSocketServerUDP(t *testing.T) {
+	conf := NewSocketServerConfig()
+	conf.Network = "udp"
+	testSocketServer(t, conf, [][]byte{
+		[]byte("foo\n"),
+		[]byte("bar\nbaz"),
+	}, []string{"foo", "bar\nbaz"})
+}
+
+func TestSocketServerUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_socket_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewSocketServerConfig()
+	conf.Address = "unix://" + filepath.Join(dir, "benthos.sock")
+	testSocketServer(t, conf, [][]byte{
+		[]byte("foo\nbar\n"),
+	}, []string{"foo", "bar"})
+
+	// Listening again must replace the socket left by the previous listener.
+	testSocketServer(t, conf, [][]byte{
+		[]byte("baz\n"),
+	}, []string{"baz"})
+}
+
+func TestSocketServerUnixgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_socket_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewSocketServerConfig()
+	conf.Network = "udp"
+	conf.Address = "unix://" + filepath.Join(dir, "benthos.sock")
+	testSocketServer(t, conf, [][]byte{
+		[]byte("foo\n"),
+		[]byte("bar"),
+	}, []string{"foo", "bar"})
+}
+
+func TestSocketServerBadConfig(t *testing.T) {
+	conf := NewSocketServerConfig()
+	conf.Network = "nope"
+	if _, err := NewSocketServer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad network")
+	}
+
+	conf = NewSocketServerConfig()
+	conf.Framing = "nope"
+	if _, err := NewSocketServer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad framing")
+	}
+}
+
+//------------------------------------------------------------------------------
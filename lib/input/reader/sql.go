@@ -0,0 +1,304 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+
+	// SQL drivers supported by the sql input.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+//------------------------------------------------------------------------------
+
+// SQLConfig is configuration for the SQL input type.
+type SQLConfig struct {
+	Driver         string `json:"driver" yaml:"driver"`
+	DSN            string `json:"dsn" yaml:"dsn"`
+	Query          string `json:"query" yaml:"query"`
+	CursorColumn   string `json:"cursor_column" yaml:"cursor_column"`
+	CursorInitial  string `json:"cursor_initial" yaml:"cursor_initial"`
+	CursorFile     string `json:"cursor_file" yaml:"cursor_file"`
+	PollIntervalMS int    `json:"poll_interval_ms" yaml:"poll_interval_ms"`
+}
+
+// NewSQLConfig creates a new SQLConfig with default values.
+func NewSQLConfig() SQLConfig {
+	return SQLConfig{
+		Driver:         "mysql",
+		DSN:            "",
+		Query:          "SELECT * FROM footable WHERE id > ? ORDER BY id ASC LIMIT 100;",
+		CursorColumn:   "id",
+		CursorInitial:  "0",
+		CursorFile:     "",
+		PollIntervalMS: 1000,
+	}
+}
+
+// sqlDrivers is the set of drivers supported by the SQL reader.
+var sqlDrivers = map[string]struct{}{
+	"mysql":    {},
+	"postgres": {},
+}
+
+//------------------------------------------------------------------------------
+
+// sqlRow is a row read from a query, with its cursor value.
+type sqlRow struct {
+	body   []byte
+	cursor string
+}
+
+// SQL is a reader type that polls a table with a query, where the position of
+// the last row read is tracked by a cursor column.
+type SQL struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf         SQLConfig
+	pollInterval time.Duration
+
+	db   *sql.DB
+	stmt *sql.Stmt
+
+	rows         []sqlRow
+	readCursor   string
+	ackedCursor  string
+	nextPollTime time.Time
+
+	closeChan chan struct{}
+}
+
+// NewSQL creates a new SQL reader type.
+func NewSQL(
+	conf SQLConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*SQL, error) {
+	if _, exists := sqlDrivers[conf.Driver]; !exists {
+		return nil, fmt.Errorf("driver not supported: %v", conf.Driver)
+	}
+	if len(conf.CursorColumn) == 0 {
+		return nil, errors.New("a cursor column must be specified")
+	}
+
+	s := &SQL{
+		log:          log.NewModule(".input.sql"),
+		stats:        stats,
+		conf:         conf,
+		pollInterval: time.Duration(conf.PollIntervalMS) * time.Millisecond,
+		readCursor:   conf.CursorInitial,
+		closeChan:    make(chan struct{}),
+	}
+
+	if len(conf.CursorFile) > 0 {
+		cursorBytes, err := ioutil.ReadFile(conf.CursorFile)
+		if err == nil {
+			s.readCursor = strings.TrimSpace(string(cursorBytes))
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read cursor file: %v", err)
+		}
+	}
+	s.ackedCursor = s.readCursor
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect opens a connection pool to the database and prepares the query.
+func (s *SQL) Connect() error {
+	if s.db != nil {
+		return nil
+	}
+
+	db, err := sql.Open(s.conf.Driver, s.conf.DSN)
+	if err != nil {
+		return err
+	}
+	if err = s.connect(db); err != nil {
+		db.Close()
+		return err
+	}
+
+	s.log.Infof("Polling %v database from cursor: %v\n", s.conf.Driver, s.readCursor)
+	return nil
+}
+
+// connect prepares the query of a database.
+func (s *SQL) connect(db *sql.DB) error {
+	if err := db.Ping(); err != nil {
+		return err
+	}
+	stmt, err := db.Prepare(s.conf.Query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare query: %v", err)
+	}
+
+	s.db = db
+	s.stmt = stmt
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// sqlValue converts a value scanned from a row into a type that marshals into
+// readable JSON.
+func sqlValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case []byte:
+		return string(t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	return v
+}
+
+// poll executes the query from the current cursor and buffers the resulting
+// rows.
+func (s *SQL) poll() error {
+	rows, err := s.stmt.Query(s.readCursor)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	cursorIndex := -1
+	for i, c := range columns {
+		if c == s.conf.CursorColumn {
+			cursorIndex = i
+		}
+	}
+	if cursorIndex < 0 {
+		return fmt.Errorf("cursor column '%v' not found in query results", s.conf.CursorColumn)
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err = rows.Scan(valuePtrs...); err != nil {
+			return err
+		}
+
+		jObj := make(map[string]interface{}, len(columns))
+		for i, c := range columns {
+			jObj[c] = sqlValue(values[i])
+		}
+
+		var body []byte
+		if body, err = json.Marshal(jObj); err != nil {
+			return err
+		}
+		s.rows = append(s.rows, sqlRow{
+			body:   body,
+			cursor: fmt.Sprintf("%v", jObj[s.conf.CursorColumn]),
+		})
+	}
+	return rows.Err()
+}
+
+// Read attempts to read a new row from the table as a message.
+func (s *SQL) Read() (types.Message, error) {
+	if s.db == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	if len(s.rows) == 0 {
+		if wait := time.Until(s.nextPollTime); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-s.closeChan:
+				return nil, types.ErrTypeClosed
+			}
+		}
+		s.nextPollTime = time.Now().Add(s.pollInterval)
+		if err := s.poll(); err != nil {
+			s.rows = nil
+			return nil, err
+		}
+		if len(s.rows) == 0 {
+			return nil, types.ErrTimeout
+		}
+	}
+
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	s.readCursor = row.cursor
+
+	msg := types.NewMessage([][]byte{row.body})
+	msg.GetMetadata(0).Set("sql_cursor", row.cursor)
+	return msg, nil
+}
+
+// Acknowledge instructs whether the rows read since the last Acknowledge call
+// were successfully propagated, in which case the cursor is persisted.
+func (s *SQL) Acknowledge(err error) error {
+	if err != nil || s.ackedCursor == s.readCursor {
+		return nil
+	}
+	s.ackedCursor = s.readCursor
+	if len(s.conf.CursorFile) == 0 {
+		return nil
+	}
+
+	tmpPath := s.conf.CursorFile + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, []byte(s.ackedCursor), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.conf.CursorFile)
+}
+
+// CloseAsync shuts down the SQL reader and stops processing messages.
+func (s *SQL) CloseAsync() {
+	close(s.closeChan)
+	if s.stmt != nil {
+		s.stmt.Close()
+	}
+	if s.db != nil {
+		s.db.Close()
+	}
+}
+
+// WaitForClose blocks until the SQL reader has closed down.
+func (s *SQL) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newTestSQL(t *testing.T, conf SQLConfig) (*SQL, sqlmock.Sqlmock) {
+	s, err := NewSQL(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectPrepare("SELECT")
+	if err = s.connect(db); err != nil {
+		t.Fatal(err)
+	}
+	return s, mock
+}
+
+func TestSQLBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewSQLConfig()
+	conf.Driver = "nope"
+	if _, err := NewSQL(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad driver")
+	}
+
+	conf = NewSQLConfig()
+	conf.CursorColumn = ""
+	if _, err := NewSQL(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing cursor column")
+	}
+}
+
+func TestSQLReadCursor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_sql_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewSQLConfig()
+	conf.CursorFile = filepath.Join(dir, "cursor")
+	conf.PollIntervalMS = 1
+
+	s, mock := newTestSQL(t, conf)
+
+	mock.ExpectQuery("SELECT").WithArgs("0").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "foo").
+			AddRow(2, "bar"),
+	)
+	mock.ExpectQuery("SELECT").WithArgs("2").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}),
+	)
+
+	exp := []string{`{"id":1,"name":"foo"}`, `{"id":2,"name":"bar"}`}
+	for i, e := range exp {
+		msg, err := s.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); act != e {
+			t.Errorf("Wrong row %v: %v != %v", i, act, e)
+		}
+	}
+
+	if _, err = s.Read(); err != types.ErrTimeout {
+		t.Errorf("Expected timeout from empty poll, received: %v", err)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	if err = s.Acknowledge(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	cursorBytes, err := ioutil.ReadFile(conf.CursorFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "2", string(cursorBytes); exp != act {
+		t.Errorf("Wrong persisted cursor: %v != %v", act, exp)
+	}
+
+	// A new reader should resume from the persisted cursor.
+	s2, mock2 := newTestSQL(t, conf)
+	mock2.ExpectQuery("SELECT").WithArgs("2").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "baz"),
+	)
+
+	msg, err := s2.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "3", msg.GetMetadata(0).Get("sql_cursor"); exp != act {
+		t.Errorf("Wrong cursor metadata: %v != %v", act, exp)
+	}
+	if err = mock2.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLMissingCursorColumn(t *testing.T) {
+	s, mock := newTestSQL(t, NewSQLConfig())
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).AddRow("foo"),
+	)
+
+	if _, err := s.Read(); err == nil {
+		t.Error("Expected error from missing cursor column")
+	}
+}
+
+//------------------------------------------------------------------------------
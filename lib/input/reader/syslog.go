@@ -0,0 +1,355 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// syslogMaxFrameSize is the largest octet counted frame accepted over TCP.
+const syslogMaxFrameSize = 1024 * 1024
+
+// SyslogConfig is configuration for the Syslog input type.
+type SyslogConfig struct {
+	Address  string `json:"address" yaml:"address"`
+	Protocol string `json:"protocol" yaml:"protocol"`
+	Format   string `json:"format" yaml:"format"`
+}
+
+// NewSyslogConfig creates a new SyslogConfig with default values.
+func NewSyslogConfig() SyslogConfig {
+	return SyslogConfig{
+		Address:  "0.0.0.0:5140",
+		Protocol: "udp",
+		Format:   "auto",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Syslog is an input type that listens for syslog messages over UDP or TCP.
+type Syslog struct {
+	cMut      sync.Mutex
+	closing   bool
+	packetCon net.PacketConn
+	listener  net.Listener
+	conns     map[net.Conn]struct{}
+
+	conf SyslogConfig
+
+	msgChan       chan types.Message
+	interruptChan chan struct{}
+	loopsWG       sync.WaitGroup
+
+	stats metrics.Type
+	log   log.Modular
+
+	mParseErr metrics.StatCounter
+}
+
+// NewSyslog creates a new Syslog input type.
+func NewSyslog(
+	conf SyslogConfig, log log.Modular, stats metrics.Type,
+) (*Syslog, error) {
+	switch conf.Protocol {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("syslog protocol not recognised: %v", conf.Protocol)
+	}
+	switch conf.Format {
+	case "auto", "rfc3164", "rfc5424":
+	default:
+		return nil, fmt.Errorf("syslog format not recognised: %v", conf.Format)
+	}
+	return &Syslog{
+		conf:          conf,
+		conns:         map[net.Conn]struct{}{},
+		msgChan:       make(chan types.Message),
+		interruptChan: make(chan struct{}),
+		stats:         stats,
+		log:           log.NewModule(".input.syslog"),
+		mParseErr:     stats.GetCounter("input.syslog.parse.error"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect starts listening for syslog messages.
+func (s *Syslog) Connect() error {
+	s.cMut.Lock()
+	defer s.cMut.Unlock()
+
+	if s.closing {
+		return types.ErrTypeClosed
+	}
+	if s.packetCon != nil || s.listener != nil {
+		return nil
+	}
+
+	if s.conf.Protocol == "udp" {
+		conn, err := net.ListenPacket("udp", s.conf.Address)
+		if err != nil {
+			return err
+		}
+		s.packetCon = conn
+		s.loopsWG.Add(1)
+		go s.packetLoop(conn)
+		s.log.Infof("Receiving syslog messages over UDP at: %v\n", conn.LocalAddr())
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.conf.Address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.loopsWG.Add(1)
+	go s.acceptLoop(listener)
+	s.log.Infof("Receiving syslog messages over TCP at: %v\n", listener.Addr())
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// toMessage parses a syslog message into a Benthos message, where the header
+// fields are added as metadata. Messages that cannot be parsed are passed on
+// unchanged.
+func (s *Syslog) toMessage(b []byte, addr net.Addr) types.Message {
+	msg := types.NewMessage(nil)
+	parsed, err := parseSyslog(s.conf.Format, b)
+	if err != nil {
+		s.mParseErr.Incr(1)
+		s.log.Debugf("Failed to parse syslog message: %v\n", err)
+		meta := msg.GetMetadata(msg.Append(b))
+		meta.Set("syslog_remote_address", addr.String())
+		return msg
+	}
+
+	meta := msg.GetMetadata(msg.Append(parsed.body))
+	meta.Set("syslog_facility", strconv.Itoa(parsed.facility)).
+		Set("syslog_severity", strconv.Itoa(parsed.severity)).
+		Set("syslog_remote_address", addr.String())
+	for k, v := range map[string]string{
+		"syslog_timestamp":       parsed.timestamp,
+		"syslog_hostname":        parsed.hostname,
+		"syslog_appname":         parsed.appname,
+		"syslog_procid":          parsed.procID,
+		"syslog_msgid":           parsed.msgID,
+		"syslog_structured_data": parsed.structuredData,
+	} {
+		if len(v) > 0 {
+			meta.Set(k, v)
+		}
+	}
+	return msg
+}
+
+func (s *Syslog) send(msg types.Message) bool {
+	select {
+	case s.msgChan <- msg:
+		return true
+	case <-s.interruptChan:
+	}
+	return false
+}
+
+func (s *Syslog) packetLoop(conn net.PacketConn) {
+	defer s.loopsWG.Done()
+
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.interruptChan:
+			default:
+				s.log.Errorf("Failed to read UDP datagram: %v\n", err)
+			}
+			return
+		}
+		b := bytes.TrimRight(buf[:n], "\r\n")
+		msgBytes := make([]byte, len(b))
+		copy(msgBytes, b)
+		if !s.send(s.toMessage(msgBytes, addr)) {
+			return
+		}
+	}
+}
+
+func (s *Syslog) acceptLoop(listener net.Listener) {
+	defer s.loopsWG.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.interruptChan:
+			default:
+				s.log.Errorf("Failed to accept TCP connection: %v\n", err)
+			}
+			return
+		}
+
+		s.cMut.Lock()
+		if s.closing {
+			s.cMut.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.loopsWG.Add(1)
+		s.cMut.Unlock()
+
+		go s.connLoop(conn)
+	}
+}
+
+func (s *Syslog) connLoop(conn net.Conn) {
+	defer func() {
+		s.cMut.Lock()
+		delete(s.conns, conn)
+		s.cMut.Unlock()
+		conn.Close()
+		s.loopsWG.Done()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		b, err := readSyslogFrame(r)
+		if err != nil {
+			if err != io.EOF {
+				select {
+				case <-s.interruptChan:
+				default:
+					s.log.Errorf("Failed to read from TCP connection: %v\n", err)
+				}
+			}
+			return
+		}
+		if len(b) == 0 {
+			continue
+		}
+		if !s.send(s.toMessage(b, conn.RemoteAddr())) {
+			return
+		}
+	}
+}
+
+// readSyslogFrame reads a single syslog message from a TCP stream. Messages are
+// expected to be framed either with octet counting, where the message is
+// prefixed with its length and a space, or by a trailing newline.
+func readSyslogFrame(r *bufio.Reader) ([]byte, error) {
+	first, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if first[0] < '0' || first[0] > '9' {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF && len(b) > 0 {
+			err = nil
+		}
+		return bytes.TrimRight(b, "\r\n"), err
+	}
+
+	lenStr, err := r.ReadString(' ')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(lenStr[:len(lenStr)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid octet count: %v", err)
+	}
+	if n > syslogMaxFrameSize {
+		return nil, errors.New("octet count exceeds maximum frame size")
+	}
+	b := make([]byte, n)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Read attempts to read a new syslog message.
+func (s *Syslog) Read() (types.Message, error) {
+	select {
+	case msg := <-s.msgChan:
+		return msg, nil
+	case <-s.interruptChan:
+	}
+	return nil, types.ErrTypeClosed
+}
+
+// Acknowledge instructs whether messages have been successfully propagated.
+func (s *Syslog) Acknowledge(err error) error {
+	return nil
+}
+
+// CloseAsync shuts down the Syslog input and stops processing requests.
+func (s *Syslog) CloseAsync() {
+	s.cMut.Lock()
+	if !s.closing {
+		s.closing = true
+		close(s.interruptChan)
+		if s.packetCon != nil {
+			s.packetCon.Close()
+		}
+		if s.listener != nil {
+			s.listener.Close()
+		}
+		for conn := range s.conns {
+			conn.Close()
+		}
+	}
+	s.cMut.Unlock()
+}
+
+// WaitForClose blocks until the Syslog input has closed down.
+func (s *Syslog) WaitForClose(timeout time.Duration) error {
+	doneChan := make(chan struct{})
+	go func() {
+		s.loopsWG.Wait()
+		close(doneChan)
+	}()
+	select {
+	case <-doneChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
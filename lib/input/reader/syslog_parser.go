@@ -0,0 +1,242 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+var (
+	errSyslogPriority  = errors.New("invalid syslog priority")
+	errSyslogHeader    = errors.New("invalid syslog header")
+	errSyslogStructure = errors.New("invalid syslog structured data")
+)
+
+// syslogMessage is a parsed syslog message. Header fields that are absent from
+// a message, or set to the nil value "-", are left empty.
+type syslogMessage struct {
+	facility int
+	severity int
+
+	timestamp      string
+	hostname       string
+	appname        string
+	procID         string
+	msgID          string
+	structuredData string
+
+	body []byte
+}
+
+//------------------------------------------------------------------------------
+
+// parseSyslog parses a syslog message of the given format, which is either
+// rfc3164, rfc5424 or auto. When the format is auto the message is parsed as
+// rfc5424 if a version follows its priority, otherwise as rfc3164.
+func parseSyslog(format string, b []byte) (*syslogMessage, error) {
+	msg, rest, err := parseSyslogPriority(b)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "rfc5424":
+		err = parseSyslog5424(msg, rest)
+	case "rfc3164":
+		parseSyslog3164(msg, rest)
+	default:
+		if isSyslog5424(rest) {
+			err = parseSyslog5424(msg, rest)
+		} else {
+			parseSyslog3164(msg, rest)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// parseSyslogPriority parses the priority of a message, in the form <PRI>, and
+// returns the remainder of the message.
+func parseSyslogPriority(b []byte) (*syslogMessage, []byte, error) {
+	if len(b) < 3 || b[0] != '<' {
+		return nil, nil, errSyslogPriority
+	}
+	end := bytes.IndexByte(b, '>')
+	if end < 2 || end > 4 {
+		return nil, nil, errSyslogPriority
+	}
+	pri, err := strconv.Atoi(string(b[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return nil, nil, errSyslogPriority
+	}
+	return &syslogMessage{
+		facility: pri / 8,
+		severity: pri % 8,
+	}, b[end+1:], nil
+}
+
+// isSyslog5424 returns true if the remainder of a message after its priority
+// begins with a version, which is only present in rfc5424 messages.
+func isSyslog5424(b []byte) bool {
+	i := 0
+	for ; i < len(b) && i < 3 && b[i] >= '0' && b[i] <= '9'; i++ {
+	}
+	return i > 0 && i < len(b) && b[i] == ' '
+}
+
+// nextSyslogField returns the next space delimited field of a message along
+// with the remainder of the message.
+func nextSyslogField(b []byte) (string, []byte) {
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		return string(b[:i]), b[i+1:]
+	}
+	return string(b), nil
+}
+
+func syslogNilValue(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+//------------------------------------------------------------------------------
+
+// parseSyslog5424 parses the remainder of an rfc5424 message after its
+// priority, in the form:
+// VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]
+func parseSyslog5424(msg *syslogMessage, b []byte) error {
+	if !isSyslog5424(b) {
+		return errSyslogHeader
+	}
+	_, b = nextSyslogField(b)
+
+	fields := []*string{
+		&msg.timestamp, &msg.hostname, &msg.appname, &msg.procID, &msg.msgID,
+	}
+	for _, f := range fields {
+		if len(b) == 0 {
+			return errSyslogHeader
+		}
+		var v string
+		v, b = nextSyslogField(b)
+		*f = syslogNilValue(v)
+	}
+
+	if len(b) == 0 {
+		return errSyslogHeader
+	}
+	if b[0] == '-' {
+		b = b[1:]
+	} else {
+		end, err := syslogStructuredDataEnd(b)
+		if err != nil {
+			return err
+		}
+		msg.structuredData = string(b[:end])
+		b = b[end:]
+	}
+
+	if len(b) > 0 {
+		if b[0] != ' ' {
+			return errSyslogStructure
+		}
+		b = bytes.TrimPrefix(b[1:], []byte("\xef\xbb\xbf"))
+	}
+	msg.body = b
+	return nil
+}
+
+// syslogStructuredDataEnd returns the index following the last element of the
+// structured data at the start of a message, where each element is enclosed in
+// square brackets and may contain quoted parameter values with escaped
+// characters.
+func syslogStructuredDataEnd(b []byte) (int, error) {
+	i := 0
+	for i < len(b) && b[i] == '[' {
+		inQuote := false
+		closed := false
+		for i++; i < len(b) && !closed; i++ {
+			switch b[i] {
+			case '\\':
+				if inQuote {
+					i++
+				}
+			case '"':
+				inQuote = !inQuote
+			case ']':
+				closed = !inQuote
+			}
+		}
+		if !closed {
+			return 0, errSyslogStructure
+		}
+	}
+	if i == 0 {
+		return 0, errSyslogStructure
+	}
+	return i, nil
+}
+
+//------------------------------------------------------------------------------
+
+// parseSyslog3164 parses the remainder of an rfc3164 message after its
+// priority, in the form: TIMESTAMP HOSTNAME TAG[PID]: MSG
+//
+// As with relays that follow rfc3164, when the message does not begin with a
+// valid timestamp the remainder is treated as the message without a header.
+func parseSyslog3164(msg *syslogMessage, b []byte) {
+	if len(b) < len(time.Stamp)+1 || b[len(time.Stamp)] != ' ' {
+		msg.body = b
+		return
+	}
+	if _, err := time.Parse(time.Stamp, string(b[:len(time.Stamp)])); err != nil {
+		msg.body = b
+		return
+	}
+	msg.timestamp = string(b[:len(time.Stamp)])
+	msg.hostname, b = nextSyslogField(b[len(time.Stamp)+1:])
+
+	tagEnd := bytes.IndexAny(b, "[: ")
+	if tagEnd <= 0 {
+		msg.body = b
+		return
+	}
+	msg.appname = string(b[:tagEnd])
+	b = b[tagEnd:]
+
+	if b[0] == '[' {
+		if pidEnd := bytes.IndexByte(b, ']'); pidEnd > 0 {
+			msg.procID = string(b[1:pidEnd])
+			b = b[pidEnd+1:]
+		}
+	}
+	b = bytes.TrimPrefix(b, []byte(":"))
+	msg.body = bytes.TrimPrefix(b, []byte(" "))
+}
+
+//------------------------------------------------------------------------------
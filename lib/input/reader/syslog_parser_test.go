@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"reflect"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestParseSyslog(t *testing.T) {
+	type testCase struct {
+		name   string
+		format string
+		input  string
+		output syslogMessage
+	}
+
+	tests := []testCase{
+		{
+			name:   "rfc5424 full",
+			format: "auto",
+			input:  `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`,
+			output: syslogMessage{
+				facility:       20,
+				severity:       5,
+				timestamp:      "2003-10-11T22:14:15.003Z",
+				hostname:       "mymachine.example.com",
+				appname:        "evntslog",
+				msgID:          "ID47",
+				structuredData: `[exampleSDID@32473 iut="3" eventSource="Application"]`,
+				body:           []byte("An application event"),
+			},
+		},
+		{
+			name:   "rfc5424 escaped structured data",
+			format: "rfc5424",
+			input:  `<34>1 - host app 123 - [a@1 x="foo \"]\" bar"][b@1 y="z"] hello`,
+			output: syslogMessage{
+				facility:       4,
+				severity:       2,
+				hostname:       "host",
+				appname:        "app",
+				procID:         "123",
+				structuredData: `[a@1 x="foo \"]\" bar"][b@1 y="z"]`,
+				body:           []byte("hello"),
+			},
+		},
+		{
+			name:   "rfc5424 bom and no structured data",
+			format: "auto",
+			input:  "<34>1 2003-10-11T22:14:15.003Z host su - ID47 - \xef\xbb\xbf'su root' failed",
+			output: syslogMessage{
+				facility:  4,
+				severity:  2,
+				timestamp: "2003-10-11T22:14:15.003Z",
+				hostname:  "host",
+				appname:   "su",
+				msgID:     "ID47",
+				body:      []byte("'su root' failed"),
+			},
+		},
+		{
+			name:   "rfc5424 no message",
+			format: "auto",
+			input:  `<34>1 - - - - - -`,
+			output: syslogMessage{
+				facility: 4,
+				severity: 2,
+				body:     []byte{},
+			},
+		},
+		{
+			name:   "rfc3164 full",
+			format: "auto",
+			input:  `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`,
+			output: syslogMessage{
+				facility:  4,
+				severity:  2,
+				timestamp: "Oct 11 22:14:15",
+				hostname:  "mymachine",
+				appname:   "su",
+				procID:    "123",
+				body:      []byte("'su root' failed for lonvick on /dev/pts/8"),
+			},
+		},
+		{
+			name:   "rfc3164 padded day without pid",
+			format: "rfc3164",
+			input:  `<13>Feb  5 17:32:18 10.0.0.99 myapp: Use the BFG!`,
+			output: syslogMessage{
+				facility:  1,
+				severity:  5,
+				timestamp: "Feb  5 17:32:18",
+				hostname:  "10.0.0.99",
+				appname:   "myapp",
+				body:      []byte("Use the BFG!"),
+			},
+		},
+		{
+			name:   "rfc3164 no header",
+			format: "auto",
+			input:  `<13>hello world`,
+			output: syslogMessage{
+				facility: 1,
+				severity: 5,
+				body:     []byte("hello world"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		act, err := parseSyslog(test.format, []byte(test.input))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.output, *act) {
+			t.Errorf("%v: wrong result: %+v != %+v", test.name, *act, test.output)
+		}
+	}
+}
+
+func TestParseSyslogErrors(t *testing.T) {
+	tests := map[string]string{
+		"no priority":      "hello world",
+		"bad priority":     "<foo>1 - - - - - -",
+		"large priority":   "<192>1 - - - - - -",
+		"missing fields":   "<34>1 - host app",
+		"unclosed element": `<34>1 - - - - - [a@1 x="]`,
+		"not rfc5424":      "<34>Oct 11 22:14:15 mymachine su: hello",
+	}
+
+	for name, input := range tests {
+		if _, err := parseSyslog("rfc5424", []byte(input)); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func testSyslogReader(t *testing.T, protocol string, send func(addr string) error, exp []string) {
+	conf := NewSyslogConfig()
+	conf.Address = "localhost:0"
+	conf.Protocol = protocol
+
+	r, err := NewSyslog(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		r.CloseAsync()
+		if err := r.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if err = r.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var addr string
+	if r.packetCon != nil {
+		addr = r.packetCon.LocalAddr().String()
+	} else {
+		addr = r.listener.Addr().String()
+	}
+	go func() {
+		if err := send(addr); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	for _, e := range exp {
+		msg, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); e != act {
+			t.Errorf("Wrong message contents: %v != %v", act, e)
+		}
+		if exp, act := "mymachine", msg.GetMetadata(0).Get("syslog_hostname"); exp != act {
+			t.Errorf("Wrong hostname: %v != %v", act, exp)
+		}
+		if exp, act := "2", msg.GetMetadata(0).Get("syslog_severity"); exp != act {
+			t.Errorf("Wrong severity: %v != %v", act, exp)
+		}
+	}
+}
+
+func TestSyslogUDP(t *testing.T) {
+	testSyslogReader(t, "udp", func(addr string) error {
+		conn, err := net.Dial("udp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		for _, m := range []string{
+			"<34>Oct 11 22:14:15 mymachine su: foo\n",
+			"<34>1 - mymachine app - - - bar",
+		} {
+			if _, err = conn.Write([]byte(m)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, []string{"foo", "bar"})
+}
+
+func TestSyslogTCP(t *testing.T) {
+	testSyslogReader(t, "tcp", func(addr string) error {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = conn.Write([]byte(
+			"<34>Oct 11 22:14:15 mymachine su: foo\n" +
+				"31 <34>1 - mymachine app - - - bar" +
+				"<34>1 - mymachine app - - - baz\r\n",
+		))
+		return err
+	}, []string{"foo", "bar", "baz"})
+}
+
+func TestSyslogBadConfig(t *testing.T) {
+	conf := NewSyslogConfig()
+	conf.Protocol = "nope"
+	if _, err := NewSyslog(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad protocol")
+	}
+
+	conf = NewSyslogConfig()
+	conf.Format = "nope"
+	if _, err := NewSyslog(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad format")
+	}
+}
+
+//------------------------------------------------------------------------------
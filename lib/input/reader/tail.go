@@ -0,0 +1,370 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// Tail is a reader implementation that continuously reads line delimited
+// messages from a file as it is written to, following the file across rotation
+// and truncation in the same way as `tail -F`.
+//
+// The offset of the last acknowledged message can optionally be written to a
+// checkpoint file, from which reading resumes when the reader is restarted.
+type Tail struct {
+	path           string
+	checkpointPath string
+	pollInterval   time.Duration
+
+	maxBuffer int
+	multipart bool
+	delimiter []byte
+
+	file *os.File
+	info os.FileInfo
+
+	// buf contains bytes read from the file that are yet to be consumed, and
+	// offset is the position within the file of the start of buf.
+	buf    []byte
+	offset int64
+
+	parts         [][]byte
+	pendingOffset int64
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewTail creates a new Tail reader type that follows the file at a path.
+func NewTail(
+	path string,
+	log log.Modular,
+	stats metrics.Type,
+	options ...func(t *Tail),
+) (*Tail, error) {
+	t := Tail{
+		path:         path,
+		pollInterval: time.Second,
+		maxBuffer:    1000000,
+		multipart:    false,
+		delimiter:    []byte("\n"),
+		closeChan:    make(chan struct{}),
+		log:          log.NewModule(".input.file.tail"),
+		stats:        stats,
+	}
+
+	for _, opt := range options {
+		opt(&t)
+	}
+
+	return &t, nil
+}
+
+//------------------------------------------------------------------------------
+
+// OptTailSetMaxBuffer is a option func that sets the maximum size of a line.
+func OptTailSetMaxBuffer(maxBuffer int) func(t *Tail) {
+	return func(t *Tail) {
+		t.maxBuffer = maxBuffer
+	}
+}
+
+// OptTailSetMultipart is a option func that sets the boolean flag indicating
+// whether lines should be parsed as multipart or not.
+func OptTailSetMultipart(multipart bool) func(t *Tail) {
+	return func(t *Tail) {
+		t.multipart = multipart
+	}
+}
+
+// OptTailSetDelimiter is a option func that sets the delimiter (default '\n')
+// used to divide lines (message parts) in the file.
+func OptTailSetDelimiter(delimiter string) func(t *Tail) {
+	return func(t *Tail) {
+		t.delimiter = []byte(delimiter)
+	}
+}
+
+// OptTailSetPollInterval is a option func that sets the period to wait before
+// checking for new content once the end of the file is reached.
+func OptTailSetPollInterval(interval time.Duration) func(t *Tail) {
+	return func(t *Tail) {
+		t.pollInterval = interval
+	}
+}
+
+// OptTailSetCheckpointPath is a option func that sets the path of a file used
+// to store the offset of the last acknowledged message.
+func OptTailSetCheckpointPath(path string) func(t *Tail) {
+	return func(t *Tail) {
+		t.checkpointPath = path
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// tailCheckpoint is the content of a checkpoint file.
+type tailCheckpoint struct {
+	Path   string `json:"path"`
+	Offset int64  `json:"offset"`
+}
+
+// readCheckpoint returns the offset stored in the checkpoint file, or zero if
+// there is no checkpoint for the file.
+func (t *Tail) readCheckpoint() (int64, error) {
+	if len(t.checkpointPath) == 0 {
+		return 0, nil
+	}
+	cpBytes, err := ioutil.ReadFile(t.checkpointPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	var cp tailCheckpoint
+	if err = json.Unmarshal(cpBytes, &cp); err != nil {
+		return 0, err
+	}
+	if cp.Path != t.path {
+		return 0, nil
+	}
+	return cp.Offset, nil
+}
+
+// writeCheckpoint atomically replaces the checkpoint file with an offset.
+func (t *Tail) writeCheckpoint(offset int64) error {
+	cpBytes, err := json.Marshal(tailCheckpoint{
+		Path:   t.path,
+		Offset: offset,
+	})
+	if err != nil {
+		return err
+	}
+	tmpPath := t.checkpointPath + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, cpBytes, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, t.checkpointPath)
+}
+
+//------------------------------------------------------------------------------
+
+// open opens the file at the path and seeks to an offset.
+func (t *Tail) open(offset int64) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if offset > info.Size() {
+		// The file has been replaced or truncated since the offset was stored.
+		offset = 0
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+
+	if t.file != nil {
+		t.file.Close()
+	}
+	t.file = file
+	t.info = info
+	t.buf = nil
+	t.offset = offset
+	return nil
+}
+
+// Connect opens the file, resuming from the checkpoint when there is one.
+func (t *Tail) Connect() error {
+	if t.file != nil {
+		return nil
+	}
+	offset, err := t.readCheckpoint()
+	if err != nil {
+		t.log.Errorf("Failed to read checkpoint, reading from the start of the file: %v\n", err)
+		offset = 0
+	}
+	if err = t.open(offset); err != nil {
+		return err
+	}
+	t.pendingOffset = t.offset
+	t.log.Infof("Following file %v from offset: %v\n", t.path, t.offset)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// readLine reads the next complete line from the file, returning false if the
+// end of the file was reached without finding one.
+func (t *Tail) readLine() ([]byte, bool, error) {
+	chunk := make([]byte, 32*1024)
+	for {
+		if i := bytes.Index(t.buf, t.delimiter); i >= 0 {
+			line := make([]byte, i)
+			copy(line, t.buf[:i])
+			t.buf = t.buf[i+len(t.delimiter):]
+			t.offset += int64(i + len(t.delimiter))
+			return line, true, nil
+		}
+		if len(t.buf) > t.maxBuffer {
+			return nil, false, errors.New("line exceeds max buffer")
+		}
+		n, err := t.file.Read(chunk)
+		t.buf = append(t.buf, chunk[:n]...)
+		if err == io.EOF {
+			if n == 0 {
+				return nil, false, nil
+			}
+		} else if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// follow checks whether the file has been rotated or truncated once the end of
+// it has been reached, in which case it is reopened from the start. Any
+// trailing content of a rotated file without a delimiter is returned as a
+// final line.
+func (t *Tail) follow() (bool, []byte, error) {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The file has been moved and is yet to be recreated.
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+
+	if !os.SameFile(info, t.info) {
+		remaining := t.buf
+		if err = t.open(0); err != nil {
+			return false, nil, err
+		}
+		t.log.Infof("File %v was rotated, following new file\n", t.path)
+		return true, remaining, nil
+	}
+
+	if info.Size() < t.offset+int64(len(t.buf)) {
+		if err = t.open(0); err != nil {
+			return false, nil, err
+		}
+		t.log.Infof("File %v was truncated, reading from the start\n", t.path)
+		return true, nil, nil
+	}
+	return false, nil, nil
+}
+
+// Read attempts to read a new line from the file, blocking for up to the poll
+// interval when there are no new lines available.
+func (t *Tail) Read() (types.Message, error) {
+	if t.file == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	for {
+		line, ok, err := t.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			var followed bool
+			if followed, line, err = t.follow(); err != nil {
+				return nil, err
+			}
+			if !followed {
+				select {
+				case <-time.After(t.pollInterval):
+				case <-t.closeChan:
+					t.file.Close()
+					t.file = nil
+					return nil, types.ErrTypeClosed
+				}
+				return nil, types.ErrTimeout
+			}
+			if len(line) == 0 {
+				continue
+			}
+		}
+
+		if len(line) > 0 {
+			t.parts = append(t.parts, line)
+			if t.multipart {
+				continue
+			}
+		} else if !t.multipart || len(t.parts) == 0 {
+			continue
+		}
+
+		msg := types.NewMessage(t.parts)
+		t.parts = nil
+		t.pendingOffset = t.offset
+		return msg, nil
+	}
+}
+
+// Acknowledge confirms whether or not our unacknowledged messages have been
+// successfully propagated or not.
+func (t *Tail) Acknowledge(err error) error {
+	if err != nil || len(t.checkpointPath) == 0 {
+		return nil
+	}
+	if cpErr := t.writeCheckpoint(t.pendingOffset); cpErr != nil {
+		t.log.Errorf("Failed to write checkpoint: %v\n", cpErr)
+	}
+	return nil
+}
+
+// CloseAsync shuts down the reader input and stops processing requests.
+func (t *Tail) CloseAsync() {
+	t.closeOnce.Do(func() {
+		close(t.closeChan)
+	})
+}
+
+// WaitForClose blocks until the reader input has closed down.
+func (t *Tail) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newTestTail(t *testing.T, path string, options ...func(*Tail)) *Tail {
+	t.Helper()
+
+	options = append([]func(*Tail){OptTailSetPollInterval(time.Millisecond)}, options...)
+	r, err := NewTail(path, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func appendTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectTailLines(t *testing.T, r *Tail, exp ...string) {
+	t.Helper()
+
+	for _, e := range exp {
+		var msg types.Message
+		var err error
+		for i := 0; i < 1000; i++ {
+			if msg, err = r.Read(); err != types.ErrTimeout {
+				break
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); e != act {
+			t.Errorf("Wrong line: %v != %v", act, e)
+		}
+		if err = r.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestTailFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_tail_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	appendTestFile(t, path, "foo\nbar\n\nba")
+
+	r := newTestTail(t, path)
+	defer r.CloseAsync()
+
+	expectTailLines(t, r, "foo", "bar")
+	if _, err = r.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+
+	appendTestFile(t, path, "z\nqux\n")
+	expectTailLines(t, r, "baz", "qux")
+}
+
+func TestTailRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_tail_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	appendTestFile(t, path, "foo\n")
+
+	r := newTestTail(t, path)
+	defer r.CloseAsync()
+
+	expectTailLines(t, r, "foo")
+
+	appendTestFile(t, path, "bar\nbaz")
+	if err = os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	expectTailLines(t, r, "bar")
+	if _, err = r.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+
+	appendTestFile(t, path, "qux\n")
+	expectTailLines(t, r, "baz", "qux")
+}
+
+func TestTailTruncation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_tail_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	appendTestFile(t, path, "foo\nbar\n")
+
+	r := newTestTail(t, path)
+	defer r.CloseAsync()
+
+	expectTailLines(t, r, "foo", "bar")
+
+	if err = os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendTestFile(t, path, "baz\n")
+	expectTailLines(t, r, "baz")
+}
+
+func TestTailCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_tail_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	cpPath := filepath.Join(dir, "checkpoint")
+	appendTestFile(t, path, "foo\nbar\nbaz\n")
+
+	r := newTestTail(t, path, OptTailSetCheckpointPath(cpPath))
+	expectTailLines(t, r, "foo")
+
+	// Read a line without acknowledging it.
+	if _, err = r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	r.CloseAsync()
+
+	r = newTestTail(t, path, OptTailSetCheckpointPath(cpPath))
+	defer r.CloseAsync()
+	expectTailLines(t, r, "bar", "baz")
+}
+
+func TestTailMultipart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_tail_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.log")
+	appendTestFile(t, path, "foo\nbar\n\nbaz\n")
+
+	r := newTestTail(t, path, OptTailSetMultipart(true))
+	defer r.CloseAsync()
+
+	msg, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 2, msg.Len(); exp != act {
+		t.Fatalf("Wrong count of parts: %v != %v", act, exp)
+	}
+	if exp, act := "bar", string(msg.Get(1)); exp != act {
+		t.Errorf("Wrong part: %v != %v", act, exp)
+	}
+
+	if _, err = r.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+	appendTestFile(t, path, "qux\n\n")
+	if msg, err = r.Read(); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 2, msg.Len(); exp != act {
+		t.Fatalf("Wrong count of parts: %v != %v", act, exp)
+	}
+	if exp, act := "baz", string(msg.Get(0)); exp != act {
+		t.Errorf("Wrong part: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
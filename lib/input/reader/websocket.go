@@ -29,6 +29,8 @@ import (
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/throttle"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
 	"github.com/gorilla/websocket"
 )
 
@@ -36,15 +38,31 @@ import (
 
 // WebsocketConfig is configuration for the Websocket input type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
-	auth.Config `json:",inline" yaml:",inline"`
+	URL           string            `json:"url" yaml:"url"`
+	Headers       map[string]string `json:"headers" yaml:"headers"`
+	Subprotocols  []string          `json:"subprotocols" yaml:"subprotocols"`
+	OpenMsg       string            `json:"open_message" yaml:"open_message"`
+	RetryMS       int64             `json:"retry_period_ms" yaml:"retry_period_ms"`
+	MaxBackoffMS  int64             `json:"max_retry_backoff_ms" yaml:"max_retry_backoff_ms"`
+	PingPeriodMS  int64             `json:"ping_period_ms" yaml:"ping_period_ms"`
+	PongTimeoutMS int64             `json:"pong_timeout_ms" yaml:"pong_timeout_ms"`
+	TLS           btls.Config       `json:"tls" yaml:"tls"`
+	auth.Config   `json:",inline" yaml:",inline"`
 }
 
 // NewWebsocketConfig creates a new WebsocketConfig with default values.
 func NewWebsocketConfig() WebsocketConfig {
 	return WebsocketConfig{
-		URL:    "ws://localhost:4195/get/ws",
-		Config: auth.NewConfig(),
+		URL:           "ws://localhost:4195/get/ws",
+		Headers:       map[string]string{},
+		Subprotocols:  []string{},
+		OpenMsg:       "",
+		RetryMS:       1000,
+		MaxBackoffMS:  60000,
+		PingPeriodMS:  30000,
+		PongTimeoutMS: 10000,
+		TLS:           btls.NewConfig(),
+		Config:        auth.NewConfig(),
 	}
 }
 
@@ -58,7 +76,22 @@ type Websocket struct {
 	lock *sync.Mutex
 
 	conf   WebsocketConfig
+	dialer websocket.Dialer
 	client *websocket.Conn
+
+	// pingDone is closed when the current connection is dropped, which stops
+	// its ping loop.
+	pingDone chan struct{}
+
+	pingPeriod  time.Duration
+	pongTimeout time.Duration
+
+	retryThrottle *throttle.Type
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	mPingErr metrics.StatCounter
 }
 
 // NewWebsocket creates a new Websocket input type.
@@ -68,11 +101,31 @@ func NewWebsocket(
 	stats metrics.Type,
 ) (*Websocket, error) {
 	ws := &Websocket{
-		log:   log.NewModule(".input.websocket"),
-		stats: stats,
-		lock:  &sync.Mutex{},
-		conf:  conf,
+		log:         log.NewModule(".input.websocket"),
+		stats:       stats,
+		lock:        &sync.Mutex{},
+		conf:        conf,
+		pingPeriod:  time.Millisecond * time.Duration(conf.PingPeriodMS),
+		pongTimeout: time.Millisecond * time.Duration(conf.PongTimeoutMS),
+		closeChan:   make(chan struct{}),
+		mPingErr:    stats.GetCounter("input.websocket.ping.error"),
 	}
+
+	ws.dialer = *websocket.DefaultDialer
+	ws.dialer.Subprotocols = conf.Subprotocols
+	if conf.TLS.Enabled {
+		var err error
+		if ws.dialer.TLSClientConfig, err = conf.TLS.Get(); err != nil {
+			return nil, err
+		}
+	}
+
+	ws.retryThrottle = throttle.New(
+		throttle.OptMaxUnthrottledRetries(0),
+		throttle.OptCloseChan(ws.closeChan),
+		throttle.OptThrottlePeriod(time.Millisecond*time.Duration(conf.RetryMS)),
+		throttle.OptMaxExponentPeriod(time.Millisecond*time.Duration(conf.MaxBackoffMS)),
+	)
 	return ws, nil
 }
 
@@ -85,6 +138,47 @@ func (w *Websocket) getWS() *websocket.Conn {
 	return ws
 }
 
+// dropWS closes the current connection if it matches the argument. Must be
+// called whilst holding the lock.
+func (w *Websocket) dropWS(client *websocket.Conn) {
+	if w.client == nil || w.client != client {
+		return
+	}
+	w.client.Close()
+	w.client = nil
+	close(w.pingDone)
+	w.pingDone = nil
+}
+
+// extendDeadline pushes back the deadline by which the server must respond to
+// pings, which is extended whenever data or a pong is received.
+func (w *Websocket) extendDeadline(client *websocket.Conn) {
+	if w.pingPeriod > 0 {
+		client.SetReadDeadline(time.Now().Add(w.pingPeriod + w.pongTimeout))
+	}
+}
+
+// pingLoop periodically writes ping frames to a connection until it is
+// dropped.
+func (w *Websocket) pingLoop(client *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(w.pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := client.WriteControl(
+				websocket.PingMessage, nil, time.Now().Add(w.pongTimeout),
+			); err != nil {
+				w.mPingErr.Incr(1)
+				w.log.Debugf("Failed to send ping: %v\n", err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Connect establishes a connection to an Websocket server.
@@ -96,21 +190,62 @@ func (w *Websocket) Connect() error {
 		return nil
 	}
 
+	select {
+	case <-w.closeChan:
+		return types.ErrTypeClosed
+	default:
+	}
+
+	client, err := w.dial()
+	if err != nil {
+		// Back off exponentially between consecutive failed attempts.
+		if !w.retryThrottle.ExponentialRetry() {
+			return types.ErrTypeClosed
+		}
+		return err
+	}
+	w.retryThrottle.Reset()
+
+	w.client = client
+	w.pingDone = make(chan struct{})
+	if w.pingPeriod > 0 {
+		client.SetPongHandler(func(string) error {
+			w.extendDeadline(client)
+			return nil
+		})
+		w.extendDeadline(client)
+		go w.pingLoop(client, w.pingDone)
+	}
+
+	w.log.Infof("Receiving websocket messages from: %v\n", w.conf.URL)
+	return nil
+}
+
+// dial opens a new connection and sends the open message, if configured.
+func (w *Websocket) dial() (*websocket.Conn, error) {
 	headers := http.Header{}
+	for k, v := range w.conf.Headers {
+		headers.Set(k, v)
+	}
 
 	if err := w.conf.Sign(&http.Request{
 		Header: headers,
 	}); err != nil {
-		return err
+		return nil, err
 	}
 
-	client, _, err := websocket.DefaultDialer.Dial(w.conf.URL, headers)
+	client, _, err := w.dialer.Dial(w.conf.URL, headers)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	w.client = client
-	return nil
+	if len(w.conf.OpenMsg) > 0 {
+		if err = client.WriteMessage(websocket.TextMessage, []byte(w.conf.OpenMsg)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
 }
 
 //------------------------------------------------------------------------------
@@ -124,12 +259,13 @@ func (w *Websocket) Read() (types.Message, error) {
 
 	_, data, err := client.ReadMessage()
 	if err != nil {
+		w.log.Warnf("Lost websocket connection: %v\n", err)
 		w.lock.Lock()
-		w.client = nil
+		w.dropWS(client)
 		w.lock.Unlock()
-		err = types.ErrNotConnected
-		return nil, err
+		return nil, types.ErrNotConnected
 	}
+	w.extendDeadline(client)
 
 	return types.NewMessage([][]byte{data}), nil
 }
@@ -142,11 +278,11 @@ func (w *Websocket) Acknowledge(err error) error {
 
 // CloseAsync shuts down the Websocket input and stops reading messages.
 func (w *Websocket) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+	})
 	w.lock.Lock()
-	if w.client != nil {
-		w.client.Close()
-		w.client = nil
-	}
+	w.dropWS(w.client)
 	w.lock.Unlock()
 }
 
@@ -26,6 +26,7 @@ import (
 	"net/url"
 	"os"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -144,3 +145,201 @@ func TestWebsocketClose(t *testing.T) {
 	wg.Wait()
 	close(closeChan)
 }
+
+func TestWebsocketOpenMsgReconnect(t *testing.T) {
+	var opens, pings int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		ws.SetPingHandler(func(data string) error {
+			atomic.AddInt32(&pings, 1)
+			return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+
+		_, openMsg, err := ws.ReadMessage()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		atomic.AddInt32(&opens, 1)
+		if err = ws.WriteMessage(websocket.TextMessage, append([]byte("echo: "), openMsg...)); err != nil {
+			t.Error(err)
+			return
+		}
+
+		// Keep reading until pings have been received, then drop the client.
+		target := atomic.LoadInt32(&pings) + 2
+		go func() {
+			for {
+				if _, _, rErr := ws.NextReader(); rErr != nil {
+					return
+				}
+			}
+		}()
+		for atomic.LoadInt32(&pings) < target {
+			<-time.After(time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	conf := NewWebsocketConfig()
+	conf.OpenMsg = "subscribe"
+	conf.PingPeriodMS = 10
+	conf.RetryMS = 10
+	if wsURL, err := url.Parse(server.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		wsURL.Scheme = "ws"
+		conf.URL = wsURL.String()
+	}
+
+	m, err := NewWebsocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.CloseAsync()
+
+	for i := 0; i < 2; i++ {
+		if err = m.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		msg, err := m.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := "echo: subscribe", string(msg.Get(0)); exp != act {
+			t.Errorf("Wrong result: %v != %v", act, exp)
+		}
+		if err = m.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+		if _, err = m.Read(); err != types.ErrNotConnected {
+			t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+		}
+	}
+
+	if exp, act := int32(2), atomic.LoadInt32(&opens); exp != act {
+		t.Errorf("Wrong count of open messages: %v != %v", act, exp)
+	}
+}
+
+func TestWebsocketPongTimeout(t *testing.T) {
+	closeChan := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		// Ignore pings, which should cause the client to give up.
+		ws.SetPingHandler(func(string) error { return nil })
+		go func() {
+			for {
+				if _, _, err := ws.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		<-closeChan
+	}))
+	defer server.Close()
+	defer close(closeChan)
+
+	conf := NewWebsocketConfig()
+	conf.PingPeriodMS = 10
+	conf.PongTimeoutMS = 10
+	if wsURL, err := url.Parse(server.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		wsURL.Scheme = "ws"
+		conf.URL = wsURL.String()
+	}
+
+	m, err := NewWebsocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.CloseAsync()
+
+	if err = m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		_, rErr := m.Read()
+		errChan <- rErr
+	}()
+
+	select {
+	case err = <-errChan:
+		if err != types.ErrNotConnected {
+			t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+		}
+	case <-time.After(time.Second):
+		t.Error("Timed out waiting for pong timeout")
+	}
+}
+
+func TestWebsocketTLSHeaders(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{
+			Subprotocols: []string{"bar"},
+		}
+
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+
+		if err = ws.WriteMessage(websocket.TextMessage, []byte(r.Header.Get("X-Foo"))); err != nil {
+			t.Error(err)
+		}
+		if err = ws.WriteMessage(websocket.TextMessage, []byte(ws.Subprotocol())); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	conf := NewWebsocketConfig()
+	conf.Headers["X-Foo"] = "foo value"
+	conf.Subprotocols = []string{"bar"}
+	conf.TLS.Enabled = true
+	conf.TLS.SkipVerify = true
+	if wsURL, err := url.Parse(server.URL); err != nil {
+		t.Fatal(err)
+	} else {
+		wsURL.Scheme = "wss"
+		conf.URL = wsURL.String()
+	}
+
+	m, err := NewWebsocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.CloseAsync()
+
+	if err = m.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exp := range []string{"foo value", "bar"} {
+		msg, err := m.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(msg.Get(0)); exp != act {
+			t.Errorf("Wrong result: %v != %v", act, exp)
+		}
+	}
+}
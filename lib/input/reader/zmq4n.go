@@ -0,0 +1,181 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/zmtp"
+)
+
+//------------------------------------------------------------------------------
+
+// ZMQ4NConfig is configuration for the ZMQ4N input type.
+type ZMQ4NConfig struct {
+	URLs          []string `json:"urls" yaml:"urls"`
+	Bind          bool     `json:"bind" yaml:"bind"`
+	SocketType    string   `json:"socket_type" yaml:"socket_type"`
+	SubFilters    []string `json:"sub_filters" yaml:"sub_filters"`
+	HighWaterMark int      `json:"high_water_mark" yaml:"high_water_mark"`
+	PollTimeoutMS int      `json:"poll_timeout_ms" yaml:"poll_timeout_ms"`
+}
+
+// NewZMQ4NConfig creates a new ZMQ4NConfig with default values.
+func NewZMQ4NConfig() ZMQ4NConfig {
+	return ZMQ4NConfig{
+		URLs:          []string{"tcp://localhost:5555"},
+		Bind:          false,
+		SocketType:    "PULL",
+		SubFilters:    []string{},
+		HighWaterMark: 0,
+		PollTimeoutMS: 5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ZMQ4N is an input type that reads ZeroMQ messages using a pure Go
+// implementation of the ZMTP protocol.
+type ZMQ4N struct {
+	urls  []string
+	conf  ZMQ4NConfig
+	stats metrics.Type
+	log   log.Modular
+
+	pollTimeout time.Duration
+	socket      *zmtp.Socket
+}
+
+// NewZMQ4N creates a new ZMQ4N input type.
+func NewZMQ4N(conf ZMQ4NConfig, log log.Modular, stats metrics.Type) (*ZMQ4N, error) {
+	z := ZMQ4N{
+		conf:        conf,
+		stats:       stats,
+		log:         log.NewModule(".input.zmq4n"),
+		pollTimeout: time.Millisecond * time.Duration(conf.PollTimeoutMS),
+	}
+
+	for _, u := range conf.URLs {
+		for _, splitU := range strings.Split(u, ",") {
+			if len(splitU) > 0 {
+				z.urls = append(z.urls, splitU)
+			}
+		}
+	}
+
+	switch conf.SocketType {
+	case "PULL", "SUB":
+	default:
+		return nil, types.ErrInvalidZMQType
+	}
+
+	return &z, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a ZMQ4N socket.
+func (z *ZMQ4N) Connect() error {
+	if z.socket != nil {
+		return nil
+	}
+
+	socket, err := zmtp.NewSocket(z.conf.SocketType, z.conf.HighWaterMark)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			socket.Close()
+		}
+	}()
+
+	for _, filter := range z.conf.SubFilters {
+		if err = socket.Subscribe([]byte(filter)); err != nil {
+			return err
+		}
+	}
+
+	for _, address := range z.urls {
+		if z.conf.Bind {
+			err = socket.Bind(address)
+		} else {
+			err = socket.Connect(address)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	z.socket = socket
+
+	if z.conf.Bind {
+		z.log.Infof("Receiving ZMQ4N messages on bound URLs: %s\n", z.urls)
+	} else {
+		z.log.Infof("Receiving ZMQ4N messages on connected URLs: %s\n", z.urls)
+	}
+	return nil
+}
+
+// Read attempts to read a new message from the ZMQ4N socket.
+func (z *ZMQ4N) Read() (types.Message, error) {
+	if z.socket == nil {
+		return nil, types.ErrNotConnected
+	}
+
+	data, err := z.socket.Recv(z.pollTimeout)
+	if err != nil {
+		if err == zmtp.ErrTimeout {
+			return nil, types.ErrTimeout
+		}
+		if err == zmtp.ErrClosed {
+			return nil, types.ErrTypeClosed
+		}
+		return nil, err
+	}
+
+	return types.NewMessage(data), nil
+}
+
+// Acknowledge instructs whether the pending messages were propagated
+// successfully.
+func (z *ZMQ4N) Acknowledge(err error) error {
+	return nil
+}
+
+// CloseAsync shuts down the ZMQ4N input and stops processing requests.
+func (z *ZMQ4N) CloseAsync() {
+	if z.socket != nil {
+		z.socket.Close()
+	}
+}
+
+// WaitForClose blocks until the ZMQ4N input has closed down.
+func (z *ZMQ4N) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reader
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/zmtp"
+)
+
+func TestZMQ4NPull(t *testing.T) {
+	conf := NewZMQ4NConfig()
+	conf.URLs = []string{"tcp://localhost:1262"}
+	conf.Bind = true
+	conf.SocketType = "PULL"
+	conf.PollTimeoutMS = 100
+
+	z, err := NewZMQ4N(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = z.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		z.CloseAsync()
+		if err := z.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if _, err = z.Read(); err != types.ErrTimeout {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTimeout)
+	}
+
+	socket, err := zmtp.NewSocket("PUSH", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer socket.Close()
+	if err = socket.Connect("tcp://localhost:1262"); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		exp := [][]byte{[]byte(fmt.Sprintf("foo%v", i)), []byte("bar")}
+		if err = socket.Send(exp, time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		var msg types.Message
+		if msg, err = z.Read(); err != nil {
+			t.Fatal(err)
+		}
+		if act := msg.GetAll(); len(act) != 2 || string(act[0]) != string(exp[0]) || string(act[1]) != "bar" {
+			t.Errorf("Wrong result: %s != %s", act, exp)
+		}
+		if err = z.Acknowledge(nil); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestZMQ4NBadSocketType(t *testing.T) {
+	conf := NewZMQ4NConfig()
+	conf.SocketType = "PUSH"
+
+	if _, err := NewZMQ4N(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err != types.ErrInvalidZMQType {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrInvalidZMQType)
+	}
+}
@@ -34,7 +34,25 @@ func init() {
 		constructor: NewRedisPubSub,
 		description: `
 Redis supports a publish/subscribe model, it's possible to subscribe to multiple
-channels using this input.`,
+channels using this input.
+
+When ` + "`use_patterns`" + ` is true the channels are subscribed to with the
+PSUBSCRIBE command, and are therefore treated as glob-style patterns such as
+` + "`events.*`" + `, allowing a single input to consume from any number of
+matching channels.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- redis_pubsub_channel
+- redis_pubsub_pattern (when use_patterns is true)
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata), which allows
+messages to be routed by their originating channel later in the pipeline.`,
 	}
 }
 
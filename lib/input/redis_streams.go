@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["redis_streams"] = TypeSpec{
+		constructor: NewRedisStreams,
+		description: `
+Pulls messages from Redis (v5.0+) streams with the XREADGROUP command as the
+consumer ` + "`client_id`" + ` of a consumer group, which is created for each
+stream if it does not already exist. When ` + "`create_streams`" + ` is true
+streams that do not exist are also created.
+
+Up to ` + "`limit`" + ` entries are read at a time and form the parts of a
+single message, where the field ` + "`body_key`" + ` of each entry is used as
+the contents of its part. Entries are only acknowledged with the XACK command
+once the message has been successfully delivered by the outputs of the stream.
+
+On connection any entries that are already pending for this consumer, such as
+those read but not acknowledged before a restart, are read before new entries.
+When ` + "`claim_min_idle_ms`" + ` is greater than zero the pending entries of
+other consumers of the group that have been idle for at least that period are
+also claimed with the XCLAIM command and read by this consumer.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- redis_stream
+- redis_stream_id
+- All fields of the entry other than the body
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewRedisStreams creates a new Redis Streams input type.
+func NewRedisStreams(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewRedisStreams(conf.RedisStreams, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("redis_streams", reader.NewPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,307 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/cron"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["schedule"] = TypeSpec{
+		constructor: NewSchedule,
+		description: `
+Activates a child input according to a cron expression and otherwise remains
+idle. Each time the schedule activates a new instance of the child input is
+created, and messages are read from it until it closes itself, or until
+` + "`max_duration_ms`" + ` has passed if it is greater than zero. This allows
+inputs such as ` + "`http_client`" + ` or ` + "`amazon_s3`" + ` to be run
+periodically without external cron jobs.
+
+The field ` + "`cron`" + ` is an expression with five space separated fields:
+minute, hour, day of month, month and day of week. Each field can be a wildcard
+(` + "`*`" + `), a value, a range (` + "`1-5`" + `) or a list of these (` + "`1,3,5`" + `),
+optionally followed by a step (` + "`*/15`" + `). The descriptors
+` + "`@yearly`, `@monthly`, `@weekly`, `@daily` and `@hourly`" + ` are also
+supported, as is ` + "`@every <duration>`" + ` for a fixed interval, e.g.
+` + "`@every 30s`" + `. Times are evaluated in the local time zone.
+
+If the child input is still active when the schedule activates again then that
+activation is skipped.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ScheduleConfig is configuration values for the Schedule input type.
+type ScheduleConfig struct {
+	Input         *Config `json:"input" yaml:"input"`
+	Cron          string  `json:"cron" yaml:"cron"`
+	MaxDurationMS int     `json:"max_duration_ms" yaml:"max_duration_ms"`
+}
+
+// NewScheduleConfig creates a new ScheduleConfig with default values.
+func NewScheduleConfig() ScheduleConfig {
+	return ScheduleConfig{
+		Input:         nil,
+		Cron:          "0 * * * *",
+		MaxDurationMS: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyScheduleConfig struct {
+	Input         interface{} `json:"input" yaml:"input"`
+	Cron          string      `json:"cron" yaml:"cron"`
+	MaxDurationMS int         `json:"max_duration_ms" yaml:"max_duration_ms"`
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (s ScheduleConfig) MarshalJSON() ([]byte, error) {
+	dummy := dummyScheduleConfig{
+		Input:         s.Input,
+		Cron:          s.Cron,
+		MaxDurationMS: s.MaxDurationMS,
+	}
+	if s.Input == nil {
+		dummy.Input = struct{}{}
+	}
+	return json.Marshal(dummy)
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (s ScheduleConfig) MarshalYAML() (interface{}, error) {
+	dummy := dummyScheduleConfig{
+		Input:         s.Input,
+		Cron:          s.Cron,
+		MaxDurationMS: s.MaxDurationMS,
+	}
+	if s.Input == nil {
+		dummy.Input = struct{}{}
+	}
+	return dummy, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Schedule is an input type that periodically activates a child input.
+type Schedule struct {
+	running int32
+	conf    ScheduleConfig
+
+	schedule    cron.Schedule
+	maxDuration time.Duration
+
+	wrapperMgr   types.Manager
+	wrapperLog   log.Modular
+	wrapperStats metrics.Type
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactions chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewSchedule creates a new Schedule input type.
+func NewSchedule(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.Schedule.Input == nil {
+		return nil, errors.New("cannot create schedule input without a child")
+	}
+	sched, err := cron.Parse(conf.Schedule.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse cron expression '%v': %v", conf.Schedule.Cron, err)
+	}
+
+	s := &Schedule{
+		running: 1,
+		conf:    conf.Schedule,
+
+		schedule:    sched,
+		maxDuration: time.Duration(conf.Schedule.MaxDurationMS) * time.Millisecond,
+
+		wrapperLog:   log,
+		wrapperStats: stats,
+		wrapperMgr:   mgr,
+
+		log:          log.NewModule(".input.schedule"),
+		stats:        stats,
+		transactions: make(chan types.Transaction),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}
+
+	go s.loop()
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Schedule) loop() {
+	var (
+		mRunning      = s.stats.GetCounter("input.schedule.running")
+		mActivated    = s.stats.GetCounter("input.schedule.activated")
+		mInputErr     = s.stats.GetCounter("input.schedule.input.error")
+		mInputClosed  = s.stats.GetCounter("input.schedule.input.closed")
+		mInputExpired = s.stats.GetCounter("input.schedule.input.expired")
+		mCount        = s.stats.GetCounter("input.schedule.count")
+	)
+
+	defer func() {
+		mRunning.Decr(1)
+
+		close(s.transactions)
+		close(s.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	for atomic.LoadInt32(&s.running) == 1 {
+		now := time.Now()
+		next := s.schedule.Next(now)
+		if next.IsZero() {
+			s.log.Infoln("Schedule will not activate again, shutting down.")
+			return
+		}
+
+		select {
+		case <-time.After(next.Sub(now)):
+		case <-s.closeChan:
+			return
+		}
+		mActivated.Incr(1)
+
+		wrapped, err := New(*s.conf.Input, s.wrapperMgr, s.wrapperLog, s.wrapperStats)
+		if err != nil {
+			mInputErr.Incr(1)
+			s.log.Errorf("Failed to create input '%v': %v\n", s.conf.Input.Type, err)
+			continue
+		}
+
+		var deadline <-chan time.Time
+		if s.maxDuration > 0 {
+			deadline = time.After(s.maxDuration)
+		}
+
+		expired, closing := s.forward(wrapped, deadline, mCount)
+		if expired {
+			mInputExpired.Incr(1)
+		} else if !closing {
+			mInputClosed.Incr(1)
+		}
+
+		wrapped.CloseAsync()
+		err = wrapped.WaitForClose(time.Second)
+		for ; err != nil; err = wrapped.WaitForClose(time.Second) {
+		}
+
+		if closing {
+			return
+		}
+	}
+}
+
+// forward reads transactions from an active child input and sends them on
+// until either the child closes, the deadline is reached, or the schedule is
+// closed.
+func (s *Schedule) forward(
+	wrapped Type,
+	deadline <-chan time.Time,
+	mCount metrics.StatCounter,
+) (expired, closing bool) {
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-wrapped.TransactionChan():
+			if !open {
+				return false, false
+			}
+		case <-deadline:
+			return true, false
+		case <-s.closeChan:
+			return false, true
+		}
+		mCount.Incr(1)
+
+		// Responses are relayed through here in order to ensure that the
+		// child input is not closed with a transaction still pending.
+		resChan := make(chan types.Response)
+		select {
+		case s.transactions <- types.NewTransaction(tran.Payload, resChan):
+		case <-s.closeChan:
+			return false, true
+		}
+
+		var res types.Response
+		select {
+		case res = <-resChan:
+		case <-s.closeChan:
+			return false, true
+		}
+		select {
+		case tran.ResponseChan <- res:
+		case <-s.closeChan:
+			return false, true
+		}
+	}
+}
+
+// TransactionChan returns the transactions channel.
+func (s *Schedule) TransactionChan() <-chan types.Transaction {
+	return s.transactions
+}
+
+// CloseAsync shuts down the Schedule input and stops processing requests.
+func (s *Schedule) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		close(s.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Schedule input has closed down.
+func (s *Schedule) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+func TestScheduleBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "schedule"
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing child")
+	}
+
+	child := NewConfig()
+	conf.Schedule.Input = &child
+	conf.Schedule.Cron = "nope"
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad cron expression")
+	}
+}
+
+func TestScheduleInput(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "benthos_schedule_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if _, err = tmpfile.Write([]byte("foo\nbar")); err != nil {
+		t.Fatal(err)
+	}
+	if err = tmpfile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	child := NewConfig()
+	child.Type = "file"
+	child.File.Path = tmpfile.Name()
+
+	conf := NewConfig()
+	conf.Type = "schedule"
+	conf.Schedule.Input = &child
+	conf.Schedule.Cron = "@every 10ms"
+
+	in, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is read in full on each activation.
+	for _, exp := range []string{"foo", "bar", "foo", "bar"} {
+		var tran types.Transaction
+		select {
+		case tran = <-in.TransactionChan():
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		if act := string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message contents: %v != %v", act, exp)
+		}
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	in.CloseAsync()
+	if err = in.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScheduleIdle(t *testing.T) {
+	child := NewConfig()
+	child.Type = "file"
+	child.File.Path = "/does/not/matter"
+
+	conf := NewConfig()
+	conf.Type = "schedule"
+	conf.Schedule.Input = &child
+	conf.Schedule.Cron = "0 0 1 1 *"
+
+	in, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-in.TransactionChan():
+		t.Error("Unexpected transaction")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	in.CloseAsync()
+	if err = in.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["sequence"] = TypeSpec{
+		constructor: NewSequence,
+		description: `
+Reads messages from a list of child inputs one after another. Only one child is
+active at any given time, and once it closes itself the next child in the list
+is created. When the last child closes the sequence input also closes, ending
+the stream.
+
+This is useful for pipelines such as a backfill from ` + "`amazon_s3`" + ` that
+then switches to live data from ` + "`kafka`" + `, where each child input must
+be one that eventually closes by itself (other than the last).
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- sequence_index
+- sequence_input
+` + "```" + `
+
+Where ` + "`sequence_index`" + ` is the index of the child input within the
+list that produced the message, and ` + "`sequence_input`" + ` is its type.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SequenceConfig is configuration values for the Sequence input type.
+type SequenceConfig struct {
+	Inputs []Config `json:"inputs" yaml:"inputs"`
+}
+
+// NewSequenceConfig creates a new SequenceConfig with default values.
+func NewSequenceConfig() SequenceConfig {
+	return SequenceConfig{
+		Inputs: []Config{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Sequence is an input type that reads from a list of child inputs in order.
+type Sequence struct {
+	running int32
+	conf    SequenceConfig
+
+	wrapperMgr   types.Manager
+	wrapperLog   log.Modular
+	wrapperStats metrics.Type
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactions chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewSequence creates a new Sequence input type.
+func NewSequence(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if len(conf.Sequence.Inputs) == 0 {
+		return nil, errors.New("cannot create sequence input without children")
+	}
+
+	s := &Sequence{
+		running: 1,
+		conf:    conf.Sequence,
+
+		wrapperLog:   log,
+		wrapperStats: stats,
+		wrapperMgr:   mgr,
+
+		log:          log.NewModule(".input.sequence"),
+		stats:        stats,
+		transactions: make(chan types.Transaction),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}
+
+	go s.loop()
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Sequence) loop() {
+	var (
+		mRunning     = s.stats.GetCounter("input.sequence.running")
+		mInputErr    = s.stats.GetCounter("input.sequence.input.error")
+		mInputClosed = s.stats.GetCounter("input.sequence.input.closed")
+		mCount       = s.stats.GetCounter("input.sequence.count")
+	)
+
+	defer func() {
+		mRunning.Decr(1)
+
+		close(s.transactions)
+		close(s.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	for i, conf := range s.conf.Inputs {
+		if atomic.LoadInt32(&s.running) != 1 {
+			return
+		}
+
+		wrapped, err := New(conf, s.wrapperMgr, s.wrapperLog, s.wrapperStats)
+		if err != nil {
+			mInputErr.Incr(1)
+			s.log.Errorf("Failed to create input '%v': %v\n", conf.Type, err)
+			return
+		}
+		s.log.Infof("Reading from input %v of type '%v'\n", i, conf.Type)
+
+		closing := s.forward(wrapped, strconv.Itoa(i), conf.Type, mCount)
+
+		wrapped.CloseAsync()
+		err = wrapped.WaitForClose(time.Second)
+		for ; err != nil; err = wrapped.WaitForClose(time.Second) {
+		}
+
+		if closing {
+			return
+		}
+		mInputClosed.Incr(1)
+	}
+}
+
+// forward reads transactions from an active child input and sends them on,
+// labelled with the child that produced them, until either the child closes or
+// the sequence is closed.
+func (s *Sequence) forward(
+	wrapped Type,
+	index, inputType string,
+	mCount metrics.StatCounter,
+) (closing bool) {
+	for {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-wrapped.TransactionChan():
+			if !open {
+				return false
+			}
+		case <-s.closeChan:
+			return true
+		}
+		mCount.Incr(1)
+
+		msg := tran.Payload.ShallowCopy()
+		for i := 0; i < msg.Len(); i++ {
+			msg.GetMetadata(i).
+				Set("sequence_index", index).
+				Set("sequence_input", inputType)
+		}
+
+		select {
+		case s.transactions <- types.NewTransaction(msg, tran.ResponseChan):
+		case <-s.closeChan:
+			return true
+		}
+	}
+}
+
+// TransactionChan returns the transactions channel.
+func (s *Sequence) TransactionChan() <-chan types.Transaction {
+	return s.transactions
+}
+
+// CloseAsync shuts down the Sequence input and stops processing requests.
+func (s *Sequence) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		close(s.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Sequence input has closed down.
+func (s *Sequence) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
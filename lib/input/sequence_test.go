@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+func TestSequenceInput(t *testing.T) {
+	first := NewConfig()
+	first.Type = "generate"
+	first.Generate.Content = "foo"
+	first.Generate.Rate = 0
+	first.Generate.Count = 2
+
+	second := NewConfig()
+	second.Type = "generate"
+	second.Generate.Content = "bar"
+	second.Generate.Rate = 0
+	second.Generate.Count = 1
+
+	conf := NewConfig()
+	conf.Type = "sequence"
+	conf.Sequence.Inputs = []Config{first, second}
+
+	in, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expMsgs := [][2]string{
+		{"foo", "0"},
+		{"foo", "0"},
+		{"bar", "1"},
+	}
+
+	for _, exp := range expMsgs {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-in.TransactionChan():
+			if !open {
+				t.Fatal("transaction chan closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		if act := string(tran.Payload.Get(0)); exp[0] != act {
+			t.Errorf("Wrong message contents: %v != %v", act, exp[0])
+		}
+		if act := tran.Payload.GetMetadata(0).Get("sequence_index"); exp[1] != act {
+			t.Errorf("Wrong sequence index: %v != %v", act, exp[1])
+		}
+		if act := tran.Payload.GetMetadata(0).Get("sequence_input"); act != "generate" {
+			t.Errorf("Wrong sequence input: %v", act)
+		}
+
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// Should close automatically now
+	select {
+	case _, open := <-in.TransactionChan():
+		if open {
+			t.Fatal("transaction chan not closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err = in.WaitForClose(time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSequenceInputEarlyClose(t *testing.T) {
+	child := NewConfig()
+	child.Type = "generate"
+	child.Generate.Rate = 0
+
+	conf := NewConfig()
+	conf.Type = "sequence"
+	conf.Sequence.Inputs = []Config{child, child}
+
+	in, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, open := <-in.TransactionChan():
+		if !open {
+			t.Fatal("transaction chan closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	in.CloseAsync()
+	if err = in.WaitForClose(time.Second * 5); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSequenceInputNoChildren(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "sequence"
+
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from empty sequence")
+	}
+}
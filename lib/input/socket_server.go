@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["socket_server"] = TypeSpec{
+		constructor: NewSocketServer,
+		description: `
+Listens for raw data over a socket, where the field ` + "`network`" + ` can be
+` + "`tcp`" + `, ` + "`udp`" + `, ` + "`unix`" + ` or ` + "`unixgram`" + `.
+
+An address prefixed with ` + "`unix://`" + `, such as
+` + "`unix:///tmp/benthos.sock`" + `, is the path of a unix domain socket, which
+is a stream socket when the network is ` + "`tcp`" + ` and a datagram socket
+when ` + "`udp`" + `. An existing socket file at the path is replaced.
+
+Data received over stream connections is divided into messages according to the
+field ` + "`framing`" + `. When ` + "`lines`" + ` each message is followed
+by the delimiter, which defaults to a line feed (\n) when left empty. When
+` + "`length_prefixed`" + ` each message is prefixed with its length as a four
+byte big endian integer. Messages larger than ` + "`max_buffer`" + ` are
+rejected and the connection is closed.
+
+Each datagram is a single message, where a trailing delimiter is removed
+when the framing is ` + "`lines`" + `.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSocketServer creates a new SocketServer input type.
+func NewSocketServer(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := reader.NewSocketServer(conf.SocketServer, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("socket_server", reader.NewPreserver(s), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["sql"] = TypeSpec{
+		constructor: NewSQL,
+		description: `
+Polls a table of a relational database by periodically executing a query, where
+each resulting row is read as a JSON object message keyed by its column names.
+The ` + "`driver`" + ` can be either ` + "`mysql`" + ` or ` + "`postgres`" + `,
+and ` + "`dsn`" + ` is the data source name in the format expected by that
+driver.
+
+The query is given a single argument, which is the value of the column
+` + "`cursor_column`" + ` of the last row read, or ` + "`cursor_initial`" + ` if
+no rows have been read. The query should therefore only select rows after the
+cursor and in the order of the cursor, such as an incrementing ID or a timestamp
+of modification:
+
+` + "``` sql" + `
+SELECT * FROM footable WHERE id > ? ORDER BY id ASC LIMIT 100;
+` + "```" + `
+
+Note that the placeholder syntax depends on the driver, MySQL uses ` + "`?`" + `
+whereas Postgres uses ` + "`$1`" + `. When a query returns no rows it is
+executed again after ` + "`poll_interval_ms`" + `.
+
+When ` + "`cursor_file`" + ` is set the cursor of the last row that was
+successfully delivered is written to that file, and is read back on start up so
+that a restarted service continues where it left off.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "``` text" + `
+- sql_cursor
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSQL creates a new SQL input type.
+func NewSQL(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	r, err := reader.NewSQL(conf.SQL, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("sql", reader.NewPreserver(r), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package input
+
+import (
+	"github.com/Jeffail/benthos/lib/input/reader"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["syslog"] = TypeSpec{
+		constructor: NewSyslog,
+		description: `
+Listens for syslog messages over either UDP or TCP, as specified by the field
+` + "`protocol`" + `. Each UDP datagram is a single message, whereas messages
+sent over TCP can be framed either with octet counting (RFC6587), where each
+message is prefixed with its length and a space, or by a trailing newline.
+
+The field ` + "`format`" + ` determines how messages are parsed, and can be
+` + "`rfc3164`" + `, ` + "`rfc5424`" + ` or ` + "`auto`" + `, which detects
+the format of each message. The header of a message is parsed into metadata and
+the remaining body is used as the contents of the message. Messages that cannot
+be parsed are passed on unchanged and without header metadata.
+
+### Metadata
+
+This input adds the following metadata fields to each message, where fields
+that are absent from the header of a message are omitted:
+
+` + "``` text" + `
+- syslog_facility
+- syslog_severity
+- syslog_timestamp
+- syslog_hostname
+- syslog_appname
+- syslog_procid
+- syslog_msgid
+- syslog_structured_data
+- syslog_remote_address
+` + "```" + `
+
+You can access these metadata fields using
+[function interpolations](../config_interpolation.md#metadata).`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSyslog creates a new Syslog input type.
+func NewSyslog(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	s, err := reader.NewSyslog(conf.Syslog, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewReader("syslog", reader.NewPreserver(s), log, stats)
+}
+
+//------------------------------------------------------------------------------
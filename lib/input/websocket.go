@@ -33,7 +33,25 @@ func init() {
 	Constructors["websocket"] = TypeSpec{
 		constructor: NewWebsocket,
 		description: `
-Sends messages to an HTTP server via a websocket connection.`,
+Connects to a websocket server and continuously receives messages.
+
+Custom ` + "`headers`" + ` are added to the opening handshake request along with
+the requested ` + "`subprotocols`" + `. When connecting to a ` + "`wss://`" + `
+URL the ` + "`tls`" + ` fields can be used to set a custom root CA, a client
+certificate, or to skip server certificate verification.
+
+It is possible to configure an ` + "`open_message`" + `, which when set to a
+non-empty string will be sent to the websocket server each time a connection is
+first established, which is useful for protocols that require a subscription
+request.
+
+Ping frames are sent to the server every ` + "`ping_period_ms`" + `, and if no
+data or pong is received within ` + "`pong_timeout_ms`" + ` of a ping the
+connection is considered lost. Setting ` + "`ping_period_ms`" + ` to zero
+disables pings.
+
+Failed connection attempts are retried with an exponential backoff starting at
+` + "`retry_period_ms`" + ` and capped at ` + "`max_retry_backoff_ms`" + `.`,
 	}
 }
 
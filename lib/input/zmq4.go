@@ -23,9 +23,12 @@
 package input
 
 import (
+	"time"
+
 	"github.com/Jeffail/benthos/lib/input/reader"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/probe"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 )
 
@@ -44,6 +47,12 @@ with the tag: 'go install -tags "ZMQ4" github.com/Jeffail/benthos/cmd/...'
 ZMQ4 input supports PULL and SUB sockets only. If there is demand for other
 socket types then they can be added easily.`,
 	}
+	connectionCheckers["zmq4"] = func(c Config, t time.Duration) error {
+		if c.ZMQ4 == nil || c.ZMQ4.Bind {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.ZMQ4.URLs...)
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -23,10 +23,12 @@ package manager
 import (
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/Jeffail/benthos/lib/cache"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/ratelimit"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 )
@@ -44,6 +46,7 @@ type APIReg interface {
 type Config struct {
 	Caches     map[string]cache.Config     `json:"caches" yaml:"caches"`
 	Conditions map[string]condition.Config `json:"conditions" yaml:"conditions"`
+	RateLimits map[string]ratelimit.Config `json:"rate_limits" yaml:"rate_limits"`
 }
 
 // NewConfig returns a Config with default values.
@@ -55,6 +58,9 @@ func NewConfig() Config {
 		Conditions: map[string]condition.Config{
 			"example": condition.NewConfig(),
 		},
+		RateLimits: map[string]ratelimit.Config{
+			"example": ratelimit.NewConfig(),
+		},
 	}
 }
 
@@ -68,6 +74,10 @@ type Type struct {
 	apiReg     APIReg
 	caches     map[string]types.Cache
 	conditions map[string]types.Condition
+	ratelimits map[string]types.RateLimit
+
+	pipes    map[string]<-chan types.Transaction
+	pipeLock sync.RWMutex
 }
 
 // New returns an instance of manager.Type, which can be shared amongst
@@ -82,6 +92,8 @@ func New(
 		apiReg:     apiReg,
 		caches:     map[string]types.Cache{},
 		conditions: map[string]types.Condition{},
+		ratelimits: map[string]types.RateLimit{},
+		pipes:      map[string]<-chan types.Transaction{},
 	}
 
 	for k, conf := range conf.Caches {
@@ -95,6 +107,17 @@ func New(
 		t.caches[k] = newCache
 	}
 
+	for k, conf := range conf.RateLimits {
+		newRL, err := ratelimit.New(conf, t, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to create rate limit resource '%v' of type '%v': %v",
+				k, conf.Type, err,
+			)
+		}
+		t.ratelimits[k] = newRL
+	}
+
 	// Sometimes condition resources might refer to other condition resources.
 	// When they are constructed they will check with the manager to ensure the
 	// resource they point to is valid, but not use the condition. Since we
@@ -117,8 +140,9 @@ func New(
 		t.conditions[k] = newCond
 	}
 
-	// Note: Caches and conditions are considered READONLY from this point
-	// onwards and are therefore NOT protected by mutexes or channels.
+	// Note: Caches, conditions and rate limits are considered READONLY from this point
+	// onwards and are therefore NOT protected by mutexes or channels. Pipes
+	// are registered at runtime and are protected by a mutex.
 
 	return t, nil
 }
@@ -146,4 +170,39 @@ func (t *Type) GetCondition(name string) (types.Condition, error) {
 	return nil, types.ErrConditionNotFound
 }
 
+// GetRateLimit attempts to find a service wide rate limit by its name.
+func (t *Type) GetRateLimit(name string) (types.RateLimit, error) {
+	if rl, exists := t.ratelimits[name]; exists {
+		return rl, nil
+	}
+	return nil, types.ErrRateLimitNotFound
+}
+
+// GetPipe attempts to find a service wide transaction chan by its name.
+func (t *Type) GetPipe(name string) (<-chan types.Transaction, error) {
+	t.pipeLock.RLock()
+	defer t.pipeLock.RUnlock()
+	if p, exists := t.pipes[name]; exists {
+		return p, nil
+	}
+	return nil, types.ErrPipeNotFound
+}
+
+// SetPipe registers a transaction chan under a name.
+func (t *Type) SetPipe(name string, tran <-chan types.Transaction) {
+	t.pipeLock.Lock()
+	t.pipes[name] = tran
+	t.pipeLock.Unlock()
+}
+
+// UnsetPipe removes a named transaction chan, but only if it is the chan that
+// is currently registered under the name.
+func (t *Type) UnsetPipe(name string, tran <-chan types.Transaction) {
+	t.pipeLock.Lock()
+	if otherTran, exists := t.pipes[name]; exists && otherTran == tran {
+		delete(t.pipes, name)
+	}
+	t.pipeLock.Unlock()
+}
+
 //------------------------------------------------------------------------------
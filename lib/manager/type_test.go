@@ -127,4 +127,40 @@ func TestManagerBadCondition(t *testing.T) {
 	}
 }
 
+func TestManagerPipes(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	mgr, err := New(NewConfig(), nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = mgr.GetPipe("foo"); err != types.ErrPipeNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrPipeNotFound)
+	}
+
+	chanA := make(chan types.Transaction)
+	chanB := make(chan types.Transaction)
+
+	mgr.SetPipe("foo", chanA)
+	if p, err := mgr.GetPipe("foo"); err != nil {
+		t.Error(err)
+	} else if p != (<-chan types.Transaction)(chanA) {
+		t.Error("Wrong pipe returned")
+	}
+
+	mgr.SetPipe("foo", chanB)
+	mgr.UnsetPipe("foo", chanA)
+	if p, err := mgr.GetPipe("foo"); err != nil {
+		t.Error(err)
+	} else if p != (<-chan types.Transaction)(chanB) {
+		t.Error("Wrong pipe returned")
+	}
+
+	mgr.UnsetPipe("foo", chanB)
+	if _, err = mgr.GetPipe("foo"); err != types.ErrPipeNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrPipeNotFound)
+	}
+}
+
 //------------------------------------------------------------------------------
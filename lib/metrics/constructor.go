@@ -26,6 +26,8 @@ import (
 	"errors"
 	"sort"
 	"strings"
+
+	"github.com/Jeffail/benthos/lib/util/config"
 )
 
 //------------------------------------------------------------------------------
@@ -91,6 +93,16 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 
 //------------------------------------------------------------------------------
 
+// Specs returns a specification of each metric target type, including the
+// default values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
 // Descriptions returns a formatted string of collated descriptions of each
 // type.
 func Descriptions() string {
@@ -36,7 +36,16 @@ func init() {
 Sends message parts as objects to an Amazon S3 bucket. Each object is uploaded
 with the path specified with the 'path' field, in order to have a different path
 for each object you should use function interpolations described
-[here](../config_interpolation.md#functions).`,
+[here](../config_interpolation.md#functions), e.g.
+` + "`logs/${!timestamp:2006/01/02}/${!uuid_v4}.json`" + `. When sending batched
+messages the interpolations are performed per message part, in order to upload
+a batch as a single object it should first be combined with the
+[` + "`archive`" + `](../processors/README.md#archive) processor.
+
+The field ` + "`content_type`" + ` also supports function interpolations.
+Objects can be encrypted at rest by setting ` + "`server_side_encryption`" + `
+to either ` + "`AES256`" + ` or ` + "`aws:kms`" + `, where a KMS key other than
+the default can be specified with ` + "`kms_key_id`" + `.`,
 	}
 }
 
@@ -44,9 +53,11 @@ for each object you should use function interpolations described
 
 // NewAmazonS3 creates a new AmazonS3 output type.
 func NewAmazonS3(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
-	return NewWriter(
-		"amazon_s3", writer.NewAmazonS3(conf.AmazonS3, log, stats), log, stats,
-	)
+	w, err := writer.NewAmazonS3(conf.AmazonS3, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("amazon_s3", w, log, stats)
 }
 
 //------------------------------------------------------------------------------
@@ -33,7 +33,17 @@ func init() {
 	Constructors["amazon_sqs"] = TypeSpec{
 		constructor: NewAmazonSQS,
 		description: `
-Sends messages to an SQS queue.`,
+Sends messages to an SQS queue.
+
+The field ` + "`message_attributes`" + ` is a map of attribute keys to values
+that are added to each message, where the values support
+[function interpolations](../config_interpolation.md#functions), e.g.
+` + "`${!metadata:kafka_key}`" + `.
+
+When sending to a FIFO queue the fields ` + "`message_group_id`" + ` and
+` + "`message_deduplication_id`" + ` can be set, which also support function
+interpolations. The deduplication ID can be omitted when content based
+deduplication is enabled for the queue.`,
 	}
 }
 
@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["azure_event_hubs"] = TypeSpec{
+		constructor: NewAzureEventHubs,
+		description: `
+Sends messages as events to an Azure Event Hub, which is identified by the
+entity path of the ` + "`connection_string`" + `. The metadata of each message
+is added to its event as properties.
+
+The field ` + "`partition_key`" + ` is optional and supports
+[function interpolations](../config_interpolation.md#functions). Events that
+share a partition key are sent to the same partition.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureEventHubs creates a new Azure Event Hubs output type.
+func NewAzureEventHubs(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewAzureEventHubs(conf.AzureEventHubs, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("azure_event_hubs", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
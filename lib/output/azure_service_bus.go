@@ -0,0 +1,56 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["azure_service_bus"] = TypeSpec{
+		constructor: NewAzureServiceBus,
+		description: `
+Sends messages to either an Azure Service Bus ` + "`queue`" + ` or ` + "`topic`" + `.
+The metadata of each message is added to it as user properties.
+
+The field ` + "`session_id`" + ` is required when sending to session enabled
+entities, and supports
+[function interpolations](../config_interpolation.md#functions).`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewAzureServiceBus creates a new Azure Service Bus output type.
+func NewAzureServiceBus(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewAzureServiceBus(conf.AzureServiceBus, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("azure_service_bus", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
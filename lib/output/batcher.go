@@ -0,0 +1,248 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/batch"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// Batcher wraps an output with a batching policy. Messages are buffered until
+// the policy triggers, at which point the buffered parts are sent to the child
+// output as a single batch.
+//
+// Transactions that do not trigger a flush are responded to with an
+// unacknowledged response, which allows inputs to continue reading without
+// acknowledging the message, and the transaction that triggers a flush is
+// responded to with the result of sending the whole batch. When that send
+// fails the parts of earlier transactions are kept in the batch, so that they
+// are sent again along with the triggering transaction once it is retried.
+// Batches flushed due to their period elapsing, or because the input has
+// closed, have no transaction to respond to and are therefore retried until
+// they succeed.
+type Batcher struct {
+	running int32
+
+	child  Type
+	policy *batch.Policy
+
+	log   log.Modular
+	stats metrics.Type
+
+	transactionsIn  <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewBatcher creates a new Batcher output around a child output.
+func NewBatcher(
+	policy *batch.Policy,
+	child Type,
+	log log.Modular,
+	stats metrics.Type,
+) Type {
+	return &Batcher{
+		running:         1,
+		child:           child,
+		policy:          policy,
+		log:             log.NewModule(".batcher"),
+		stats:           stats,
+		transactionsOut: make(chan types.Transaction),
+		closeChan:       make(chan struct{}),
+		closedChan:      make(chan struct{}),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// BatchCountLimit returns the current count of message parts that triggers a
+// batch and the maximum, which is the count of the batch policy.
+func (b *Batcher) BatchCountLimit() (limit, max int) {
+	return b.policy.CountLimit()
+}
+
+// SetBatchCountLimit changes the count of message parts that triggers a batch.
+// Batches already being accumulated are flushed once they reach the new count.
+func (b *Batcher) SetBatchCountLimit(limit int) {
+	b.policy.SetCountLimit(limit)
+}
+
+//------------------------------------------------------------------------------
+
+func (b *Batcher) loop() {
+	var (
+		mSent      = b.stats.GetCounter("output.batcher.sent")
+		mSentParts = b.stats.GetCounter("output.batcher.sent.parts")
+		mError     = b.stats.GetCounter("output.batcher.error")
+	)
+
+	defer func() {
+		close(b.transactionsOut)
+		b.child.CloseAsync()
+		err := b.child.WaitForClose(time.Second)
+		for ; err != nil; err = b.child.WaitForClose(time.Second) {
+		}
+		close(b.closedChan)
+	}()
+
+	resChan := make(chan types.Response)
+
+	// sendBatch flushes the policy and sends the result to the child output,
+	// returning nil if the output was closed during the send.
+	sendBatch := func(msg types.Message) types.Response {
+		select {
+		case b.transactionsOut <- types.NewTransaction(msg, resChan):
+		case <-b.closeChan:
+			return nil
+		}
+		var res types.Response
+		select {
+		case res = <-resChan:
+		case <-b.closeChan:
+			return nil
+		}
+		if res.Error() != nil {
+			mError.Incr(1)
+		} else {
+			mSent.Incr(1)
+			mSentParts.Incr(int64(msg.Len()))
+		}
+		return res
+	}
+
+	// sendUntilSent sends a batch that has no transaction to respond to,
+	// retrying until it succeeds, and returns false if the output was closed.
+	sendUntilSent := func(msg types.Message) bool {
+		for {
+			res := sendBatch(msg)
+			if res == nil {
+				return false
+			}
+			if res.Error() == nil {
+				return true
+			}
+			b.log.Errorf("Failed to send batch%v: %v\n", types.CorrelationLogTag(msg), res.Error())
+			select {
+			case <-time.After(time.Second):
+			case <-b.closeChan:
+				return false
+			}
+		}
+	}
+
+	for atomic.LoadInt32(&b.running) == 1 {
+		var timer <-chan time.Time
+		if until := b.policy.UntilNext(); until >= 0 {
+			timer = time.After(until)
+		}
+
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-b.transactionsIn:
+			if !open {
+				if msg := b.policy.Flush(); msg != nil {
+					sendUntilSent(msg)
+				}
+				return
+			}
+		case <-timer:
+			if msg := b.policy.Flush(); msg != nil && !sendUntilSent(msg) {
+				return
+			}
+			continue
+		case <-b.closeChan:
+			return
+		}
+
+		flush := false
+		tran.Payload.Iter(func(i int, p []byte) error {
+			if b.policy.Add(p, tran.Payload.GetMetadata(i)) {
+				flush = true
+			}
+			return nil
+		})
+
+		var res types.Response = types.NewUnacknowledgedResponse()
+		if flush {
+			msg := b.policy.Flush()
+			if res = sendBatch(msg); res == nil {
+				return
+			}
+			if res.Error() != nil {
+				// The parts of earlier transactions have already been
+				// responded to and are therefore kept for the next batch,
+				// whereas the triggering parts are added again when the
+				// transaction is retried.
+				for i := 0; i < msg.Len()-tran.Payload.Len(); i++ {
+					b.policy.Add(msg.Get(i), msg.GetMetadata(i))
+				}
+			}
+		}
+
+		select {
+		case tran.ResponseChan <- res:
+		case <-b.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a new transactions channel for the output to read.
+func (b *Batcher) StartReceiving(ts <-chan types.Transaction) error {
+	if b.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := b.child.StartReceiving(b.transactionsOut); err != nil {
+		return err
+	}
+	b.transactionsIn = ts
+	go b.loop()
+	return nil
+}
+
+// CloseAsync shuts down the Batcher and stops processing messages.
+func (b *Batcher) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&b.running, 1, 0) {
+		close(b.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Batcher output has closed down.
+func (b *Batcher) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-b.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
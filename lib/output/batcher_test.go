@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/batch"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func startBatcherTest(t *testing.T, conf batch.PolicyConfig) (chan types.Transaction, *mockOutput, Type) {
+	testLog := log.NewLogger(os.Stdout, logConfig)
+
+	policy, err := batch.NewPolicy(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := &mockOutput{}
+	b := NewBatcher(policy, child, testLog, metrics.DudType{})
+
+	tChan := make(chan types.Transaction)
+	if err = b.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+	return tChan, child, b
+}
+
+func sendBatcherTest(t *testing.T, tChan chan types.Transaction, content string) <-chan types.Response {
+	resChan := make(chan types.Response, 1)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	return resChan
+}
+
+func TestBatcherCount(t *testing.T) {
+	conf := batch.NewPolicyConfig()
+	conf.Count = 2
+
+	tChan, child, b := startBatcherTest(t, conf)
+	defer func() {
+		close(tChan)
+		if err := b.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case res := <-sendBatcherTest(t, tChan, "foo"):
+		if res.Error() != nil || !res.SkipAck() {
+			t.Errorf("Expected unacknowledged response, received: %v", res)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// Only the triggering transaction is resent after a failed batch, and the
+	// parts of earlier transactions must be kept.
+	for _, resErr := range []error{errors.New("nope"), nil} {
+		resChan := sendBatcherTest(t, tChan, "bar")
+
+		select {
+		case tran := <-child.ts:
+			exp := [][]byte{[]byte("foo"), []byte("bar")}
+			if act := tran.Payload.GetAll(); !reflect.DeepEqual(exp, act) {
+				t.Errorf("Wrong batch: %s != %s", act, exp)
+			}
+			tran.ResponseChan <- types.NewSimpleResponse(resErr)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() != resErr {
+				t.Errorf("Wrong response: %v != %v", res.Error(), resErr)
+			}
+			if res.SkipAck() {
+				t.Error("Expected flush response to be acknowledged")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+}
+
+func TestBatcherFlushOnClose(t *testing.T) {
+	conf := batch.NewPolicyConfig()
+	conf.Count = 10
+
+	tChan, child, b := startBatcherTest(t, conf)
+
+	select {
+	case <-sendBatcherTest(t, tChan, "foo"):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	close(tChan)
+
+	select {
+	case tran := <-child.ts:
+		exp := [][]byte{[]byte("foo")}
+		if act := tran.Payload.GetAll(); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong batch: %s != %s", act, exp)
+		}
+		tran.ResponseChan <- types.NewSimpleResponse(nil)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	if err := b.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBatcherPeriod(t *testing.T) {
+	conf := batch.NewPolicyConfig()
+	conf.Count = 10
+	conf.PeriodMS = 10
+
+	tChan, child, b := startBatcherTest(t, conf)
+	defer func() {
+		close(tChan)
+		if err := b.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	select {
+	case <-sendBatcherTest(t, tChan, "foo"):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case tran := <-child.ts:
+		exp := [][]byte{[]byte("foo")}
+		if act := tran.Payload.GetAll(); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong batch: %s != %s", act, exp)
+		}
+		tran.ResponseChan <- types.NewSimpleResponse(nil)
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+//------------------------------------------------------------------------------
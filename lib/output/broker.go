@@ -21,6 +21,7 @@
 package output
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
@@ -80,6 +81,22 @@ through Benthos. If an output applies back pressure it will block all subsequent
 messages, and if an output fails to send a message it will be retried
 continuously until completion or service shut down.
 
+#### ` + "`fan_out_async`" + `
+
+The async fan out pattern also sends every message to all outputs, but each
+output has its own queue of up to ` + "`fan_out_async.queue_size`" + ` messages,
+so that a slow output does not throttle the others. Messages are acknowledged
+once they have been added to the queue of each output, and therefore messages
+that are still queued when the service shuts down are lost.
+
+When the queue of an output is full the action taken depends on
+` + "`fan_out_async.queue_full_policy`" + `, which can be one of:
+
+- ` + "`block`" + `: Wait until the queue has space, which blocks all outputs.
+- ` + "`drop`" + `: Drop the message for that output.
+- ` + "`dead_letter`" + `: Send the message to the output configured in
+  ` + "`fan_out_async.dead_letter`" + ` instead.
+
 #### ` + "`round_robin`" + `
 
 With the round robin pattern each message will be assigned a single output
@@ -96,6 +113,27 @@ messages as soon as they are able to process them. This results in certain
 faster outputs potentially processing more messages at the cost of slower
 outputs.
 
+Since an output only claims a message once it has resolved its previous ones
+this pattern balances messages by the backlog of each output, which improves
+throughput when the outputs have uneven latencies. The backlog allowed per
+output can be increased with its ` + "`max_in_flight`" + ` field.
+
+#### ` + "`try`" + `
+
+The try pattern attempts to send each message to only one output, starting from
+the first output on the list. If an output fails then the broker attempts to
+send to the next output in the list and so on. If every output fails the
+message is rejected, and is therefore resent by inputs that support
+acknowledgements.
+
+This pattern is useful for triggering events in the case where certain output
+targets have broken. For example, if you had an output type ` + "`http_client`" + `
+but wished to reroute messages whenever the endpoint becomes unreachable you
+could use a try broker with a ` + "`file`" + ` output as a dead letter queue.
+Some outputs retry failed messages internally before reporting a failure, in
+which case it is worth limiting those retries, e.g. with the
+` + "`retries`" + ` field of an ` + "`http_client`" + ` output.
+
 ### Utilising More Outputs
 
 When using brokered outputs with patterns such as round robin or greedy it is
@@ -115,19 +153,71 @@ nodes processors.`,
 
 //------------------------------------------------------------------------------
 
+// BrokerFanOutAsyncConfig contains configuration fields for the fan_out_async
+// broker pattern.
+type BrokerFanOutAsyncConfig struct {
+	QueueSize       int     `json:"queue_size" yaml:"queue_size"`
+	QueueFullPolicy string  `json:"queue_full_policy" yaml:"queue_full_policy"`
+	DeadLetter      *Config `json:"dead_letter" yaml:"dead_letter"`
+}
+
+// NewBrokerFanOutAsyncConfig creates a new BrokerFanOutAsyncConfig with
+// default values.
+func NewBrokerFanOutAsyncConfig() BrokerFanOutAsyncConfig {
+	return BrokerFanOutAsyncConfig{
+		QueueSize:       1000,
+		QueueFullPolicy: broker.QueueFullBlock,
+		DeadLetter:      nil,
+	}
+}
+
+type dummyBrokerFanOutAsyncConfig struct {
+	QueueSize       int         `json:"queue_size" yaml:"queue_size"`
+	QueueFullPolicy string      `json:"queue_full_policy" yaml:"queue_full_policy"`
+	DeadLetter      interface{} `json:"dead_letter" yaml:"dead_letter"`
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (b BrokerFanOutAsyncConfig) MarshalJSON() ([]byte, error) {
+	dummy := dummyBrokerFanOutAsyncConfig{
+		QueueSize:       b.QueueSize,
+		QueueFullPolicy: b.QueueFullPolicy,
+		DeadLetter:      b.DeadLetter,
+	}
+	if b.DeadLetter == nil {
+		dummy.DeadLetter = struct{}{}
+	}
+	return json.Marshal(dummy)
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (b BrokerFanOutAsyncConfig) MarshalYAML() (interface{}, error) {
+	dummy := dummyBrokerFanOutAsyncConfig{
+		QueueSize:       b.QueueSize,
+		QueueFullPolicy: b.QueueFullPolicy,
+		DeadLetter:      b.DeadLetter,
+	}
+	if b.DeadLetter == nil {
+		dummy.DeadLetter = struct{}{}
+	}
+	return dummy, nil
+}
+
 // BrokerConfig is configuration for the Broker output type.
 type BrokerConfig struct {
-	Copies  int              `json:"copies" yaml:"copies"`
-	Pattern string           `json:"pattern" yaml:"pattern"`
-	Outputs brokerOutputList `json:"outputs" yaml:"outputs"`
+	Copies      int                     `json:"copies" yaml:"copies"`
+	Pattern     string                  `json:"pattern" yaml:"pattern"`
+	Outputs     brokerOutputList        `json:"outputs" yaml:"outputs"`
+	FanOutAsync BrokerFanOutAsyncConfig `json:"fan_out_async" yaml:"fan_out_async"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
-		Copies:  1,
-		Pattern: "fan_out",
-		Outputs: brokerOutputList{},
+		Copies:      1,
+		Pattern:     "fan_out",
+		Outputs:     brokerOutputList{},
+		FanOutAsync: NewBrokerFanOutAsyncConfig(),
 	}
 }
 
@@ -168,10 +258,23 @@ func NewBroker(
 	switch conf.Broker.Pattern {
 	case "fan_out":
 		return broker.NewFanOut(outputs, log, stats)
+	case "fan_out_async":
+		asyncConf := conf.Broker.FanOutAsync
+		var deadLetter types.Output
+		if asyncConf.DeadLetter != nil && asyncConf.QueueFullPolicy == broker.QueueFullDeadLetter {
+			if deadLetter, err = New(*asyncConf.DeadLetter, mgr, log, stats); err != nil {
+				return nil, fmt.Errorf("failed to create dead letter output: %v", err)
+			}
+		}
+		return broker.NewFanOutAsync(
+			outputs, asyncConf.QueueSize, asyncConf.QueueFullPolicy, deadLetter, log, stats,
+		)
 	case "round_robin":
 		return broker.NewRoundRobin(outputs, stats)
 	case "greedy":
 		return broker.NewGreedy(outputs)
+	case "try":
+		return broker.NewTry(outputs, log, stats)
 	}
 
 	return nil, fmt.Errorf("broker pattern was not recognised: %v", conf.Broker.Pattern)
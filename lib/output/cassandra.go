@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["cassandra"] = TypeSpec{
+		constructor: NewCassandra,
+		description: `
+Executes a parameterised CQL query for each message part against a Cassandra or
+ScyllaDB cluster. Multiple part messages are executed as a single unlogged
+batch.
+
+The values bound to the query are resolved from ` + "`args`" + `, which support
+[function interpolations](../config_interpolation.md#functions) evaluated per
+message part, allowing values to be mapped from JSON fields or metadata:
+
+` + "``` yaml" + `
+output:
+  type: cassandra
+  cassandra:
+    addresses:
+    - localhost:9042
+    query: INSERT INTO foo.bar (id, content, topic) VALUES (?, ?, ?)
+    args:
+    - ${!json_field:id}
+    - ${!json_field:content}
+    - ${!metadata:kafka_topic}
+` + "```" + `
+
+Each value is bound as a string, which is converted to the type of its column
+where possible.
+
+When ` + "`token_aware`" + ` is true queries are routed directly to the replicas
+that own the partition key. The field ` + "`consistency`" + ` sets the
+consistency level of each query, e.g. ` + "`ONE`" + `, ` + "`QUORUM`" + ` or
+` + "`LOCAL_QUORUM`" + `.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewCassandra creates a new Cassandra output type.
+func NewCassandra(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewCassandra(conf.Cassandra, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("cassandra", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
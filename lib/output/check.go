@@ -0,0 +1,241 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/probe"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/socket"
+)
+
+//------------------------------------------------------------------------------
+
+// connectionChecker dials the targets of an output config without connecting
+// with the output itself, which avoids side effects such as declaring
+// exchanges or creating topics.
+type connectionChecker func(conf Config, timeout time.Duration) error
+
+// connectionCheckers is a map of output types to their connection checkers.
+// Every output type must either have a checker, be a composite of other
+// outputs, or be listed within checksNotSupported.
+var connectionCheckers = map[string]connectionChecker{
+	"amazon_s3": func(c Config, t time.Duration) error {
+		return probe.DialAWS(t, "s3", c.AmazonS3.Region)
+	},
+	"amazon_sqs": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.AmazonSQS.URL)
+	},
+	"amqp": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, append([]string{c.AMQP.URL}, c.AMQP.URLs...)...)
+	},
+	"azure_event_hubs": func(c Config, t time.Duration) error {
+		return probe.DialAzure(t, c.AzureEventHubs.ConnectionString)
+	},
+	"azure_service_bus": func(c Config, t time.Duration) error {
+		return probe.DialAzure(t, c.AzureServiceBus.ConnectionString)
+	},
+	"cassandra": func(c Config, t time.Duration) error {
+		return probe.Dial(t, "tcp", "9042", c.Cassandra.Addresses...)
+	},
+	"elasticsearch": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.Elasticsearch.URLs...)
+	},
+	"gcp_pubsub": func(c Config, t time.Duration) error {
+		if host := os.Getenv("PUBSUB_EMULATOR_HOST"); len(host) > 0 {
+			return probe.Dial(t, "tcp", "", host)
+		}
+		return probe.Dial(t, "tcp", "443", "pubsub.googleapis.com")
+	},
+	"grpc": func(c Config, t time.Duration) error {
+		return probe.Dial(t, "tcp", "", c.GRPC.Address)
+	},
+	"http_client": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.HTTPClient.URL)
+	},
+	"influxdb": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.InfluxDB.URL)
+	},
+	"kafka": func(c Config, t time.Duration) error {
+		return probe.Dial(t, "tcp", "9092", c.Kafka.Addresses...)
+	},
+	"mongodb": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.MongoDB.URL)
+	},
+	"mqtt": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.MQTT.URLs...)
+	},
+	"nats": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.NATS.URLs...)
+	},
+	"nats_stream": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.NATSStream.URLs...)
+	},
+	"nsq": func(c Config, t time.Duration) error {
+		return probe.Dial(t, "tcp", "", c.NSQ.Address)
+	},
+	"redis_list": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.RedisList.URL)
+	},
+	"redis_pubsub": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.RedisPubSub.URL)
+	},
+	"redis_streams": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.RedisStreams.URL)
+	},
+	"scalability_protocols": func(c Config, t time.Duration) error {
+		if c.ScaleProto.Bind {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.ScaleProto.URLs...)
+	},
+	"socket": func(c Config, t time.Duration) error {
+		network, address, err := socket.Resolve(c.Socket.Network, c.Socket.Address)
+		if err != nil {
+			return err
+		}
+		if socket.IsPacket(network) {
+			return types.ErrCheckNotSupported
+		}
+		return probe.Dial(t, network, "", address)
+	},
+	"sql": func(c Config, t time.Duration) error {
+		return probe.DialSQL(t, c.SQL.Driver, c.SQL.DSN)
+	},
+	"webhdfs": func(c Config, t time.Duration) error {
+		return probe.DialURLs(t, c.WebHDFS.URL)
+	},
+	"websocket": func(c Config, t time.Duration) error {
+		if c.Websocket.Server.Enabled {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.Websocket.URL)
+	},
+	"zmq4n": func(c Config, t time.Duration) error {
+		if c.ZMQ4N.Bind {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.ZMQ4N.URLs...)
+	},
+}
+
+// checksNotSupported is the set of output types that do not connect to a
+// remote target, or that cannot be checked without connecting with the output
+// itself.
+var checksNotSupported = map[string]struct{}{
+	"dynamic":       {},
+	"file":          {},
+	"files":         {},
+	"http_server":   {},
+	"inproc":        {},
+	"stdout":        {},
+	"sync_response": {},
+}
+
+//------------------------------------------------------------------------------
+
+// CheckConnections attempts to dial the targets of each output within a config
+// (including the children of brokers) without connecting with the outputs
+// themselves or producing any data, and returns the status of each attempt.
+// Output types that do not support connection checks are reported with the
+// error types.ErrCheckNotSupported.
+func CheckConnections(
+	conf Config,
+	timeout time.Duration,
+	log log.Modular,
+	stats metrics.Type,
+) []types.ConnectionStatus {
+	return checkConnections("output", conf, timeout, log, stats)
+}
+
+func checkConnections(
+	path string,
+	conf Config,
+	timeout time.Duration,
+	log log.Modular,
+	stats metrics.Type,
+) []types.ConnectionStatus {
+	switch conf.Type {
+	case "broker":
+		statuses := []types.ConnectionStatus{}
+		for i, c := range conf.Broker.Outputs {
+			statuses = append(statuses, checkConnections(
+				fmt.Sprintf("%v.broker.outputs.%v", path, i), c, timeout, log, stats,
+			)...)
+		}
+		return statuses
+	case "drop_on_error":
+		if conf.DropOnError.Output == nil {
+			break
+		}
+		return checkConnections(
+			path+".drop_on_error.output", *conf.DropOnError.Output, timeout, log, stats,
+		)
+	case "retry":
+		if conf.Retry.Output == nil {
+			break
+		}
+		return checkConnections(
+			path+".retry.output", *conf.Retry.Output, timeout, log, stats,
+		)
+	case "switch":
+		statuses := []types.ConnectionStatus{}
+		for i, c := range conf.Switch.Cases {
+			statuses = append(statuses, checkConnections(
+				fmt.Sprintf("%v.switch.cases.%v.output", path, i), c.Output, timeout, log, stats,
+			)...)
+		}
+		return statuses
+	case "fault_injection":
+		if conf.FaultInjection.Output == nil {
+			break
+		}
+		return checkConnections(
+			path+".fault_injection.output", *conf.FaultInjection.Output, timeout, log, stats,
+		)
+	}
+	return []types.ConnectionStatus{{
+		Path: path + "." + conf.Type,
+		Err:  checkConnection(conf, timeout, log),
+	}}
+}
+
+func checkConnection(conf Config, timeout time.Duration, log log.Modular) error {
+	if _, exists := Constructors[conf.Type]; !exists {
+		return types.ErrInvalidOutputType
+	}
+	if _, exists := checksNotSupported[conf.Type]; exists {
+		return types.ErrCheckNotSupported
+	}
+	checker, exists := connectionCheckers[conf.Type]
+	if !exists {
+		return fmt.Errorf("no connection check exists for output type: %v", conf.Type)
+	}
+	log.Debugf("Checking connection of output type: %v\n", conf.Type)
+	return checker(conf, timeout)
+}
+
+//------------------------------------------------------------------------------
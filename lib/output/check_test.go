@@ -0,0 +1,124 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCheckConnections(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	socketConf := NewConfig()
+	socketConf.Type = "socket"
+	socketConf.Socket.Network = "tcp"
+	socketConf.Socket.Address = ln.Addr().String()
+
+	stdoutConf := NewConfig()
+	stdoutConf.Type = "stdout"
+
+	redisConf := NewConfig()
+	redisConf.Type = "redis_list"
+	redisConf.RedisList.URL = "tcp://localhost:1"
+
+	badConf := NewConfig()
+	badConf.Type = "not_exist"
+
+	conf := NewConfig()
+	conf.Type = "broker"
+	conf.Broker.Outputs = append(conf.Broker.Outputs, socketConf, stdoutConf, redisConf, badConf)
+
+	statuses := CheckConnections(
+		conf, time.Second*5, log.NewLogger(os.Stdout, logConfig), metrics.DudType{},
+	)
+	if exp, act := 4, len(statuses); exp != act {
+		t.Fatalf("Wrong count of statuses: %v != %v", act, exp)
+	}
+
+	if exp, act := "output.broker.outputs.0.socket", statuses[0].Path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if err = statuses[0].Err; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	if exp, act := "output.broker.outputs.1.stdout", statuses[1].Path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if exp, act := types.ErrCheckNotSupported, statuses[1].Err; exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+
+	if exp, act := "output.broker.outputs.2.redis_list", statuses[2].Path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if statuses[2].Err == nil {
+		t.Error("Expected error from unreachable redis")
+	}
+
+	if exp, act := "output.broker.outputs.3.not_exist", statuses[3].Path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if exp, act := types.ErrInvalidOutputType, statuses[3].Err; exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+}
+
+func TestCheckConnectionsCoverage(t *testing.T) {
+	composites := map[string]struct{}{
+		"broker":          {},
+		"drop_on_error":   {},
+		"fault_injection": {},
+		"retry":           {},
+		"switch":          {},
+	}
+	for typeStr := range Constructors {
+		_, isComposite := composites[typeStr]
+		_, isNotSupported := checksNotSupported[typeStr]
+		_, hasChecker := connectionCheckers[typeStr]
+		if !isComposite && !isNotSupported && !hasChecker {
+			t.Errorf("Output type '%v' has no connection check", typeStr)
+		}
+		if hasChecker && isNotSupported {
+			t.Errorf("Output type '%v' has a connection check but is listed as not supported", typeStr)
+		}
+	}
+	for typeStr := range connectionCheckers {
+		if _, exists := Constructors[typeStr]; !exists {
+			t.Errorf("Connection check exists for unknown output type '%v'", typeStr)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
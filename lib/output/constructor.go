@@ -31,7 +31,9 @@ import (
 	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/pipeline"
 	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/processor/condition"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/batch"
 	"github.com/Jeffail/benthos/lib/util/config"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 	yaml "gopkg.in/yaml.v2"
@@ -61,57 +63,97 @@ var Constructors = map[string]TypeSpec{}
 // Note that some configs are empty structs, as the type has no optional values
 // but we want to list it as an option.
 type Config struct {
-	Type          string                     `json:"type" yaml:"type"`
-	AmazonS3      writer.AmazonS3Config      `json:"amazon_s3" yaml:"amazon_s3"`
-	AmazonSQS     writer.AmazonSQSConfig     `json:"amazon_sqs" yaml:"amazon_sqs"`
-	AMQP          AMQPConfig                 `json:"amqp" yaml:"amqp"`
-	Broker        BrokerConfig               `json:"broker" yaml:"broker"`
-	Dynamic       DynamicConfig              `json:"dynamic" yaml:"dynamic"`
-	Elasticsearch writer.ElasticsearchConfig `json:"elasticsearch" yaml:"elasticsearch"`
-	File          FileConfig                 `json:"file" yaml:"file"`
-	Files         writer.FilesConfig         `json:"files" yaml:"files"`
-	HTTPClient    HTTPClientConfig           `json:"http_client" yaml:"http_client"`
-	HTTPServer    HTTPServerConfig           `json:"http_server" yaml:"http_server"`
-	Kafka         writer.KafkaConfig         `json:"kafka" yaml:"kafka"`
-	MQTT          writer.MQTTConfig          `json:"mqtt" yaml:"mqtt"`
-	NATS          NATSConfig                 `json:"nats" yaml:"nats"`
-	NATSStream    NATSStreamConfig           `json:"nats_stream" yaml:"nats_stream"`
-	NSQ           NSQConfig                  `json:"nsq" yaml:"nsq"`
-	RedisList     writer.RedisListConfig     `json:"redis_list" yaml:"redis_list"`
-	RedisPubSub   RedisPubSubConfig          `json:"redis_pubsub" yaml:"redis_pubsub"`
-	ScaleProto    ScaleProtoConfig           `json:"scalability_protocols" yaml:"scalability_protocols"`
-	STDOUT        STDOUTConfig               `json:"stdout" yaml:"stdout"`
-	Websocket     writer.WebsocketConfig     `json:"websocket" yaml:"websocket"`
-	ZMQ4          *writer.ZMQ4Config         `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
-	Processors    []processor.Config         `json:"processors" yaml:"processors"`
+	Type            string                       `json:"type" yaml:"type"`
+	AmazonS3        writer.AmazonS3Config        `json:"amazon_s3" yaml:"amazon_s3"`
+	AmazonSQS       writer.AmazonSQSConfig       `json:"amazon_sqs" yaml:"amazon_sqs"`
+	AMQP            AMQPConfig                   `json:"amqp" yaml:"amqp"`
+	AzureEventHubs  writer.AzureEventHubsConfig  `json:"azure_event_hubs" yaml:"azure_event_hubs"`
+	AzureServiceBus writer.AzureServiceBusConfig `json:"azure_service_bus" yaml:"azure_service_bus"`
+	Broker          BrokerConfig                 `json:"broker" yaml:"broker"`
+	Cassandra       writer.CassandraConfig       `json:"cassandra" yaml:"cassandra"`
+	DropOnError     DropOnErrorConfig            `json:"drop_on_error" yaml:"drop_on_error"`
+	Dynamic         DynamicConfig                `json:"dynamic" yaml:"dynamic"`
+	Elasticsearch   writer.ElasticsearchConfig   `json:"elasticsearch" yaml:"elasticsearch"`
+	FaultInjection  FaultInjectionConfig         `json:"fault_injection" yaml:"fault_injection"`
+	File            FileConfig                   `json:"file" yaml:"file"`
+	Files           writer.FilesConfig           `json:"files" yaml:"files"`
+	GCPPubSub       writer.GCPPubSubConfig       `json:"gcp_pubsub" yaml:"gcp_pubsub"`
+	GRPC            writer.GRPCConfig            `json:"grpc" yaml:"grpc"`
+	HTTPClient      HTTPClientConfig             `json:"http_client" yaml:"http_client"`
+	HTTPServer      HTTPServerConfig             `json:"http_server" yaml:"http_server"`
+	InfluxDB        writer.InfluxDBConfig        `json:"influxdb" yaml:"influxdb"`
+	Inproc          InprocConfig                 `json:"inproc" yaml:"inproc"`
+	Kafka           writer.KafkaConfig           `json:"kafka" yaml:"kafka"`
+	MongoDB         writer.MongoDBConfig         `json:"mongodb" yaml:"mongodb"`
+	MQTT            writer.MQTTConfig            `json:"mqtt" yaml:"mqtt"`
+	NATS            NATSConfig                   `json:"nats" yaml:"nats"`
+	NATSStream      NATSStreamConfig             `json:"nats_stream" yaml:"nats_stream"`
+	NSQ             NSQConfig                    `json:"nsq" yaml:"nsq"`
+	RedisList       writer.RedisListConfig       `json:"redis_list" yaml:"redis_list"`
+	RedisPubSub     RedisPubSubConfig            `json:"redis_pubsub" yaml:"redis_pubsub"`
+	RedisStreams    writer.RedisStreamsConfig    `json:"redis_streams" yaml:"redis_streams"`
+	Retry           RetryConfig                  `json:"retry" yaml:"retry"`
+	ScaleProto      ScaleProtoConfig             `json:"scalability_protocols" yaml:"scalability_protocols"`
+	Socket          writer.SocketConfig          `json:"socket" yaml:"socket"`
+	SQL             writer.SQLConfig             `json:"sql" yaml:"sql"`
+	STDOUT          STDOUTConfig                 `json:"stdout" yaml:"stdout"`
+	Switch          SwitchConfig                 `json:"switch" yaml:"switch"`
+	WebHDFS         writer.WebHDFSConfig         `json:"webhdfs" yaml:"webhdfs"`
+	Websocket       writer.WebsocketConfig       `json:"websocket" yaml:"websocket"`
+	ZMQ4            *writer.ZMQ4Config           `json:"zmq4,omitempty" yaml:"zmq4,omitempty"`
+	ZMQ4N           writer.ZMQ4NConfig           `json:"zmq4n" yaml:"zmq4n"`
+	MaxInFlight     int                          `json:"max_in_flight" yaml:"max_in_flight"`
+	OrderedAcks     bool                         `json:"ordered_acks" yaml:"ordered_acks"`
+	Batching        batch.PolicyConfig           `json:"batching" yaml:"batching"`
+	Processors      []processor.Config           `json:"processors" yaml:"processors"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:          "stdout",
-		AmazonS3:      writer.NewAmazonS3Config(),
-		AmazonSQS:     writer.NewAmazonSQSConfig(),
-		AMQP:          NewAMQPConfig(),
-		Broker:        NewBrokerConfig(),
-		Dynamic:       NewDynamicConfig(),
-		Elasticsearch: writer.NewElasticsearchConfig(),
-		File:          NewFileConfig(),
-		Files:         writer.NewFilesConfig(),
-		HTTPClient:    NewHTTPClientConfig(),
-		HTTPServer:    NewHTTPServerConfig(),
-		Kafka:         writer.NewKafkaConfig(),
-		MQTT:          writer.NewMQTTConfig(),
-		NATS:          NewNATSConfig(),
-		NATSStream:    NewNATSStreamConfig(),
-		NSQ:           NewNSQConfig(),
-		RedisList:     writer.NewRedisListConfig(),
-		RedisPubSub:   NewRedisPubSubConfig(),
-		ScaleProto:    NewScaleProtoConfig(),
-		STDOUT:        NewSTDOUTConfig(),
-		Websocket:     writer.NewWebsocketConfig(),
-		ZMQ4:          writer.NewZMQ4Config(),
-		Processors:    []processor.Config{},
+		Type:            "stdout",
+		AmazonS3:        writer.NewAmazonS3Config(),
+		AmazonSQS:       writer.NewAmazonSQSConfig(),
+		AMQP:            NewAMQPConfig(),
+		AzureEventHubs:  writer.NewAzureEventHubsConfig(),
+		AzureServiceBus: writer.NewAzureServiceBusConfig(),
+		Broker:          NewBrokerConfig(),
+		Cassandra:       writer.NewCassandraConfig(),
+		DropOnError:     NewDropOnErrorConfig(),
+		Dynamic:         NewDynamicConfig(),
+		Elasticsearch:   writer.NewElasticsearchConfig(),
+		FaultInjection:  NewFaultInjectionConfig(),
+		File:            NewFileConfig(),
+		Files:           writer.NewFilesConfig(),
+		GCPPubSub:       writer.NewGCPPubSubConfig(),
+		GRPC:            writer.NewGRPCConfig(),
+		HTTPClient:      NewHTTPClientConfig(),
+		HTTPServer:      NewHTTPServerConfig(),
+		InfluxDB:        writer.NewInfluxDBConfig(),
+		Inproc:          NewInprocConfig(),
+		Kafka:           writer.NewKafkaConfig(),
+		MongoDB:         writer.NewMongoDBConfig(),
+		MQTT:            writer.NewMQTTConfig(),
+		NATS:            NewNATSConfig(),
+		NATSStream:      NewNATSStreamConfig(),
+		NSQ:             NewNSQConfig(),
+		RedisList:       writer.NewRedisListConfig(),
+		RedisPubSub:     NewRedisPubSubConfig(),
+		RedisStreams:    writer.NewRedisStreamsConfig(),
+		Retry:           NewRetryConfig(),
+		ScaleProto:      NewScaleProtoConfig(),
+		Socket:          writer.NewSocketConfig(),
+		SQL:             writer.NewSQLConfig(),
+		STDOUT:          NewSTDOUTConfig(),
+		Switch:          NewSwitchConfig(),
+		WebHDFS:         writer.NewWebHDFSConfig(),
+		Websocket:       writer.NewWebsocketConfig(),
+		ZMQ4:            writer.NewZMQ4Config(),
+		ZMQ4N:           writer.NewZMQ4NConfig(),
+		MaxInFlight:     1,
+		OrderedAcks:     false,
+		Batching:        batch.NewPolicyConfig(),
+		Processors:      []processor.Config{},
 	}
 }
 
@@ -148,15 +190,99 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 			}
 			outSlice = append(outSlice, sanOutput)
 		}
-		outputMap[t] = map[string]interface{}{
+		brokerMap := map[string]interface{}{
 			"copies":  conf.Broker.Copies,
 			"pattern": conf.Broker.Pattern,
 			"outputs": outSlice,
 		}
+		if conf.Broker.Pattern == "fan_out_async" {
+			asyncMap := map[string]interface{}{
+				"queue_size":        conf.Broker.FanOutAsync.QueueSize,
+				"queue_full_policy": conf.Broker.FanOutAsync.QueueFullPolicy,
+			}
+			if dl := conf.Broker.FanOutAsync.DeadLetter; dl != nil {
+				var sanDL interface{}
+				if sanDL, err = SanitiseConfig(*dl); err != nil {
+					return nil, err
+				}
+				asyncMap["dead_letter"] = sanDL
+			}
+			brokerMap["fan_out_async"] = asyncMap
+		}
+		outputMap[t] = brokerMap
+	} else if t == "switch" {
+		caseSlice := []interface{}{}
+		for _, c := range conf.Switch.Cases {
+			var sanCond, sanOutput interface{}
+			if sanCond, err = condition.SanitiseConfig(c.Condition); err != nil {
+				return nil, err
+			}
+			if sanOutput, err = SanitiseConfig(c.Output); err != nil {
+				return nil, err
+			}
+			caseSlice = append(caseSlice, map[string]interface{}{
+				"condition":       sanCond,
+				"fallthrough":     c.Fallthrough,
+				"max_retries":     c.MaxRetries,
+				"retry_period_ms": c.RetryPeriodMS,
+				"output":          sanOutput,
+			})
+		}
+		outputMap[t] = map[string]interface{}{
+			"cases": caseSlice,
+		}
+	} else if t == "drop_on_error" {
+		dropMap := map[string]interface{}{
+			"log_drops": conf.DropOnError.LogDrops,
+			"output":    struct{}{},
+		}
+		if conf.DropOnError.Output != nil {
+			var sanOutput interface{}
+			if sanOutput, err = SanitiseConfig(*conf.DropOnError.Output); err != nil {
+				return nil, err
+			}
+			dropMap["output"] = sanOutput
+		}
+		outputMap[t] = dropMap
+	} else if t == "retry" {
+		retryMap := map[string]interface{}{
+			"max_retries":         conf.Retry.MaxRetries,
+			"initial_interval_ms": conf.Retry.InitialInterval,
+			"max_interval_ms":     conf.Retry.MaxInterval,
+			"max_elapsed_time_ms": conf.Retry.MaxElapsedTimeMS,
+			"jitter":              conf.Retry.Jitter,
+			"output":              struct{}{},
+		}
+		if conf.Retry.Output != nil {
+			var sanOutput interface{}
+			if sanOutput, err = SanitiseConfig(*conf.Retry.Output); err != nil {
+				return nil, err
+			}
+			retryMap["output"] = sanOutput
+		}
+		outputMap[t] = retryMap
 	} else {
 		outputMap[t] = hashMap[t]
 	}
 
+	if conf.MaxInFlight != 1 {
+		outputMap["max_in_flight"] = conf.MaxInFlight
+		outputMap["ordered_acks"] = conf.OrderedAcks
+	}
+
+	if !conf.Batching.IsNoop() {
+		sanCond, err := condition.SanitiseConfig(conf.Batching.Condition)
+		if err != nil {
+			return nil, err
+		}
+		outputMap["batching"] = map[string]interface{}{
+			"count":     conf.Batching.Count,
+			"byte_size": conf.Batching.ByteSize,
+			"period_ms": conf.Batching.PeriodMS,
+			"condition": sanCond,
+		}
+	}
+
 	if len(conf.Processors) == 0 {
 		return outputMap, nil
 	}
@@ -218,13 +344,61 @@ combines multiple outputs under a specific pattern.
 
 It is possible to perform
 [content based multiplexing](../concepts.md#content-based-multiplexing) of
-messages to specific outputs using a broker with the 'fan_out' pattern and a
-[filter processor](../processors/README.md#filter) on each output, which
-is a processor that drops messages if the condition does not pass. Conditions
-are content aware logical operators that can be combined using boolean logic.
+messages to specific outputs using a [switch output](#switch), where each case
+has a condition that determines whether a message is sent to its output.
+Conditions are content aware logical operators that can be combined using
+boolean logic.
 
 For more information regarding conditions, including a full list of available
-conditions please [read the docs here](../conditions/README.md)`
+conditions please [read the docs here](../conditions/README.md)
+
+### Max In Flight
+
+By default an output resolves a single message at a time. Setting the field
+` + "`max_in_flight`" + ` of an output to a value greater than one creates that number
+of instances of the output, allowing multiple messages to be sent in parallel
+as long as the layers before it provide them (e.g. with multiple
+[pipeline threads](../pipeline.md)). Since each instance is created separately
+this should not be used with outputs that bind to an address, such as
+` + "`http_server`" + `.
+
+Messages sent in parallel can be acknowledged out of order, which is a problem
+for inputs such as ` + "`kafka`" + ` that commit offsets. Setting the field
+` + "`ordered_acks`" + ` to ` + "`true`" + ` holds acknowledgements until all preceding
+messages have also been acknowledged.
+
+### Batching
+
+The field ` + "`batching`" + ` of an output allows messages to be grouped into
+batches right before they are written, so that each output can produce the
+batch shape that suits it best from the same stream. A batch is flushed when any
+of the following triggers: the number of parts reaches ` + "`count`" + `, the total
+size of the parts in bytes reaches ` + "`byte_size`" + `, ` + "`period_ms`" + ` has
+passed since the first part of the batch was added, or the ` + "`condition`" + `
+resolves to true for an added part. The defaults disable batching.
+
+` + "``` yaml" + `
+output:
+  type: amazon_s3
+  batching:
+    count: 100
+    period_ms: 5000
+` + "```" + `
+
+Messages that are buffered are not acknowledged until the batch containing them
+is sent, which preserves at-least-once delivery for inputs that acknowledge
+cumulatively, such as ` + "`kafka`" + `. Batching applies to all outputs except
+brokers, where it should instead be configured on the child outputs.`
+
+// Specs returns a specification of each output type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
 
 // Descriptions returns a formatted string of collated descriptions of each
 // type.
@@ -296,7 +470,11 @@ func New(
 			return pipeline.NewProcessor(log, stats, processors...), nil
 		}}, pipelines...)
 	}
-	if c, ok := Constructors[conf.Type]; ok {
+	c, ok := Constructors[conf.Type]
+	if !ok {
+		return nil, types.ErrInvalidOutputType
+	}
+	ctor := func() (Type, error) {
 		if c.brokerConstructor != nil {
 			return c.brokerConstructor(conf, mgr, log, stats, pipelines...)
 		}
@@ -304,9 +482,32 @@ func New(
 		if err != nil {
 			return nil, fmt.Errorf("failed to create output '%v': %v", conf.Type, err)
 		}
+		if !conf.Batching.IsNoop() {
+			policy, err := batch.NewPolicy(conf.Batching, mgr, log.NewModule("."+conf.Type), stats)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create batch policy: %v", err)
+			}
+			output = NewBatcher(policy, output, log.NewModule("."+conf.Type), stats)
+		}
 		return WrapWithPipelines(output, pipelines...)
 	}
-	return nil, types.ErrInvalidOutputType
+	if conf.MaxInFlight <= 1 {
+		return ctor()
+	}
+
+	// Each transaction in flight is given its own instance of the output, and
+	// of any processing pipelines, so that they can be resolved in parallel.
+	outputs := make([]Type, conf.MaxInFlight)
+	for i := range outputs {
+		var err error
+		if outputs[i], err = ctor(); err != nil {
+			for _, o := range outputs[:i] {
+				o.CloseAsync()
+			}
+			return nil, err
+		}
+	}
+	return NewMaxInFlight(outputs, conf.OrderedAcks)
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,244 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["drop_on_error"] = TypeSpec{
+		constructor: NewDropOnError,
+		description: `
+Attempts to write messages to a child output and if the write fails for any
+reason the message is dropped instead of being reattempted. Messages are
+therefore acknowledged upstream regardless of whether the child output succeeds.
+
+This output is useful for pipelines where availability matters more than
+delivery, such as sampled metrics streams, where a failing output should not
+block the rest of the pipeline. Dropped messages are counted with the metric
+` + "`output.drop_on_error.dropped`" + ` and, if ` + "`log_drops`" + ` is true,
+logged as errors.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// DropOnErrorConfig contains configuration values for the DropOnError output
+// type.
+type DropOnErrorConfig struct {
+	Output   *Config `json:"output" yaml:"output"`
+	LogDrops bool    `json:"log_drops" yaml:"log_drops"`
+}
+
+// NewDropOnErrorConfig creates a new DropOnErrorConfig with default values.
+func NewDropOnErrorConfig() DropOnErrorConfig {
+	return DropOnErrorConfig{
+		Output:   nil,
+		LogDrops: true,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyDropOnErrorConfig struct {
+	Output   interface{} `json:"output" yaml:"output"`
+	LogDrops bool        `json:"log_drops" yaml:"log_drops"`
+}
+
+func (d DropOnErrorConfig) dummy() dummyDropOnErrorConfig {
+	dummy := dummyDropOnErrorConfig{
+		Output:   d.Output,
+		LogDrops: d.LogDrops,
+	}
+	if d.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return dummy
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (d DropOnErrorConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.dummy())
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (d DropOnErrorConfig) MarshalYAML() (interface{}, error) {
+	return d.dummy(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// DropOnError is an output type that writes messages to a child output and
+// acknowledges them upstream even when the child fails.
+type DropOnError struct {
+	running int32
+	conf    DropOnErrorConfig
+
+	wrapped Type
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactionsIn  <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewDropOnError creates a new DropOnError output type.
+func NewDropOnError(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.DropOnError.Output == nil {
+		return nil, errors.New("cannot create drop_on_error output without a child")
+	}
+
+	wrapped, err := New(*conf.DropOnError.Output, mgr, log, stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output '%v': %v", conf.DropOnError.Output.Type, err)
+	}
+
+	return &DropOnError{
+		running: 1,
+		conf:    conf.DropOnError,
+
+		log:     log.NewModule(".output.drop_on_error"),
+		stats:   stats,
+		wrapped: wrapped,
+
+		transactionsOut: make(chan types.Transaction),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (d *DropOnError) loop() {
+	var (
+		mRunning = d.stats.GetCounter("output.drop_on_error.running")
+		mCount   = d.stats.GetCounter("output.drop_on_error.count")
+		mSuccess = d.stats.GetCounter("output.drop_on_error.send.success")
+		mDropped = d.stats.GetCounter("output.drop_on_error.dropped")
+	)
+
+	defer func() {
+		close(d.transactionsOut)
+		d.wrapped.CloseAsync()
+		err := d.wrapped.WaitForClose(time.Second)
+		for ; err != nil; err = d.wrapped.WaitForClose(time.Second) {
+		}
+		mRunning.Decr(1)
+		close(d.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	resChan := make(chan types.Response)
+
+	for atomic.LoadInt32(&d.running) == 1 {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-d.transactionsIn:
+			if !open {
+				return
+			}
+			mCount.Incr(1)
+		case <-d.closeChan:
+			return
+		}
+
+		select {
+		case d.transactionsOut <- types.NewTransaction(tran.Payload, resChan):
+		case <-d.closeChan:
+			return
+		}
+
+		var res types.Response
+		select {
+		case res = <-resChan:
+		case <-d.closeChan:
+			return
+		}
+		if err := res.Error(); err != nil {
+			mDropped.Incr(1)
+			if d.conf.LogDrops {
+				d.log.Errorf("Dropping message%v after failed send: %v\n", types.CorrelationLogTag(tran.Payload), err)
+			}
+		} else {
+			mSuccess.Incr(1)
+		}
+
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-d.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a new transactions channel for the output to read.
+func (d *DropOnError) StartReceiving(ts <-chan types.Transaction) error {
+	if d.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := d.wrapped.StartReceiving(d.transactionsOut); err != nil {
+		return err
+	}
+	d.transactionsIn = ts
+	go d.loop()
+	return nil
+}
+
+// CloseAsync shuts down the DropOnError output and stops processing messages.
+func (d *DropOnError) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&d.running, 1, 0) {
+		close(d.closeChan)
+	}
+}
+
+// WaitForClose blocks until the DropOnError output has closed down.
+func (d *DropOnError) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-d.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestDropOnErrorNoChild(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "drop_on_error"
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing child")
+	}
+}
+
+func TestDropOnErrorAcks(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	mgr, err := manager.New(manager.NewConfig(), types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	childConf := NewConfig()
+	childConf.Type = "inproc"
+	childConf.Inproc = InprocConfig("drop_on_error")
+
+	conf := NewConfig()
+	conf.Type = "drop_on_error"
+	conf.DropOnError.Output = &childConf
+
+	d, err := New(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		d.CloseAsync()
+		if err = d.WaitForClose(time.Second * 5); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	tChan := make(chan types.Transaction)
+	if err = d.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	var pipeChan <-chan types.Transaction
+	for i := 0; i < 100 && pipeChan == nil; i++ {
+		if pipeChan, err = mgr.GetPipe("drop_on_error"); err != nil {
+			<-time.After(time.Millisecond * 10)
+		}
+	}
+	if pipeChan == nil {
+		t.Fatal("Pipe was not registered")
+	}
+
+	for _, resErr := range []error{errors.New("nope"), nil} {
+		resChan := make(chan types.Response)
+		select {
+		case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case tran := <-pipeChan:
+			if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong payload: %v != %v", act, exp)
+			}
+			select {
+			case tran.ResponseChan <- types.NewSimpleResponse(resErr):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Errorf("Expected ack, received: %v", res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
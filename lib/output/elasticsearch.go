@@ -34,7 +34,21 @@ func init() {
 		constructor: NewElasticsearch,
 		description: `
 Publishes messages into an Elasticsearch index as documents. This output
-currently does not support creating the target index.`,
+currently does not support creating the target index.
+
+Each message is written using a single request to the bulk API, where each part
+of the message is a document. Messages can therefore be grouped into larger bulk
+requests with the ` + "`batching`" + ` field of the output, e.g. by count or by
+period. Both the fields ` + "`index`" + ` and ` + "`id`" + ` support
+[function interpolations](../config_interpolation.md#functions), which are
+resolved per document.
+
+Documents rejected with a 429 or 5XX status are retried on their own with an
+exponential backoff until ` + "`max_retries`" + ` (zero means unlimited) or
+` + "`backoff.max_elapsed_time_ms`" + ` is reached, after which the message is
+failed and resent as a whole. Documents rejected with any other status, such
+as a mapping error, would be rejected again and are therefore logged with their
+reasons and dropped without failing the message.`,
 	}
 }
 
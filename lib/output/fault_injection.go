@@ -0,0 +1,350 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/cenkalti/backoff"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["fault_injection"] = TypeSpec{
+		constructor: NewFaultInjection,
+		description: `
+Wraps an output and injects artificial failures into it. This output is intended
+for testing the delivery guarantees of a pipeline before it is trusted in
+production, and should never be used otherwise.
+
+The field ` + "`error_rate`" + ` is the probability (between 0 and 1) that a
+message is rejected with an error without being sent to the child output.
+
+The field ` + "`ack_delay_ms`" + ` adds a delay to the acknowledgement of each
+message after it has been sent.
+
+The field ` + "`disconnect_period_ms`" + `, when greater than zero, causes the
+child output to be closed and recreated on that schedule, which simulates a
+dropped connection. If the child output fails to be recreated then further
+attempts are made with an exponential backoff until either it succeeds or the
+output is closed.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ErrFaultInjected is returned for messages that are rejected by the
+// fault_injection output.
+var ErrFaultInjected = errors.New("fault injected")
+
+// FaultInjectionConfig is configuration values for the FaultInjection output
+// type.
+type FaultInjectionConfig struct {
+	Output             *Config `json:"output" yaml:"output"`
+	ErrorRate          float64 `json:"error_rate" yaml:"error_rate"`
+	AckDelayMS         int     `json:"ack_delay_ms" yaml:"ack_delay_ms"`
+	DisconnectPeriodMS int     `json:"disconnect_period_ms" yaml:"disconnect_period_ms"`
+}
+
+// NewFaultInjectionConfig creates a new FaultInjectionConfig with default
+// values.
+func NewFaultInjectionConfig() FaultInjectionConfig {
+	return FaultInjectionConfig{
+		Output:             nil,
+		ErrorRate:          0,
+		AckDelayMS:         0,
+		DisconnectPeriodMS: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyFaultInjectionConfig struct {
+	Output             interface{} `json:"output" yaml:"output"`
+	ErrorRate          float64     `json:"error_rate" yaml:"error_rate"`
+	AckDelayMS         int         `json:"ack_delay_ms" yaml:"ack_delay_ms"`
+	DisconnectPeriodMS int         `json:"disconnect_period_ms" yaml:"disconnect_period_ms"`
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (f FaultInjectionConfig) MarshalJSON() ([]byte, error) {
+	dummy := dummyFaultInjectionConfig{
+		Output:             f.Output,
+		ErrorRate:          f.ErrorRate,
+		AckDelayMS:         f.AckDelayMS,
+		DisconnectPeriodMS: f.DisconnectPeriodMS,
+	}
+	if f.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return json.Marshal(dummy)
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (f FaultInjectionConfig) MarshalYAML() (interface{}, error) {
+	dummy := dummyFaultInjectionConfig{
+		Output:             f.Output,
+		ErrorRate:          f.ErrorRate,
+		AckDelayMS:         f.AckDelayMS,
+		DisconnectPeriodMS: f.DisconnectPeriodMS,
+	}
+	if f.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return dummy, nil
+}
+
+//------------------------------------------------------------------------------
+
+// FaultInjection is an output type that wraps another output and injects
+// artificial failures into it.
+type FaultInjection struct {
+	running int32
+	conf    FaultInjectionConfig
+
+	wrapped     Type
+	wrappedTran chan types.Transaction
+	wrappedCtor func(Config, types.Manager, log.Modular, metrics.Type) (Type, error)
+	backoffCtor func() backoff.BackOff
+	rand        *rand.Rand
+
+	wrapperMgr   types.Manager
+	wrapperLog   log.Modular
+	wrapperStats metrics.Type
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactions <-chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewFaultInjection creates a new FaultInjection output type.
+func NewFaultInjection(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.FaultInjection.Output == nil {
+		return nil, errors.New("cannot create fault_injection output without a child")
+	}
+
+	f := &FaultInjection{
+		running: 1,
+		conf:    conf.FaultInjection,
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+
+		wrappedCtor: newFaultInjectionChild,
+		backoffCtor: func() backoff.BackOff {
+			boff := backoff.NewExponentialBackOff()
+			boff.InitialInterval = time.Millisecond * 100
+			boff.MaxInterval = time.Second * 5
+			boff.MaxElapsedTime = 0
+			return boff
+		},
+
+		wrapperLog:   log,
+		wrapperStats: stats,
+		wrapperMgr:   mgr,
+
+		log:        log.NewModule(".output.fault_injection"),
+		stats:      stats,
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+	if err := f.createWrapped(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+//------------------------------------------------------------------------------
+
+func newFaultInjectionChild(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	return New(conf, mgr, log, stats)
+}
+
+func (f *FaultInjection) createWrapped() error {
+	wrapped, err := f.wrappedCtor(*f.conf.Output, f.wrapperMgr, f.wrapperLog, f.wrapperStats)
+	if err != nil {
+		return fmt.Errorf("failed to create output '%v': %v", f.conf.Output.Type, err)
+	}
+	wrappedTran := make(chan types.Transaction)
+	if err = wrapped.StartReceiving(wrappedTran); err != nil {
+		wrapped.CloseAsync()
+		return err
+	}
+	f.wrapped = wrapped
+	f.wrappedTran = wrappedTran
+	return nil
+}
+
+func (f *FaultInjection) closeWrapped() {
+	f.wrapped.CloseAsync()
+	err := f.wrapped.WaitForClose(time.Second)
+	for ; err != nil; err = f.wrapped.WaitForClose(time.Second) {
+	}
+}
+
+func (f *FaultInjection) loop() {
+	var (
+		mRunning      = f.stats.GetCounter("output.fault_injection.running")
+		mCount        = f.stats.GetCounter("output.fault_injection.count")
+		mInjectedErr  = f.stats.GetCounter("output.fault_injection.error.injected")
+		mDisconnect   = f.stats.GetCounter("output.fault_injection.disconnect")
+		mRecreateErr  = f.stats.GetCounter("output.fault_injection.output.recreate.error")
+		mRecreateSucc = f.stats.GetCounter("output.fault_injection.output.recreate.success")
+	)
+
+	defer func() {
+		if f.wrapped != nil {
+			f.closeWrapped()
+		}
+		mRunning.Decr(1)
+		close(f.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	var disconnectChan <-chan time.Time
+	if f.conf.DisconnectPeriodMS > 0 {
+		ticker := time.NewTicker(time.Duration(f.conf.DisconnectPeriodMS) * time.Millisecond)
+		defer ticker.Stop()
+		disconnectChan = ticker.C
+	}
+	ackDelay := time.Duration(f.conf.AckDelayMS) * time.Millisecond
+	resChan := make(chan types.Response)
+
+	for atomic.LoadInt32(&f.running) == 1 {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-f.transactions:
+			if !open {
+				return
+			}
+			mCount.Incr(1)
+		case <-disconnectChan:
+			mDisconnect.Incr(1)
+			f.log.Infoln("Injecting output disconnect")
+			f.closeWrapped()
+			f.wrapped = nil
+			boff := f.backoffCtor()
+			for {
+				err := f.createWrapped()
+				if err == nil {
+					break
+				}
+				mRecreateErr.Incr(1)
+				f.log.Errorf("Failed to recreate output: %v\n", err)
+				select {
+				case <-time.After(boff.NextBackOff()):
+				case <-f.closeChan:
+					return
+				}
+			}
+			mRecreateSucc.Incr(1)
+			continue
+		case <-f.closeChan:
+			return
+		}
+
+		var res types.Response
+		if f.conf.ErrorRate > 0 && f.rand.Float64() < f.conf.ErrorRate {
+			mInjectedErr.Incr(1)
+			res = types.NewSimpleResponse(ErrFaultInjected)
+		} else {
+			select {
+			case f.wrappedTran <- types.NewTransaction(tran.Payload, resChan):
+			case <-f.closeChan:
+				return
+			}
+			select {
+			case res, open = <-resChan:
+				if !open {
+					return
+				}
+			case <-f.closeChan:
+				return
+			}
+		}
+
+		if ackDelay > 0 {
+			select {
+			case <-time.After(ackDelay):
+			case <-f.closeChan:
+				return
+			}
+		}
+
+		select {
+		case tran.ResponseChan <- res:
+		case <-f.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a messages channel for the output to read.
+func (f *FaultInjection) StartReceiving(ts <-chan types.Transaction) error {
+	if f.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	f.transactions = ts
+	go f.loop()
+	return nil
+}
+
+// CloseAsync shuts down the FaultInjection output and stops processing
+// messages.
+func (f *FaultInjection) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&f.running, 1, 0) {
+		close(f.closeChan)
+	}
+}
+
+// WaitForClose blocks until the FaultInjection output has closed down.
+func (f *FaultInjection) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-f.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
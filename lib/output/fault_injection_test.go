@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/cenkalti/backoff"
+)
+
+//------------------------------------------------------------------------------
+
+func newFaultInjectionTestConf(t *testing.T) (Config, string) {
+	tmpDir, err := ioutil.TempDir("", "benthos_fault_injection_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	childConf := NewConfig()
+	childConf.Type = "files"
+	childConf.Files.Path = filepath.Join(tmpDir, "${!count:fault_injection_files}.txt")
+
+	conf := NewConfig()
+	conf.Type = "fault_injection"
+	conf.FaultInjection.Output = &childConf
+	return conf, tmpDir
+}
+
+func sendFaultInjectionMsg(t *testing.T, tChan chan types.Transaction, content string) types.Response {
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case res := <-resChan:
+		return res
+	case <-time.After(time.Second * 5):
+		t.Fatal("Timed out")
+	}
+	return nil
+}
+
+func TestFaultInjectionNoChild(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "fault_injection"
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing child")
+	}
+}
+
+func TestFaultInjectionErrors(t *testing.T) {
+	conf, tmpDir := newFaultInjectionTestConf(t)
+	defer os.RemoveAll(tmpDir)
+	conf.FaultInjection.ErrorRate = 1
+
+	f, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tChan := make(chan types.Transaction)
+	if err = f.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if exp, act := ErrFaultInjected, sendFaultInjectionMsg(t, tChan, "foo").Error(); exp != act {
+			t.Errorf("Wrong response error: %v != %v", act, exp)
+		}
+	}
+
+	f.CloseAsync()
+	if err = f.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected no files written, found %v", len(files))
+	}
+}
+
+func TestFaultInjectionAckDelayAndDisconnect(t *testing.T) {
+	conf, tmpDir := newFaultInjectionTestConf(t)
+	defer os.RemoveAll(tmpDir)
+	conf.FaultInjection.AckDelayMS = 20
+	conf.FaultInjection.DisconnectPeriodMS = 10
+
+	f, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tChan := make(chan types.Transaction)
+	if err = f.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		start := time.Now()
+		if res := sendFaultInjectionMsg(t, tChan, "foo"); res.Error() != nil {
+			t.Error(res.Error())
+		}
+		if elapsed := time.Since(start); elapsed < time.Millisecond*20 {
+			t.Errorf("Ack was not delayed: %v", elapsed)
+		}
+		<-time.After(time.Millisecond * 15)
+	}
+
+	f.CloseAsync()
+	if err = f.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 5, len(files); exp != act {
+		t.Errorf("Wrong count of files written: %v != %v", act, exp)
+	}
+}
+
+func TestFaultInjectionRecreateRetries(t *testing.T) {
+	conf, tmpDir := newFaultInjectionTestConf(t)
+	defer os.RemoveAll(tmpDir)
+	conf.FaultInjection.DisconnectPeriodMS = 10
+
+	o, err := NewFaultInjection(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := o.(*FaultInjection)
+
+	failures := 0
+	f.wrappedCtor = func(c Config, mgr types.Manager, l log.Modular, s metrics.Type) (Type, error) {
+		if failures < 3 {
+			failures++
+			return nil, errors.New("test err")
+		}
+		return New(c, mgr, l, s)
+	}
+	f.backoffCtor = func() backoff.BackOff {
+		return backoff.NewConstantBackOff(time.Millisecond)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = f.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if res := sendFaultInjectionMsg(t, tChan, "foo"); res.Error() != nil {
+			t.Error(res.Error())
+		}
+		<-time.After(time.Millisecond * 15)
+	}
+
+	f.CloseAsync()
+	if err = f.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+
+	if exp, act := 3, failures; exp != act {
+		t.Errorf("Wrong count of failed recreates: %v != %v", act, exp)
+	}
+	files, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := 5, len(files); exp != act {
+		t.Errorf("Wrong count of files written: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["grpc"] = TypeSpec{
+		constructor: NewGRPC,
+		description: `
+Pushes messages over a bidirectional gRPC stream to a server of the service
+defined by the protobuf file ` + "`lib/util/pb/benthos.proto`" + `, such as the
+` + "`grpc`" + ` input of another Benthos instance.
+
+Each message is sent along with the metadata of its parts, and the output waits
+for the server to respond before the next message is sent. When the response
+contains an error the message is considered undelivered, which is propagated
+back to the input of the pipeline.
+
+TLS is enabled with the field ` + "`tls.enabled`" + `, custom root
+certificate authorities can be specified with ` + "`tls.root_cas_file`" + `,
+and a client certificate for mutual TLS with ` + "`tls.cert_file`" + ` and
+` + "`tls.key_file`" + `.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewGRPC creates a new GRPC output type.
+func NewGRPC(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewGRPC(conf.GRPC, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("grpc", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -23,7 +23,10 @@ package output
 import (
 	"bytes"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/textproto"
@@ -33,7 +36,9 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
+	"github.com/Jeffail/benthos/lib/util/http/pool"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
 	"github.com/Jeffail/benthos/lib/util/throttle"
 )
 
@@ -47,8 +52,28 @@ The HTTP client output type connects to a server and sends POST requests for
 each message. The body of the request is the raw message contents. The output
 will apply back pressure until a 2XX response has been returned from the server.
 
-For more information about sending HTTP messages, including details on sending
-multipart, please read the 'docs/using_http.md' document.`,
+Messages with multiple parts are sent as a single multipart request, where the
+field ` + "`multipart_type`" + ` sets the media type of the request to either
+` + "`form-data`" + ` (the default) or ` + "`mixed`" + `.
+
+The field ` + "`headers`" + ` is a map of header keys to values that are added to
+each request. The values support
+[function interpolations](../config_interpolation.md#functions), which allows
+message metadata to be mapped to headers, e.g. ` + "`${!metadata:kafka_key}`" + `.
+
+### Response Capture
+
+If the field ` + "`response_inproc`" + ` is set then the body of each successful
+response is sent to an [` + "`inproc`" + `](../inputs/README.md#inproc) input
+of that ID, allowing responses to be audited or processed by another stream.
+The output waits for the captured response to be acknowledged before
+acknowledging the original message, and therefore applies back pressure when
+nothing consumes the responses.
+
+By default the captured message contains the response body as a single part. If
+the field ` + "`response_metadata_key`" + ` is set then the captured message is
+instead a copy of the sent message with the response body stored as metadata
+under that key.`,
 	}
 }
 
@@ -56,29 +81,41 @@ multipart, please read the 'docs/using_http.md' document.`,
 
 // HTTPClientConfig is configuration for the HTTPClient output type.
 type HTTPClientConfig struct {
-	URL            string `json:"url" yaml:"url"`
-	Verb           string `json:"verb" yaml:"verb"`
-	ContentType    string `json:"content_type" yaml:"content_type"`
-	TimeoutMS      int64  `json:"timeout_ms" yaml:"timeout_ms"`
-	RetryMS        int64  `json:"retry_period_ms" yaml:"retry_period_ms"`
-	MaxBackoffMS   int64  `json:"max_retry_backoff_ms" yaml:"max_retry_backoff_ms"`
-	NumRetries     int    `json:"retries" yaml:"retries"`
-	SkipCertVerify bool   `json:"skip_cert_verify" yaml:"skip_cert_verify"`
-	auth.Config    `json:",inline" yaml:",inline"`
+	URL             string            `json:"url" yaml:"url"`
+	Verb            string            `json:"verb" yaml:"verb"`
+	ContentType     string            `json:"content_type" yaml:"content_type"`
+	Headers         map[string]string `json:"headers" yaml:"headers"`
+	TimeoutMS       int64             `json:"timeout_ms" yaml:"timeout_ms"`
+	RetryMS         int64             `json:"retry_period_ms" yaml:"retry_period_ms"`
+	MaxBackoffMS    int64             `json:"max_retry_backoff_ms" yaml:"max_retry_backoff_ms"`
+	NumRetries      int               `json:"retries" yaml:"retries"`
+	SkipCertVerify  bool              `json:"skip_cert_verify" yaml:"skip_cert_verify"`
+	RateLimit       string            `json:"rate_limit" yaml:"rate_limit"`
+	MultipartType   string            `json:"multipart_type" yaml:"multipart_type"`
+	ResponseInproc  string            `json:"response_inproc" yaml:"response_inproc"`
+	ResponseMetaKey string            `json:"response_metadata_key" yaml:"response_metadata_key"`
+	ConnectionPool  pool.Config       `json:"connection_pool" yaml:"connection_pool"`
+	auth.Config     `json:",inline" yaml:",inline"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
 func NewHTTPClientConfig() HTTPClientConfig {
 	return HTTPClientConfig{
-		URL:            "http://localhost:4195/post",
-		Verb:           "POST",
-		ContentType:    "application/octet-stream",
-		TimeoutMS:      5000,
-		RetryMS:        1000,
-		MaxBackoffMS:   300000,
-		NumRetries:     3,
-		SkipCertVerify: false,
-		Config:         auth.NewConfig(),
+		URL:             "http://localhost:4195/post",
+		Verb:            "POST",
+		ContentType:     "application/octet-stream",
+		Headers:         map[string]string{},
+		TimeoutMS:       5000,
+		RetryMS:         1000,
+		MaxBackoffMS:    300000,
+		NumRetries:      3,
+		SkipCertVerify:  false,
+		RateLimit:       "",
+		MultipartType:   "form-data",
+		ResponseInproc:  "",
+		ResponseMetaKey: "",
+		ConnectionPool:  pool.NewConfig(),
+		Config:          auth.NewConfig(),
 	}
 }
 
@@ -88,10 +125,13 @@ func NewHTTPClientConfig() HTTPClientConfig {
 type HTTPClient struct {
 	running int32
 
+	mgr   types.Manager
 	stats metrics.Type
 	log   log.Modular
 
 	conf          Config
+	headers       map[string][]byte
+	rateLimit     types.RateLimit
 	retryThrottle *throttle.Type
 
 	transactions <-chan types.Transaction
@@ -102,8 +142,18 @@ type HTTPClient struct {
 
 // NewHTTPClient creates a new HTTPClient output type.
 func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	switch conf.HTTPClient.MultipartType {
+	case "form-data", "mixed":
+	default:
+		return nil, fmt.Errorf("multipart_type not recognised: %v", conf.HTTPClient.MultipartType)
+	}
+	if len(conf.HTTPClient.ResponseInproc) > 0 && mgr == nil {
+		return nil, errors.New("response_inproc requires a manager")
+	}
+
 	h := HTTPClient{
 		running:    1,
+		mgr:        mgr,
 		stats:      stats,
 		log:        log.NewModule(".output.http"),
 		conf:       conf,
@@ -111,6 +161,18 @@ func NewHTTPClient(conf Config, mgr types.Manager, log log.Modular, stats metric
 		closedChan: make(chan struct{}),
 	}
 
+	h.headers = make(map[string][]byte, len(conf.HTTPClient.Headers))
+	for k, v := range conf.HTTPClient.Headers {
+		h.headers[k] = []byte(v)
+	}
+
+	if rl := conf.HTTPClient.RateLimit; len(rl) > 0 {
+		var err error
+		if h.rateLimit, err = mgr.GetRateLimit(rl); err != nil {
+			return nil, fmt.Errorf("unable to locate rate_limit resource '%v': %v", rl, err)
+		}
+	}
+
 	h.retryThrottle = throttle.New(
 		throttle.OptMaxUnthrottledRetries(0),
 		throttle.OptCloseChan(h.closeChan),
@@ -153,13 +215,96 @@ func (h *HTTPClient) createRequest(msg types.Message) (req *http.Request, err er
 			h.conf.HTTPClient.URL,
 			body,
 		); err == nil {
-			req.Header.Add("Content-Type", writer.FormDataContentType())
+			req.Header.Add("Content-Type", fmt.Sprintf(
+				"multipart/%v; boundary=%v",
+				h.conf.HTTPClient.MultipartType, writer.Boundary(),
+			))
 		}
 	}
+	if err != nil {
+		return
+	}
+	for k, v := range h.headers {
+		req.Header.Set(k, string(text.ReplaceFunctionVariablesFor(msg, v)))
+	}
 	err = h.conf.HTTPClient.Config.Sign(req)
 	return
 }
 
+// waitForAccess blocks until the configured rate limit, if any, allows a
+// request. Returns false if the output was closed whilst waiting.
+func (h *HTTPClient) waitForAccess() bool {
+	if h.rateLimit == nil {
+		return true
+	}
+	for {
+		period, err := h.rateLimit.Access()
+		if err != nil {
+			h.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(period):
+		case <-h.closeChan:
+			return false
+		}
+	}
+}
+
+// readResponse reads and closes the body of a response, returning an error if
+// the status code is not 2XX.
+func (h *HTTPClient) readResponse(res *http.Response) ([]byte, error) {
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
+	}
+	if len(h.conf.HTTPClient.ResponseInproc) == 0 {
+		_, err := io.Copy(ioutil.Discard, res.Body)
+		return nil, err
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// captureResponse sends a message containing a response body to the response
+// pipe and waits for it to be acknowledged. Returns false if the output was
+// closed whilst waiting.
+func (h *HTTPClient) captureResponse(
+	sent types.Message, body []byte, pipe chan types.Transaction,
+) (bool, error) {
+	var msg types.Message
+	if key := h.conf.HTTPClient.ResponseMetaKey; len(key) > 0 {
+		msg = sent.ShallowCopy()
+		for i := 0; i < msg.Len(); i++ {
+			msg.SetMetadata(msg.GetMetadata(i).Copy().Set(key, string(body)), i)
+		}
+	} else {
+		msg = types.NewMessage([][]byte{body})
+		if sent.Len() > 0 {
+			msg.SetMetadata(sent.GetMetadata(0))
+		}
+	}
+
+	resChan := make(chan types.Response)
+	select {
+	case pipe <- types.NewTransaction(msg, resChan):
+	case <-h.closeChan:
+		return false, nil
+	}
+	select {
+	case res := <-resChan:
+		if err := res.Error(); err != nil {
+			h.log.Errorf("Failed to capture HTTP response: %v\n", err)
+			return true, err
+		}
+	case <-h.closeChan:
+		return false, nil
+	}
+	return true, nil
+}
+
 // loop is an internal loop brokers incoming messages to output pipe through
 // POST requests.
 func (h *HTTPClient) loop() {
@@ -183,10 +328,21 @@ func (h *HTTPClient) loop() {
 	var client http.Client
 	client.Timeout = time.Duration(h.conf.HTTPClient.TimeoutMS) * time.Millisecond
 
+	var tlsConf *tls.Config
 	if h.conf.HTTPClient.SkipCertVerify {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+		tlsConf = &tls.Config{InsecureSkipVerify: true}
+	}
+	client.Transport = h.conf.HTTPClient.ConnectionPool.Transport(tlsConf)
+
+	var responsePipe chan types.Transaction
+	if pipe := h.conf.HTTPClient.ResponseInproc; len(pipe) > 0 {
+		responsePipe = make(chan types.Transaction)
+		h.mgr.SetPipe(pipe, responsePipe)
+		defer func() {
+			h.mgr.UnsetPipe(pipe, responsePipe)
+			close(responsePipe)
+		}()
+		h.log.Infof("Sending HTTP responses to inproc ID: %s\n", pipe)
 	}
 
 	var open bool
@@ -200,18 +356,17 @@ func (h *HTTPClient) loop() {
 		// POST message
 		var req *http.Request
 		var res *http.Response
+		var resBody []byte
 		var err error
 
 		if req, err = h.createRequest(ts.Payload); err == nil {
 			rateLimited := false
+			if !h.waitForAccess() {
+				return
+			}
 			if res, err = client.Do(req); err == nil {
-				if res.StatusCode < 200 || res.StatusCode > 299 {
-					if res.StatusCode == 429 {
-						rateLimited = true
-					}
-					err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
-				}
-				res.Body.Close()
+				resBody, err = h.readResponse(res)
+				rateLimited = res.StatusCode == 429
 			}
 
 			i, j := 0, h.conf.HTTPClient.NumRetries
@@ -230,14 +385,12 @@ func (h *HTTPClient) loop() {
 					}
 				}
 				rateLimited = false
+				if !h.waitForAccess() {
+					return
+				}
 				if res, err = client.Do(req); err == nil {
-					if res.StatusCode < 200 || res.StatusCode > 299 {
-						if res.StatusCode == 429 {
-							rateLimited = true
-						}
-						err = types.ErrUnexpectedHTTPRes{Code: res.StatusCode, S: res.Status}
-					}
-					res.Body.Close()
+					resBody, err = h.readResponse(res)
+					rateLimited = res.StatusCode == 429
 				}
 				i++
 			}
@@ -249,6 +402,11 @@ func (h *HTTPClient) loop() {
 		} else {
 			mSendSucc.Incr(1)
 			h.retryThrottle.Reset()
+			if len(h.conf.HTTPClient.ResponseInproc) > 0 {
+				if open, err = h.captureResponse(ts.Payload, resBody, responsePipe); !open {
+					return
+				}
+			}
 		}
 		select {
 		case ts.ResponseChan <- types.NewSimpleResponse(err):
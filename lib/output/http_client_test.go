@@ -34,6 +34,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/lib/manager"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
@@ -195,6 +196,68 @@ func TestHTTPClientBasic(t *testing.T) {
 	}
 }
 
+func TestHTTPClientHeaders(t *testing.T) {
+	resultChan := make(chan http.Header, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resultChan <- r.Header
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTPClient.URL = ts.URL + "/testpost"
+	conf.HTTPClient.Headers = map[string]string{
+		"X-Static": "foo",
+		"X-Key":    "${!metadata:kafka_key}",
+	}
+
+	h, err := NewHTTPClient(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err = h.StartReceiving(sendChan); err != nil {
+		t.Fatal(err)
+	}
+
+	testMsg := types.NewMessage([][]byte{[]byte("hello world")})
+	testMsg.GetMetadata(0).Set("kafka_key", "bar")
+
+	select {
+	case sendChan <- types.NewTransaction(testMsg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+
+	select {
+	case header := <-resultChan:
+		if exp, act := "foo", header.Get("X-Static"); exp != act {
+			t.Errorf("Wrong static header: %v != %v", act, exp)
+		}
+		if exp, act := "bar", header.Get("X-Key"); exp != act {
+			t.Errorf("Wrong interpolated header: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+
+	h.CloseAsync()
+	close(sendChan)
+
+	if err := h.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestHTTPClientMultipart(t *testing.T) {
 	nTestLoops := 1000
 
@@ -310,4 +373,242 @@ func TestHTTPClientMultipart(t *testing.T) {
 	}
 }
 
+func TestHTTPClientMultipartMixed(t *testing.T) {
+	typeChan := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			t.Error(err)
+		}
+		typeChan <- mediaType
+	}))
+	defer ts.Close()
+
+	conf := NewConfig()
+	conf.HTTPClient.URL = ts.URL + "/testpost"
+	conf.HTTPClient.MultipartType = "mixed"
+
+	h, err := NewHTTPClient(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err = h.StartReceiving(sendChan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case sendChan <- types.NewTransaction(types.NewMessage([][]byte{
+		[]byte("foo"), []byte("bar"),
+	}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+
+	select {
+	case mediaType := <-typeChan:
+		if exp, act := "multipart/mixed", mediaType; exp != act {
+			t.Errorf("Wrong media type: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+
+	h.CloseAsync()
+	close(sendChan)
+	if err := h.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHTTPClientBadMultipartType(t *testing.T) {
+	conf := NewConfig()
+	conf.HTTPClient.MultipartType = "nope"
+
+	if _, err := NewHTTPClient(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad multipart type")
+	}
+}
+
+func TestHTTPClientResponseInproc(t *testing.T) {
+	type testCase struct {
+		name       string
+		metaKey    string
+		expPart    string
+		expMetaVal string
+	}
+
+	tests := []testCase{
+		{name: "body", metaKey: "", expPart: "response", expMetaVal: "bar"},
+		{name: "metadata", metaKey: "http_response", expPart: "request", expMetaVal: "response"},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("response"))
+	}))
+	defer ts.Close()
+
+	testLog := log.NewLogger(os.Stdout, logConfig)
+	for _, test := range tests {
+		mgr, err := manager.New(manager.NewConfig(), nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conf := NewConfig()
+		conf.HTTPClient.URL = ts.URL + "/testpost"
+		conf.HTTPClient.ResponseInproc = "responses"
+		conf.HTTPClient.ResponseMetaKey = test.metaKey
+
+		h, err := NewHTTPClient(conf, mgr, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sendChan, resChan := make(chan types.Transaction), make(chan types.Response)
+		if err = h.StartReceiving(sendChan); err != nil {
+			t.Fatal(err)
+		}
+
+		var pipe <-chan types.Transaction
+		for i := 0; i < 100 && pipe == nil; i++ {
+			if pipe, err = mgr.GetPipe("responses"); err != nil {
+				<-time.After(time.Millisecond * 10)
+			}
+		}
+		if pipe == nil {
+			t.Fatalf("%v: pipe was not registered", test.name)
+		}
+
+		key := test.metaKey
+		if len(key) == 0 {
+			key = "foo"
+		}
+
+		msg := types.NewMessage([][]byte{[]byte("request")})
+		msg.SetMetadata(types.NewMetadata().Set("foo", "bar"))
+
+		select {
+		case sendChan <- types.NewTransaction(msg, resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+
+		var captured types.Transaction
+		select {
+		case captured = <-pipe:
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+		if exp, act := test.expPart, string(captured.Payload.Get(0)); exp != act {
+			t.Errorf("%v: wrong captured part: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.expMetaVal, captured.Payload.GetMetadata(0).Get(key); exp != act {
+			t.Errorf("%v: wrong captured metadata: %v != %v", test.name, act, exp)
+		}
+		if act := msg.GetMetadata(0).Get("http_response"); len(act) > 0 {
+			t.Errorf("%v: original message was modified: %v", test.name, act)
+		}
+
+		select {
+		case res := <-resChan:
+			t.Fatalf("%v: received response before capture was acknowledged: %v", test.name, res)
+		case <-time.After(time.Millisecond * 50):
+		}
+
+		select {
+		case captured.ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+
+		h.CloseAsync()
+		close(sendChan)
+		if err := h.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestHTTPClientRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	testLog := log.NewLogger(os.Stdout, logConfig)
+
+	conf := NewConfig()
+	conf.HTTPClient.URL = ts.URL + "/testpost"
+	conf.HTTPClient.RateLimit = "foo"
+
+	if _, err := NewHTTPClient(conf, types.DudMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing rate limit")
+	}
+
+	mgrConf := manager.NewConfig()
+	rlConf := mgrConf.RateLimits["example"]
+	rlConf.Local.Count = 1
+	rlConf.Local.IntervalMS = 100
+	mgrConf.RateLimits["foo"] = rlConf
+
+	mgr, err := manager.New(mgrConf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewHTTPClient(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sendChan, resChan := make(chan types.Transaction), make(chan types.Response)
+	if err = h.StartReceiving(sendChan); err != nil {
+		t.Fatal(err)
+	}
+
+	tStarted := time.Now()
+	for i := 0; i < 3; i++ {
+		select {
+		case sendChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+	}
+	if dur := time.Since(tStarted); dur < time.Millisecond*200 {
+		t.Errorf("Requests were not rate limited: %v", dur)
+	}
+
+	h.CloseAsync()
+	close(sendChan)
+	if err := h.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
 //------------------------------------------------------------------------------
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["influxdb"] = TypeSpec{
+		constructor: NewInfluxDB,
+		description: `
+Writes points to an InfluxDB database over its HTTP API, where the parts of a
+message are written as a single request. Messages can therefore be grouped into
+larger writes with the ` + "`batching`" + ` field of the output.
+
+When ` + "`format`" + ` is ` + "`line_protocol`" + ` each message part is
+expected to already be a point in
+[line protocol](https://docs.influxdata.com/influxdb/v1.6/write_protocols/line_protocol_reference/).
+
+When ` + "`format`" + ` is ` + "`json`" + ` each message part is expected to be
+a JSON object, which is converted into a point of the measurement
+` + "`json.measurement`" + ` (supports
+[function interpolations](../config_interpolation.md#functions)). The fields of
+the object listed in ` + "`json.tags`" + ` are written as tags, and the fields
+listed in ` + "`json.fields`" + ` are written as fields. If ` + "`json.fields`" + `
+is empty then all remaining fields of the object with a string, number or
+boolean value are written as fields. If ` + "`json.timestamp_field`" + ` is set
+then the timestamp of the point is taken from that field, which can either be a
+number in the units of ` + "`precision`" + ` or an RFC 3339 formatted string.
+Otherwise the point is timestamped by the server.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewInfluxDB creates a new InfluxDB output type.
+func NewInfluxDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewInfluxDB(conf.InfluxDB, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("influxdb", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
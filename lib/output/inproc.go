@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["inproc"] = TypeSpec{
+		constructor: NewInproc,
+		description: `
+Sends data directly to Benthos inputs by connecting to a unique ID. This allows
+you to hook up isolated streams whilst running Benthos in
+[streams mode](../streams_mode.md) or with multiple streams within a single
+config. It is NOT recommended that you connect the inputs of a stream with an
+output of the same stream, as feedback loops can lead to deadlocks in your
+message flow.
+
+It is possible to connect multiple inputs to the same inproc ID, in which case
+messages are distributed between them. However, only one output can be bound
+to an ID at any given time, and a new output replaces the previous one.
+
+Messages are passed through the bridge along with their acknowledgements, and
+therefore an output is only acknowledged once the stream that consumes the
+messages has acknowledged them.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// InprocConfig is configuration values for the inproc output type, which is
+// the ID of the pipe to bind to.
+type InprocConfig string
+
+// NewInprocConfig creates a new InprocConfig with default values.
+func NewInprocConfig() InprocConfig {
+	return InprocConfig("")
+}
+
+//------------------------------------------------------------------------------
+
+// Inproc is an output type that sends messages to inproc inputs bound to the
+// same ID.
+type Inproc struct {
+	running int32
+
+	pipe string
+	mgr  types.Manager
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactionsOut chan types.Transaction
+	transactionsIn  <-chan types.Transaction
+
+	closedChan chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewInproc creates a new Inproc output type.
+func NewInproc(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if len(conf.Inproc) == 0 {
+		return nil, errors.New("inproc output requires an ID")
+	}
+	return &Inproc{
+		running:         1,
+		pipe:            string(conf.Inproc),
+		mgr:             mgr,
+		log:             log.NewModule(".output.inproc"),
+		stats:           stats,
+		transactionsOut: make(chan types.Transaction),
+		closedChan:      make(chan struct{}),
+		closeChan:       make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (i *Inproc) loop() {
+	var (
+		mRunning  = i.stats.GetCounter("output.inproc.running")
+		mCount    = i.stats.GetCounter("output.inproc.count")
+		mSendSucc = i.stats.GetCounter("output.inproc.send.success")
+	)
+
+	defer func() {
+		mRunning.Decr(1)
+		i.mgr.UnsetPipe(i.pipe, i.transactionsOut)
+		close(i.transactionsOut)
+		close(i.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	i.mgr.SetPipe(i.pipe, i.transactionsOut)
+	i.log.Infof("Sending inproc messages to ID: %s\n", i.pipe)
+
+	for atomic.LoadInt32(&i.running) == 1 {
+		var ts types.Transaction
+		var open bool
+
+		select {
+		case ts, open = <-i.transactionsIn:
+			if !open {
+				return
+			}
+			mCount.Incr(1)
+		case <-i.closeChan:
+			return
+		}
+
+		select {
+		case i.transactionsOut <- ts:
+			mSendSucc.Incr(1)
+		case <-i.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a messages channel for the output to read.
+func (i *Inproc) StartReceiving(ts <-chan types.Transaction) error {
+	if i.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	i.transactionsIn = ts
+	go i.loop()
+	return nil
+}
+
+// CloseAsync shuts down the Inproc output and stops processing messages.
+func (i *Inproc) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&i.running, 1, 0) {
+		close(i.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Inproc output has closed down.
+func (i *Inproc) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-i.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
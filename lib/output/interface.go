@@ -27,3 +27,28 @@ type Type interface {
 	types.Closable
 	types.Consumer
 }
+
+// Tunable is an interface implemented by output types that are able to adjust
+// the number of transactions they allow in flight at runtime.
+type Tunable interface {
+	// InFlightLimit returns the current limit of transactions in flight
+	// along with the maximum that the limit can be set to.
+	InFlightLimit() (limit, max int)
+
+	// SetInFlightLimit changes the limit of transactions in flight, the
+	// value is bounded between one and the maximum.
+	SetInFlightLimit(limit int)
+}
+
+// BatchTunable is an interface implemented by output types that are able to
+// adjust the count of message parts they batch at runtime.
+type BatchTunable interface {
+	// BatchCountLimit returns the current count of message parts that
+	// triggers a batch along with the maximum that the count can be set to. A
+	// maximum of zero means that the output does not batch by count.
+	BatchCountLimit() (limit, max int)
+
+	// SetBatchCountLimit changes the count of message parts that triggers a
+	// batch, the value is bounded between one and the maximum.
+	SetBatchCountLimit(limit int)
+}
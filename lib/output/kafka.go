@@ -39,16 +39,32 @@ acknowledged, which is propagated back to the input. The config field
 or just a single broker.
 
 It is possible to specify a compression codec to use out of the following
-options: none, snappy, lz4 and gzip.
+options: none, snappy, lz4, gzip and zstd, where zstd requires a target version
+of at least 2.1.0.
+
+The field 'max_in_flight' sets the maximum number of unacknowledged requests
+sent to each broker. When 'idempotent_write' is enabled the producer ensures
+that each message is written exactly once to the log of a partition even when
+retried, which requires a target version of at least 0.11.0.0, 'ack_replicas'
+to be enabled and a 'max_in_flight' of 1.
 
 If the field 'key' is not empty then each message will be given its contents as
 a key. This field can be dynamically set using function interpolations described
 [here](../config_interpolation.md#functions).
 
-By default the paritioner will select partitions based on a hash of the key
-value. If the key is empty then a partition is chosen at random. You can
-alternatively force the partitioner to round-robin partitions with the field
-'round_robin_partitions'.
+The field 'partitioner' determines how the partition of each message is
+chosen, and can be one of hash, random, round_robin or manual. By default the
+hash partitioner selects partitions based on a hash of the key value, which
+ensures that messages sharing a key are written to the same partition in order.
+If the key is empty then a partition is chosen at random.
+
+The manual partitioner writes each message to the partition of the field
+'partition', which can also be set using function interpolations, e.g.
+'${!metadata:kafka_partition}'. The field 'round_robin_partitions' is
+deprecated, and when true overrides the partitioner with round_robin.
+
+When the target version is at least 0.11.0.0 the metadata of each message is
+added to it as headers.
 
 The target version by default will be the oldest supported, as it is expected
 that the server will be backwards compatible. In order to support newer client
@@ -22,7 +22,6 @@ package output
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"sync/atomic"
 	"time"
@@ -104,6 +103,10 @@ func (w *LineWriter) loop() {
 		delim = w.customDelim
 	}
 
+	// Each message is written to the handle with a single write from a buffer
+	// that is reused between messages.
+	var buf bytes.Buffer
+
 	for atomic.LoadInt32(&w.running) == 1 {
 		var ts types.Transaction
 		var open bool
@@ -117,12 +120,19 @@ func (w *LineWriter) loop() {
 		case <-w.closeChan:
 			return
 		}
-		var err error
-		if ts.Payload.Len() == 1 {
-			_, err = fmt.Fprintf(w.handle, "%s%s", ts.Payload.Get(0), delim)
-		} else {
-			_, err = fmt.Fprintf(w.handle, "%s%s%s", bytes.Join(ts.Payload.GetAll(), delim), delim, delim)
+		// Multiple part messages are terminated with an extra delimiter.
+		buf.Reset()
+		for i, part := range ts.Payload.GetAll() {
+			if i > 0 {
+				buf.Write(delim)
+			}
+			buf.Write(part)
+		}
+		buf.Write(delim)
+		if ts.Payload.Len() != 1 {
+			buf.Write(delim)
 		}
+		_, err := buf.WriteTo(w.handle)
 		if err != nil {
 			mError.Incr(1)
 			mErrorF.Incr(1)
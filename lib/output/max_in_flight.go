@@ -0,0 +1,271 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// ErrMaxInFlightNoOutputs is returned when creating a MaxInFlight type with
+// zero outputs.
+var ErrMaxInFlightNoOutputs = errors.New("attempting to create max in flight output type with no outputs")
+
+//------------------------------------------------------------------------------
+
+// MaxInFlight is an output type that dispatches transactions across a pool of
+// identical outputs, allowing multiple transactions to be in flight at the same
+// time. When ordered the responses of transactions are propagated upstream in
+// the same order as the transactions were received, regardless of the order in
+// which the outputs resolve them.
+type MaxInFlight struct {
+	running int32
+
+	outputs []Type
+	ordered bool
+
+	transactions <-chan types.Transaction
+	outputTsChan chan types.Transaction
+
+	limit     int
+	pending   int
+	limitCond *sync.Cond
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewMaxInFlight creates a new MaxInFlight output type, where the number of
+// transactions in flight at any given time is limited to the number of outputs
+// provided. The limit can be lowered at runtime with SetInFlightLimit.
+func NewMaxInFlight(outputs []Type, ordered bool) (*MaxInFlight, error) {
+	if len(outputs) == 0 {
+		return nil, ErrMaxInFlightNoOutputs
+	}
+	return &MaxInFlight{
+		running:      1,
+		outputs:      outputs,
+		ordered:      ordered,
+		outputTsChan: make(chan types.Transaction),
+		limit:        len(outputs),
+		limitCond:    sync.NewCond(&sync.Mutex{}),
+		closeChan:    make(chan struct{}),
+		closedChan:   make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// InFlightLimit returns the current limit of transactions in flight and the
+// maximum, which is the number of outputs.
+func (m *MaxInFlight) InFlightLimit() (limit, max int) {
+	m.limitCond.L.Lock()
+	limit = m.limit
+	m.limitCond.L.Unlock()
+	return limit, len(m.outputs)
+}
+
+// SetInFlightLimit changes the limit of transactions in flight. Lowering the
+// limit does not affect transactions that are already in flight.
+func (m *MaxInFlight) SetInFlightLimit(limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > len(m.outputs) {
+		limit = len(m.outputs)
+	}
+	m.limitCond.L.Lock()
+	m.limit = limit
+	m.limitCond.Broadcast()
+	m.limitCond.L.Unlock()
+}
+
+// BatchCountLimit returns the batch count limit of the outputs, or zero if they
+// do not batch.
+func (m *MaxInFlight) BatchCountLimit() (limit, max int) {
+	if t, ok := m.outputs[0].(BatchTunable); ok {
+		return t.BatchCountLimit()
+	}
+	return 0, 0
+}
+
+// SetBatchCountLimit changes the batch count limit of each output that
+// batches.
+func (m *MaxInFlight) SetBatchCountLimit(limit int) {
+	for _, o := range m.outputs {
+		if t, ok := o.(BatchTunable); ok {
+			t.SetBatchCountLimit(limit)
+		}
+	}
+}
+
+// acquire blocks until the number of transactions in flight is below the
+// limit, and returns false if the output was closed whilst waiting.
+func (m *MaxInFlight) acquire() bool {
+	m.limitCond.L.Lock()
+	defer m.limitCond.L.Unlock()
+	for m.pending >= m.limit && atomic.LoadInt32(&m.running) == 1 {
+		m.limitCond.Wait()
+	}
+	if atomic.LoadInt32(&m.running) != 1 {
+		return false
+	}
+	m.pending++
+	return true
+}
+
+func (m *MaxInFlight) release() {
+	m.limitCond.L.Lock()
+	m.pending--
+	m.limitCond.Broadcast()
+	m.limitCond.L.Unlock()
+}
+
+//------------------------------------------------------------------------------
+
+// loop is an internal loop that dispatches incoming transactions to the pool of
+// outputs.
+func (m *MaxInFlight) loop() {
+	defer func() {
+		close(m.outputTsChan)
+		for _, o := range m.outputs {
+			err := o.WaitForClose(time.Second)
+			for ; err != nil; err = o.WaitForClose(time.Second) {
+			}
+		}
+		close(m.closedChan)
+	}()
+
+	// The first transaction has nothing to wait for.
+	prevDone := make(chan struct{})
+	close(prevDone)
+
+	for atomic.LoadInt32(&m.running) == 1 {
+		if !m.acquire() {
+			return
+		}
+
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-m.transactions:
+			if !open {
+				return
+			}
+		case <-m.closeChan:
+			return
+		}
+
+		resChan := make(chan types.Response)
+		select {
+		case m.outputTsChan <- types.NewTransaction(ts.Payload, resChan):
+		case <-m.closeChan:
+			return
+		}
+
+		done := make(chan struct{})
+		go m.resolve(ts.ResponseChan, resChan, prevDone, done)
+		if m.ordered {
+			prevDone = done
+		}
+	}
+}
+
+// resolve waits for the response of a dispatched transaction and, once the
+// preceding transaction has been resolved, propagates it upstream.
+func (m *MaxInFlight) resolve(
+	resChanOut chan<- types.Response,
+	resChanIn <-chan types.Response,
+	prevDone <-chan struct{},
+	done chan<- struct{},
+) {
+	defer func() {
+		close(done)
+		m.release()
+	}()
+
+	var res types.Response
+	select {
+	case res = <-resChanIn:
+	case <-m.closeChan:
+		return
+	}
+
+	select {
+	case <-prevDone:
+	case <-m.closeChan:
+		return
+	}
+
+	select {
+	case resChanOut <- res:
+	case <-m.closeChan:
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// StartReceiving assigns a new transactions channel for the output to read.
+func (m *MaxInFlight) StartReceiving(ts <-chan types.Transaction) error {
+	if m.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	for _, o := range m.outputs {
+		if err := o.StartReceiving(m.outputTsChan); err != nil {
+			return err
+		}
+	}
+	m.transactions = ts
+	go m.loop()
+	return nil
+}
+
+// CloseAsync shuts down the MaxInFlight output and stops processing messages.
+func (m *MaxInFlight) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&m.running, 1, 0) {
+		for _, o := range m.outputs {
+			o.CloseAsync()
+		}
+		close(m.closeChan)
+
+		m.limitCond.L.Lock()
+		m.limitCond.Broadcast()
+		m.limitCond.L.Unlock()
+	}
+}
+
+// WaitForClose blocks until the MaxInFlight output has closed down.
+func (m *MaxInFlight) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-m.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
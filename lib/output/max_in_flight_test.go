@@ -0,0 +1,192 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestMaxInFlightNoOutputs(t *testing.T) {
+	if _, err := NewMaxInFlight(nil, false); err != ErrMaxInFlightNoOutputs {
+		t.Errorf("Wrong error returned: %v != %v", err, ErrMaxInFlightNoOutputs)
+	}
+}
+
+func testMaxInFlightDispatch(t *testing.T, ordered bool) (
+	tChan chan types.Transaction,
+	resChans []chan types.Response,
+	outTrans []types.Transaction,
+	m *MaxInFlight,
+) {
+	mockOuts := []*mockOutput{{}, {}, {}}
+	outputs := []Type{}
+	for _, o := range mockOuts {
+		outputs = append(outputs, o)
+	}
+
+	var err error
+	if m, err = NewMaxInFlight(outputs, ordered); err != nil {
+		t.Fatal(err)
+	}
+	tChan = make(chan types.Transaction)
+	if err = m.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		resChan := make(chan types.Response)
+		resChans = append(resChans, resChan)
+		msg := types.NewMessage([][]byte{[]byte{byte('a' + i)}})
+		select {
+		case tChan <- types.NewTransaction(msg, resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case tran := <-mockOuts[i].ts:
+			outTrans = append(outTrans, tran)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// All slots are in use, the next transaction must not be read.
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage(nil), nil):
+		t.Fatal("Expected transaction to block")
+	case <-time.After(time.Millisecond * 50):
+	}
+	return
+}
+
+func TestMaxInFlightUnordered(t *testing.T) {
+	tChan, resChans, outTrans, m := testMaxInFlightDispatch(t, false)
+
+	for _, i := range []int{2, 0, 1} {
+		select {
+		case outTrans[i].ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChans[i]:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	close(tChan)
+	if err := m.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxInFlightOrdered(t *testing.T) {
+	tChan, resChans, outTrans, m := testMaxInFlightDispatch(t, true)
+
+	for _, i := range []int{2, 1} {
+		select {
+		case outTrans[i].ResponseChan <- types.NewSimpleResponse(nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// Responses must not be propagated before the first is resolved.
+	for _, i := range []int{1, 2} {
+		select {
+		case <-resChans[i]:
+			t.Fatalf("Received response %v out of order", i)
+		case <-time.After(time.Millisecond * 50):
+		}
+	}
+
+	select {
+	case outTrans[0].ResponseChan <- types.NewSimpleResponse(nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	for i, resChan := range resChans {
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for response %v", i)
+		}
+	}
+
+	close(tChan)
+	if err := m.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMaxInFlightBatchTunable(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "stdout"
+	conf.MaxInFlight = 2
+	conf.Batching.Count = 8
+
+	out, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.CloseAsync()
+
+	tunable, ok := out.(BatchTunable)
+	if !ok {
+		t.Fatal("Expected output to be batch tunable")
+	}
+	if limit, max := tunable.BatchCountLimit(); limit != 8 || max != 8 {
+		t.Errorf("Wrong batch count limit: %v, %v != 8, 8", limit, max)
+	}
+	tunable.SetBatchCountLimit(3)
+	for i, o := range out.(*MaxInFlight).outputs {
+		if limit, _ := o.(BatchTunable).BatchCountLimit(); limit != 3 {
+			t.Errorf("Wrong batch count limit of output %v: %v != 3", i, limit)
+		}
+	}
+
+	conf.Batching.Count = 0
+	if out, err = New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err != nil {
+		t.Fatal(err)
+	}
+	defer out.CloseAsync()
+	if _, max := out.(BatchTunable).BatchCountLimit(); max != 0 {
+		t.Errorf("Expected no batch count limit without batching: %v", max)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["mongodb"] = TypeSpec{
+		constructor: NewMongoDB,
+		description: `
+Writes message parts as documents of a MongoDB collection, where the parts of a
+message are written within a single unordered bulk operation. Messages can
+therefore be grouped into larger bulk operations with the ` + "`batching`" + `
+field of the output.
+
+Each part is parsed as JSON, which may include
+[extended JSON](https://docs.mongodb.com/manual/reference/mongodb-extended-json/)
+types such as ` + "`{\"$oid\":\"...\"}`" + `. The document written is either the
+whole part or, when ` + "`document_path`" + ` is set, the object found at that
+dot separated path.
+
+The field ` + "`operation`" + ` can be one of the following:
+
+- ` + "`insert`" + ` inserts each document as a new document.
+- ` + "`update`" + ` sets the fields of the existing document matched by the
+  ` + "`filter_fields`" + ` of the document.
+- ` + "`upsert`" + ` is the same as ` + "`update`" + `, but inserts the document
+  when no match is found.
+
+The write concern ` + "`w`" + ` is either a number of nodes or a mode such as
+` + "`majority`" + `.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewMongoDB creates a new MongoDB output type.
+func NewMongoDB(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewMongoDB(conf.MongoDB, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("mongodb", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
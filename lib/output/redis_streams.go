@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["redis_streams"] = TypeSpec{
+		constructor: NewRedisStreams,
+		description: `
+Adds messages to a Redis (v5.0+) stream (which is created if it doesn't already
+exist) using the XADD command. The contents of each message part are added to
+its entry with the field ` + "`body_key`" + `, and its metadata as the remaining
+fields.
+
+The field ` + "`stream`" + ` supports
+[function interpolations](../config_interpolation.md#functions). When
+` + "`max_length`" + ` is greater than zero the stream is trimmed to
+approximately that number of entries.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewRedisStreams creates a new RedisStreams output type.
+func NewRedisStreams(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewRedisStreams(conf.RedisStreams, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("redis_streams", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
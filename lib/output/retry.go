@@ -0,0 +1,296 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/cenkalti/backoff"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["retry"] = TypeSpec{
+		constructor: NewRetry,
+		description: `
+Attempts to write messages to a child output and if the write fails for any
+reason the message is retried with an exponential backoff, starting at
+` + "`initial_interval_ms`" + ` and capped at ` + "`max_interval_ms`" + `. Each
+interval is randomly varied by a factor of up to ` + "`jitter`" + `, which should
+be between zero and one, in order to avoid many instances retrying in lockstep.
+
+If ` + "`max_retries`" + ` is greater than zero or ` + "`max_elapsed_time_ms`" + `
+is greater than zero then the retries of a message are limited accordingly,
+after which the message is rejected and is therefore resent by inputs that
+support acknowledgements. Otherwise the message is retried until it succeeds or
+the service is shut down.
+
+This output is useful for wrapping outputs that do not retry by themselves, or
+for setting a consistent retry behaviour across outputs.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RetryConfig contains configuration values for the Retry output type.
+type RetryConfig struct {
+	Output           *Config `json:"output" yaml:"output"`
+	MaxRetries       uint64  `json:"max_retries" yaml:"max_retries"`
+	InitialInterval  int     `json:"initial_interval_ms" yaml:"initial_interval_ms"`
+	MaxInterval      int     `json:"max_interval_ms" yaml:"max_interval_ms"`
+	MaxElapsedTimeMS int     `json:"max_elapsed_time_ms" yaml:"max_elapsed_time_ms"`
+	Jitter           float64 `json:"jitter" yaml:"jitter"`
+}
+
+// NewRetryConfig creates a new RetryConfig with default values.
+func NewRetryConfig() RetryConfig {
+	return RetryConfig{
+		Output:           nil,
+		MaxRetries:       0,
+		InitialInterval:  500,
+		MaxInterval:      3000,
+		MaxElapsedTimeMS: 0,
+		Jitter:           0.5,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type dummyRetryConfig struct {
+	Output           interface{} `json:"output" yaml:"output"`
+	MaxRetries       uint64      `json:"max_retries" yaml:"max_retries"`
+	InitialInterval  int         `json:"initial_interval_ms" yaml:"initial_interval_ms"`
+	MaxInterval      int         `json:"max_interval_ms" yaml:"max_interval_ms"`
+	MaxElapsedTimeMS int         `json:"max_elapsed_time_ms" yaml:"max_elapsed_time_ms"`
+	Jitter           float64     `json:"jitter" yaml:"jitter"`
+}
+
+func (r RetryConfig) dummy() dummyRetryConfig {
+	dummy := dummyRetryConfig{
+		Output:           r.Output,
+		MaxRetries:       r.MaxRetries,
+		InitialInterval:  r.InitialInterval,
+		MaxInterval:      r.MaxInterval,
+		MaxElapsedTimeMS: r.MaxElapsedTimeMS,
+		Jitter:           r.Jitter,
+	}
+	if r.Output == nil {
+		dummy.Output = struct{}{}
+	}
+	return dummy
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (r RetryConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.dummy())
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (r RetryConfig) MarshalYAML() (interface{}, error) {
+	return r.dummy(), nil
+}
+
+//------------------------------------------------------------------------------
+
+// Retry is an output type that continuously writes a message to a child output
+// until the send is successful.
+type Retry struct {
+	running int32
+	conf    RetryConfig
+
+	wrapped     Type
+	backoffCtor func() backoff.BackOff
+
+	stats metrics.Type
+	log   log.Modular
+
+	transactionsIn  <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// NewRetry creates a new Retry output type.
+func NewRetry(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	if conf.Retry.Output == nil {
+		return nil, errors.New("cannot create retry output without a child")
+	}
+	if conf.Retry.Jitter < 0 || conf.Retry.Jitter > 1 {
+		return nil, fmt.Errorf("jitter must be between zero and one, got: %v", conf.Retry.Jitter)
+	}
+
+	wrapped, err := New(*conf.Retry.Output, mgr, log, stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output '%v': %v", conf.Retry.Output.Type, err)
+	}
+
+	rConf := conf.Retry
+	return &Retry{
+		running: 1,
+		conf:    rConf,
+
+		log:     log.NewModule(".output.retry"),
+		stats:   stats,
+		wrapped: wrapped,
+		backoffCtor: func() backoff.BackOff {
+			boff := backoff.NewExponentialBackOff()
+			boff.InitialInterval = time.Millisecond * time.Duration(rConf.InitialInterval)
+			boff.MaxInterval = time.Millisecond * time.Duration(rConf.MaxInterval)
+			boff.MaxElapsedTime = time.Millisecond * time.Duration(rConf.MaxElapsedTimeMS)
+			boff.RandomizationFactor = rConf.Jitter
+			if rConf.MaxRetries > 0 {
+				return backoff.WithMaxRetries(boff, rConf.MaxRetries)
+			}
+			return boff
+		},
+		transactionsOut: make(chan types.Transaction),
+
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *Retry) loop() {
+	var (
+		mRunning = r.stats.GetCounter("output.retry.running")
+		mCount   = r.stats.GetCounter("output.retry.count")
+		mSuccess = r.stats.GetCounter("output.retry.send.success")
+		mRetry   = r.stats.GetCounter("output.retry.send.retry")
+		mFailed  = r.stats.GetCounter("output.retry.send.failed")
+	)
+
+	defer func() {
+		close(r.transactionsOut)
+		r.wrapped.CloseAsync()
+		err := r.wrapped.WaitForClose(time.Second)
+		for ; err != nil; err = r.wrapped.WaitForClose(time.Second) {
+		}
+		mRunning.Decr(1)
+		close(r.closedChan)
+	}()
+	mRunning.Incr(1)
+
+	resChan := make(chan types.Response)
+
+	for atomic.LoadInt32(&r.running) == 1 {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-r.transactionsIn:
+			if !open {
+				return
+			}
+			mCount.Incr(1)
+		case <-r.closeChan:
+			return
+		}
+
+		boff := r.backoffCtor()
+
+		var res types.Response
+		for {
+			// Each attempt is given a copy of the message as it could be
+			// modified by the processors of the child output.
+			select {
+			case r.transactionsOut <- types.NewTransaction(tran.Payload.ShallowCopy(), resChan):
+			case <-r.closeChan:
+				return
+			}
+			select {
+			case res = <-resChan:
+			case <-r.closeChan:
+				return
+			}
+			if res.Error() == nil {
+				mSuccess.Incr(1)
+				break
+			}
+
+			nextBackoff := boff.NextBackOff()
+			if nextBackoff == backoff.Stop {
+				mFailed.Incr(1)
+				r.log.Errorf("Failed to send message%v after retries: %v\n", types.CorrelationLogTag(tran.Payload), res.Error())
+				break
+			}
+			mRetry.Incr(1)
+			r.log.Errorf("Failed to send message%v: %v\n", types.CorrelationLogTag(tran.Payload), res.Error())
+			select {
+			case <-time.After(nextBackoff):
+			case <-r.closeChan:
+				return
+			}
+		}
+
+		select {
+		case tran.ResponseChan <- res:
+		case <-r.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a new transactions channel for the output to read.
+func (r *Retry) StartReceiving(ts <-chan types.Transaction) error {
+	if r.transactionsIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := r.wrapped.StartReceiving(r.transactionsOut); err != nil {
+		return err
+	}
+	r.transactionsIn = ts
+	go r.loop()
+	return nil
+}
+
+// CloseAsync shuts down the Retry output and stops processing messages.
+func (r *Retry) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&r.running, 1, 0) {
+		close(r.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Retry output has closed down.
+func (r *Retry) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-r.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func startRetryTest(t *testing.T, pipe string, conf Config) (chan types.Transaction, <-chan types.Transaction, Type) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	mgr, err := manager.New(manager.NewConfig(), types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	childConf := NewConfig()
+	childConf.Type = "inproc"
+	childConf.Inproc = InprocConfig(pipe)
+
+	conf.Type = "retry"
+	conf.Retry.Output = &childConf
+
+	r, err := New(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = r.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	var pipeChan <-chan types.Transaction
+	for i := 0; i < 100 && pipeChan == nil; i++ {
+		if pipeChan, err = mgr.GetPipe(pipe); err != nil {
+			<-time.After(time.Millisecond * 10)
+		}
+	}
+	if pipeChan == nil {
+		t.Fatalf("Pipe '%v' was not registered", pipe)
+	}
+	return tChan, pipeChan, r
+}
+
+func TestRetryNoChild(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "retry"
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing child")
+	}
+}
+
+func TestRetryBadJitter(t *testing.T) {
+	childConf := NewConfig()
+	conf := NewConfig()
+	conf.Type = "retry"
+	conf.Retry.Output = &childConf
+	conf.Retry.Jitter = 2
+	if _, err := New(conf, nil, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad jitter")
+	}
+}
+
+func TestRetryEventualSuccess(t *testing.T) {
+	conf := NewConfig()
+	conf.Retry.InitialInterval = 1
+	conf.Retry.MaxInterval = 5
+
+	tChan, pipeChan, r := startRetryTest(t, "retry_success", conf)
+	defer func() {
+		r.CloseAsync()
+		if err := r.WaitForClose(time.Second * 5); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	for i := 0; i < 3; i++ {
+		var tran types.Transaction
+		select {
+		case tran = <-pipeChan:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong payload: %v != %v", act, exp)
+		}
+		var res types.Response = types.NewSimpleResponse(nil)
+		if i < 2 {
+			res = types.NewSimpleResponse(errors.New("nope"))
+		}
+		select {
+		case tran.ResponseChan <- res:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+func TestRetryMaxRetries(t *testing.T) {
+	conf := NewConfig()
+	conf.Retry.InitialInterval = 1
+	conf.Retry.MaxInterval = 5
+	conf.Retry.MaxRetries = 2
+
+	tChan, pipeChan, r := startRetryTest(t, "retry_max", conf)
+	defer func() {
+		r.CloseAsync()
+		if err := r.WaitForClose(time.Second * 5); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	// The initial attempt plus two retries.
+	for i := 0; i < 3; i++ {
+		var tran types.Transaction
+		select {
+		case tran = <-pipeChan:
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(errors.New("nope")):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	select {
+	case res := <-resChan:
+		if res.Error() == nil {
+			t.Error("Expected error after retries were exhausted")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+func TestRetryCloseWhileBackingOff(t *testing.T) {
+	conf := NewConfig()
+	conf.Retry.InitialInterval = 60000
+	conf.Retry.MaxInterval = 60000
+
+	tChan, pipeChan, r := startRetryTest(t, "retry_close", conf)
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	select {
+	case tran := <-pipeChan:
+		tran.ResponseChan <- types.NewSimpleResponse(errors.New("nope"))
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	r.CloseAsync()
+	if err := r.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
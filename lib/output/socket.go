@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["socket"] = TypeSpec{
+		constructor: NewSocket,
+		description: `
+Dials an address and writes raw data over the socket, where the field
+` + "`network`" + ` can be ` + "`tcp`" + `, ` + "`udp`" + `, ` + "`unix`" + ` or
+` + "`unixgram`" + `.
+
+An address prefixed with ` + "`unix://`" + `, such as
+` + "`unix:///tmp/benthos.sock`" + `, is the path of a unix domain socket, which
+is a stream socket when the network is ` + "`tcp`" + ` and a datagram socket
+when ` + "`udp`" + `.
+
+Each part of a message is written as a separate frame according to the field
+` + "`framing`" + `. When ` + "`lines`" + ` each part is followed by the
+delimiter, which defaults to a line feed (\n) when left empty. When
+` + "`length_prefixed`" + ` each part is prefixed with its length as a four
+byte big endian integer. Over datagram sockets each frame is sent as a separate
+datagram.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSocket creates a new Socket output type.
+func NewSocket(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewSocket(conf.Socket, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("socket", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,380 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/throttle"
+)
+
+//------------------------------------------------------------------------------
+
+var (
+	// ErrSwitchNoCases is returned when creating a Switch type with zero cases.
+	ErrSwitchNoCases = errors.New("attempting to create switch output type with no cases")
+)
+
+func init() {
+	Constructors["switch"] = TypeSpec{
+		constructor: NewSwitch,
+		description: `
+The switch output type allows you to route messages to different outputs based
+on their contents. Each case has a [condition](../conditions/README.md) and an
+output, and cases are tested in order:
+
+` + "``` yaml" + `
+output:
+  type: switch
+  switch:
+    cases:
+    - condition:
+        type: content
+        content:
+          operator: contains
+          arg: foo
+      output:
+        type: foo
+        foo:
+          foo_field_1: value1
+    - condition:
+        type: static
+        static: true
+      output:
+        type: bar
+        bar:
+          bar_field_1: value2
+` + "```" + `
+
+A message is sent to the output of the first case with a condition that passes.
+If that case has ` + "`fallthrough`" + ` set to ` + "`true`" + ` then the
+following cases are also tested, allowing a message to be sent to multiple
+outputs. Messages that match no cases are dropped.
+
+If an output fails to send a message it is retried with an exponential backoff
+starting at ` + "`retry_period_ms`" + ` for the case. When ` + "`max_retries`" + `
+is greater than zero and the retries of a case are exhausted the message is
+rejected, and is therefore resent by inputs that support acknowledgements,
+otherwise the message is retried until completion or service shut down.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SwitchConfigCase contains configuration fields per output of a switch type.
+type SwitchConfigCase struct {
+	Condition     condition.Config `json:"condition" yaml:"condition"`
+	Fallthrough   bool             `json:"fallthrough" yaml:"fallthrough"`
+	MaxRetries    int              `json:"max_retries" yaml:"max_retries"`
+	RetryPeriodMS int              `json:"retry_period_ms" yaml:"retry_period_ms"`
+	Output        Config           `json:"output" yaml:"output"`
+}
+
+// NewSwitchConfigCase creates a new switch output config with default values.
+func NewSwitchConfigCase() SwitchConfigCase {
+	cond := condition.NewConfig()
+	cond.Type = "static"
+	cond.Static = true
+
+	return SwitchConfigCase{
+		Condition:     cond,
+		Fallthrough:   false,
+		MaxRetries:    0,
+		RetryPeriodMS: 1000,
+		Output:        NewConfig(),
+	}
+}
+
+// UnmarshalJSON ensures that when parsing configs that are in a slice the
+// default values are still applied.
+func (s *SwitchConfigCase) UnmarshalJSON(bytes []byte) error {
+	type confAlias SwitchConfigCase
+	aliased := confAlias(NewSwitchConfigCase())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*s = SwitchConfigCase(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a slice the
+// default values are still applied.
+func (s *SwitchConfigCase) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias SwitchConfigCase
+	aliased := confAlias(NewSwitchConfigCase())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+
+	*s = SwitchConfigCase(aliased)
+	return nil
+}
+
+// SwitchConfig is configuration for the Switch output type.
+type SwitchConfig struct {
+	Cases []SwitchConfigCase `json:"cases" yaml:"cases"`
+}
+
+// NewSwitchConfig creates a new SwitchConfig with default values.
+func NewSwitchConfig() SwitchConfig {
+	return SwitchConfig{
+		Cases: []SwitchConfigCase{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// switchCase is a runtime representation of a switch case, including the
+// output and the channels used for sending it transactions.
+type switchCase struct {
+	cond       condition.Type
+	continues  bool
+	maxRetries int
+	throt      *throttle.Type
+
+	output  Type
+	tsChan  chan types.Transaction
+	resChan chan types.Response
+}
+
+// Switch is a broker that implements types.Consumer and routes each message to
+// the outputs of cases with conditions that pass.
+type Switch struct {
+	running int32
+
+	logger log.Modular
+	stats  metrics.Type
+
+	transactions <-chan types.Transaction
+
+	cases []*switchCase
+
+	closedChan chan struct{}
+	closeChan  chan struct{}
+}
+
+// NewSwitch creates a new Switch type by providing outputs. Messages will be
+// sent to a subset of outputs according to condition and fallthrough settings.
+func NewSwitch(
+	conf Config,
+	mgr types.Manager,
+	logger log.Modular,
+	stats metrics.Type,
+) (Type, error) {
+	lCases := len(conf.Switch.Cases)
+	if lCases == 0 {
+		return nil, ErrSwitchNoCases
+	}
+
+	o := &Switch{
+		running:      1,
+		stats:        stats,
+		logger:       logger.NewModule(".broker.switch"),
+		transactions: nil,
+		cases:        make([]*switchCase, lCases),
+		closedChan:   make(chan struct{}),
+		closeChan:    make(chan struct{}),
+	}
+
+	var err error
+	for i, cConf := range conf.Switch.Cases {
+		c := &switchCase{
+			continues:  cConf.Fallthrough,
+			maxRetries: cConf.MaxRetries,
+			tsChan:     make(chan types.Transaction),
+			resChan:    make(chan types.Response),
+			throt: throttle.New(
+				throttle.OptCloseChan(o.closeChan),
+				throttle.OptThrottlePeriod(time.Millisecond*time.Duration(cConf.RetryPeriodMS)),
+			),
+		}
+		if c.cond, err = condition.New(cConf.Condition, mgr, logger, stats); err != nil {
+			o.closeCases(i)
+			return nil, fmt.Errorf("failed to create case '%v' condition '%v': %v", i, cConf.Condition.Type, err)
+		}
+		if c.output, err = New(cConf.Output, mgr, logger, stats); err != nil {
+			o.closeCases(i)
+			return nil, fmt.Errorf("failed to create case '%v' output '%v': %v", i, cConf.Output.Type, err)
+		}
+		if err = c.output.StartReceiving(c.tsChan); err != nil {
+			c.output.CloseAsync()
+			o.closeCases(i)
+			return nil, err
+		}
+		o.cases[i] = c
+	}
+	return o, nil
+}
+
+// closeCases shuts down the outputs of the first n cases, used when
+// construction fails.
+func (o *Switch) closeCases(n int) {
+	for _, c := range o.cases[:n] {
+		close(c.tsChan)
+		c.output.CloseAsync()
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// StartReceiving assigns a new transactions channel for the broker to read.
+func (o *Switch) StartReceiving(transactions <-chan types.Transaction) error {
+	if o.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	o.transactions = transactions
+
+	go o.loop()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// loop is an internal loop that brokers incoming messages to many outputs.
+func (o *Switch) loop() {
+	defer func() {
+		for _, c := range o.cases {
+			close(c.tsChan)
+		}
+		close(o.closedChan)
+	}()
+
+	var (
+		mMsgsRcvd   = o.stats.GetCounter("broker.switch.messages.received")
+		mMsgsDrop   = o.stats.GetCounter("broker.switch.messages.dropped")
+		mOutputErr  = o.stats.GetCounter("broker.switch.output.error")
+		mOutputRej  = o.stats.GetCounter("broker.switch.output.rejected")
+		mMsgsSnt    = o.stats.GetCounter("broker.switch.messages.sent")
+		mMsgsFailed = o.stats.GetCounter("broker.switch.messages.failed")
+	)
+
+	for atomic.LoadInt32(&o.running) == 1 {
+		var ts types.Transaction
+		var open bool
+
+		select {
+		case ts, open = <-o.transactions:
+			if !open {
+				return
+			}
+		case <-o.closeChan:
+			return
+		}
+		mMsgsRcvd.Incr(1)
+
+		var targets []*switchCase
+		for _, c := range o.cases {
+			if !c.cond.Check(ts.Payload) {
+				continue
+			}
+			targets = append(targets, c)
+			if !c.continues {
+				break
+			}
+		}
+		if len(targets) == 0 {
+			mMsgsDrop.Incr(1)
+		}
+
+		var resErr error
+		retries := make([]int, len(targets))
+		for len(targets) > 0 {
+			for _, c := range targets {
+				// Perform a copy here as it could be dangerous to release the
+				// same message to parallel processor pipelines.
+				msgCopy := ts.Payload.ShallowCopy()
+				select {
+				case c.tsChan <- types.NewTransaction(msgCopy, c.resChan):
+				case <-o.closeChan:
+					return
+				}
+			}
+			newTargets := []*switchCase{}
+			newRetries := []int{}
+			for i, c := range targets {
+				select {
+				case res := <-c.resChan:
+					if res.Error() == nil {
+						c.throt.Reset()
+						mMsgsSnt.Incr(1)
+						continue
+					}
+					mOutputErr.Incr(1)
+					if c.maxRetries > 0 && retries[i] >= c.maxRetries {
+						o.logger.Errorf("Failed to dispatch switch message%v after %v retries: %v\n", types.CorrelationLogTag(ts.Payload), retries[i], res.Error())
+						mOutputRej.Incr(1)
+						resErr = res.Error()
+						c.throt.Reset()
+						continue
+					}
+					o.logger.Errorf("Failed to dispatch switch message%v: %v\n", types.CorrelationLogTag(ts.Payload), res.Error())
+					if !c.throt.Retry() {
+						return
+					}
+					newTargets = append(newTargets, c)
+					newRetries = append(newRetries, retries[i]+1)
+				case <-o.closeChan:
+					return
+				}
+			}
+			targets, retries = newTargets, newRetries
+		}
+
+		if resErr != nil {
+			mMsgsFailed.Incr(1)
+		}
+		select {
+		case ts.ResponseChan <- types.NewSimpleResponse(resErr):
+		case <-o.closeChan:
+			return
+		}
+	}
+}
+
+// CloseAsync shuts down the Switch broker and stops processing requests.
+func (o *Switch) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&o.running, 1, 0) {
+		close(o.closeChan)
+	}
+}
+
+// WaitForClose blocks until the Switch broker has closed down.
+func (o *Switch) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
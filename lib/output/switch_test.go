@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/manager"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newSwitchTestCase(pipe, contains string) SwitchConfigCase {
+	c := NewSwitchConfigCase()
+	if len(contains) > 0 {
+		c.Condition = condition.NewConfig()
+		c.Condition.Type = "content"
+		c.Condition.Content.Operator = "contains"
+		c.Condition.Content.Arg = contains
+	}
+	c.RetryPeriodMS = 1
+	c.Output.Type = "inproc"
+	c.Output.Inproc = InprocConfig(pipe)
+	return c
+}
+
+func startSwitchTest(t *testing.T, cases ...SwitchConfigCase) (chan types.Transaction, map[string]<-chan types.Transaction, Type) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	mgr, err := manager.New(manager.NewConfig(), types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Type = "switch"
+	conf.Switch.Cases = cases
+
+	s, err := New(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = s.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	pipes := map[string]<-chan types.Transaction{}
+	for _, c := range cases {
+		pipeID := string(c.Output.Inproc)
+		var pipe <-chan types.Transaction
+		for i := 0; i < 100 && pipe == nil; i++ {
+			if pipe, err = mgr.GetPipe(pipeID); err != nil {
+				<-time.After(time.Millisecond * 10)
+			}
+		}
+		if pipe == nil {
+			t.Fatalf("Pipe %v was not registered", pipeID)
+		}
+		pipes[pipeID] = pipe
+	}
+	return tChan, pipes, s
+}
+
+func sendSwitchTest(t *testing.T, tChan chan types.Transaction, content string) <-chan types.Response {
+	resChan := make(chan types.Response, 1)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	return resChan
+}
+
+func expectSwitchTest(t *testing.T, pipe <-chan types.Transaction, content string, resErr error) {
+	select {
+	case tran := <-pipe:
+		if act := string(tran.Payload.Get(0)); act != content {
+			t.Errorf("Wrong message: %v != %v", act, content)
+		}
+		select {
+		case tran.ResponseChan <- types.NewSimpleResponse(resErr):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+func expectSwitchRes(t *testing.T, resChan <-chan types.Response, exp error) {
+	select {
+	case res := <-resChan:
+		if act := res.Error(); act != exp {
+			t.Errorf("Wrong response: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func TestSwitchNoCases(t *testing.T) {
+	conf := NewConfig()
+	conf.Type = "switch"
+
+	if _, err := NewSwitch(conf, types.DudMgr{}, log.NewLogger(os.Stdout, logConfig), metrics.DudType{}); err != ErrSwitchNoCases {
+		t.Errorf("Wrong error: %v != %v", err, ErrSwitchNoCases)
+	}
+}
+
+func TestSwitchRouting(t *testing.T) {
+	tChan, pipes, s := startSwitchTest(
+		t,
+		newSwitchTestCase("foo", "foo"),
+		newSwitchTestCase("bar", ""),
+	)
+
+	resChan := sendSwitchTest(t, tChan, "hello foo")
+	expectSwitchTest(t, pipes["foo"], "hello foo", nil)
+	expectSwitchRes(t, resChan, nil)
+
+	resChan = sendSwitchTest(t, tChan, "hello world")
+	expectSwitchTest(t, pipes["bar"], "hello world", nil)
+	expectSwitchRes(t, resChan, nil)
+
+	select {
+	case <-pipes["foo"]:
+		t.Error("Unexpected message to foo")
+	case <-pipes["bar"]:
+		t.Error("Unexpected message to bar")
+	default:
+	}
+
+	s.CloseAsync()
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSwitchFallthrough(t *testing.T) {
+	fooCase := newSwitchTestCase("foo", "foo")
+	fooCase.Fallthrough = true
+
+	tChan, pipes, s := startSwitchTest(
+		t,
+		fooCase,
+		newSwitchTestCase("bar", "bar"),
+		newSwitchTestCase("baz", ""),
+	)
+
+	resChan := sendSwitchTest(t, tChan, "foo bar")
+	expectSwitchTest(t, pipes["foo"], "foo bar", nil)
+	expectSwitchTest(t, pipes["bar"], "foo bar", nil)
+	expectSwitchRes(t, resChan, nil)
+
+	resChan = sendSwitchTest(t, tChan, "foo baz")
+	expectSwitchTest(t, pipes["foo"], "foo baz", nil)
+	expectSwitchTest(t, pipes["baz"], "foo baz", nil)
+	expectSwitchRes(t, resChan, nil)
+
+	s.CloseAsync()
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSwitchNoMatch(t *testing.T) {
+	tChan, _, s := startSwitchTest(t, newSwitchTestCase("foo", "foo"))
+
+	resChan := sendSwitchTest(t, tChan, "hello world")
+	expectSwitchRes(t, resChan, nil)
+
+	s.CloseAsync()
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSwitchRetries(t *testing.T) {
+	fooCase := newSwitchTestCase("foo", "")
+	fooCase.MaxRetries = 1
+
+	tChan, pipes, s := startSwitchTest(t, fooCase)
+
+	errTest := errors.New("test err")
+
+	resChan := sendSwitchTest(t, tChan, "hello world")
+	expectSwitchTest(t, pipes["foo"], "hello world", errTest)
+	expectSwitchTest(t, pipes["foo"], "hello world", nil)
+	expectSwitchRes(t, resChan, nil)
+
+	resChan = sendSwitchTest(t, tChan, "hello world")
+	expectSwitchTest(t, pipes["foo"], "hello world", errTest)
+	expectSwitchTest(t, pipes["foo"], "hello world", errTest)
+	expectSwitchRes(t, resChan, errTest)
+
+	s.CloseAsync()
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
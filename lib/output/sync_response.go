@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["sync_response"] = TypeSpec{
+		constructor: NewSyncResponse,
+		description: `
+Returns messages as a synchronous response to the input that they originated
+from. This is only supported by inputs that are configured to wait for a
+response, such as the ` + "`http_server`" + ` input with the field
+` + "`sync_response`" + ` set to true, and messages from any other input are
+dropped.
+
+The origin of each message part is tracked with the metadata field
+` + "`benthos_roundtrip_id`" + `, and therefore processors that remove metadata
+prevent messages from being returned.
+
+In order to return responses whilst also sending messages elsewhere use this
+output within a ` + "`fan_out`" + ` broker.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewSyncResponse creates a new SyncResponse output type.
+func NewSyncResponse(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	return NewWriter("sync_response", writer.NewSyncResponse(log, stats), log, stats)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["webhdfs"] = TypeSpec{
+		constructor: NewWebHDFS,
+		description: `
+Appends messages to files in HDFS using the
+[WebHDFS REST API](https://hadoop.apache.org/docs/stable/hadoop-project-dist/hadoop-hdfs/WebHDFS.html),
+where each message part is followed by the ` + "`delimiter`" + `. Files that do
+not exist are created.
+
+Requests are made as the HDFS user ` + "`user`" + `, and when ` + "`proxy_user`" + `
+is set that user is impersonated, which requires the user to be configured as a
+proxy user in HDFS.
+
+The field ` + "`path`" + ` supports
+[function interpolations](../config_interpolation.md#functions). When neither
+` + "`roll_size_bytes`" + ` nor ` + "`roll_period_ms`" + ` are set the path is
+resolved for each message part, allowing parts to be written to files based on
+their contents or metadata.
+
+When either of the rolling fields are greater than zero the path is instead
+resolved only when a new file is started, and message parts are appended to
+that file until it reaches the size of ` + "`roll_size_bytes`" + ` or has been
+open for ` + "`roll_period_ms`" + `. The path should therefore contain an
+interpolation that results in a new file name each time it is resolved, such as
+` + "`${!timestamp_unix_nano}`" + ` or ` + "`${!count:files}`" + `.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewWebHDFS creates a new WebHDFS output type.
+func NewWebHDFS(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	w, err := writer.NewWebHDFS(conf.WebHDFS, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter("webhdfs", w, log, stats)
+}
+
+//------------------------------------------------------------------------------
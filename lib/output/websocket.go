@@ -33,7 +33,22 @@ func init() {
 	Constructors["websocket"] = TypeSpec{
 		constructor: NewWebsocket,
 		description: `
-Sends messages to an HTTP server via a websocket connection.`,
+Sends messages to an HTTP server via a websocket connection.
+
+### Broadcast Server
+
+When ` + "`server.enabled`" + ` is true the output instead runs a websocket
+server at ` + "`server.address`" + ` and broadcasts each message to all clients
+connected to ` + "`server.path`" + `, which allows dashboards and other
+consumers to subscribe to a live stream. In this mode the ` + "`url`" + ` and
+auth fields are ignored, and messages are dropped when no clients are
+connected.
+
+Each client has a queue of up to ` + "`server.client_buffer_size`" + ` message
+parts. When the queue of a client is full it is disconnected if
+` + "`server.evict_slow_clients`" + ` is true, otherwise the output blocks until
+the client catches up. Clients that fail to accept a frame within
+` + "`server.write_timeout_ms`" + ` are also disconnected.`,
 	}
 }
 
@@ -41,6 +56,13 @@ Sends messages to an HTTP server via a websocket connection.`,
 
 // NewWebsocket creates a new Websocket output type.
 func NewWebsocket(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	if conf.Websocket.Server.Enabled {
+		w, err := writer.NewWebsocketServer(conf.Websocket.Server, log, stats)
+		if err != nil {
+			return nil, err
+		}
+		return NewWriter("websocket", w, log, stats)
+	}
 	w, err := writer.NewWebsocket(conf.Websocket, log, stats)
 	if err != nil {
 		return nil, err
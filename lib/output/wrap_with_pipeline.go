@@ -67,6 +67,25 @@ func WrapWithPipelines(out Type, pipeConstructors ...pipeline.ConstructorFunc) (
 
 //------------------------------------------------------------------------------
 
+// BatchCountLimit returns the batch count limit of the wrapped output, or zero
+// if the wrapped output does not batch.
+func (i *WithPipeline) BatchCountLimit() (limit, max int) {
+	if t, ok := i.out.(BatchTunable); ok {
+		return t.BatchCountLimit()
+	}
+	return 0, 0
+}
+
+// SetBatchCountLimit changes the batch count limit of the wrapped output if it
+// batches.
+func (i *WithPipeline) SetBatchCountLimit(limit int) {
+	if t, ok := i.out.(BatchTunable); ok {
+		t.SetBatchCountLimit(limit)
+	}
+}
+
+//------------------------------------------------------------------------------
+
 // StartReceiving starts the type listening to a message channel from a
 // producer.
 func (i *WithPipeline) StartReceiving(tsChan <-chan types.Transaction) error {
@@ -173,7 +173,7 @@ func (w *Writer) loop() {
 		}
 
 		if err != nil {
-			w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+			w.log.Errorf("Failed to send message%v to %v: %v\n", types.CorrelationLogTag(ts.Payload), w.typeStr, err)
 			mError.Incr(1)
 			mErrorF.Incr(1)
 		} else {
@@ -22,6 +22,7 @@ package writer
 
 import (
 	"bytes"
+	"fmt"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -47,19 +48,25 @@ type AmazonAWSCredentialsConfig struct {
 
 // AmazonS3Config is configuration values for the input type.
 type AmazonS3Config struct {
-	Region      string                     `json:"region" yaml:"region"`
-	Bucket      string                     `json:"bucket" yaml:"bucket"`
-	Path        string                     `json:"path" yaml:"path"`
-	Credentials AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
-	TimeoutS    int64                      `json:"timeout_s" yaml:"timeout_s"`
+	Region               string                     `json:"region" yaml:"region"`
+	Bucket               string                     `json:"bucket" yaml:"bucket"`
+	Path                 string                     `json:"path" yaml:"path"`
+	ContentType          string                     `json:"content_type" yaml:"content_type"`
+	ServerSideEncryption string                     `json:"server_side_encryption" yaml:"server_side_encryption"`
+	KMSKeyID             string                     `json:"kms_key_id" yaml:"kms_key_id"`
+	Credentials          AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
+	TimeoutS             int64                      `json:"timeout_s" yaml:"timeout_s"`
 }
 
 // NewAmazonS3Config creates a new Config with default values.
 func NewAmazonS3Config() AmazonS3Config {
 	return AmazonS3Config{
-		Region: "eu-west-1",
-		Bucket: "",
-		Path:   "${!count:files}-${!timestamp_unix_nano}.txt",
+		Region:               "eu-west-1",
+		Bucket:               "",
+		Path:                 "${!count:files}-${!timestamp_unix_nano}.txt",
+		ContentType:          "application/octet-stream",
+		ServerSideEncryption: "",
+		KMSKeyID:             "",
 		Credentials: AmazonAWSCredentialsConfig{
 			ID:     "",
 			Secret: "",
@@ -76,8 +83,9 @@ func NewAmazonS3Config() AmazonS3Config {
 type AmazonS3 struct {
 	conf AmazonS3Config
 
-	pathBytes       []byte
-	interpolatePath bool
+	pathBytes        []byte
+	interpolatePath  bool
+	contentTypeBytes []byte
 
 	session  *session.Session
 	uploader *s3manager.Uploader
@@ -91,16 +99,25 @@ func NewAmazonS3(
 	conf AmazonS3Config,
 	log log.Modular,
 	stats metrics.Type,
-) *AmazonS3 {
+) (*AmazonS3, error) {
+	switch conf.ServerSideEncryption {
+	case "", "AES256", "aws:kms":
+	default:
+		return nil, fmt.Errorf("server side encryption type not recognised: %v", conf.ServerSideEncryption)
+	}
+	if len(conf.KMSKeyID) > 0 && conf.ServerSideEncryption != "aws:kms" {
+		return nil, fmt.Errorf("kms_key_id requires server_side_encryption to be aws:kms")
+	}
 	pathBytes := []byte(conf.Path)
 	interpolatePath := text.ContainsFunctionVariables(pathBytes)
 	return &AmazonS3{
-		conf:            conf,
-		pathBytes:       pathBytes,
-		interpolatePath: interpolatePath,
-		log:             log.NewModule(".output.amazon_s3"),
-		stats:           stats,
-	}
+		conf:             conf,
+		pathBytes:        pathBytes,
+		interpolatePath:  interpolatePath,
+		contentTypeBytes: []byte(conf.ContentType),
+		log:              log.NewModule(".output.amazon_s3"),
+		stats:            stats,
+	}, nil
 }
 
 // Connect attempts to establish a connection to the target S3 bucket and any
@@ -146,17 +163,8 @@ func (a *AmazonS3) Write(msg types.Message) error {
 		return types.ErrNotConnected
 	}
 
-	for _, part := range msg.GetAll() {
-		path := a.conf.Path
-		if a.interpolatePath {
-			path = string(text.ReplaceFunctionVariables(a.pathBytes))
-		}
-
-		if _, err := a.uploader.Upload(&s3manager.UploadInput{
-			Body:   bytes.NewReader(part),
-			Bucket: aws.String(a.conf.Bucket),
-			Key:    aws.String(path),
-		}); err != nil {
+	for i := 0; i < msg.Len(); i++ {
+		if _, err := a.uploader.Upload(a.uploadInput(msg, i)); err != nil {
 			return err
 		}
 	}
@@ -164,6 +172,31 @@ func (a *AmazonS3) Write(msg types.Message) error {
 	return nil
 }
 
+// uploadInput creates the S3 upload input of a message part, where the path
+// and content type are interpolated for the part.
+func (a *AmazonS3) uploadInput(msg types.Message, index int) *s3manager.UploadInput {
+	part := types.ExtractPart(msg, index)
+
+	path := a.conf.Path
+	if a.interpolatePath {
+		path = string(text.ReplaceFunctionVariablesFor(part, a.pathBytes))
+	}
+
+	input := &s3manager.UploadInput{
+		Body:        bytes.NewReader(msg.Get(index)),
+		Bucket:      aws.String(a.conf.Bucket),
+		Key:         aws.String(path),
+		ContentType: aws.String(string(text.ReplaceFunctionVariablesFor(part, a.contentTypeBytes))),
+	}
+	if len(a.conf.ServerSideEncryption) > 0 {
+		input.ServerSideEncryption = aws.String(a.conf.ServerSideEncryption)
+	}
+	if len(a.conf.KMSKeyID) > 0 {
+		input.SSEKMSKeyId = aws.String(a.conf.KMSKeyID)
+	}
+	return input
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *AmazonS3) CloseAsync() {
 }
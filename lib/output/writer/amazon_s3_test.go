@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestAmazonS3UploadInput(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewAmazonS3Config()
+	conf.Bucket = "foo"
+	conf.Path = "${!metadata:dir}/${!count:s3_upload_input}.json"
+	conf.ContentType = "application/${!metadata:type}"
+	conf.ServerSideEncryption = "aws:kms"
+	conf.KMSKeyID = "bar"
+
+	w, err := NewAmazonS3(conf, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{[]byte("first"), []byte("second")})
+	msg.GetMetadata(1).Set("dir", "logs").Set("type", "json")
+
+	input := w.uploadInput(msg, 1)
+	if exp, act := "foo", *input.Bucket; exp != act {
+		t.Errorf("Wrong bucket: %v != %v", act, exp)
+	}
+	if exp, act := "logs/1.json", *input.Key; exp != act {
+		t.Errorf("Wrong key: %v != %v", act, exp)
+	}
+	if exp, act := "application/json", *input.ContentType; exp != act {
+		t.Errorf("Wrong content type: %v != %v", act, exp)
+	}
+	if exp, act := "aws:kms", *input.ServerSideEncryption; exp != act {
+		t.Errorf("Wrong encryption: %v != %v", act, exp)
+	}
+	if exp, act := "bar", *input.SSEKMSKeyId; exp != act {
+		t.Errorf("Wrong kms key: %v != %v", act, exp)
+	}
+}
+
+func TestAmazonS3BadEncryption(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewAmazonS3Config()
+	conf.ServerSideEncryption = "nope"
+	if _, err := NewAmazonS3(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad encryption type")
+	}
+
+	conf = NewAmazonS3Config()
+	conf.KMSKeyID = "foo"
+	if _, err := NewAmazonS3(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from kms key without kms encryption")
+	}
+}
+
+//------------------------------------------------------------------------------
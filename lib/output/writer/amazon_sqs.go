@@ -26,6 +26,7 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
@@ -37,9 +38,12 @@ import (
 
 // AmazonSQSConfig is configuration values for the input type.
 type AmazonSQSConfig struct {
-	Region      string                     `json:"region" yaml:"region"`
-	URL         string                     `json:"url" yaml:"url"`
-	Credentials AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
+	Region            string                     `json:"region" yaml:"region"`
+	URL               string                     `json:"url" yaml:"url"`
+	Credentials       AmazonAWSCredentialsConfig `json:"credentials" yaml:"credentials"`
+	MessageAttributes map[string]string          `json:"message_attributes" yaml:"message_attributes"`
+	MessageGroupID    string                     `json:"message_group_id" yaml:"message_group_id"`
+	MessageDedupeID   string                     `json:"message_deduplication_id" yaml:"message_deduplication_id"`
 }
 
 // NewAmazonSQSConfig creates a new Config with default values.
@@ -52,6 +56,9 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 			Secret: "",
 			Token:  "",
 		},
+		MessageAttributes: map[string]string{},
+		MessageGroupID:    "",
+		MessageDedupeID:   "",
 	}
 }
 
@@ -62,6 +69,10 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 type AmazonSQS struct {
 	conf AmazonSQSConfig
 
+	attributes map[string][]byte
+	groupID    []byte
+	dedupeID   []byte
+
 	session *session.Session
 	sqs     *sqs.SQS
 
@@ -75,11 +86,18 @@ func NewAmazonSQS(
 	log log.Modular,
 	stats metrics.Type,
 ) *AmazonSQS {
-	return &AmazonSQS{
-		conf:  conf,
-		log:   log.NewModule(".output.amazon_sqs"),
-		stats: stats,
+	a := &AmazonSQS{
+		conf:       conf,
+		attributes: make(map[string][]byte, len(conf.MessageAttributes)),
+		groupID:    []byte(conf.MessageGroupID),
+		dedupeID:   []byte(conf.MessageDedupeID),
+		log:        log.NewModule(".output.amazon_sqs"),
+		stats:      stats,
+	}
+	for k, v := range conf.MessageAttributes {
+		a.attributes[k] = []byte(v)
 	}
+	return a
 }
 
 // Connect attempts to establish a connection to the target S3 bucket and any
@@ -119,7 +137,7 @@ func (a *AmazonSQS) Connect() error {
 	return nil
 }
 
-// Write attempts to write message contents to a target S3 bucket as files.
+// Write attempts to write message contents to a target SQS queue.
 func (a *AmazonSQS) Write(msg types.Message) error {
 	if a.session == nil {
 		return types.ErrNotConnected
@@ -145,17 +163,40 @@ func (a *AmazonSQS) Write(msg types.Message) error {
 		}
 	*/
 
-	for _, part := range msg.GetAll() {
-		if _, err := a.sqs.SendMessage(&sqs.SendMessageInput{
-			QueueUrl:    aws.String(a.conf.URL),
-			MessageBody: aws.String(string(part)),
-		}); err != nil {
+	for i := 0; i < msg.Len(); i++ {
+		if _, err := a.sqs.SendMessage(a.sendInput(msg, i)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// sendInput creates the SQS send input of a message part, where attributes and
+// FIFO queue IDs are interpolated for the part.
+func (a *AmazonSQS) sendInput(msg types.Message, index int) *sqs.SendMessageInput {
+	part := types.ExtractPart(msg, index)
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(a.conf.URL),
+		MessageBody: aws.String(string(msg.Get(index))),
+	}
+	if len(a.attributes) > 0 {
+		input.MessageAttributes = make(map[string]*sqs.MessageAttributeValue, len(a.attributes))
+		for k, v := range a.attributes {
+			input.MessageAttributes[k] = &sqs.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(string(text.ReplaceFunctionVariablesFor(part, v))),
+			}
+		}
+	}
+	if len(a.groupID) > 0 {
+		input.MessageGroupId = aws.String(string(text.ReplaceFunctionVariablesFor(part, a.groupID)))
+	}
+	if len(a.dedupeID) > 0 {
+		input.MessageDeduplicationId = aws.String(string(text.ReplaceFunctionVariablesFor(part, a.dedupeID)))
+	}
+	return input
+}
+
 // CloseAsync begins cleaning up resources used by this reader asynchronously.
 func (a *AmazonSQS) CloseAsync() {
 }
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestAmazonSQSSendInput(t *testing.T) {
+	conf := NewAmazonSQSConfig()
+	conf.URL = "foo"
+	conf.MessageAttributes = map[string]string{
+		"static":  "bar",
+		"dynamic": "${!metadata:key}",
+	}
+	conf.MessageGroupID = "${!metadata:group}"
+	conf.MessageDedupeID = "${!metadata:key}-dedupe"
+
+	w := NewAmazonSQS(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+
+	msg := types.NewMessage([][]byte{[]byte("first"), []byte("second")})
+	msg.GetMetadata(1).Set("key", "baz").Set("group", "qux")
+
+	input := w.sendInput(msg, 1)
+	if exp, act := "foo", *input.QueueUrl; exp != act {
+		t.Errorf("Wrong queue url: %v != %v", act, exp)
+	}
+	if exp, act := "second", *input.MessageBody; exp != act {
+		t.Errorf("Wrong body: %v != %v", act, exp)
+	}
+	if exp, act := "qux", *input.MessageGroupId; exp != act {
+		t.Errorf("Wrong group ID: %v != %v", act, exp)
+	}
+	if exp, act := "baz-dedupe", *input.MessageDeduplicationId; exp != act {
+		t.Errorf("Wrong deduplication ID: %v != %v", act, exp)
+	}
+	for k, exp := range map[string]string{"static": "bar", "dynamic": "baz"} {
+		attr, exists := input.MessageAttributes[k]
+		if !exists {
+			t.Errorf("Missing attribute: %v", k)
+			continue
+		}
+		if act := *attr.StringValue; exp != act {
+			t.Errorf("Wrong attribute %v value: %v != %v", k, act, exp)
+		}
+	}
+
+	conf = NewAmazonSQSConfig()
+	w = NewAmazonSQS(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	input = w.sendInput(msg, 0)
+	if input.MessageAttributes != nil || input.MessageGroupId != nil || input.MessageDeduplicationId != nil {
+		t.Errorf("Unexpected fields set: %v", input)
+	}
+}
+
+//------------------------------------------------------------------------------
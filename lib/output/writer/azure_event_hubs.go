@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	eventhub "github.com/Azure/azure-event-hubs-go/v3"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubsConfig contains configuration fields for the output
+// AzureEventHubs type.
+type AzureEventHubsConfig struct {
+	ConnectionString string `json:"connection_string" yaml:"connection_string"`
+	PartitionKey     string `json:"partition_key" yaml:"partition_key"`
+	TimeoutMS        int    `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewAzureEventHubsConfig creates a new Config with default values.
+func NewAzureEventHubsConfig() AzureEventHubsConfig {
+	return AzureEventHubsConfig{
+		ConnectionString: "",
+		PartitionKey:     "",
+		TimeoutMS:        5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AzureEventHubs is a benthos writer.Type implementation that writes events to
+// an Azure Event Hub.
+type AzureEventHubs struct {
+	conf AzureEventHubsConfig
+
+	partitionKeyBytes []byte
+	timeout           time.Duration
+
+	hub *eventhub.Hub
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureEventHubs creates a new Azure Event Hubs writer.Type.
+func NewAzureEventHubs(
+	conf AzureEventHubsConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AzureEventHubs, error) {
+	if len(conf.ConnectionString) == 0 {
+		return nil, errors.New("a connection_string must be specified")
+	}
+	return &AzureEventHubs{
+		conf:              conf,
+		partitionKeyBytes: []byte(conf.PartitionKey),
+		timeout:           time.Duration(conf.TimeoutMS) * time.Millisecond,
+		log:               log.NewModule(".output.azure_event_hubs"),
+		stats:             stats,
+	}, nil
+}
+
+// Connect attempts to establish a connection to the target event hub.
+func (a *AzureEventHubs) Connect() error {
+	if a.hub != nil {
+		return nil
+	}
+
+	hub, err := eventhub.NewHubFromConnectionString(a.conf.ConnectionString)
+	if err != nil {
+		return err
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), a.timeout)
+	defer done()
+	if _, err = hub.GetRuntimeInformation(ctx); err != nil {
+		hub.Close(context.Background())
+		return err
+	}
+
+	a.hub = hub
+	a.log.Infoln("Sending Azure Event Hubs events")
+	return nil
+}
+
+// event creates the event of a message part, where the metadata of the part is
+// mapped to properties.
+func (a *AzureEventHubs) event(msg types.Message, index int) *eventhub.Event {
+	event := eventhub.NewEvent(msg.Get(index))
+	msg.GetMetadata(index).Iter(func(k, v string) error {
+		event.Set(k, v)
+		return nil
+	})
+	if len(a.partitionKeyBytes) > 0 {
+		key := string(text.ReplaceFunctionVariablesFor(
+			types.ExtractPart(msg, index), a.partitionKeyBytes,
+		))
+		event.PartitionKey = &key
+	}
+	return event
+}
+
+// Write attempts to write message contents to the target event hub.
+func (a *AzureEventHubs) Write(msg types.Message) error {
+	if a.hub == nil {
+		return types.ErrNotConnected
+	}
+
+	for i := 0; i < msg.Len(); i++ {
+		ctx, done := context.WithTimeout(context.Background(), a.timeout)
+		err := a.hub.Send(ctx, a.event(msg, i))
+		done()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (a *AzureEventHubs) CloseAsync() {
+	if a.hub != nil {
+		a.hub.Close(context.Background())
+		a.hub = nil
+	}
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (a *AzureEventHubs) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	servicebus "github.com/Azure/azure-service-bus-go"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBusConfig contains configuration fields for the output
+// AzureServiceBus type.
+type AzureServiceBusConfig struct {
+	ConnectionString string `json:"connection_string" yaml:"connection_string"`
+	Queue            string `json:"queue" yaml:"queue"`
+	Topic            string `json:"topic" yaml:"topic"`
+	SessionID        string `json:"session_id" yaml:"session_id"`
+	TimeoutMS        int    `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewAzureServiceBusConfig creates a new Config with default values.
+func NewAzureServiceBusConfig() AzureServiceBusConfig {
+	return AzureServiceBusConfig{
+		ConnectionString: "",
+		Queue:            "",
+		Topic:            "",
+		SessionID:        "",
+		TimeoutMS:        5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AzureServiceBus is a benthos writer.Type implementation that writes messages
+// to an Azure Service Bus queue or topic.
+type AzureServiceBus struct {
+	conf AzureServiceBusConfig
+
+	entityPath     string
+	sessionIDBytes []byte
+	timeout        time.Duration
+
+	sender *servicebus.Sender
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewAzureServiceBus creates a new Azure Service Bus writer.Type.
+func NewAzureServiceBus(
+	conf AzureServiceBusConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*AzureServiceBus, error) {
+	if len(conf.ConnectionString) == 0 {
+		return nil, errors.New("a connection_string must be specified")
+	}
+	if (len(conf.Queue) > 0) == (len(conf.Topic) > 0) {
+		return nil, errors.New("exactly one of a queue or a topic must be specified")
+	}
+	entityPath := conf.Queue
+	if len(entityPath) == 0 {
+		entityPath = conf.Topic
+	}
+	return &AzureServiceBus{
+		conf:           conf,
+		entityPath:     entityPath,
+		sessionIDBytes: []byte(conf.SessionID),
+		timeout:        time.Duration(conf.TimeoutMS) * time.Millisecond,
+		log:            log.NewModule(".output.azure_service_bus"),
+		stats:          stats,
+	}, nil
+}
+
+// Connect attempts to establish a connection to the target queue or topic.
+func (a *AzureServiceBus) Connect() error {
+	if a.sender != nil {
+		return nil
+	}
+
+	ns, err := servicebus.NewNamespace(
+		servicebus.NamespaceWithConnectionString(a.conf.ConnectionString),
+	)
+	if err != nil {
+		return err
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), a.timeout)
+	defer done()
+	sender, err := ns.NewSender(ctx, a.entityPath)
+	if err != nil {
+		if sender != nil {
+			sender.Close(context.Background())
+		}
+		return err
+	}
+
+	a.sender = sender
+	a.log.Infof("Sending Azure Service Bus messages to '%v'\n", a.entityPath)
+	return nil
+}
+
+// message creates the Service Bus message of a message part, where the
+// metadata of the part is mapped to user properties.
+func (a *AzureServiceBus) message(msg types.Message, index int) *servicebus.Message {
+	sbMsg := servicebus.NewMessage(msg.Get(index))
+	msg.GetMetadata(index).Iter(func(k, v string) error {
+		sbMsg.Set(k, v)
+		return nil
+	})
+	if len(a.sessionIDBytes) > 0 {
+		sessionID := string(text.ReplaceFunctionVariablesFor(
+			types.ExtractPart(msg, index), a.sessionIDBytes,
+		))
+		sbMsg.SessionID = &sessionID
+	}
+	return sbMsg
+}
+
+// Write attempts to write message contents to the target queue or topic.
+func (a *AzureServiceBus) Write(msg types.Message) error {
+	if a.sender == nil {
+		return types.ErrNotConnected
+	}
+
+	for i := 0; i < msg.Len(); i++ {
+		ctx, done := context.WithTimeout(context.Background(), a.timeout)
+		err := a.sender.Send(ctx, a.message(msg, i))
+		done()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (a *AzureServiceBus) CloseAsync() {
+	if a.sender != nil {
+		a.sender.Close(context.Background())
+		a.sender = nil
+	}
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (a *AzureServiceBus) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
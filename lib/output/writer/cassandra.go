@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/gocql/gocql"
+)
+
+//------------------------------------------------------------------------------
+
+// CassandraConfig is configuration for the Cassandra output type.
+type CassandraConfig struct {
+	Addresses   []string `json:"addresses" yaml:"addresses"`
+	Query       string   `json:"query" yaml:"query"`
+	Args        []string `json:"args" yaml:"args"`
+	Consistency string   `json:"consistency" yaml:"consistency"`
+	TokenAware  bool     `json:"token_aware" yaml:"token_aware"`
+	Username    string   `json:"username" yaml:"username"`
+	Password    string   `json:"password" yaml:"password"`
+	TimeoutMS   int      `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewCassandraConfig creates a new CassandraConfig with default values.
+func NewCassandraConfig() CassandraConfig {
+	return CassandraConfig{
+		Addresses:   []string{"localhost:9042"},
+		Query:       "",
+		Args:        []string{},
+		Consistency: "QUORUM",
+		TokenAware:  true,
+		Username:    "",
+		Password:    "",
+		TimeoutMS:   5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Cassandra is a writer type that executes a CQL query for each message part.
+type Cassandra struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf        CassandraConfig
+	addresses   []string
+	consistency gocql.Consistency
+	args        [][]byte
+
+	session *gocql.Session
+}
+
+// NewCassandra creates a new Cassandra writer type.
+func NewCassandra(
+	conf CassandraConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*Cassandra, error) {
+	if len(conf.Query) == 0 {
+		return nil, errors.New("a query must be specified")
+	}
+
+	consistency, err := gocql.ParseConsistencyWrapper(conf.Consistency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse consistency: %v", err)
+	}
+
+	c := &Cassandra{
+		log:         log.NewModule(".output.cassandra"),
+		stats:       stats,
+		conf:        conf,
+		consistency: consistency,
+	}
+	for _, addr := range conf.Addresses {
+		for _, splitAddr := range strings.Split(addr, ",") {
+			if len(splitAddr) > 0 {
+				c.addresses = append(c.addresses, splitAddr)
+			}
+		}
+	}
+	for _, arg := range conf.Args {
+		c.args = append(c.args, []byte(arg))
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a session with a Cassandra cluster.
+func (c *Cassandra) Connect() error {
+	if c.session != nil {
+		return nil
+	}
+
+	cluster := gocql.NewCluster(c.addresses...)
+	cluster.Consistency = c.consistency
+	cluster.Timeout = time.Duration(c.conf.TimeoutMS) * time.Millisecond
+	if c.conf.TokenAware {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.RoundRobinHostPolicy())
+	}
+	if len(c.conf.Username) > 0 {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: c.conf.Username,
+			Password: c.conf.Password,
+		}
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return err
+	}
+
+	c.session = session
+	c.log.Infof("Executing queries on Cassandra cluster at addresses: %s\n", c.addresses)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// bindArgs resolves the interpolated arguments of the query for a message
+// part.
+func (c *Cassandra) bindArgs(msg types.Message, index int) []interface{} {
+	partMsg := types.ExtractPart(msg, index)
+	values := make([]interface{}, len(c.args))
+	for i, arg := range c.args {
+		values[i] = string(text.ReplaceFunctionVariablesFor(partMsg, arg))
+	}
+	return values
+}
+
+// Write attempts to write a message by executing the query for each part. The
+// queries of a multiple part message are executed as a single unlogged batch.
+func (c *Cassandra) Write(msg types.Message) error {
+	if c.session == nil {
+		return types.ErrNotConnected
+	}
+
+	if msg.Len() == 1 {
+		return c.session.Query(c.conf.Query, c.bindArgs(msg, 0)...).Exec()
+	}
+
+	batch := c.session.NewBatch(gocql.UnloggedBatch)
+	for i := 0; i < msg.Len(); i++ {
+		batch.Query(c.conf.Query, c.bindArgs(msg, i)...)
+	}
+	return c.session.ExecuteBatch(batch)
+}
+
+// CloseAsync shuts down the Cassandra writer and stops processing messages.
+func (c *Cassandra) CloseAsync() {
+	if c.session != nil {
+		c.session.Close()
+	}
+}
+
+// WaitForClose blocks until the Cassandra writer has closed down.
+func (c *Cassandra) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
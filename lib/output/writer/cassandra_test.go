@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCassandraBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewCassandraConfig()
+	if _, err := NewCassandra(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing query")
+	}
+
+	conf.Query = "INSERT INTO foo.bar (id) VALUES (?)"
+	conf.Consistency = "NOPE"
+	if _, err := NewCassandra(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad consistency")
+	}
+}
+
+func TestCassandraNotConnected(t *testing.T) {
+	conf := NewCassandraConfig()
+	conf.Query = "INSERT INTO foo.bar (id) VALUES (?)"
+
+	c, err := NewCassandra(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = c.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+}
+
+func TestCassandraBindArgs(t *testing.T) {
+	conf := NewCassandraConfig()
+	conf.Query = "INSERT INTO foo.bar (id, content, topic) VALUES (?, ?, ?)"
+	conf.Args = []string{
+		"${!json_field:id}",
+		"${!json_field:content}",
+		"${!metadata:topic}",
+	}
+
+	c, err := NewCassandra(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{
+		[]byte(`{"id":"1","content":"foo"}`),
+		[]byte(`{"id":"2","content":"bar"}`),
+	})
+	msg.GetMetadata(0).Set("topic", "first")
+	msg.GetMetadata(1).Set("topic", "second")
+
+	exp := [][]interface{}{
+		{"1", "foo", "first"},
+		{"2", "bar", "second"},
+	}
+	for i, e := range exp {
+		if act := c.bindArgs(msg, i); !reflect.DeepEqual(e, act) {
+			t.Errorf("Wrong args for part %v: %v != %v", i, act, e)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
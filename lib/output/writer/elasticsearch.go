@@ -22,6 +22,7 @@ package writer
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -30,35 +31,75 @@ import (
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/http/auth"
+	"github.com/Jeffail/benthos/lib/util/http/pool"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/cenkalti/backoff"
 	"github.com/olivere/elastic"
 )
 
 //------------------------------------------------------------------------------
 
+// ElasticsearchBackoffConfig contains the backoff settings used when retrying
+// documents that were rejected by Elasticsearch.
+type ElasticsearchBackoffConfig struct {
+	InitialInterval  int `json:"initial_interval_ms" yaml:"initial_interval_ms"`
+	MaxInterval      int `json:"max_interval_ms" yaml:"max_interval_ms"`
+	MaxElapsedTimeMS int `json:"max_elapsed_time_ms" yaml:"max_elapsed_time_ms"`
+}
+
 // ElasticsearchConfig is configuration for the Elasticsearch output type.
 type ElasticsearchConfig struct {
-	URLs      []string             `json:"urls" yaml:"urls"`
-	ID        string               `json:"id" yaml:"id"`
-	Index     string               `json:"index" yaml:"index"`
-	TimeoutMS int                  `json:"timeout_ms" yaml:"timeout_ms"`
-	Auth      auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	URLs           []string                   `json:"urls" yaml:"urls"`
+	Sniff          bool                       `json:"sniff" yaml:"sniff"`
+	ID             string                     `json:"id" yaml:"id"`
+	Index          string                     `json:"index" yaml:"index"`
+	TimeoutMS      int                        `json:"timeout_ms" yaml:"timeout_ms"`
+	MaxRetries     uint64                     `json:"max_retries" yaml:"max_retries"`
+	Backoff        ElasticsearchBackoffConfig `json:"backoff" yaml:"backoff"`
+	Auth           auth.BasicAuthConfig       `json:"basic_auth" yaml:"basic_auth"`
+	ConnectionPool pool.Config                `json:"connection_pool" yaml:"connection_pool"`
 }
 
 // NewElasticsearchConfig creates a new ElasticsearchConfig with default values.
 func NewElasticsearchConfig() ElasticsearchConfig {
 	return ElasticsearchConfig{
-		URLs:      []string{"http://localhost:9200"},
-		ID:        "${!count:elastic_ids}-${!timestamp_unix}",
-		Index:     "benthos_index",
-		TimeoutMS: 5000,
-		Auth:      auth.NewBasicAuthConfig(),
+		URLs:       []string{"http://localhost:9200"},
+		Sniff:      true,
+		ID:         "${!count:elastic_ids}-${!timestamp_unix}",
+		Index:      "benthos_index",
+		TimeoutMS:  5000,
+		MaxRetries: 0,
+		Backoff: ElasticsearchBackoffConfig{
+			InitialInterval:  1000,
+			MaxInterval:      5000,
+			MaxElapsedTimeMS: 30000,
+		},
+		Auth:           auth.NewBasicAuthConfig(),
+		ConnectionPool: pool.NewConfig(),
 	}
 }
 
 //------------------------------------------------------------------------------
 
+// ElasticsearchBulkError is returned by an Elasticsearch writer when documents
+// of a bulk request could not be indexed after exhausting their retries, and
+// contains the indexes of those message parts along with the reason.
+type ElasticsearchBulkError struct {
+	Failed map[int]string
+}
+
+// Error returns a summary of the failed documents.
+func (e ElasticsearchBulkError) Error() string {
+	reasons := make([]string, 0, len(e.Failed))
+	for i, reason := range e.Failed {
+		reasons = append(reasons, fmt.Sprintf("%v: %v", i, reason))
+	}
+	return fmt.Sprintf("failed to index %v documents: %v", len(e.Failed), strings.Join(reasons, ", "))
+}
+
+//------------------------------------------------------------------------------
+
 // Elasticsearch is a writer type that writes messages into elasticsearch.
 type Elasticsearch struct {
 	log   log.Modular
@@ -67,23 +108,46 @@ type Elasticsearch struct {
 	urls []string
 	conf ElasticsearchConfig
 
-	idBytes       []byte
-	interpolateID bool
+	idBytes          []byte
+	interpolateID    bool
+	indexBytes       []byte
+	interpolateIndex bool
+
+	backoffCtor func() backoff.BackOff
 
 	client *elastic.Client
+
+	mRetried  metrics.StatCounter
+	mFailed   metrics.StatCounter
+	mRejected metrics.StatCounter
 }
 
 // NewElasticsearch creates a new Elasticsearch writer type.
 func NewElasticsearch(conf ElasticsearchConfig, log log.Modular, stats metrics.Type) (*Elasticsearch, error) {
 	idBytes := []byte(conf.ID)
-	interpolateID := text.ContainsFunctionVariables(idBytes)
+	indexBytes := []byte(conf.Index)
 
 	e := Elasticsearch{
-		log:           log.NewModule(".output.elasticsearch"),
-		stats:         stats,
-		conf:          conf,
-		idBytes:       idBytes,
-		interpolateID: interpolateID,
+		log:              log.NewModule(".output.elasticsearch"),
+		stats:            stats,
+		conf:             conf,
+		idBytes:          idBytes,
+		interpolateID:    text.ContainsFunctionVariables(idBytes),
+		indexBytes:       indexBytes,
+		interpolateIndex: text.ContainsFunctionVariables(indexBytes),
+		backoffCtor: func() backoff.BackOff {
+			boff := backoff.NewExponentialBackOff()
+			boff.InitialInterval = time.Millisecond * time.Duration(conf.Backoff.InitialInterval)
+			boff.MaxInterval = time.Millisecond * time.Duration(conf.Backoff.MaxInterval)
+			boff.MaxElapsedTime = time.Millisecond * time.Duration(conf.Backoff.MaxElapsedTimeMS)
+			if conf.MaxRetries > 0 {
+				return backoff.WithMaxRetries(boff, conf.MaxRetries)
+			}
+			return boff
+		},
+		mRetried:  stats.GetCounter("output.elasticsearch.bulk.retried"),
+		mFailed:   stats.GetCounter("output.elasticsearch.bulk.failed"),
+		mRejected: stats.GetCounter("output.elasticsearch.bulk.rejected"),
 	}
 
 	for _, u := range conf.URLs {
@@ -107,8 +171,10 @@ func (e *Elasticsearch) Connect() error {
 
 	opts := []elastic.ClientOptionFunc{
 		elastic.SetURL(e.urls...),
+		elastic.SetSniff(e.conf.Sniff),
 		elastic.SetHttpClient(&http.Client{
-			Timeout: time.Duration(e.conf.TimeoutMS) * time.Millisecond,
+			Timeout:   time.Duration(e.conf.TimeoutMS) * time.Millisecond,
+			Transport: e.conf.ConnectionPool.Transport(nil),
 		}),
 	}
 
@@ -118,47 +184,135 @@ func (e *Elasticsearch) Connect() error {
 		))
 	}
 
-	var err error
-	if e.client, err = elastic.NewClient(opts...); err != nil {
+	client, err := elastic.NewClient(opts...)
+	if err != nil {
 		return err
 	}
 
-	if err == nil {
+	// The index can only be checked up front when it is static.
+	if !e.interpolateIndex {
 		var indexExists bool
-		indexExists, err = e.client.IndexExists(e.conf.Index).Do(context.Background())
-		if err == nil && !indexExists {
-			err = fmt.Errorf("index '%v' does not exist", e.conf.Index)
+		if indexExists, err = client.IndexExists(e.conf.Index).Do(context.Background()); err != nil {
+			return err
+		}
+		if !indexExists {
+			return fmt.Errorf("index '%v' does not exist", e.conf.Index)
 		}
 	}
 
-	if err == nil {
-		e.log.Infof("Sending messages to Elasticsearch index at urls: %s\n", e.urls)
-	}
-	return err
+	e.client = client
+	e.log.Infof("Sending messages to Elasticsearch index at urls: %s\n", e.urls)
+	return nil
+}
+
+// shouldRetry returns true if a failed bulk item should be retried.
+func shouldRetry(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
 }
 
-// Write will attempt to write a message to Elasticsearch, wait for acknowledgement, and
-// returns an error if applicable.
+// Write will attempt to write a message to Elasticsearch as a single bulk
+// request, wait for acknowledgement, and returns an error if applicable.
+// Documents that are rejected due to back pressure are retried with a backoff
+// until they succeed or the retries are exhausted, in which case an error is
+// returned. Documents that are rejected for any other reason would fail again
+// if the message were resent, and are therefore logged and dropped rather than
+// failing the message.
 func (e *Elasticsearch) Write(msg types.Message) error {
 	if e.client == nil {
 		return types.ErrNotConnected
 	}
 
-	return msg.Iter(func(i int, part []byte) error {
-		id := e.idBytes
-		if e.interpolateID {
-			id = text.ReplaceFunctionVariables(id)
+	requests := make([]elastic.BulkableRequest, msg.Len())
+	msg.Iter(func(i int, part []byte) error {
+		id, index := e.idBytes, e.indexBytes
+		if e.interpolateID || e.interpolateIndex {
+			partMsg := types.ExtractPart(msg, i)
+			if e.interpolateID {
+				id = text.ReplaceFunctionVariablesFor(partMsg, id)
+			}
+			if e.interpolateIndex {
+				index = text.ReplaceFunctionVariablesFor(partMsg, index)
+			}
 		}
-
-		_, err := e.client.Index().
-			Index(e.conf.Index).
+		requests[i] = elastic.NewBulkIndexRequest().
+			Index(string(index)).
 			Type("doc").
 			Id(string(id)).
-			BodyString(string(part)).
-			Do(context.Background())
-
-		return err
+			Doc(json.RawMessage(part))
+		return nil
 	})
+
+	// Maps the index of each pending request to its message part.
+	pending := make([]int, len(requests))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	boff := e.backoffCtor()
+	failed := map[int]string{}
+	rejected := map[int]string{}
+	for {
+		bulk := e.client.Bulk()
+		for _, i := range pending {
+			bulk.Add(requests[i])
+		}
+
+		res, err := bulk.Do(context.Background())
+		if err != nil {
+			return err
+		}
+
+		for _, i := range pending {
+			delete(failed, i)
+		}
+
+		var retries []int
+		for j, item := range res.Items {
+			if j >= len(pending) {
+				break
+			}
+			for _, result := range item {
+				if result.Status >= 200 && result.Status <= 299 {
+					continue
+				}
+				reason := fmt.Sprintf("status %v", result.Status)
+				if result.Error != nil {
+					reason = fmt.Sprintf("%v: %v", result.Error.Type, result.Error.Reason)
+				}
+				if shouldRetry(result.Status) {
+					retries = append(retries, pending[j])
+					failed[pending[j]] = reason
+				} else {
+					rejected[pending[j]] = reason
+				}
+			}
+		}
+
+		if len(retries) == 0 {
+			break
+		}
+		pending = retries
+
+		nextBackoff := boff.NextBackOff()
+		if nextBackoff == backoff.Stop {
+			break
+		}
+		e.mRetried.Incr(int64(len(pending)))
+		<-time.After(nextBackoff)
+	}
+
+	if len(rejected) > 0 {
+		e.mRejected.Incr(int64(len(rejected)))
+		e.log.Errorf(
+			"Dropping documents rejected by Elasticsearch%v: %v\n",
+			types.CorrelationLogTag(msg), ElasticsearchBulkError{Failed: rejected},
+		)
+	}
+	if len(failed) > 0 {
+		e.mFailed.Incr(int64(len(failed)))
+		return ElasticsearchBulkError{Failed: failed}
+	}
+	return nil
 }
 
 // CloseAsync shuts down the Elasticsearch writer and stops processing messages.
@@ -0,0 +1,230 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+type fakeElasticBulkItem struct {
+	Index  string
+	ID     string
+	Status int
+}
+
+// newFakeElastic creates a server that responds to bulk requests with the
+// statuses returned by the provided closure.
+func newFakeElastic(t *testing.T, respond func(call int, items []fakeElasticBulkItem) []int) (*httptest.Server, *[][]fakeElasticBulkItem) {
+	var mut sync.Mutex
+	calls := [][]fakeElasticBulkItem{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{}`))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var items []fakeElasticBulkItem
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for i := 0; scanner.Scan(); i++ {
+			if i%2 != 0 {
+				continue
+			}
+			var action map[string]struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id"`
+			}
+			if err = json.Unmarshal(scanner.Bytes(), &action); err != nil {
+				t.Error(err)
+				return
+			}
+			items = append(items, fakeElasticBulkItem{
+				Index: action["index"].Index,
+				ID:    action["index"].ID,
+			})
+		}
+
+		mut.Lock()
+		statuses := respond(len(calls), items)
+		calls = append(calls, items)
+		mut.Unlock()
+
+		resItems := []interface{}{}
+		hasErrors := false
+		for i, item := range items {
+			res := map[string]interface{}{
+				"_index": item.Index,
+				"_type":  "doc",
+				"_id":    item.ID,
+				"status": statuses[i],
+			}
+			if statuses[i] >= 300 {
+				hasErrors = true
+				res["error"] = map[string]interface{}{
+					"type":   "test_exception",
+					"reason": "nope",
+				}
+			}
+			resItems = append(resItems, map[string]interface{}{"index": res})
+		}
+
+		resBytes, _ := json.Marshal(map[string]interface{}{
+			"took":   1,
+			"errors": hasErrors,
+			"items":  resItems,
+		})
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(resBytes)
+	}))
+	return server, &calls
+}
+
+func newTestElastic(t *testing.T, url string, conf ElasticsearchConfig) *Elasticsearch {
+	conf.URLs = []string{url}
+	conf.Sniff = false
+	conf.Backoff.InitialInterval = 1
+	conf.Backoff.MaxInterval = 1
+
+	e, err := NewElasticsearch(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestElasticBulkInterpolation(t *testing.T) {
+	server, calls := newFakeElastic(t, func(call int, items []fakeElasticBulkItem) []int {
+		statuses := make([]int, len(items))
+		for i := range statuses {
+			statuses[i] = 201
+		}
+		return statuses
+	})
+	defer server.Close()
+
+	conf := NewElasticsearchConfig()
+	conf.Index = "${!metadata:index}"
+	conf.ID = "${!json_field:id,0}"
+
+	e := newTestElastic(t, server.URL, conf)
+
+	msg := types.NewMessage([][]byte{
+		[]byte(`{"id":"foo"}`),
+		[]byte(`{"id":"bar"}`),
+	})
+	msg.GetMetadata(0).Set("index", "first")
+	msg.GetMetadata(1).Set("index", "second")
+
+	if err := e.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]fakeElasticBulkItem{{
+		{Index: "first", ID: "foo"},
+		{Index: "second", ID: "bar"},
+	}}
+	if !reflect.DeepEqual(exp, *calls) {
+		t.Errorf("Wrong bulk requests: %v != %v", *calls, exp)
+	}
+}
+
+func TestElasticBulkPartialFailure(t *testing.T) {
+	server, calls := newFakeElastic(t, func(call int, items []fakeElasticBulkItem) []int {
+		if call > 0 {
+			return []int{201}
+		}
+		return []int{201, 429, 400}
+	})
+	defer server.Close()
+
+	conf := NewElasticsearchConfig()
+	conf.Index = "foo"
+	conf.ID = "${!count:elastic_bulk_partial}"
+
+	e := newTestElastic(t, server.URL, conf)
+
+	err := e.Write(types.NewMessage([][]byte{
+		[]byte(`{"doc":0}`),
+		[]byte(`{"doc":1}`),
+		[]byte(`{"doc":2}`),
+	}))
+
+	if err != nil {
+		t.Errorf("Expected rejected document to be dropped, received: %v", err)
+	}
+
+	exp := [][]fakeElasticBulkItem{
+		{{Index: "foo", ID: "1"}, {Index: "foo", ID: "2"}, {Index: "foo", ID: "3"}},
+		{{Index: "foo", ID: "2"}},
+	}
+	if !reflect.DeepEqual(exp, *calls) {
+		t.Errorf("Wrong bulk requests: %v != %v", *calls, exp)
+	}
+}
+
+func TestElasticBulkRetriesExhausted(t *testing.T) {
+	server, calls := newFakeElastic(t, func(call int, items []fakeElasticBulkItem) []int {
+		return []int{429}
+	})
+	defer server.Close()
+
+	conf := NewElasticsearchConfig()
+	conf.Index = "foo"
+	conf.ID = "bar"
+	conf.MaxRetries = 2
+
+	e := newTestElastic(t, server.URL, conf)
+
+	err := e.Write(types.NewMessage([][]byte{[]byte(`{"doc":0}`)}))
+	if _, ok := err.(ElasticsearchBulkError); !ok {
+		t.Fatalf("Expected bulk error, received: %v", err)
+	}
+	if exp, act := 3, len(*calls); exp != act {
+		t.Errorf("Wrong count of bulk requests: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
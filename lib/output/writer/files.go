@@ -85,10 +85,12 @@ func (f *Files) Connect() error {
 
 // Write attempts to write message contents to a directory as files.
 func (f *Files) Write(msg types.Message) error {
-	for _, part := range msg.GetAll() {
+	for i, part := range msg.GetAll() {
 		path := f.conf.Path
 		if f.interpolatePath {
-			path = string(text.ReplaceFunctionVariables(f.pathBytes))
+			path = string(text.ReplaceFunctionVariablesFor(
+				types.ExtractPart(msg, i), f.pathBytes,
+			))
 		}
 
 		err := os.MkdirAll(filepath.Dir(path), os.FileMode(0777))
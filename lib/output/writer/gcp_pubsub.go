@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// GCPPubSubConfig contains configuration fields for the output GCPPubSub type.
+type GCPPubSubConfig struct {
+	ProjectID   string `json:"project" yaml:"project"`
+	TopicID     string `json:"topic" yaml:"topic"`
+	OrderingKey string `json:"ordering_key" yaml:"ordering_key"`
+}
+
+// NewGCPPubSubConfig creates a new Config with default values.
+func NewGCPPubSubConfig() GCPPubSubConfig {
+	return GCPPubSubConfig{
+		ProjectID:   "",
+		TopicID:     "",
+		OrderingKey: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GCPPubSub is a benthos writer.Type implementation that writes messages to a
+// GCP Pub/Sub topic.
+type GCPPubSub struct {
+	conf GCPPubSubConfig
+
+	orderingKeyBytes []byte
+
+	client *pubsub.Client
+	topic  *pubsub.Topic
+
+	log   log.Modular
+	stats metrics.Type
+}
+
+// NewGCPPubSub creates a new GCP Pub/Sub writer.Type.
+func NewGCPPubSub(
+	conf GCPPubSubConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*GCPPubSub, error) {
+	return &GCPPubSub{
+		conf:             conf,
+		orderingKeyBytes: []byte(conf.OrderingKey),
+		log:              log.NewModule(".output.gcp_pubsub"),
+		stats:            stats,
+	}, nil
+}
+
+// Connect attempts to establish a connection to the target topic.
+func (c *GCPPubSub) Connect() error {
+	if c.topic != nil {
+		return nil
+	}
+
+	client, err := pubsub.NewClient(context.Background(), c.conf.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	topic := client.Topic(c.conf.TopicID)
+	exists, err := topic.Exists(context.Background())
+	if err != nil {
+		client.Close()
+		return err
+	}
+	if !exists {
+		client.Close()
+		return fmt.Errorf("topic '%v' does not exist", c.conf.TopicID)
+	}
+	topic.EnableMessageOrdering = len(c.orderingKeyBytes) > 0
+
+	c.client = client
+	c.topic = topic
+
+	c.log.Infof("Sending GCP Pub/Sub messages to project '%v' and topic '%v'\n", c.conf.ProjectID, c.conf.TopicID)
+	return nil
+}
+
+// pubsubMessage creates the Pub/Sub message of a message part, where the
+// metadata of the part is mapped to attributes.
+func (c *GCPPubSub) pubsubMessage(msg types.Message, index int) *pubsub.Message {
+	gmsg := &pubsub.Message{
+		Data: msg.Get(index),
+	}
+	msg.GetMetadata(index).Iter(func(k, v string) error {
+		if gmsg.Attributes == nil {
+			gmsg.Attributes = map[string]string{}
+		}
+		gmsg.Attributes[k] = v
+		return nil
+	})
+	if len(c.orderingKeyBytes) > 0 {
+		gmsg.OrderingKey = string(text.ReplaceFunctionVariablesFor(
+			types.ExtractPart(msg, index), c.orderingKeyBytes,
+		))
+	}
+	return gmsg
+}
+
+// Write attempts to write message contents to the target topic. All parts of
+// the message are published before waiting for their results.
+func (c *GCPPubSub) Write(msg types.Message) error {
+	if c.topic == nil {
+		return types.ErrNotConnected
+	}
+
+	gmsgs := make([]*pubsub.Message, msg.Len())
+	results := make([]*pubsub.PublishResult, msg.Len())
+	for i := range gmsgs {
+		gmsgs[i] = c.pubsubMessage(msg, i)
+		results[i] = c.topic.Publish(context.Background(), gmsgs[i])
+	}
+
+	var err error
+	for i, r := range results {
+		if _, rerr := r.Get(context.Background()); rerr != nil {
+			// Publishing of an ordering key is paused after a failure until it
+			// is explicitly resumed.
+			if len(gmsgs[i].OrderingKey) > 0 {
+				c.topic.ResumePublish(gmsgs[i].OrderingKey)
+			}
+			if err == nil {
+				err = rerr
+			}
+		}
+	}
+	return err
+}
+
+// CloseAsync begins cleaning up resources used by this writer asynchronously.
+func (c *GCPPubSub) CloseAsync() {
+	if c.topic != nil {
+		c.topic.Stop()
+		c.client.Close()
+		c.topic = nil
+		c.client = nil
+	}
+}
+
+// WaitForClose will block until either the writer is closed or a specified
+// timeout occurs.
+func (c *GCPPubSub) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
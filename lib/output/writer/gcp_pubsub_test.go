@@ -0,0 +1,67 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestGCPPubSubMessage(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewGCPPubSubConfig()
+	conf.OrderingKey = "${!metadata:user}"
+
+	w, err := NewGCPPubSub(conf, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{[]byte("first"), []byte("second")})
+	msg.GetMetadata(1).Set("user", "foo").Set("source", "bar")
+
+	gmsg := w.pubsubMessage(msg, 1)
+	if exp, act := "second", string(gmsg.Data); exp != act {
+		t.Errorf("Wrong data: %v != %v", act, exp)
+	}
+	if exp, act := "foo", gmsg.OrderingKey; exp != act {
+		t.Errorf("Wrong ordering key: %v != %v", act, exp)
+	}
+	expAttrs := map[string]string{"user": "foo", "source": "bar"}
+	if act := gmsg.Attributes; !reflect.DeepEqual(expAttrs, act) {
+		t.Errorf("Wrong attributes: %v != %v", act, expAttrs)
+	}
+
+	gmsg = w.pubsubMessage(msg, 0)
+	if gmsg.Attributes != nil {
+		t.Errorf("Unexpected attributes: %v", gmsg.Attributes)
+	}
+}
+
+//------------------------------------------------------------------------------
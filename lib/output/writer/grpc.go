@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pb"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	btls "github.com/Jeffail/benthos/lib/util/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+//------------------------------------------------------------------------------
+
+// GRPCConfig is configuration for the GRPC output type.
+type GRPCConfig struct {
+	Address   string      `json:"address" yaml:"address"`
+	TimeoutMS int64       `json:"timeout_ms" yaml:"timeout_ms"`
+	TLS       btls.Config `json:"tls" yaml:"tls"`
+}
+
+// NewGRPCConfig creates a new GRPCConfig with default values.
+func NewGRPCConfig() GRPCConfig {
+	return GRPCConfig{
+		Address:   "localhost:4197",
+		TimeoutMS: 5000,
+		TLS:       btls.NewConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GRPC is an output type that pushes messages over a gRPC stream.
+type GRPC struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf    GRPCConfig
+	dialOpt grpc.DialOption
+
+	conn    *grpc.ClientConn
+	stream  pb.Benthos_SendClient
+	cancel  context.CancelFunc
+	connMut sync.Mutex
+}
+
+// NewGRPC creates a new GRPC output type.
+func NewGRPC(
+	conf GRPCConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*GRPC, error) {
+	g := &GRPC{
+		log:     log.NewModule(".output.grpc"),
+		stats:   stats,
+		conf:    conf,
+		dialOpt: grpc.WithInsecure(),
+	}
+	if conf.TLS.Enabled {
+		tlsConf, err := conf.TLS.Get()
+		if err != nil {
+			return nil, err
+		}
+		g.dialOpt = grpc.WithTransportCredentials(credentials.NewTLS(tlsConf))
+	}
+	return g, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a connection and open a stream to a gRPC
+// server.
+func (g *GRPC) Connect() error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.stream != nil {
+		return nil
+	}
+
+	dialCtx, dialDone := context.WithTimeout(
+		context.Background(), time.Millisecond*time.Duration(g.conf.TimeoutMS),
+	)
+	defer dialDone()
+
+	conn, err := grpc.DialContext(dialCtx, g.conf.Address, g.dialOpt, grpc.WithBlock())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := pb.NewBenthosClient(conn).Send(ctx)
+	if err != nil {
+		cancel()
+		conn.Close()
+		return err
+	}
+
+	g.conn = conn
+	g.stream = stream
+	g.cancel = cancel
+
+	g.log.Infof("Sending gRPC messages to: %v\n", g.conf.Address)
+	return nil
+}
+
+// Write attempts to send a message over the stream and blocks until the server
+// responds. An error is returned if the server failed to deliver the message.
+func (g *GRPC) Write(msg types.Message) error {
+	g.connMut.Lock()
+	stream := g.stream
+	g.connMut.Unlock()
+
+	if stream == nil {
+		return types.ErrNotConnected
+	}
+
+	if err := stream.Send(pb.FromMessage(msg)); err != nil {
+		g.log.Errorf("Failed to send message: %v\n", err)
+		g.disconnect()
+		return types.ErrNotConnected
+	}
+
+	res, err := stream.Recv()
+	if err != nil {
+		g.log.Errorf("Failed to receive response: %v\n", err)
+		g.disconnect()
+		return types.ErrNotConnected
+	}
+	if len(res.Error) > 0 {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// disconnect safely closes the stream and connection to the gRPC server.
+func (g *GRPC) disconnect() error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.stream == nil {
+		return nil
+	}
+
+	g.stream.CloseSend()
+	g.cancel()
+	err := g.conn.Close()
+
+	g.stream = nil
+	g.cancel = nil
+	g.conn = nil
+	return err
+}
+
+// CloseAsync shuts down the GRPC output and stops processing messages.
+func (g *GRPC) CloseAsync() {
+	g.disconnect()
+}
+
+// WaitForClose blocks until the GRPC output has closed down.
+func (g *GRPC) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/pb"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"google.golang.org/grpc"
+)
+
+//------------------------------------------------------------------------------
+
+// mockBenthosServer records the messages it receives and rejects those with
+// the content "fail".
+type mockBenthosServer struct {
+	msgs    []types.Message
+	msgsMut sync.Mutex
+}
+
+func (m *mockBenthosServer) Send(stream pb.Benthos_SendServer) error {
+	for {
+		pbMsg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		msg := pb.ToMessage(pbMsg)
+
+		m.msgsMut.Lock()
+		m.msgs = append(m.msgs, msg)
+		m.msgsMut.Unlock()
+
+		res := &pb.Response{}
+		if string(msg.Get(0)) == "fail" {
+			res.Error = "rejected"
+		}
+		if err = stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+func startMockBenthosServer(t *testing.T) (*grpc.Server, *mockBenthosServer, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	mock := &mockBenthosServer{}
+	pb.RegisterBenthosServer(srv, mock)
+	go srv.Serve(l)
+	return srv, mock, l.Addr().String()
+}
+
+//------------------------------------------------------------------------------
+
+func TestGRPCWrite(t *testing.T) {
+	srv, mock, addr := startMockBenthosServer(t)
+	defer srv.Stop()
+
+	conf := NewGRPCConfig()
+	conf.Address = addr
+
+	g, err := NewGRPC(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.CloseAsync()
+
+	if err = g.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error before connecting: %v != %v", err, types.ErrNotConnected)
+	}
+
+	if err = g.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	msg.GetMetadata(1).Set("baz", "qux")
+	if err = g.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = g.Write(types.NewMessage([][]byte{[]byte("fail")})); err == nil {
+		t.Error("Expected error from rejected message")
+	} else if exp, act := "rejected", err.Error(); exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+
+	mock.msgsMut.Lock()
+	received := mock.msgs
+	mock.msgsMut.Unlock()
+
+	if exp, act := 2, len(received); exp != act {
+		t.Fatalf("Wrong count of received messages: %v != %v", act, exp)
+	}
+	if exp, act := msg.GetAll(), received[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong message contents: %s != %s", act, exp)
+	}
+	if exp, act := "qux", received[0].GetMetadata(1).Get("baz"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+}
+
+func TestGRPCServerGone(t *testing.T) {
+	srv, _, addr := startMockBenthosServer(t)
+
+	conf := NewGRPCConfig()
+	conf.Address = addr
+
+	g, err := NewGRPC(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.CloseAsync()
+
+	if err = g.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Stop()
+
+	if err = g.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+	if err = g.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error after disconnect: %v != %v", err, types.ErrNotConnected)
+	}
+}
+
+//------------------------------------------------------------------------------
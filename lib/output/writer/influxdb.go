@@ -0,0 +1,357 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/http/auth"
+	"github.com/Jeffail/benthos/lib/util/http/pool"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// InfluxDBJSONConfig contains the settings for converting JSON documents into
+// InfluxDB line protocol.
+type InfluxDBJSONConfig struct {
+	Measurement    string   `json:"measurement" yaml:"measurement"`
+	Tags           []string `json:"tags" yaml:"tags"`
+	Fields         []string `json:"fields" yaml:"fields"`
+	TimestampField string   `json:"timestamp_field" yaml:"timestamp_field"`
+}
+
+// InfluxDBConfig is configuration for the InfluxDB output type.
+type InfluxDBConfig struct {
+	URL             string               `json:"url" yaml:"url"`
+	Database        string               `json:"database" yaml:"database"`
+	RetentionPolicy string               `json:"retention_policy" yaml:"retention_policy"`
+	Precision       string               `json:"precision" yaml:"precision"`
+	Format          string               `json:"format" yaml:"format"`
+	JSON            InfluxDBJSONConfig   `json:"json" yaml:"json"`
+	TimeoutMS       int                  `json:"timeout_ms" yaml:"timeout_ms"`
+	Auth            auth.BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
+	ConnectionPool  pool.Config          `json:"connection_pool" yaml:"connection_pool"`
+}
+
+// NewInfluxDBConfig creates a new InfluxDBConfig with default values.
+func NewInfluxDBConfig() InfluxDBConfig {
+	return InfluxDBConfig{
+		URL:             "http://localhost:8086",
+		Database:        "benthos",
+		RetentionPolicy: "",
+		Precision:       "ns",
+		Format:          "line_protocol",
+		JSON: InfluxDBJSONConfig{
+			Measurement:    "benthos",
+			Tags:           []string{},
+			Fields:         []string{},
+			TimestampField: "",
+		},
+		TimeoutMS:      5000,
+		Auth:           auth.NewBasicAuthConfig(),
+		ConnectionPool: pool.NewConfig(),
+	}
+}
+
+// influxPrecisions maps the supported precisions to their durations.
+var influxPrecisions = map[string]time.Duration{
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+//------------------------------------------------------------------------------
+
+// InfluxDB is a writer type that writes points to InfluxDB over HTTP.
+type InfluxDB struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf      InfluxDBConfig
+	writeURL  string
+	precision time.Duration
+
+	measurement            []byte
+	interpolateMeasurement bool
+	tags                   map[string]struct{}
+
+	client *http.Client
+}
+
+// NewInfluxDB creates a new InfluxDB writer type.
+func NewInfluxDB(
+	conf InfluxDBConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*InfluxDB, error) {
+	precision, exists := influxPrecisions[conf.Precision]
+	if !exists {
+		return nil, fmt.Errorf("precision not recognised: %v", conf.Precision)
+	}
+	if conf.Format != "line_protocol" && conf.Format != "json" {
+		return nil, fmt.Errorf("format not recognised: %v", conf.Format)
+	}
+
+	query := url.Values{}
+	query.Set("db", conf.Database)
+	query.Set("precision", conf.Precision)
+	if len(conf.RetentionPolicy) > 0 {
+		query.Set("rp", conf.RetentionPolicy)
+	}
+
+	i := &InfluxDB{
+		log:                    log.NewModule(".output.influxdb"),
+		stats:                  stats,
+		conf:                   conf,
+		writeURL:               strings.TrimSuffix(conf.URL, "/") + "/write?" + query.Encode(),
+		precision:              precision,
+		measurement:            []byte(conf.JSON.Measurement),
+		interpolateMeasurement: text.ContainsFunctionVariables([]byte(conf.JSON.Measurement)),
+		tags:                   map[string]struct{}{},
+	}
+	for _, t := range conf.JSON.Tags {
+		i.tags[t] = struct{}{}
+	}
+	return i, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect creates the HTTP client of the writer and checks that the server is
+// reachable.
+func (i *InfluxDB) Connect() error {
+	if i.client != nil {
+		return nil
+	}
+	client := &http.Client{
+		Timeout:   time.Duration(i.conf.TimeoutMS) * time.Millisecond,
+		Transport: i.conf.ConnectionPool.Transport(nil),
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimSuffix(i.conf.URL, "/")+"/ping", nil)
+	if err != nil {
+		return err
+	}
+	if err = i.conf.Auth.Sign(req); err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("ping returned status %v", res.StatusCode)
+	}
+
+	i.client = client
+	i.log.Infof("Writing points to InfluxDB database %v at URL: %v\n", i.conf.Database, i.conf.URL)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxKeyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	influxStringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// influxFieldValue formats a JSON value as a line protocol field value.
+func influxFieldValue(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return `"` + influxStringEscaper.Replace(t) + `"`, true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	return "", false
+}
+
+// influxTagValue formats a JSON value as a line protocol tag value.
+func influxTagValue(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return influxKeyEscaper.Replace(t), len(t) > 0
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	return "", false
+}
+
+// timestamp converts a JSON value into a timestamp of the configured precision.
+func (i *InfluxDB) timestamp(v interface{}) (int64, error) {
+	switch t := v.(type) {
+	case float64:
+		return int64(t), nil
+	case string:
+		ts, err := time.Parse(time.RFC3339Nano, t)
+		if err != nil {
+			return 0, err
+		}
+		return ts.UnixNano() / int64(i.precision), nil
+	}
+	return 0, fmt.Errorf("unsupported timestamp type: %T", v)
+}
+
+// toLineProtocol converts a JSON object message part into a line protocol
+// point.
+func (i *InfluxDB) toLineProtocol(msg types.Message, index int) ([]byte, error) {
+	jObj, err := msg.GetJSON(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse part as JSON: %v", err)
+	}
+	obj, ok := jObj.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected JSON object, found: %T", jObj)
+	}
+
+	measurement := i.measurement
+	if i.interpolateMeasurement {
+		measurement = text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, index), measurement)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(influxMeasurementEscaper.Replace(string(measurement)))
+
+	for _, tag := range i.conf.JSON.Tags {
+		if v, ok := influxTagValue(obj[tag]); ok {
+			buf.WriteByte(',')
+			buf.WriteString(influxKeyEscaper.Replace(tag))
+			buf.WriteByte('=')
+			buf.WriteString(v)
+		}
+	}
+
+	fieldKeys := i.conf.JSON.Fields
+	if len(fieldKeys) == 0 {
+		for k := range obj {
+			if _, isTag := i.tags[k]; !isTag && k != i.conf.JSON.TimestampField {
+				fieldKeys = append(fieldKeys, k)
+			}
+		}
+		sort.Strings(fieldKeys)
+	}
+
+	nFields := 0
+	for _, key := range fieldKeys {
+		v, ok := influxFieldValue(obj[key])
+		if !ok {
+			continue
+		}
+		if nFields == 0 {
+			buf.WriteByte(' ')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(influxKeyEscaper.Replace(key))
+		buf.WriteByte('=')
+		buf.WriteString(v)
+		nFields++
+	}
+	if nFields == 0 {
+		return nil, fmt.Errorf("no fields found for point")
+	}
+
+	if len(i.conf.JSON.TimestampField) > 0 {
+		if v, exists := obj[i.conf.JSON.TimestampField]; exists {
+			ts, err := i.timestamp(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse timestamp: %v", err)
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatInt(ts, 10))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Write attempts to write a message by sending all of its parts as points of a
+// single write request.
+func (i *InfluxDB) Write(msg types.Message) error {
+	if i.client == nil {
+		return types.ErrNotConnected
+	}
+
+	var body bytes.Buffer
+	if err := msg.Iter(func(index int, part []byte) error {
+		if i.conf.Format == "json" {
+			var err error
+			if part, err = i.toLineProtocol(msg, index); err != nil {
+				return fmt.Errorf("part %v: %v", index, err)
+			}
+		}
+		body.Write(bytes.TrimRight(part, "\n"))
+		body.WriteByte('\n')
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", i.writeURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if err = i.conf.Auth.Sign(req); err != nil {
+		return err
+	}
+
+	res, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		resBody, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("write returned status %v: %s", res.StatusCode, bytes.TrimSpace(resBody))
+	}
+	return nil
+}
+
+// CloseAsync shuts down the InfluxDB writer and stops processing messages.
+func (i *InfluxDB) CloseAsync() {
+}
+
+// WaitForClose blocks until the InfluxDB writer has closed down.
+func (i *InfluxDB) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
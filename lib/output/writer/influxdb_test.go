@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newTestInfluxDB(t *testing.T, conf InfluxDBConfig) *InfluxDB {
+	i, err := NewInfluxDB(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return i
+}
+
+func TestInfluxDBBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewInfluxDBConfig()
+	conf.Precision = "h"
+	if _, err := NewInfluxDB(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad precision")
+	}
+
+	conf = NewInfluxDBConfig()
+	conf.Format = "nope"
+	if _, err := NewInfluxDB(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad format")
+	}
+}
+
+func TestInfluxDBToLineProtocol(t *testing.T) {
+	conf := NewInfluxDBConfig()
+	conf.Format = "json"
+	conf.Precision = "s"
+	conf.JSON.Measurement = "${!metadata:measurement}"
+	conf.JSON.Tags = []string{"host", "region"}
+	conf.JSON.TimestampField = "ts"
+
+	i := newTestInfluxDB(t, conf)
+
+	msg := types.NewMessage([][]byte{
+		[]byte(`{"host":"server 1","region":"eu,west","value":0.5,"count":10,"ok":true,"name":"foo \"bar\"","ts":"2018-10-15T10:00:00Z"}`),
+		[]byte(`{"host":"server2","value":1,"ts":1539597600}`),
+		[]byte(`{"host":"server3"}`),
+	})
+	msg.GetMetadata(0).Set("measurement", "cpu load")
+	msg.GetMetadata(1).Set("measurement", "mem")
+
+	tests := []string{
+		`cpu\ load,host=server\ 1,region=eu\,west count=10,name="foo \"bar\"",ok=true,value=0.5 1539597600`,
+		`mem,host=server2 value=1 1539597600`,
+	}
+	for index, exp := range tests {
+		act, err := i.toLineProtocol(msg, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(act) != exp {
+			t.Errorf("Wrong result for part %v: %s != %s", index, act, exp)
+		}
+	}
+
+	if _, err := i.toLineProtocol(msg, 2); err == nil {
+		t.Error("Expected error from point without fields")
+	}
+}
+
+func TestInfluxDBWrite(t *testing.T) {
+	var reqURL, reqBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ping" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		reqURL, reqBody = r.URL.String(), string(body)
+		if r.URL.Query().Get("db") == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"partial write"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	conf := NewInfluxDBConfig()
+	conf.URL = server.URL
+	conf.Database = "foo"
+	conf.RetentionPolicy = "bar"
+
+	i := newTestInfluxDB(t, conf)
+	if err := i.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+	if err := i.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := i.Write(types.NewMessage([][]byte{
+		[]byte("cpu value=1\n"),
+		[]byte("cpu value=2"),
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "/write?db=foo&precision=ns&rp=bar"; reqURL != exp {
+		t.Errorf("Wrong URL: %v != %v", reqURL, exp)
+	}
+	if exp := "cpu value=1\ncpu value=2\n"; reqBody != exp {
+		t.Errorf("Wrong body: %q != %q", reqBody, exp)
+	}
+
+	conf.Database = "bad"
+	i = newTestInfluxDB(t, conf)
+	if err := i.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Write(types.NewMessage([][]byte{[]byte("cpu value=1")})); err == nil {
+		t.Error("Expected error from bad status")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -21,7 +21,9 @@
 package writer
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -40,11 +42,15 @@ type KafkaConfig struct {
 	ClientID             string   `json:"client_id" yaml:"client_id"`
 	Key                  string   `json:"key" yaml:"key"`
 	RoundRobinPartitions bool     `json:"round_robin_partitions" yaml:"round_robin_partitions"`
+	Partitioner          string   `json:"partitioner" yaml:"partitioner"`
+	Partition            string   `json:"partition" yaml:"partition"`
 	Topic                string   `json:"topic" yaml:"topic"`
 	Compression          string   `json:"compression" yaml:"compression"`
 	MaxMsgBytes          int      `json:"max_msg_bytes" yaml:"max_msg_bytes"`
 	TimeoutMS            int      `json:"timeout_ms" yaml:"timeout_ms"`
 	AckReplicas          bool     `json:"ack_replicas" yaml:"ack_replicas"`
+	MaxInFlight          int      `json:"max_in_flight" yaml:"max_in_flight"`
+	IdempotentWrite      bool     `json:"idempotent_write" yaml:"idempotent_write"`
 	TargetVersion        string   `json:"target_version" yaml:"target_version"`
 }
 
@@ -55,11 +61,15 @@ func NewKafkaConfig() KafkaConfig {
 		ClientID:             "benthos_kafka_output",
 		Key:                  "",
 		RoundRobinPartitions: false,
+		Partitioner:          "hash",
+		Partition:            "",
 		Topic:                "benthos_stream",
 		Compression:          "none",
 		MaxMsgBytes:          1000000,
 		TimeoutMS:            5000,
 		AckReplicas:          false,
+		MaxInFlight:          5,
+		IdempotentWrite:      false,
 		TargetVersion:        sarama.V0_8_2_0.String(),
 	}
 }
@@ -78,8 +88,11 @@ type Kafka struct {
 	keyBytes       []byte
 	interpolateKey bool
 
+	partitionBytes []byte
+
 	producer    sarama.SyncProducer
 	compression sarama.CompressionCodec
+	partitioner sarama.PartitionerConstructor
 }
 
 // NewKafka creates a new Kafka writer type.
@@ -92,18 +105,53 @@ func NewKafka(conf KafkaConfig, log log.Modular, stats metrics.Type) (*Kafka, er
 		return nil, err
 	}
 
+	partitionerStr := conf.Partitioner
+	if conf.RoundRobinPartitions {
+		partitionerStr = "round_robin"
+	}
+	partitioner, err := strToPartitioner(partitionerStr)
+	if err != nil {
+		return nil, err
+	}
+	var partitionBytes []byte
+	if partitionerStr == "manual" {
+		if len(conf.Partition) == 0 {
+			return nil, errors.New("a partition must be specified with the manual partitioner")
+		}
+		partitionBytes = []byte(conf.Partition)
+	}
+
 	k := Kafka{
 		log:            log.NewModule(".output.kafka"),
 		stats:          stats,
 		conf:           conf,
 		keyBytes:       keyBytes,
 		interpolateKey: interpolateKey,
+		partitionBytes: partitionBytes,
 		compression:    compression,
+		partitioner:    partitioner,
 	}
 
 	if k.version, err = sarama.ParseKafkaVersion(conf.TargetVersion); err != nil {
 		return nil, err
 	}
+	if conf.MaxInFlight < 1 {
+		return nil, errors.New("max_in_flight must be at least 1")
+	}
+	if conf.IdempotentWrite {
+		if !k.version.IsAtLeast(sarama.V0_11_0_0) {
+			return nil, errors.New("idempotent_write requires a target_version of at least 0.11.0.0")
+		}
+		if !conf.AckReplicas {
+			return nil, errors.New("idempotent_write requires ack_replicas to be enabled")
+		}
+		if conf.MaxInFlight != 1 {
+			return nil, errors.New("idempotent_write requires a max_in_flight of 1")
+		}
+	}
+	if compression == sarama.CompressionZSTD && !k.version.IsAtLeast(sarama.V2_1_0_0) {
+		return nil, errors.New("zstd compression requires a target_version of at least 2.1.0")
+	}
 
 	for _, addr := range conf.Addresses {
 		for _, splitAddr := range strings.Split(addr, ",") {
@@ -128,10 +176,26 @@ func strToCompressionCodec(str string) (sarama.CompressionCodec, error) {
 		return sarama.CompressionLZ4, nil
 	case "gzip":
 		return sarama.CompressionGZIP, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
 	}
 	return sarama.CompressionNone, fmt.Errorf("compression codec not recognised: %v", str)
 }
 
+func strToPartitioner(str string) (sarama.PartitionerConstructor, error) {
+	switch str {
+	case "hash":
+		return sarama.NewHashPartitioner, nil
+	case "random":
+		return sarama.NewRandomPartitioner, nil
+	case "round_robin":
+		return sarama.NewRoundRobinPartitioner, nil
+	case "manual":
+		return sarama.NewManualPartitioner, nil
+	}
+	return nil, fmt.Errorf("partitioner not recognised: %v", str)
+}
+
 //------------------------------------------------------------------------------
 
 // Connect attempts to establish a connection to a Kafka broker.
@@ -150,10 +214,10 @@ func (k *Kafka) Connect() error {
 	config.Producer.Timeout = time.Duration(k.conf.TimeoutMS) * time.Millisecond
 	config.Producer.Return.Errors = true
 	config.Producer.Return.Successes = true
+	config.Producer.Idempotent = k.conf.IdempotentWrite
+	config.Net.MaxOpenRequests = k.conf.MaxInFlight
 
-	if k.conf.RoundRobinPartitions {
-		config.Producer.Partitioner = sarama.NewRoundRobinPartitioner
-	}
+	config.Producer.Partitioner = k.partitioner
 
 	if k.conf.AckReplicas {
 		config.Producer.RequiredAcks = sarama.WaitForAll
@@ -170,6 +234,42 @@ func (k *Kafka) Connect() error {
 	return err
 }
 
+// producerMessage creates the producer message of a message part, where the
+// key and, when using the manual partitioner, the partition are resolved from
+// function interpolations. The metadata of the part is added as headers when the
+// target version supports them.
+func (k *Kafka) producerMessage(msg types.Message, index int) (*sarama.ProducerMessage, error) {
+	key := k.keyBytes
+	if k.interpolateKey {
+		key = text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, index), k.keyBytes)
+	}
+	pMsg := &sarama.ProducerMessage{
+		Topic: k.conf.Topic,
+		Value: sarama.ByteEncoder(msg.Get(index)),
+	}
+	if len(key) > 0 {
+		pMsg.Key = sarama.ByteEncoder(key)
+	}
+	if k.version.IsAtLeast(sarama.V0_11_0_0) {
+		msg.GetMetadata(index).Iter(func(mk, mv string) error {
+			pMsg.Headers = append(pMsg.Headers, sarama.RecordHeader{
+				Key:   []byte(mk),
+				Value: []byte(mv),
+			})
+			return nil
+		})
+	}
+	if len(k.partitionBytes) > 0 {
+		partStr := text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, index), k.partitionBytes)
+		partition, err := strconv.ParseInt(string(partStr), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse partition '%s': %v", partStr, err)
+		}
+		pMsg.Partition = int32(partition)
+	}
+	return pMsg, nil
+}
+
 // Write will attempt to write a message to Kafka, wait for acknowledgement, and
 // returns an error if applicable.
 func (k *Kafka) Write(msg types.Message) error {
@@ -178,22 +278,14 @@ func (k *Kafka) Write(msg types.Message) error {
 	}
 
 	msgs := []*sarama.ProducerMessage{}
-	for _, part := range msg.GetAll() {
+	for i, part := range msg.GetAll() {
 		if len(part) > k.conf.MaxMsgBytes {
 			k.stats.Incr("output.kafka.send.dropped.max_msg_bytes", 1)
 			continue
 		}
-
-		key := k.keyBytes
-		if k.interpolateKey {
-			key = text.ReplaceFunctionVariables(k.keyBytes)
-		}
-		nextMsg := &sarama.ProducerMessage{
-			Topic: k.conf.Topic,
-			Value: sarama.ByteEncoder(part),
-		}
-		if len(key) > 0 {
-			nextMsg.Key = sarama.ByteEncoder(key)
+		nextMsg, err := k.producerMessage(msg, i)
+		if err != nil {
+			return err
 		}
 		msgs = append(msgs, nextMsg)
 	}
@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Shopify/sarama"
+)
+
+//------------------------------------------------------------------------------
+
+func TestKafkaProducerMessage(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewKafkaConfig()
+	conf.Key = "${!metadata:user}"
+	conf.Partitioner = "manual"
+	conf.Partition = "${!metadata:partition}"
+
+	k, err := NewKafka(conf, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{[]byte("first"), []byte("second")})
+	msg.GetMetadata(0).Set("user", "foo").Set("partition", "nope")
+	msg.GetMetadata(1).Set("user", "bar").Set("partition", "3")
+
+	pMsg, err := k.producerMessage(msg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "bar", string(pMsg.Key.(sarama.ByteEncoder)); exp != act {
+		t.Errorf("Wrong key: %v != %v", act, exp)
+	}
+	if exp, act := int32(3), pMsg.Partition; exp != act {
+		t.Errorf("Wrong partition: %v != %v", act, exp)
+	}
+
+	if _, err = k.producerMessage(msg, 0); err == nil {
+		t.Error("Expected error from bad partition")
+	}
+}
+
+func TestKafkaProducerMessageHeaders(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	msg := types.NewMessage([][]byte{[]byte("first")})
+	msg.GetMetadata(0).Set("foo", "bar").Set("baz", "qux")
+
+	conf := NewKafkaConfig()
+	k, err := NewKafka(conf, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pMsg, err := k.producerMessage(msg, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pMsg.Headers) > 0 {
+		t.Errorf("Unexpected headers for old target version: %v", pMsg.Headers)
+	}
+
+	conf.TargetVersion = sarama.V0_11_0_0.String()
+	if k, err = NewKafka(conf, testLog, metrics.DudType{}); err != nil {
+		t.Fatal(err)
+	}
+	if pMsg, err = k.producerMessage(msg, 0); err != nil {
+		t.Fatal(err)
+	}
+	exp := []sarama.RecordHeader{
+		{Key: []byte("baz"), Value: []byte("qux")},
+		{Key: []byte("foo"), Value: []byte("bar")},
+	}
+	if act := pMsg.Headers; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong headers: %s != %s", act, exp)
+	}
+}
+
+func TestKafkaBadPartitioner(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewKafkaConfig()
+	conf.Partitioner = "nope"
+	if _, err := NewKafka(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad partitioner")
+	}
+
+	conf = NewKafkaConfig()
+	conf.Partitioner = "manual"
+	if _, err := NewKafka(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing partition")
+	}
+}
+
+func TestKafkaIdempotentWrite(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewKafkaConfig()
+	conf.IdempotentWrite = true
+	conf.TargetVersion = "0.11.0.0"
+	conf.AckReplicas = true
+	conf.MaxInFlight = 1
+	if _, err := NewKafka(conf, testLog, metrics.DudType{}); err != nil {
+		t.Error(err)
+	}
+
+	badConf := conf
+	badConf.TargetVersion = "0.10.2.0"
+	if _, err := NewKafka(badConf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from old target version")
+	}
+
+	badConf = conf
+	badConf.AckReplicas = false
+	if _, err := NewKafka(badConf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from disabled ack replicas")
+	}
+
+	badConf = conf
+	badConf.MaxInFlight = 5
+	if _, err := NewKafka(badConf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from max in flight")
+	}
+}
+
+func TestKafkaZSTDVersion(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewKafkaConfig()
+	conf.Compression = "zstd"
+	if _, err := NewKafka(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from old target version")
+	}
+
+	conf.TargetVersion = "2.1.0"
+	if _, err := NewKafka(conf, testLog, metrics.DudType{}); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
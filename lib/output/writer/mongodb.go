@@ -0,0 +1,257 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+//------------------------------------------------------------------------------
+
+// MongoDBWriteConcern contains the write concern settings of a MongoDB writer.
+type MongoDBWriteConcern struct {
+	W          string `json:"w" yaml:"w"`
+	J          bool   `json:"j" yaml:"j"`
+	WTimeoutMS int    `json:"w_timeout_ms" yaml:"w_timeout_ms"`
+}
+
+// MongoDBConfig is configuration for the MongoDB output type.
+type MongoDBConfig struct {
+	URL          string              `json:"url" yaml:"url"`
+	Database     string              `json:"database" yaml:"database"`
+	Collection   string              `json:"collection" yaml:"collection"`
+	Operation    string              `json:"operation" yaml:"operation"`
+	DocumentPath string              `json:"document_path" yaml:"document_path"`
+	FilterFields []string            `json:"filter_fields" yaml:"filter_fields"`
+	WriteConcern MongoDBWriteConcern `json:"write_concern" yaml:"write_concern"`
+	TimeoutMS    int                 `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewMongoDBConfig creates a new MongoDBConfig with default values.
+func NewMongoDBConfig() MongoDBConfig {
+	return MongoDBConfig{
+		URL:          "mongodb://localhost:27017",
+		Database:     "benthos",
+		Collection:   "benthos_collection",
+		Operation:    "insert",
+		DocumentPath: "",
+		FilterFields: []string{"_id"},
+		WriteConcern: MongoDBWriteConcern{
+			W:          "1",
+			J:          false,
+			WTimeoutMS: 0,
+		},
+		TimeoutMS: 5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MongoDB is a writer type that writes messages as documents of a MongoDB
+// collection.
+type MongoDB struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf     MongoDBConfig
+	dialInfo *mgo.DialInfo
+	safe     *mgo.Safe
+	docPath  []string
+
+	session *mgo.Session
+}
+
+// NewMongoDB creates a new MongoDB writer type.
+func NewMongoDB(
+	conf MongoDBConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*MongoDB, error) {
+	switch conf.Operation {
+	case "insert":
+	case "update", "upsert":
+		if len(conf.FilterFields) == 0 {
+			return nil, fmt.Errorf("operation '%v' requires filter_fields", conf.Operation)
+		}
+	default:
+		return nil, fmt.Errorf("operation not recognised: %v", conf.Operation)
+	}
+
+	dialInfo, err := mgo.ParseURL(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %v", err)
+	}
+	dialInfo.Timeout = time.Duration(conf.TimeoutMS) * time.Millisecond
+
+	safe := &mgo.Safe{
+		J:        conf.WriteConcern.J,
+		WTimeout: conf.WriteConcern.WTimeoutMS,
+	}
+	if w, err := strconv.Atoi(conf.WriteConcern.W); err == nil {
+		safe.W = w
+	} else {
+		safe.WMode = conf.WriteConcern.W
+	}
+
+	m := &MongoDB{
+		log:      log.NewModule(".output.mongodb"),
+		stats:    stats,
+		conf:     conf,
+		dialInfo: dialInfo,
+		safe:     safe,
+	}
+	if len(conf.DocumentPath) > 0 {
+		m.docPath = strings.Split(conf.DocumentPath, ".")
+	}
+	return m, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a session with a MongoDB deployment.
+func (m *MongoDB) Connect() error {
+	if m.session != nil {
+		return nil
+	}
+
+	session, err := mgo.DialWithInfo(m.dialInfo)
+	if err != nil {
+		return err
+	}
+	session.SetSafe(m.safe)
+
+	m.session = session
+	m.log.Infof("Writing documents to MongoDB collection %v.%v\n", m.conf.Database, m.conf.Collection)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// getPath walks a path of map keys from a document.
+func getPath(doc interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if doc, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// mapPart parses a message part into the document to be written and, for
+// update and upsert operations, the selector of that document.
+func (m *MongoDB) mapPart(part []byte) (map[string]interface{}, map[string]interface{}, error) {
+	var root interface{}
+	if err := bson.UnmarshalJSON(part, &root); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse document: %v", err)
+	}
+
+	docObj, exists := getPath(root, m.docPath)
+	if !exists {
+		return nil, nil, fmt.Errorf("document path '%v' not found", m.conf.DocumentPath)
+	}
+	doc, ok := docObj.(map[string]interface{})
+	if !ok {
+		return nil, nil, errors.New("document is not an object")
+	}
+	if m.conf.Operation == "insert" {
+		return doc, nil, nil
+	}
+
+	selector := map[string]interface{}{}
+	for _, field := range m.conf.FilterFields {
+		v, exists := getPath(doc, strings.Split(field, "."))
+		if !exists {
+			return nil, nil, fmt.Errorf("filter field '%v' not found in document", field)
+		}
+		selector[field] = v
+	}
+	return doc, selector, nil
+}
+
+// Write attempts to write a message by writing each part as a document within
+// a single unordered bulk operation.
+func (m *MongoDB) Write(msg types.Message) error {
+	if m.session == nil {
+		return types.ErrNotConnected
+	}
+
+	session := m.session.Copy()
+	defer session.Close()
+
+	bulk := session.DB(m.conf.Database).C(m.conf.Collection).Bulk()
+	bulk.Unordered()
+
+	if err := msg.Iter(func(i int, part []byte) error {
+		doc, selector, err := m.mapPart(part)
+		if err != nil {
+			return fmt.Errorf("part %v: %v", i, err)
+		}
+		switch m.conf.Operation {
+		case "insert":
+			bulk.Insert(doc)
+		case "update":
+			bulk.Update(selector, bson.M{"$set": doc})
+		case "upsert":
+			bulk.Upsert(selector, bson.M{"$set": doc})
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	_, err := bulk.Run()
+	if bErr, ok := err.(*mgo.BulkError); ok {
+		reasons := []string{}
+		for _, c := range bErr.Cases() {
+			reasons = append(reasons, fmt.Sprintf("%v: %v", c.Index, c.Err))
+		}
+		return fmt.Errorf("failed to write %v documents: %v", len(reasons), strings.Join(reasons, ", "))
+	}
+	return err
+}
+
+// CloseAsync shuts down the MongoDB writer and stops processing messages.
+func (m *MongoDB) CloseAsync() {
+	if m.session != nil {
+		m.session.Close()
+	}
+}
+
+// WaitForClose blocks until the MongoDB writer has closed down.
+func (m *MongoDB) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/globalsign/mgo/bson"
+)
+
+//------------------------------------------------------------------------------
+
+func newTestMongoDB(t *testing.T, conf MongoDBConfig) *MongoDB {
+	m, err := NewMongoDB(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMongoDBBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewMongoDBConfig()
+	conf.Operation = "nope"
+	if _, err := NewMongoDB(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operation")
+	}
+
+	conf = NewMongoDBConfig()
+	conf.Operation = "upsert"
+	conf.FilterFields = nil
+	if _, err := NewMongoDB(conf, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing filter fields")
+	}
+}
+
+func TestMongoDBWriteConcern(t *testing.T) {
+	conf := NewMongoDBConfig()
+	conf.WriteConcern.W = "majority"
+	conf.WriteConcern.J = true
+	conf.WriteConcern.WTimeoutMS = 100
+
+	m := newTestMongoDB(t, conf)
+	if m.safe.WMode != "majority" || m.safe.W != 0 || !m.safe.J || m.safe.WTimeout != 100 {
+		t.Errorf("Wrong write concern: %+v", m.safe)
+	}
+
+	conf.WriteConcern.W = "2"
+	m = newTestMongoDB(t, conf)
+	if m.safe.WMode != "" || m.safe.W != 2 {
+		t.Errorf("Wrong write concern: %+v", m.safe)
+	}
+}
+
+func TestMongoDBNotConnected(t *testing.T) {
+	m := newTestMongoDB(t, NewMongoDBConfig())
+	if err := m.Write(types.NewMessage([][]byte{[]byte(`{}`)})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+}
+
+func TestMongoDBMapInsert(t *testing.T) {
+	conf := NewMongoDBConfig()
+	conf.DocumentPath = "foo.bar"
+
+	m := newTestMongoDB(t, conf)
+
+	doc, selector, err := m.mapPart([]byte(`{"foo":{"bar":{"_id":{"$oid":"5bc4b1f0c1d3a3b0e3a4c1b2"},"baz":"qux"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{
+		"_id": bson.ObjectIdHex("5bc4b1f0c1d3a3b0e3a4c1b2"),
+		"baz": "qux",
+	}
+	if !reflect.DeepEqual(exp, doc) {
+		t.Errorf("Wrong document: %v != %v", doc, exp)
+	}
+	if selector != nil {
+		t.Errorf("Unexpected selector: %v", selector)
+	}
+
+	if _, _, err = m.mapPart([]byte(`{"foo":{}}`)); err == nil {
+		t.Error("Expected error from missing document path")
+	}
+	if _, _, err = m.mapPart([]byte(`not json`)); err == nil {
+		t.Error("Expected error from invalid document")
+	}
+}
+
+func TestMongoDBMapUpsert(t *testing.T) {
+	conf := NewMongoDBConfig()
+	conf.Operation = "upsert"
+	conf.FilterFields = []string{"id", "user.name"}
+
+	m := newTestMongoDB(t, conf)
+
+	doc, selector, err := m.mapPart([]byte(`{"id":"foo","user":{"name":"bar"},"value":"baz"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "baz", doc["value"]; exp != act {
+		t.Errorf("Wrong document value: %v != %v", act, exp)
+	}
+	exp := map[string]interface{}{
+		"id":        "foo",
+		"user.name": "bar",
+	}
+	if !reflect.DeepEqual(exp, selector) {
+		t.Errorf("Wrong selector: %v != %v", selector, exp)
+	}
+
+	if _, _, err = m.mapPart([]byte(`{"id":"foo"}`)); err == nil {
+		t.Error("Expected error from missing filter field")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -22,11 +22,13 @@ package writer
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
@@ -60,7 +62,11 @@ type MQTT struct {
 	urls []string
 	conf MQTTConfig
 
-	client mqtt.Client
+	topicBytes       []byte
+	interpolateTopic bool
+
+	client  mqtt.Client
+	connMut sync.RWMutex
 }
 
 // NewMQTT creates a new MQTT output type.
@@ -74,6 +80,8 @@ func NewMQTT(
 		stats: stats,
 		conf:  conf,
 	}
+	m.topicBytes = []byte(conf.Topic)
+	m.interpolateTopic = text.ContainsFunctionVariables(m.topicBytes)
 
 	for _, u := range conf.URLs {
 		for _, splitURL := range strings.Split(u, ",") {
@@ -90,15 +98,23 @@ func NewMQTT(
 
 // Connect establishes a connection to an MQTT server.
 func (m *MQTT) Connect() error {
+	m.connMut.Lock()
+	defer m.connMut.Unlock()
+
 	if m.client != nil {
 		return nil
 	}
 
+	// Reconnects are driven by the output so that lost connections are
+	// reported and retried consistently with other output types.
 	conf := mqtt.NewClientOptions().
-		SetAutoReconnect(true).
+		SetAutoReconnect(false).
 		SetConnectTimeout(time.Second).
 		SetWriteTimeout(time.Second).
-		SetClientID(m.conf.ClientID)
+		SetClientID(m.conf.ClientID).
+		SetConnectionLostHandler(func(client mqtt.Client, reason error) {
+			m.log.Errorf("Connection lost due to: %v\n", reason)
+		})
 
 	for _, u := range m.urls {
 		conf = conf.AddBroker(u)
@@ -113,6 +129,7 @@ func (m *MQTT) Connect() error {
 	}
 
 	m.client = client
+	m.log.Infof("Sending MQTT messages to topic: %v\n", m.conf.Topic)
 	return nil
 }
 
@@ -120,14 +137,30 @@ func (m *MQTT) Connect() error {
 
 // Write attempts to write a message by pushing it to an MQTT broker.
 func (m *MQTT) Write(msg types.Message) error {
-	if m.client == nil {
+	m.connMut.RLock()
+	client := m.client
+	m.connMut.RUnlock()
+
+	if client == nil {
+		return types.ErrNotConnected
+	}
+	if !client.IsConnected() {
+		m.disconnect(client)
 		return types.ErrNotConnected
 	}
 
-	for _, part := range msg.GetAll() {
-		mtok := m.client.Publish(m.conf.Topic, byte(m.conf.QoS), false, part)
+	for i, part := range msg.GetAll() {
+		topic := m.conf.Topic
+		if m.interpolateTopic {
+			topic = string(text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, i), m.topicBytes))
+		}
+		mtok := client.Publish(topic, byte(m.conf.QoS), false, part)
 		mtok.Wait()
 		if err := mtok.Error(); err != nil {
+			if err == mqtt.ErrNotConnected {
+				m.disconnect(client)
+				return types.ErrNotConnected
+			}
 			return err
 		}
 	}
@@ -135,12 +168,24 @@ func (m *MQTT) Write(msg types.Message) error {
 	return nil
 }
 
+// disconnect closes a client and clears it if it is still the active client.
+func (m *MQTT) disconnect(client mqtt.Client) {
+	m.connMut.Lock()
+	if m.client == client {
+		m.client = nil
+	}
+	m.connMut.Unlock()
+	client.Disconnect(0)
+}
+
 // CloseAsync shuts down the MQTT output and stops processing messages.
 func (m *MQTT) CloseAsync() {
+	m.connMut.Lock()
 	if m.client != nil {
 		m.client.Disconnect(0)
 		m.client = nil
 	}
+	m.connMut.Unlock()
 }
 
 // WaitForClose blocks until the MQTT output has closed down.
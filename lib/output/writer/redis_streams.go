@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/go-redis/redis"
+)
+
+//------------------------------------------------------------------------------
+
+// RedisStreamsConfig is configuration for the RedisStreams output type.
+type RedisStreamsConfig struct {
+	URL       string `json:"url" yaml:"url"`
+	Stream    string `json:"stream" yaml:"stream"`
+	BodyKey   string `json:"body_key" yaml:"body_key"`
+	MaxLength int64  `json:"max_length" yaml:"max_length"`
+}
+
+// NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
+func NewRedisStreamsConfig() RedisStreamsConfig {
+	return RedisStreamsConfig{
+		URL:       "tcp://localhost:6379",
+		Stream:    "benthos_stream",
+		BodyKey:   "body",
+		MaxLength: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// RedisStreams is an output type that adds messages as entries of a Redis
+// stream.
+type RedisStreams struct {
+	log   log.Modular
+	stats metrics.Type
+
+	url  *url.URL
+	conf RedisStreamsConfig
+
+	streamBytes []byte
+
+	client *redis.Client
+}
+
+// NewRedisStreams creates a new RedisStreams output type.
+func NewRedisStreams(
+	conf RedisStreamsConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*RedisStreams, error) {
+
+	r := &RedisStreams{
+		log:         log.NewModule(".output.redis_streams"),
+		stats:       stats,
+		conf:        conf,
+		streamBytes: []byte(conf.Stream),
+	}
+
+	var err error
+	r.url, err = url.Parse(conf.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect establishes a connection to a Redis server.
+func (r *RedisStreams) Connect() error {
+	var pass string
+	if r.url.User != nil {
+		pass, _ = r.url.User.Password()
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     r.url.Host,
+		Network:  r.url.Scheme,
+		Password: pass,
+	})
+
+	if _, err := client.Ping().Result(); err != nil {
+		return err
+	}
+
+	r.log.Infof("Adding messages to Redis stream %v at URL: %v\n", r.conf.Stream, r.conf.URL)
+
+	r.client = client
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// addArgs creates the XADD arguments of a message part, where the metadata of
+// the part is added as fields of the entry alongside its contents.
+func (r *RedisStreams) addArgs(msg types.Message, index int) *redis.XAddArgs {
+	values := map[string]interface{}{}
+	msg.GetMetadata(index).Iter(func(k, v string) error {
+		values[k] = v
+		return nil
+	})
+	values[r.conf.BodyKey] = msg.Get(index)
+
+	return &redis.XAddArgs{
+		Stream: string(text.ReplaceFunctionVariablesFor(
+			types.ExtractPart(msg, index), r.streamBytes,
+		)),
+		MaxLenApprox: r.conf.MaxLength,
+		ID:           "*",
+		Values:       values,
+	}
+}
+
+// Write attempts to write a message by adding each part as an entry of a Redis
+// stream.
+func (r *RedisStreams) Write(msg types.Message) error {
+	if r.client == nil {
+		return types.ErrNotConnected
+	}
+
+	for i := 0; i < msg.Len(); i++ {
+		if err := r.client.XAdd(r.addArgs(msg, i)).Err(); err != nil {
+			r.disconnect()
+			r.log.Errorf("Error from redis: %v\n", err)
+			return types.ErrNotConnected
+		}
+	}
+
+	return nil
+}
+
+// disconnect safely closes a connection to a Redis server.
+func (r *RedisStreams) disconnect() error {
+	if r.client != nil {
+		err := r.client.Close()
+		r.client = nil
+		return err
+	}
+	return nil
+}
+
+// CloseAsync shuts down the RedisStreams output and stops processing messages.
+func (r *RedisStreams) CloseAsync() {
+	r.disconnect()
+}
+
+// WaitForClose blocks until the RedisStreams output has closed down.
+func (r *RedisStreams) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
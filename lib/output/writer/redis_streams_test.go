@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestRedisStreamsAddArgs(t *testing.T) {
+	conf := NewRedisStreamsConfig()
+	conf.Stream = "${!metadata:target}_stream"
+	conf.BodyKey = "data"
+	conf.MaxLength = 100
+
+	r, err := NewRedisStreams(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	msg.GetMetadata(0).Set("target", "first")
+	msg.GetMetadata(1).Set("target", "second").Set("baz", "qux")
+
+	args := r.addArgs(msg, 0)
+	if exp, act := "first_stream", args.Stream; exp != act {
+		t.Errorf("Wrong stream: %v != %v", act, exp)
+	}
+	if exp, act := int64(100), args.MaxLenApprox; exp != act {
+		t.Errorf("Wrong max length: %v != %v", act, exp)
+	}
+	if exp, act := "foo", string(args.Values["data"].([]byte)); exp != act {
+		t.Errorf("Wrong body: %v != %v", act, exp)
+	}
+	if exp, act := 2, len(args.Values); exp != act {
+		t.Errorf("Wrong count of values: %v != %v", act, exp)
+	}
+
+	args = r.addArgs(msg, 1)
+	if exp, act := "second_stream", args.Stream; exp != act {
+		t.Errorf("Wrong stream: %v != %v", act, exp)
+	}
+	if exp, act := "qux", args.Values["baz"]; exp != act {
+		t.Errorf("Wrong metadata value: %v != %v", act, exp)
+	}
+	if exp, act := "bar", string(args.Values["data"].([]byte)); exp != act {
+		t.Errorf("Wrong body: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/socket"
+)
+
+//------------------------------------------------------------------------------
+
+// SocketConfig is configuration for the Socket output type.
+type SocketConfig struct {
+	Network   string `json:"network" yaml:"network"`
+	Address   string `json:"address" yaml:"address"`
+	Framing   string `json:"framing" yaml:"framing"`
+	Delimiter string `json:"delimiter" yaml:"delimiter"`
+	TimeoutMS int64  `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewSocketConfig creates a new SocketConfig with default values.
+func NewSocketConfig() SocketConfig {
+	return SocketConfig{
+		Network:   "tcp",
+		Address:   "localhost:4198",
+		Framing:   "lines",
+		Delimiter: "",
+		TimeoutMS: 5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Socket is an output type that writes raw data to a TCP, UDP or unix domain
+// socket.
+type Socket struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf      SocketConfig
+	network   string
+	address   string
+	delimiter []byte
+	timeout   time.Duration
+
+	conn    net.Conn
+	connMut sync.Mutex
+}
+
+// NewSocket creates a new Socket output type.
+func NewSocket(
+	conf SocketConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*Socket, error) {
+	network, address, err := socket.Resolve(conf.Network, conf.Address)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Framing {
+	case "lines", "length_prefixed":
+	default:
+		return nil, fmt.Errorf("socket framing not recognised: %v", conf.Framing)
+	}
+	delim := conf.Delimiter
+	if len(delim) == 0 {
+		delim = "\n"
+	}
+	return &Socket{
+		log:       log.NewModule(".output.socket"),
+		stats:     stats,
+		conf:      conf,
+		network:   network,
+		address:   address,
+		delimiter: []byte(delim),
+		timeout:   time.Millisecond * time.Duration(conf.TimeoutMS),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to dial the target address.
+func (s *Socket) Connect() error {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	s.log.Infof("Sending messages over %v to: %v\n", s.network, s.address)
+	return nil
+}
+
+// socketBufferPool contains buffers for framing messages, which are reused
+// between writes in order to avoid growing a new buffer for each message.
+var socketBufferPool = sync.Pool{
+	New: func() interface{} {
+		return &bytes.Buffer{}
+	},
+}
+
+// frame writes a message part to a buffer along with its framing.
+func (s *Socket) frame(buf *bytes.Buffer, part []byte) {
+	if s.conf.Framing == "length_prefixed" {
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(part)))
+		buf.Write(size[:])
+		buf.Write(part)
+		return
+	}
+	buf.Write(part)
+	buf.Write(s.delimiter)
+}
+
+// Write attempts to write a message to the socket. Each part of the message is
+// written as a separate frame, and over datagram sockets each frame is a
+// separate datagram.
+func (s *Socket) Write(msg types.Message) error {
+	s.connMut.Lock()
+	conn := s.conn
+	s.connMut.Unlock()
+
+	if conn == nil {
+		return types.ErrNotConnected
+	}
+
+	buf := socketBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer socketBufferPool.Put(buf)
+
+	var err error
+	for i := 0; i < msg.Len() && err == nil; i++ {
+		s.frame(buf, msg.Get(i))
+		if socket.IsPacket(s.network) {
+			_, err = conn.Write(buf.Bytes())
+			buf.Reset()
+		}
+	}
+	if err == nil && buf.Len() > 0 {
+		_, err = conn.Write(buf.Bytes())
+	}
+	if err != nil {
+		s.log.Errorf("Failed to write message: %v\n", err)
+		s.disconnect()
+		return types.ErrNotConnected
+	}
+	return nil
+}
+
+// disconnect safely closes the socket connection.
+func (s *Socket) disconnect() error {
+	s.connMut.Lock()
+	defer s.connMut.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// CloseAsync shuts down the Socket output and stops processing messages.
+func (s *Socket) CloseAsync() {
+	s.disconnect()
+}
+
+// WaitForClose blocks until the Socket output has closed down.
+func (s *Socket) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func testSocketStream(t *testing.T, conf SocketConfig, network, address, exp string) {
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network == "unix" {
+		conf.Address = "unix://" + address
+	} else {
+		conf.Address = ln.Addr().String()
+	}
+	defer ln.Close()
+
+	resChan := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			resChan <- ""
+			return
+		}
+		defer conn.Close()
+		b, err := ioutil.ReadAll(conn)
+		if err != nil {
+			t.Error(err)
+		}
+		resChan <- string(b)
+	}()
+
+	s, err := NewSocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Write(types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})); err != nil {
+		t.Error(err)
+	}
+	if err = s.Write(types.NewMessage([][]byte{[]byte("baz")})); err != nil {
+		t.Error(err)
+	}
+	s.CloseAsync()
+
+	if act := <-resChan; exp != act {
+		t.Errorf("Wrong data received: %q != %q", act, exp)
+	}
+}
+
+func TestSocketTCPLines(t *testing.T) {
+	testSocketStream(t, NewSocketConfig(), "tcp", "localhost:0", "foo\nbar\nbaz\n")
+}
+
+func TestSocketTCPCustomDelim(t *testing.T) {
+	conf := NewSocketConfig()
+	conf.Delimiter = "||"
+	testSocketStream(t, conf, "tcp", "localhost:0", "foo||bar||baz||")
+}
+
+func TestSocketTCPLengthPrefixed(t *testing.T) {
+	conf := NewSocketConfig()
+	conf.Framing = "length_prefixed"
+	testSocketStream(t, conf, "tcp", "localhost:0", "\x00\x00\x00\x03foo\x00\x00\x00\x03bar\x00\x00\x00\x03baz")
+}
+
+func TestSocketUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benthos_socket_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testSocketStream(t, NewSocketConfig(), "unix", filepath.Join(dir, "benthos.sock"), "foo\nbar\nbaz\n")
+}
+
+func TestSocketUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conf := NewSocketConfig()
+	conf.Network = "udp"
+	conf.Address = conn.LocalAddr().String()
+
+	s, err := NewSocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.CloseAsync()
+
+	if err = s.Write(types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 1024)
+	for _, exp := range []string{"foo\n", "bar\n"} {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := string(buf[:n]); exp != act {
+			t.Errorf("Wrong datagram: %q != %q", act, exp)
+		}
+	}
+}
+
+func TestSocketBadConfig(t *testing.T) {
+	conf := NewSocketConfig()
+	conf.Network = "nope"
+	if _, err := NewSocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad network")
+	}
+
+	conf = NewSocketConfig()
+	conf.Framing = "nope"
+	if _, err := NewSocket(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad framing")
+	}
+}
+
+//------------------------------------------------------------------------------
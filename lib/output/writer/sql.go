@@ -0,0 +1,206 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+
+	// SQL drivers supported by the sql output.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+//------------------------------------------------------------------------------
+
+// SQLConfig is configuration for the SQL output type.
+type SQLConfig struct {
+	Driver            string   `json:"driver" yaml:"driver"`
+	DSN               string   `json:"dsn" yaml:"dsn"`
+	Query             string   `json:"query" yaml:"query"`
+	Args              []string `json:"args" yaml:"args"`
+	UseTransaction    bool     `json:"use_transaction" yaml:"use_transaction"`
+	MaxOpenConns      int      `json:"max_open_connections" yaml:"max_open_connections"`
+	MaxIdleConns      int      `json:"max_idle_connections" yaml:"max_idle_connections"`
+	ConnMaxLifetimeMS int      `json:"connection_max_lifetime_ms" yaml:"connection_max_lifetime_ms"`
+}
+
+// NewSQLConfig creates a new SQLConfig with default values.
+func NewSQLConfig() SQLConfig {
+	return SQLConfig{
+		Driver:            "mysql",
+		DSN:               "",
+		Query:             "INSERT INTO footable (foo, bar, baz) VALUES (?, ?, ?);",
+		Args:              []string{},
+		UseTransaction:    false,
+		MaxOpenConns:      0,
+		MaxIdleConns:      2,
+		ConnMaxLifetimeMS: 0,
+	}
+}
+
+// sqlDrivers is the set of drivers supported by the SQL writer.
+var sqlDrivers = map[string]struct{}{
+	"mysql":    {},
+	"postgres": {},
+}
+
+//------------------------------------------------------------------------------
+
+// SQL is a writer type that executes a prepared statement for each message
+// part.
+type SQL struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf SQLConfig
+	args [][]byte
+
+	db   *sql.DB
+	stmt *sql.Stmt
+}
+
+// NewSQL creates a new SQL writer type.
+func NewSQL(
+	conf SQLConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*SQL, error) {
+	if _, exists := sqlDrivers[conf.Driver]; !exists {
+		return nil, fmt.Errorf("driver not supported: %v", conf.Driver)
+	}
+
+	s := &SQL{
+		log:   log.NewModule(".output.sql"),
+		stats: stats,
+		conf:  conf,
+	}
+	for _, arg := range conf.Args {
+		s.args = append(s.args, []byte(arg))
+	}
+	return s, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect opens a connection pool to the database and prepares the statement.
+func (s *SQL) Connect() error {
+	if s.db != nil {
+		return nil
+	}
+
+	db, err := sql.Open(s.conf.Driver, s.conf.DSN)
+	if err != nil {
+		return err
+	}
+	if err = s.connect(db); err != nil {
+		db.Close()
+		return err
+	}
+
+	s.log.Infof("Executing statements on %v database\n", s.conf.Driver)
+	return nil
+}
+
+// connect configures the pool of a database and prepares the statement.
+func (s *SQL) connect(db *sql.DB) error {
+	db.SetMaxOpenConns(s.conf.MaxOpenConns)
+	db.SetMaxIdleConns(s.conf.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(s.conf.ConnMaxLifetimeMS) * time.Millisecond)
+
+	if err := db.Ping(); err != nil {
+		return err
+	}
+	stmt, err := db.Prepare(s.conf.Query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %v", err)
+	}
+
+	s.db = db
+	s.stmt = stmt
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// bindArgs resolves the interpolated arguments of the statement for a message
+// part.
+func (s *SQL) bindArgs(msg types.Message, index int) []interface{} {
+	partMsg := types.ExtractPart(msg, index)
+	values := make([]interface{}, len(s.args))
+	for i, arg := range s.args {
+		values[i] = string(text.ReplaceFunctionVariablesFor(partMsg, arg))
+	}
+	return values
+}
+
+// Write attempts to write a message by executing the statement for each part.
+// When use_transaction is enabled the statements of a message are executed
+// within a single transaction.
+func (s *SQL) Write(msg types.Message) error {
+	if s.db == nil {
+		return types.ErrNotConnected
+	}
+
+	if !s.conf.UseTransaction {
+		return msg.Iter(func(i int, _ []byte) error {
+			_, err := s.stmt.Exec(s.bindArgs(msg, i)...)
+			return err
+		})
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	txStmt := tx.Stmt(s.stmt)
+	if err = msg.Iter(func(i int, _ []byte) error {
+		_, err := txStmt.Exec(s.bindArgs(msg, i)...)
+		return err
+	}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// CloseAsync shuts down the SQL writer and stops processing messages.
+func (s *SQL) CloseAsync() {
+	if s.stmt != nil {
+		s.stmt.Close()
+	}
+	if s.db != nil {
+		s.db.Close()
+	}
+}
+
+// WaitForClose blocks until the SQL writer has closed down.
+func (s *SQL) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newTestSQL(t *testing.T, conf SQLConfig) (*SQL, sqlmock.Sqlmock) {
+	s, err := NewSQL(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectPrepare("INSERT INTO foo")
+	if err = s.connect(db); err != nil {
+		t.Fatal(err)
+	}
+	return s, mock
+}
+
+func newTestSQLMessage() types.Message {
+	msg := types.NewMessage([][]byte{
+		[]byte(`{"id":"1"}`),
+		[]byte(`{"id":"2"}`),
+	})
+	msg.GetMetadata(0).Set("topic", "first")
+	msg.GetMetadata(1).Set("topic", "second")
+	return msg
+}
+
+func TestSQLBadDriver(t *testing.T) {
+	conf := NewSQLConfig()
+	conf.Driver = "nope"
+	if _, err := NewSQL(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad driver")
+	}
+}
+
+func TestSQLNotConnected(t *testing.T) {
+	s, err := NewSQL(NewSQLConfig(), log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrNotConnected {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrNotConnected)
+	}
+}
+
+func TestSQLWrite(t *testing.T) {
+	conf := NewSQLConfig()
+	conf.Query = "INSERT INTO foo (id, topic) VALUES (?, ?)"
+	conf.Args = []string{"${!json_field:id}", "${!metadata:topic}"}
+
+	s, mock := newTestSQL(t, conf)
+
+	mock.ExpectExec("INSERT INTO foo").WithArgs("1", "first").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO foo").WithArgs("2", "second").WillReturnResult(sqlmock.NewResult(2, 1))
+
+	if err := s.Write(newTestSQLMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLWriteTransaction(t *testing.T) {
+	conf := NewSQLConfig()
+	conf.Query = "INSERT INTO foo (id, topic) VALUES (?, ?)"
+	conf.Args = []string{"${!json_field:id}", "${!metadata:topic}"}
+	conf.UseTransaction = true
+
+	s, mock := newTestSQL(t, conf)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO foo").WithArgs("1", "first").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO foo").WithArgs("2", "second").WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	if err := s.Write(newTestSQLMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSQLWriteTransactionRollback(t *testing.T) {
+	conf := NewSQLConfig()
+	conf.Query = "INSERT INTO foo (id, topic) VALUES (?, ?)"
+	conf.Args = []string{"${!json_field:id}", "${!metadata:topic}"}
+	conf.UseTransaction = true
+
+	s, mock := newTestSQL(t, conf)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO foo").WithArgs("1", "first").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO foo").WithArgs("2", "second").WillReturnError(errors.New("nope"))
+	mock.ExpectRollback()
+
+	if err := s.Write(newTestSQLMessage()); err == nil {
+		t.Error("Expected error")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/roundtrip"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// SyncResponse is a writer implementation that adds messages to the result
+// stores of the inputs that they originated from.
+type SyncResponse struct {
+	log log.Modular
+
+	mNoStore metrics.StatCounter
+}
+
+// NewSyncResponse creates a new SyncResponse writer.Type.
+func NewSyncResponse(log log.Modular, stats metrics.Type) *SyncResponse {
+	return &SyncResponse{
+		log:      log.NewModule(".output.sync_response"),
+		mNoStore: stats.GetCounter("output.sync_response.no_store"),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Connect is a noop.
+func (s *SyncResponse) Connect() error {
+	s.log.Infoln("Sending messages as synchronous responses")
+	return nil
+}
+
+// Write a message as the response of the inputs it originated from.
+func (s *SyncResponse) Write(msg types.Message) error {
+	if roundtrip.SetAsResponse(msg) == 0 {
+		s.mNoStore.Incr(1)
+		s.log.Debugln("Message has no origin awaiting a response")
+	}
+	return nil
+}
+
+// CloseAsync is a noop.
+func (s *SyncResponse) CloseAsync() {
+}
+
+// WaitForClose is a noop.
+func (s *SyncResponse) WaitForClose(time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,304 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// WebHDFSConfig is configuration for the WebHDFS output type.
+type WebHDFSConfig struct {
+	URL           string `json:"url" yaml:"url"`
+	User          string `json:"user" yaml:"user"`
+	ProxyUser     string `json:"proxy_user" yaml:"proxy_user"`
+	Path          string `json:"path" yaml:"path"`
+	Delimiter     string `json:"delimiter" yaml:"delimiter"`
+	RollSizeBytes int64  `json:"roll_size_bytes" yaml:"roll_size_bytes"`
+	RollPeriodMS  int    `json:"roll_period_ms" yaml:"roll_period_ms"`
+	TimeoutMS     int    `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewWebHDFSConfig creates a new WebHDFSConfig with default values.
+func NewWebHDFSConfig() WebHDFSConfig {
+	return WebHDFSConfig{
+		URL:           "http://localhost:50070",
+		User:          "benthos",
+		ProxyUser:     "",
+		Path:          "/benthos/${!timestamp_unix_nano}.txt",
+		Delimiter:     "\n",
+		RollSizeBytes: 0,
+		RollPeriodMS:  0,
+		TimeoutMS:     5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// errWebHDFSNotFound is returned when an operation targets a file that does not
+// exist.
+var errWebHDFSNotFound = errors.New("file not found")
+
+// WebHDFS is a writer type that appends messages to files in HDFS via the
+// WebHDFS REST API.
+type WebHDFS struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf       WebHDFSConfig
+	baseURL    string
+	path       []byte
+	delim      []byte
+	rollPeriod time.Duration
+
+	currentPath    string
+	currentSize    int64
+	currentStarted time.Time
+
+	client *http.Client
+}
+
+// NewWebHDFS creates a new WebHDFS writer type.
+func NewWebHDFS(
+	conf WebHDFSConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*WebHDFS, error) {
+	if len(conf.Path) == 0 {
+		return nil, errors.New("a path must be specified")
+	}
+	return &WebHDFS{
+		log:        log.NewModule(".output.webhdfs"),
+		stats:      stats,
+		conf:       conf,
+		baseURL:    strings.TrimSuffix(conf.URL, "/") + "/webhdfs/v1",
+		path:       []byte(conf.Path),
+		delim:      []byte(conf.Delimiter),
+		rollPeriod: time.Duration(conf.RollPeriodMS) * time.Millisecond,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect creates the HTTP client of the writer and checks that the namenode
+// is reachable.
+func (w *WebHDFS) Connect() error {
+	if w.client != nil {
+		return nil
+	}
+	client := &http.Client{
+		Timeout: time.Duration(w.conf.TimeoutMS) * time.Millisecond,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// Redirects are followed manually in order to send data only to
+			// the datanode.
+			return http.ErrUseLastResponse
+		},
+	}
+
+	res, err := client.Get(w.opURL("/", "GETFILESTATUS", nil))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("namenode returned status %v", res.StatusCode)
+	}
+
+	w.client = client
+	w.log.Infof("Writing files to WebHDFS at URL: %v\n", w.conf.URL)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// opURL creates the URL of an operation on a path.
+func (w *WebHDFS) opURL(path, op string, params url.Values) string {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("op", op)
+	params.Set("user.name", w.conf.User)
+	if len(w.conf.ProxyUser) > 0 {
+		params.Set("doas", w.conf.ProxyUser)
+	}
+	return w.baseURL + path + "?" + params.Encode()
+}
+
+// remoteError parses the error of a failed WebHDFS response.
+func remoteError(res *http.Response) error {
+	body, _ := ioutil.ReadAll(res.Body)
+
+	var remote struct {
+		RemoteException struct {
+			Exception string `json:"exception"`
+			Message   string `json:"message"`
+		} `json:"RemoteException"`
+	}
+	if json.Unmarshal(body, &remote) == nil && len(remote.RemoteException.Exception) > 0 {
+		if remote.RemoteException.Exception == "FileNotFoundException" {
+			return errWebHDFSNotFound
+		}
+		return fmt.Errorf("%v: %v", remote.RemoteException.Exception, remote.RemoteException.Message)
+	}
+	return fmt.Errorf("request returned status %v: %s", res.StatusCode, bytes.TrimSpace(body))
+}
+
+// do performs a data operation, where the namenode redirects the request to a
+// datanode that receives the data.
+func (w *WebHDFS) do(method, path, op string, params url.Values, data []byte) error {
+	req, err := http.NewRequest(method, w.opURL(path, op, params), nil)
+	if err != nil {
+		return err
+	}
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusTemporaryRedirect {
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return errWebHDFSNotFound
+		}
+		return remoteError(res)
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+
+	if req, err = http.NewRequest(method, res.Header.Get("Location"), bytes.NewReader(data)); err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if res, err = w.client.Do(req); err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		return remoteError(res)
+	}
+	return nil
+}
+
+// appendFile appends data to a file, creating it if it does not exist.
+func (w *WebHDFS) appendFile(path string, data []byte) error {
+	err := w.do("POST", path, "APPEND", nil, data)
+	if err == errWebHDFSNotFound {
+		err = w.do("PUT", path, "CREATE", url.Values{"overwrite": []string{"false"}}, data)
+	}
+	return err
+}
+
+// shouldRoll returns true if the current file should be rolled.
+func (w *WebHDFS) shouldRoll() bool {
+	if len(w.currentPath) == 0 {
+		return true
+	}
+	if w.conf.RollSizeBytes > 0 && w.currentSize >= w.conf.RollSizeBytes {
+		return true
+	}
+	return w.rollPeriod > 0 && time.Since(w.currentStarted) >= w.rollPeriod
+}
+
+// Write attempts to write a message by appending each part, followed by the
+// delimiter, to a file.
+//
+// When rolling is disabled the path is resolved for each part, and the parts
+// of a message are grouped into a single append per file. Otherwise the path
+// is only resolved when a new file is started, and parts are appended to the
+// current file until it is rolled.
+func (w *WebHDFS) Write(msg types.Message) error {
+	if w.client == nil {
+		return types.ErrNotConnected
+	}
+
+	rolling := w.conf.RollSizeBytes > 0 || w.rollPeriod > 0
+
+	var paths []string
+	contents := map[string]*bytes.Buffer{}
+
+	if err := msg.Iter(func(i int, part []byte) error {
+		var path string
+		if rolling {
+			if w.shouldRoll() {
+				// Flush the parts of the previous file before rolling.
+				if buf, exists := contents[w.currentPath]; exists {
+					if err := w.appendFile(w.currentPath, buf.Bytes()); err != nil {
+						return err
+					}
+					delete(contents, w.currentPath)
+					paths = paths[:0]
+				}
+				w.currentPath = string(text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, i), w.path))
+				w.currentSize = 0
+				w.currentStarted = time.Now()
+			}
+			path = w.currentPath
+			w.currentSize += int64(len(part) + len(w.delim))
+		} else {
+			path = string(text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, i), w.path))
+		}
+
+		buf, exists := contents[path]
+		if !exists {
+			buf = &bytes.Buffer{}
+			contents[path] = buf
+			paths = append(paths, path)
+		}
+		buf.Write(part)
+		buf.Write(w.delim)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err := w.appendFile(path, contents[path].Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseAsync shuts down the WebHDFS writer and stops processing messages.
+func (w *WebHDFS) CloseAsync() {
+}
+
+// WaitForClose blocks until the WebHDFS writer has closed down.
+func (w *WebHDFS) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
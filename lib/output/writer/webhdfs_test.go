@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// fakeWebHDFS emulates a namenode that redirects data operations to a datanode
+// served from the same address.
+type fakeWebHDFS struct {
+	sync.Mutex
+	files map[string]string
+	users []string
+}
+
+func (f *fakeWebHDFS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/datanode") {
+		path := strings.TrimPrefix(r.URL.Path, "/datanode")
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Query().Get("op") {
+		case "CREATE":
+			f.files[path] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		case "APPEND":
+			f.files[path] += string(body)
+			w.WriteHeader(http.StatusOK)
+		}
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/webhdfs/v1")
+	query := r.URL.Query()
+	f.users = append(f.users, query.Get("user.name")+":"+query.Get("doas"))
+
+	switch query.Get("op") {
+	case "GETFILESTATUS":
+		w.Write([]byte(`{"FileStatus":{}}`))
+		return
+	case "APPEND":
+		if _, exists := f.files[path]; !exists {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"RemoteException":{"exception":"FileNotFoundException","message":"nope"}}`))
+			return
+		}
+	case "CREATE":
+		if query.Get("overwrite") != "false" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+	w.Header().Set("Location", "http://"+r.Host+"/datanode"+path+"?"+r.URL.RawQuery)
+	w.WriteHeader(http.StatusTemporaryRedirect)
+}
+
+func newTestWebHDFS(t *testing.T, conf WebHDFSConfig) (*WebHDFS, *fakeWebHDFS, func()) {
+	fake := &fakeWebHDFS{files: map[string]string{}}
+	server := httptest.NewServer(fake)
+
+	conf.URL = server.URL
+	w, err := NewWebHDFS(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	return w, fake, server.Close
+}
+
+func TestWebHDFSAppendByPath(t *testing.T) {
+	conf := NewWebHDFSConfig()
+	conf.Path = "/data/${!metadata:topic}.txt"
+	conf.ProxyUser = "alice"
+
+	w, fake, done := newTestWebHDFS(t, conf)
+	defer done()
+
+	msg := types.NewMessage([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	})
+	msg.GetMetadata(0).Set("topic", "a")
+	msg.GetMetadata(1).Set("topic", "b")
+	msg.GetMetadata(2).Set("topic", "a")
+
+	for i := 0; i < 2; i++ {
+		if err := w.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := map[string]string{
+		"/data/a.txt": "foo\nbaz\nfoo\nbaz\n",
+		"/data/b.txt": "bar\nbar\n",
+	}
+	if !reflect.DeepEqual(exp, fake.files) {
+		t.Errorf("Wrong files: %v != %v", fake.files, exp)
+	}
+	for _, u := range fake.users {
+		if u != "benthos:alice" {
+			t.Errorf("Wrong user: %v", u)
+		}
+	}
+}
+
+func TestWebHDFSRollBySize(t *testing.T) {
+	conf := NewWebHDFSConfig()
+	conf.Path = "/data/${!count:webhdfs_roll}.txt"
+	conf.RollSizeBytes = 8
+
+	w, fake, done := newTestWebHDFS(t, conf)
+	defer done()
+
+	if err := w.Write(types.NewMessage([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(types.NewMessage([][]byte{
+		[]byte("qux"),
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := map[string]string{
+		"/data/1.txt": "foo\nbar\n",
+		"/data/2.txt": "baz\nqux\n",
+	}
+	if !reflect.DeepEqual(exp, fake.files) {
+		t.Errorf("Wrong files: %v != %v", fake.files, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -36,7 +36,8 @@ import (
 
 // WebsocketConfig is configuration for the Websocket output type.
 type WebsocketConfig struct {
-	URL         string `json:"url" yaml:"url"`
+	URL         string                `json:"url" yaml:"url"`
+	Server      WebsocketServerConfig `json:"server" yaml:"server"`
 	auth.Config `json:",inline" yaml:",inline"`
 }
 
@@ -44,6 +45,7 @@ type WebsocketConfig struct {
 func NewWebsocketConfig() WebsocketConfig {
 	return WebsocketConfig{
 		URL:    "ws://localhost:4195/post/ws",
+		Server: NewWebsocketServerConfig(),
 		Config: auth.NewConfig(),
 	}
 }
@@ -0,0 +1,276 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/gorilla/websocket"
+)
+
+//------------------------------------------------------------------------------
+
+// WebsocketServerConfig is configuration for the broadcast server mode of the
+// Websocket output type.
+type WebsocketServerConfig struct {
+	Enabled          bool   `json:"enabled" yaml:"enabled"`
+	Address          string `json:"address" yaml:"address"`
+	Path             string `json:"path" yaml:"path"`
+	ClientBufferSize int    `json:"client_buffer_size" yaml:"client_buffer_size"`
+	EvictSlowClients bool   `json:"evict_slow_clients" yaml:"evict_slow_clients"`
+	WriteTimeoutMS   int64  `json:"write_timeout_ms" yaml:"write_timeout_ms"`
+}
+
+// NewWebsocketServerConfig creates a new WebsocketServerConfig with default
+// values.
+func NewWebsocketServerConfig() WebsocketServerConfig {
+	return WebsocketServerConfig{
+		Enabled:          false,
+		Address:          "0.0.0.0:4196",
+		Path:             "/",
+		ClientBufferSize: 100,
+		EvictSlowClients: true,
+		WriteTimeoutMS:   5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// websocketClient is a client connected to a WebsocketServer along with its
+// queue of pending message parts.
+type websocketClient struct {
+	conn      *websocket.Conn
+	sendChan  chan []byte
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func (c *websocketClient) close() {
+	c.closeOnce.Do(func() {
+		close(c.closeChan)
+		c.conn.Close()
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// WebsocketServer is an output type that runs a websocket server and
+// broadcasts each message to all connected clients.
+type WebsocketServer struct {
+	log   log.Modular
+	stats metrics.Type
+
+	conf         WebsocketServerConfig
+	writeTimeout time.Duration
+
+	server   *http.Server
+	listener net.Listener
+
+	clientsMut sync.Mutex
+	clients    map[*websocketClient]struct{}
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+
+	mClients metrics.StatGauge
+	mEvicted metrics.StatCounter
+}
+
+// NewWebsocketServer creates a new WebsocketServer output type.
+func NewWebsocketServer(
+	conf WebsocketServerConfig,
+	log log.Modular,
+	stats metrics.Type,
+) (*WebsocketServer, error) {
+	w := &WebsocketServer{
+		log:          log.NewModule(".output.websocket"),
+		stats:        stats,
+		conf:         conf,
+		writeTimeout: time.Millisecond * time.Duration(conf.WriteTimeoutMS),
+		clients:      map[*websocketClient]struct{}{},
+		closeChan:    make(chan struct{}),
+		mClients:     stats.GetGauge("output.websocket.server.clients"),
+		mEvicted:     stats.GetCounter("output.websocket.server.evicted"),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(conf.Path, w.wsHandler)
+	w.server = &http.Server{Addr: conf.Address, Handler: mux}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (w *WebsocketServer) addClient(c *websocketClient) {
+	w.clientsMut.Lock()
+	w.clients[c] = struct{}{}
+	w.mClients.Gauge(int64(len(w.clients)))
+	w.clientsMut.Unlock()
+}
+
+func (w *WebsocketServer) removeClient(c *websocketClient) {
+	c.close()
+	w.clientsMut.Lock()
+	delete(w.clients, c)
+	w.mClients.Gauge(int64(len(w.clients)))
+	w.clientsMut.Unlock()
+}
+
+func (w *WebsocketServer) wsHandler(rw http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+
+	conn, err := upgrader.Upgrade(rw, r, nil)
+	if err != nil {
+		w.log.Warnf("Websocket request failed: %v\n", err)
+		return
+	}
+
+	c := &websocketClient{
+		conn:      conn,
+		sendChan:  make(chan []byte, w.conf.ClientBufferSize),
+		closeChan: make(chan struct{}),
+	}
+	w.addClient(c)
+	defer w.removeClient(c)
+
+	// Drain any frames sent by the client in order to process control frames
+	// and detect disconnects.
+	go func() {
+		for {
+			if _, _, rerr := conn.NextReader(); rerr != nil {
+				c.close()
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case part := <-c.sendChan:
+			if w.writeTimeout > 0 {
+				conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+			}
+			if err = conn.WriteMessage(websocket.BinaryMessage, part); err != nil {
+				w.log.Debugf("Failed to write to websocket client: %v\n", err)
+				return
+			}
+		case <-c.closeChan:
+			return
+		case <-w.closeChan:
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Connect starts the websocket server.
+func (w *WebsocketServer) Connect() error {
+	w.clientsMut.Lock()
+	defer w.clientsMut.Unlock()
+
+	if w.listener != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", w.conf.Address)
+	if err != nil {
+		return err
+	}
+	w.listener = listener
+
+	go func() {
+		if serr := w.server.Serve(listener); serr != http.ErrServerClosed {
+			w.log.Errorf("Server error: %v\n", serr)
+		}
+	}()
+
+	w.log.Infof("Broadcasting websocket messages at: ws://%v%v\n", listener.Addr(), w.conf.Path)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// Write broadcasts each part of a message to all connected clients. When a
+// client is too slow to keep up it is either evicted or the write blocks until
+// the client has room for the message.
+func (w *WebsocketServer) Write(msg types.Message) error {
+	w.clientsMut.Lock()
+	clients := make([]*websocketClient, 0, len(w.clients))
+	for c := range w.clients {
+		clients = append(clients, c)
+	}
+	w.clientsMut.Unlock()
+
+	for _, c := range clients {
+	partLoop:
+		for _, part := range msg.GetAll() {
+			if w.conf.EvictSlowClients {
+				select {
+				case c.sendChan <- part:
+				default:
+					w.log.Warnf("Evicting slow websocket client: %v\n", c.conn.RemoteAddr())
+					w.mEvicted.Incr(1)
+					w.removeClient(c)
+					break partLoop
+				}
+				continue
+			}
+			select {
+			case c.sendChan <- part:
+			case <-c.closeChan:
+				break partLoop
+			case <-w.closeChan:
+				return types.ErrTypeClosed
+			}
+		}
+	}
+	return nil
+}
+
+// CloseAsync shuts down the WebsocketServer output and disconnects all
+// clients.
+func (w *WebsocketServer) CloseAsync() {
+	w.closeOnce.Do(func() {
+		close(w.closeChan)
+		w.server.Shutdown(context.Background())
+
+		w.clientsMut.Lock()
+		for c := range w.clients {
+			c.close()
+		}
+		w.clientsMut.Unlock()
+	})
+}
+
+// WaitForClose blocks until the WebsocketServer output has closed down.
+func (w *WebsocketServer) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/gorilla/websocket"
+)
+
+func awaitWebsocketClients(t *testing.T, w *WebsocketServer, n int) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		w.clientsMut.Lock()
+		count := len(w.clients)
+		w.clientsMut.Unlock()
+		if count == n {
+			return
+		}
+		<-time.After(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %v clients", n)
+}
+
+func TestWebsocketServerBroadcast(t *testing.T) {
+	conf := NewWebsocketServerConfig()
+	conf.Enabled = true
+	conf.Address = "localhost:0"
+	conf.Path = "/stream"
+
+	w, err := NewWebsocketServer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.CloseAsync()
+
+	if err = w.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	url := fmt.Sprintf("ws://%v/stream", w.listener.Addr())
+	var clients []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		c, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		clients = append(clients, c)
+	}
+	awaitWebsocketClients(t, w, 2)
+
+	if err = w.Write(types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, c := range clients {
+		c.SetReadDeadline(time.Now().Add(time.Second))
+		for _, exp := range []string{"foo", "bar"} {
+			_, act, err := c.ReadMessage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exp != string(act) {
+				t.Errorf("Wrong message for client %v: %s != %v", i, act, exp)
+			}
+		}
+	}
+
+	clients[0].Close()
+	awaitWebsocketClients(t, w, 1)
+
+	if err = w.Write(types.NewMessage([][]byte{[]byte("baz")})); err != nil {
+		t.Fatal(err)
+	}
+	if _, act, err := clients[1].ReadMessage(); err != nil {
+		t.Fatal(err)
+	} else if exp := "baz"; exp != string(act) {
+		t.Errorf("Wrong message: %s != %v", act, exp)
+	}
+}
+
+func TestWebsocketServerEviction(t *testing.T) {
+	conf := NewWebsocketServerConfig()
+	conf.Enabled = true
+	conf.Address = "localhost:0"
+	conf.ClientBufferSize = 1
+	conf.WriteTimeoutMS = 0
+
+	w, err := NewWebsocketServer(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.CloseAsync()
+
+	if err = w.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The client never reads, and therefore eventually falls behind.
+	c, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://%v/", w.listener.Addr()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	awaitWebsocketClients(t, w, 1)
+
+	part := bytes.Repeat([]byte("a"), 1000000)
+	for i := 0; i < 100; i++ {
+		if err = w.Write(types.NewMessage([][]byte{part})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	awaitWebsocketClients(t, w, 0)
+}
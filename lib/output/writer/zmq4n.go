@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/zmtp"
+)
+
+//------------------------------------------------------------------------------
+
+// ZMQ4NConfig is configuration for the ZMQ4N output type.
+type ZMQ4NConfig struct {
+	URLs          []string `json:"urls" yaml:"urls"`
+	Bind          bool     `json:"bind" yaml:"bind"`
+	SocketType    string   `json:"socket_type" yaml:"socket_type"`
+	HighWaterMark int      `json:"high_water_mark" yaml:"high_water_mark"`
+	PollTimeoutMS int      `json:"poll_timeout_ms" yaml:"poll_timeout_ms"`
+}
+
+// NewZMQ4NConfig creates a new ZMQ4NConfig with default values.
+func NewZMQ4NConfig() ZMQ4NConfig {
+	return ZMQ4NConfig{
+		URLs:          []string{"tcp://*:5556"},
+		Bind:          true,
+		SocketType:    "PUSH",
+		HighWaterMark: 0,
+		PollTimeoutMS: 5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ZMQ4N is an output type that writes ZeroMQ messages using a pure Go
+// implementation of the ZMTP protocol.
+type ZMQ4N struct {
+	log   log.Modular
+	stats metrics.Type
+
+	urls []string
+	conf ZMQ4NConfig
+
+	pollTimeout time.Duration
+	socket      *zmtp.Socket
+}
+
+// NewZMQ4N creates a new ZMQ4N output type.
+func NewZMQ4N(conf ZMQ4NConfig, log log.Modular, stats metrics.Type) (*ZMQ4N, error) {
+	z := ZMQ4N{
+		log:         log.NewModule(".output.zmq4n"),
+		stats:       stats,
+		conf:        conf,
+		pollTimeout: time.Millisecond * time.Duration(conf.PollTimeoutMS),
+	}
+
+	switch conf.SocketType {
+	case "PUSH", "PUB":
+	default:
+		return nil, types.ErrInvalidZMQType
+	}
+
+	for _, u := range conf.URLs {
+		for _, splitU := range strings.Split(u, ",") {
+			if len(splitU) > 0 {
+				z.urls = append(z.urls, splitU)
+			}
+		}
+	}
+
+	return &z, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Connect attempts to establish a ZMQ4N socket.
+func (z *ZMQ4N) Connect() error {
+	if z.socket != nil {
+		return nil
+	}
+
+	socket, err := zmtp.NewSocket(z.conf.SocketType, z.conf.HighWaterMark)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range z.urls {
+		if z.conf.Bind {
+			err = socket.Bind(address)
+		} else {
+			err = socket.Connect(address)
+		}
+		if err != nil {
+			socket.Close()
+			return err
+		}
+	}
+
+	z.socket = socket
+
+	z.log.Infof("Sending ZMQ4N messages to URLs: %s\n", z.urls)
+	return nil
+}
+
+// Write will attempt to write a message to the ZMQ4N socket.
+func (z *ZMQ4N) Write(msg types.Message) error {
+	if z.socket == nil {
+		return types.ErrNotConnected
+	}
+	err := z.socket.Send(msg.GetAll(), z.pollTimeout)
+	if err == zmtp.ErrTimeout {
+		return types.ErrTimeout
+	}
+	if err == zmtp.ErrClosed {
+		return types.ErrTypeClosed
+	}
+	return err
+}
+
+// CloseAsync shuts down the ZMQ4N output and stops processing messages.
+func (z *ZMQ4N) CloseAsync() {
+	if z.socket != nil {
+		z.socket.Close()
+	}
+}
+
+// WaitForClose blocks until the ZMQ4N output has closed down.
+func (z *ZMQ4N) WaitForClose(timeout time.Duration) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package writer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/zmtp"
+)
+
+func TestZMQ4NPush(t *testing.T) {
+	conf := NewZMQ4NConfig()
+	conf.URLs = []string{"tcp://localhost:1263"}
+	conf.Bind = true
+	conf.SocketType = "PUSH"
+	conf.PollTimeoutMS = 100
+
+	z, err := NewZMQ4N(conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = z.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		z.CloseAsync()
+		if err := z.WaitForClose(time.Second); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if err = z.Write(types.NewMessage([][]byte{[]byte("foo")})); err != types.ErrTimeout {
+		t.Errorf("Wrong error: %v != %v", err, types.ErrTimeout)
+	}
+
+	socket, err := zmtp.NewSocket("PULL", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer socket.Close()
+	if err = socket.Connect("tcp://localhost:1263"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = z.Write(types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})); err != nil {
+		t.Fatal(err)
+	}
+	act, err := socket.Recv(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(act) != 2 || string(act[0]) != "foo" || string(act[1]) != "bar" {
+		t.Errorf("Wrong result: %s", act)
+	}
+}
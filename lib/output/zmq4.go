@@ -23,9 +23,12 @@
 package output
 
 import (
+	"time"
+
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/output/writer"
 	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/probe"
 	"github.com/Jeffail/benthos/lib/util/service/log"
 )
 
@@ -38,6 +41,12 @@ func init() {
 The zmq4 output type attempts to send messages to a ZMQ4 port, currently only
 PUSH and PUB sockets are supported.`,
 	}
+	connectionCheckers["zmq4"] = func(c Config, t time.Duration) error {
+		if c.ZMQ4 == nil || c.ZMQ4.Bind {
+			return types.ErrCheckNotSupported
+		}
+		return probe.DialURLs(t, c.ZMQ4.URLs...)
+	}
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package output
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output/writer"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["zmq4n"] = TypeSpec{
+		constructor: NewZMQ4N,
+		description: `
+Sends messages to ZeroMQ peers using a pure Go implementation of the ZMTP 3.0
+protocol, and therefore does not require C bindings or the ZMQ4 build tag.
+
+Only PUSH and PUB sockets are supported, over tcp:// URLs with the NULL
+security mechanism. A PUSH socket distributes messages across its peers in a
+round robin fashion and blocks until a peer is available, whereas a PUB socket
+drops messages that have no subscribed peers.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewZMQ4N creates a new ZMQ4N output type.
+func NewZMQ4N(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (Type, error) {
+	z, err := writer.NewZMQ4N(conf.ZMQ4N, log, stats)
+	if err != nil {
+		return nil, err
+	}
+	return NewWriter(
+		"zmq4n", z, log, stats,
+	)
+}
+
+//------------------------------------------------------------------------------
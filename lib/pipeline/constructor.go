@@ -35,6 +35,7 @@ import (
 // Config is a configuration struct for a pipeline.
 type Config struct {
 	Threads    int                `json:"threads" yaml:"threads"`
+	Ordered    bool               `json:"ordered" yaml:"ordered"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
 }
 
@@ -42,6 +43,7 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Threads:    1,
+		Ordered:    false,
 		Processors: []processor.Config{},
 	}
 }
@@ -59,6 +61,11 @@ func SanitiseConfig(conf Config) (interface{}, error) {
 		return nil, err
 	}
 
+	// Ordering is only relevant when enabled.
+	if !conf.Ordered {
+		delete(hashMap, "ordered")
+	}
+
 	procSlice := []interface{}{}
 	for _, proc := range conf.Processors {
 		var procSanitised interface{}
@@ -104,6 +111,9 @@ func New(
 	if conf.Threads <= 1 {
 		return procCtor()
 	}
+	if conf.Ordered {
+		return NewOrderedPool(procCtor, conf.Threads, log, stats)
+	}
 	return NewPool(procCtor, conf.Threads, log, stats)
 }
 
@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipeline
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// orderedItem tracks a transaction dispatched to a worker of an OrderedPool.
+type orderedItem struct {
+	worker       int
+	resChan      chan types.Response
+	upstreamChan chan<- types.Response
+}
+
+// OrderedPool is a pool of pipelines that processes transactions across
+// multiple processor threads whilst preserving the order in which they were
+// received. Transactions are dispatched to workers in turn, and the resulting
+// transactions of each are only propagated once all of those that preceded it
+// have been resolved.
+type OrderedPool struct {
+	running uint32
+
+	workers     []Type
+	workerChans []chan types.Transaction
+
+	log   log.Modular
+	stats metrics.Type
+
+	messagesIn  <-chan types.Transaction
+	messagesOut chan types.Transaction
+
+	closeChan chan struct{}
+	closed    chan struct{}
+}
+
+// NewOrderedPool returns a new pipeline pool that utilises multiple processor
+// threads and preserves the ordering of transactions.
+func NewOrderedPool(
+	constructor ConstructorFunc,
+	threads int,
+	log log.Modular,
+	stats metrics.Type,
+) (*OrderedPool, error) {
+	p := &OrderedPool{
+		running:     1,
+		workers:     make([]Type, threads),
+		workerChans: make([]chan types.Transaction, threads),
+		log:         log,
+		stats:       stats,
+		messagesOut: make(chan types.Transaction),
+		closeChan:   make(chan struct{}),
+		closed:      make(chan struct{}),
+	}
+
+	for i := range p.workers {
+		var err error
+		if p.workers[i], err = constructor(); err != nil {
+			return nil, err
+		}
+		p.workerChans[i] = make(chan types.Transaction)
+	}
+
+	return p, nil
+}
+
+//------------------------------------------------------------------------------
+
+// dispatch reads transactions and distributes them to each worker in turn,
+// queueing each for the collector in the same order.
+func (p *OrderedPool) dispatch(queue chan<- orderedItem) {
+	defer func() {
+		close(queue)
+		for _, c := range p.workerChans {
+			close(c)
+		}
+	}()
+
+	next := 0
+	for atomic.LoadUint32(&p.running) == 1 {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-p.messagesIn:
+			if !open {
+				return
+			}
+		case <-p.closeChan:
+			return
+		}
+
+		item := orderedItem{
+			worker:       next,
+			resChan:      make(chan types.Response),
+			upstreamChan: tran.ResponseChan,
+		}
+		select {
+		case queue <- item:
+		case <-p.closeChan:
+			return
+		}
+		select {
+		case p.workerChans[next] <- types.NewTransaction(tran.Payload, item.resChan):
+		case <-p.closeChan:
+			return
+		}
+		next = (next + 1) % len(p.workers)
+	}
+}
+
+// loop is the processing loop of this pipeline.
+func (p *OrderedPool) loop() {
+	defer func() {
+		atomic.StoreUint32(&p.running, 0)
+
+		// Signal all workers to close.
+		for _, worker := range p.workers {
+			worker.CloseAsync()
+		}
+
+		// Wait for all workers to be closed before closing our response and
+		// messages channels as the workers may still have access to them.
+		for _, worker := range p.workers {
+			err := worker.WaitForClose(time.Second)
+			for err != nil {
+				err = worker.WaitForClose(time.Second)
+			}
+		}
+
+		close(p.messagesOut)
+		close(p.closed)
+	}()
+
+	for i, worker := range p.workers {
+		if err := worker.StartReceiving(p.workerChans[i]); err != nil {
+			p.log.Errorf("Failed to start pipeline worker: %v\n", err)
+			return
+		}
+	}
+
+	queue := make(chan orderedItem, len(p.workers))
+	go p.dispatch(queue)
+
+	for atomic.LoadUint32(&p.running) == 1 {
+		var item orderedItem
+		var open bool
+		select {
+		case item, open = <-queue:
+			if !open {
+				return
+			}
+		case <-p.closeChan:
+			return
+		}
+
+		// Propagate the results of this item until the worker resolves it.
+	resultLoop:
+		for {
+			select {
+			case t, open := <-p.workers[item.worker].TransactionChan():
+				if !open {
+					return
+				}
+				select {
+				case p.messagesOut <- t:
+				case <-p.closeChan:
+					return
+				}
+			case res := <-item.resChan:
+				select {
+				case item.upstreamChan <- res:
+				case <-p.closeChan:
+					return
+				}
+				break resultLoop
+			case <-p.closeChan:
+				return
+			}
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// StartReceiving assigns a messages channel for the pipeline to read.
+func (p *OrderedPool) StartReceiving(msgs <-chan types.Transaction) error {
+	if p.messagesIn != nil {
+		return types.ErrAlreadyStarted
+	}
+	p.messagesIn = msgs
+	go p.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages from this
+// pipeline.
+func (p *OrderedPool) TransactionChan() <-chan types.Transaction {
+	return p.messagesOut
+}
+
+// CloseAsync shuts down the pipeline and stops processing messages.
+func (p *OrderedPool) CloseAsync() {
+	if atomic.CompareAndSwapUint32(&p.running, 1, 0) {
+		close(p.closeChan)
+	}
+}
+
+// WaitForClose blocks until the pipeline has closed down.
+func (p *OrderedPool) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.closed:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
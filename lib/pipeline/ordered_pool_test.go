@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipeline
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// mockDelayProcessor delays each message by a number of milliseconds parsed
+// from its contents, and drops messages with a delay of zero.
+type mockDelayProcessor struct{}
+
+func (m mockDelayProcessor) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	delay, _ := strconv.Atoi(string(msg.Get(0)))
+	if delay == 0 {
+		return nil, types.NewSimpleResponse(nil)
+	}
+	<-time.After(time.Duration(delay) * time.Millisecond)
+	return []types.Message{msg}, nil
+}
+
+func TestOrderedPool(t *testing.T) {
+	constr := func() (Type, error) {
+		return NewProcessor(
+			log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+			metrics.DudType{},
+			mockDelayProcessor{},
+		), nil
+	}
+
+	proc, err := NewOrderedPool(
+		constr, 4,
+		log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = proc.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+	if err = proc.StartReceiving(tChan); err == nil {
+		t.Error("Expected error from dupe receiving")
+	}
+
+	// Earlier messages take longer to process, and a delay of zero is dropped.
+	delays := []string{"40", "30", "0", "20", "10", "5", "1"}
+	expOrder := []string{"40", "30", "20", "10", "5", "1"}
+
+	resChans := make([]chan types.Response, len(delays))
+	go func() {
+		for i, d := range delays {
+			resChans[i] = make(chan types.Response, 1)
+			select {
+			case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(d)}), resChans[i]):
+			case <-time.After(time.Second):
+				t.Error("Timed out")
+				return
+			}
+		}
+	}()
+
+	for _, exp := range expOrder {
+		select {
+		case tran := <-proc.TransactionChan():
+			if act := string(tran.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong order: %v != %v", act, exp)
+			}
+			select {
+			case tran.ResponseChan <- types.NewSimpleResponse(nil):
+			case <-time.After(time.Second):
+				t.Fatal("Timed out")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	proc.CloseAsync()
+	if err = proc.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderedPoolShutdown(t *testing.T) {
+	constr := func() (Type, error) {
+		return NewProcessor(
+			log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+			metrics.DudType{},
+			mockDelayProcessor{},
+		), nil
+	}
+
+	proc, err := NewOrderedPool(
+		constr, 2,
+		log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = proc.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	resChan := make(chan types.Response)
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("0")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+
+	close(tChan)
+	select {
+	case _, open := <-proc.TransactionChan():
+		if open {
+			t.Error("Expected transaction chan to close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	if err = proc.WaitForClose(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
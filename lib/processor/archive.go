@@ -22,6 +22,7 @@ package processor
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"fmt"
 	"os"
@@ -40,13 +41,16 @@ func init() {
 		constructor: NewArchive,
 		description: `
 Archives all the parts of a message into a single part according to the selected
-archive type. Supported archive types are: tar, binary (I'll add more later).
-
-Some archive types (such as tar) treat each archive item (message part) as a
-file with a path. Since message parts only contain raw data a unique path must
-be generated for each part. This can be done by using function interpolations on
-the 'path' field as described [here](../config_interpolation.md#functions). For
-types that aren't file based (such as binary) the file field is ignored.`,
+archive type. Supported archive types are: tar, zip, binary.
+
+Some archive types (such as tar and zip) treat each archive item (message part)
+as a file with a path. Since message parts only contain raw data a unique path
+must be generated for each part. This can be done by using function
+interpolations on the 'path' field as described
+[here](../config_interpolation.md#functions), which are resolved individually
+for each part and therefore have access to its metadata, e.g.
+` + "`${!metadata:id}.json`" + `. For types that aren't file based (such as
+binary) the file field is ignored.`,
 	}
 }
 
@@ -68,40 +72,82 @@ func NewArchiveConfig() ArchiveConfig {
 
 //------------------------------------------------------------------------------
 
-type archiveFunc func(hFunc headerFunc, parts [][]byte) ([]byte, error)
+// tarBlockSize is the size of the blocks that a tar archive is written in.
+const tarBlockSize = 512
 
-type headerFunc func(body []byte) os.FileInfo
+type archiveFunc func(hFunc headerFunc, msg types.Message) ([]byte, error)
 
-func tarArchive(hFunc headerFunc, parts [][]byte) ([]byte, error) {
-	buf := &bytes.Buffer{}
+type headerFunc func(index int, msg types.Message) os.FileInfo
+
+func tarArchive(hFunc headerFunc, msg types.Message) ([]byte, error) {
+	// Each part is written with a header block and padded to a whole block,
+	// and the archive ends with two empty blocks.
+	size := 2 * tarBlockSize
+	for _, part := range msg.GetAll() {
+		size += 2*tarBlockSize + len(part)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, size))
 	tw := tar.NewWriter(buf)
 
 	// Iterate through the parts of the message.
-	for _, part := range parts {
-		hdr, err := tar.FileInfoHeader(hFunc(part), "")
+	err := msg.Iter(func(i int, part []byte) error {
+		hdr, err := tar.FileInfoHeader(hFunc(i, msg), "")
 		if err != nil {
-			return nil, err
+			return err
 		}
 		if err = tw.WriteHeader(hdr); err != nil {
-			return nil, err
-		}
-		if _, err = tw.Write(part); err != nil {
-			return nil, err
+			return err
 		}
+		_, err = tw.Write(part)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 	tw.Close()
 
 	return buf.Bytes(), nil
 }
 
-func binaryArchive(hFunc headerFunc, parts [][]byte) ([]byte, error) {
-	return types.NewMessage(parts).Bytes(), nil
+func zipArchive(hFunc headerFunc, msg types.Message) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+
+	// Iterate through the parts of the message.
+	err := msg.Iter(func(i int, part []byte) error {
+		hdr, err := zip.FileInfoHeader(hFunc(i, msg))
+		if err != nil {
+			return err
+		}
+		hdr.Method = zip.Deflate
+
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(part)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func binaryArchive(hFunc headerFunc, msg types.Message) ([]byte, error) {
+	return types.NewMessage(msg.GetAll()).Bytes(), nil
 }
 
 func strToArchiver(str string) (archiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarArchive, nil
+	case "zip":
+		return zipArchive, nil
 	case "binary":
 		return binaryArchive, nil
 	}
@@ -184,14 +230,16 @@ func (f fakeInfo) Sys() interface{} {
 	return nil
 }
 
-func (d *Archive) createHeader(body []byte) os.FileInfo {
+func (d *Archive) createHeader(index int, msg types.Message) os.FileInfo {
 	path := d.conf.Path
 	if d.interpolatePath {
-		path = string(text.ReplaceFunctionVariables(d.pathBytes))
+		path = string(text.ReplaceFunctionVariablesFor(
+			types.ExtractPart(msg, index), d.pathBytes,
+		))
 	}
 	return fakeInfo{
 		name: path,
-		size: int64(len(body)),
+		size: int64(len(msg.Get(index))),
 		mode: 0666,
 	}
 }
@@ -208,7 +256,7 @@ func (d *Archive) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 		return nil, types.NewSimpleResponse(nil)
 	}
 
-	newPart, err := d.archive(d.createHeader, msg.GetAll())
+	newPart, err := d.archive(d.createHeader, msg)
 	if err != nil {
 		d.log.Debugf("Failed to create archive: %v\n", err)
 		d.mErr.Incr(1)
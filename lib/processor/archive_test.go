@@ -22,6 +22,7 @@ package processor
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"io"
 	"os"
@@ -101,6 +102,72 @@ func TestArchiveTar(t *testing.T) {
 	}
 }
 
+func TestArchiveZip(t *testing.T) {
+	conf := NewConfig()
+	conf.Archive.Format = "zip"
+	conf.Archive.Path = "${!metadata:id}.json"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	exp := [][]byte{
+		[]byte(`{"first":"part"}`),
+		[]byte(`{"second":"part"}`),
+		[]byte(`{"third":"part"}`),
+	}
+	expNames := []string{"foo.json", "bar.json", "baz.json"}
+
+	proc, err := NewArchive(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage(exp)
+	for i, name := range []string{"foo", "bar", "baz"} {
+		input.GetMetadata(i).Set("id", name)
+	}
+
+	msgs, res := proc.ProcessMessage(input)
+	if len(msgs) != 1 {
+		t.Fatal("Archive failed")
+	} else if res != nil {
+		t.Errorf("Expected nil response: %v", res)
+	}
+	if msgs[0].Len() != 1 {
+		t.Fatal("More parts than expected")
+	}
+
+	act := [][]byte{}
+	actNames := []string{}
+
+	archive := msgs[0].Get(0)
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		fr, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newPartBuf := bytes.Buffer{}
+		if _, err = newPartBuf.ReadFrom(fr); err != nil {
+			t.Fatal(err)
+		}
+		fr.Close()
+
+		act = append(act, newPartBuf.Bytes())
+		actNames = append(actNames, f.Name)
+	}
+
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected output: %s != %s", act, exp)
+	}
+	if !reflect.DeepEqual(expNames, actNames) {
+		t.Errorf("Unexpected file names: %v != %v", actNames, expNames)
+	}
+}
+
 func TestArchiveBinary(t *testing.T) {
 	conf := NewConfig()
 	conf.Archive.Format = "binary"
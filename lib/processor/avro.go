@@ -0,0 +1,348 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/linkedin/goavro"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["avro"] = TypeSpec{
+		constructor: NewAvro,
+		description: `
+Converts message parts between JSON and Avro messages in the Confluent wire
+format, which consists of a zero magic byte, a four byte big endian schema ID
+and the Avro binary encoding of the message. Schemas are fetched from the
+schema registry at ` + "`schema_registry_url`" + ` and cached.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.
+
+### Operators
+
+#### ` + "`to_json`" + `
+
+Decodes an Avro message into JSON using the schema of the ID embedded within
+the message. Schemas are immutable and are therefore cached indefinitely. The
+resulting JSON uses the Avro JSON encoding, where values of union types are
+wrapped in an object keyed by their type, e.g. ` + "`{\"string\":\"foo\"}`" + `.
+
+#### ` + "`from_json`" + `
+
+Encodes a JSON document in the Avro JSON encoding into an Avro message using
+the latest schema of the registry subject ` + "`subject`" + `. The latest
+schema is fetched again once ` + "`subject_refresh_period_ms`" + `
+milliseconds have passed since it was last resolved.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AvroConfig contains any configuration for the Avro processor.
+type AvroConfig struct {
+	Parts             []int  `json:"parts" yaml:"parts"`
+	Operator          string `json:"operator" yaml:"operator"`
+	SchemaRegistryURL string `json:"schema_registry_url" yaml:"schema_registry_url"`
+	Subject           string `json:"subject" yaml:"subject"`
+	SubjectRefreshMS  int64  `json:"subject_refresh_period_ms" yaml:"subject_refresh_period_ms"`
+	TimeoutMS         int64  `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewAvroConfig returns an AvroConfig with default values.
+func NewAvroConfig() AvroConfig {
+	return AvroConfig{
+		Parts:             []int{},
+		Operator:          "to_json",
+		SchemaRegistryURL: "http://localhost:8081",
+		Subject:           "",
+		SubjectRefreshMS:  60000,
+		TimeoutMS:         5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// avroSchema is a compiled Avro schema along with its registry ID.
+type avroSchema struct {
+	id    int
+	codec *goavro.Codec
+}
+
+// avroRegistry fetches schemas from a Confluent schema registry and caches
+// them.
+type avroRegistry struct {
+	url           *url.URL
+	client        http.Client
+	refreshPeriod time.Duration
+
+	mut         sync.Mutex
+	byID        map[int]*goavro.Codec
+	subject     *avroSchema
+	subjectTime time.Time
+}
+
+func newAvroRegistry(conf AvroConfig) (*avroRegistry, error) {
+	u, err := url.Parse(conf.SchemaRegistryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema_registry_url: %v", err)
+	}
+	return &avroRegistry{
+		url: u,
+		client: http.Client{
+			Timeout: time.Duration(conf.TimeoutMS) * time.Millisecond,
+		},
+		refreshPeriod: time.Duration(conf.SubjectRefreshMS) * time.Millisecond,
+		byID:          map[int]*goavro.Codec{},
+	}, nil
+}
+
+type avroRegistryResponse struct {
+	ID     int    `json:"id"`
+	Schema string `json:"schema"`
+}
+
+func (r *avroRegistry) get(path string) (*avroRegistryResponse, error) {
+	u := *r.url
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+
+	res, err := r.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schema registry returned status %v: %s", res.StatusCode, body)
+	}
+
+	var resObj avroRegistryResponse
+	if err = json.Unmarshal(body, &resObj); err != nil {
+		return nil, fmt.Errorf("failed to parse schema registry response: %v", err)
+	}
+	return &resObj, nil
+}
+
+// schemaByID returns the codec of a schema ID, fetching it from the registry
+// if it is not already cached.
+func (r *avroRegistry) schemaByID(id int) (*goavro.Codec, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if codec, exists := r.byID[id]; exists {
+		return codec, nil
+	}
+	res, err := r.get("/schemas/ids/" + strconv.Itoa(id))
+	if err != nil {
+		return nil, err
+	}
+	codec, err := goavro.NewCodec(res.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %v: %v", id, err)
+	}
+	r.byID[id] = codec
+	return codec, nil
+}
+
+// latestSchema returns the latest schema of a subject, which is refreshed from
+// the registry once the refresh period has passed.
+func (r *avroRegistry) latestSchema(subject string) (*avroSchema, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.subject != nil && time.Since(r.subjectTime) < r.refreshPeriod {
+		return r.subject, nil
+	}
+	res, err := r.get("/subjects/" + url.PathEscape(subject) + "/versions/latest")
+	if err != nil {
+		return nil, err
+	}
+	codec, exists := r.byID[res.ID]
+	if !exists {
+		if codec, err = goavro.NewCodec(res.Schema); err != nil {
+			return nil, fmt.Errorf("failed to parse schema %v: %v", res.ID, err)
+		}
+		r.byID[res.ID] = codec
+	}
+	r.subject = &avroSchema{id: res.ID, codec: codec}
+	r.subjectTime = time.Now()
+	return r.subject, nil
+}
+
+//------------------------------------------------------------------------------
+
+type avroOperator func(body []byte) ([]byte, error)
+
+func newAvroToJSONOperator(r *avroRegistry) avroOperator {
+	return func(body []byte) ([]byte, error) {
+		if len(body) < 5 || body[0] != 0 {
+			return nil, errors.New("message is not in the Avro wire format")
+		}
+		id := int(binary.BigEndian.Uint32(body[1:5]))
+		codec, err := r.schemaByID(id)
+		if err != nil {
+			return nil, err
+		}
+		native, remaining, err := codec.NativeFromBinary(body[5:])
+		if err != nil {
+			return nil, err
+		}
+		if len(remaining) > 0 {
+			return nil, fmt.Errorf("message contained %v unexpected trailing bytes", len(remaining))
+		}
+		return codec.TextualFromNative(nil, native)
+	}
+}
+
+func newAvroFromJSONOperator(r *avroRegistry, subject string) avroOperator {
+	return func(body []byte) ([]byte, error) {
+		schema, err := r.latestSchema(subject)
+		if err != nil {
+			return nil, err
+		}
+		native, _, err := schema.codec.NativeFromTextual(body)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 5, len(body)+5)
+		binary.BigEndian.PutUint32(buf[1:5], uint32(schema.id))
+		return schema.codec.BinaryFromNative(buf, native)
+	}
+}
+
+func avroOperatorFromConfig(conf AvroConfig) (avroOperator, error) {
+	r, err := newAvroRegistry(conf)
+	if err != nil {
+		return nil, err
+	}
+	switch conf.Operator {
+	case "to_json":
+		return newAvroToJSONOperator(r), nil
+	case "from_json":
+		if len(conf.Subject) == 0 {
+			return nil, errors.New("subject must not be empty")
+		}
+		return newAvroFromJSONOperator(r, conf.Subject), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// Avro is a processor that converts message parts between JSON and Avro.
+type Avro struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts    []int
+	operator avroOperator
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewAvro returns an Avro processor.
+func NewAvro(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := avroOperatorFromConfig(conf.Avro)
+	if err != nil {
+		return nil, err
+	}
+	return &Avro{
+		conf:  conf,
+		log:   log.NewModule(".processor.avro"),
+		stats: stats,
+
+		parts:    conf.Avro.Parts,
+		operator: op,
+
+		mCount:     stats.GetCounter("processor.avro.count"),
+		mErr:       stats.GetCounter("processor.avro.error"),
+		mSucc:      stats.GetCounter("processor.avro.success"),
+		mSent:      stats.GetCounter("processor.avro.sent"),
+		mSentParts: stats.GetCounter("processor.avro.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage converts each targeted message part between JSON and Avro.
+func (a *Avro) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	a.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := a.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		result, err := a.operator(newMsg.Get(index))
+		if err != nil {
+			a.mErr.Incr(1)
+			a.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		newMsg.Set(index, result)
+		a.mSucc.Incr(1)
+	}
+
+	a.mSent.Incr(1)
+	a.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
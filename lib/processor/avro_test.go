@@ -0,0 +1,209 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+const testAvroSchema = `{
+	"namespace": "foo.namespace.com",
+	"type": "record",
+	"name": "identity",
+	"fields": [
+		{ "name": "Name", "type": "string"},
+		{ "name": "Address", "type": ["null",{
+			"namespace": "my.namespace.com",
+			"type": "record",
+			"name": "address",
+			"fields": [
+				{ "name": "City", "type": "string" }
+			]
+		}],"default":null}
+	]
+}`
+
+func newTestAvroRegistry(t *testing.T, reqCount *int64) *httptest.Server {
+	schemaBytes, err := json.Marshal(map[string]interface{}{
+		"id":     3,
+		"schema": testAvroSchema,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(reqCount, 1)
+		switch r.URL.Path {
+		case "/schemas/ids/3", "/subjects/foo/versions/latest":
+			w.Write(schemaBytes)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+}
+
+func TestAvroBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Avro.Operator = "nope"
+	if _, err := NewAvro(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+
+	conf = NewConfig()
+	conf.Avro.Operator = "from_json"
+	if _, err := NewAvro(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from empty subject")
+	}
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	var reqCount int64
+	ts := newTestAvroRegistry(t, &reqCount)
+	defer ts.Close()
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	encConf := NewConfig()
+	encConf.Avro.Operator = "from_json"
+	encConf.Avro.SchemaRegistryURL = ts.URL
+	encConf.Avro.Subject = "foo"
+	encoder, err := NewAvro(encConf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decConf := NewConfig()
+	decConf.Avro.Operator = "to_json"
+	decConf.Avro.SchemaRegistryURL = ts.URL
+	decoder, err := NewAvro(decConf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := [][]byte{
+		[]byte(`{"Name":"foo","Address":{"my.namespace.com.address":{"City":"bar"}}}`),
+		[]byte(`{"Name":"baz","Address":null}`),
+	}
+
+	msgs, _ := encoder.ProcessMessage(types.NewMessage(input))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if HasFailed(msgs[0]) {
+		t.Fatalf("Unexpected failure: %v", msgs[0].GetMetadata(0).Get(FailFlagKey))
+	}
+	for i, part := range msgs[0].GetAll() {
+		if len(part) < 5 || part[0] != 0 || part[4] != 3 {
+			t.Errorf("Part %v not in wire format: %v", i, part)
+		}
+	}
+
+	msgs, _ = decoder.ProcessMessage(msgs[0])
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	for i, exp := range []string{
+		`{"Address":{"my.namespace.com.address":{"City":"bar"}},"Name":"foo"}`,
+		`{"Address":null,"Name":"baz"}`,
+	} {
+		var expObj, actObj interface{}
+		if err = json.Unmarshal([]byte(exp), &expObj); err != nil {
+			t.Fatal(err)
+		}
+		if err = json.Unmarshal(msgs[0].Get(i), &actObj); err != nil {
+			t.Fatalf("Failed to parse result: %v", err)
+		}
+		if !reflect.DeepEqual(expObj, actObj) {
+			t.Errorf("Wrong result: %s != %s", msgs[0].Get(i), exp)
+		}
+	}
+	if HasFailed(msgs[0]) {
+		t.Errorf("Unexpected failure: %v", msgs[0].GetMetadata(0).Get(FailFlagKey))
+	}
+
+	if exp, act := int64(2), atomic.LoadInt64(&reqCount); exp != act {
+		t.Errorf("Wrong count of registry requests: %v != %v", act, exp)
+	}
+}
+
+func TestAvroFailures(t *testing.T) {
+	var reqCount int64
+	ts := newTestAvroRegistry(t, &reqCount)
+	defer ts.Close()
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Avro.Operator = "to_json"
+	conf.Avro.SchemaRegistryURL = ts.URL
+	decoder, err := NewAvro(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := decoder.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(`not avro`),
+		{0, 0, 0, 0, 4, 0},
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	for i := 0; i < 2; i++ {
+		if !HasFailed(types.ExtractPart(msgs[0], i)) {
+			t.Errorf("Expected part %v to fail", i)
+		}
+	}
+
+	conf.Avro.Operator = "from_json"
+	conf.Avro.Subject = "foo"
+	encoder, err := NewAvro(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = encoder.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(`{"Name":10}`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if !HasFailed(msgs[0]) {
+		t.Error("Expected encoding to fail")
+	}
+	if exp, act := `{"Name":10}`, string(msgs[0].Get(0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -76,6 +76,7 @@ type Batch struct {
 	n         int
 	sizeTally int
 	parts     [][]byte
+	metadata  []types.Metadata
 
 	mCount     metrics.StatCounter
 	mWarnParts metrics.StatCounter
@@ -108,15 +109,20 @@ func (c *Batch) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 	c.mCount.Incr(1)
 
 	// Add new parts to the buffer.
-	for _, part := range msg.GetAll() {
+	for i, part := range msg.GetAll() {
 		c.sizeTally += len(part)
 		c.parts = append(c.parts, part)
+		c.metadata = append(c.metadata, msg.GetMetadata(i))
 	}
 
 	// If we have reached our target count of parts in the buffer.
 	if c.sizeTally >= c.n {
 		newMsg := types.NewMessage(c.parts)
+		for i, md := range c.metadata {
+			newMsg.SetMetadata(md, i)
+		}
 		c.parts = nil
+		c.metadata = nil
 		c.sizeTally = 0
 
 		c.mSent.Incr(1)
@@ -0,0 +1,201 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/ugorji/go/codec"
+)
+
+//------------------------------------------------------------------------------
+
+// binaryCodecOperator converts a message part between JSON and a binary
+// serialisation format.
+type binaryCodecOperator func(body []byte) ([]byte, error)
+
+// newMsgpackHandle returns a MessagePack handle that decodes strings as strings
+// and maps as JSON compatible string keyed maps.
+func newMsgpackHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}{})
+	return h
+}
+
+// newCBORHandle returns a CBOR handle that decodes maps as JSON compatible
+// string keyed maps.
+func newCBORHandle() *codec.CborHandle {
+	h := &codec.CborHandle{}
+	h.MapType = reflect.TypeOf(map[string]interface{}{})
+	return h
+}
+
+// normaliseJSONNumbers walks a JSON document decoded with UseNumber and
+// replaces numbers with an int64 when they are integers and a float64
+// otherwise, so that integers are serialised as integer types.
+func normaliseJSONNumbers(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	case map[string]interface{}:
+		for k, ele := range t {
+			var err error
+			if t[k], err = normaliseJSONNumbers(ele); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, ele := range t {
+			var err error
+			if t[i], err = normaliseJSONNumbers(ele); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v, nil
+}
+
+func newBinaryCodecToJSONOperator(h codec.Handle) binaryCodecOperator {
+	return func(body []byte) ([]byte, error) {
+		var v interface{}
+		if err := codec.NewDecoderBytes(body, h).Decode(&v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	}
+}
+
+func newBinaryCodecFromJSONOperator(h codec.Handle) binaryCodecOperator {
+	return func(body []byte) ([]byte, error) {
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		v, err := normaliseJSONNumbers(v)
+		if err != nil {
+			return nil, err
+		}
+
+		var result []byte
+		if err = codec.NewEncoderBytes(&result, h).Encode(v); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+func binaryCodecOperatorFromString(h codec.Handle, operator string) (binaryCodecOperator, error) {
+	switch operator {
+	case "to_json":
+		return newBinaryCodecToJSONOperator(h), nil
+	case "from_json":
+		return newBinaryCodecFromJSONOperator(h), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", operator)
+}
+
+//------------------------------------------------------------------------------
+
+// binaryCodec is the shared implementation of processors that convert message
+// parts between JSON and a binary serialisation format.
+type binaryCodec struct {
+	log   log.Modular
+	stats metrics.Type
+
+	parts    []int
+	operator binaryCodecOperator
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+func newBinaryCodec(
+	name string,
+	parts []int,
+	operator binaryCodecOperator,
+	log log.Modular,
+	stats metrics.Type,
+) *binaryCodec {
+	return &binaryCodec{
+		log:   log.NewModule(".processor." + name),
+		stats: stats,
+
+		parts:    parts,
+		operator: operator,
+
+		mCount:     stats.GetCounter("processor." + name + ".count"),
+		mErr:       stats.GetCounter("processor." + name + ".error"),
+		mSucc:      stats.GetCounter("processor." + name + ".success"),
+		mSent:      stats.GetCounter("processor." + name + ".sent"),
+		mSentParts: stats.GetCounter("processor." + name + ".parts.sent"),
+	}
+}
+
+// ProcessMessage converts each targeted message part.
+func (b *binaryCodec) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	b.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := b.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		result, err := b.operator(newMsg.Get(index))
+		if err != nil {
+			b.mErr.Incr(1)
+			b.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		newMsg.Set(index, result)
+		b.mSucc.Incr(1)
+	}
+
+	b.mSent.Incr(1)
+	b.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
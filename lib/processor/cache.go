@@ -0,0 +1,265 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["cache"] = TypeSpec{
+		constructor: NewCache,
+		description: `
+Performs operations against a [cache resource](../caches) for each message
+part, allowing you to store or retrieve data within message payloads.
+
+The ` + "`key`" + ` and ` + "`value`" + ` fields support
+[interpolation functions](../config_interpolation.md#functions), which are
+resolved individually for each message part. If the ` + "`value`" + ` field is
+empty then the contents of the message part are used as the value.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part, including a ` + "`get`" + ` of a key that
+does not exist or an ` + "`add`" + ` of a key that already exists, the part is
+flagged as having failed a processing step and is otherwise left unchanged.
+
+### Operators
+
+#### ` + "`set`" + `
+
+Set a key in the cache to a value. If the key already exists the contents are
+overridden.
+
+#### ` + "`add`" + `
+
+Set a key in the cache to a value. If the key already exists the action fails.
+
+#### ` + "`get`" + `
+
+Retrieve the contents of a cached key and replace the original message part with
+them.
+
+#### ` + "`delete`" + `
+
+Delete a key and its contents from the cache. Deleting a key that does not exist
+is not considered a failure.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CacheConfig contains any configuration for the Cache processor.
+type CacheConfig struct {
+	Cache    string `json:"cache" yaml:"cache"`
+	Parts    []int  `json:"parts" yaml:"parts"`
+	Operator string `json:"operator" yaml:"operator"`
+	Key      string `json:"key" yaml:"key"`
+	Value    string `json:"value" yaml:"value"`
+}
+
+// NewCacheConfig returns a CacheConfig with default values.
+func NewCacheConfig() CacheConfig {
+	return CacheConfig{
+		Cache:    "",
+		Parts:    []int{},
+		Operator: "set",
+		Key:      "",
+		Value:    "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cacheOperator func(c types.Cache, key string, value []byte) ([]byte, bool, error)
+
+func newCacheSetOperator() cacheOperator {
+	return func(c types.Cache, key string, value []byte) ([]byte, bool, error) {
+		return nil, false, c.Set(key, value)
+	}
+}
+
+func newCacheAddOperator() cacheOperator {
+	return func(c types.Cache, key string, value []byte) ([]byte, bool, error) {
+		return nil, false, c.Add(key, value)
+	}
+}
+
+func newCacheGetOperator() cacheOperator {
+	return func(c types.Cache, key string, _ []byte) ([]byte, bool, error) {
+		result, err := c.Get(key)
+		return result, true, err
+	}
+}
+
+func newCacheDeleteOperator() cacheOperator {
+	return func(c types.Cache, key string, _ []byte) ([]byte, bool, error) {
+		return nil, false, c.Delete(key)
+	}
+}
+
+func cacheOperatorFromString(operator string) (cacheOperator, error) {
+	switch operator {
+	case "set":
+		return newCacheSetOperator(), nil
+	case "add":
+		return newCacheAddOperator(), nil
+	case "get":
+		return newCacheGetOperator(), nil
+	case "delete":
+		return newCacheDeleteOperator(), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", operator)
+}
+
+//------------------------------------------------------------------------------
+
+// Cache is a processor that performs operations against a cache resource for
+// each message part.
+type Cache struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts    []int
+	cache    types.Cache
+	operator cacheOperator
+
+	key         []byte
+	interpKey   bool
+	value       []byte
+	interpValue bool
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mKeyErr    metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewCache returns a Cache processor.
+func NewCache(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	c, err := mgr.GetCache(conf.Cache.Cache)
+	if err != nil {
+		return nil, fmt.Errorf("unable to locate cache resource '%v': %v", conf.Cache.Cache, err)
+	}
+	op, err := cacheOperatorFromString(conf.Cache.Operator)
+	if err != nil {
+		return nil, err
+	}
+	if len(conf.Cache.Key) == 0 {
+		return nil, errors.New("key must not be empty")
+	}
+
+	key, value := []byte(conf.Cache.Key), []byte(conf.Cache.Value)
+	return &Cache{
+		conf:  conf,
+		log:   log.NewModule(".processor.cache"),
+		stats: stats,
+
+		parts:    conf.Cache.Parts,
+		cache:    c,
+		operator: op,
+
+		key:         key,
+		interpKey:   text.ContainsFunctionVariables(key),
+		value:       value,
+		interpValue: text.ContainsFunctionVariables(value),
+
+		mCount:     stats.GetCounter("processor.cache.count"),
+		mErr:       stats.GetCounter("processor.cache.error"),
+		mKeyErr:    stats.GetCounter("processor.cache.key.error"),
+		mSucc:      stats.GetCounter("processor.cache.success"),
+		mSent:      stats.GetCounter("processor.cache.sent"),
+		mSentParts: stats.GetCounter("processor.cache.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage performs a cache operation for each targeted message part.
+func (c *Cache) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := c.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		key, value := c.key, c.value
+		if c.interpKey || c.interpValue {
+			partMsg := types.ExtractPart(newMsg, index)
+			if c.interpKey {
+				key = text.ReplaceFunctionVariablesFor(partMsg, key)
+			}
+			if c.interpValue {
+				value = text.ReplaceFunctionVariablesFor(partMsg, value)
+			}
+		}
+		if len(key) == 0 {
+			c.mKeyErr.Incr(1)
+			c.log.Debugf("Resolved empty key for part: %v\n", index)
+			FlagFail(newMsg, index, errors.New("resolved cache key was empty"))
+			continue
+		}
+		if len(c.value) == 0 {
+			value = newMsg.Get(index)
+		}
+
+		result, useResult, err := c.operator(c.cache, string(key), value)
+		if err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Operator failed for key '%s': %v\n", key, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		if useResult {
+			newMsg.Set(index, result)
+		}
+		c.mSucc.Incr(1)
+	}
+
+	c.mSent.Incr(1)
+	c.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
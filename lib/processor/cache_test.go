@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newCacheTestMgr(t *testing.T) (*fakeMgr, types.Cache) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	memCache, err := cache.NewMemory(cache.NewConfig(), nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}, memCache
+}
+
+func TestCacheBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	mgr, _ := newCacheTestMgr(t)
+
+	conf := NewConfig()
+	conf.Cache.Cache = "barcache"
+	conf.Cache.Key = "foo"
+	if _, err := NewCache(conf, mgr, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing cache")
+	}
+
+	conf.Cache.Cache = "foocache"
+	conf.Cache.Operator = "nope"
+	if _, err := NewCache(conf, mgr, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+
+	conf.Cache.Operator = "set"
+	conf.Cache.Key = ""
+	if _, err := NewCache(conf, mgr, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from empty key")
+	}
+}
+
+func TestCacheSetGet(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	mgr, memCache := newCacheTestMgr(t)
+
+	conf := NewConfig()
+	conf.Cache.Cache = "foocache"
+	conf.Cache.Operator = "set"
+	conf.Cache.Key = "${!json_field:id}"
+
+	setProc, err := NewCache(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := [][]byte{
+		[]byte(`{"id":"1","name":"foo"}`),
+		[]byte(`{"id":"2","name":"bar"}`),
+	}
+	msgs, res := setProc.ProcessMessage(types.NewMessage(input))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(input, act) {
+		t.Errorf("Set modified message: %s != %s", act, input)
+	}
+	if HasFailed(msgs[0]) {
+		t.Error("Unexpected failure flag")
+	}
+
+	for i, k := range []string{"1", "2"} {
+		v, err := memCache.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp, act := string(input[i]), string(v); exp != act {
+			t.Errorf("Wrong cached value: %v != %v", act, exp)
+		}
+	}
+
+	conf.Cache.Operator = "get"
+	conf.Cache.Key = "${!json_field:id}"
+	getProc, err := NewCache(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res = getProc.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(`{"id":"2"}`),
+		[]byte(`{"id":"3"}`),
+	}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if exp, act := string(input[1]), string(msgs[0].Get(0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := `{"id":"3"}`, string(msgs[0].Get(1)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if len(msgs[0].GetMetadata(0).Get(FailFlagKey)) > 0 {
+		t.Error("Unexpected failure flag on hit")
+	}
+	if len(msgs[0].GetMetadata(1).Get(FailFlagKey)) == 0 {
+		t.Error("Expected failure flag on miss")
+	}
+}
+
+func TestCacheAddDelete(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	mgr, memCache := newCacheTestMgr(t)
+
+	conf := NewConfig()
+	conf.Cache.Cache = "foocache"
+	conf.Cache.Operator = "add"
+	conf.Cache.Key = "foo"
+	conf.Cache.Value = "${!metadata:bar}"
+	conf.Cache.Parts = []int{-1}
+
+	addProc, err := NewCache(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{[]byte("first"), []byte("second")})
+	msg.SetMetadata(types.NewMetadata().Set("bar", "baz"), 1)
+
+	msgs, _ := addProc.ProcessMessage(msg)
+	if HasFailed(msgs[0]) {
+		t.Error("Unexpected failure flag")
+	}
+	if v, err := memCache.Get("foo"); err != nil {
+		t.Error(err)
+	} else if exp, act := "baz", string(v); exp != act {
+		t.Errorf("Wrong cached value: %v != %v", act, exp)
+	}
+
+	msgs, _ = addProc.ProcessMessage(msg)
+	if len(msgs[0].GetMetadata(1).Get(FailFlagKey)) == 0 {
+		t.Error("Expected failure flag on duplicate add")
+	}
+	if len(msg.GetMetadata(1).Get(FailFlagKey)) > 0 {
+		t.Error("Original message was modified")
+	}
+
+	conf.Cache.Operator = "delete"
+	delProc, err := NewCache(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = delProc.ProcessMessage(types.NewMessage([][]byte{[]byte("first")}))
+	if HasFailed(msgs[0]) {
+		t.Error("Unexpected failure flag")
+	}
+	if _, err = memCache.Get("foo"); err != types.ErrKeyNotFound {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrKeyNotFound)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["catch"] = TypeSpec{
+		constructor: NewCatch,
+		description: `
+Applies a list of child processors only to message parts that have been flagged
+as having failed a processing step, leaving all other parts unchanged. Once the
+child processors have been applied the failure flags of the resulting parts are
+removed.
+
+The child processors are applied to a message made only of the failed parts. If
+the children result in a single message with the same number of parts then the
+parts that had not failed are placed back into their original positions,
+otherwise they continue as a separate message.
+
+This processor is useful for recovering from errors, or for marking failed
+parts in a way that allows them to be routed elsewhere, such as a dead letter
+queue:
+
+` + "``` yaml" + `
+- type: catch
+  catch:
+  - type: metadata
+    metadata:
+      operator: set
+      key: route
+      value: dlq
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CatchConfig is a config struct containing fields for the Catch processor.
+type CatchConfig []Config
+
+// NewCatchConfig returns a default CatchConfig.
+func NewCatchConfig() CatchConfig {
+	return CatchConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// Catch is a processor that applies a list of child processors to the parts of
+// a message that have failed a processing step.
+type Catch struct {
+	children []Type
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount   metrics.StatCounter
+	mCaught  metrics.StatCounter
+	mDropped metrics.StatCounter
+	mSent    metrics.StatCounter
+}
+
+// NewCatch returns a Catch processor.
+func NewCatch(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var children []Type
+	for i, pconf := range conf.Catch {
+		proc, err := New(pconf, mgr, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create child processor '%v': %v", i, err)
+		}
+		children = append(children, proc)
+	}
+	return &Catch{
+		children: children,
+
+		log:   log.NewModule(".processor.catch"),
+		stats: stats,
+
+		mCount:   stats.GetCounter("processor.catch.count"),
+		mCaught:  stats.GetCounter("processor.catch.parts.caught"),
+		mDropped: stats.GetCounter("processor.catch.dropped"),
+		mSent:    stats.GetCounter("processor.catch.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// processFailed applies the child processors to a message made of failed parts
+// and removes the failure flags from the results.
+func (c *Catch) processFailed(msg types.Message) ([]types.Message, types.Response) {
+	resultMsgs := []types.Message{msg}
+	var resultRes types.Response
+
+	for i := 0; len(resultMsgs) > 0 && i < len(c.children); i++ {
+		var nextResultMsgs []types.Message
+		for _, m := range resultMsgs {
+			var rMsgs []types.Message
+			rMsgs, resultRes = c.children[i].ProcessMessage(m)
+			nextResultMsgs = append(nextResultMsgs, rMsgs...)
+		}
+		resultMsgs = nextResultMsgs
+	}
+
+	for _, m := range resultMsgs {
+		for i := 0; i < m.Len(); i++ {
+			ClearFail(m, i)
+		}
+	}
+	return resultMsgs, resultRes
+}
+
+// ProcessMessage applies the child processors to the parts of a message that
+// have failed a processing step.
+func (c *Catch) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	c.mCount.Incr(1)
+
+	failed := func(i int) bool {
+		if len(msg.GetMetadata(i).Get(FailFlagKey)) > 0 {
+			c.mCaught.Incr(1)
+			return true
+		}
+		return false
+	}
+
+	resultMsgs, resultRes := processSubset(msg, failed, c.processFailed)
+	if len(resultMsgs) == 0 {
+		c.mDropped.Incr(1)
+		res = resultRes
+	} else {
+		c.mSent.Incr(int64(len(resultMsgs)))
+		msgs = resultMsgs
+	}
+
+	return
+}
+
+//------------------------------------------------------------------------------
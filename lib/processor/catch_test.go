@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+var errFailed = errors.New("simulated failure")
+
+func TestCatchBadChild(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	procConf := NewConfig()
+	procConf.Type = "nope"
+
+	conf := NewConfig()
+	conf.Catch = append(conf.Catch, procConf)
+
+	if _, err := NewCatch(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad child processor")
+	}
+}
+
+func TestCatchFailedParts(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	metaConf := NewConfig()
+	metaConf.Type = "metadata"
+	metaConf.Metadata.Operator = "set"
+	metaConf.Metadata.Key = "route"
+	metaConf.Metadata.Value = "dlq"
+
+	prependConf := NewConfig()
+	prependConf.Type = "text"
+	prependConf.Text.Operator = "prepend"
+	prependConf.Text.Value = "failed: "
+
+	conf := NewConfig()
+	conf.Catch = append(conf.Catch, metaConf, prependConf)
+
+	proc, err := NewCatch(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte(`foo`),
+		[]byte(`bar`),
+		[]byte(`baz`),
+	})
+	FlagFail(input, 1, errFailed)
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+
+	exp := [][]byte{
+		[]byte(`foo`),
+		[]byte(`failed: bar`),
+		[]byte(`baz`),
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if HasFailed(msgs[0]) {
+		t.Error("Expected failure flags to be cleared")
+	}
+	for i, exp := range []string{"", "dlq", ""} {
+		if act := msgs[0].GetMetadata(i).Get("route"); act != exp {
+			t.Errorf("Wrong metadata for part %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestCatchNoFailures(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	filterConf := NewConfig()
+	filterConf.Type = "filter"
+	filterConf.Filter.Type = "static"
+	filterConf.Filter.Static = false
+
+	conf := NewConfig()
+	conf.Catch = append(conf.Catch, filterConf)
+
+	proc, err := NewCatch(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{[]byte(`foo`), []byte(`bar`)})
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 || !reflect.DeepEqual(input.GetAll(), msgs[0].GetAll()) {
+		t.Errorf("Expected message unchanged: %v", msgs)
+	}
+
+	FlagFail(input, 0, errFailed)
+	FlagFail(input, 1, errFailed)
+	if msgs, res = proc.ProcessMessage(input); len(msgs) != 0 {
+		t.Errorf("Expected all failed parts to be dropped: %v", len(msgs))
+	} else if res == nil {
+		t.Error("Expected response from dropped message")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["cbor"] = TypeSpec{
+		constructor: NewCBOR,
+		description: `
+Converts message parts between CBOR and JSON.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.
+
+### Operators
+
+#### ` + "`to_json`" + `
+
+Decodes a CBOR payload into JSON. Binary values are written as base64
+encoded strings.
+
+#### ` + "`from_json`" + `
+
+Encodes a JSON document as CBOR, where whole numbers are encoded as integers
+and all other numbers as floating points.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CBORConfig contains any configuration for the CBOR processor.
+type CBORConfig struct {
+	Parts    []int  `json:"parts" yaml:"parts"`
+	Operator string `json:"operator" yaml:"operator"`
+}
+
+// NewCBORConfig returns a CBORConfig with default values.
+func NewCBORConfig() CBORConfig {
+	return CBORConfig{
+		Parts:    []int{},
+		Operator: "to_json",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// NewCBOR returns a processor that converts message parts between CBOR and
+// JSON.
+func NewCBOR(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := binaryCodecOperatorFromString(newCBORHandle(), conf.CBOR.Operator)
+	if err != nil {
+		return nil, err
+	}
+	return newBinaryCodec("cbor", conf.CBOR.Parts, op, log, stats), nil
+}
+
+//------------------------------------------------------------------------------
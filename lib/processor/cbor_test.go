@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCBORBadOperator(t *testing.T) {
+	conf := NewConfig()
+	conf.CBOR.Operator = "nope"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	if _, err := NewCBOR(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+}
+
+func TestCBORFromJSON(t *testing.T) {
+	conf := NewConfig()
+	conf.CBOR.Operator = "from_json"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	proc, err := NewCBOR(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := proc.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(`{"a":1}`),
+		[]byte(`[1,1.5]`),
+		[]byte(`not json`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if exp, act := []byte{0xa1, 0x61, 'a', 0x01}, msgs[0].Get(0); !bytes.Equal(exp, act) {
+		t.Errorf("Wrong result: %x != %x", act, exp)
+	}
+	if exp, act := []byte{0x82, 0x01, 0xfb}, msgs[0].Get(1); !bytes.HasPrefix(act, exp) {
+		t.Errorf("Wrong result prefix: %x != %x", act, exp)
+	}
+	if HasFailed(types.ExtractPart(msgs[0], 0)) || HasFailed(types.ExtractPart(msgs[0], 1)) {
+		t.Error("Unexpected failure")
+	}
+	if !HasFailed(types.ExtractPart(msgs[0], 2)) {
+		t.Error("Expected third part to fail")
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.CBOR.Operator = "from_json"
+	encoder, err := NewCBOR(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.CBOR.Operator = "to_json"
+	decoder, err := NewCBOR(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := [][]byte{
+		[]byte(`{"bool":true,"float":-1.25,"int":-5,"list":[1,"two",null],"nested":{"str":"foo"}}`),
+		[]byte(`"just a string"`),
+	}
+	msgs, _ := encoder.ProcessMessage(types.NewMessage(input))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	msgs, _ = decoder.ProcessMessage(msgs[0])
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	for i, exp := range input {
+		if act := msgs[0].Get(i); !bytes.Equal(exp, act) {
+			t.Errorf("Wrong result: %s != %s", act, exp)
+		}
+	}
+	if HasFailed(msgs[0]) {
+		t.Errorf("Unexpected failure: %v", msgs[0].GetMetadata(0).Get(FailFlagKey))
+	}
+
+	msgs, _ = decoder.ProcessMessage(types.NewMessage([][]byte{{0xff}}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if !HasFailed(msgs[0]) {
+		t.Error("Expected bad payload to fail")
+	}
+}
+
+//------------------------------------------------------------------------------
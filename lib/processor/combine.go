@@ -74,10 +74,11 @@ func NewCombineConfig() CombineConfig {
 // benthos multiple part blob format and decodes them into multiple part
 // messages.
 type Combine struct {
-	log   log.Modular
-	stats metrics.Type
-	n     int
-	parts [][]byte
+	log      log.Modular
+	stats    metrics.Type
+	n        int
+	parts    [][]byte
+	metadata []types.Metadata
 
 	mCount     metrics.StatCounter
 	mWarnParts metrics.StatCounter
@@ -116,14 +117,19 @@ func (c *Combine) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 	}
 
 	// Add new parts to the buffer.
-	for _, part := range msg.GetAll() {
+	for i, part := range msg.GetAll() {
 		c.parts = append(c.parts, part)
+		c.metadata = append(c.metadata, msg.GetMetadata(i))
 	}
 
 	// If we have reached our target count of parts in the buffer.
 	if len(c.parts) >= c.n {
 		newMsg := types.NewMessage(c.parts)
+		for i, md := range c.metadata {
+			newMsg.SetMetadata(md, i)
+		}
 		c.parts = nil
+		c.metadata = nil
 
 		c.mSent.Incr(1)
 		msgs := [1]types.Message{newMsg}
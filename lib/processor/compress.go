@@ -22,12 +22,18 @@ package processor
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
 	"fmt"
+	"sync"
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
 )
 
 //------------------------------------------------------------------------------
@@ -37,15 +43,23 @@ func init() {
 		constructor: NewCompress,
 		description: `
 Compresses parts of a message according to the selected algorithm. Supported
-compression types are: gzip (I'll add more later). If the list of target parts
-is empty the compression will be applied to all message parts.
+compression types are: gzip, zlib, flate, zstd, lz4 and snappy. If the list of
+target parts is empty the compression will be applied to all message parts.
 
-The 'level' field might not apply to all algorithms.
+The 'level' field might not apply to all algorithms. For gzip, zlib and flate it
+ranges from -2 (Huffman only) to 9 (best compression), where -1 is the default.
+For zstd it is a zstd level from 1 to 22, and for lz4 a value above zero enables
+high compression mode, where in both cases values below one select the default
+compression. Snappy ignores the level and uses the block format, whereas zstd
+and lz4 use their frame formats.
 
 Part indexes can be negative, and if so the part will be selected from the end
 counting backwards starting from -1. E.g. if index = -1 then the selected part
 will be the last part of the message, if index = -2 then the part before the
-last element with be selected, and so on.`,
+last element with be selected, and so on.
+
+If the compression fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.`,
 	}
 }
 
@@ -71,24 +85,126 @@ func NewCompressConfig() CompressConfig {
 
 type compressFunc func(level int, bytes []byte) ([]byte, error)
 
+// gzipWriterPools contains a pool of gzip writers for each compression level,
+// as allocating a new writer for each message part is expensive.
+var gzipWriterPools [gzip.BestCompression - gzip.HuffmanOnly + 1]sync.Pool
+
 func gzipCompress(level int, b []byte) ([]byte, error) {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		return nil, fmt.Errorf("gzip: invalid compression level: %d", level)
+	}
+	pool := &gzipWriterPools[level-gzip.HuffmanOnly]
+
+	buf := &bytes.Buffer{}
+	zw, _ := pool.Get().(*gzip.Writer)
+	if zw == nil {
+		var err error
+		if zw, err = gzip.NewWriterLevel(buf, level); err != nil {
+			return nil, err
+		}
+	} else {
+		zw.Reset(buf)
+	}
+	defer pool.Put(zw)
+
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	zw.Close()
+	return buf.Bytes(), nil
+}
+
+func zlibCompress(level int, b []byte) ([]byte, error) {
 	buf := &bytes.Buffer{}
-	zw, err := gzip.NewWriterLevel(buf, level)
+	zw, err := zlib.NewWriterLevel(buf, level)
 	if err != nil {
 		return nil, err
 	}
+	if _, err = zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
 
+func flateCompress(level int, b []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw, err := flate.NewWriter(buf, level)
+	if err != nil {
+		return nil, err
+	}
 	if _, err = zw.Write(b); err != nil {
 		return nil, err
 	}
-	zw.Close()
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// zstdEncoders contains a zstd encoder for each encoder level, which are safe
+// to share as EncodeAll may be called concurrently.
+var (
+	zstdEncoders    = map[zstd.EncoderLevel]*zstd.Encoder{}
+	zstdEncodersMut sync.Mutex
+)
+
+func zstdCompress(level int, b []byte) ([]byte, error) {
+	encLevel := zstd.SpeedDefault
+	if level > 0 {
+		encLevel = zstd.EncoderLevelFromZstd(level)
+	}
+
+	zstdEncodersMut.Lock()
+	enc, exists := zstdEncoders[encLevel]
+	if !exists {
+		var err error
+		if enc, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(encLevel)); err != nil {
+			zstdEncodersMut.Unlock()
+			return nil, err
+		}
+		zstdEncoders[encLevel] = enc
+	}
+	zstdEncodersMut.Unlock()
+
+	return enc.EncodeAll(b, nil), nil
+}
+
+func lz4Compress(level int, b []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	zw := lz4.NewWriter(buf)
+	if level > 0 {
+		zw.Header.CompressionLevel = level
+	}
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
 	return buf.Bytes(), nil
 }
 
+func snappyCompress(level int, b []byte) ([]byte, error) {
+	return snappy.Encode(nil, b), nil
+}
+
 func strToCompressor(str string) (compressFunc, error) {
 	switch str {
 	case "gzip":
 		return gzipCompress, nil
+	case "zlib":
+		return zlibCompress, nil
+	case "flate":
+		return flateCompress, nil
+	case "zstd":
+		return zstdCompress, nil
+	case "lz4":
+		return lz4Compress, nil
+	case "snappy":
+		return snappyCompress, nil
 	}
 	return nil, fmt.Errorf("compression type not recognised: %v", str)
 }
@@ -156,16 +272,19 @@ func (c *Compress) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 			}
 		}
 		if !isTarget {
-			newMsg.Append(part)
+			newMsg.SetMetadata(msg.GetMetadata(i), newMsg.Append(part))
 			continue
 		}
 		newPart, err := c.comp(c.conf.Level, part)
 		if err == nil {
 			c.mSucc.Incr(1)
-			newMsg.Append(newPart)
+			newMsg.SetMetadata(msg.GetMetadata(i), newMsg.Append(newPart))
 		} else {
 			c.log.Debugf("Failed to compress message part: %v\n", err)
 			c.mErr.Incr(1)
+			index := newMsg.Append(part)
+			newMsg.SetMetadata(msg.GetMetadata(i), index)
+			FlagFail(newMsg, index, err)
 		}
 	}
 
@@ -163,3 +163,95 @@ func TestCompressEmpty(t *testing.T) {
 		t.Error("Expected failure with zero part message")
 	}
 }
+
+func TestCompressGZIPLevels(t *testing.T) {
+	input := []byte("hello world, hello world, hello world")
+
+	for level := gzip.HuffmanOnly; level <= gzip.BestCompression; level++ {
+		// Compress twice in order to exercise pooled writers.
+		for i := 0; i < 2; i++ {
+			compressed, err := gzipCompress(level, input)
+			if err != nil {
+				t.Fatalf("Level %v: %v", level, err)
+			}
+			decompressed, err := gzipDecompress(compressed)
+			if err != nil {
+				t.Fatalf("Level %v: %v", level, err)
+			}
+			if exp, act := string(input), string(decompressed); exp != act {
+				t.Errorf("Level %v: wrong result: %v != %v", level, act, exp)
+			}
+		}
+	}
+
+	if _, err := gzipCompress(gzip.BestCompression+1, input); err == nil {
+		t.Error("Expected error from bad level")
+	}
+}
+
+func TestCompressAlgorithms(t *testing.T) {
+	input := []byte("hello world, hello world, hello world")
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	tests := map[string][]int{
+		"gzip":   {-1, 1, 9},
+		"zlib":   {-2, -1, 0, 9},
+		"flate":  {-2, -1, 0, 9},
+		"zstd":   {-1, 1, 3, 22},
+		"lz4":    {-1, 0, 9},
+		"snappy": {-1},
+	}
+
+	for algo, levels := range tests {
+		for _, level := range levels {
+			conf := NewConfig()
+			conf.Compress.Algorithm = algo
+			conf.Compress.Level = level
+			conf.Decompress.Algorithm = algo
+
+			comp, err := NewCompress(conf, nil, testLog, metrics.DudType{})
+			if err != nil {
+				t.Fatalf("%v: %v", algo, err)
+			}
+			decomp, err := NewDecompress(conf, nil, testLog, metrics.DudType{})
+			if err != nil {
+				t.Fatalf("%v: %v", algo, err)
+			}
+
+			msgs, res := comp.ProcessMessage(types.NewMessage([][]byte{input}))
+			if len(msgs) != 1 {
+				t.Fatalf("%v level %v: failed to compress: %v", algo, level, res)
+			}
+			if bytes.Equal(input, msgs[0].Get(0)) {
+				t.Errorf("%v level %v: payload was not compressed", algo, level)
+			}
+			if msgs, res = decomp.ProcessMessage(msgs[0]); len(msgs) != 1 {
+				t.Fatalf("%v level %v: failed to decompress: %v", algo, level, res)
+			}
+			if exp, act := string(input), string(msgs[0].Get(0)); exp != act {
+				t.Errorf("%v level %v: wrong result: %v != %v", algo, level, act, exp)
+			}
+		}
+	}
+}
+
+func BenchmarkCompressGZIP(b *testing.B) {
+	conf := NewConfig()
+	conf.Compress.Algorithm = "gzip"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	proc, err := NewCompress(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	msg := types.NewMessage([][]byte{
+		[]byte(`{"foo":"hello world first part","bar":"hello world second part"}`),
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proc.ProcessMessage(msg)
+	}
+}
@@ -197,6 +197,16 @@ var footer = `
 [1]: ../processors/README.md#filter
 [2]: #resource`
 
+// Specs returns a specification of each condition type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
 // Descriptions returns a formatted string of collated descriptions of each
 // type.
 func Descriptions() string {
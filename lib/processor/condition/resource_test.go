@@ -45,6 +45,14 @@ func (f *fakeMgr) GetCondition(name string) (types.Condition, error) {
 	}
 	return nil, types.ErrConditionNotFound
 }
+func (f *fakeMgr) GetRateLimit(name string) (types.RateLimit, error) {
+	return nil, types.ErrRateLimitNotFound
+}
+func (f *fakeMgr) GetPipe(name string) (<-chan types.Transaction, error) {
+	return nil, types.ErrPipeNotFound
+}
+func (f *fakeMgr) SetPipe(name string, t <-chan types.Transaction)   {}
+func (f *fakeMgr) UnsetPipe(name string, t <-chan types.Transaction) {}
 
 func TestResourceCheck(t *testing.T) {
 	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
@@ -54,55 +54,95 @@ var Constructors = map[string]TypeSpec{}
 
 // Config is the all encompassing configuration struct for all processor types.
 type Config struct {
-	Type        string            `json:"type" yaml:"type"`
-	Archive     ArchiveConfig     `json:"archive" yaml:"archive"`
-	Batch       BatchConfig       `json:"batch" yaml:"batch"`
-	BoundsCheck BoundsCheckConfig `json:"bounds_check" yaml:"bounds_check"`
-	Combine     CombineConfig     `json:"combine" yaml:"combine"`
-	Compress    CompressConfig    `json:"compress" yaml:"compress"`
-	Conditional ConditionalConfig `json:"conditional" yaml:"conditional"`
-	Decompress  DecompressConfig  `json:"decompress" yaml:"decompress"`
-	Dedupe      DedupeConfig      `json:"dedupe" yaml:"dedupe"`
-	DeleteJSON  DeleteJSONConfig  `json:"delete_json" yaml:"delete_json"`
-	Filter      FilterConfig      `json:"filter" yaml:"filter"`
-	Grok        GrokConfig        `json:"grok" yaml:"grok"`
-	HashSample  HashSampleConfig  `json:"hash_sample" yaml:"hash_sample"`
-	InsertPart  InsertPartConfig  `json:"insert_part" yaml:"insert_part"`
-	JMESPath    JMESPathConfig    `json:"jmespath" yaml:"jmespath"`
-	MergeJSON   MergeJSONConfig   `json:"merge_json" yaml:"merge_json"`
-	Sample      SampleConfig      `json:"sample" yaml:"sample"`
-	SelectJSON  SelectJSONConfig  `json:"select_json" yaml:"select_json"`
-	SelectParts SelectPartsConfig `json:"select_parts" yaml:"select_parts"`
-	SetJSON     SetJSONConfig     `json:"set_json" yaml:"set_json"`
-	Split       struct{}          `json:"split" yaml:"split"`
-	Unarchive   UnarchiveConfig   `json:"unarchive" yaml:"unarchive"`
+	Type         string             `json:"type" yaml:"type"`
+	Archive      ArchiveConfig      `json:"archive" yaml:"archive"`
+	Avro         AvroConfig         `json:"avro" yaml:"avro"`
+	Batch        BatchConfig        `json:"batch" yaml:"batch"`
+	BoundsCheck  BoundsCheckConfig  `json:"bounds_check" yaml:"bounds_check"`
+	Cache        CacheConfig        `json:"cache" yaml:"cache"`
+	Catch        CatchConfig        `json:"catch" yaml:"catch"`
+	CBOR         CBORConfig         `json:"cbor" yaml:"cbor"`
+	Combine      CombineConfig      `json:"combine" yaml:"combine"`
+	Compress     CompressConfig     `json:"compress" yaml:"compress"`
+	Conditional  ConditionalConfig  `json:"conditional" yaml:"conditional"`
+	Crypto       CryptoConfig       `json:"crypto" yaml:"crypto"`
+	CSV          CSVConfig          `json:"csv" yaml:"csv"`
+	Decompress   DecompressConfig   `json:"decompress" yaml:"decompress"`
+	Dedupe       DedupeConfig       `json:"dedupe" yaml:"dedupe"`
+	DeleteJSON   DeleteJSONConfig   `json:"delete_json" yaml:"delete_json"`
+	Filter       FilterConfig       `json:"filter" yaml:"filter"`
+	ForEach      ForEachConfig      `json:"for_each" yaml:"for_each"`
+	Grok         GrokConfig         `json:"grok" yaml:"grok"`
+	GroupBy      GroupByConfig      `json:"group_by" yaml:"group_by"`
+	GroupByValue GroupByValueConfig `json:"group_by_value" yaml:"group_by_value"`
+	Hash         HashConfig         `json:"hash" yaml:"hash"`
+	HashSample   HashSampleConfig   `json:"hash_sample" yaml:"hash_sample"`
+	InsertPart   InsertPartConfig   `json:"insert_part" yaml:"insert_part"`
+	JMESPath     JMESPathConfig     `json:"jmespath" yaml:"jmespath"`
+	JQ           JQConfig           `json:"jq" yaml:"jq"`
+	JSON         JSONConfig         `json:"json" yaml:"json"`
+	MergeJSON    MergeJSONConfig    `json:"merge_json" yaml:"merge_json"`
+	Metadata     MetadataConfig     `json:"metadata" yaml:"metadata"`
+	MsgPack      MsgPackConfig      `json:"msgpack" yaml:"msgpack"`
+	Protobuf     ProtobufConfig     `json:"protobuf" yaml:"protobuf"`
+	Sample       SampleConfig       `json:"sample" yaml:"sample"`
+	SelectJSON   SelectJSONConfig   `json:"select_json" yaml:"select_json"`
+	SelectParts  SelectPartsConfig  `json:"select_parts" yaml:"select_parts"`
+	SetJSON      SetJSONConfig      `json:"set_json" yaml:"set_json"`
+	Split        SplitConfig        `json:"split" yaml:"split"`
+	Switch       SwitchConfig       `json:"switch" yaml:"switch"`
+	Text         TextConfig         `json:"text" yaml:"text"`
+	Throttle     ThrottleConfig     `json:"throttle" yaml:"throttle"`
+	Try          TryConfig          `json:"try" yaml:"try"`
+	Unarchive    UnarchiveConfig    `json:"unarchive" yaml:"unarchive"`
+	Window       WindowConfig       `json:"window" yaml:"window"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
 func NewConfig() Config {
 	return Config{
-		Type:        "bounds_check",
-		Archive:     NewArchiveConfig(),
-		Batch:       NewBatchConfig(),
-		BoundsCheck: NewBoundsCheckConfig(),
-		Combine:     NewCombineConfig(),
-		Compress:    NewCompressConfig(),
-		Conditional: NewConditionalConfig(),
-		Decompress:  NewDecompressConfig(),
-		Dedupe:      NewDedupeConfig(),
-		DeleteJSON:  NewDeleteJSONConfig(),
-		Filter:      NewFilterConfig(),
-		Grok:        NewGrokConfig(),
-		HashSample:  NewHashSampleConfig(),
-		InsertPart:  NewInsertPartConfig(),
-		JMESPath:    NewJMESPathConfig(),
-		MergeJSON:   NewMergeJSONConfig(),
-		Sample:      NewSampleConfig(),
-		SelectJSON:  NewSelectJSONConfig(),
-		SelectParts: NewSelectPartsConfig(),
-		SetJSON:     NewSetJSONConfig(),
-		Split:       struct{}{},
-		Unarchive:   NewUnarchiveConfig(),
+		Type:         "bounds_check",
+		Archive:      NewArchiveConfig(),
+		Avro:         NewAvroConfig(),
+		Batch:        NewBatchConfig(),
+		BoundsCheck:  NewBoundsCheckConfig(),
+		Cache:        NewCacheConfig(),
+		Catch:        NewCatchConfig(),
+		CBOR:         NewCBORConfig(),
+		Combine:      NewCombineConfig(),
+		Compress:     NewCompressConfig(),
+		Conditional:  NewConditionalConfig(),
+		Crypto:       NewCryptoConfig(),
+		CSV:          NewCSVConfig(),
+		Decompress:   NewDecompressConfig(),
+		Dedupe:       NewDedupeConfig(),
+		DeleteJSON:   NewDeleteJSONConfig(),
+		Filter:       NewFilterConfig(),
+		ForEach:      NewForEachConfig(),
+		Grok:         NewGrokConfig(),
+		GroupBy:      NewGroupByConfig(),
+		GroupByValue: NewGroupByValueConfig(),
+		Hash:         NewHashConfig(),
+		HashSample:   NewHashSampleConfig(),
+		InsertPart:   NewInsertPartConfig(),
+		JMESPath:     NewJMESPathConfig(),
+		JQ:           NewJQConfig(),
+		JSON:         NewJSONConfig(),
+		MergeJSON:    NewMergeJSONConfig(),
+		Metadata:     NewMetadataConfig(),
+		MsgPack:      NewMsgPackConfig(),
+		Protobuf:     NewProtobufConfig(),
+		Sample:       NewSampleConfig(),
+		SelectJSON:   NewSelectJSONConfig(),
+		SelectParts:  NewSelectPartsConfig(),
+		SetJSON:      NewSetJSONConfig(),
+		Split:        NewSplitConfig(),
+		Switch:       NewSwitchConfig(),
+		Text:         NewTextConfig(),
+		Throttle:     NewThrottleConfig(),
+		Try:          NewTryConfig(),
+		Unarchive:    NewUnarchiveConfig(),
+		Window:       NewWindowConfig(),
 	}
 }
 
@@ -175,6 +215,16 @@ You can [find some examples here][0].`
 var footer = `
 [0]: ./examples.md`
 
+// Specs returns a specification of each processor type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
 // Descriptions returns a formatted string of collated descriptions of each
 // type.
 func Descriptions() string {
@@ -0,0 +1,315 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["crypto"] = TypeSpec{
+		constructor: NewCrypto,
+		description: `
+Encrypts or decrypts message parts, allowing sensitive payloads to be protected
+before they are sent through shared brokers and decrypted by their consumers.
+
+The ` + "`operator`" + ` field is either ` + "`encrypt`" + ` or
+` + "`decrypt`" + `, and the ` + "`algorithm`" + ` field is one of the
+following:
+
+#### ` + "`aes-gcm`" + `
+
+Encrypts parts with AES in Galois/Counter Mode using the base64 encoded key of
+the field ` + "`key`" + `, or of the contents of the file at
+` + "`key_file`" + `. The key must be 16, 24 or 32 bytes in length in order to
+select AES-128, AES-192 or AES-256 respectively. Encrypted parts consist of a
+random 12 byte nonce followed by the sealed payload.
+
+#### ` + "`age`" + `
+
+Encrypts parts in the binary [age](https://age-encryption.org) format for each
+X25519 public key of the field ` + "`recipients`" + `. Parts are decrypted with
+the age identities (secret keys) of the field ` + "`key`" + `, or of the
+contents of the file at ` + "`key_file`" + `, where multiple identities are
+separated by line breaks.
+
+The key can be loaded from an environment variable using
+[config interpolation](../config_interpolation.md#environment-variables), e.g.
+` + "`key: ${ENCRYPTION_KEY}`" + `.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CryptoConfig contains any configuration for the Crypto processor.
+type CryptoConfig struct {
+	Parts      []int    `json:"parts" yaml:"parts"`
+	Operator   string   `json:"operator" yaml:"operator"`
+	Algorithm  string   `json:"algorithm" yaml:"algorithm"`
+	Key        string   `json:"key" yaml:"key"`
+	KeyFile    string   `json:"key_file" yaml:"key_file"`
+	Recipients []string `json:"recipients" yaml:"recipients"`
+}
+
+// NewCryptoConfig returns a CryptoConfig with default values.
+func NewCryptoConfig() CryptoConfig {
+	return CryptoConfig{
+		Parts:      []int{},
+		Operator:   "encrypt",
+		Algorithm:  "aes-gcm",
+		Key:        "",
+		KeyFile:    "",
+		Recipients: []string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type cryptoOperator func(body []byte) ([]byte, error)
+
+// cryptoKey returns the key of a config, which is read from a file when
+// key_file is set.
+func cryptoKey(conf CryptoConfig) (string, error) {
+	if len(conf.KeyFile) > 0 {
+		if len(conf.Key) > 0 {
+			return "", errors.New("key and key_file cannot both be set")
+		}
+		keyBytes, err := ioutil.ReadFile(conf.KeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read key_file: %v", err)
+		}
+		return strings.TrimSpace(string(keyBytes)), nil
+	}
+	if len(conf.Key) == 0 {
+		return "", errors.New("either key or key_file must be set")
+	}
+	return conf.Key, nil
+}
+
+func newAESGCM(conf CryptoConfig) (cipher.AEAD, error) {
+	key, err := cryptoKey(conf)
+	if err != nil {
+		return nil, err
+	}
+	keyBytes, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %v", err)
+	}
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func newAESGCMEncryptOperator(gcm cipher.AEAD) cryptoOperator {
+	return func(body []byte) ([]byte, error) {
+		nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(body)+gcm.Overhead())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return nil, err
+		}
+		return gcm.Seal(nonce, nonce, body, nil), nil
+	}
+}
+
+func newAESGCMDecryptOperator(gcm cipher.AEAD) cryptoOperator {
+	return func(body []byte) ([]byte, error) {
+		if len(body) < gcm.NonceSize() {
+			return nil, errors.New("payload is too short to contain a nonce")
+		}
+		nonce, sealed := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+		return gcm.Open(nil, nonce, sealed, nil)
+	}
+}
+
+func newAgeEncryptOperator(conf CryptoConfig) (cryptoOperator, error) {
+	if len(conf.Recipients) == 0 {
+		return nil, errors.New("at least one recipient must be set")
+	}
+	recipients := make([]age.Recipient, 0, len(conf.Recipients))
+	for _, r := range conf.Recipients {
+		recipient, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse recipient '%v': %v", r, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return func(body []byte) ([]byte, error) {
+		buf := &bytes.Buffer{}
+		w, err := age.Encrypt(buf, recipients...)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(body); err != nil {
+			return nil, err
+		}
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}, nil
+}
+
+func newAgeDecryptOperator(conf CryptoConfig) (cryptoOperator, error) {
+	key, err := cryptoKey(conf)
+	if err != nil {
+		return nil, err
+	}
+	identities, err := age.ParseIdentities(strings.NewReader(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identities: %v", err)
+	}
+	return func(body []byte) ([]byte, error) {
+		r, err := age.Decrypt(bytes.NewReader(body), identities...)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	}, nil
+}
+
+func cryptoOperatorFromConfig(conf CryptoConfig) (cryptoOperator, error) {
+	switch conf.Operator {
+	case "encrypt", "decrypt":
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+	}
+	encrypt := conf.Operator == "encrypt"
+
+	switch conf.Algorithm {
+	case "aes-gcm":
+		gcm, err := newAESGCM(conf)
+		if err != nil {
+			return nil, err
+		}
+		if encrypt {
+			return newAESGCMEncryptOperator(gcm), nil
+		}
+		return newAESGCMDecryptOperator(gcm), nil
+	case "age":
+		if encrypt {
+			return newAgeEncryptOperator(conf)
+		}
+		return newAgeDecryptOperator(conf)
+	}
+	return nil, fmt.Errorf("algorithm not recognised: %v", conf.Algorithm)
+}
+
+//------------------------------------------------------------------------------
+
+// Crypto is a processor that encrypts or decrypts message parts.
+type Crypto struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts    []int
+	operator cryptoOperator
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewCrypto returns a Crypto processor.
+func NewCrypto(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := cryptoOperatorFromConfig(conf.Crypto)
+	if err != nil {
+		return nil, err
+	}
+	return &Crypto{
+		conf:  conf,
+		log:   log.NewModule(".processor.crypto"),
+		stats: stats,
+
+		parts:    conf.Crypto.Parts,
+		operator: op,
+
+		mCount:     stats.GetCounter("processor.crypto.count"),
+		mErr:       stats.GetCounter("processor.crypto.error"),
+		mSucc:      stats.GetCounter("processor.crypto.success"),
+		mSent:      stats.GetCounter("processor.crypto.sent"),
+		mSentParts: stats.GetCounter("processor.crypto.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage encrypts or decrypts each targeted message part.
+func (c *Crypto) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := c.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		result, err := c.operator(newMsg.Get(index))
+		if err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		newMsg.Set(index, result)
+		c.mSucc.Incr(1)
+	}
+
+	c.mSent.Incr(1)
+	c.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
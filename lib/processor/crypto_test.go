@@ -0,0 +1,206 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func testCryptoRoundTrip(t *testing.T, encConf, decConf Config) {
+	t.Helper()
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	encConf.Crypto.Operator = "encrypt"
+	encrypter, err := NewCrypto(encConf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decConf.Crypto.Operator = "decrypt"
+	decrypter, err := NewCrypto(decConf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := [][]byte{
+		[]byte("hello world"),
+		[]byte("hello world"),
+		{},
+	}
+	msgs, _ := encrypter.ProcessMessage(types.NewMessage(input))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if HasFailed(msgs[0]) {
+		t.Fatalf("Unexpected failure: %v", msgs[0].GetMetadata(0).Get(FailFlagKey))
+	}
+	encrypted := msgs[0]
+	if bytes.Contains(encrypted.Get(0), input[0]) {
+		t.Error("Payload was not encrypted")
+	}
+	if bytes.Equal(encrypted.Get(0), encrypted.Get(1)) {
+		t.Error("Expected identical payloads to encrypt differently")
+	}
+
+	if msgs, _ = decrypter.ProcessMessage(encrypted); len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	for i, exp := range input {
+		if act := msgs[0].Get(i); !bytes.Equal(exp, act) {
+			t.Errorf("Wrong result: %s != %s", act, exp)
+		}
+	}
+	if HasFailed(msgs[0]) {
+		t.Errorf("Unexpected failure: %v", msgs[0].GetMetadata(0).Get(FailFlagKey))
+	}
+
+	tampered := encrypted.DeepCopy()
+	tampered.Get(0)[len(tampered.Get(0))-1] ^= 0xff
+	if msgs, _ = decrypter.ProcessMessage(tampered); len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if !HasFailed(types.ExtractPart(msgs[0], 0)) {
+		t.Error("Expected tampered part to fail")
+	}
+	if exp, act := tampered.Get(0), msgs[0].Get(0); !bytes.Equal(exp, act) {
+		t.Errorf("Expected failed part to be unchanged: %x != %x", act, exp)
+	}
+}
+
+func TestCryptoAESGCM(t *testing.T) {
+	for _, size := range []int{16, 24, 32} {
+		key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{byte(size)}, size))
+
+		conf := NewConfig()
+		conf.Crypto.Algorithm = "aes-gcm"
+		conf.Crypto.Key = key
+
+		testCryptoRoundTrip(t, conf, conf)
+	}
+}
+
+func TestCryptoAESGCMKeyFile(t *testing.T) {
+	keyFile, err := ioutil.TempFile("", "benthos_crypto_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(keyFile.Name())
+
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	if _, err = keyFile.WriteString(key + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	keyFile.Close()
+
+	fileConf := NewConfig()
+	fileConf.Crypto.KeyFile = keyFile.Name()
+
+	keyConf := NewConfig()
+	keyConf.Crypto.Key = key
+
+	testCryptoRoundTrip(t, fileConf, keyConf)
+}
+
+func TestCryptoAge(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encConf := NewConfig()
+	encConf.Crypto.Algorithm = "age"
+	encConf.Crypto.Recipients = []string{
+		other.Recipient().String(),
+		identity.Recipient().String(),
+	}
+
+	decConf := NewConfig()
+	decConf.Crypto.Algorithm = "age"
+	decConf.Crypto.Key = identity.String()
+
+	testCryptoRoundTrip(t, encConf, decConf)
+}
+
+func TestCryptoBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	tests := map[string]func(c *CryptoConfig){
+		"bad operator": func(c *CryptoConfig) {
+			c.Operator = "nope"
+		},
+		"bad algorithm": func(c *CryptoConfig) {
+			c.Algorithm = "nope"
+		},
+		"no key": func(c *CryptoConfig) {
+			c.Key = ""
+		},
+		"key and key file": func(c *CryptoConfig) {
+			c.KeyFile = "/does/not/exist"
+		},
+		"missing key file": func(c *CryptoConfig) {
+			c.Key = ""
+			c.KeyFile = "/does/not/exist"
+		},
+		"bad key encoding": func(c *CryptoConfig) {
+			c.Key = "not base64!"
+		},
+		"bad key size": func(c *CryptoConfig) {
+			c.Key = base64.StdEncoding.EncodeToString([]byte("short"))
+		},
+		"age no recipients": func(c *CryptoConfig) {
+			c.Algorithm = "age"
+		},
+		"age bad recipient": func(c *CryptoConfig) {
+			c.Algorithm = "age"
+			c.Recipients = []string{"nope"}
+		},
+		"age bad identity": func(c *CryptoConfig) {
+			c.Algorithm = "age"
+			c.Operator = "decrypt"
+		},
+	}
+
+	for name, fn := range tests {
+		conf := NewConfig()
+		conf.Crypto.Key = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+		fn(&conf.Crypto)
+
+		if _, err := NewCrypto(conf, nil, testLog, metrics.DudType{}); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
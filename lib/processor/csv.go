@@ -0,0 +1,288 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["csv"] = TypeSpec{
+		constructor: NewCSV,
+		description: `
+Converts message parts between CSV and JSON.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.
+
+### Operators
+
+#### ` + "`to_json`" + `
+
+Parses CSV into JSON. When ` + "`columns`" + ` is set each part must contain a
+single CSV line, which is converted into a JSON object with the columns as
+keys. Otherwise the first line of each part is treated as a header row, and the
+remaining lines are converted into a JSON array of objects. All values are
+strings.
+
+#### ` + "`from_json`" + `
+
+Converts a JSON object or an array of objects into CSV lines. When
+` + "`columns`" + ` is set the values of those keys are written in order without
+a header row. Otherwise a header row is written from the sorted keys of the
+first object. String values are written as they are and all other values are
+written as JSON.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CSVConfig contains any configuration for the CSV processor.
+type CSVConfig struct {
+	Parts     []int    `json:"parts" yaml:"parts"`
+	Operator  string   `json:"operator" yaml:"operator"`
+	Delimiter string   `json:"delimiter" yaml:"delimiter"`
+	Columns   []string `json:"columns" yaml:"columns"`
+}
+
+// NewCSVConfig returns a CSVConfig with default values.
+func NewCSVConfig() CSVConfig {
+	return CSVConfig{
+		Parts:     []int{},
+		Operator:  "to_json",
+		Delimiter: ",",
+		Columns:   []string{},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type csvOperator func(body []byte) ([]byte, error)
+
+func newCSVToJSONOperator(comma rune, columns []string) csvOperator {
+	return func(body []byte) ([]byte, error) {
+		r := csv.NewReader(bytes.NewReader(body))
+		r.Comma = comma
+		if len(columns) > 0 {
+			r.FieldsPerRecord = len(columns)
+		}
+		records, err := r.ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) > 0 {
+			if len(records) != 1 {
+				return nil, fmt.Errorf("expected a single CSV line, found %v", len(records))
+			}
+			return json.Marshal(csvRecordToObject(columns, records[0]))
+		}
+		if len(records) == 0 {
+			return nil, errors.New("expected a header row")
+		}
+		objs := make([]map[string]string, 0, len(records)-1)
+		for _, record := range records[1:] {
+			objs = append(objs, csvRecordToObject(records[0], record))
+		}
+		return json.Marshal(objs)
+	}
+}
+
+func csvRecordToObject(columns, record []string) map[string]string {
+	obj := make(map[string]string, len(columns))
+	for i, col := range columns {
+		obj[col] = record[i]
+	}
+	return obj
+}
+
+func newCSVFromJSONOperator(comma rune, columns []string) csvOperator {
+	return func(body []byte) ([]byte, error) {
+		var root interface{}
+		if err := json.Unmarshal(body, &root); err != nil {
+			return nil, err
+		}
+
+		var objs []map[string]interface{}
+		switch t := root.(type) {
+		case map[string]interface{}:
+			objs = append(objs, t)
+		case []interface{}:
+			for _, ele := range t {
+				obj, ok := ele.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("expected array of objects, found element of type %T", ele)
+				}
+				objs = append(objs, obj)
+			}
+		default:
+			return nil, fmt.Errorf("expected object or array of objects, found %T", root)
+		}
+
+		buf := &bytes.Buffer{}
+		w := csv.NewWriter(buf)
+		w.Comma = comma
+
+		header := columns
+		if len(header) == 0 {
+			if len(objs) > 0 {
+				for k := range objs[0] {
+					header = append(header, k)
+				}
+				sort.Strings(header)
+			}
+			if err := w.Write(header); err != nil {
+				return nil, err
+			}
+		}
+		for _, obj := range objs {
+			record := make([]string, len(header))
+			for i, col := range header {
+				switch v := obj[col].(type) {
+				case nil:
+				case string:
+					record[i] = v
+				default:
+					vBytes, err := json.Marshal(v)
+					if err != nil {
+						return nil, err
+					}
+					record[i] = string(vBytes)
+				}
+			}
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+		return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+	}
+}
+
+func csvOperatorFromConfig(conf CSVConfig) (csvOperator, error) {
+	if utf8.RuneCountInString(conf.Delimiter) != 1 {
+		return nil, fmt.Errorf("delimiter must be a single character, got: '%v'", conf.Delimiter)
+	}
+	comma, _ := utf8.DecodeRuneInString(conf.Delimiter)
+	switch conf.Operator {
+	case "to_json":
+		return newCSVToJSONOperator(comma, conf.Columns), nil
+	case "from_json":
+		return newCSVFromJSONOperator(comma, conf.Columns), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// CSV is a processor that converts message parts between CSV and JSON.
+type CSV struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts    []int
+	operator csvOperator
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewCSV returns a CSV processor.
+func NewCSV(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := csvOperatorFromConfig(conf.CSV)
+	if err != nil {
+		return nil, err
+	}
+	return &CSV{
+		conf:  conf,
+		log:   log.NewModule(".processor.csv"),
+		stats: stats,
+
+		parts:    conf.CSV.Parts,
+		operator: op,
+
+		mCount:     stats.GetCounter("processor.csv.count"),
+		mErr:       stats.GetCounter("processor.csv.error"),
+		mSucc:      stats.GetCounter("processor.csv.success"),
+		mSent:      stats.GetCounter("processor.csv.sent"),
+		mSentParts: stats.GetCounter("processor.csv.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage converts each targeted message part between CSV and JSON.
+func (c *CSV) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	c.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := c.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		result, err := c.operator(newMsg.Get(index))
+		if err != nil {
+			c.mErr.Incr(1)
+			c.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		newMsg.Set(index, result)
+		c.mSucc.Incr(1)
+	}
+
+	c.mSent.Incr(1)
+	c.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
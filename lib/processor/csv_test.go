@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCSVBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.CSV.Operator = "nope"
+	if _, err := NewCSV(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+
+	conf = NewConfig()
+	conf.CSV.Delimiter = ""
+	if _, err := NewCSV(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from empty delimiter")
+	}
+}
+
+func TestCSVOperators(t *testing.T) {
+	type cTest struct {
+		name      string
+		operator  string
+		delimiter string
+		columns   []string
+		input     string
+		output    string
+		failed    bool
+	}
+
+	tests := []cTest{
+		{
+			name:     "to json columns",
+			operator: "to_json",
+			columns:  []string{"name", "age"},
+			input:    `foo,10`,
+			output:   `{"age":"10","name":"foo"}`,
+		},
+		{
+			name:      "to json columns delimiter",
+			operator:  "to_json",
+			delimiter: "\t",
+			columns:   []string{"name", "age"},
+			input:     "foo, bar\t10",
+			output:    `{"age":"10","name":"foo, bar"}`,
+		},
+		{
+			name:     "to json columns too many fields",
+			operator: "to_json",
+			columns:  []string{"name", "age"},
+			input:    `foo,10,20`,
+			output:   `foo,10,20`,
+			failed:   true,
+		},
+		{
+			name:     "to json columns multiple lines",
+			operator: "to_json",
+			columns:  []string{"name", "age"},
+			input:    "foo,10\nbar,20",
+			output:   "foo,10\nbar,20",
+			failed:   true,
+		},
+		{
+			name:     "to json header",
+			operator: "to_json",
+			input:    "name,age\nfoo,10\n\"bar\nbaz\",20\n",
+			output:   `[{"age":"10","name":"foo"},{"age":"20","name":"bar\nbaz"}]`,
+		},
+		{
+			name:     "to json header only",
+			operator: "to_json",
+			input:    "name,age",
+			output:   `[]`,
+		},
+		{
+			name:     "from json columns",
+			operator: "from_json",
+			columns:  []string{"name", "age", "missing"},
+			input:    `{"name":"foo, bar","age":10,"tags":["a"]}`,
+			output:   `"foo, bar",10,`,
+		},
+		{
+			name:     "from json header",
+			operator: "from_json",
+			input:    `[{"name":"foo","age":10},{"name":"bar","nested":{"a":true}}]`,
+			output:   "age,name\n10,foo\n,bar",
+		},
+		{
+			name:     "from json not objects",
+			operator: "from_json",
+			input:    `[1,2,3]`,
+			output:   `[1,2,3]`,
+			failed:   true,
+		},
+		{
+			name:     "from json not json",
+			operator: "from_json",
+			input:    `foo,bar`,
+			output:   `foo,bar`,
+			failed:   true,
+		},
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.CSV.Operator = test.operator
+		if len(test.delimiter) > 0 {
+			conf.CSV.Delimiter = test.delimiter
+		}
+		conf.CSV.Columns = test.columns
+
+		proc, err := NewCSV(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{[]byte(test.input)}))
+		if len(msgs) != 1 {
+			t.Fatalf("%v: expected one message, received: %v", test.name, res)
+		}
+		if exp, act := test.output, string(msgs[0].Get(0)); exp != act {
+			t.Errorf("%v: wrong result: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.failed, HasFailed(msgs[0]); exp != act {
+			t.Errorf("%v: wrong failed flag: %v != %v", test.name, act, exp)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
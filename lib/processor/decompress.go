@@ -22,13 +22,19 @@ package processor
 
 import (
 	"bytes"
+	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
 )
 
 //------------------------------------------------------------------------------
@@ -38,16 +44,18 @@ func init() {
 		constructor: NewDecompress,
 		description: `
 Decompresses the parts of a message according to the selected algorithm.
-Supported decompression types are: gzip (I'll add more later). If the list of
-target parts is empty the decompression will be applied to all message parts.
+Supported decompression types are: gzip, zlib, flate, zstd, lz4 and snappy. If
+the list of target parts is empty the decompression will be applied to all
+message parts. Snappy expects the block format, whereas zstd and lz4 expect
+their frame formats.
 
 Part indexes can be negative, and if so the part will be selected from the end
 counting backwards starting from -1. E.g. if index = -1 then the selected part
 will be the last part of the message, if index = -2 then the part before the
 last element with be selected, and so on.
 
-Parts that fail to decompress (invalid format) will be removed from the message.
-If the message results in zero parts it is skipped entirely.`,
+Parts that fail to decompress (invalid format) are flagged as having failed a
+processing step and are otherwise left unchanged.`,
 	}
 }
 
@@ -71,25 +79,95 @@ func NewDecompressConfig() DecompressConfig {
 
 type decompressFunc func(bytes []byte) ([]byte, error)
 
+// gzipReaderPool contains gzip readers that are reset for each message part.
+var gzipReaderPool sync.Pool
+
 func gzipDecompress(b []byte) ([]byte, error) {
-	buf := bytes.NewBuffer(b)
-	zr, err := gzip.NewReader(buf)
-	if err != nil {
+	buf := bytes.NewReader(b)
+
+	zr, _ := gzipReaderPool.Get().(*gzip.Reader)
+	if zr == nil {
+		var err error
+		if zr, err = gzip.NewReader(buf); err != nil {
+			return nil, err
+		}
+	} else if err := zr.Reset(buf); err != nil {
+		gzipReaderPool.Put(zr)
 		return nil, err
 	}
+	defer gzipReaderPool.Put(zr)
 
 	outBuf := bytes.Buffer{}
-	if _, err = outBuf.ReadFrom(zr); err != nil && err != io.EOF {
+	if _, err := outBuf.ReadFrom(zr); err != nil && err != io.EOF {
 		return nil, err
 	}
 	zr.Close()
 	return outBuf.Bytes(), nil
 }
 
+func readAllDecompressed(r io.Reader) ([]byte, error) {
+	outBuf := bytes.Buffer{}
+	if _, err := outBuf.ReadFrom(r); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return outBuf.Bytes(), nil
+}
+
+func zlibDecompress(b []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return readAllDecompressed(zr)
+}
+
+func flateDecompress(b []byte) ([]byte, error) {
+	zr := flate.NewReader(bytes.NewReader(b))
+	defer zr.Close()
+	return readAllDecompressed(zr)
+}
+
+// zstdDecoder is shared by all zstd decompressors as DecodeAll may be called
+// concurrently.
+var (
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+	zstdDecoderOnce sync.Once
+)
+
+func zstdDecompress(b []byte) ([]byte, error) {
+	zstdDecoderOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	if zstdDecoderErr != nil {
+		return nil, zstdDecoderErr
+	}
+	return zstdDecoder.DecodeAll(b, nil)
+}
+
+func lz4Decompress(b []byte) ([]byte, error) {
+	return readAllDecompressed(lz4.NewReader(bytes.NewReader(b)))
+}
+
+func snappyDecompress(b []byte) ([]byte, error) {
+	return snappy.Decode(nil, b)
+}
+
 func strToDecompressor(str string) (decompressFunc, error) {
 	switch str {
 	case "gzip":
 		return gzipDecompress, nil
+	case "zlib":
+		return zlibDecompress, nil
+	case "flate":
+		return flateDecompress, nil
+	case "zstd":
+		return zstdDecompress, nil
+	case "lz4":
+		return lz4Decompress, nil
+	case "snappy":
+		return snappyDecompress, nil
 	}
 	return nil, fmt.Errorf("decompression type not recognised: %v", str)
 }
@@ -157,15 +235,19 @@ func (d *Decompress) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			}
 		}
 		if !isTarget {
-			newMsg.Append(part)
+			newMsg.SetMetadata(msg.GetMetadata(i), newMsg.Append(part))
 			continue
 		}
 		newPart, err := d.decomp(part)
 		if err == nil {
 			d.mSucc.Incr(1)
-			newMsg.Append(newPart)
+			newMsg.SetMetadata(msg.GetMetadata(i), newMsg.Append(newPart))
 		} else {
+			d.log.Debugf("Failed to decompress message part: %v\n", err)
 			d.mErr.Incr(1)
+			index := newMsg.Append(part)
+			newMsg.SetMetadata(msg.GetMetadata(i), index)
+			FlagFail(newMsg, index, err)
 		}
 	}
 
@@ -201,7 +201,38 @@ func TestDecompressEmpty(t *testing.T) {
 	msgs, _ = proc.ProcessMessage(types.NewMessage(
 		[][]byte{[]byte("first"), []byte("second")},
 	))
-	if len(msgs) > 0 {
-		t.Error("Expected failure with bad data")
+	if len(msgs) != 1 {
+		t.Fatal("Expected bad data to be kept")
+	}
+	for i, exp := range []string{"first", "second"} {
+		if act := string(msgs[0].Get(i)); exp != act {
+			t.Errorf("Wrong result at %v: %v != %v", i, act, exp)
+		}
+		if len(msgs[0].GetMetadata(i).Get(FailFlagKey)) == 0 {
+			t.Errorf("Expected part %v to be flagged", i)
+		}
+	}
+}
+
+func TestDecompressBadData(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, algo := range []string{"gzip", "zlib", "flate", "zstd", "lz4", "snappy"} {
+		conf := NewConfig()
+		conf.Decompress.Algorithm = algo
+
+		proc, err := NewDecompress(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", algo, err)
+		}
+
+		msgs, _ := proc.ProcessMessage(types.NewMessage(
+			[][]byte{[]byte("this is not compressed data at all")},
+		))
+		if len(msgs) != 1 {
+			t.Errorf("%v: expected bad data to be kept", algo)
+		} else if !HasFailed(msgs[0]) {
+			t.Errorf("%v: expected failure with bad data: %s", algo, msgs[0].Get(0))
+		}
 	}
 }
@@ -73,8 +73,14 @@ dedupe:
   hash: none
 ` + "```" + `
 
+It's also possible to dedupe based on the metadata of message parts by setting
+the value of ` + "`metadata_keys`" + `, where the values of each key are
+concatenated with any extracted JSON fields. When either ` + "`json_paths`" + `
+or ` + "`metadata_keys`" + ` are set the raw contents of the parts are not used.
+
 Caches should be configured as a resource, for more information check out the
-[documentation here](../caches).`,
+[documentation here](../caches). The period for which a message is considered
+a duplicate is determined by the TTL of the cache.`,
 	}
 }
 
@@ -86,6 +92,7 @@ type DedupeConfig struct {
 	HashType       string   `json:"hash" yaml:"hash"`
 	Parts          []int    `json:"parts" yaml:"parts"` // message parts to hash
 	JSONPaths      []string `json:"json_paths" yaml:"json_paths"`
+	MetadataKeys   []string `json:"metadata_keys" yaml:"metadata_keys"`
 	DropOnCacheErr bool     `json:"drop_on_err" yaml:"drop_on_err"`
 }
 
@@ -96,6 +103,7 @@ func NewDedupeConfig() DedupeConfig {
 		HashType:       "none",
 		Parts:          []int{0}, // only consider the 1st part
 		JSONPaths:      []string{},
+		MetadataKeys:   []string{},
 		DropOnCacheErr: true,
 	}
 }
@@ -153,6 +161,7 @@ type Dedupe struct {
 	cache      types.Cache
 	hasherFunc hasherFunc
 	jPaths     []string
+	mKeys      []string
 
 	mCount    metrics.StatCounter
 	mErrJSON  metrics.StatCounter
@@ -182,6 +191,7 @@ func NewDedupe(
 		cache:      c,
 		hasherFunc: hFunc,
 		jPaths:     conf.Dedupe.JSONPaths,
+		mKeys:      conf.Dedupe.MetadataKeys,
 
 		mCount:    stats.GetCounter("processor.dedupe.count"),
 		mErrJSON:  stats.GetCounter("processor.dedupe.error.json_parse"),
@@ -202,6 +212,23 @@ func (d *Dedupe) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 	hasher := d.hasherFunc()
 
 	for _, index := range d.conf.Dedupe.Parts {
+		if len(d.mKeys) > 0 {
+			// Attempt to add metadata values from part to hash.
+			meta := msg.GetMetadata(index)
+			for _, key := range d.mKeys {
+				value := meta.Get(key)
+				if len(value) == 0 {
+					continue
+				}
+				if _, err := hasher.Write([]byte(value)); nil != err {
+					d.mErrHash.Incr(1)
+					d.mDropped.Incr(1)
+					d.log.Debugf("Hash error: %v\n", err)
+				} else {
+					extractedHash = true
+				}
+			}
+		}
 		if len(d.jPaths) > 0 {
 			// Attempt to add JSON fields from part to hash.
 			jPart, err := msg.GetJSON(index)
@@ -242,7 +269,7 @@ func (d *Dedupe) ProcessMessage(msg types.Message) ([]types.Message, types.Respo
 					extractedHash = true
 				}
 			}
-		} else {
+		} else if len(d.mKeys) == 0 {
 			// Attempt to add whole part to hash.
 			if partBytes := msg.Get(index); partBytes != nil {
 				if _, err := hasher.Write(msg.Get(index)); nil != err {
@@ -43,7 +43,8 @@ func init() {
 var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
 type fakeMgr struct {
-	caches map[string]types.Cache
+	caches     map[string]types.Cache
+	ratelimits map[string]types.RateLimit
 }
 
 func (f *fakeMgr) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
@@ -57,6 +58,17 @@ func (f *fakeMgr) GetCache(name string) (types.Cache, error) {
 func (f *fakeMgr) GetCondition(name string) (types.Condition, error) {
 	return nil, types.ErrConditionNotFound
 }
+func (f *fakeMgr) GetRateLimit(name string) (types.RateLimit, error) {
+	if r, exists := f.ratelimits[name]; exists {
+		return r, nil
+	}
+	return nil, types.ErrRateLimitNotFound
+}
+func (f *fakeMgr) GetPipe(name string) (<-chan types.Transaction, error) {
+	return nil, types.ErrPipeNotFound
+}
+func (f *fakeMgr) SetPipe(name string, t <-chan types.Transaction)   {}
+func (f *fakeMgr) UnsetPipe(name string, t <-chan types.Transaction) {}
 
 func TestDedupe(t *testing.T) {
 	rndText1 := randStringRunes(20)
@@ -589,6 +601,62 @@ func TestDedupeNegBoundsCheck(t *testing.T) {
 	}
 }
 
+func TestDedupeMetadataKeys(t *testing.T) {
+	conf := NewConfig()
+	conf.Dedupe.Cache = "foocache"
+	conf.Dedupe.MetadataKeys = []string{"kafka_partition", "kafka_offset"}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	memCache, cacheErr := cache.NewMemory(cache.NewConfig(), nil, testLog, metrics.DudType{})
+	if cacheErr != nil {
+		t.Fatal(cacheErr)
+	}
+	mgr := &fakeMgr{
+		caches: map[string]types.Cache{
+			"foocache": memCache,
+		},
+	}
+
+	proc, err := NewDedupe(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newMsg := func(content, partition, offset string) types.Message {
+		msg := types.NewMessage([][]byte{[]byte(content)})
+		meta := types.NewMetadata()
+		if len(partition) > 0 {
+			meta.Set("kafka_partition", partition)
+		}
+		if len(offset) > 0 {
+			meta.Set("kafka_offset", offset)
+		}
+		msg.SetMetadata(meta)
+		return msg
+	}
+
+	tests := []struct {
+		msg       types.Message
+		propagate bool
+	}{
+		{newMsg("foo", "0", "1"), true},
+		{newMsg("bar", "0", "1"), false},
+		{newMsg("foo", "0", "2"), true},
+		{newMsg("foo", "1", "1"), true},
+		{newMsg("foo", "", ""), false},
+	}
+
+	for i, test := range tests {
+		msgs, _ := proc.ProcessMessage(test.msg)
+		if test.propagate && len(msgs) == 0 {
+			t.Errorf("Message %v told not to propagate", i)
+		} else if !test.propagate && len(msgs) > 0 {
+			t.Errorf("Message %v told to propagate", i)
+		}
+	}
+}
+
 func randStringRunes(n int) string {
 	b := make([]rune, n)
 	for i := range b {
@@ -121,10 +121,11 @@ func (p *DeleteJSON) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	}
 
 	for _, index := range targetParts {
-		jsonObj, err := msg.GetJSON(index)
+		jsonObj, err := newMsg.GetJSON(index)
 		if err != nil {
 			p.mErrJSONP.Incr(1)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
@@ -132,6 +133,7 @@ func (p *DeleteJSON) ProcessMessage(msg types.Message) ([]types.Message, types.R
 		if gPart, err = gabs.Consume(jsonObj); err != nil {
 			p.mErrJSONP.Incr(1)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
@@ -139,6 +141,7 @@ func (p *DeleteJSON) ProcessMessage(msg types.Message) ([]types.Message, types.R
 		if err := newMsg.SetJSON(index, gPart.Data()); err != nil {
 			p.mErrJSONS.Incr(1)
 			p.log.Debugf("Failed to convert json into part: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
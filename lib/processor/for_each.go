@@ -0,0 +1,147 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["for_each"] = TypeSpec{
+		constructor: NewForEach,
+		description: `
+A processor that applies a list of child processors to each message part of a
+batch individually, as if the part were a message of its own. The parts that
+result from the child processors are then combined back into a single message,
+preserving the boundary of the original batch.
+
+This is useful for applying processors that act on whole messages, such as
+` + "`archive`" + `, ` + "`dedupe`" + ` or ` + "`split`" + `, to each part of a
+batch independently. Parts that are dropped by the child processors are removed
+from the resulting message, and if all parts are dropped the message is dropped
+entirely.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ForEachConfig is a config struct containing fields for the ForEach
+// processor.
+type ForEachConfig []Config
+
+// NewForEachConfig returns a default ForEachConfig.
+func NewForEachConfig() ForEachConfig {
+	return ForEachConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// ForEach is a processor that applies child processors to each message part
+// individually.
+type ForEach struct {
+	children []Type
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount     metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewForEach returns a ForEach processor.
+func NewForEach(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var children []Type
+	for i, pconf := range conf.ForEach {
+		proc, err := New(pconf, mgr, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create child processor '%v': %v", i, err)
+		}
+		children = append(children, proc)
+	}
+	return &ForEach{
+		children: children,
+
+		log:   log.NewModule(".processor.for_each"),
+		stats: stats,
+
+		mCount:     stats.GetCounter("processor.for_each.count"),
+		mDropped:   stats.GetCounter("processor.for_each.dropped"),
+		mSent:      stats.GetCounter("processor.for_each.sent"),
+		mSentParts: stats.GetCounter("processor.for_each.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the child processors to each part of a message
+// individually and combines the results into a single message.
+func (f *ForEach) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	f.mCount.Incr(1)
+
+	newMsg := types.NewMessage(nil)
+	var lastRes types.Response
+
+	for i := 0; i < msg.Len(); i++ {
+		resultMsgs := []types.Message{types.ExtractPart(msg, i)}
+
+		for j := 0; len(resultMsgs) > 0 && j < len(f.children); j++ {
+			var nextResultMsgs []types.Message
+			for _, m := range resultMsgs {
+				var rMsgs []types.Message
+				rMsgs, lastRes = f.children[j].ProcessMessage(m)
+				nextResultMsgs = append(nextResultMsgs, rMsgs...)
+			}
+			resultMsgs = nextResultMsgs
+		}
+
+		for _, m := range resultMsgs {
+			m.Iter(func(k int, part []byte) error {
+				newMsg.SetMetadata(m.GetMetadata(k), newMsg.Append(part))
+				return nil
+			})
+		}
+	}
+
+	if newMsg.Len() == 0 {
+		f.mDropped.Incr(1)
+		if lastRes == nil {
+			lastRes = types.NewSimpleResponse(nil)
+		}
+		return nil, lastRes
+	}
+
+	f.mSent.Incr(1)
+	f.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestForEachBadChild(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	procConf := NewConfig()
+	procConf.Type = "nope"
+
+	conf := NewConfig()
+	conf.ForEach = append(conf.ForEach, procConf)
+
+	if _, err := NewForEach(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad child processor")
+	}
+}
+
+func TestForEachInsertAndFilter(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	filterConf := NewConfig()
+	filterConf.Type = "filter"
+	filterConf.Filter.Type = "content"
+	filterConf.Filter.Content.Operator = "prefix"
+	filterConf.Filter.Content.Arg = "keep"
+
+	insertConf := NewConfig()
+	insertConf.Type = "insert_part"
+	insertConf.InsertPart.Index = -1
+	insertConf.InsertPart.Content = "footer"
+
+	conf := NewConfig()
+	conf.ForEach = append(conf.ForEach, filterConf, insertConf)
+
+	proc, err := NewForEach(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte("keep 1"),
+		[]byte("drop 2"),
+		[]byte("keep 3"),
+	})
+	input.GetMetadata(2).Set("id", "3")
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+
+	exp := [][]byte{
+		[]byte("keep 1"),
+		[]byte("footer"),
+		[]byte("keep 3"),
+		[]byte("footer"),
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if act := msgs[0].GetMetadata(2).Get("id"); act != "3" {
+		t.Errorf("Metadata not preserved: %v", act)
+	}
+}
+
+func TestForEachAllDropped(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	filterConf := NewConfig()
+	filterConf.Type = "filter"
+	filterConf.Filter.Type = "content"
+	filterConf.Filter.Content.Operator = "prefix"
+	filterConf.Filter.Content.Arg = "keep"
+
+	conf := NewConfig()
+	conf.ForEach = append(conf.ForEach, filterConf)
+
+	proc, err := NewForEach(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{
+		[]byte("drop 1"),
+		[]byte("drop 2"),
+	}))
+	if len(msgs) != 0 {
+		t.Errorf("Expected no messages: %v", len(msgs))
+	}
+	if res == nil {
+		t.Error("Expected response from dropped message")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -21,6 +21,7 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -44,6 +45,21 @@ This processor respects type hints in the grok patterns, therefore with the
 pattern ` + "`%{WORD:first},%{INT:second:int}`" + ` and a payload of ` + "`foo,1`" + `
 the resulting payload would be ` + "`{\"first\":\"foo\",\"second\":1}`" + `.
 
+The standard Grok pattern library, which includes patterns such as
+` + "`COMBINEDAPACHELOG`" + ` and ` + "`SYSLOGBASE`" + `, is available when
+` + "`use_default_patterns`" + ` is true. Custom patterns can be defined with the
+field ` + "`pattern_definitions`" + `, which is a map of pattern names to
+expressions that can then be referenced within ` + "`patterns`" + ` and other
+definitions:
+
+` + "``` yaml" + `
+grok:
+  patterns:
+  - '%{NGINXACCESS}'
+  pattern_definitions:
+    NGINXACCESS: '%{IPORHOST:client} - %{DATA:user} \[%{HTTPDATE:timestamp}\] "%{WORD:method} %{DATA:path} HTTP/%{NUMBER:http_version}" %{INT:status:int} %{INT:bytes:int}'
+` + "```" + `
+
 If the list of target parts is empty the query will be applied to all message
 parts.
 
@@ -58,12 +74,13 @@ last element with be selected, and so on.`,
 
 // GrokConfig contains any configuration for the Grok processor.
 type GrokConfig struct {
-	Parts       []int    `json:"parts" yaml:"parts"`
-	Patterns    []string `json:"patterns" yaml:"patterns"`
-	RemoveEmpty bool     `json:"remove_empty_values" yaml:"remove_empty_values"`
-	NamedOnly   bool     `json:"named_captures_only" yaml:"named_captures_only"`
-	UseDefaults bool     `json:"use_default_patterns" yaml:"use_default_patterns"`
-	To          string   `json:"output_format" yaml:"output_format"`
+	Parts       []int             `json:"parts" yaml:"parts"`
+	Patterns    []string          `json:"patterns" yaml:"patterns"`
+	PatternDefs map[string]string `json:"pattern_definitions" yaml:"pattern_definitions"`
+	RemoveEmpty bool              `json:"remove_empty_values" yaml:"remove_empty_values"`
+	NamedOnly   bool              `json:"named_captures_only" yaml:"named_captures_only"`
+	UseDefaults bool              `json:"use_default_patterns" yaml:"use_default_patterns"`
+	To          string            `json:"output_format" yaml:"output_format"`
 }
 
 // NewGrokConfig returns a GrokConfig with default values.
@@ -71,6 +88,7 @@ func NewGrokConfig() GrokConfig {
 	return GrokConfig{
 		Parts:       []int{},
 		Patterns:    []string{},
+		PatternDefs: map[string]string{},
 		RemoveEmpty: true,
 		NamedOnly:   true,
 		UseDefaults: true,
@@ -105,6 +123,7 @@ func NewGrok(
 		RemoveEmptyValues:   conf.Grok.RemoveEmpty,
 		NamedCapturesOnly:   conf.Grok.NamedOnly,
 		SkipDefaultPatterns: !conf.Grok.UseDefaults,
+		Patterns:            conf.Grok.PatternDefs,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create grok compiler: %v", err)
@@ -169,12 +188,14 @@ func (g *Grok) ProcessMessage(msg types.Message) ([]types.Message, types.Respons
 		if len(values) == 0 {
 			g.mErrGrok.Incr(1)
 			g.log.Debugf("No matches found for payload: %s\n", body)
+			FlagFail(newMsg, index, errors.New("no pattern matches found"))
 			continue
 		}
 
 		if err := newMsg.SetJSON(index, values); err != nil {
 			g.mErrJSONS.Incr(1)
 			g.log.Debugf("Failed to convert grok result into json: %v\n", err)
+			FlagFail(newMsg, index, err)
 		} else {
 			g.mSucc.Incr(1)
 		}
@@ -113,3 +113,43 @@ func TestGrok(t *testing.T) {
 		}
 	}
 }
+
+func TestGrokPatternDefinitions(t *testing.T) {
+	conf := NewConfig()
+	conf.Grok.Patterns = []string{"%{NGINXACCESS}"}
+	conf.Grok.PatternDefs = map[string]string{
+		"REQUEST":     `"%{WORD:method} %{DATA:path} HTTP/%{NUMBER:http_version}"`,
+		"NGINXACCESS": `%{IPORHOST:client} - %{DATA:user} \[%{HTTPDATE:timestamp}\] %{REQUEST} %{INT:status:int} %{INT:bytes:int}`,
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	gSet, err := NewGrok(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := gSet.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(`10.0.0.1 - bob [23/Apr/2014:22:58:32 +0200] "POST /login HTTP/1.1" 200 512`),
+		[]byte(`not a log line`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Wrong count of messages")
+	}
+
+	exp := `{"bytes":512,"client":"10.0.0.1","http_version":"1.1","method":"POST","path":"/login","status":200,"timestamp":"23/Apr/2014:22:58:32 +0200","user":"bob"}`
+	if act := string(msgs[0].Get(0)); exp != act {
+		t.Errorf("Wrong output from grok: %v != %v", act, exp)
+	}
+	if !HasFailed(types.ExtractPart(msgs[0], 1)) {
+		t.Error("Expected second part to be flagged as failed")
+	}
+
+	conf.Grok.PatternDefs = map[string]string{
+		"BROKEN": `%{NOTAPATTERN}`,
+	}
+	conf.Grok.Patterns = []string{"%{BROKEN}"}
+	if _, err = NewGrok(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad pattern definition")
+	}
+}
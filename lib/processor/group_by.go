@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["group_by"] = TypeSpec{
+		constructor: NewGroupBy,
+		description: `
+Splits a message into N messages, where each message is a group of parts that
+passed a condition. Each group has a condition and an optional list of child
+processors that are applied to the resulting message of that group.
+
+Each part of the message is tested against the conditions in the order they are
+listed, and is added to the group of the first condition that passes. Parts
+that do not pass any condition are grouped together into a final message that
+has no processors applied. The order of parts within each group is preserved,
+and groups that end up with zero parts are not sent.
+
+Conditions are tested against each part individually, and therefore should
+target the first part of a message where applicable.
+
+For example, the following config would group parts containing the string
+` + "`foo`" + ` and archive them as a single part, leaving all other parts in a
+separate message:
+
+` + "``` yaml" + `
+group_by:
+- condition:
+    type: content
+    content:
+      operator: contains
+      arg: foo
+  processors:
+  - type: archive
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GroupByElement represents a group determined by a condition and a list of
+// group specific processors.
+type GroupByElement struct {
+	Condition  condition.Config `json:"condition" yaml:"condition"`
+	Processors []Config         `json:"processors" yaml:"processors"`
+}
+
+// GroupByConfig is a configuration struct containing fields for the GroupBy
+// processor, which breaks message batches down into N groups.
+type GroupByConfig []GroupByElement
+
+// NewGroupByConfig returns a GroupByConfig with default values.
+func NewGroupByConfig() GroupByConfig {
+	return GroupByConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+type group struct {
+	Condition  condition.Type
+	Processors []Type
+}
+
+// GroupBy is a processor that groups parts of a message into N messages
+// according to a list of conditions.
+type GroupBy struct {
+	log   log.Modular
+	stats metrics.Type
+
+	groups []group
+
+	mCount     metrics.StatCounter
+	mGroups    metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewGroupBy returns a GroupBy processor.
+func NewGroupBy(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var err error
+	groups := make([]group, len(conf.GroupBy))
+	for i, gConf := range conf.GroupBy {
+		if groups[i].Condition, err = condition.New(
+			gConf.Condition, mgr, log, stats,
+		); err != nil {
+			return nil, fmt.Errorf("failed to create condition for group '%v': %v", i, err)
+		}
+		for j, pConf := range gConf.Processors {
+			var proc Type
+			if proc, err = New(pConf, mgr, log, stats); err != nil {
+				return nil, fmt.Errorf("failed to create processor '%v' for group '%v': %v", j, i, err)
+			}
+			groups[i].Processors = append(groups[i].Processors, proc)
+		}
+	}
+
+	return &GroupBy{
+		log:   log.NewModule(".processor.group_by"),
+		stats: stats,
+
+		groups: groups,
+
+		mCount:     stats.GetCounter("processor.group_by.count"),
+		mGroups:    stats.GetCounter("processor.group_by.groups"),
+		mDropped:   stats.GetCounter("processor.group_by.dropped"),
+		mSent:      stats.GetCounter("processor.group_by.sent"),
+		mSentParts: stats.GetCounter("processor.group_by.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage groups the parts of a message into N messages and applies the
+// processors of each group.
+func (g *GroupBy) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	g.mCount.Incr(1)
+
+	if msg.Len() == 0 {
+		g.mDropped.Incr(1)
+		return nil, types.NewSimpleResponse(nil)
+	}
+
+	groupMsgs := make([]types.Message, len(g.groups))
+	for i := range groupMsgs {
+		groupMsgs[i] = types.NewMessage(nil)
+	}
+	remaining := types.NewMessage(nil)
+
+	msg.Iter(func(i int, part []byte) error {
+		partMsg := types.ExtractPart(msg, i)
+		target := remaining
+		for j, gr := range g.groups {
+			if gr.Condition.Check(partMsg) {
+				target = groupMsgs[j]
+				break
+			}
+		}
+		target.SetMetadata(msg.GetMetadata(i), target.Append(part))
+		return nil
+	})
+
+	var msgs []types.Message
+	var res types.Response
+
+	for i, gMsg := range groupMsgs {
+		if gMsg.Len() == 0 {
+			continue
+		}
+		g.mGroups.Incr(1)
+
+		procs := g.groups[i].Processors
+		resultMsgs := []types.Message{gMsg}
+		var resultRes types.Response
+
+		for j := 0; len(resultMsgs) > 0 && j < len(procs); j++ {
+			var nextResultMsgs []types.Message
+			for _, m := range resultMsgs {
+				var rMsgs []types.Message
+				rMsgs, resultRes = procs[j].ProcessMessage(m)
+				nextResultMsgs = append(nextResultMsgs, rMsgs...)
+			}
+			resultMsgs = nextResultMsgs
+		}
+
+		if len(resultMsgs) == 0 {
+			res = resultRes
+			continue
+		}
+		msgs = append(msgs, resultMsgs...)
+	}
+	if remaining.Len() > 0 {
+		g.mGroups.Incr(1)
+		msgs = append(msgs, remaining)
+	}
+
+	if len(msgs) == 0 {
+		g.mDropped.Incr(1)
+		if res == nil {
+			res = types.NewSimpleResponse(nil)
+		}
+		return nil, res
+	}
+
+	g.mSent.Incr(int64(len(msgs)))
+	for _, m := range msgs {
+		g.mSentParts.Incr(int64(m.Len()))
+	}
+	return msgs, nil
+}
+
+//------------------------------------------------------------------------------
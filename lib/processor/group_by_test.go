@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestGroupByErrs(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	condConf := condition.NewConfig()
+	condConf.Type = "nope"
+
+	conf := NewConfig()
+	conf.GroupBy = append(conf.GroupBy, GroupByElement{
+		Condition: condConf,
+	})
+	if _, err := NewGroupBy(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad condition")
+	}
+
+	procConf := NewConfig()
+	procConf.Type = "nope"
+
+	conf = NewConfig()
+	conf.GroupBy = append(conf.GroupBy, GroupByElement{
+		Condition:  condition.NewConfig(),
+		Processors: []Config{procConf},
+	})
+	if _, err := NewGroupBy(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad processor")
+	}
+}
+
+func TestGroupByBasic(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	condFoo := condition.NewConfig()
+	condFoo.Type = "content"
+	condFoo.Content.Operator = "contains"
+	condFoo.Content.Arg = "foo"
+
+	condBar := condition.NewConfig()
+	condBar.Type = "content"
+	condBar.Content.Operator = "contains"
+	condBar.Content.Arg = "bar"
+
+	archiveConf := NewConfig()
+	archiveConf.Type = "archive"
+	archiveConf.Archive.Format = "binary"
+
+	conf := NewConfig()
+	conf.GroupBy = GroupByConfig{
+		{
+			Condition:  condFoo,
+			Processors: []Config{archiveConf},
+		},
+		{
+			Condition: condBar,
+		},
+	}
+
+	proc, err := NewGroupBy(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte("foo 1"),
+		[]byte("bar 1"),
+		[]byte("baz 1"),
+		[]byte("foo bar 2"),
+		[]byte("bar 2"),
+		[]byte("baz 2"),
+	})
+	input.GetMetadata(4).Set("id", "bar 2")
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+
+	exp := [][][]byte{
+		{
+			types.NewMessage([][]byte{
+				[]byte("foo 1"),
+				[]byte("foo bar 2"),
+			}).Bytes(),
+		},
+		{
+			[]byte("bar 1"),
+			[]byte("bar 2"),
+		},
+		{
+			[]byte("baz 1"),
+			[]byte("baz 2"),
+		},
+	}
+
+	act := [][][]byte{}
+	for _, m := range msgs {
+		act = append(act, m.GetAll())
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if act := msgs[1].GetMetadata(1).Get("id"); act != "bar 2" {
+		t.Errorf("Metadata not preserved: %v", act)
+	}
+}
+
+func TestGroupByEmpty(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	proc, err := NewGroupBy(NewConfig(), nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(types.NewMessage(nil))
+	if len(msgs) != 0 {
+		t.Error("Expected no messages")
+	}
+	if res == nil {
+		t.Error("Expected response from empty message")
+	}
+
+	msgs, res = proc.ProcessMessage(types.NewMessage([][]byte{[]byte("foo")}))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 || !reflect.DeepEqual([][]byte{[]byte("foo")}, msgs[0].GetAll()) {
+		t.Errorf("Expected message unchanged: %v", msgs)
+	}
+}
+
+//------------------------------------------------------------------------------
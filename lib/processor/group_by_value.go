@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["group_by_value"] = TypeSpec{
+		constructor: NewGroupByValue,
+		description: `
+Splits a message into N messages, where each message is a group of parts that
+share the same value of the field ` + "`value`" + `. The value supports
+[interpolation functions](../config_interpolation.md#functions), which are
+resolved individually for each message part.
+
+The resulting messages are ordered by the first appearance of their value, and
+the order of parts within each group is preserved.
+
+For example, the following config would break a message down into groups of
+parts that share the same ` + "`tenant`" + ` JSON field:
+
+` + "``` yaml" + `
+group_by_value:
+  value: ${!json_field:tenant}
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GroupByValueConfig is a configuration struct containing fields for the
+// GroupByValue processor, which breaks message batches down into N groups of
+// equal value.
+type GroupByValueConfig struct {
+	Value string `json:"value" yaml:"value"`
+}
+
+// NewGroupByValueConfig returns a GroupByValueConfig with default values.
+func NewGroupByValueConfig() GroupByValueConfig {
+	return GroupByValueConfig{
+		Value: "${!metadata:example}",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// GroupByValue is a processor that breaks message batches down into N groups
+// of equal value.
+type GroupByValue struct {
+	log   log.Modular
+	stats metrics.Type
+
+	value     []byte
+	interpVal bool
+
+	mCount     metrics.StatCounter
+	mGroups    metrics.StatCounter
+	mDropped   metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewGroupByValue returns a GroupByValue processor.
+func NewGroupByValue(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	value := []byte(conf.GroupByValue.Value)
+	return &GroupByValue{
+		log:   log.NewModule(".processor.group_by_value"),
+		stats: stats,
+
+		value:     value,
+		interpVal: text.ContainsFunctionVariables(value),
+
+		mCount:     stats.GetCounter("processor.group_by_value.count"),
+		mGroups:    stats.GetCounter("processor.group_by_value.groups"),
+		mDropped:   stats.GetCounter("processor.group_by_value.dropped"),
+		mSent:      stats.GetCounter("processor.group_by_value.sent"),
+		mSentParts: stats.GetCounter("processor.group_by_value.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage groups the parts of a message into N messages of equal value.
+func (g *GroupByValue) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	g.mCount.Incr(1)
+
+	if msg.Len() == 0 {
+		g.mDropped.Incr(1)
+		return nil, types.NewSimpleResponse(nil)
+	}
+
+	groupIndexes := map[string]int{}
+	var msgs []types.Message
+
+	msg.Iter(func(i int, part []byte) error {
+		value := g.value
+		if g.interpVal {
+			value = text.ReplaceFunctionVariablesFor(types.ExtractPart(msg, i), value)
+		}
+
+		index, exists := groupIndexes[string(value)]
+		if !exists {
+			index = len(msgs)
+			groupIndexes[string(value)] = index
+			msgs = append(msgs, types.NewMessage(nil))
+			g.mGroups.Incr(1)
+		}
+
+		target := msgs[index]
+		target.SetMetadata(msg.GetMetadata(i), target.Append(part))
+		return nil
+	})
+
+	g.mSent.Incr(int64(len(msgs)))
+	g.mSentParts.Incr(int64(msg.Len()))
+	return msgs, nil
+}
+
+//------------------------------------------------------------------------------
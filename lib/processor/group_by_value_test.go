@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestGroupByValueBasic(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.GroupByValue.Value = "${!json_field:tenant}"
+
+	proc, err := NewGroupByValue(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte(`{"tenant":"foo","n":1}`),
+		[]byte(`{"tenant":"bar","n":2}`),
+		[]byte(`{"tenant":"foo","n":3}`),
+		[]byte(`{"n":4}`),
+		[]byte(`{"tenant":"bar","n":5}`),
+	})
+	input.GetMetadata(2).Set("id", "3")
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+
+	exp := [][][]byte{
+		{
+			[]byte(`{"tenant":"foo","n":1}`),
+			[]byte(`{"tenant":"foo","n":3}`),
+		},
+		{
+			[]byte(`{"tenant":"bar","n":2}`),
+			[]byte(`{"tenant":"bar","n":5}`),
+		},
+		{
+			[]byte(`{"n":4}`),
+		},
+	}
+
+	act := [][][]byte{}
+	for _, m := range msgs {
+		act = append(act, m.GetAll())
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if act := msgs[0].GetMetadata(1).Get("id"); act != "3" {
+		t.Errorf("Metadata not preserved: %v", act)
+	}
+}
+
+func TestGroupByValueEmpty(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	proc, err := NewGroupByValue(NewConfig(), nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(types.NewMessage(nil))
+	if len(msgs) != 0 {
+		t.Error("Expected no messages")
+	}
+	if res == nil {
+		t.Error("Expected response from empty message")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,281 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/gabs"
+	"github.com/OneOfOne/xxhash"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["hash"] = TypeSpec{
+		constructor: NewHash,
+		description: `
+Computes a hash of message parts using the chosen ` + "`algorithm`" + `. By
+default the hash replaces the contents of the part, but if the field
+` + "`metadata_key`" + ` is set then the hash is instead stored as a metadata
+value under that key and the payload is left unchanged.
+
+Supported algorithms are: md5, sha1, sha256 and xxhash64. When the field
+` + "`hmac_key`" + ` is set the md5, sha1 and sha256 algorithms are computed as
+an HMAC with that key. The xxhash64 algorithm does not support HMAC.
+
+The field ` + "`path`" + ` can be used in order to hash a value found within
+a JSON payload at a dot path rather than the full payload. String values are
+hashed as raw bytes and any other value is hashed as its JSON serialisation.
+
+The field ` + "`encoding`" + ` determines how the resulting hash is
+represented, and can be one of hex, base64 or raw.
+
+If the list of target parts is empty the hash will be computed for all message
+parts. Part indexes can be negative, and if so the part will be selected from
+the end counting backwards starting from -1.
+
+If the hash fails for a part the part is flagged as having failed a processing
+step and is otherwise left unchanged.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// HashConfig contains any configuration for the Hash processor.
+type HashConfig struct {
+	Parts       []int  `json:"parts" yaml:"parts"`
+	Algorithm   string `json:"algorithm" yaml:"algorithm"`
+	Path        string `json:"path" yaml:"path"`
+	HMACKey     string `json:"hmac_key" yaml:"hmac_key"`
+	Encoding    string `json:"encoding" yaml:"encoding"`
+	MetadataKey string `json:"metadata_key" yaml:"metadata_key"`
+}
+
+// NewHashConfig returns a HashConfig with default values.
+func NewHashConfig() HashConfig {
+	return HashConfig{
+		Parts:       []int{},
+		Algorithm:   "sha256",
+		Path:        "",
+		HMACKey:     "",
+		Encoding:    "hex",
+		MetadataKey: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type hashFactory func() hash.Hash
+
+func hashFactoryFromConfig(conf HashConfig) (hashFactory, error) {
+	var ctor func() hash.Hash
+	switch conf.Algorithm {
+	case "md5":
+		ctor = md5.New
+	case "sha1":
+		ctor = sha1.New
+	case "sha256":
+		ctor = sha256.New
+	case "xxhash64":
+		if len(conf.HMACKey) > 0 {
+			return nil, errors.New("algorithm xxhash64 does not support hmac_key")
+		}
+		return func() hash.Hash {
+			return xxhash.New64()
+		}, nil
+	default:
+		return nil, fmt.Errorf("algorithm not recognised: %v", conf.Algorithm)
+	}
+	if len(conf.HMACKey) > 0 {
+		key := []byte(conf.HMACKey)
+		return func() hash.Hash {
+			return hmac.New(ctor, key)
+		}, nil
+	}
+	return ctor, nil
+}
+
+type hashEncoder func(sum []byte) []byte
+
+func hashEncoderFromString(encoding string) (hashEncoder, error) {
+	switch encoding {
+	case "hex":
+		return func(sum []byte) []byte {
+			encoded := make([]byte, hex.EncodedLen(len(sum)))
+			hex.Encode(encoded, sum)
+			return encoded
+		}, nil
+	case "base64":
+		return func(sum []byte) []byte {
+			encoded := make([]byte, base64.StdEncoding.EncodedLen(len(sum)))
+			base64.StdEncoding.Encode(encoded, sum)
+			return encoded
+		}, nil
+	case "raw":
+		return func(sum []byte) []byte {
+			return sum
+		}, nil
+	}
+	return nil, fmt.Errorf("encoding not recognised: %v", encoding)
+}
+
+//------------------------------------------------------------------------------
+
+// Hash is a processor that computes a hash of each message part and either
+// replaces the part with it or stores it as metadata.
+type Hash struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts   []int
+	path    []string
+	metaKey string
+	newHash hashFactory
+	encode  hashEncoder
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewHash returns a Hash processor.
+func NewHash(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	newHash, err := hashFactoryFromConfig(conf.Hash)
+	if err != nil {
+		return nil, err
+	}
+	encode, err := hashEncoderFromString(conf.Hash.Encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var path []string
+	if len(conf.Hash.Path) > 0 {
+		path = strings.Split(conf.Hash.Path, ".")
+	}
+
+	return &Hash{
+		conf:  conf,
+		log:   log.NewModule(".processor.hash"),
+		stats: stats,
+
+		parts:   conf.Hash.Parts,
+		path:    path,
+		metaKey: conf.Hash.MetadataKey,
+		newHash: newHash,
+		encode:  encode,
+
+		mCount:     stats.GetCounter("processor.hash.count"),
+		mErr:       stats.GetCounter("processor.hash.error"),
+		mSucc:      stats.GetCounter("processor.hash.success"),
+		mSent:      stats.GetCounter("processor.hash.sent"),
+		mSentParts: stats.GetCounter("processor.hash.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (h *Hash) target(msg types.Message, index int) ([]byte, error) {
+	if len(h.path) == 0 {
+		return msg.Get(index), nil
+	}
+	jsonPart, err := msg.GetJSON(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse part into json: %v", err)
+	}
+	gPart, err := gabs.Consume(jsonPart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse part into json: %v", err)
+	}
+	if !gPart.Exists(h.path...) {
+		return nil, fmt.Errorf("path not found: %v", strings.Join(h.path, "."))
+	}
+	switch t := gPart.Search(h.path...).Data().(type) {
+	case string:
+		return []byte(t), nil
+	case json.Number:
+		return []byte(t.String()), nil
+	default:
+		return json.Marshal(t)
+	}
+}
+
+// ProcessMessage computes the hash of each targeted message part.
+func (h *Hash) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	h.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := h.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		data, err := h.target(newMsg, index)
+		if err != nil {
+			h.mErr.Incr(1)
+			h.log.Debugf("Failed to hash part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+
+		hasher := h.newHash()
+		hasher.Write(data)
+		result := h.encode(hasher.Sum(nil))
+
+		if len(h.metaKey) > 0 {
+			newMsg.GetMetadata(index).Set(h.metaKey, string(result))
+		} else {
+			newMsg.Set(index, result)
+		}
+		h.mSucc.Incr(1)
+	}
+
+	h.mSent.Incr(1)
+	h.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
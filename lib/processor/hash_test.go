@@ -0,0 +1,190 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/OneOfOne/xxhash"
+)
+
+//------------------------------------------------------------------------------
+
+func TestHashBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Hash.Algorithm = "nope"
+	if _, err := NewHash(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad algorithm")
+	}
+
+	conf = NewConfig()
+	conf.Hash.Encoding = "nope"
+	if _, err := NewHash(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad encoding")
+	}
+
+	conf = NewConfig()
+	conf.Hash.Algorithm = "xxhash64"
+	conf.Hash.HMACKey = "foo"
+	if _, err := NewHash(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from xxhash64 with hmac key")
+	}
+}
+
+func TestHashAlgorithms(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	input := []byte("hello world")
+	sumOf := func(h hash.Hash) []byte {
+		h.Write(input)
+		return h.Sum(nil)
+	}
+
+	tests := map[string][]byte{
+		"md5":      sumOf(md5.New()),
+		"sha1":     sumOf(sha1.New()),
+		"sha256":   sumOf(sha256.New()),
+		"xxhash64": sumOf(xxhash.New64()),
+	}
+
+	for algo, exp := range tests {
+		conf := NewConfig()
+		conf.Hash.Algorithm = algo
+		conf.Hash.Encoding = "raw"
+
+		proc, err := NewHash(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", algo, err)
+		}
+
+		msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{input}))
+		if res != nil {
+			t.Fatalf("%v: unexpected response: %v", algo, res.Error())
+		}
+		if len(msgs) != 1 {
+			t.Fatalf("%v: wrong count of messages: %v", algo, len(msgs))
+		}
+		if act := msgs[0].Get(0); string(act) != string(exp) {
+			t.Errorf("%v: wrong result: %x != %x", algo, act, exp)
+		}
+	}
+}
+
+func TestHashHMACEncodings(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("hello world"))
+	sum := mac.Sum(nil)
+
+	tests := map[string]string{
+		"hex":    hex.EncodeToString(sum),
+		"base64": base64.StdEncoding.EncodeToString(sum),
+		"raw":    string(sum),
+	}
+
+	for encoding, exp := range tests {
+		conf := NewConfig()
+		conf.Hash.HMACKey = "secret"
+		conf.Hash.Encoding = encoding
+
+		proc, err := NewHash(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", encoding, err)
+		}
+
+		msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{[]byte("hello world")}))
+		if res != nil {
+			t.Fatalf("%v: unexpected response: %v", encoding, res.Error())
+		}
+		if act := string(msgs[0].Get(0)); act != exp {
+			t.Errorf("%v: wrong result: %v != %v", encoding, act, exp)
+		}
+	}
+}
+
+func TestHashPathToMetadata(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Hash.Algorithm = "md5"
+	conf.Hash.Path = "foo.bar"
+	conf.Hash.MetadataKey = "foo_hash"
+	conf.Hash.Parts = []int{0, 1, 2}
+
+	proc, err := NewHash(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := [][]byte{
+		[]byte(`{"foo":{"bar":"hello world"}}`),
+		[]byte(`{"foo":{"bar":{"baz":5}}}`),
+		[]byte(`{"foo":{"nope":"hello world"}}`),
+		[]byte(`not json`),
+	}
+
+	msgs, res := proc.ProcessMessage(types.NewMessage(input))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+
+	md5Hex := func(b string) string {
+		sum := md5.Sum([]byte(b))
+		return hex.EncodeToString(sum[:])
+	}
+
+	if exp, act := md5Hex("hello world"), msgs[0].GetMetadata(0).Get("foo_hash"); exp != act {
+		t.Errorf("Wrong hash for part 0: %v != %v", act, exp)
+	}
+	if exp, act := md5Hex(`{"baz":5}`), msgs[0].GetMetadata(1).Get("foo_hash"); exp != act {
+		t.Errorf("Wrong hash for part 1: %v != %v", act, exp)
+	}
+	if !HasFailed(types.ExtractPart(msgs[0], 2)) {
+		t.Error("Expected part 2 to be flagged as failed")
+	}
+	if HasFailed(types.ExtractPart(msgs[0], 3)) {
+		t.Error("Expected part 3 to be untouched")
+	}
+	for i, p := range input {
+		if act := string(msgs[0].Get(i)); act != string(p) {
+			t.Errorf("Payload of part %v changed: %v != %v", i, act, string(p))
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
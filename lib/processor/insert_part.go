@@ -105,7 +105,7 @@ func (p *InsertPart) ProcessMessage(msg types.Message) ([]types.Message, types.R
 
 	var newPart []byte
 	if p.interpolate {
-		newPart = text.ReplaceFunctionVariables(p.part)
+		newPart = text.ReplaceFunctionVariablesFor(msg, p.part)
 	} else {
 		newPart = p.part
 	}
@@ -136,6 +136,13 @@ func (p *InsertPart) ProcessMessage(msg types.Message) ([]types.Message, types.R
 	copy(newParts[index+1:], post)
 
 	newMsg := types.NewMessage(newParts)
+	for i := 0; i < msgLen; i++ {
+		j := i
+		if i >= index {
+			j = i + 1
+		}
+		newMsg.SetMetadata(msg.GetMetadata(i), j)
+	}
 
 	p.mSent.Incr(1)
 	msgs := [1]types.Message{newMsg}
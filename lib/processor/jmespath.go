@@ -161,6 +161,7 @@ func (p *JMESPath) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		if err != nil {
 			p.mErrJSONP.Incr(1)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
@@ -168,12 +169,14 @@ func (p *JMESPath) ProcessMessage(msg types.Message) ([]types.Message, types.Res
 		if result, err = p.query.Search(jsonPart); err != nil {
 			p.mErrJMES.Incr(1)
 			p.log.Debugf("Failed to search json: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
 		if err = newMsg.SetJSON(index, result); err != nil {
 			p.mErrJSONS.Incr(1)
 			p.log.Debugf("Failed to convert jmespath result into part: %v\n", err)
+			FlagFail(newMsg, index, err)
 		} else {
 			p.mSucc.Incr(1)
 		}
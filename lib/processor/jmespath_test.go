@@ -59,6 +59,9 @@ func TestJMESPathAllParts(t *testing.T) {
 			t.Errorf("Wrong output from json: %v != %v", act, exp)
 		}
 	}
+	if HasFailed(msgs[0]) {
+		t.Error("Unexpected failure flag")
+	}
 }
 
 func TestJMESPathValidation(t *testing.T) {
@@ -84,6 +87,12 @@ func TestJMESPathValidation(t *testing.T) {
 	if exp, act := "this is bad json", string(msgs[0].GetAll()[0]); exp != act {
 		t.Errorf("Wrong output from bad json: %v != %v", act, exp)
 	}
+	if !HasFailed(msgs[0]) {
+		t.Error("Expected bad json to be flagged as failed")
+	}
+	if HasFailed(msgIn) {
+		t.Error("Input message was flagged as failed")
+	}
 
 	conf.JMESPath.Parts = []int{5}
 
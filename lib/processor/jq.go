@@ -0,0 +1,227 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/itchyny/gojq"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["jq"] = TypeSpec{
+		constructor: NewJQ,
+		description: `
+Parses a message part as a JSON blob and attempts to apply a
+[jq](https://stedolan.github.io/jq/manual/) query to it, replacing the contents
+of the part with the result. If the list of target parts is empty the query will
+be applied to all message parts.
+
+If the query emits a single value then that value becomes the new contents of
+the part. If the query emits multiple values they are collected into an array,
+and if it emits no values the part is set to ` + "`null`" + `.
+
+For example, with the following config:
+
+` + "``` yaml" + `
+jq:
+  parts: [ 0 ]
+  query: '{Cities: [.locations[] | select(.state == "WA").name] | sort | join(", ")}'
+` + "```" + `
+
+If the initial contents of part 0 were:
+
+` + "``` json" + `
+{
+  "locations": [
+    {"name": "Seattle", "state": "WA"},
+    {"name": "New York", "state": "NY"},
+    {"name": "Bellevue", "state": "WA"},
+    {"name": "Olympia", "state": "WA"}
+  ]
+}
+` + "```" + `
+
+Then the resulting contents of part 0 would be:
+
+` + "``` json" + `
+{"Cities": "Bellevue, Olympia, Seattle"}
+` + "```" + `
+
+If ` + "`output_raw`" + ` is set to ` + "`true`" + ` then a result that is a
+string is written without quotes, which is useful for extracting plain values.
+
+Part indexes can be negative, and if so the part will be selected from the end
+counting backwards starting from -1.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JQConfig contains any configuration for the JQ processor.
+type JQConfig struct {
+	Parts     []int  `json:"parts" yaml:"parts"`
+	Query     string `json:"query" yaml:"query"`
+	OutputRaw bool   `json:"output_raw" yaml:"output_raw"`
+}
+
+// NewJQConfig returns a JQConfig with default values.
+func NewJQConfig() JQConfig {
+	return JQConfig{
+		Parts:     []int{},
+		Query:     ".",
+		OutputRaw: false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JQ is a processor that executes jq queries on a message part and replaces
+// the contents with the result.
+type JQ struct {
+	parts []int
+	code  *gojq.Code
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount    metrics.StatCounter
+	mErrJSONP metrics.StatCounter
+	mErrQuery metrics.StatCounter
+	mErrJSONS metrics.StatCounter
+	mSucc     metrics.StatCounter
+	mSent     metrics.StatCounter
+}
+
+// NewJQ returns a JQ processor.
+func NewJQ(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	query, err := gojq.Parse(conf.JQ.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jq query: %v", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile jq query: %v", err)
+	}
+	j := &JQ{
+		parts: conf.JQ.Parts,
+		code:  code,
+		conf:  conf,
+		log:   log.NewModule(".processor.jq"),
+		stats: stats,
+
+		mCount:    stats.GetCounter("processor.jq.count"),
+		mErrJSONP: stats.GetCounter("processor.jq.error.json_parse"),
+		mErrQuery: stats.GetCounter("processor.jq.error.query"),
+		mErrJSONS: stats.GetCounter("processor.jq.error.json_set"),
+		mSucc:     stats.GetCounter("processor.jq.success"),
+		mSent:     stats.GetCounter("processor.jq.sent"),
+	}
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+
+// query runs the compiled query against a JSON document and collects the
+// emitted values.
+func (p *JQ) query(jsonPart interface{}) (interface{}, error) {
+	var results []interface{}
+	iter := p.code.Run(jsonPart)
+	for {
+		v, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, isErr := v.(error); isErr {
+			return nil, err
+		}
+		results = append(results, v)
+	}
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return results[0], nil
+	}
+	return results, nil
+}
+
+// ProcessMessage applies the query to each targeted message part.
+func (p *JQ) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := p.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		jsonPart, err := newMsg.GetJSON(index)
+		if err != nil {
+			p.mErrJSONP.Incr(1)
+			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+
+		var result interface{}
+		if result, err = p.query(jsonPart); err != nil {
+			p.mErrQuery.Incr(1)
+			p.log.Debugf("Failed to execute query: %v\n", err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+
+		if str, isStr := result.(string); isStr && p.conf.JQ.OutputRaw {
+			newMsg.Set(index, []byte(str))
+			p.mSucc.Incr(1)
+			continue
+		}
+
+		if err = newMsg.SetJSON(index, result); err != nil {
+			p.mErrJSONS.Incr(1)
+			p.log.Debugf("Failed to convert jq result into part: %v\n", err)
+			FlagFail(newMsg, index, err)
+		} else {
+			p.mSucc.Incr(1)
+		}
+	}
+
+	msgs := [1]types.Message{newMsg}
+
+	p.mSent.Incr(1)
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestJQBadQuery(t *testing.T) {
+	conf := NewConfig()
+	conf.JQ.Query = "this is ] not a query"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	if _, err := NewJQ(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad query")
+	}
+}
+
+func TestJQQueries(t *testing.T) {
+	type jTest struct {
+		name      string
+		query     string
+		outputRaw bool
+		input     string
+		output    string
+		failed    bool
+	}
+
+	tests := []jTest{
+		{
+			name:   "identity",
+			query:  ".",
+			input:  `{"foo":{"bar":5}}`,
+			output: `{"foo":{"bar":5}}`,
+		},
+		{
+			name:   "projection",
+			query:  `{Cities: [.locations[] | select(.state == "WA").name] | sort | join(", ")}`,
+			input:  `{"locations":[{"name":"Seattle","state":"WA"},{"name":"New York","state":"NY"},{"name":"Bellevue","state":"WA"},{"name":"Olympia","state":"WA"}]}`,
+			output: `{"Cities":"Bellevue, Olympia, Seattle"}`,
+		},
+		{
+			name:   "multiple results",
+			query:  `.foo[]`,
+			input:  `{"foo":[1,2,3]}`,
+			output: `[1,2,3]`,
+		},
+		{
+			name:   "no results",
+			query:  `empty`,
+			input:  `{"foo":1}`,
+			output: `null`,
+		},
+		{
+			name:   "string quoted",
+			query:  `.foo`,
+			input:  `{"foo":"bar"}`,
+			output: `"bar"`,
+		},
+		{
+			name:      "string raw",
+			query:     `.foo`,
+			outputRaw: true,
+			input:     `{"foo":"bar"}`,
+			output:    `bar`,
+		},
+		{
+			name:   "query error",
+			query:  `.foo | error("nope")`,
+			input:  `{"foo":"bar"}`,
+			output: `{"foo":"bar"}`,
+			failed: true,
+		},
+		{
+			name:   "not json",
+			query:  `.foo`,
+			input:  `not json`,
+			output: `not json`,
+			failed: true,
+		},
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.JQ.Query = test.query
+		conf.JQ.OutputRaw = test.outputRaw
+
+		proc, err := NewJQ(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{[]byte(test.input)}))
+		if len(msgs) != 1 {
+			t.Fatalf("%v: expected one message, received: %v", test.name, res)
+		}
+		if exp, act := test.output, string(msgs[0].Get(0)); exp != act {
+			t.Errorf("%v: wrong result: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.failed, HasFailed(msgs[0]); exp != act {
+			t.Errorf("%v: wrong failed flag: %v != %v", test.name, act, exp)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,386 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+	"github.com/Jeffail/gabs"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["json"] = TypeSpec{
+		constructor: NewJSON,
+		description: `
+Parses a message part as a JSON blob, performs a mutation on the data, and then
+overwrites the previous contents with the new value.
+
+If the path is empty or "." the root of the data will be targeted.
+
+If the list of target parts is empty the processor will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+This processor will interpolate functions within the 'value' field, which are
+resolved individually for each message part. You can find a list of functions
+[here](../config_interpolation.md#functions).
+
+If the operation fails for a part, for example when the part is not valid JSON,
+the part is flagged as having failed a processing step and is otherwise left
+unchanged.
+
+### Operators
+
+#### ` + "`set`" + `
+
+Sets the value of a field at a dot path. If the path does not exist all objects
+in the path are created (unless there is a collision). The value can be any type,
+including objects and arrays, and when using YAML config files a YAML object is
+converted into a JSON object.
+
+#### ` + "`delete`" + `
+
+Removes a key identified by the dot path. If the path does not exist this is a
+no-op.
+
+#### ` + "`move`" + `
+
+Moves the value of a path to a new location. The destination path is specified
+in the value field as a string. If the source path does not exist the operation
+fails.
+
+#### ` + "`copy`" + `
+
+Copies the value of a path to a new location, leaving the original intact. The
+destination path is specified in the value field as a string. If the source path
+does not exist the operation fails.
+
+#### ` + "`select`" + `
+
+Reads the value found at a dot path and replaces the original contents entirely
+by the new value. Strings are written raw, any other type is written as JSON.
+
+#### ` + "`append`" + `
+
+Appends the value to an array at the target path. If the path does not exist it
+is created as an array, and if it exists but is not an array then it is
+converted into an array containing the existing value followed by the new
+value. If the value is an array then its elements are appended individually.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// JSONConfig contains any configuration for the JSON processor.
+type JSONConfig struct {
+	Parts    []int        `json:"parts" yaml:"parts"`
+	Operator string       `json:"operator" yaml:"operator"`
+	Path     string       `json:"path" yaml:"path"`
+	Value    rawJSONValue `json:"value" yaml:"value"`
+}
+
+// NewJSONConfig returns a JSONConfig with default values.
+func NewJSONConfig() JSONConfig {
+	return JSONConfig{
+		Parts:    []int{},
+		Operator: "select",
+		Path:     "",
+		Value:    rawJSONValue(`""`),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// jsonOperator performs an operation on a JSON document, the value argument is
+// the interpolated raw JSON of the value field.
+type jsonOperator func(body interface{}, value json.RawMessage) (interface{}, error)
+
+// errJSONPathNotFound is returned by operators that require a path to exist.
+var errJSONPathNotFound = errors.New("path not found")
+
+func newSetJSONOperator(path []string) jsonOperator {
+	return func(body interface{}, value json.RawMessage) (interface{}, error) {
+		var data interface{}
+		if err := json.Unmarshal(value, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse value: %v", err)
+		}
+		if len(path) == 0 {
+			return data, nil
+		}
+
+		gPart, _ := gabs.Consume(body)
+		if _, err := gPart.Set(data, path...); err != nil {
+			return nil, err
+		}
+		return gPart.Data(), nil
+	}
+}
+
+func newDeleteJSONOperator(path []string) jsonOperator {
+	return func(body interface{}, value json.RawMessage) (interface{}, error) {
+		if len(path) == 0 {
+			return nil, nil
+		}
+
+		gPart, _ := gabs.Consume(body)
+		if gPart.Exists(path...) {
+			if err := gPart.Delete(path...); err != nil {
+				return nil, err
+			}
+		}
+		return gPart.Data(), nil
+	}
+}
+
+func newMoveJSONOperator(path []string, keepSource bool) jsonOperator {
+	return func(body interface{}, value json.RawMessage) (interface{}, error) {
+		var destStr string
+		if err := json.Unmarshal(value, &destStr); err != nil {
+			return nil, fmt.Errorf("failed to parse destination path: %v", err)
+		}
+		dest := jsonPathToSlice(destStr)
+
+		gPart, _ := gabs.Consume(body)
+		if len(path) > 0 && !gPart.Exists(path...) {
+			return nil, errJSONPathNotFound
+		}
+		data := gPart.Search(path...).Data()
+
+		if !keepSource && len(path) > 0 {
+			if err := gPart.Delete(path...); err != nil {
+				return nil, err
+			}
+		}
+		if len(dest) == 0 {
+			return data, nil
+		}
+		if keepSource {
+			// Copy the value via JSON in order to avoid sharing structures.
+			var copied interface{}
+			copyBytes, err := json.Marshal(data)
+			if err == nil {
+				err = json.Unmarshal(copyBytes, &copied)
+			}
+			if err != nil {
+				return nil, err
+			}
+			data = copied
+		}
+		if _, err := gPart.Set(data, dest...); err != nil {
+			return nil, err
+		}
+		return gPart.Data(), nil
+	}
+}
+
+func newAppendJSONOperator(path []string) jsonOperator {
+	return func(body interface{}, value json.RawMessage) (interface{}, error) {
+		var data interface{}
+		if err := json.Unmarshal(value, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse value: %v", err)
+		}
+
+		gPart, _ := gabs.Consume(body)
+
+		var array []interface{}
+		if len(path) == 0 || gPart.Exists(path...) {
+			switch t := gPart.Search(path...).Data().(type) {
+			case []interface{}:
+				array = t
+			default:
+				array = []interface{}{t}
+			}
+		}
+		if values, isArray := data.([]interface{}); isArray {
+			array = append(array, values...)
+		} else {
+			array = append(array, data)
+		}
+
+		if len(path) == 0 {
+			return array, nil
+		}
+		if _, err := gPart.Set(array, path...); err != nil {
+			return nil, err
+		}
+		return gPart.Data(), nil
+	}
+}
+
+func jsonPathToSlice(path string) []string {
+	if len(path) == 0 || path == "." {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+func getJSONOperator(operator string, path []string) (jsonOperator, error) {
+	switch operator {
+	case "set":
+		return newSetJSONOperator(path), nil
+	case "delete":
+		return newDeleteJSONOperator(path), nil
+	case "move":
+		return newMoveJSONOperator(path, false), nil
+	case "copy":
+		return newMoveJSONOperator(path, true), nil
+	case "append":
+		return newAppendJSONOperator(path), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", operator)
+}
+
+//------------------------------------------------------------------------------
+
+// JSON is a processor that performs an operation on a JSON payload.
+type JSON struct {
+	parts       []int
+	path        []string
+	selectOp    bool
+	operator    jsonOperator
+	interpolate bool
+	valueBytes  rawJSONValue
+
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	mCount    metrics.StatCounter
+	mErrJSONP metrics.StatCounter
+	mErrJSONS metrics.StatCounter
+	mErr      metrics.StatCounter
+	mSucc     metrics.StatCounter
+	mSent     metrics.StatCounter
+}
+
+// NewJSON returns a JSON processor.
+func NewJSON(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	j := &JSON{
+		parts:      conf.JSON.Parts,
+		path:       jsonPathToSlice(conf.JSON.Path),
+		valueBytes: conf.JSON.Value,
+		conf:       conf,
+		log:        log.NewModule(".processor.json"),
+		stats:      stats,
+
+		mCount:    stats.GetCounter("processor.json.count"),
+		mErrJSONP: stats.GetCounter("processor.json.error.json_parse"),
+		mErrJSONS: stats.GetCounter("processor.json.error.json_set"),
+		mErr:      stats.GetCounter("processor.json.error"),
+		mSucc:     stats.GetCounter("processor.json.success"),
+		mSent:     stats.GetCounter("processor.json.sent"),
+	}
+
+	if conf.JSON.Operator == "select" {
+		j.selectOp = true
+	} else {
+		var err error
+		if j.operator, err = getJSONOperator(conf.JSON.Operator, j.path); err != nil {
+			return nil, err
+		}
+	}
+
+	j.interpolate = text.ContainsFunctionVariables(j.valueBytes)
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the operation to each targeted message part.
+func (p *JSON) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := p.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		jsonPart, err := newMsg.GetJSON(index)
+		if err != nil {
+			p.mErrJSONP.Incr(1)
+			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+
+		if p.selectOp {
+			gPart, _ := gabs.Consume(jsonPart)
+			switch t := gPart.Search(p.path...).Data().(type) {
+			case string:
+				newMsg.Set(index, []byte(t))
+			default:
+				if err = newMsg.SetJSON(index, t); err != nil {
+					p.mErrJSONS.Incr(1)
+					p.log.Debugf("Failed to convert json into part: %v\n", err)
+					FlagFail(newMsg, index, err)
+					continue
+				}
+			}
+			p.mSucc.Incr(1)
+			continue
+		}
+
+		valueBytes := p.valueBytes
+		if p.interpolate {
+			valueBytes = text.ReplaceFunctionVariablesFor(types.ExtractPart(newMsg, index), valueBytes)
+		}
+
+		var data interface{}
+		if data, err = p.operator(jsonPart, json.RawMessage(valueBytes)); err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Failed to apply operator: %v\n", err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+
+		if err = newMsg.SetJSON(index, data); err != nil {
+			p.mErrJSONS.Incr(1)
+			p.log.Debugf("Failed to convert json into part: %v\n", err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		p.mSucc.Incr(1)
+	}
+
+	msgs := [1]types.Message{newMsg}
+
+	p.mSent.Incr(1)
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
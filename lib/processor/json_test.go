@@ -0,0 +1,214 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestJSONBadOperator(t *testing.T) {
+	conf := NewConfig()
+	conf.JSON.Operator = "nope"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	if _, err := NewJSON(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+}
+
+func TestJSONOperators(t *testing.T) {
+	type jTest struct {
+		name     string
+		operator string
+		path     string
+		value    string
+		input    string
+		output   string
+		failed   bool
+	}
+
+	tests := []jTest{
+		{
+			name:     "set 1",
+			operator: "set",
+			path:     "foo.bar",
+			value:    `{"baz":1}`,
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"foo":{"bar":{"baz":1}}}`,
+		},
+		{
+			name:     "set root",
+			operator: "set",
+			path:     ".",
+			value:    `[1,2]`,
+			input:    `{"foo":{"bar":5}}`,
+			output:   `[1,2]`,
+		},
+		{
+			name:     "set interpolated",
+			operator: "set",
+			path:     "foo.bar",
+			value:    `"${!json_field:foo.baz}"`,
+			input:    `{"foo":{"bar":5,"baz":"qux"}}`,
+			output:   `{"foo":{"bar":"qux","baz":"qux"}}`,
+		},
+		{
+			name:     "delete 1",
+			operator: "delete",
+			path:     "foo.bar",
+			input:    `{"foo":{"bar":5,"baz":6}}`,
+			output:   `{"foo":{"baz":6}}`,
+		},
+		{
+			name:     "delete missing",
+			operator: "delete",
+			path:     "foo.nope",
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"foo":{"bar":5}}`,
+		},
+		{
+			name:     "move 1",
+			operator: "move",
+			path:     "foo.bar",
+			value:    `"baz.qux"`,
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"baz":{"qux":5},"foo":{}}`,
+		},
+		{
+			name:     "move to root",
+			operator: "move",
+			path:     "foo",
+			value:    `"."`,
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"bar":5}`,
+		},
+		{
+			name:     "move missing",
+			operator: "move",
+			path:     "foo.nope",
+			value:    `"baz"`,
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"foo":{"bar":5}}`,
+			failed:   true,
+		},
+		{
+			name:     "copy 1",
+			operator: "copy",
+			path:     "foo",
+			value:    `"baz"`,
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"baz":{"bar":5},"foo":{"bar":5}}`,
+		},
+		{
+			name:     "select string",
+			operator: "select",
+			path:     "foo.bar",
+			input:    `{"foo":{"bar":"hello world"}}`,
+			output:   `hello world`,
+		},
+		{
+			name:     "select object",
+			operator: "select",
+			path:     "foo",
+			input:    `{"foo":{"bar":5}}`,
+			output:   `{"bar":5}`,
+		},
+		{
+			name:     "append existing array",
+			operator: "append",
+			path:     "foo",
+			value:    `3`,
+			input:    `{"foo":[1,2]}`,
+			output:   `{"foo":[1,2,3]}`,
+		},
+		{
+			name:     "append array values",
+			operator: "append",
+			path:     "foo",
+			value:    `[3,4]`,
+			input:    `{"foo":[1,2]}`,
+			output:   `{"foo":[1,2,3,4]}`,
+		},
+		{
+			name:     "append non array",
+			operator: "append",
+			path:     "foo",
+			value:    `"bar"`,
+			input:    `{"foo":"baz"}`,
+			output:   `{"foo":["baz","bar"]}`,
+		},
+		{
+			name:     "append missing",
+			operator: "append",
+			path:     "foo.bar",
+			value:    `"baz"`,
+			input:    `{}`,
+			output:   `{"foo":{"bar":["baz"]}}`,
+		},
+		{
+			name:     "not json",
+			operator: "set",
+			path:     "foo",
+			value:    `1`,
+			input:    `not json`,
+			output:   `not json`,
+			failed:   true,
+		},
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.JSON.Operator = test.operator
+		conf.JSON.Path = test.path
+		conf.JSON.Value = rawJSONValue(test.value)
+
+		jSet, err := NewJSON(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		inMsg := types.NewMessage([][]byte{[]byte(test.input)})
+		msgs, res := jSet.ProcessMessage(inMsg)
+		if len(msgs) != 1 {
+			t.Fatalf("%v: expected one message, received: %v", test.name, res)
+		}
+		if exp, act := test.output, string(msgs[0].Get(0)); exp != act {
+			t.Errorf("%v: wrong result: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.failed, HasFailed(msgs[0]); exp != act {
+			t.Errorf("%v: wrong failed flag: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.input, string(inMsg.Get(0)); exp != act {
+			t.Errorf("%v: input message was modified: %v != %v", test.name, act, exp)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -106,9 +106,13 @@ func NewMergeJSON(
 func (p *MergeJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	p.mCount.Incr(1)
 
+	// Merging can modify the structures of the parts being merged, and so they
+	// are parsed from a copy that does not share the JSON cache of msg.
+	parsedMsg := msg.ShallowCopy()
+
 	newPart := gabs.New()
 	mergeFunc := func(index int) {
-		jsonPart, err := msg.GetJSON(index)
+		jsonPart, err := parsedMsg.GetJSON(index)
 		if err != nil {
 			p.mErrJSONP.Incr(1)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
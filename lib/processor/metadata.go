@@ -0,0 +1,259 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["metadata"] = TypeSpec{
+		constructor: NewMetadata,
+		description: `
+Performs operations on the metadata of a message. Metadata are key/value pairs
+that are associated with each message part, and are populated by inputs with
+attributes of their source such as Kafka keys, AMQP headers or HTTP headers.
+Metadata can be referenced within configuration fields using the
+[interpolation function](../config_interpolation.md#metadata)
+` + "`${!metadata:key}`" + `, and many outputs map it into attributes of the
+messages they send.
+
+The ` + "`key`" + ` and ` + "`value`" + ` fields support
+[interpolation functions](../config_interpolation.md#functions), which are
+resolved individually for each message part.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+### Operators
+
+#### ` + "`set`" + `
+
+Sets the value of a metadata key.
+
+#### ` + "`delete`" + `
+
+Removes a metadata key.
+
+#### ` + "`delete_all`" + `
+
+Removes all metadata values from the message part.
+
+#### ` + "`copy`" + `
+
+Copies the value of the metadata key ` + "`key`" + ` to the key named by the
+field ` + "`value`" + `.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// MetadataConfig contains any configuration for the Metadata processor.
+type MetadataConfig struct {
+	Parts    []int  `json:"parts" yaml:"parts"`
+	Operator string `json:"operator" yaml:"operator"`
+	Key      string `json:"key" yaml:"key"`
+	Value    string `json:"value" yaml:"value"`
+}
+
+// NewMetadataConfig returns a MetadataConfig with default values.
+func NewMetadataConfig() MetadataConfig {
+	return MetadataConfig{
+		Parts:    []int{},
+		Operator: "set",
+		Key:      "example",
+		Value:    "${!hostname}",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type metadataOperator func(m types.Metadata, key, value string) error
+
+func newMetadataSetOperator() metadataOperator {
+	return func(m types.Metadata, key, value string) error {
+		m.Set(key, value)
+		return nil
+	}
+}
+
+func newMetadataDeleteOperator() metadataOperator {
+	return func(m types.Metadata, key, value string) error {
+		m.Delete(key)
+		return nil
+	}
+}
+
+func newMetadataDeleteAllOperator() metadataOperator {
+	return func(m types.Metadata, key, value string) error {
+		var keys []string
+		m.Iter(func(k, _ string) error {
+			keys = append(keys, k)
+			return nil
+		})
+		for _, k := range keys {
+			m.Delete(k)
+		}
+		return nil
+	}
+}
+
+func newMetadataCopyOperator() metadataOperator {
+	return func(m types.Metadata, key, value string) error {
+		if len(value) == 0 {
+			return errors.New("resolved destination key was empty")
+		}
+		m.Set(value, m.Get(key))
+		return nil
+	}
+}
+
+func metadataOperatorFromString(operator string) (metadataOperator, error) {
+	switch operator {
+	case "set":
+		return newMetadataSetOperator(), nil
+	case "delete":
+		return newMetadataDeleteOperator(), nil
+	case "delete_all":
+		return newMetadataDeleteAllOperator(), nil
+	case "copy":
+		return newMetadataCopyOperator(), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", operator)
+}
+
+//------------------------------------------------------------------------------
+
+// Metadata is a processor that performs an operation on the metadata of each
+// message part.
+type Metadata struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts     []int
+	operator  metadataOperator
+	needsKey  bool
+	key       []byte
+	interpKey bool
+	value     []byte
+	interpVal bool
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewMetadata returns a Metadata processor.
+func NewMetadata(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := metadataOperatorFromString(conf.Metadata.Operator)
+	if err != nil {
+		return nil, err
+	}
+	needsKey := conf.Metadata.Operator != "delete_all"
+	if needsKey && len(conf.Metadata.Key) == 0 {
+		return nil, errors.New("key must not be empty")
+	}
+
+	key, value := []byte(conf.Metadata.Key), []byte(conf.Metadata.Value)
+	return &Metadata{
+		conf:  conf,
+		log:   log.NewModule(".processor.metadata"),
+		stats: stats,
+
+		parts:     conf.Metadata.Parts,
+		operator:  op,
+		needsKey:  needsKey,
+		key:       key,
+		interpKey: text.ContainsFunctionVariables(key),
+		value:     value,
+		interpVal: text.ContainsFunctionVariables(value),
+
+		mCount:     stats.GetCounter("processor.metadata.count"),
+		mErr:       stats.GetCounter("processor.metadata.error"),
+		mSucc:      stats.GetCounter("processor.metadata.success"),
+		mSent:      stats.GetCounter("processor.metadata.sent"),
+		mSentParts: stats.GetCounter("processor.metadata.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the metadata operation to each targeted message part.
+func (m *Metadata) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	m.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := m.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		key, value := m.key, m.value
+		if m.interpKey || m.interpVal {
+			partMsg := types.ExtractPart(newMsg, index)
+			if m.interpKey {
+				key = text.ReplaceFunctionVariablesFor(partMsg, key)
+			}
+			if m.interpVal {
+				value = text.ReplaceFunctionVariablesFor(partMsg, value)
+			}
+		}
+		if m.needsKey && len(key) == 0 {
+			m.mErr.Incr(1)
+			m.log.Debugf("Resolved empty key for part: %v\n", index)
+			FlagFail(newMsg, index, errors.New("resolved metadata key was empty"))
+			continue
+		}
+		if err := m.operator(newMsg.GetMetadata(index), string(key), string(value)); err != nil {
+			m.mErr.Incr(1)
+			m.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		m.mSucc.Incr(1)
+	}
+
+	m.mSent.Incr(1)
+	m.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
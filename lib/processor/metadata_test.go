@@ -0,0 +1,200 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func metadataMap(m types.Metadata) map[string]string {
+	res := map[string]string{}
+	m.Iter(func(k, v string) error {
+		if k != FailFlagKey {
+			res[k] = v
+		}
+		return nil
+	})
+	return res
+}
+
+func TestMetadataBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Metadata.Operator = "nope"
+	if _, err := NewMetadata(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+
+	conf = NewConfig()
+	conf.Metadata.Key = ""
+	if _, err := NewMetadata(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from empty key")
+	}
+
+	conf.Metadata.Operator = "delete_all"
+	if _, err := NewMetadata(conf, nil, testLog, metrics.DudType{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetadataOperators(t *testing.T) {
+	type mTest struct {
+		name     string
+		operator string
+		key      string
+		value    string
+		output   map[string]string
+		failed   bool
+	}
+
+	tests := []mTest{
+		{
+			name:     "set",
+			operator: "set",
+			key:      "baz",
+			value:    "qux",
+			output:   map[string]string{"foo": "bar", "baz": "qux"},
+		},
+		{
+			name:     "set interpolated",
+			operator: "set",
+			key:      "${!metadata:foo}",
+			value:    "${!json_field:value}",
+			output:   map[string]string{"foo": "bar", "bar": "hello world"},
+		},
+		{
+			name:     "set override",
+			operator: "set",
+			key:      "foo",
+			value:    "baz",
+			output:   map[string]string{"foo": "baz"},
+		},
+		{
+			name:     "set empty key",
+			operator: "set",
+			key:      "${!metadata:nope}",
+			value:    "baz",
+			output:   map[string]string{"foo": "bar"},
+			failed:   true,
+		},
+		{
+			name:     "delete",
+			operator: "delete",
+			key:      "foo",
+			output:   map[string]string{},
+		},
+		{
+			name:     "delete missing",
+			operator: "delete",
+			key:      "nope",
+			output:   map[string]string{"foo": "bar"},
+		},
+		{
+			name:     "delete all",
+			operator: "delete_all",
+			output:   map[string]string{},
+		},
+		{
+			name:     "copy",
+			operator: "copy",
+			key:      "foo",
+			value:    "baz",
+			output:   map[string]string{"foo": "bar", "baz": "bar"},
+		},
+		{
+			name:     "copy no destination",
+			operator: "copy",
+			key:      "foo",
+			value:    "",
+			output:   map[string]string{"foo": "bar"},
+			failed:   true,
+		},
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.Metadata.Operator = test.operator
+		conf.Metadata.Key = test.key
+		conf.Metadata.Value = test.value
+
+		proc, err := NewMetadata(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		input := types.NewMessage([][]byte{[]byte(`{"value":"hello world"}`)})
+		input.GetMetadata(0).Set("foo", "bar")
+
+		msgs, res := proc.ProcessMessage(input)
+		if len(msgs) != 1 {
+			t.Fatalf("%v: expected one message, received: %v", test.name, res)
+		}
+		if exp, act := test.output, metadataMap(msgs[0].GetMetadata(0)); !reflect.DeepEqual(exp, act) {
+			t.Errorf("%v: wrong result: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.failed, HasFailed(msgs[0]); exp != act {
+			t.Errorf("%v: wrong failed flag: %v != %v", test.name, act, exp)
+		}
+		if exp, act := "bar", input.GetMetadata(0).Get("foo"); exp != act {
+			t.Errorf("%v: input metadata was modified: %v != %v", test.name, act, exp)
+		}
+	}
+}
+
+func TestMetadataParts(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Metadata.Parts = []int{-1}
+	conf.Metadata.Key = "foo"
+	conf.Metadata.Value = "${!json_field:value}"
+
+	proc, err := NewMetadata(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := proc.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(`{"value":"first"}`), []byte(`{"value":"second"}`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if exp, act := "", msgs[0].GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong metadata of first part: %v != %v", act, exp)
+	}
+	if exp, act := "second", msgs[0].GetMetadata(1).Get("foo"); exp != act {
+		t.Errorf("Wrong metadata of second part: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
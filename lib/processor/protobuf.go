@@ -0,0 +1,262 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/golang/protobuf/proto"
+	dpb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["protobuf"] = TypeSpec{
+		constructor: NewProtobuf,
+		description: `
+Converts message parts between protobuf and JSON, where the protobuf message
+type is the fully qualified name of the field ` + "`message`" + `, e.g.
+` + "`foo.bar.Baz`" + `.
+
+The message type is defined either with a list of ` + "`.proto`" + ` files in
+the field ` + "`files`" + `, where imports are resolved from the directories of
+` + "`import_paths`" + `, or with the path of a compiled descriptor set in the
+field ` + "`descriptor_set`" + `. A descriptor set can be generated with
+` + "`protoc --include_imports --descriptor_set_out=set.pb foo.proto`" + `.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.
+
+### Operators
+
+#### ` + "`to_json`" + `
+
+Decodes a protobuf message into its JSON representation.
+
+#### ` + "`from_json`" + `
+
+Encodes a JSON document into a protobuf message.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ProtobufConfig contains any configuration for the Protobuf processor.
+type ProtobufConfig struct {
+	Parts         []int    `json:"parts" yaml:"parts"`
+	Operator      string   `json:"operator" yaml:"operator"`
+	Message       string   `json:"message" yaml:"message"`
+	Files         []string `json:"files" yaml:"files"`
+	ImportPaths   []string `json:"import_paths" yaml:"import_paths"`
+	DescriptorSet string   `json:"descriptor_set" yaml:"descriptor_set"`
+}
+
+// NewProtobufConfig returns a ProtobufConfig with default values.
+func NewProtobufConfig() ProtobufConfig {
+	return ProtobufConfig{
+		Parts:         []int{},
+		Operator:      "to_json",
+		Message:       "",
+		Files:         []string{},
+		ImportPaths:   []string{},
+		DescriptorSet: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// loadProtobufFiles returns the file descriptors of a config, which are either
+// parsed from proto files or read from a compiled descriptor set.
+func loadProtobufFiles(conf ProtobufConfig) ([]*desc.FileDescriptor, error) {
+	if len(conf.DescriptorSet) > 0 {
+		if len(conf.Files) > 0 {
+			return nil, errors.New("files and descriptor_set cannot both be set")
+		}
+		setBytes, err := ioutil.ReadFile(conf.DescriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read descriptor set: %v", err)
+		}
+		var set dpb.FileDescriptorSet
+		if err = proto.Unmarshal(setBytes, &set); err != nil {
+			return nil, fmt.Errorf("failed to parse descriptor set: %v", err)
+		}
+		fileMap, err := desc.CreateFileDescriptorsFromSet(&set)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load descriptor set: %v", err)
+		}
+		files := make([]*desc.FileDescriptor, 0, len(fileMap))
+		for _, fd := range fileMap {
+			files = append(files, fd)
+		}
+		return files, nil
+	}
+	if len(conf.Files) == 0 {
+		return nil, errors.New("either files or descriptor_set must be set")
+	}
+	files, err := protoparse.Parser{
+		ImportPaths: conf.ImportPaths,
+	}.ParseFiles(conf.Files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse proto files: %v", err)
+	}
+	return files, nil
+}
+
+type protobufOperator func(body []byte) ([]byte, error)
+
+func newProtobufToJSONOperator(md *desc.MessageDescriptor) protobufOperator {
+	return func(body []byte) ([]byte, error) {
+		msg := dynamic.NewMessage(md)
+		if err := msg.Unmarshal(body); err != nil {
+			return nil, err
+		}
+		return msg.MarshalJSON()
+	}
+}
+
+func newProtobufFromJSONOperator(md *desc.MessageDescriptor) protobufOperator {
+	return func(body []byte) ([]byte, error) {
+		msg := dynamic.NewMessage(md)
+		if err := msg.UnmarshalJSON(body); err != nil {
+			return nil, err
+		}
+		return msg.Marshal()
+	}
+}
+
+func protobufOperatorFromConfig(conf ProtobufConfig) (protobufOperator, error) {
+	if len(conf.Message) == 0 {
+		return nil, errors.New("message must not be empty")
+	}
+	files, err := loadProtobufFiles(conf)
+	if err != nil {
+		return nil, err
+	}
+	var md *desc.MessageDescriptor
+	for _, fd := range files {
+		if md = fd.FindMessage(conf.Message); md != nil {
+			break
+		}
+	}
+	if md == nil {
+		return nil, fmt.Errorf("message type not found: %v", conf.Message)
+	}
+	switch conf.Operator {
+	case "to_json":
+		return newProtobufToJSONOperator(md), nil
+	case "from_json":
+		return newProtobufFromJSONOperator(md), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// Protobuf is a processor that converts message parts between protobuf and
+// JSON.
+type Protobuf struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts    []int
+	operator protobufOperator
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewProtobuf returns a Protobuf processor.
+func NewProtobuf(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := protobufOperatorFromConfig(conf.Protobuf)
+	if err != nil {
+		return nil, err
+	}
+	return &Protobuf{
+		conf:  conf,
+		log:   log.NewModule(".processor.protobuf"),
+		stats: stats,
+
+		parts:    conf.Protobuf.Parts,
+		operator: op,
+
+		mCount:     stats.GetCounter("processor.protobuf.count"),
+		mErr:       stats.GetCounter("processor.protobuf.error"),
+		mSucc:      stats.GetCounter("processor.protobuf.success"),
+		mSent:      stats.GetCounter("processor.protobuf.sent"),
+		mSentParts: stats.GetCounter("processor.protobuf.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage converts each targeted message part between protobuf and
+// JSON.
+func (p *Protobuf) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	p.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := p.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		result, err := p.operator(newMsg.Get(index))
+		if err != nil {
+			p.mErr.Incr(1)
+			p.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		newMsg.Set(index, result)
+		p.mSucc.Incr(1)
+	}
+
+	p.mSent.Incr(1)
+	p.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+//------------------------------------------------------------------------------
+
+const testProtoCommon = `
+syntax = "proto3";
+package testing;
+
+message Address {
+  string city = 1;
+}
+`
+
+const testProtoPerson = `
+syntax = "proto3";
+package testing;
+
+import "common.proto";
+
+message Person {
+  string name = 1;
+  int32 age = 2;
+  repeated Address addresses = 3;
+}
+`
+
+func writeTestProtos(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "benthos_protobuf_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"common.proto": testProtoCommon,
+		"person.proto": testProtoPerson,
+	} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func testProtobufRoundTrip(t *testing.T, conf Config) {
+	t.Helper()
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf.Protobuf.Operator = "from_json"
+	encoder, err := NewProtobuf(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Protobuf.Operator = "to_json"
+	decoder, err := NewProtobuf(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := `{"name":"foo","age":10,"addresses":[{"city":"bar"},{"city":"baz"}]}`
+
+	msgs, _ := encoder.ProcessMessage(types.NewMessage([][]byte{
+		[]byte(input),
+		[]byte(`{"name":10}`),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if HasFailed(types.ExtractPart(msgs[0], 0)) {
+		t.Fatalf("Unexpected failure: %v", msgs[0].GetMetadata(0).Get(FailFlagKey))
+	}
+	if !HasFailed(types.ExtractPart(msgs[0], 1)) {
+		t.Error("Expected second part to fail")
+	}
+	if string(msgs[0].Get(0)) == input {
+		t.Error("Expected first part to be encoded")
+	}
+
+	msgs, _ = decoder.ProcessMessage(types.NewMessage([][]byte{
+		msgs[0].Get(0),
+		{0xff, 0xff},
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+
+	var expObj, actObj interface{}
+	if err = json.Unmarshal([]byte(input), &expObj); err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(msgs[0].Get(0), &actObj); err != nil {
+		t.Fatalf("Failed to parse result: %v", err)
+	}
+	if !reflect.DeepEqual(expObj, actObj) {
+		t.Errorf("Wrong result: %s != %s", msgs[0].Get(0), input)
+	}
+	if !HasFailed(types.ExtractPart(msgs[0], 1)) {
+		t.Error("Expected second part to fail")
+	}
+}
+
+func TestProtobufFiles(t *testing.T) {
+	dir := writeTestProtos(t)
+	defer os.RemoveAll(dir)
+
+	conf := NewConfig()
+	conf.Protobuf.Message = "testing.Person"
+	conf.Protobuf.Files = []string{"person.proto"}
+	conf.Protobuf.ImportPaths = []string{dir}
+
+	testProtobufRoundTrip(t, conf)
+}
+
+func TestProtobufDescriptorSet(t *testing.T) {
+	dir := writeTestProtos(t)
+	defer os.RemoveAll(dir)
+
+	fds, err := protoparse.Parser{ImportPaths: []string{dir}}.ParseFiles("person.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setBytes, err := proto.Marshal(desc.ToFileDescriptorSet(fds...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	setPath := filepath.Join(dir, "set.pb")
+	if err = ioutil.WriteFile(setPath, setBytes, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Protobuf.Message = "testing.Person"
+	conf.Protobuf.DescriptorSet = setPath
+
+	testProtobufRoundTrip(t, conf)
+}
+
+func TestProtobufBadConfig(t *testing.T) {
+	dir := writeTestProtos(t)
+	defer os.RemoveAll(dir)
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	tests := map[string]func(c *ProtobufConfig){
+		"no message": func(c *ProtobufConfig) {
+			c.Message = ""
+		},
+		"no files": func(c *ProtobufConfig) {
+			c.Files = nil
+		},
+		"files and descriptor set": func(c *ProtobufConfig) {
+			c.DescriptorSet = filepath.Join(dir, "set.pb")
+		},
+		"unknown message": func(c *ProtobufConfig) {
+			c.Message = "testing.Nope"
+		},
+		"missing import": func(c *ProtobufConfig) {
+			c.ImportPaths = nil
+		},
+		"bad operator": func(c *ProtobufConfig) {
+			c.Operator = "nope"
+		},
+	}
+
+	for name, fn := range tests {
+		conf := NewConfig()
+		conf.Protobuf.Message = "testing.Person"
+		conf.Protobuf.Files = []string{"person.proto"}
+		conf.Protobuf.ImportPaths = []string{dir}
+		fn(&conf.Protobuf)
+
+		if _, err := NewProtobuf(conf, nil, testLog, metrics.DudType{}); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -164,6 +164,7 @@ func (p *SelectJSON) ProcessMessage(msg types.Message) ([]types.Message, types.R
 		if err != nil {
 			p.mErrJSONP.Incr(1)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
@@ -171,6 +172,7 @@ func (p *SelectJSON) ProcessMessage(msg types.Message) ([]types.Message, types.R
 		if gPart, err = gabs.Consume(jsonPart); err != nil {
 			p.mErrJSONP.Incr(1)
 			p.log.Debugf("Failed to parse part into json: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
@@ -183,6 +185,7 @@ func (p *SelectJSON) ProcessMessage(msg types.Message) ([]types.Message, types.R
 			if err = newMsg.SetJSON(index, t); err != nil {
 				p.mErrJSONS.Incr(1)
 				p.log.Debugf("Failed to convert json into part: %v\n", err)
+				FlagFail(newMsg, index, err)
 			}
 		}
 
@@ -115,7 +115,7 @@ func (m *SelectParts) ProcessMessage(msg types.Message) ([]types.Message, types.
 			m.mSkipped.Incr(1)
 		} else {
 			m.mSelected.Incr(1)
-			newMsg.Append(msg.Get(index))
+			newMsg.SetMetadata(msg.GetMetadata(index), newMsg.Append(msg.Get(index)))
 		}
 	}
 
@@ -204,7 +204,7 @@ func (p *SetJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 
 	valueBytes := p.valueBytes
 	if p.interpolate {
-		valueBytes = text.ReplaceFunctionVariables(valueBytes)
+		valueBytes = text.ReplaceFunctionVariablesFor(msg, valueBytes)
 	}
 
 	targetParts := p.parts
@@ -219,10 +219,11 @@ func (p *SetJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 		var data interface{} = valueBytes
 
 		if len(p.target) > 0 {
-			jsonPart, err := msg.GetJSON(index)
+			jsonPart, err := newMsg.GetJSON(index)
 			if err != nil {
 				p.mErrJSONP.Incr(1)
 				p.log.Debugf("Failed to parse part into json: %v\n", err)
+				FlagFail(newMsg, index, err)
 				continue
 			}
 
@@ -230,6 +231,7 @@ func (p *SetJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 			if gPart, err = gabs.Consume(jsonPart); err != nil {
 				p.mErrJSONP.Incr(1)
 				p.log.Debugf("Failed to parse part into json: %v\n", err)
+				FlagFail(newMsg, index, err)
 				continue
 			}
 
@@ -240,6 +242,7 @@ func (p *SetJSON) ProcessMessage(msg types.Message) ([]types.Message, types.Resp
 		if err := newMsg.SetJSON(index, data); err != nil {
 			p.mErrJSONS.Incr(1)
 			p.log.Debugf("Failed to convert json into part: %v\n", err)
+			FlagFail(newMsg, index, err)
 			continue
 		}
 
@@ -22,6 +22,7 @@ package processor
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
 	"github.com/Jeffail/benthos/lib/metrics"
@@ -75,6 +76,41 @@ func TestSetJSONValidation(t *testing.T) {
 	}
 }
 
+func TestSetJSONCachedInput(t *testing.T) {
+	conf := NewConfig()
+	conf.SetJSON.Path = "foo.bar"
+	conf.SetJSON.Value = []byte(`"new"`)
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	jSet, err := NewSetJSON(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgIn := types.NewMessage([][]byte{[]byte(`{"foo":{"bar":"old"}}`)})
+	if _, err = msgIn.GetJSON(0); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := jSet.ProcessMessage(msgIn)
+	if len(msgs) != 1 {
+		t.Fatal("Wrong count of messages")
+	}
+	if exp, act := `{"foo":{"bar":"new"}}`, string(msgs[0].Get(0)); exp != act {
+		t.Errorf("Wrong output: %v != %v", act, exp)
+	}
+
+	jObj, err := msgIn.GetJSON(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]interface{}{"foo": map[string]interface{}{"bar": "old"}}
+	if !reflect.DeepEqual(exp, jObj) {
+		t.Errorf("Cached JSON of input was modified: %v != %v", jObj, exp)
+	}
+}
+
 func TestSetJSONPartBounds(t *testing.T) {
 	tLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
 	tStats := metrics.DudType{}
@@ -21,6 +21,8 @@
 package processor
 
 import (
+	"errors"
+
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/types"
 	"github.com/Jeffail/benthos/lib/util/service/log"
@@ -32,10 +34,17 @@ func init() {
 	Constructors["split"] = TypeSpec{
 		constructor: NewSplit,
 		description: `
-Extracts the individual parts of a multipart message and turns them each into a
-unique message. It is NOT necessary to use the split processor when your output
-only supports single part messages, since those message parts will automatically
-be sent as individual messages.
+Breaks a multipart message into smaller messages of at most ` + "`size`" + `
+parts each. By default ` + "`size`" + ` is 1 and each part of the message
+becomes a unique message. It is NOT necessary to use the split processor when
+your output only supports single part messages, since those message parts will
+automatically be sent as individual messages.
+
+If ` + "`byte_size`" + ` is greater than zero then each resulting message is
+also limited to a total payload size of that many bytes, which is useful for
+outputs with payload limits such as SQS or Kinesis. A single part that exceeds
+` + "`byte_size`" + ` is sent as a message of its own. A ` + "`size`" + ` of
+zero means the count of parts is not limited.
 
 Please note that when you split a message you will lose the coupling between the
 acknowledgement from the output destination to the origin message at the input
@@ -53,11 +62,31 @@ the combine processor. For example:
 
 //------------------------------------------------------------------------------
 
-// Split is a processor that splits messages into a message per part.
+// SplitConfig contains any configuration for the Split processor.
+type SplitConfig struct {
+	Size     int `json:"size" yaml:"size"`
+	ByteSize int `json:"byte_size" yaml:"byte_size"`
+}
+
+// NewSplitConfig returns a SplitConfig with default values.
+func NewSplitConfig() SplitConfig {
+	return SplitConfig{
+		Size:     1,
+		ByteSize: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Split is a processor that splits messages into smaller messages of a limited
+// count of parts or bytes.
 type Split struct {
 	log   log.Modular
 	stats metrics.Type
 
+	size     int
+	byteSize int
+
 	mCount   metrics.StatCounter
 	mDropped metrics.StatCounter
 	mSent    metrics.StatCounter
@@ -67,10 +96,22 @@ type Split struct {
 func NewSplit(
 	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
 ) (Type, error) {
+	if conf.Split.Size < 0 {
+		return nil, errors.New("size must not be negative")
+	}
+	if conf.Split.ByteSize < 0 {
+		return nil, errors.New("byte_size must not be negative")
+	}
+	if conf.Split.Size == 0 && conf.Split.ByteSize == 0 {
+		return nil, errors.New("at least one of size or byte_size must be greater than zero")
+	}
 	return &Split{
 		log:   log.NewModule(".processor.split"),
 		stats: stats,
 
+		size:     conf.Split.Size,
+		byteSize: conf.Split.ByteSize,
+
 		mCount:   stats.GetCounter("processor.split.count"),
 		mDropped: stats.GetCounter("processor.split.dropped"),
 		mSent:    stats.GetCounter("processor.split.sent"),
@@ -79,8 +120,8 @@ func NewSplit(
 
 //------------------------------------------------------------------------------
 
-// ProcessMessage takes a single message and returns a slice of messages,
-// containing a message per part.
+// ProcessMessage takes a single message and returns a slice of messages, each
+// containing a limited count of parts or bytes.
 func (s *Split) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
 	s.mCount.Incr(1)
 
@@ -89,10 +130,30 @@ func (s *Split) ProcessMessage(msg types.Message) ([]types.Message, types.Respon
 		return nil, types.NewSimpleResponse(nil)
 	}
 
-	msgs := make([]types.Message, msg.Len())
-	for i, part := range msg.GetAll() {
-		msgs[i] = types.NewMessage([][]byte{part})
-	}
+	var msgs []types.Message
+	var nextMsg types.Message
+	nextBytes := 0
+
+	msg.Iter(func(i int, part []byte) error {
+		if nextMsg != nil {
+			full := s.size > 0 && nextMsg.Len() >= s.size
+			if !full && s.byteSize > 0 {
+				full = nextBytes+len(part) > s.byteSize
+			}
+			if full {
+				msgs = append(msgs, nextMsg)
+				nextMsg = nil
+			}
+		}
+		if nextMsg == nil {
+			nextMsg = types.NewMessage(nil)
+			nextBytes = 0
+		}
+		nextMsg.SetMetadata(msg.GetMetadata(i), nextMsg.Append(part))
+		nextBytes += len(part)
+		return nil
+	})
+	msgs = append(msgs, nextMsg)
 
 	s.mSent.Incr(int64(len(msgs)))
 	return msgs, nil
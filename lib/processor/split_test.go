@@ -69,3 +69,98 @@ func TestSplitParts(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Split.Size = 0
+	if _, err := NewSplit(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from zero size and byte_size")
+	}
+
+	conf = NewConfig()
+	conf.Split.ByteSize = -1
+	if _, err := NewSplit(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from negative byte_size")
+	}
+}
+
+func TestSplitLimits(t *testing.T) {
+	type tTest struct {
+		name     string
+		size     int
+		byteSize int
+		input    []string
+		output   [][]string
+	}
+
+	tests := []tTest{
+		{
+			name:   "size two",
+			size:   2,
+			input:  []string{"foo", "bar", "baz", "qux", "quz"},
+			output: [][]string{{"foo", "bar"}, {"baz", "qux"}, {"quz"}},
+		},
+		{
+			name:     "byte size only",
+			byteSize: 7,
+			input:    []string{"foo", "bar", "baz", "quxquxqux", "a", "b"},
+			output:   [][]string{{"foo", "bar"}, {"baz"}, {"quxquxqux"}, {"a", "b"}},
+		},
+		{
+			name:     "size and byte size",
+			size:     2,
+			byteSize: 10,
+			input:    []string{"a", "b", "c", "dddddddddd", "e"},
+			output:   [][]string{{"a", "b"}, {"c"}, {"dddddddddd"}, {"e"}},
+		},
+		{
+			name:   "size larger than message",
+			size:   10,
+			input:  []string{"foo", "bar"},
+			output: [][]string{{"foo", "bar"}},
+		},
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.Split.Size = test.size
+		conf.Split.ByteSize = test.byteSize
+
+		proc, err := NewSplit(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		input := types.NewMessage(nil)
+		for _, p := range test.input {
+			input.Append([]byte(p))
+		}
+		input.GetMetadata(-1).Set("foo", "bar")
+
+		msgs, res := proc.ProcessMessage(input)
+		if res != nil {
+			t.Fatalf("%v: unexpected response: %v", test.name, res.Error())
+		}
+
+		act := [][]string{}
+		for _, m := range msgs {
+			parts := []string{}
+			for _, p := range m.GetAll() {
+				parts = append(parts, string(p))
+			}
+			act = append(act, parts)
+		}
+		if !reflect.DeepEqual(test.output, act) {
+			t.Errorf("%v: wrong result: %v != %v", test.name, act, test.output)
+		}
+
+		last := msgs[len(msgs)-1]
+		if act := last.GetMetadata(-1).Get("foo"); act != "bar" {
+			t.Errorf("%v: metadata not preserved: %v", test.name, act)
+		}
+	}
+}
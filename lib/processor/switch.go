@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["switch"] = TypeSpec{
+		constructor: NewSwitch,
+		description: `
+Switch is a processor that lists child case objects each containing a condition
+and processors. Each message is tested against the condition of each child case
+until a condition passes, where the processors of that case are applied to the
+message.
+
+If the ` + "`fallthrough`" + ` field of a case is set to ` + "`true`" + ` then
+the processors of the following case are also applied, regardless of its
+condition. If no condition passes the message is left unchanged. This processor
+is an extension of the ` + "`conditional`" + ` processor for cases with more
+than two branches.
+
+For example, the following config would archive messages containing the string
+` + "`foo`" + ` and compress all other messages:
+
+` + "``` yaml" + `
+switch:
+- condition:
+    type: content
+    content:
+      operator: contains
+      arg: foo
+  processors:
+  - type: archive
+- condition:
+    type: static
+    static: true
+  processors:
+  - type: compress
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// SwitchCaseConfig contains a condition, processors and other fields for an
+// individual case in the Switch processor.
+type SwitchCaseConfig struct {
+	Condition   condition.Config `json:"condition" yaml:"condition"`
+	Processors  []Config         `json:"processors" yaml:"processors"`
+	Fallthrough bool             `json:"fallthrough" yaml:"fallthrough"`
+}
+
+// NewSwitchCaseConfig returns a new SwitchCaseConfig with default values.
+func NewSwitchCaseConfig() SwitchCaseConfig {
+	return SwitchCaseConfig{
+		Condition:   condition.NewConfig(),
+		Processors:  []Config{},
+		Fallthrough: false,
+	}
+}
+
+// SwitchConfig is a config struct containing fields for the Switch processor.
+type SwitchConfig []SwitchCaseConfig
+
+// NewSwitchConfig returns a default SwitchConfig.
+func NewSwitchConfig() SwitchConfig {
+	return SwitchConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// switchCase contains a condition, processors and other fields for an
+// individual case in the Switch processor.
+type switchCase struct {
+	condition   condition.Type
+	processors  []Type
+	fallThrough bool
+}
+
+// Switch is a processor that only applies child processors under a certain
+// condition.
+type Switch struct {
+	cases []switchCase
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount   metrics.StatCounter
+	mNoMatch metrics.StatCounter
+	mSent    metrics.StatCounter
+	mDropped metrics.StatCounter
+}
+
+// NewSwitch returns a Switch processor.
+func NewSwitch(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var cases []switchCase
+	for i, caseConf := range conf.Switch {
+		var err error
+		var cond condition.Type
+		if cond, err = condition.New(caseConf.Condition, mgr, log, stats); err != nil {
+			return nil, fmt.Errorf("failed to create case '%v' condition: %v", i, err)
+		}
+		var procs []Type
+		for j, procConf := range caseConf.Processors {
+			var proc Type
+			if proc, err = New(procConf, mgr, log, stats); err != nil {
+				return nil, fmt.Errorf("failed to create case '%v' processor '%v': %v", i, j, err)
+			}
+			procs = append(procs, proc)
+		}
+		cases = append(cases, switchCase{
+			condition:   cond,
+			processors:  procs,
+			fallThrough: caseConf.Fallthrough,
+		})
+	}
+	return &Switch{
+		cases: cases,
+
+		log:   log.NewModule(".processor.switch"),
+		stats: stats,
+
+		mCount:   stats.GetCounter("processor.switch.count"),
+		mNoMatch: stats.GetCounter("processor.switch.no_match"),
+		mSent:    stats.GetCounter("processor.switch.sent"),
+		mDropped: stats.GetCounter("processor.switch.dropped"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the processors of the first case with a passing
+// condition, and of any following cases reached by falling through.
+func (s *Switch) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	s.mCount.Incr(1)
+
+	var procs []Type
+
+	matched := false
+	for _, switchCase := range s.cases {
+		if !matched && !switchCase.condition.Check(msg) {
+			continue
+		}
+		matched = true
+		procs = append(procs, switchCase.processors...)
+		if !switchCase.fallThrough {
+			break
+		}
+	}
+	if !matched {
+		s.mNoMatch.Incr(1)
+	}
+
+	resultMsgs := []types.Message{msg}
+	var resultRes types.Response
+
+	for i := 0; len(resultMsgs) > 0 && i < len(procs); i++ {
+		var nextResultMsgs []types.Message
+		for _, m := range resultMsgs {
+			var rMsgs []types.Message
+			rMsgs, resultRes = procs[i].ProcessMessage(m)
+			nextResultMsgs = append(nextResultMsgs, rMsgs...)
+		}
+		resultMsgs = nextResultMsgs
+	}
+
+	if len(resultMsgs) == 0 {
+		s.mDropped.Incr(1)
+		res = resultRes
+	} else {
+		s.mSent.Incr(int64(len(resultMsgs)))
+		msgs = resultMsgs
+	}
+
+	return
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+func TestSwitchBadCase(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	caseConf := NewSwitchCaseConfig()
+	caseConf.Condition.Type = "nope"
+
+	conf := NewConfig()
+	conf.Switch = append(conf.Switch, caseConf)
+	if _, err := NewSwitch(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad condition")
+	}
+}
+
+func TestSwitchCases(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	if err := yaml.Unmarshal([]byte(`
+type: switch
+switch:
+- condition:
+    type: content
+    content:
+      operator: contains
+      arg: foo
+  processors:
+  - type: insert_part
+    insert_part:
+      content: foo case
+  fallthrough: true
+- condition:
+    type: content
+    content:
+      operator: contains
+      arg: bar
+  processors:
+  - type: insert_part
+    insert_part:
+      content: bar case
+- condition:
+    type: content
+    content:
+      operator: contains
+      arg: baz
+  processors:
+  - type: insert_part
+    insert_part:
+      content: baz case
+`), &conf); err != nil {
+		t.Fatal(err)
+	}
+
+	proc, err := New(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input  string
+		output []string
+	}{
+		{input: "foo", output: []string{"foo", "foo case", "bar case"}},
+		{input: "bar", output: []string{"bar", "bar case"}},
+		{input: "baz", output: []string{"baz", "baz case"}},
+		{input: "bar baz", output: []string{"bar baz", "bar case"}},
+		{input: "qux", output: []string{"qux"}},
+	}
+
+	for _, test := range tests {
+		msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{[]byte(test.input)}))
+		if res != nil {
+			t.Fatalf("%v: unexpected response: %v", test.input, res.Error())
+		}
+		if len(msgs) != 1 {
+			t.Fatalf("%v: wrong count of messages: %v", test.input, len(msgs))
+		}
+		act := []string{}
+		for _, p := range msgs[0].GetAll() {
+			act = append(act, string(p))
+		}
+		if !reflect.DeepEqual(test.output, act) {
+			t.Errorf("%v: wrong result: %v != %v", test.input, act, test.output)
+		}
+	}
+}
+
+func TestSwitchDropped(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	filterConf := NewConfig()
+	filterConf.Type = "filter"
+	filterConf.Filter.Type = "static"
+	filterConf.Filter.Static = false
+
+	caseConf := NewSwitchCaseConfig()
+	caseConf.Condition = condition.NewConfig()
+	caseConf.Condition.Type = "static"
+	caseConf.Condition.Static = true
+	caseConf.Processors = append(caseConf.Processors, filterConf)
+
+	conf := NewConfig()
+	conf.Switch = append(conf.Switch, caseConf)
+
+	proc, err := NewSwitch(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{[]byte("foo")}))
+	if len(msgs) != 0 {
+		t.Errorf("Expected no messages: %v", len(msgs))
+	}
+	if res == nil {
+		t.Error("Expected response from dropped message")
+	}
+}
+
+//------------------------------------------------------------------------------
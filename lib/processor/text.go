@@ -0,0 +1,292 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["text"] = TypeSpec{
+		constructor: NewText,
+		description: `
+Performs text based mutations on payloads, allowing plain text pipelines such
+as logs to be transformed without converting them to JSON.
+
+The ` + "`value`" + ` field supports
+[interpolation functions](../config_interpolation.md#functions), which are
+resolved individually for each message part.
+
+If the list of target parts is empty the operation will be applied to all
+message parts. Part indexes can be negative, and if so the part will be selected
+from the end counting backwards starting from -1.
+
+If an operation fails for a part the part is flagged as having failed a
+processing step and is otherwise left unchanged.
+
+### Operators
+
+#### ` + "`trim`" + `
+
+Removes all leading and trailing occurrences of characters within the field
+` + "`arg`" + `, or all leading and trailing whitespace when ` + "`arg`" + ` is
+empty.
+
+#### ` + "`prepend`" + `
+
+Prepends the contents of ` + "`value`" + ` to the payload.
+
+#### ` + "`append`" + `
+
+Appends the contents of ` + "`value`" + ` to the payload.
+
+#### ` + "`replace_regexp`" + `
+
+Replaces all matches of the regular expression ` + "`arg`" + ` with the
+contents of ` + "`value`" + `, where ` + "`$1`" + ` style references are
+expanded to capture groups.
+
+#### ` + "`to_upper`" + `
+
+Converts all characters of the payload to upper case.
+
+#### ` + "`to_lower`" + `
+
+Converts all characters of the payload to lower case.
+
+#### ` + "`extract_regexp`" + `
+
+Finds the first match of the regular expression ` + "`arg`" + ` and stores it
+as a metadata value under the key of the field ` + "`value`" + `. If the
+expression contains a capture group then the first group is stored instead of
+the full match. The payload is left unchanged, and a part without a match is
+flagged as having failed.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TextConfig contains any configuration for the Text processor.
+type TextConfig struct {
+	Parts    []int  `json:"parts" yaml:"parts"`
+	Operator string `json:"operator" yaml:"operator"`
+	Arg      string `json:"arg" yaml:"arg"`
+	Value    string `json:"value" yaml:"value"`
+}
+
+// NewTextConfig returns a TextConfig with default values.
+func NewTextConfig() TextConfig {
+	return TextConfig{
+		Parts:    []int{},
+		Operator: "trim",
+		Arg:      "",
+		Value:    "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type textOperator func(body []byte, value []byte, meta types.Metadata) ([]byte, error)
+
+func newTextTrimOperator(arg string) textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		if len(arg) == 0 {
+			return bytes.TrimSpace(body), nil
+		}
+		return bytes.Trim(body, arg), nil
+	}
+}
+
+func newTextPrependOperator() textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		newBody := make([]byte, 0, len(value)+len(body))
+		newBody = append(newBody, value...)
+		return append(newBody, body...), nil
+	}
+}
+
+func newTextAppendOperator() textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		newBody := make([]byte, 0, len(body)+len(value))
+		newBody = append(newBody, body...)
+		return append(newBody, value...), nil
+	}
+}
+
+func newTextReplaceRegexpOperator(re *regexp.Regexp) textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		return re.ReplaceAll(body, value), nil
+	}
+}
+
+func newTextToUpperOperator() textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		return bytes.ToUpper(body), nil
+	}
+}
+
+func newTextToLowerOperator() textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		return bytes.ToLower(body), nil
+	}
+}
+
+func newTextExtractRegexpOperator(re *regexp.Regexp) textOperator {
+	return func(body []byte, value []byte, meta types.Metadata) ([]byte, error) {
+		if len(value) == 0 {
+			return nil, errors.New("resolved metadata key was empty")
+		}
+		matches := re.FindSubmatch(body)
+		if matches == nil {
+			return nil, errors.New("regular expression did not match")
+		}
+		match := matches[0]
+		if len(matches) > 1 {
+			match = matches[1]
+		}
+		meta.Set(string(value), string(match))
+		return body, nil
+	}
+}
+
+func textOperatorFromConfig(conf TextConfig) (textOperator, error) {
+	switch conf.Operator {
+	case "trim":
+		return newTextTrimOperator(conf.Arg), nil
+	case "prepend":
+		return newTextPrependOperator(), nil
+	case "append":
+		return newTextAppendOperator(), nil
+	case "to_upper":
+		return newTextToUpperOperator(), nil
+	case "to_lower":
+		return newTextToLowerOperator(), nil
+	case "replace_regexp", "extract_regexp":
+		re, err := regexp.Compile(conf.Arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile regexp: %v", err)
+		}
+		if conf.Operator == "replace_regexp" {
+			return newTextReplaceRegexpOperator(re), nil
+		}
+		return newTextExtractRegexpOperator(re), nil
+	}
+	return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+}
+
+//------------------------------------------------------------------------------
+
+// Text is a processor that performs a text based operation on each message
+// part.
+type Text struct {
+	conf  Config
+	log   log.Modular
+	stats metrics.Type
+
+	parts     []int
+	operator  textOperator
+	value     []byte
+	interpVal bool
+
+	mCount     metrics.StatCounter
+	mErr       metrics.StatCounter
+	mSucc      metrics.StatCounter
+	mSent      metrics.StatCounter
+	mSentParts metrics.StatCounter
+}
+
+// NewText returns a Text processor.
+func NewText(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	op, err := textOperatorFromConfig(conf.Text)
+	if err != nil {
+		return nil, err
+	}
+
+	value := []byte(conf.Text.Value)
+	return &Text{
+		conf:  conf,
+		log:   log.NewModule(".processor.text"),
+		stats: stats,
+
+		parts:     conf.Text.Parts,
+		operator:  op,
+		value:     value,
+		interpVal: text.ContainsFunctionVariables(value),
+
+		mCount:     stats.GetCounter("processor.text.count"),
+		mErr:       stats.GetCounter("processor.text.error"),
+		mSucc:      stats.GetCounter("processor.text.success"),
+		mSent:      stats.GetCounter("processor.text.sent"),
+		mSentParts: stats.GetCounter("processor.text.parts.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the text operation to each targeted message part.
+func (t *Text) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	t.mCount.Incr(1)
+
+	newMsg := msg.ShallowCopy()
+
+	targetParts := t.parts
+	if len(targetParts) == 0 {
+		targetParts = make([]int, newMsg.Len())
+		for i := range targetParts {
+			targetParts[i] = i
+		}
+	}
+
+	for _, index := range targetParts {
+		value := t.value
+		if t.interpVal {
+			value = text.ReplaceFunctionVariablesFor(types.ExtractPart(newMsg, index), value)
+		}
+		result, err := t.operator(newMsg.Get(index), value, newMsg.GetMetadata(index))
+		if err != nil {
+			t.mErr.Incr(1)
+			t.log.Debugf("Operator failed for part %v: %v\n", index, err)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		newMsg.Set(index, result)
+		t.mSucc.Incr(1)
+	}
+
+	t.mSent.Incr(1)
+	t.mSentParts.Incr(int64(newMsg.Len()))
+	msgs := [1]types.Message{newMsg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
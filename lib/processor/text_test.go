@@ -0,0 +1,195 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestTextBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Text.Operator = "nope"
+	if _, err := NewText(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad operator")
+	}
+
+	conf = NewConfig()
+	conf.Text.Operator = "replace_regexp"
+	conf.Text.Arg = "foo("
+	if _, err := NewText(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad regexp")
+	}
+}
+
+func TestTextOperators(t *testing.T) {
+	type tTest struct {
+		name     string
+		operator string
+		arg      string
+		value    string
+		input    string
+		output   string
+		failed   bool
+	}
+
+	tests := []tTest{
+		{
+			name:     "trim whitespace",
+			operator: "trim",
+			input:    "  \thello world\n ",
+			output:   "hello world",
+		},
+		{
+			name:     "trim cutset",
+			operator: "trim",
+			arg:      "#!",
+			input:    "#!hello world!#",
+			output:   "hello world",
+		},
+		{
+			name:     "prepend",
+			operator: "prepend",
+			value:    "foo: ",
+			input:    "hello world",
+			output:   "foo: hello world",
+		},
+		{
+			name:     "append interpolated",
+			operator: "append",
+			value:    " (${!metadata:source})",
+			input:    "hello world",
+			output:   "hello world (test)",
+		},
+		{
+			name:     "replace regexp",
+			operator: "replace_regexp",
+			arg:      `(\w+)=(\w+)`,
+			value:    "$2=$1",
+			input:    "foo=bar baz=qux",
+			output:   "bar=foo qux=baz",
+		},
+		{
+			name:     "to upper",
+			operator: "to_upper",
+			input:    "hello World",
+			output:   "HELLO WORLD",
+		},
+		{
+			name:     "to lower",
+			operator: "to_lower",
+			input:    "Hello WORLD",
+			output:   "hello world",
+		},
+		{
+			name:     "extract no match",
+			operator: "extract_regexp",
+			arg:      `level=(\w+)`,
+			value:    "level",
+			input:    "hello world",
+			output:   "hello world",
+			failed:   true,
+		},
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	for _, test := range tests {
+		conf := NewConfig()
+		conf.Text.Operator = test.operator
+		conf.Text.Arg = test.arg
+		conf.Text.Value = test.value
+
+		proc, err := NewText(conf, nil, testLog, metrics.DudType{})
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+
+		input := types.NewMessage([][]byte{[]byte(test.input)})
+		input.GetMetadata(0).Set("source", "test")
+
+		msgs, res := proc.ProcessMessage(input)
+		if len(msgs) != 1 {
+			t.Fatalf("%v: expected one message, received: %v", test.name, res)
+		}
+		if exp, act := test.output, string(msgs[0].Get(0)); exp != act {
+			t.Errorf("%v: wrong result: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.failed, HasFailed(msgs[0]); exp != act {
+			t.Errorf("%v: wrong failed flag: %v != %v", test.name, act, exp)
+		}
+		if exp, act := test.input, string(input.Get(0)); exp != act {
+			t.Errorf("%v: input message was modified: %v != %v", test.name, act, exp)
+		}
+	}
+}
+
+func TestTextExtractRegexp(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Text.Parts = []int{1}
+	conf.Text.Operator = "extract_regexp"
+	conf.Text.Arg = `level=(\w+)`
+	conf.Text.Value = "level"
+
+	proc, err := NewText(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs, _ := proc.ProcessMessage(types.NewMessage([][]byte{
+		[]byte("level=info first"), []byte("level=error second"),
+	}))
+	if len(msgs) != 1 {
+		t.Fatal("Expected one message")
+	}
+	if exp, act := "", msgs[0].GetMetadata(0).Get("level"); exp != act {
+		t.Errorf("Wrong metadata of first part: %v != %v", act, exp)
+	}
+	if exp, act := "error", msgs[0].GetMetadata(1).Get("level"); exp != act {
+		t.Errorf("Wrong metadata of second part: %v != %v", act, exp)
+	}
+	if exp, act := "level=error second", string(msgs[0].Get(1)); exp != act {
+		t.Errorf("Wrong contents of second part: %v != %v", act, exp)
+	}
+
+	conf.Text.Arg = `\d+`
+	if proc, err = NewText(conf, nil, testLog, metrics.DudType{}); err != nil {
+		t.Fatal(err)
+	}
+	msgs, _ = proc.ProcessMessage(types.NewMessage([][]byte{
+		[]byte("first"), []byte("took 250ms"),
+	}))
+	if exp, act := "250", msgs[0].GetMetadata(1).Get("level"); exp != act {
+		t.Errorf("Wrong full match metadata: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,124 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["throttle"] = TypeSpec{
+		constructor: NewThrottle,
+		description: `
+Throttles the throughput of a pipeline according to a
+[rate limit resource](../rate_limits), where each message is counted as a single
+access. Messages are blocked until the rate limit allows them through.
+
+Since rate limits are resources they can be shared with other components, such
+as the ` + "`http_client`" + ` input and output, in order for multiple
+components to share a single budget.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ThrottleConfig contains any configuration for the Throttle processor.
+type ThrottleConfig struct {
+	RateLimit string `json:"rate_limit" yaml:"rate_limit"`
+}
+
+// NewThrottleConfig returns a ThrottleConfig with default values.
+func NewThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		RateLimit: "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Throttle is a processor that blocks messages until a rate limit allows them
+// through.
+type Throttle struct {
+	log   log.Modular
+	stats metrics.Type
+
+	rl types.RateLimit
+
+	mCount     metrics.StatCounter
+	mLimited   metrics.StatCounter
+	mErrAccess metrics.StatCounter
+	mSent      metrics.StatCounter
+}
+
+// NewThrottle returns a Throttle processor.
+func NewThrottle(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	rl, err := mgr.GetRateLimit(conf.Throttle.RateLimit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to locate rate_limit resource '%v': %v", conf.Throttle.RateLimit, err)
+	}
+	return &Throttle{
+		log:   log.NewModule(".processor.throttle"),
+		stats: stats,
+
+		rl: rl,
+
+		mCount:     stats.GetCounter("processor.throttle.count"),
+		mLimited:   stats.GetCounter("processor.throttle.limited"),
+		mErrAccess: stats.GetCounter("processor.throttle.error.access"),
+		mSent:      stats.GetCounter("processor.throttle.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage blocks until the rate limit allows the message through.
+func (t *Throttle) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	t.mCount.Incr(1)
+
+	for {
+		period, err := t.rl.Access()
+		if err != nil {
+			t.mErrAccess.Incr(1)
+			t.log.Errorf("Rate limit error: %v\n", err)
+			period = time.Second
+		}
+		if period <= 0 {
+			break
+		}
+		t.mLimited.Incr(1)
+		<-time.After(period)
+	}
+
+	t.mSent.Incr(1)
+	msgs := [1]types.Message{msg}
+	return msgs[:], nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+type fakeRateLimit struct {
+	periods []time.Duration
+}
+
+func (f *fakeRateLimit) Access() (time.Duration, error) {
+	if len(f.periods) == 0 {
+		return 0, nil
+	}
+	p := f.periods[0]
+	f.periods = f.periods[1:]
+	return p, nil
+}
+
+func TestThrottleNoRateLimit(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Throttle.RateLimit = "foo"
+	if _, err := NewThrottle(conf, &fakeMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing rate limit")
+	}
+}
+
+func TestThrottleBasic(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	rl := &fakeRateLimit{
+		periods: []time.Duration{time.Millisecond * 20, time.Millisecond * 20},
+	}
+	mgr := &fakeMgr{
+		ratelimits: map[string]types.RateLimit{"foo": rl},
+	}
+
+	conf := NewConfig()
+	conf.Throttle.RateLimit = "foo"
+	proc, err := NewThrottle(conf, mgr, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := [][]byte{[]byte("foo"), []byte("bar")}
+
+	tStarted := time.Now()
+	msgs, res := proc.ProcessMessage(types.NewMessage(exp))
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if dur := time.Since(tStarted); dur < time.Millisecond*40 {
+		t.Errorf("Message was not throttled: %v", dur)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"fmt"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["try"] = TypeSpec{
+		constructor: NewTry,
+		description: `
+Applies a list of child processors to messages in order, where message parts
+that have been flagged as having failed a processing step skip all remaining
+child processors. Parts that had already failed before reaching this processor
+skip all of them.
+
+Each child processor is applied to a message made only of the parts that have
+not failed. If the child results in a single message with the same number of
+parts then the failed parts are placed back into their original positions,
+otherwise the failed parts continue as a separate message.
+
+Failed parts keep their failure flag, and can therefore be handled by following
+this processor with a ` + "`catch`" + ` processor:
+
+` + "``` yaml" + `
+- type: try
+  try:
+  - type: json
+    json:
+      operator: select
+      path: foo
+  - type: text
+    text:
+      operator: to_upper
+- type: catch
+  catch:
+  - type: metadata
+    metadata:
+      operator: set
+      key: route
+      value: dlq
+` + "```" + ``,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// TryConfig is a config struct containing fields for the Try processor.
+type TryConfig []Config
+
+// NewTryConfig returns a default TryConfig.
+func NewTryConfig() TryConfig {
+	return TryConfig{}
+}
+
+//------------------------------------------------------------------------------
+
+// Try is a processor that applies a list of child processors to the parts of
+// a message that have not failed a processing step.
+type Try struct {
+	children []Type
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount   metrics.StatCounter
+	mSkipped metrics.StatCounter
+	mDropped metrics.StatCounter
+	mSent    metrics.StatCounter
+}
+
+// NewTry returns a Try processor.
+func NewTry(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	var children []Type
+	for i, pconf := range conf.Try {
+		proc, err := New(pconf, mgr, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create child processor '%v': %v", i, err)
+		}
+		children = append(children, proc)
+	}
+	return &Try{
+		children: children,
+
+		log:   log.NewModule(".processor.try"),
+		stats: stats,
+
+		mCount:   stats.GetCounter("processor.try.count"),
+		mSkipped: stats.GetCounter("processor.try.parts.skipped"),
+		mDropped: stats.GetCounter("processor.try.dropped"),
+		mSent:    stats.GetCounter("processor.try.sent"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage applies the child processors to the parts of a message that
+// have not failed a processing step.
+func (t *Try) ProcessMessage(msg types.Message) (msgs []types.Message, res types.Response) {
+	t.mCount.Incr(1)
+
+	resultMsgs := []types.Message{msg}
+	var resultRes types.Response
+
+	for i := 0; len(resultMsgs) > 0 && i < len(t.children); i++ {
+		var nextResultMsgs []types.Message
+		for _, m := range resultMsgs {
+			notFailed := func(j int) bool {
+				if len(m.GetMetadata(j).Get(FailFlagKey)) > 0 {
+					t.mSkipped.Incr(1)
+					return false
+				}
+				return true
+			}
+			var rMsgs []types.Message
+			rMsgs, resultRes = processSubset(m, notFailed, t.children[i].ProcessMessage)
+			nextResultMsgs = append(nextResultMsgs, rMsgs...)
+		}
+		resultMsgs = nextResultMsgs
+	}
+
+	if len(resultMsgs) == 0 {
+		t.mDropped.Incr(1)
+		res = resultRes
+	} else {
+		t.mSent.Incr(int64(len(resultMsgs)))
+		msgs = resultMsgs
+	}
+
+	return
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestTryBadChild(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	procConf := NewConfig()
+	procConf.Type = "nope"
+
+	conf := NewConfig()
+	conf.Try = append(conf.Try, procConf)
+
+	if _, err := NewTry(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad child processor")
+	}
+}
+
+func TestTrySkipsFailedParts(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	selectConf := NewConfig()
+	selectConf.Type = "select_json"
+	selectConf.SelectJSON.Path = "foo"
+
+	appendConf := NewConfig()
+	appendConf.Type = "text"
+	appendConf.Text.Operator = "append"
+	appendConf.Text.Value = " done"
+
+	conf := NewConfig()
+	conf.Try = append(conf.Try, selectConf, appendConf)
+
+	proc, err := NewTry(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte(`{"foo":"first"}`),
+		[]byte(`not json`),
+		[]byte(`{"foo":"third"}`),
+		[]byte(`{"foo":"fourth"}`),
+	})
+	FlagFail(input, 3, errFailed)
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of messages: %v", len(msgs))
+	}
+
+	exp := [][]byte{
+		[]byte(`first done`),
+		[]byte(`not json`),
+		[]byte(`third done`),
+		[]byte(`{"foo":"fourth"}`),
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	for i, exp := range []bool{false, true, false, true} {
+		if act := HasFailed(types.ExtractPart(msgs[0], i)); act != exp {
+			t.Errorf("Wrong failure flag for part %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestTryStructureChange(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	splitConf := NewConfig()
+	splitConf.Type = "split"
+
+	conf := NewConfig()
+	conf.Try = append(conf.Try, splitConf)
+
+	proc, err := NewTry(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte(`foo`),
+		[]byte(`bar`),
+		[]byte(`baz`),
+	})
+	FlagFail(input, 1, errFailed)
+
+	msgs, res := proc.ProcessMessage(input)
+	if res != nil {
+		t.Fatal(res.Error())
+	}
+
+	exp := [][][]byte{
+		{[]byte(`foo`)},
+		{[]byte(`baz`)},
+		{[]byte(`bar`)},
+	}
+	act := [][][]byte{}
+	for _, m := range msgs {
+		act = append(act, m.GetAll())
+	}
+	if !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -38,3 +38,87 @@ type Type interface {
 }
 
 //------------------------------------------------------------------------------
+
+// FailFlagKey is the metadata key used to flag a message part as having failed
+// a processing step, the value of the key is the error that occurred.
+const FailFlagKey = "benthos_processing_failed"
+
+// FlagFail marks a message part as having failed a processing step. Negative
+// indexes are counted from the end of the message.
+func FlagFail(msg types.Message, index int, err error) {
+	msg.GetMetadata(index).Set(FailFlagKey, err.Error())
+}
+
+// ClearFail removes the processing failure flag from a message part. Negative
+// indexes are counted from the end of the message.
+func ClearFail(msg types.Message, index int) {
+	msg.GetMetadata(index).Delete(FailFlagKey)
+}
+
+// HasFailed returns true if any part of a message has been flagged as having
+// failed a processing step.
+func HasFailed(msg types.Message) bool {
+	for i := 0; i < msg.Len(); i++ {
+		if len(msg.GetMetadata(i).Get(FailFlagKey)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// processSubset applies a process function to a message made of the parts of
+// msg that are selected by the index predicate, and combines the result with
+// the parts that were not selected. If the process function results in a
+// single message with the same number of parts as the subset then the parts are
+// placed back into their original positions. Otherwise the parts that were not
+// selected are kept as a separate message following the results.
+func processSubset(
+	msg types.Message,
+	selected func(i int) bool,
+	process func(msg types.Message) ([]types.Message, types.Response),
+) ([]types.Message, types.Response) {
+	subset := types.NewMessage(nil)
+	remaining := types.NewMessage(nil)
+	isSelected := make([]bool, msg.Len())
+
+	msg.Iter(func(i int, part []byte) error {
+		target := remaining
+		if isSelected[i] = selected(i); isSelected[i] {
+			target = subset
+		}
+		target.SetMetadata(msg.GetMetadata(i), target.Append(part))
+		return nil
+	})
+
+	if subset.Len() == 0 {
+		return []types.Message{msg}, nil
+	}
+	if remaining.Len() == 0 {
+		return process(msg)
+	}
+
+	// The parts that were not selected are always propagated, and therefore a
+	// response from the subset is not returned.
+	resultMsgs, _ := process(subset)
+	if len(resultMsgs) != 1 || resultMsgs[0].Len() != subset.Len() {
+		return append(resultMsgs, remaining), nil
+	}
+
+	result := resultMsgs[0]
+	newMsg := types.NewMessage(nil)
+	j, k := 0, 0
+	for i := range isSelected {
+		if isSelected[i] {
+			newMsg.SetMetadata(result.GetMetadata(j), newMsg.Append(result.Get(j)))
+			j++
+		} else {
+			newMsg.SetMetadata(remaining.GetMetadata(k), newMsg.Append(remaining.Get(k)))
+			k++
+		}
+	}
+	return []types.Message{newMsg}, nil
+}
+
+//------------------------------------------------------------------------------
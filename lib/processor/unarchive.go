@@ -22,6 +22,7 @@ package processor
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"fmt"
 	"io"
@@ -38,20 +39,27 @@ func init() {
 		constructor: NewUnarchive,
 		description: `
 Unarchives parts of a message according to the selected archive type into
-multiple parts. Supported archive types are: tar, binary. If the list of target
-parts is empty the unarchive will be applied to all message parts.
+multiple parts. Supported archive types are: tar, zip, binary, lines. If the
+list of target parts is empty the unarchive will be applied to all message
+parts.
 
 When a part is unarchived it is split into more message parts that replace the
 original part. If you wish to split the archive into one message per file then
-follow this with the 'split' processor.
+follow this with the 'split' processor. Each new part inherits the metadata of
+the part it was unarchived from.
+
+The 'tar' and 'zip' types extract each file of the archive into a part and
+ignore directories. The 'binary' type extracts parts from the binary format
+written by the 'archive' processor. The 'lines' type extracts each line of the
+part, where a single trailing newline is ignored.
 
 Part indexes can be negative, and if so the part will be selected from the end
 counting backwards starting from -1. E.g. if index = -1 then the selected part
 will be the last part of the message, if index = -2 then the part before the
 last element with be selected, and so on.
 
-Parts that are selected but fail to unarchive (invalid format) will be removed
-from the message. If the message results in zero parts it is skipped entirely.`,
+Parts that are selected but fail to unarchive (invalid format) are flagged as
+having failed a processing step and are otherwise left unchanged.`,
 	}
 }
 
@@ -103,6 +111,48 @@ func tarUnarchive(b []byte) ([][]byte, error) {
 	return newParts, nil
 }
 
+func zipUnarchive(b []byte) ([][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+
+	var newParts [][]byte
+
+	// Iterate through the files in the archive.
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		fr, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		newPartBuf := bytes.Buffer{}
+		_, err = newPartBuf.ReadFrom(fr)
+		fr.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		newParts = append(newParts, newPartBuf.Bytes())
+	}
+
+	return newParts, nil
+}
+
+func linesUnarchive(b []byte) ([][]byte, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if b[len(b)-1] == '\n' {
+		b = b[:len(b)-1]
+	}
+	return bytes.Split(b, []byte("\n")), nil
+}
+
 func binaryUnarchive(b []byte) ([][]byte, error) {
 	msg, err := types.FromBytes(b)
 	if err != nil {
@@ -116,8 +166,12 @@ func strToUnarchiver(str string) (unarchiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarUnarchive, nil
+	case "zip":
+		return zipUnarchive, nil
 	case "binary":
 		return binaryUnarchive, nil
+	case "lines":
+		return linesUnarchive, nil
 	}
 	return nil, fmt.Errorf("archive format not recognised: %v", str)
 }
@@ -187,15 +241,21 @@ func (d *Unarchive) ProcessMessage(msg types.Message) ([]types.Message, types.Re
 			}
 		}
 		if !isTarget {
-			newMsg.Append(part)
+			newMsg.SetMetadata(msg.GetMetadata(i), newMsg.Append(part))
 			continue
 		}
 		newParts, err := d.unarchive(part)
-		if err == nil {
-			d.mSucc.Incr(1)
-			newMsg.Append(newParts...)
-		} else {
+		if err != nil {
+			d.log.Debugf("Failed to unarchive part %v: %v\n", i, err)
 			d.mErr.Incr(1)
+			index := newMsg.Append(part)
+			newMsg.SetMetadata(msg.GetMetadata(i), index)
+			FlagFail(newMsg, index, err)
+			continue
+		}
+		d.mSucc.Incr(1)
+		for _, newPart := range newParts {
+			newMsg.SetMetadata(msg.GetMetadata(i), newMsg.Append(newPart))
 		}
 	}
 
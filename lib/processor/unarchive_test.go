@@ -22,6 +22,7 @@ package processor
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"fmt"
 	"os"
@@ -124,8 +125,12 @@ func TestUnarchiveBinary(t *testing.T) {
 	}
 	if msgs, _ := proc.ProcessMessage(
 		types.NewMessage([][]byte{[]byte("wat this isnt good")}),
-	); len(msgs) > 0 {
-		t.Error("Expected fail on bad message")
+	); len(msgs) != 1 {
+		t.Error("Expected bad message to be kept")
+	} else if !HasFailed(msgs[0]) {
+		t.Error("Expected bad message to be flagged")
+	} else if exp, act := "wat this isnt good", string(msgs[0].Get(0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
 	}
 
 	testMsg := types.NewMessage([][]byte{[]byte("hello"), []byte("world")})
@@ -140,6 +145,105 @@ func TestUnarchiveBinary(t *testing.T) {
 	}
 }
 
+func TestUnarchiveZip(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "zip"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	exp := [][]byte{
+		[]byte("hello world first part"),
+		[]byte("hello world second part"),
+		[]byte("third part"),
+	}
+
+	buf := bytes.Buffer{}
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.Create("somedir/"); err != nil {
+		t.Fatal(err)
+	}
+	for i, part := range exp {
+		fw, err := zw.Create(fmt.Sprintf("somedir/testfile%v", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = fw.Write(part); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	proc, err := NewUnarchive(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msgs, _ := proc.ProcessMessage(
+		types.NewMessage([][]byte{[]byte("wat this isnt good")}),
+	); len(msgs) != 1 {
+		t.Error("Expected bad message to be kept")
+	} else if !HasFailed(msgs[0]) {
+		t.Error("Expected bad message to be flagged")
+	} else if exp, act := "wat this isnt good", string(msgs[0].Get(0)); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{buf.Bytes()}))
+	if len(msgs) != 1 {
+		t.Fatal("Unarchive failed")
+	} else if res != nil {
+		t.Errorf("Expected nil response: %v", res)
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected output: %s != %s", act, exp)
+	}
+}
+
+func TestUnarchiveLines(t *testing.T) {
+	conf := NewConfig()
+	conf.Unarchive.Format = "lines"
+	conf.Unarchive.Parts = []int{1}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	proc, err := NewUnarchive(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := types.NewMessage([][]byte{
+		[]byte("first\nnot split"),
+		[]byte("foo\nbar\n\nbaz\n"),
+	})
+	input.GetMetadata(0).Set("source", "a")
+	input.GetMetadata(1).Set("source", "b")
+
+	exp := [][]byte{
+		[]byte("first\nnot split"),
+		[]byte("foo"),
+		[]byte("bar"),
+		[]byte(""),
+		[]byte("baz"),
+	}
+	expMeta := []string{"a", "b", "b", "b", "b"}
+
+	msgs, res := proc.ProcessMessage(input)
+	if len(msgs) != 1 {
+		t.Fatal("Unarchive failed")
+	} else if res != nil {
+		t.Errorf("Expected nil response: %v", res)
+	}
+	if act := msgs[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Unexpected output: %s != %s", act, exp)
+	}
+	for i, exp := range expMeta {
+		if act := msgs[0].GetMetadata(i).Get("source"); act != exp {
+			t.Errorf("Wrong metadata for part %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
 func TestUnarchiveIndexBounds(t *testing.T) {
 	conf := NewConfig()
 	conf.Unarchive.Format = "tar"
@@ -264,7 +368,15 @@ func TestUnarchiveEmpty(t *testing.T) {
 	msgs, _ = proc.ProcessMessage(types.NewMessage(
 		[][]byte{[]byte("first"), []byte("second")},
 	))
-	if len(msgs) != 0 {
-		t.Error("Expected failure with bad data")
+	if len(msgs) != 1 {
+		t.Fatal("Expected bad data to be kept")
+	}
+	for i, exp := range []string{"first", "second"} {
+		if act := string(msgs[0].Get(i)); exp != act {
+			t.Errorf("Wrong result at %v: %v != %v", i, act, exp)
+		}
+		if len(msgs[0].GetMetadata(i).Get(FailFlagKey)) == 0 {
+			t.Errorf("Expected part %v to be flagged", i)
+		}
 	}
 }
@@ -0,0 +1,306 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/gabs"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["window"] = TypeSpec{
+		constructor: NewWindow,
+		description: `
+Groups message parts into windows of time, buffering (but not acknowledging)
+them until a window closes, at which point all parts of the window are sent
+through the pipeline as a single batch. Once the batch has reached a destination
+the acknowledgement is sent out for all messages inside it.
+
+The time of a message is the time at which it was read by the input. Since
+processors are only executed when messages arrive a window is closed by the
+first message to arrive after the window has ended. Closed windows are sent in
+order of their end times.
+
+The ` + "`mode`" + ` field determines the shape of windows:
+
+- ` + "`tumbling`" + ` windows are consecutive, non-overlapping, and of a fixed
+  size ` + "`size_ms`" + `.
+- ` + "`sliding`" + ` windows are of a fixed size ` + "`size_ms`" + ` and begin
+  every ` + "`slide_ms`" + `, meaning a part can belong to multiple windows.
+- ` + "`session`" + ` windows begin with the first part to arrive and remain
+  open until no parts have arrived for ` + "`gap_ms`" + `.
+
+If ` + "`key_path`" + ` is set then each part is parsed as a JSON blob and the
+value at the dot path is used as a key, where each key has its own windows.
+
+Each part of a window batch is given the metadata keys ` + "`window_key`" + `,
+` + "`window_start`" + ` and ` + "`window_end`" + `, where times are in RFC3339
+format. Processors such as ` + "`merge_json`" + ` can then be used in order to
+aggregate the batch.
+
+Since some inputs, such as ` + "`kafka`" + `, acknowledge messages
+cumulatively, parts in windows that are still open may be acknowledged by the
+delivery of earlier windows when using keys or sliding windows.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// WindowConfig contains configuration for the Window processor.
+type WindowConfig struct {
+	Mode    string `json:"mode" yaml:"mode"`
+	KeyPath string `json:"key_path" yaml:"key_path"`
+	SizeMS  int    `json:"size_ms" yaml:"size_ms"`
+	SlideMS int    `json:"slide_ms" yaml:"slide_ms"`
+	GapMS   int    `json:"gap_ms" yaml:"gap_ms"`
+}
+
+// NewWindowConfig returns a WindowConfig with default values.
+func NewWindowConfig() WindowConfig {
+	return WindowConfig{
+		Mode:    "tumbling",
+		KeyPath: "",
+		SizeMS:  60000,
+		SlideMS: 10000,
+		GapMS:   10000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// windowState is a window that is open and accumulating message parts.
+type windowState struct {
+	key        string
+	start, end time.Time
+	parts      [][]byte
+	metadata   []types.Metadata
+}
+
+// Window is a processor that groups message parts into windows of time, which
+// are sent out as batches once they close.
+type Window struct {
+	mode    string
+	keyPath []string
+	size    time.Duration
+	slide   time.Duration
+	gap     time.Duration
+
+	windows []*windowState
+	timeFn  func(msg types.Message) time.Time
+
+	log   log.Modular
+	stats metrics.Type
+
+	mCount   metrics.StatCounter
+	mErrKey  metrics.StatCounter
+	mSent    metrics.StatCounter
+	mDropped metrics.StatCounter
+}
+
+// NewWindow returns a Window processor.
+func NewWindow(
+	conf Config, mgr types.Manager, log log.Modular, stats metrics.Type,
+) (Type, error) {
+	w := &Window{
+		mode:  conf.Window.Mode,
+		size:  time.Duration(conf.Window.SizeMS) * time.Millisecond,
+		slide: time.Duration(conf.Window.SlideMS) * time.Millisecond,
+		gap:   time.Duration(conf.Window.GapMS) * time.Millisecond,
+		timeFn: func(msg types.Message) time.Time {
+			return msg.CreatedAt()
+		},
+
+		log:   log.NewModule(".processor.window"),
+		stats: stats,
+
+		mCount:   stats.GetCounter("processor.window.count"),
+		mErrKey:  stats.GetCounter("processor.window.error.key"),
+		mSent:    stats.GetCounter("processor.window.sent"),
+		mDropped: stats.GetCounter("processor.window.dropped"),
+	}
+	if len(conf.Window.KeyPath) > 0 {
+		w.keyPath = strings.Split(conf.Window.KeyPath, ".")
+	}
+
+	switch w.mode {
+	case "tumbling":
+		if w.size <= 0 {
+			return nil, fmt.Errorf("size_ms must be greater than zero for %v windows", w.mode)
+		}
+	case "sliding":
+		if w.size <= 0 || w.slide <= 0 {
+			return nil, fmt.Errorf("size_ms and slide_ms must be greater than zero for %v windows", w.mode)
+		}
+	case "session":
+		if w.gap <= 0 {
+			return nil, fmt.Errorf("gap_ms must be greater than zero for %v windows", w.mode)
+		}
+	default:
+		return nil, fmt.Errorf("window mode not recognised: %v", w.mode)
+	}
+	return w, nil
+}
+
+//------------------------------------------------------------------------------
+
+// getKey returns the window key of a message part.
+func (w *Window) getKey(msg types.Message, index int) string {
+	if len(w.keyPath) == 0 {
+		return ""
+	}
+	jsonPart, err := msg.GetJSON(index)
+	if err != nil {
+		w.mErrKey.Incr(1)
+		w.log.Debugf("Failed to parse part into json: %v\n", err)
+		return ""
+	}
+	gPart, err := gabs.Consume(jsonPart)
+	if err != nil {
+		w.mErrKey.Incr(1)
+		w.log.Debugf("Failed to parse part into json: %v\n", err)
+		return ""
+	}
+	switch t := gPart.Search(w.keyPath...).Data().(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	default:
+		return gPart.Search(w.keyPath...).String()
+	}
+}
+
+// getWindow returns the open window of a key that starts at a time, creating
+// it if it doesn't yet exist.
+func (w *Window) getWindow(key string, start, end time.Time) *windowState {
+	for _, win := range w.windows {
+		if win.key == key && win.start.Equal(start) {
+			return win
+		}
+	}
+	win := &windowState{key: key, start: start, end: end}
+	w.windows = append(w.windows, win)
+	return win
+}
+
+// add places a message part in each of the windows it belongs to.
+func (w *Window) add(key string, t time.Time, part []byte, md types.Metadata) {
+	var targets []*windowState
+
+	switch w.mode {
+	case "tumbling":
+		start := t.Truncate(w.size)
+		targets = append(targets, w.getWindow(key, start, start.Add(w.size)))
+	case "sliding":
+		for start := t.Truncate(w.slide); start.Add(w.size).After(t); start = start.Add(-w.slide) {
+			targets = append(targets, w.getWindow(key, start, start.Add(w.size)))
+		}
+	case "session":
+		var win *windowState
+		for _, s := range w.windows {
+			if s.key == key {
+				win = s
+				break
+			}
+		}
+		if win == nil {
+			win = &windowState{key: key, start: t}
+			w.windows = append(w.windows, win)
+		}
+		win.end = t.Add(w.gap)
+		targets = append(targets, win)
+	}
+
+	for _, win := range targets {
+		win.parts = append(win.parts, part)
+		win.metadata = append(win.metadata, md.Copy())
+	}
+}
+
+// flush removes all windows that have closed by a time and returns them as
+// messages, ordered by their end times.
+func (w *Window) flush(t time.Time) []types.Message {
+	var closed []*windowState
+	open := w.windows[:0]
+	for _, win := range w.windows {
+		if !win.end.After(t) {
+			closed = append(closed, win)
+		} else {
+			open = append(open, win)
+		}
+	}
+	w.windows = open
+
+	sort.SliceStable(closed, func(i, j int) bool {
+		return closed[i].end.Before(closed[j].end)
+	})
+
+	var msgs []types.Message
+	for _, win := range closed {
+		newMsg := types.NewMessage(win.parts)
+		for i, md := range win.metadata {
+			md.Set("window_key", win.key).
+				Set("window_start", win.start.Format(time.RFC3339Nano)).
+				Set("window_end", win.end.Format(time.RFC3339Nano))
+			newMsg.SetMetadata(md, i)
+		}
+		msgs = append(msgs, newMsg)
+	}
+	return msgs
+}
+
+//------------------------------------------------------------------------------
+
+// ProcessMessage takes a single message and adds its parts to windows,
+// returning a NoAck response until one or more windows have closed, at which
+// point each closed window is sent on as a message.
+func (w *Window) ProcessMessage(msg types.Message) ([]types.Message, types.Response) {
+	w.mCount.Incr(1)
+
+	t := w.timeFn(msg)
+	msgs := w.flush(t)
+
+	for i, part := range msg.GetAll() {
+		w.add(w.getKey(msg, i), t, part, msg.GetMetadata(i))
+	}
+
+	if len(msgs) == 0 {
+		w.mDropped.Incr(1)
+		return nil, types.NewUnacknowledgedResponse()
+	}
+
+	w.mSent.Incr(int64(len(msgs)))
+	return msgs, nil
+}
+
+//------------------------------------------------------------------------------
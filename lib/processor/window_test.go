@@ -0,0 +1,200 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package processor
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+type windowTestInput struct {
+	part    string
+	atMS    int
+	outputs [][]string
+}
+
+func testWindow(t *testing.T, conf Config, inputs []windowTestInput) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	proc, err := NewWindow(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	epoch := time.Unix(1000, 0)
+	var at time.Time
+	proc.(*Window).timeFn = func(types.Message) time.Time { return at }
+
+	for i, input := range inputs {
+		at = epoch.Add(time.Millisecond * time.Duration(input.atMS))
+		msgs, res := proc.ProcessMessage(types.NewMessage([][]byte{[]byte(input.part)}))
+
+		if len(input.outputs) == 0 {
+			if res == nil || !res.SkipAck() {
+				t.Errorf("Expected skip ack response at input %v", i)
+			}
+			continue
+		}
+		if res != nil {
+			t.Errorf("Unexpected response at input %v: %v", i, res.Error())
+		}
+		var act [][]string
+		for _, m := range msgs {
+			var parts []string
+			for _, p := range m.GetAll() {
+				parts = append(parts, string(p))
+			}
+			act = append(act, parts)
+		}
+		if exp := input.outputs; !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong windows at input %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestWindowTumbling(t *testing.T) {
+	conf := NewConfig()
+	conf.Window.Mode = "tumbling"
+	conf.Window.SizeMS = 100
+
+	testWindow(t, conf, []windowTestInput{
+		{part: "a", atMS: 0},
+		{part: "b", atMS: 50},
+		{part: "c", atMS: 99},
+		{part: "d", atMS: 100, outputs: [][]string{{"a", "b", "c"}}},
+		{part: "e", atMS: 350, outputs: [][]string{{"d"}}},
+		{part: "f", atMS: 400, outputs: [][]string{{"e"}}},
+	})
+}
+
+func TestWindowSliding(t *testing.T) {
+	conf := NewConfig()
+	conf.Window.Mode = "sliding"
+	conf.Window.SizeMS = 100
+	conf.Window.SlideMS = 50
+
+	testWindow(t, conf, []windowTestInput{
+		{part: "a", atMS: 0},
+		{part: "b", atMS: 60, outputs: [][]string{{"a"}}},
+		{part: "c", atMS: 110, outputs: [][]string{{"a", "b"}}},
+		{part: "d", atMS: 160, outputs: [][]string{{"b", "c"}}},
+		{part: "e", atMS: 300, outputs: [][]string{{"c", "d"}, {"d"}}},
+	})
+}
+
+func TestWindowSession(t *testing.T) {
+	conf := NewConfig()
+	conf.Window.Mode = "session"
+	conf.Window.GapMS = 100
+
+	testWindow(t, conf, []windowTestInput{
+		{part: "a", atMS: 0},
+		{part: "b", atMS: 90},
+		{part: "c", atMS: 180},
+		{part: "d", atMS: 280, outputs: [][]string{{"a", "b", "c"}}},
+		{part: "e", atMS: 379},
+		{part: "f", atMS: 500, outputs: [][]string{{"d", "e"}}},
+	})
+}
+
+func TestWindowKeyed(t *testing.T) {
+	conf := NewConfig()
+	conf.Window.Mode = "tumbling"
+	conf.Window.SizeMS = 100
+	conf.Window.KeyPath = "user"
+
+	testWindow(t, conf, []windowTestInput{
+		{part: `{"user":"x","v":1}`, atMS: 0},
+		{part: `{"user":"y","v":2}`, atMS: 10},
+		{part: `{"user":"x","v":3}`, atMS: 20},
+		{part: `{"user":"y","v":4}`, atMS: 150, outputs: [][]string{
+			{`{"user":"x","v":1}`, `{"user":"x","v":3}`},
+			{`{"user":"y","v":2}`},
+		}},
+	})
+}
+
+func TestWindowMetadata(t *testing.T) {
+	conf := NewConfig()
+	conf.Window.Mode = "tumbling"
+	conf.Window.SizeMS = 1000
+	conf.Window.KeyPath = "user"
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	proc, err := NewWindow(conf, nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	epoch := time.Unix(1000, 0).UTC()
+	at := epoch
+	proc.(*Window).timeFn = func(types.Message) time.Time { return at }
+
+	msg := types.NewMessage([][]byte{[]byte(`{"user":"x"}`)})
+	msg.GetMetadata(0).Set("foo", "bar")
+	if _, res := proc.ProcessMessage(msg); res == nil || !res.SkipAck() {
+		t.Fatal("Expected skip ack response")
+	}
+
+	at = epoch.Add(time.Second)
+	msgs, _ := proc.ProcessMessage(types.NewMessage([][]byte{[]byte(`{"user":"x"}`)}))
+	if len(msgs) != 1 {
+		t.Fatalf("Wrong count of windows: %v", len(msgs))
+	}
+
+	md := msgs[0].GetMetadata(0)
+	for k, exp := range map[string]string{
+		"foo":          "bar",
+		"window_key":   "x",
+		"window_start": epoch.Format(time.RFC3339Nano),
+		"window_end":   epoch.Add(time.Second).Format(time.RFC3339Nano),
+	} {
+		if act := md.Get(k); exp != act {
+			t.Errorf("Wrong metadata value for %v: %v != %v", k, act, exp)
+		}
+	}
+}
+
+func TestWindowBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Window.Mode = "nope"
+	if _, err := NewWindow(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad mode")
+	}
+
+	conf = NewConfig()
+	conf.Window.Mode = "sliding"
+	conf.Window.SlideMS = 0
+	if _, err := NewWindow(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from zero slide")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/config"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// TypeSpec is a constructor and a usage description for each rate limit type.
+type TypeSpec struct {
+	constructor func(conf Config, mgr types.Manager, log log.Modular, stats metrics.Type) (types.RateLimit, error)
+	description string
+}
+
+// Constructors is a map of all rate limit types with their specs.
+var Constructors = map[string]TypeSpec{}
+
+//------------------------------------------------------------------------------
+
+// Config is the all encompassing configuration struct for all rate limit
+// types.
+type Config struct {
+	Type  string      `json:"type" yaml:"type"`
+	Local LocalConfig `json:"local" yaml:"local"`
+}
+
+// NewConfig returns a configuration struct fully populated with default values.
+func NewConfig() Config {
+	return Config{
+		Type:  "local",
+		Local: NewLocalConfig(),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// UnmarshalJSON ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (c *Config) UnmarshalJSON(bytes []byte) error {
+	type confAlias Config
+	aliased := confAlias(NewConfig())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*c = Config(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias Config
+	aliased := confAlias(NewConfig())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+
+	*c = Config(aliased)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+var header = "This document was generated with `benthos --list-rate-limits`" + `
+
+A rate limit is a strategy for limiting the usage of a shared resource across
+parallel components in a Benthos instance, or potentially across multiple
+instances. Rate limits are listed with unique labels which are referred to by
+components that share them. For example, if we wished to limit the requests of
+both an ` + "`http_client`" + ` input and output to the same API we could arrange
+our config as follows:
+
+` + "``` yaml" + `
+input:
+  type: http_client
+  http_client:
+    url: http://localhost:4195/get
+    rate_limit: foobar
+output:
+  type: http_client
+  http_client:
+    url: http://localhost:4195/post
+    rate_limit: foobar
+resources:
+  rate_limits:
+    foobar:
+      type: local
+      local:
+        count: 500
+        interval_ms: 1000
+` + "```" + `
+
+In that example the input and output share a budget of 500 requests per second
+between them. Processing steps can also be limited with the ` + "`throttle`" + `
+processor.`
+
+// Specs returns a specification of each rate limit type, including the default
+// values of its config fields.
+func Specs() []config.ComponentSpec {
+	descriptions := map[string]string{}
+	for name, spec := range Constructors {
+		descriptions[name] = spec.description
+	}
+	return config.NewComponentSpecs(descriptions, NewConfig())
+}
+
+// Descriptions returns a formatted string of descriptions for each type.
+func Descriptions() string {
+	// Order our rate limit types alphabetically
+	names := []string{}
+	for name := range Constructors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := bytes.Buffer{}
+	buf.WriteString("Rate Limits\n")
+	buf.WriteString(strings.Repeat("=", 11))
+	buf.WriteString("\n\n")
+	buf.WriteString(header)
+	buf.WriteString("\n\n")
+
+	buf.WriteString("### Contents\n\n")
+	for i, name := range names {
+		buf.WriteString(fmt.Sprintf("%v. [`%v`](#%v)\n", i+1, name, name))
+	}
+	buf.WriteString("\n")
+
+	// Append each description
+	for i, name := range names {
+		buf.WriteString("## ")
+		buf.WriteString("`" + name + "`")
+		buf.WriteString("\n")
+		buf.WriteString(Constructors[name].description)
+		if i != (len(names) - 1) {
+			buf.WriteString("\n\n")
+		}
+	}
+	return buf.String()
+}
+
+// New creates a rate limit type based on a rate limit configuration.
+func New(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (types.RateLimit, error) {
+	if c, ok := Constructors[conf.Type]; ok {
+		rl, err := c.constructor(conf, mgr, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rate limit '%v': %v", conf.Type, err)
+		}
+		return rl, nil
+	}
+	return nil, types.ErrInvalidRateLimitType
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func init() {
+	Constructors["local"] = TypeSpec{
+		constructor: NewLocal,
+		description: `
+The local rate limit is a simple token bucket held in memory, allowing up to
+` + "`count`" + ` accesses within each ` + "`interval_ms`" + ` period. Once the
+bucket is empty components wait until the interval has passed, at which point
+the bucket is refilled.
+
+This rate limit is only shared by components within a single Benthos instance.`,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// LocalConfig contains config fields for the Local rate limit type.
+type LocalConfig struct {
+	Count      int `json:"count" yaml:"count"`
+	IntervalMS int `json:"interval_ms" yaml:"interval_ms"`
+}
+
+// NewLocalConfig creates a LocalConfig populated with default values.
+func NewLocalConfig() LocalConfig {
+	return LocalConfig{
+		Count:      1000,
+		IntervalMS: 1000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Local is a token bucket based rate limit held in memory.
+type Local struct {
+	mut         sync.Mutex
+	bucket      int
+	lastRefresh time.Time
+
+	size   int
+	period time.Duration
+
+	mChecked metrics.StatCounter
+	mLimited metrics.StatCounter
+}
+
+// NewLocal creates a new Local rate limit type.
+func NewLocal(
+	conf Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (types.RateLimit, error) {
+	if conf.Local.Count <= 0 {
+		return nil, errors.New("count must be larger than zero")
+	}
+	if conf.Local.IntervalMS <= 0 {
+		return nil, errors.New("interval_ms must be larger than zero")
+	}
+	return &Local{
+		bucket:      conf.Local.Count,
+		lastRefresh: time.Now(),
+		size:        conf.Local.Count,
+		period:      time.Duration(conf.Local.IntervalMS) * time.Millisecond,
+
+		mChecked: stats.GetCounter("rate_limit.local.checked"),
+		mLimited: stats.GetCounter("rate_limit.local.limited"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Access the rate limited resource. Returns a duration or an error if the rate
+// limit check fails. The returned duration is either zero (meaning the resource
+// can be accessed) or a reasonable length of time to wait before requesting
+// again.
+func (r *Local) Access() (time.Duration, error) {
+	r.mChecked.Incr(1)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.bucket--
+	if r.bucket < 0 {
+		r.bucket = 0
+		remaining := r.period - time.Since(r.lastRefresh)
+		if remaining > 0 {
+			r.mLimited.Incr(1)
+			return remaining, nil
+		}
+		r.bucket = r.size - 1
+		r.lastRefresh = time.Now()
+	}
+	return 0, nil
+}
+
+//------------------------------------------------------------------------------
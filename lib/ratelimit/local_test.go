@@ -0,0 +1,162 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestLocalRateLimitConfErrors(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Local.Count = -1
+	if _, err := NewLocal(conf, types.DudMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error with count")
+	}
+
+	conf = NewConfig()
+	conf.Local.IntervalMS = 0
+	if _, err := NewLocal(conf, types.DudMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error with interval")
+	}
+}
+
+func TestLocalRateLimitBasic(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Local.Count = 10
+	conf.Local.IntervalMS = 1000
+
+	rl, err := NewLocal(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < conf.Local.Count; i++ {
+		period, err := rl.Access()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if period > 0 {
+			t.Errorf("Period above zero at access %v: %v", i, period)
+		}
+	}
+
+	period, err := rl.Access()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if period == 0 {
+		t.Error("Expected limit on final access")
+	}
+	if period > time.Second {
+		t.Errorf("Period beyond interval: %v", period)
+	}
+}
+
+func TestLocalRateLimitRefresh(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Local.Count = 10
+	conf.Local.IntervalMS = 10
+
+	rl, err := NewLocal(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for j := 0; j < 3; j++ {
+		for i := 0; i < conf.Local.Count; i++ {
+			period, err := rl.Access()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if period != 0 {
+				t.Errorf("Rate limited on get %v:%v: %v", j, i, period)
+			}
+		}
+
+		period, err := rl.Access()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if period == 0 {
+			t.Errorf("Expected limit on final access %v", j)
+		}
+
+		<-time.After(period)
+	}
+}
+
+func TestLocalRateLimitParallel(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewConfig()
+	conf.Local.Count = 100
+	conf.Local.IntervalMS = 100000
+
+	rl, err := NewLocal(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mut sync.Mutex
+	allowed := 0
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				period, err := rl.Access()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if period == 0 {
+					mut.Lock()
+					allowed++
+					mut.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if exp, act := conf.Local.Count, allowed; exp != act {
+		t.Errorf("Wrong count of allowed accesses: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
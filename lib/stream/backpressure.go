@@ -0,0 +1,283 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// stageMonitor sits in front of a layer of a stream and measures how long each
+// transaction waits for the layer to accept it.
+type stageMonitor struct {
+	name string
+
+	waitNS       int64
+	count        int64
+	blockedSince int64
+
+	transactionsOut chan types.Transaction
+	closeChan       <-chan struct{}
+}
+
+func newStageMonitor(name string, closeChan <-chan struct{}) *stageMonitor {
+	return &stageMonitor{
+		name:            name,
+		transactionsOut: make(chan types.Transaction),
+		closeChan:       closeChan,
+	}
+}
+
+func (s *stageMonitor) loop(transactionsIn <-chan types.Transaction) {
+	defer close(s.transactionsOut)
+
+	for {
+		var tran types.Transaction
+		var open bool
+
+		select {
+		case tran, open = <-transactionsIn:
+			if !open {
+				return
+			}
+		case <-s.closeChan:
+			return
+		}
+
+		started := time.Now()
+		atomic.StoreInt64(&s.blockedSince, started.UnixNano())
+		select {
+		case s.transactionsOut <- tran:
+		case <-s.closeChan:
+			return
+		}
+		atomic.StoreInt64(&s.blockedSince, 0)
+		atomic.AddInt64(&s.waitNS, int64(time.Since(started)))
+		atomic.AddInt64(&s.count, 1)
+	}
+}
+
+// meanWait returns the mean time that transactions waited for the layer since
+// the last call. If no transactions were accepted in that time then the time
+// spent waiting on the current transaction is returned instead.
+func (s *stageMonitor) meanWait() time.Duration {
+	wait := atomic.SwapInt64(&s.waitNS, 0)
+	count := atomic.SwapInt64(&s.count, 0)
+	if count > 0 {
+		return time.Duration(wait / count)
+	}
+	if since := atomic.LoadInt64(&s.blockedSince); since > 0 {
+		return time.Since(time.Unix(0, since))
+	}
+	return 0
+}
+
+//------------------------------------------------------------------------------
+
+// backpressure measures the time that transactions wait between the layers of
+// a stream, exposing them as gauges, and optionally tunes the number of
+// transactions in flight and the batch count at the output layer in response.
+type backpressure struct {
+	conf BackpressureConfig
+
+	monitors []*stageMonitor
+
+	log   log.Modular
+	stats metrics.Type
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+	closeOnce  sync.Once
+}
+
+func newBackpressure(conf BackpressureConfig, log log.Modular, stats metrics.Type) *backpressure {
+	return &backpressure{
+		conf:       conf,
+		log:        log.NewModule(".backpressure"),
+		stats:      stats,
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// monitor returns a transaction channel to be consumed by the named layer in
+// place of the provided channel, where the wait time of each transaction is
+// measured.
+func (b *backpressure) monitor(stage string, transactions <-chan types.Transaction) <-chan types.Transaction {
+	s := newStageMonitor(stage, b.closeChan)
+	b.monitors = append(b.monitors, s)
+	go s.loop(transactions)
+	return s.transactionsOut
+}
+
+// wrappedOutput is implemented by the layers of a stream that wrap its output,
+// which allows the output to be reached for tuning.
+type wrappedOutput interface {
+	unwrap() output.Type
+}
+
+// findTunables unwraps an output layer until it finds outputs that are able to
+// tune their transactions in flight and the count of their batches.
+func findTunables(out output.Type) (inFlight output.Tunable, batching output.BatchTunable) {
+	for out != nil {
+		if t, ok := out.(output.Tunable); ok && inFlight == nil {
+			inFlight = t
+		}
+		if t, ok := out.(output.BatchTunable); ok && batching == nil {
+			if _, max := t.BatchCountLimit(); max > 1 {
+				batching = t
+			}
+		}
+		w, ok := out.(wrappedOutput)
+		if !ok {
+			break
+		}
+		out = w.unwrap()
+	}
+	return
+}
+
+// start begins periodically reporting wait times and, when enabled, tuning the
+// output layer.
+func (b *backpressure) start(out output.Type) {
+	var inFlight output.Tunable
+	var batching output.BatchTunable
+	if b.conf.AutoTune.Enabled {
+		inFlight, batching = findTunables(out)
+		if inFlight != nil {
+			inFlight.SetInFlightLimit(b.conf.AutoTune.MinInFlight)
+		}
+		if batching != nil {
+			batching.SetBatchCountLimit(b.conf.AutoTune.MinBatchCount)
+		}
+		if inFlight == nil && batching == nil {
+			b.log.Warnln("Output does not support auto tuning, either max_in_flight or batching.count must be greater than one.")
+		}
+	}
+	go b.loop(inFlight, batching)
+}
+
+func (b *backpressure) loop(inFlight output.Tunable, batching output.BatchTunable) {
+	defer close(b.closedChan)
+
+	gauges := make([]metrics.StatGauge, len(b.monitors))
+	for i, s := range b.monitors {
+		gauges[i] = b.stats.GetGauge("backpressure." + s.name + ".wait_ns")
+	}
+	mLimit := b.stats.GetGauge("backpressure.output.in_flight_limit")
+	mBatchLimit := b.stats.GetGauge("backpressure.output.batch_count_limit")
+
+	period := time.Millisecond * time.Duration(b.conf.PeriodMS)
+	for {
+		select {
+		case <-time.After(period):
+		case <-b.closeChan:
+			return
+		}
+		for i, s := range b.monitors {
+			wait := s.meanWait()
+			gauges[i].Gauge(int64(wait))
+			if s.name != "output" {
+				continue
+			}
+			if inFlight != nil {
+				mLimit.Gauge(int64(b.tune(inFlight, wait)))
+			}
+			if batching != nil {
+				mBatchLimit.Gauge(int64(b.tuneBatching(batching, wait)))
+			}
+		}
+	}
+}
+
+// tune adjusts the in flight limit of an output by one in the direction that
+// brings the wait time of the output layer towards the target, and returns the
+// resulting limit.
+func (b *backpressure) tune(tunable output.Tunable, wait time.Duration) int {
+	target := time.Millisecond * time.Duration(b.conf.AutoTune.TargetWaitMS)
+	limit, max := tunable.InFlightLimit()
+
+	newLimit := limit
+	if wait > target && limit < max {
+		newLimit++
+	} else if wait < target/2 && limit > b.conf.AutoTune.MinInFlight {
+		newLimit--
+	}
+	if newLimit != limit {
+		b.log.Debugf("Changing output in flight limit from %v to %v\n", limit, newLimit)
+		tunable.SetInFlightLimit(newLimit)
+	}
+	return newLimit
+}
+
+// tuneBatching doubles or halves the batch count limit of an output in the
+// direction that brings the wait time of the output layer towards the target,
+// and returns the resulting limit. Larger batches mean fewer sends and
+// therefore less time spent waiting for the output.
+func (b *backpressure) tuneBatching(tunable output.BatchTunable, wait time.Duration) int {
+	target := time.Millisecond * time.Duration(b.conf.AutoTune.TargetWaitMS)
+	limit, max := tunable.BatchCountLimit()
+
+	newLimit := limit
+	if wait > target && limit < max {
+		if newLimit = limit * 2; newLimit > max {
+			newLimit = max
+		}
+	} else if wait < target/2 && limit > b.conf.AutoTune.MinBatchCount {
+		if newLimit = limit / 2; newLimit < b.conf.AutoTune.MinBatchCount {
+			newLimit = b.conf.AutoTune.MinBatchCount
+		}
+	}
+	if newLimit != limit {
+		b.log.Debugf("Changing output batch count limit from %v to %v\n", limit, newLimit)
+		tunable.SetBatchCountLimit(newLimit)
+	}
+	return newLimit
+}
+
+// CloseAsync stops the monitoring of each layer.
+func (b *backpressure) CloseAsync() {
+	b.closeOnce.Do(func() {
+		close(b.closeChan)
+	})
+}
+
+// WaitForClose blocks until the reporting loop has stopped.
+func (b *backpressure) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-b.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
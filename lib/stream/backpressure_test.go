@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestStageMonitorWait(t *testing.T) {
+	closeChan := make(chan struct{})
+	defer close(closeChan)
+
+	tChan := make(chan types.Transaction)
+	s := newStageMonitor("foo", closeChan)
+	go s.loop(tChan)
+
+	if exp, act := time.Duration(0), s.meanWait(); exp != act {
+		t.Errorf("Wrong wait: %v != %v", act, exp)
+	}
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	<-time.After(time.Millisecond * 50)
+
+	// The transaction has not yet been accepted.
+	if wait := s.meanWait(); wait < time.Millisecond*50 {
+		t.Errorf("Wait too short: %v", wait)
+	}
+
+	select {
+	case tran := <-s.transactionsOut:
+		if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	<-time.After(time.Millisecond * 10)
+
+	if wait := s.meanWait(); wait < time.Millisecond*50 {
+		t.Errorf("Wait too short: %v", wait)
+	}
+	if exp, act := time.Duration(0), s.meanWait(); exp != act {
+		t.Errorf("Wrong wait: %v != %v", act, exp)
+	}
+
+	close(tChan)
+	select {
+	case _, open := <-s.transactionsOut:
+		if open {
+			t.Error("Transaction chan not closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type mockTunable struct {
+	limit, max int
+}
+
+func (m *mockTunable) InFlightLimit() (int, int) {
+	return m.limit, m.max
+}
+
+func (m *mockTunable) SetInFlightLimit(limit int) {
+	m.limit = limit
+}
+
+func TestBackpressureTune(t *testing.T) {
+	conf := NewBackpressureConfig()
+	conf.AutoTune.Enabled = true
+	conf.AutoTune.TargetWaitMS = 10
+	conf.AutoTune.MinInFlight = 2
+
+	b := newBackpressure(
+		conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		metrics.DudType{},
+	)
+	tunable := &mockTunable{limit: 2, max: 4}
+
+	type step struct {
+		wait  time.Duration
+		limit int
+	}
+	for i, s := range []step{
+		{wait: time.Millisecond * 20, limit: 3},
+		{wait: time.Millisecond * 20, limit: 4},
+		{wait: time.Millisecond * 20, limit: 4},
+		{wait: time.Millisecond * 7, limit: 4},
+		{wait: time.Millisecond, limit: 3},
+		{wait: 0, limit: 2},
+		{wait: 0, limit: 2},
+	} {
+		if exp, act := s.limit, b.tune(tunable, s.wait); exp != act {
+			t.Errorf("Wrong limit returned at step %v: %v != %v", i, act, exp)
+		}
+		if exp, act := s.limit, tunable.limit; exp != act {
+			t.Errorf("Wrong limit set at step %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+type mockBatchTunable struct {
+	mockOutput
+	limit, max int
+}
+
+func (m *mockBatchTunable) BatchCountLimit() (int, int) {
+	return m.limit, m.max
+}
+
+func (m *mockBatchTunable) SetBatchCountLimit(limit int) {
+	m.limit = limit
+}
+
+func TestBackpressureTuneBatching(t *testing.T) {
+	conf := NewBackpressureConfig()
+	conf.AutoTune.Enabled = true
+	conf.AutoTune.TargetWaitMS = 10
+	conf.AutoTune.MinBatchCount = 3
+
+	b := newBackpressure(
+		conf, log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		metrics.DudType{},
+	)
+	tunable := &mockBatchTunable{limit: 3, max: 20}
+
+	type step struct {
+		wait  time.Duration
+		limit int
+	}
+	for i, s := range []step{
+		{wait: time.Millisecond * 20, limit: 6},
+		{wait: time.Millisecond * 20, limit: 12},
+		{wait: time.Millisecond * 20, limit: 20},
+		{wait: time.Millisecond * 20, limit: 20},
+		{wait: time.Millisecond * 7, limit: 20},
+		{wait: time.Millisecond, limit: 10},
+		{wait: 0, limit: 5},
+		{wait: 0, limit: 3},
+		{wait: 0, limit: 3},
+	} {
+		if exp, act := s.limit, b.tuneBatching(tunable, s.wait); exp != act {
+			t.Errorf("Wrong limit returned at step %v: %v != %v", i, act, exp)
+		}
+		if exp, act := s.limit, tunable.limit; exp != act {
+			t.Errorf("Wrong limit set at step %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestFindTunables(t *testing.T) {
+	batching := &mockBatchTunable{limit: 10, max: 10}
+	out := newErrorRouter(batching, &mockOutput{})
+
+	inFlight, foundBatching := findTunables(out)
+	if inFlight != nil {
+		t.Errorf("Unexpected in flight tunable: %v", inFlight)
+	}
+	if foundBatching != batching {
+		t.Errorf("Wrong batch tunable found: %v", foundBatching)
+	}
+
+	mif, err := output.NewMaxInFlight([]output.Type{&mockOutput{}, &mockOutput{}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inFlight, _ = findTunables(newErrorRouter(mif, &mockOutput{})); inFlight != mif {
+		t.Errorf("Wrong in flight tunable found: %v", inFlight)
+	}
+
+	if _, foundBatching = findTunables(newErrorRouter(
+		&mockBatchTunable{limit: 0, max: 0}, &mockOutput{},
+	)); foundBatching != nil {
+		t.Error("Expected output without batch count not to be tunable")
+	}
+}
+
+//------------------------------------------------------------------------------
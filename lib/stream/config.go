@@ -25,6 +25,7 @@ import (
 	"github.com/Jeffail/benthos/lib/input"
 	"github.com/Jeffail/benthos/lib/output"
 	"github.com/Jeffail/benthos/lib/pipeline"
+	"github.com/Jeffail/benthos/lib/processor/condition"
 )
 
 //------------------------------------------------------------------------------
@@ -35,6 +36,11 @@ type Config struct {
 	Buffer   buffer.Config   `json:"buffer" yaml:"buffer"`
 	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
 	Output   output.Config   `json:"output" yaml:"output"`
+	OnError  *output.Config  `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+
+	MessageSize *MessageSizeConfig `json:"message_size,omitempty" yaml:"message_size,omitempty"`
+	Priority    *PriorityConfig    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Dedupe      *DedupeConfig      `json:"dedupe,omitempty" yaml:"dedupe,omitempty"`
 }
 
 // NewConfig returns a new configuration with default values.
@@ -74,17 +80,235 @@ func (c Config) Sanitised() (interface{}, error) {
 		return nil, err
 	}
 
+	var errOutConf interface{}
+	if c.OnError != nil {
+		if errOutConf, err = output.SanitiseConfig(*c.OnError); err != nil {
+			return nil, err
+		}
+	}
+
+	var sizeConf interface{}
+	if c.MessageSize != nil {
+		if sizeConf, err = c.MessageSize.Sanitised(); err != nil {
+			return nil, err
+		}
+	}
+
+	var priorityConf interface{}
+	if c.Priority != nil {
+		if priorityConf, err = c.Priority.Sanitised(); err != nil {
+			return nil, err
+		}
+	}
+
 	return struct {
-		Input    interface{} `json:"input" yaml:"input"`
-		Buffer   interface{} `json:"buffer" yaml:"buffer"`
-		Pipeline interface{} `json:"pipeline" yaml:"pipeline"`
-		Output   interface{} `json:"output" yaml:"output"`
+		Input       interface{}   `json:"input" yaml:"input"`
+		Buffer      interface{}   `json:"buffer" yaml:"buffer"`
+		Pipeline    interface{}   `json:"pipeline" yaml:"pipeline"`
+		Output      interface{}   `json:"output" yaml:"output"`
+		OnError     interface{}   `json:"on_error,omitempty" yaml:"on_error,omitempty"`
+		MessageSize interface{}   `json:"message_size,omitempty" yaml:"message_size,omitempty"`
+		Priority    interface{}   `json:"priority,omitempty" yaml:"priority,omitempty"`
+		Dedupe      *DedupeConfig `json:"dedupe,omitempty" yaml:"dedupe,omitempty"`
 	}{
-		Input:    inConf,
-		Buffer:   bufConf,
-		Pipeline: pipeConf,
-		Output:   outConf,
+		Input:       inConf,
+		Buffer:      bufConf,
+		Pipeline:    pipeConf,
+		Output:      outConf,
+		OnError:     errOutConf,
+		MessageSize: sizeConf,
+		Priority:    priorityConf,
+		Dedupe:      c.Dedupe,
 	}, nil
 }
 
 //------------------------------------------------------------------------------
+
+// MessageSizeConfig contains configuration fields that determine the maximum
+// size of messages read by a stream, and what happens to messages that exceed
+// it.
+type MessageSizeConfig struct {
+	MaxBytes   int            `json:"max_bytes" yaml:"max_bytes"`
+	Policy     string         `json:"policy" yaml:"policy"`
+	Quarantine *output.Config `json:"quarantine,omitempty" yaml:"quarantine,omitempty"`
+}
+
+// NewMessageSizeConfig returns a MessageSizeConfig with default values.
+func NewMessageSizeConfig() MessageSizeConfig {
+	return MessageSizeConfig{
+		MaxBytes: 1048576,
+		Policy:   "reject",
+	}
+}
+
+// Sanitised returns a sanitised copy of the message size configuration, where
+// the quarantine output is only included when it is set.
+func (c MessageSizeConfig) Sanitised() (interface{}, error) {
+	var quarantineConf interface{}
+	if c.Quarantine != nil {
+		var err error
+		if quarantineConf, err = output.SanitiseConfig(*c.Quarantine); err != nil {
+			return nil, err
+		}
+	}
+	return struct {
+		MaxBytes   int         `json:"max_bytes" yaml:"max_bytes"`
+		Policy     string      `json:"policy" yaml:"policy"`
+		Quarantine interface{} `json:"quarantine,omitempty" yaml:"quarantine,omitempty"`
+	}{
+		MaxBytes:   c.MaxBytes,
+		Policy:     c.Policy,
+		Quarantine: quarantineConf,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// PriorityConfig contains configuration fields that assign messages to priority
+// lanes ahead of the output. Each lane is a condition, where the first lane has
+// the highest priority. MaxPending caps the number of transactions that can be
+// queued across all lanes before the scheduler stops reading new ones.
+type PriorityConfig struct {
+	Lanes      []condition.Config `json:"lanes" yaml:"lanes"`
+	MaxPending int                `json:"max_pending" yaml:"max_pending"`
+}
+
+// NewPriorityConfig returns a PriorityConfig with default values.
+func NewPriorityConfig() PriorityConfig {
+	return PriorityConfig{
+		Lanes:      []condition.Config{},
+		MaxPending: 1000,
+	}
+}
+
+// Sanitised returns a sanitised copy of the priority configuration.
+func (c PriorityConfig) Sanitised() (interface{}, error) {
+	lanes := []interface{}{}
+	for _, cConf := range c.Lanes {
+		sanLane, err := condition.SanitiseConfig(cConf)
+		if err != nil {
+			return nil, err
+		}
+		lanes = append(lanes, sanLane)
+	}
+	return struct {
+		Lanes      []interface{} `json:"lanes" yaml:"lanes"`
+		MaxPending int           `json:"max_pending" yaml:"max_pending"`
+	}{
+		Lanes:      lanes,
+		MaxPending: c.MaxPending,
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// DedupeConfig contains configuration fields that determine how a stream
+// suppresses messages that have already been delivered by the output. When the
+// key is empty message parts are keyed by their source ID and offset metadata.
+type DedupeConfig struct {
+	Cache string `json:"cache" yaml:"cache"`
+	Key   string `json:"key" yaml:"key"`
+}
+
+// NewDedupeConfig returns a DedupeConfig with default values.
+func NewDedupeConfig() DedupeConfig {
+	return DedupeConfig{
+		Cache: "",
+		Key:   "",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// ShutdownConfig contains configuration fields that determine how a stream
+// behaves when it is being shut down.
+type ShutdownConfig struct {
+	TimeoutMS      int    `json:"timeout_ms" yaml:"timeout_ms"`
+	DrainTimeoutMS int    `json:"drain_timeout_ms" yaml:"drain_timeout_ms"`
+	Pending        string `json:"pending" yaml:"pending"`
+}
+
+// NewShutdownConfig returns a ShutdownConfig with default values.
+func NewShutdownConfig() ShutdownConfig {
+	return ShutdownConfig{
+		TimeoutMS:      20000,
+		DrainTimeoutMS: 0,
+		Pending:        "abandon",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// AcknowledgementConfig contains configuration fields that determine how long
+// a stream waits for a message to be acknowledged by the layers following the
+// input before giving up on it.
+type AcknowledgementConfig struct {
+	TimeoutMS int `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewAcknowledgementConfig returns an AcknowledgementConfig with default
+// values.
+func NewAcknowledgementConfig() AcknowledgementConfig {
+	return AcknowledgementConfig{
+		TimeoutMS: 0,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// CorrelationConfig contains configuration fields that determine whether a
+// stream tags messages with a correlation ID as they are read from the input.
+type CorrelationConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	MetadataKey string `json:"metadata_key" yaml:"metadata_key"`
+}
+
+// NewCorrelationConfig returns a CorrelationConfig with default values.
+func NewCorrelationConfig() CorrelationConfig {
+	return CorrelationConfig{
+		Enabled:     false,
+		MetadataKey: "benthos_correlation_id",
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// BackpressureConfig contains configuration fields that determine whether a
+// stream measures the time that transactions wait between each of its layers,
+// and whether it uses those measurements to tune the output.
+type BackpressureConfig struct {
+	Enabled  bool           `json:"enabled" yaml:"enabled"`
+	PeriodMS int            `json:"period_ms" yaml:"period_ms"`
+	AutoTune AutoTuneConfig `json:"auto_tune" yaml:"auto_tune"`
+}
+
+// NewBackpressureConfig returns a BackpressureConfig with default values.
+func NewBackpressureConfig() BackpressureConfig {
+	return BackpressureConfig{
+		Enabled:  false,
+		PeriodMS: 1000,
+		AutoTune: NewAutoTuneConfig(),
+	}
+}
+
+// AutoTuneConfig contains configuration fields that determine how a stream
+// adjusts the number of transactions in flight and the batch count at the
+// output layer according to how long transactions wait for the output.
+type AutoTuneConfig struct {
+	Enabled       bool `json:"enabled" yaml:"enabled"`
+	TargetWaitMS  int  `json:"target_wait_ms" yaml:"target_wait_ms"`
+	MinInFlight   int  `json:"min_in_flight" yaml:"min_in_flight"`
+	MinBatchCount int  `json:"min_batch_count" yaml:"min_batch_count"`
+}
+
+// NewAutoTuneConfig returns an AutoTuneConfig with default values.
+func NewAutoTuneConfig() AutoTuneConfig {
+	return AutoTuneConfig{
+		Enabled:       false,
+		TargetWaitMS:  10,
+		MinInFlight:   1,
+		MinBatchCount: 1,
+	}
+}
+
+//------------------------------------------------------------------------------
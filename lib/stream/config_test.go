@@ -60,10 +60,34 @@ func TestConfigSanitised(t *testing.T) {
 	{
 	}
 	exp = `{` +
-		`"input":{"type":"file","file":{"delimiter":"","max_buffer":1000000,"multipart":false,"path":""}},` +
+		`"input":{"type":"file","file":{"checkpoint_path":"","delimiter":"","max_buffer":1000000,"multipart":false,"path":"","poll_interval_ms":1000,"tail":false}},` +
 		`"buffer":{"type":"none","none":{}},` +
 		`"pipeline":{"processors":[],"threads":1},` +
-		`"output":{"type":"kafka","kafka":{"ack_replicas":false,"addresses":["localhost:9092"],"client_id":"benthos_kafka_output","compression":"none","key":"","max_msg_bytes":1000000,"round_robin_partitions":false,"target_version":"0.8.2.0","timeout_ms":5000,"topic":"benthos_stream"}}` +
+		`"output":{"type":"kafka","kafka":{"ack_replicas":false,"addresses":["localhost:9092"],"client_id":"benthos_kafka_output","compression":"none","idempotent_write":false,"key":"","max_in_flight":5,"max_msg_bytes":1000000,"partition":"","partitioner":"hash","round_robin_partitions":false,"target_version":"0.8.2.0","timeout_ms":5000,"topic":"benthos_stream"}}` +
+		`}`
+
+	if dat, err = c.Sanitised(); err != nil {
+		t.Fatal(err)
+	}
+	if actBytes, err = json.Marshal(dat); err != nil {
+		t.Fatal(err)
+	}
+	if act := string(actBytes); exp != act {
+		t.Errorf("Wrong sanitised output: %v != %v", act, exp)
+	}
+
+	c = NewConfig()
+	c.Input.Processors = nil
+	c.Output.Processors = nil
+	sizeConf := NewMessageSizeConfig()
+	c.MessageSize = &sizeConf
+
+	exp = `{` +
+		`"input":{"type":"stdin","stdin":{"delimiter":"","max_buffer":1000000,"multipart":false}},` +
+		`"buffer":{"type":"none","none":{}},` +
+		`"pipeline":{"processors":[],"threads":1},` +
+		`"output":{"type":"stdout","stdout":{"delimiter":""}},` +
+		`"message_size":{"max_bytes":1048576,"policy":"reject"}` +
 		`}`
 
 	if dat, err = c.Sanitised(); err != nil {
@@ -0,0 +1,237 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	"github.com/Jeffail/benthos/lib/util/text"
+)
+
+//------------------------------------------------------------------------------
+
+// deduper is an output layer that suppresses messages that have already been
+// delivered. Each message part is given a key, which by default is composed of
+// the source ID and offset attached to its metadata by the input, and parts
+// where the key is already present within a cache are removed before the
+// message is sent. Messages where all parts are removed are acknowledged
+// without being sent.
+//
+// Keys are only recorded once the output has successfully sent the message,
+// and therefore a crash may still result in a duplicate but never in a message
+// being lost.
+type deduper struct {
+	running int32
+
+	output output.Type
+	cache  types.Cache
+	key    []byte
+
+	log   log.Modular
+	stats metrics.Type
+
+	transactions <-chan types.Transaction
+	outChan      chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newDeduper(
+	out output.Type,
+	cache types.Cache,
+	key string,
+	log log.Modular,
+	stats metrics.Type,
+) *deduper {
+	return &deduper{
+		running:    1,
+		output:     out,
+		cache:      cache,
+		key:        []byte(key),
+		log:        log.NewModule(".dedupe"),
+		stats:      stats,
+		outChan:    make(chan types.Transaction),
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// partKey returns the key of a message part, which is empty when the part
+// cannot be deduplicated.
+func (d *deduper) partKey(msg types.Message, index int) string {
+	if len(d.key) == 0 {
+		meta := msg.GetMetadata(index)
+		source, offset := meta.Get(types.SourceIDKey), meta.Get(types.SourceOffsetKey)
+		if len(source) == 0 || len(offset) == 0 {
+			return ""
+		}
+		return source + "-" + offset
+	}
+	if msg.Len() > 1 {
+		part := types.NewMessage([][]byte{msg.Get(index)})
+		part.SetMetadata(msg.GetMetadata(index), 0)
+		msg = part
+	}
+	return string(text.ReplaceFunctionVariablesFor(msg, d.key))
+}
+
+func (d *deduper) loop() {
+	defer func() {
+		close(d.outChan)
+		err := d.output.WaitForClose(time.Second)
+		for ; err != nil; err = d.output.WaitForClose(time.Second) {
+		}
+		close(d.closedChan)
+	}()
+
+	var (
+		mDuplicate = d.stats.GetCounter("dedupe.duplicate")
+		mErrCache  = d.stats.GetCounter("dedupe.error.cache")
+	)
+
+	for atomic.LoadInt32(&d.running) == 1 {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-d.transactions:
+			if !open {
+				return
+			}
+		case <-d.closeChan:
+			return
+		}
+
+		var keys []string
+		var newIndexes []int
+		for i := 0; i < ts.Payload.Len(); i++ {
+			key := d.partKey(ts.Payload, i)
+			if len(key) > 0 {
+				if _, err := d.cache.Get(key); err == nil {
+					mDuplicate.Incr(1)
+					d.log.Debugf("Suppressing duplicate message part with key: %v\n", key)
+					continue
+				} else if err != types.ErrKeyNotFound {
+					mErrCache.Incr(1)
+					d.log.Errorf("Failed to check key '%v' in cache: %v\n", key, err)
+				}
+			}
+			keys = append(keys, key)
+			newIndexes = append(newIndexes, i)
+		}
+
+		msg := ts.Payload
+		if len(newIndexes) == 0 && msg.Len() > 0 {
+			select {
+			case ts.ResponseChan <- types.NewSimpleResponse(nil):
+			case <-d.closeChan:
+				return
+			}
+			continue
+		} else if len(newIndexes) < msg.Len() {
+			msg = subsetMessage(msg, newIndexes)
+		}
+
+		resChan := make(chan types.Response)
+		select {
+		case d.outChan <- types.NewTransaction(msg, resChan):
+		case <-d.closeChan:
+			return
+		}
+		go d.resolve(keys, ts.ResponseChan, resChan, mErrCache)
+	}
+}
+
+// resolve waits for the response of the output to a message and records the
+// keys of its parts when it was successfully sent.
+func (d *deduper) resolve(
+	keys []string,
+	resChanOut chan<- types.Response,
+	resChanIn <-chan types.Response,
+	mErrCache metrics.StatCounter,
+) {
+	var res types.Response
+	select {
+	case res = <-resChanIn:
+	case <-d.closeChan:
+		return
+	}
+	if res.Error() == nil {
+		for _, key := range keys {
+			if len(key) == 0 {
+				continue
+			}
+			if err := d.cache.Set(key, []byte("t")); err != nil {
+				mErrCache.Incr(1)
+				d.log.Errorf("Failed to record key '%v' in cache: %v\n", key, err)
+			}
+		}
+	}
+	select {
+	case resChanOut <- res:
+	case <-d.closeChan:
+	}
+}
+
+// unwrap returns the output wrapped by the deduper.
+func (d *deduper) unwrap() output.Type {
+	return d.output
+}
+
+// StartReceiving assigns a new transactions channel for the deduper to read.
+func (d *deduper) StartReceiving(ts <-chan types.Transaction) error {
+	if d.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := d.output.StartReceiving(d.outChan); err != nil {
+		return err
+	}
+	d.transactions = ts
+	go d.loop()
+	return nil
+}
+
+// CloseAsync shuts down the deduper and its output.
+func (d *deduper) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&d.running, 1, 0) {
+		d.output.CloseAsync()
+		close(d.closeChan)
+	}
+}
+
+// WaitForClose blocks until the deduper and its output have closed.
+func (d *deduper) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-d.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
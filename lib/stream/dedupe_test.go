@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/cache"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestDeduper(t *testing.T) {
+	c, err := cache.NewMemory(cache.NewConfig(), types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &mockOutput{}
+	d := newDeduper(out, c, "${!metadata:source}-${!metadata:offset}", testLog, metrics.DudType{})
+
+	tChan := make(chan types.Transaction)
+	if err = d.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	newMsg := func(source, offset string) types.Message {
+		msg := types.NewMessage([][]byte{[]byte("foo")})
+		if len(source) > 0 {
+			msg.GetMetadata(0).Set("source", source).Set("offset", offset)
+		}
+		return msg
+	}
+
+	errFailed := errors.New("failed")
+	for i, test := range []struct {
+		msg    types.Message
+		sent   bool
+		resErr error
+	}{
+		{msg: newMsg("a", "1"), sent: true},
+		{msg: newMsg("a", "1"), sent: false},
+		{msg: newMsg("a", "2"), sent: true},
+		{msg: newMsg("b", "1"), sent: true, resErr: errFailed},
+		{msg: newMsg("b", "1"), sent: true},
+		{msg: newMsg("b", "1"), sent: false},
+	} {
+		resChan := make(chan types.Response)
+		select {
+		case tChan <- types.NewTransaction(test.msg, resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		if test.sent {
+			select {
+			case tran := <-out.ts:
+				if tran.Payload != test.msg {
+					t.Errorf("Wrong message sent: %v", i)
+				}
+				tran.ResponseChan <- types.NewSimpleResponse(test.resErr)
+			case <-time.After(time.Second):
+				t.Fatalf("timed out: %v", i)
+			}
+		}
+
+		select {
+		case res := <-resChan:
+			if exp, act := test.resErr, res.Error(); exp != act {
+				t.Errorf("Wrong response: %v: %v != %v", i, act, exp)
+			}
+		case <-out.ts:
+			t.Fatalf("Duplicate message was sent: %v", i)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out: %v", i)
+		}
+	}
+
+	close(tChan)
+	if err = d.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeduperParts(t *testing.T) {
+	c, err := cache.NewMemory(cache.NewConfig(), types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &mockOutput{}
+	d := newDeduper(out, c, "", testLog, metrics.DudType{})
+
+	tChan := make(chan types.Transaction)
+	if err = d.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	newMsg := func(offsets ...string) types.Message {
+		msg := types.NewMessage(nil)
+		for i, offset := range offsets {
+			msg.Append([]byte(offset))
+			if len(offset) > 0 {
+				msg.GetMetadata(i).
+					Set(types.SourceIDKey, "foo").
+					Set(types.SourceOffsetKey, offset)
+			}
+		}
+		return msg
+	}
+
+	for i, test := range []struct {
+		msg  types.Message
+		sent []string
+	}{
+		{msg: newMsg("1", "2"), sent: []string{"1", "2"}},
+		{msg: newMsg("2", "3", ""), sent: []string{"3", ""}},
+		{msg: newMsg("1", "3"), sent: nil},
+		{msg: newMsg("", ""), sent: []string{"", ""}},
+	} {
+		resChan := make(chan types.Response)
+		select {
+		case tChan <- types.NewTransaction(test.msg, resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		if len(test.sent) > 0 {
+			select {
+			case tran := <-out.ts:
+				var act []string
+				for j, part := range tran.Payload.GetAll() {
+					act = append(act, string(part))
+					if exp, actOffset := string(part), tran.Payload.GetMetadata(j).Get(types.SourceOffsetKey); exp != actOffset {
+						t.Errorf("Wrong metadata for part: %v: %v != %v", i, actOffset, exp)
+					}
+				}
+				if !reflect.DeepEqual(test.sent, act) {
+					t.Errorf("Wrong parts sent: %v: %s != %s", i, act, test.sent)
+				}
+				tran.ResponseChan <- types.NewSimpleResponse(nil)
+			case <-time.After(time.Second):
+				t.Fatalf("timed out: %v", i)
+			}
+		}
+
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Errorf("Unexpected response error: %v: %v", i, res.Error())
+			}
+		case <-out.ts:
+			t.Fatalf("Duplicate message was sent: %v", i)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out: %v", i)
+		}
+	}
+
+	close(tChan)
+	if err = d.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
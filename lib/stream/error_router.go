@@ -0,0 +1,224 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// errorRouter is an output layer that sends message parts flagged as having
+// failed processing to a dedicated error output, and all other parts to the
+// main output of the stream. Messages where only some parts are flagged are
+// split, and the responses of both outputs are merged into a single response.
+type errorRouter struct {
+	running int32
+
+	output    output.Type
+	errOutput output.Type
+
+	transactions <-chan types.Transaction
+	outChan      chan types.Transaction
+	errChan      chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+func newErrorRouter(out, errOut output.Type) *errorRouter {
+	return &errorRouter{
+		running:    1,
+		output:     out,
+		errOutput:  errOut,
+		outChan:    make(chan types.Transaction),
+		errChan:    make(chan types.Transaction),
+		closeChan:  make(chan struct{}),
+		closedChan: make(chan struct{}),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func (e *errorRouter) loop() {
+	defer func() {
+		close(e.outChan)
+		close(e.errChan)
+		for _, o := range []output.Type{e.output, e.errOutput} {
+			err := o.WaitForClose(time.Second)
+			for ; err != nil; err = o.WaitForClose(time.Second) {
+			}
+		}
+		close(e.closedChan)
+	}()
+
+	for atomic.LoadInt32(&e.running) == 1 {
+		var ts types.Transaction
+		var open bool
+		select {
+		case ts, open = <-e.transactions:
+			if !open {
+				return
+			}
+		case <-e.closeChan:
+			return
+		}
+
+		good, failed := splitFailed(ts.Payload)
+		if failed == nil || good == nil {
+			target := e.outChan
+			if failed != nil {
+				target = e.errChan
+			}
+			select {
+			case target <- ts:
+			case <-e.closeChan:
+				return
+			}
+			continue
+		}
+
+		outResChan, errResChan := make(chan types.Response), make(chan types.Response)
+		select {
+		case e.outChan <- types.NewTransaction(good, outResChan):
+		case <-e.closeChan:
+			return
+		}
+		select {
+		case e.errChan <- types.NewTransaction(failed, errResChan):
+		case <-e.closeChan:
+			return
+		}
+		go e.mergeResponses(ts.ResponseChan, outResChan, errResChan)
+	}
+}
+
+// mergeResponses waits for the responses of both halves of a split message, in
+// any order, and sends a single response upstream which carries the first error
+// encountered.
+func (e *errorRouter) mergeResponses(resChan chan<- types.Response, outResChan, errResChan <-chan types.Response) {
+	var res types.Response = types.NewSimpleResponse(nil)
+	for outResChan != nil || errResChan != nil {
+		var r types.Response
+		var open bool
+		select {
+		case r, open = <-outResChan:
+			outResChan = nil
+		case r, open = <-errResChan:
+			errResChan = nil
+		case <-e.closeChan:
+			return
+		}
+		if !open {
+			return
+		}
+		if r.Error() != nil && res.Error() == nil {
+			res = r
+		}
+	}
+	select {
+	case resChan <- res:
+	case <-e.closeChan:
+	}
+}
+
+// splitFailed separates the parts of a message that are flagged as having
+// failed processing from those that are not, where the metadata of each part
+// is kept. Either result is nil when it would contain no parts, and when only
+// one result is non-nil it is the original message.
+func splitFailed(msg types.Message) (good, failed types.Message) {
+	var goodIndexes, failedIndexes []int
+	for i := 0; i < msg.Len(); i++ {
+		if len(msg.GetMetadata(i).Get(processor.FailFlagKey)) > 0 {
+			failedIndexes = append(failedIndexes, i)
+		} else {
+			goodIndexes = append(goodIndexes, i)
+		}
+	}
+	if len(failedIndexes) == 0 {
+		return msg, nil
+	}
+	if len(goodIndexes) == 0 {
+		return nil, msg
+	}
+	return subsetMessage(msg, goodIndexes), subsetMessage(msg, failedIndexes)
+}
+
+// subsetMessage returns a new message containing the parts of a message at the
+// given indexes along with their metadata.
+func subsetMessage(msg types.Message, indexes []int) types.Message {
+	parts := make([][]byte, len(indexes))
+	for i, index := range indexes {
+		parts[i] = msg.Get(index)
+	}
+	newMsg := types.NewMessage(parts)
+	for i, index := range indexes {
+		newMsg.SetMetadata(msg.GetMetadata(index).Copy(), i)
+	}
+	return newMsg
+}
+
+// unwrap returns the output wrapped by the error router.
+func (e *errorRouter) unwrap() output.Type {
+	return e.output
+}
+
+// StartReceiving assigns a new transactions channel for the router to read.
+func (e *errorRouter) StartReceiving(ts <-chan types.Transaction) error {
+	if e.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	if err := e.output.StartReceiving(e.outChan); err != nil {
+		return err
+	}
+	if err := e.errOutput.StartReceiving(e.errChan); err != nil {
+		return err
+	}
+	e.transactions = ts
+	go e.loop()
+	return nil
+}
+
+// CloseAsync shuts down the router and both of its outputs.
+func (e *errorRouter) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&e.running, 1, 0) {
+		e.output.CloseAsync()
+		e.errOutput.CloseAsync()
+		close(e.closeChan)
+	}
+}
+
+// WaitForClose blocks until the router and both of its outputs have closed.
+func (e *errorRouter) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-e.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+type mockOutput struct {
+	ts <-chan types.Transaction
+}
+
+func (m *mockOutput) StartReceiving(ts <-chan types.Transaction) error {
+	m.ts = ts
+	return nil
+}
+
+func (m *mockOutput) CloseAsync() {
+}
+
+func (m *mockOutput) WaitForClose(time.Duration) error {
+	if _, open := <-m.ts; open {
+		return errors.New("transaction chan still open")
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func TestErrorRouter(t *testing.T) {
+	out, errOut := &mockOutput{}, &mockOutput{}
+	r := newErrorRouter(out, errOut)
+
+	tChan := make(chan types.Transaction)
+	if err := r.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	goodMsg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	badMsg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	processor.FlagFail(badMsg, 0, errors.New("nope"))
+	processor.FlagFail(badMsg, 1, errors.New("nope"))
+
+	for _, test := range []struct {
+		msg    types.Message
+		target *mockOutput
+	}{
+		{msg: goodMsg, target: out},
+		{msg: badMsg, target: errOut},
+		{msg: goodMsg, target: out},
+	} {
+		select {
+		case tChan <- types.NewTransaction(test.msg, nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case tran := <-test.target.ts:
+			if tran.Payload != test.msg {
+				t.Error("Wrong message routed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	if exp, act := "nope", badMsg.GetMetadata(1).Get(processor.FailFlagKey); exp != act {
+		t.Errorf("Wrong error context: %v != %v", act, exp)
+	}
+
+	close(tChan)
+	if err := r.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestErrorRouterSplit(t *testing.T) {
+	out, errOut := &mockOutput{}, &mockOutput{}
+	r := newErrorRouter(out, errOut)
+
+	tChan := make(chan types.Transaction)
+	if err := r.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		outErr error
+		errErr error
+		expErr error
+	}{
+		{outErr: nil, errErr: nil, expErr: nil},
+		{outErr: nil, errErr: errors.New("err output"), expErr: errors.New("err output")},
+		{outErr: errors.New("main output"), errErr: nil, expErr: errors.New("main output")},
+	} {
+		msg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+		msg.GetMetadata(0).Set("key", "first")
+		processor.FlagFail(msg, 1, errors.New("nope"))
+
+		resChan := make(chan types.Response)
+		select {
+		case tChan <- types.NewTransaction(msg, resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		var outTran, errTran types.Transaction
+		select {
+		case outTran = <-out.ts:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case errTran = <-errOut.ts:
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+
+		if exp, act := []string{"foo", "baz"}, messageStrings(outTran.Payload); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong parts routed to output: %v != %v", act, exp)
+		}
+		if exp, act := "first", outTran.Payload.GetMetadata(0).Get("key"); exp != act {
+			t.Errorf("Metadata not kept: %v != %v", act, exp)
+		}
+		if exp, act := []string{"bar"}, messageStrings(errTran.Payload); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong parts routed to error output: %v != %v", act, exp)
+		}
+		if exp, act := "nope", errTran.Payload.GetMetadata(0).Get(processor.FailFlagKey); exp != act {
+			t.Errorf("Wrong error context: %v != %v", act, exp)
+		}
+
+		select {
+		case errTran.ResponseChan <- types.NewSimpleResponse(test.errErr):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case outTran.ResponseChan <- types.NewSimpleResponse(test.outErr):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case res := <-resChan:
+			if !reflect.DeepEqual(test.expErr, res.Error()) {
+				t.Errorf("Wrong merged response: %v != %v", res.Error(), test.expErr)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	close(tChan)
+	if err := r.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func messageStrings(msg types.Message) []string {
+	strs := []string{}
+	for _, p := range msg.GetAll() {
+		strs = append(strs, string(p))
+	}
+	return strs
+}
+
+//------------------------------------------------------------------------------
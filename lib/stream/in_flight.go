@@ -0,0 +1,268 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+	uuid "github.com/satori/go.uuid"
+)
+
+//------------------------------------------------------------------------------
+
+// inFlight sits between the input layer of a stream and the layers that follow
+// it, keeping track of transactions that have been read from the input but not
+// yet resolved. This allows a stream to stop reading new messages during
+// shutdown whilst still waiting for, and optionally nacking, the transactions
+// that are in flight.
+//
+// When an ack timeout is set any transaction that is not resolved within that
+// period is nacked, which prevents a wedged output from holding messages
+// indefinitely.
+//
+// When a correlation key is set each transaction is tagged with a correlation
+// ID in the metadata of its parts, which is adopted from the parts themselves
+// when already present.
+type inFlight struct {
+	transactionsOut chan types.Transaction
+
+	ackTimeout     time.Duration
+	correlationKey string
+
+	log         log.Modular
+	mAckTimeout metrics.StatCounter
+
+	pending      sync.WaitGroup
+	pendingCount int64
+
+	stopChan   chan struct{}
+	nackChan   chan struct{}
+	closeChan  chan struct{}
+	closedChan chan struct{}
+
+	stopOnce  sync.Once
+	nackOnce  sync.Once
+	closeOnce sync.Once
+}
+
+func newInFlight(
+	ackTimeout time.Duration,
+	correlationKey string,
+	log log.Modular,
+	stats metrics.Type,
+) *inFlight {
+	return &inFlight{
+		transactionsOut: make(chan types.Transaction),
+		ackTimeout:      ackTimeout,
+		correlationKey:  correlationKey,
+		log:             log,
+		mAckTimeout:     stats.GetCounter("in_flight.ack_timeout"),
+		stopChan:        make(chan struct{}),
+		nackChan:        make(chan struct{}),
+		closeChan:       make(chan struct{}),
+		closedChan:      make(chan struct{}),
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// correlate tags each part of a message that lacks a correlation ID with the ID
+// of the first part that has one, or a newly generated ID if none do, and
+// returns the ID.
+func correlate(msg types.Message, key string) string {
+	var id string
+	for i := 0; i < msg.Len() && len(id) == 0; i++ {
+		id = msg.GetMetadata(i).Get(key)
+	}
+	if len(id) == 0 {
+		id = uuid.NewV4().String()
+	}
+	for i := 0; i < msg.Len(); i++ {
+		if md := msg.GetMetadata(i); len(md.Get(key)) == 0 {
+			md.Set(key, id)
+		}
+	}
+	return id
+}
+
+//------------------------------------------------------------------------------
+
+func (f *inFlight) loop(transactionsIn <-chan types.Transaction) {
+	defer func() {
+		close(f.transactionsOut)
+		close(f.closedChan)
+	}()
+
+	for {
+		var tran types.Transaction
+		var open bool
+
+		select {
+		case tran, open = <-transactionsIn:
+			if !open {
+				return
+			}
+		case <-f.stopChan:
+			return
+		}
+
+		// The response channel is buffered so that downstream layers are never
+		// blocked by a transaction that has already been resolved.
+		resChan := make(chan types.Response, 1)
+
+		var id string
+		if len(f.correlationKey) > 0 {
+			id = correlate(tran.Payload, f.correlationKey)
+		}
+
+		select {
+		case f.transactionsOut <- types.NewTransaction(tran.Payload, resChan):
+		case <-f.stopChan:
+			// The transaction was read but never forwarded, and is therefore
+			// rejected so that the input can redeliver it.
+			select {
+			case tran.ResponseChan <- types.NewSimpleResponse(types.ErrTypeClosed):
+			case <-f.closeChan:
+			}
+			return
+		}
+
+		f.pending.Add(1)
+		atomic.AddInt64(&f.pendingCount, 1)
+		go f.resolve(id, tran.ResponseChan, resChan)
+	}
+}
+
+func (f *inFlight) resolve(id string, resChanOut chan<- types.Response, resChanIn <-chan types.Response) {
+	defer func() {
+		atomic.AddInt64(&f.pendingCount, -1)
+		f.pending.Done()
+	}()
+
+	var timeoutChan <-chan time.Time
+	if f.ackTimeout > 0 {
+		timer := time.NewTimer(f.ackTimeout)
+		defer timer.Stop()
+		timeoutChan = timer.C
+	}
+
+	var res types.Response
+	select {
+	case res = <-resChanIn:
+		if err := res.Error(); err != nil && len(id) > 0 {
+			f.log.Debugf("Transaction %v was rejected: %v\n", id, err)
+		}
+	case <-timeoutChan:
+		f.mAckTimeout.Incr(1)
+		if len(id) > 0 {
+			f.log.Warnf("Transaction %v timed out awaiting acknowledgement\n", id)
+		} else {
+			f.log.Warnln("Transaction timed out awaiting acknowledgement")
+		}
+		res = types.NewSimpleResponse(types.ErrTimeout)
+	case <-f.nackChan:
+		res = types.NewSimpleResponse(types.ErrTypeClosed)
+	case <-f.closeChan:
+		return
+	}
+
+	select {
+	case resChanOut <- res:
+	case <-f.closeChan:
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// StartReceiving begins reading transactions from the input layer.
+func (f *inFlight) StartReceiving(transactions <-chan types.Transaction) {
+	go f.loop(transactions)
+}
+
+// TransactionChan returns the channel used for consuming tracked transactions.
+func (f *inFlight) TransactionChan() <-chan types.Transaction {
+	return f.transactionsOut
+}
+
+// StopReading prevents any further transactions from being read from the input
+// layer and closes the outgoing transaction channel, which prompts the
+// following layers to shut down once they are drained.
+func (f *inFlight) StopReading() {
+	f.stopOnce.Do(func() {
+		close(f.stopChan)
+	})
+}
+
+// WaitForPending blocks until reading has stopped and all transactions that
+// were read are resolved, or the timeout occurs.
+func (f *inFlight) WaitForPending(timeout time.Duration) error {
+	doneChan := make(chan struct{})
+	go func() {
+		<-f.closedChan
+		f.pending.Wait()
+		close(doneChan)
+	}()
+	select {
+	case <-doneChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+// PendingCount returns the number of transactions that were read from the input
+// layer and are yet to be resolved.
+func (f *inFlight) PendingCount() int {
+	return int(atomic.LoadInt64(&f.pendingCount))
+}
+
+// NackPending resolves all pending transactions with an error, which causes the
+// input to negatively acknowledge them where supported.
+func (f *inFlight) NackPending() {
+	f.nackOnce.Do(func() {
+		close(f.nackChan)
+	})
+}
+
+// CloseAsync abandons any transactions that are still pending and shuts down.
+func (f *inFlight) CloseAsync() {
+	f.StopReading()
+	f.closeOnce.Do(func() {
+		close(f.closeChan)
+	})
+}
+
+// WaitForClose blocks until the tracker has stopped reading transactions.
+func (f *inFlight) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-f.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,301 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+var testLog = log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+//------------------------------------------------------------------------------
+
+func TestInFlightPropagation(t *testing.T) {
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	f := newInFlight(0, "", testLog, metrics.DudType{})
+	f.StartReceiving(tChan)
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var tran types.Transaction
+	select {
+	case tran = <-f.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+		t.Errorf("Wrong message: %v != %v", act, exp)
+	}
+
+	if err := f.WaitForPending(time.Millisecond * 50); err != types.ErrTimeout {
+		t.Errorf("Wrong error returned: %v != %v", err, types.ErrTimeout)
+	}
+
+	f.StopReading()
+	tran.ResponseChan <- types.NewSimpleResponse(nil)
+
+	select {
+	case res := <-resChan:
+		if res.Error() != nil {
+			t.Error(res.Error())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err := f.WaitForPending(time.Second); err != nil {
+		t.Error(err)
+	}
+	if _, open := <-f.TransactionChan(); open {
+		t.Error("Transaction chan not closed")
+	}
+
+	f.CloseAsync()
+	if err := f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInFlightNack(t *testing.T) {
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	f := newInFlight(0, "", testLog, metrics.DudType{})
+	f.StartReceiving(tChan)
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var tran types.Transaction
+	select {
+	case tran = <-f.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	f.StopReading()
+	f.NackPending()
+
+	select {
+	case res := <-resChan:
+		if exp, act := types.ErrTypeClosed, res.Error(); exp != act {
+			t.Errorf("Wrong error returned: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// A late response must not block the downstream layer.
+	select {
+	case tran.ResponseChan <- types.NewSimpleResponse(nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err := f.WaitForPending(time.Second); err != nil {
+		t.Error(err)
+	}
+
+	f.CloseAsync()
+	if err := f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInFlightAckTimeout(t *testing.T) {
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	f := newInFlight(time.Millisecond*10, "", testLog, metrics.DudType{})
+	f.StartReceiving(tChan)
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	var tran types.Transaction
+	select {
+	case tran = <-f.TransactionChan():
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case res := <-resChan:
+		if exp, act := types.ErrTimeout, res.Error(); exp != act {
+			t.Errorf("Wrong error returned: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// A late response must not block the downstream layer.
+	select {
+	case tran.ResponseChan <- types.NewSimpleResponse(nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	f.CloseAsync()
+	if err := f.WaitForPending(time.Second); err != nil {
+		t.Error(err)
+	}
+	if err := f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInFlightCorrelation(t *testing.T) {
+	tChan := make(chan types.Transaction)
+
+	f := newInFlight(0, "corr_id", testLog, metrics.DudType{})
+	f.StartReceiving(tChan)
+
+	adoptMsg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar"), []byte("baz")})
+	adoptMsg.GetMetadata(1).Set("corr_id", "first")
+	adoptMsg.GetMetadata(2).Set("corr_id", "second")
+
+	newMsgA := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	newMsgB := types.NewMessage([][]byte{[]byte("foo")})
+
+	var ids []string
+	for _, msg := range []types.Message{adoptMsg, newMsgA, newMsgB} {
+		select {
+		case tChan <- types.NewTransaction(msg, nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case tran := <-f.TransactionChan():
+			ids = append(ids, tran.Payload.GetMetadata(0).Get("corr_id"))
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	for i, exp := range []string{"first", "first", "second"} {
+		if act := adoptMsg.GetMetadata(i).Get("corr_id"); exp != act {
+			t.Errorf("Wrong correlation ID of part %v: %v != %v", i, act, exp)
+		}
+	}
+	if exp, act := ids[1], newMsgA.GetMetadata(1).Get("corr_id"); exp != act {
+		t.Errorf("Mismatched correlation IDs: %v != %v", act, exp)
+	}
+	if len(ids[1]) == 0 || len(ids[2]) == 0 {
+		t.Error("Expected correlation IDs to be generated")
+	}
+	if ids[1] == ids[2] {
+		t.Errorf("Expected unique correlation IDs: %v == %v", ids[1], ids[2])
+	}
+
+	f.CloseAsync()
+	if err := f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInFlightStopWhileBlocked(t *testing.T) {
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	f := newInFlight(0, "", testLog, metrics.DudType{})
+	f.StartReceiving(tChan)
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	// The output never reads the transaction, and therefore stopping must
+	// reject it rather than leave it pending.
+	f.StopReading()
+
+	select {
+	case res := <-resChan:
+		if exp, act := types.ErrTypeClosed, res.Error(); exp != act {
+			t.Errorf("Wrong error returned: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err := f.WaitForPending(time.Second); err != nil {
+		t.Error(err)
+	}
+	if exp, act := 0, f.PendingCount(); exp != act {
+		t.Errorf("Wrong pending count: %v != %v", act, exp)
+	}
+
+	f.CloseAsync()
+	if err := f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInFlightAbandon(t *testing.T) {
+	tChan := make(chan types.Transaction)
+	resChan := make(chan types.Response)
+
+	f := newInFlight(0, "", testLog, metrics.DudType{})
+	f.StartReceiving(tChan)
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	f.CloseAsync()
+	if err := f.WaitForPending(time.Second); err != nil {
+		t.Error(err)
+	}
+	if err := f.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+
+	select {
+	case <-resChan:
+		t.Error("Unexpected response")
+	default:
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -21,6 +21,8 @@
 package manager
 
 import (
+	"encoding/json"
+
 	"github.com/Jeffail/benthos/lib/stream"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -31,6 +33,25 @@ import (
 // parsed without losing default values inside the stream configs.
 type ConfigSet map[string]stream.Config
 
+// UnmarshalJSON ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (c ConfigSet) UnmarshalJSON(bytes []byte) error {
+	tmpSet := map[string]json.RawMessage{}
+	if err := json.Unmarshal(bytes, &tmpSet); err != nil {
+		return err
+	}
+
+	for k, v := range tmpSet {
+		conf := stream.NewConfig()
+		if err := json.Unmarshal(v, &conf); err != nil {
+			return err
+		}
+		c[k] = conf
+	}
+
+	return nil
+}
+
 // UnmarshalYAML ensures that when parsing configs that are in a map or slice
 // the default values are still applied.
 func (c ConfigSet) UnmarshalYAML(unmarshal func(interface{}) error) error {
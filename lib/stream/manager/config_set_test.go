@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package manager
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/stream"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfigSetDefaults(t *testing.T) {
+	fooConf := stream.NewConfig()
+	fooConf.Input.Type = "inproc"
+	fooConf.Input.Inproc = "bar"
+
+	barConf := stream.NewConfig()
+	barConf.Output.Type = "inproc"
+	barConf.Output.Inproc = "bar"
+
+	expSet := ConfigSet{
+		"foo": fooConf,
+		"bar": barConf,
+	}
+
+	yamlSet := ConfigSet{}
+	if err := yaml.Unmarshal([]byte(`
+foo:
+  input:
+    type: inproc
+    inproc: bar
+bar:
+  output:
+    type: inproc
+    inproc: bar
+`), &yamlSet); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expSet, yamlSet) {
+		t.Errorf("Wrong YAML config set: %v != %v", yamlSet, expSet)
+	}
+
+	jsonSet := ConfigSet{}
+	if err := json.Unmarshal([]byte(`{
+	"foo": {"input": {"type": "inproc", "inproc": "bar"}},
+	"bar": {"output": {"type": "inproc", "inproc": "bar"}}
+}`), &jsonSet); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expSet, jsonSet) {
+		t.Errorf("Wrong JSON config set: %v != %v", jsonSet, expSet)
+	}
+}
@@ -108,6 +108,26 @@ func (n *nsMgr) GetCondition(name string) (types.Condition, error) {
 	return n.mgr.GetCondition(name)
 }
 
+// GetRateLimit attempts to find a service wide rate limit by its name.
+func (n *nsMgr) GetRateLimit(name string) (types.RateLimit, error) {
+	return n.mgr.GetRateLimit(name)
+}
+
+// GetPipe attempts to find a service wide transaction chan by its name.
+func (n *nsMgr) GetPipe(name string) (<-chan types.Transaction, error) {
+	return n.mgr.GetPipe(name)
+}
+
+// SetPipe registers a transaction chan under a name.
+func (n *nsMgr) SetPipe(name string, t <-chan types.Transaction) {
+	n.mgr.SetPipe(name, t)
+}
+
+// UnsetPipe removes a named transaction chan.
+func (n *nsMgr) UnsetPipe(name string, t <-chan types.Transaction) {
+	n.mgr.UnsetPipe(name, t)
+}
+
 //------------------------------------------------------------------------------
 
 // StreamProcConstructorFunc is a closure type that constructs a processor type
@@ -126,10 +146,14 @@ type Type struct {
 	closed  bool
 	streams map[string]*streamWrapper
 
-	manager    types.Manager
-	stats      metrics.Type
-	logger     log.Modular
-	apiTimeout time.Duration
+	manager      types.Manager
+	stats        metrics.Type
+	logger       log.Modular
+	apiTimeout   time.Duration
+	shutdown     stream.ShutdownConfig
+	ack          stream.AcknowledgementConfig
+	corr         stream.CorrelationConfig
+	backpressure stream.BackpressureConfig
 
 	inputPipeCtors    []StreamPipeConstructorFunc
 	pipelineProcCtors []StreamProcConstructorFunc
@@ -141,11 +165,15 @@ type Type struct {
 // New creates a new stream manager.Type.
 func New(opts ...func(*Type)) *Type {
 	t := &Type{
-		streams:    map[string]*streamWrapper{},
-		manager:    types.DudMgr{},
-		stats:      metrics.DudType{},
-		apiTimeout: time.Second * 5,
-		logger:     log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		streams:      map[string]*streamWrapper{},
+		manager:      types.DudMgr{},
+		stats:        metrics.DudType{},
+		apiTimeout:   time.Second * 5,
+		shutdown:     stream.NewShutdownConfig(),
+		ack:          stream.NewAcknowledgementConfig(),
+		corr:         stream.NewCorrelationConfig(),
+		backpressure: stream.NewBackpressureConfig(),
+		logger:       log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -187,6 +215,35 @@ func OptSetAPITimeout(tout time.Duration) func(*Type) {
 	}
 }
 
+// OptSetShutdown sets the shutdown behaviour of all child streams.
+func OptSetShutdown(conf stream.ShutdownConfig) func(*Type) {
+	return func(t *Type) {
+		t.shutdown = conf
+	}
+}
+
+// OptSetAcknowledgement sets the acknowledgement timeout of all child streams.
+func OptSetAcknowledgement(conf stream.AcknowledgementConfig) func(*Type) {
+	return func(t *Type) {
+		t.ack = conf
+	}
+}
+
+// OptSetCorrelation sets the correlation ID behaviour of all child streams.
+func OptSetCorrelation(conf stream.CorrelationConfig) func(*Type) {
+	return func(t *Type) {
+		t.corr = conf
+	}
+}
+
+// OptSetBackpressure sets the backpressure measurement and tuning behaviour of
+// all child streams.
+func OptSetBackpressure(conf stream.BackpressureConfig) func(*Type) {
+	return func(t *Type) {
+		t.backpressure = conf
+	}
+}
+
 // OptAddInputPipelines adds pipeline constructors that will be called for every
 // new stream and attached to the input component. The constructor is given the
 // name of the stream as an argument.
@@ -273,6 +330,10 @@ func (m *Type) Create(id string, conf stream.Config) error {
 		stream.OptSetLogger(m.logger.NewModule("."+id)),
 		stream.OptSetStats(metrics.Namespaced(m.stats, id)),
 		stream.OptSetManager(namespacedMgr(id, m.manager)),
+		stream.OptSetShutdown(m.shutdown),
+		stream.OptSetAcknowledgement(m.ack),
+		stream.OptSetCorrelation(m.corr),
+		stream.OptSetBackpressure(m.backpressure),
 		stream.OptOnClose(func() {
 			wrapper.SetClosed()
 		}),
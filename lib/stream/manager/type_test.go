@@ -21,11 +21,14 @@
 package manager
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/lib/manager"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/pipeline"
 	"github.com/Jeffail/benthos/lib/processor"
@@ -121,6 +124,65 @@ func TestTypeProcsAndPipes(t *testing.T) {
 	}
 }
 
+func TestTypeInprocStages(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_inproc_stages_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	logger := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	stats := metrics.DudType{}
+
+	resMgr, err := manager.New(manager.NewConfig(), types.DudMgr{}, logger, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := New(
+		OptSetLogger(logger),
+		OptSetStats(stats),
+		OptSetManager(resMgr),
+	)
+
+	first := harmlessConf()
+	first.Input.Type = "generate"
+	first.Input.Generate.Content = "hello world"
+	first.Input.Generate.Rate = 0
+	first.Input.Generate.Count = 3
+	first.Output.Type = "inproc"
+	first.Output.Inproc = "bridge"
+
+	outPath := filepath.Join(tmpDir, "out.txt")
+	second := harmlessConf()
+	second.Input.Type = "inproc"
+	second.Input.Inproc = "bridge"
+	second.Output.Type = "file"
+	second.Output.File.Path = outPath
+
+	if err = mgr.Create("second", second); err != nil {
+		t.Fatal(err)
+	}
+	if err = mgr.Create("first", first); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := "hello world\nhello world\nhello world\n"
+	var act string
+	for i := 0; i < 100 && act != exp; i++ {
+		<-time.After(time.Millisecond * 10)
+		resBytes, _ := ioutil.ReadFile(outPath)
+		act = string(resBytes)
+	}
+	if act != exp {
+		t.Errorf("Wrong output: %q != %q", act, exp)
+	}
+
+	if err = mgr.Stop(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestTypeBasicOperations(t *testing.T) {
 	mgr := New(
 		OptSetLogger(log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})),
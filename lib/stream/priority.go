@@ -0,0 +1,242 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// priorityScheduler sits in front of the output layer of a stream and assigns
+// each transaction to a priority lane according to the first condition that it
+// matches, transactions that match no condition are assigned to a final lane
+// of the lowest priority. Whenever the output is ready to accept a transaction
+// it is given the oldest transaction of the highest priority lane.
+//
+// Once maxPending transactions are queued the scheduler stops reading new ones
+// until the output has consumed some, and any that remain queued when the
+// scheduler is closed are rejected.
+type priorityScheduler struct {
+	running int32
+
+	conditions []condition.Type
+	maxPending int
+
+	log   log.Modular
+	stats metrics.Type
+
+	transactions    <-chan types.Transaction
+	transactionsOut chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// newPriorityScheduler creates a new priority scheduler layer.
+func newPriorityScheduler(
+	conf PriorityConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*priorityScheduler, error) {
+	if len(conf.Lanes) == 0 {
+		return nil, fmt.Errorf("priority requires at least one lane")
+	}
+	if conf.MaxPending <= 0 {
+		return nil, fmt.Errorf("priority max_pending must be greater than zero")
+	}
+	var conds []condition.Type
+	for i, cConf := range conf.Lanes {
+		ns := fmt.Sprintf("priority.lane.%v", i)
+		cond, err := condition.New(
+			cConf, mgr, log.NewModule("."+ns), metrics.Namespaced(stats, ns),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lane '%v' condition: %v", i, err)
+		}
+		conds = append(conds, cond)
+	}
+	return &priorityScheduler{
+		running:         1,
+		conditions:      conds,
+		maxPending:      conf.MaxPending,
+		log:             log.NewModule(".priority"),
+		stats:           stats,
+		transactionsOut: make(chan types.Transaction),
+		closeChan:       make(chan struct{}),
+		closedChan:      make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// laneOf returns the index of the lane that a message belongs to.
+func (p *priorityScheduler) laneOf(msg types.Message) int {
+	for i, cond := range p.conditions {
+		if cond.Check(msg) {
+			return i
+		}
+	}
+	return len(p.conditions)
+}
+
+// rejectPending responds to each transaction that is still queued with a
+// closed error so that its source can reattempt it, giving up on those that
+// are not being listened for within a second.
+func (p *priorityScheduler) rejectPending(lanes [][]types.Transaction) {
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+
+	timedOut := false
+	for _, lane := range lanes {
+		for _, tran := range lane {
+			res := types.NewSimpleResponse(types.ErrTypeClosed)
+			if timedOut {
+				select {
+				case tran.ResponseChan <- res:
+				default:
+				}
+				continue
+			}
+			select {
+			case tran.ResponseChan <- res:
+			case <-timer.C:
+				timedOut = true
+			}
+		}
+	}
+}
+
+func (p *priorityScheduler) loop() {
+	lanes := make([][]types.Transaction, len(p.conditions)+1)
+	defer func() {
+		p.rejectPending(lanes)
+		close(p.transactionsOut)
+		close(p.closedChan)
+	}()
+
+	mLaneCounts := make([]metrics.StatCounter, len(lanes))
+	for i := range lanes {
+		mLaneCounts[i] = p.stats.GetCounter(fmt.Sprintf("priority.lane.%v.count", i))
+	}
+
+	pending := 0
+	inChan := p.transactions
+	push := func(tran types.Transaction) {
+		i := p.laneOf(tran.Payload)
+		mLaneCounts[i].Incr(1)
+		lanes[i] = append(lanes[i], tran)
+		pending++
+	}
+
+	for atomic.LoadInt32(&p.running) == 1 {
+		// Stop reading transactions whilst the lanes are full.
+		readChan := inChan
+		if pending >= p.maxPending {
+			readChan = nil
+		}
+
+		// Take any transaction that is ready before scheduling, so that it has
+		// a chance to overtake those of lower priority.
+		select {
+		case tran, open := <-readChan:
+			if !open {
+				inChan = nil
+			} else {
+				push(tran)
+			}
+			continue
+		default:
+		}
+
+		var outChan chan<- types.Transaction
+		var next types.Transaction
+		lane := -1
+		for i := range lanes {
+			if len(lanes[i]) > 0 {
+				lane, next, outChan = i, lanes[i][0], p.transactionsOut
+				break
+			}
+		}
+		if lane == -1 && inChan == nil {
+			return
+		}
+
+		select {
+		case tran, open := <-readChan:
+			if !open {
+				inChan = nil
+			} else {
+				push(tran)
+			}
+		case outChan <- next:
+			lanes[lane][0] = types.Transaction{}
+			lanes[lane] = lanes[lane][1:]
+			pending--
+		case <-p.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a new transactions channel for the scheduler to read.
+func (p *priorityScheduler) StartReceiving(ts <-chan types.Transaction) error {
+	if p.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	p.transactions = ts
+	go p.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming scheduled
+// transactions.
+func (p *priorityScheduler) TransactionChan() <-chan types.Transaction {
+	return p.transactionsOut
+}
+
+// CloseAsync shuts down the scheduler, rejecting any transactions that are
+// waiting to be scheduled.
+func (p *priorityScheduler) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&p.running, 1, 0) {
+		close(p.closeChan)
+	}
+}
+
+// WaitForClose blocks until the scheduler has closed.
+func (p *priorityScheduler) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-p.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
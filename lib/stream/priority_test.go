@@ -0,0 +1,243 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestPriorityBadConfig(t *testing.T) {
+	conf := NewPriorityConfig()
+	if _, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from no lanes")
+	}
+
+	conf.Lanes = append(conf.Lanes, condition.NewConfig())
+	conf.MaxPending = 0
+	if _, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from zero max pending")
+	}
+	conf = NewPriorityConfig()
+
+	cConf := condition.NewConfig()
+	cConf.Type = "nope"
+	conf.Lanes = append(conf.Lanes, cConf)
+	if _, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad condition")
+	}
+}
+
+func TestPriorityScheduling(t *testing.T) {
+	conf := NewPriorityConfig()
+	for _, prefix := range []string{"high", "mid"} {
+		cConf := condition.NewConfig()
+		cConf.Type = "content"
+		cConf.Content.Operator = "prefix"
+		cConf.Content.Arg = prefix
+		conf.Lanes = append(conf.Lanes, cConf)
+	}
+
+	p, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = p.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	// Whilst nothing consumes from the scheduler all transactions are queued.
+	for _, content := range []string{"low 1", "mid 1", "low 2", "high 1", "mid 2"} {
+		select {
+		case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	for _, exp := range []string{"high 1", "mid 1", "mid 2", "low 1", "low 2"} {
+		select {
+		case tran := <-p.TransactionChan():
+			if act := string(tran.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong order: %v != %v", act, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	close(tChan)
+	if err = p.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+	if _, open := <-p.TransactionChan(); open {
+		t.Error("Transaction chan not closed")
+	}
+}
+
+func TestPriorityDrainOnClose(t *testing.T) {
+	conf := NewPriorityConfig()
+	conf.Lanes = append(conf.Lanes, condition.NewConfig())
+
+	p, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = p.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("foo")}), nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	close(tChan)
+
+	select {
+	case tran := <-p.TransactionChan():
+		if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if err = p.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPriorityMaxPending(t *testing.T) {
+	conf := NewPriorityConfig()
+	conf.Lanes = append(conf.Lanes, condition.NewConfig())
+	conf.MaxPending = 2
+
+	p, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = p.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{"foo", "bar"} {
+		select {
+		case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	// The lanes are full and so the scheduler should stop reading.
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("baz")}), nil):
+		t.Fatal("Expected scheduler to stop reading when full")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	select {
+	case tran := <-p.TransactionChan():
+		if exp, act := "foo", string(tran.Payload.Get(0)); exp != act {
+			t.Errorf("Wrong message: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	select {
+	case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte("baz")}), nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	for _, exp := range []string{"bar", "baz"} {
+		select {
+		case tran := <-p.TransactionChan():
+			if act := string(tran.Payload.Get(0)); exp != act {
+				t.Errorf("Wrong message: %v != %v", act, exp)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	close(tChan)
+	if err = p.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPriorityRejectOnClose(t *testing.T) {
+	conf := NewPriorityConfig()
+	conf.Lanes = append(conf.Lanes, condition.NewConfig())
+
+	p, err := newPriorityScheduler(conf, types.DudMgr{}, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = p.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+
+	resChans := []chan types.Response{}
+	for _, content := range []string{"foo", "bar"} {
+		resChan := make(chan types.Response)
+		resChans = append(resChans, resChan)
+		select {
+		case tChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	p.CloseAsync()
+	for i, resChan := range resChans {
+		select {
+		case res := <-resChan:
+			if res.Error() != types.ErrTypeClosed {
+				t.Errorf("Wrong response for transaction %v: %v", i, res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for response %v", i)
+		}
+	}
+
+	if err = p.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,233 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// ErrMessageSizeExceeded is returned to an input when a message it provided was
+// rejected for exceeding the maximum message size of the stream.
+var ErrMessageSizeExceeded = errors.New("message exceeds maximum size")
+
+// messageSize returns the total size in bytes of all parts of a message.
+func messageSize(msg types.Message) int {
+	size := 0
+	msg.Iter(func(i int, b []byte) error {
+		size += len(b)
+		return nil
+	})
+	return size
+}
+
+// truncateMessage returns a shallow copy of a message where the contents of
+// parts are cut short such that the total size of the message does not exceed
+// maxBytes. Parts that begin beyond the limit are left empty.
+func truncateMessage(msg types.Message, maxBytes int) types.Message {
+	newMsg := msg.ShallowCopy()
+	remaining := maxBytes
+	for i := 0; i < newMsg.Len(); i++ {
+		part := newMsg.Get(i)
+		if len(part) > remaining {
+			newMsg.Set(i, part[:remaining])
+		}
+		if remaining -= len(part); remaining < 0 {
+			remaining = 0
+		}
+	}
+	return newMsg
+}
+
+//------------------------------------------------------------------------------
+
+// sizeLimiter sits between the input layer of a stream and the layers that
+// follow it, enforcing a maximum size on each message read. Messages that
+// exceed the size are either rejected back to the input, truncated, or sent to
+// a quarantine output.
+type sizeLimiter struct {
+	running int32
+
+	maxBytes   int
+	policy     string
+	quarantine output.Type
+
+	log   log.Modular
+	stats metrics.Type
+
+	transactions    <-chan types.Transaction
+	transactionsOut chan types.Transaction
+	quarantineChan  chan types.Transaction
+
+	closeChan  chan struct{}
+	closedChan chan struct{}
+}
+
+// newSizeLimiter creates a new size limiter layer. The quarantine output is
+// required when the policy is quarantine and ignored otherwise.
+func newSizeLimiter(
+	conf MessageSizeConfig,
+	quarantine output.Type,
+	log log.Modular,
+	stats metrics.Type,
+) (*sizeLimiter, error) {
+	if conf.MaxBytes <= 0 {
+		return nil, fmt.Errorf("message size max_bytes must be greater than zero, got %v", conf.MaxBytes)
+	}
+	switch conf.Policy {
+	case "reject", "truncate":
+		quarantine = nil
+	case "quarantine":
+		if quarantine == nil {
+			return nil, errors.New("message size policy quarantine requires a quarantine output")
+		}
+	default:
+		return nil, fmt.Errorf("message size policy not recognised: %v", conf.Policy)
+	}
+	return &sizeLimiter{
+		running:         1,
+		maxBytes:        conf.MaxBytes,
+		policy:          conf.Policy,
+		quarantine:      quarantine,
+		log:             log.NewModule(".message_size"),
+		stats:           stats,
+		transactionsOut: make(chan types.Transaction),
+		quarantineChan:  make(chan types.Transaction),
+		closeChan:       make(chan struct{}),
+		closedChan:      make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *sizeLimiter) loop() {
+	defer func() {
+		close(s.transactionsOut)
+		close(s.quarantineChan)
+		if s.quarantine != nil {
+			err := s.quarantine.WaitForClose(time.Second)
+			for ; err != nil; err = s.quarantine.WaitForClose(time.Second) {
+			}
+		}
+		close(s.closedChan)
+	}()
+
+	var (
+		mExceeded    = s.stats.GetCounter("message_size.exceeded")
+		mRejected    = s.stats.GetCounter("message_size.rejected")
+		mTruncated   = s.stats.GetCounter("message_size.truncated")
+		mQuarantined = s.stats.GetCounter("message_size.quarantined")
+	)
+
+	for atomic.LoadInt32(&s.running) == 1 {
+		var tran types.Transaction
+		var open bool
+		select {
+		case tran, open = <-s.transactions:
+			if !open {
+				return
+			}
+		case <-s.closeChan:
+			return
+		}
+
+		target := s.transactionsOut
+		if size := messageSize(tran.Payload); size > s.maxBytes {
+			mExceeded.Incr(1)
+			s.log.Debugf("Message of size %v exceeds maximum of %v bytes\n", size, s.maxBytes)
+
+			switch s.policy {
+			case "reject":
+				mRejected.Incr(1)
+				select {
+				case tran.ResponseChan <- types.NewSimpleResponse(ErrMessageSizeExceeded):
+				case <-s.closeChan:
+					return
+				}
+				continue
+			case "truncate":
+				mTruncated.Incr(1)
+				tran = types.NewTransaction(truncateMessage(tran.Payload, s.maxBytes), tran.ResponseChan)
+			case "quarantine":
+				mQuarantined.Incr(1)
+				target = s.quarantineChan
+			}
+		}
+
+		select {
+		case target <- tran:
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// StartReceiving assigns a new transactions channel for the limiter to read.
+func (s *sizeLimiter) StartReceiving(ts <-chan types.Transaction) error {
+	if s.transactions != nil {
+		return types.ErrAlreadyStarted
+	}
+	if s.quarantine != nil {
+		if err := s.quarantine.StartReceiving(s.quarantineChan); err != nil {
+			return err
+		}
+	}
+	s.transactions = ts
+	go s.loop()
+	return nil
+}
+
+// TransactionChan returns the channel used for consuming messages that are
+// within the size limit, or have been truncated.
+func (s *sizeLimiter) TransactionChan() <-chan types.Transaction {
+	return s.transactionsOut
+}
+
+// CloseAsync shuts down the limiter and its quarantine output.
+func (s *sizeLimiter) CloseAsync() {
+	if atomic.CompareAndSwapInt32(&s.running, 1, 0) {
+		if s.quarantine != nil {
+			s.quarantine.CloseAsync()
+		}
+		close(s.closeChan)
+	}
+}
+
+// WaitForClose blocks until the limiter and its quarantine output have closed.
+func (s *sizeLimiter) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-s.closedChan:
+	case <-time.After(timeout):
+		return types.ErrTimeout
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package stream
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/output"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func newTestSizeLimiter(t *testing.T, policy string, quarantine *mockOutput) (*sizeLimiter, chan types.Transaction) {
+	t.Helper()
+
+	conf := NewMessageSizeConfig()
+	conf.MaxBytes = 5
+	conf.Policy = policy
+
+	var out output.Type
+	if quarantine != nil {
+		out = quarantine
+	}
+
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+	s, err := newSizeLimiter(conf, out, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tChan := make(chan types.Transaction)
+	if err = s.StartReceiving(tChan); err != nil {
+		t.Fatal(err)
+	}
+	return s, tChan
+}
+
+func TestSizeLimiterBadConfig(t *testing.T) {
+	testLog := log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"})
+
+	conf := NewMessageSizeConfig()
+	conf.Policy = "nope"
+	if _, err := newSizeLimiter(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from bad policy")
+	}
+
+	conf = NewMessageSizeConfig()
+	conf.Policy = "quarantine"
+	if _, err := newSizeLimiter(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from missing quarantine output")
+	}
+
+	conf = NewMessageSizeConfig()
+	conf.MaxBytes = 0
+	if _, err := newSizeLimiter(conf, nil, testLog, metrics.DudType{}); err == nil {
+		t.Error("Expected error from zero max bytes")
+	}
+}
+
+func TestSizeLimiterReject(t *testing.T) {
+	s, tChan := newTestSizeLimiter(t, "reject", nil)
+	resChan := make(chan types.Response)
+
+	smallMsg := types.NewMessage([][]byte{[]byte("foo"), []byte("ba")})
+	select {
+	case tChan <- types.NewTransaction(smallMsg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case tran := <-s.TransactionChan():
+		if tran.Payload != smallMsg {
+			t.Error("Wrong message forwarded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	largeMsg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	select {
+	case tChan <- types.NewTransaction(largeMsg, resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case res := <-resChan:
+		if exp, act := ErrMessageSizeExceeded, res.Error(); exp != act {
+			t.Errorf("Wrong error returned: %v != %v", act, exp)
+		}
+	case <-s.TransactionChan():
+		t.Fatal("Large message was forwarded")
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	close(tChan)
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSizeLimiterTruncate(t *testing.T) {
+	s, tChan := newTestSizeLimiter(t, "truncate", nil)
+
+	largeMsg := types.NewMessage([][]byte{
+		[]byte("foo"), []byte("bar"), []byte("baz"),
+	})
+	largeMsg.GetMetadata(2).Set("foo", "bar")
+
+	select {
+	case tChan <- types.NewTransaction(largeMsg, nil):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	select {
+	case tran := <-s.TransactionChan():
+		exp := [][]byte{[]byte("foo"), []byte("ba"), []byte("")}
+		if act := tran.Payload.GetAll(); !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong result: %s != %s", act, exp)
+		}
+		if exp, act := "bar", tran.Payload.GetMetadata(2).Get("foo"); exp != act {
+			t.Errorf("Wrong metadata: %v != %v", act, exp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	if exp, act := "baz", string(largeMsg.Get(2)); exp != act {
+		t.Errorf("Original message was modified: %v != %v", act, exp)
+	}
+
+	close(tChan)
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSizeLimiterQuarantine(t *testing.T) {
+	quarantine := &mockOutput{}
+	s, tChan := newTestSizeLimiter(t, "quarantine", quarantine)
+
+	smallMsg := types.NewMessage([][]byte{[]byte("foo")})
+	largeMsg := types.NewMessage([][]byte{[]byte("foo bar")})
+
+	for _, test := range []struct {
+		msg    types.Message
+		target <-chan types.Transaction
+	}{
+		{msg: smallMsg, target: s.TransactionChan()},
+		{msg: largeMsg, target: quarantine.ts},
+		{msg: smallMsg, target: s.TransactionChan()},
+	} {
+		select {
+		case tChan <- types.NewTransaction(test.msg, nil):
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+		select {
+		case tran := <-test.target:
+			if tran.Payload != test.msg {
+				t.Error("Wrong message routed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+
+	close(tChan)
+	if err := s.WaitForClose(time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -22,6 +22,7 @@ package stream
 
 import (
 	"bytes"
+	"fmt"
 	"os"
 	"runtime/pprof"
 	"time"
@@ -42,8 +43,11 @@ type Type struct {
 	conf Config
 
 	inputLayer    input.Type
+	inFlight      *inFlight
+	sizeLimiter   *sizeLimiter
 	bufferLayer   buffer.Type
 	pipelineLayer pipeline.Type
+	priority      *priorityScheduler
 	outputLayer   output.Type
 
 	complementaryInputPipes  []pipeline.ConstructorFunc
@@ -54,17 +58,28 @@ type Type struct {
 	stats   metrics.Type
 	logger  log.Modular
 
+	shutdown ShutdownConfig
+	ack      AcknowledgementConfig
+	corr     CorrelationConfig
+
+	backpressureConf BackpressureConfig
+	backpressure     *backpressure
+
 	onClose func()
 }
 
 // New creates a new stream.Type.
 func New(conf Config, opts ...func(*Type)) (*Type, error) {
 	t := &Type{
-		conf:    conf,
-		stats:   metrics.DudType{},
-		logger:  log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
-		manager: types.DudMgr{},
-		onClose: func() {},
+		conf:             conf,
+		stats:            metrics.DudType{},
+		logger:           log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		manager:          types.DudMgr{},
+		shutdown:         NewShutdownConfig(),
+		ack:              NewAcknowledgementConfig(),
+		corr:             NewCorrelationConfig(),
+		backpressureConf: NewBackpressureConfig(),
+		onClose:          func() {},
 	}
 	for _, opt := range opts {
 		opt(t)
@@ -125,6 +140,39 @@ func OptSetManager(mgr types.Manager) func(*Type) {
 	}
 }
 
+// OptSetShutdown sets the behaviour of the stream when it is stopped with
+// transactions still in flight.
+func OptSetShutdown(conf ShutdownConfig) func(*Type) {
+	return func(t *Type) {
+		t.shutdown = conf
+	}
+}
+
+// OptSetAcknowledgement sets how long the stream waits for messages read from
+// the input to be acknowledged before they are nacked.
+func OptSetAcknowledgement(conf AcknowledgementConfig) func(*Type) {
+	return func(t *Type) {
+		t.ack = conf
+	}
+}
+
+// OptSetCorrelation sets whether the stream tags messages read from the input
+// with a correlation ID.
+func OptSetCorrelation(conf CorrelationConfig) func(*Type) {
+	return func(t *Type) {
+		t.corr = conf
+	}
+}
+
+// OptSetBackpressure sets whether the stream measures the time that
+// transactions wait between each layer, and whether the output layer is tuned
+// in response.
+func OptSetBackpressure(conf BackpressureConfig) func(*Type) {
+	return func(t *Type) {
+		t.backpressureConf = conf
+	}
+}
+
 // OptOnClose sets a closure to be called when the stream closes.
 func OptOnClose(onClose func()) func(*Type) {
 	return func(t *Type) {
@@ -135,6 +183,12 @@ func OptOnClose(onClose func()) func(*Type) {
 //------------------------------------------------------------------------------
 
 func (t *Type) start() (err error) {
+	switch t.shutdown.Pending {
+	case "abandon", "nack":
+	default:
+		return fmt.Errorf("shutdown pending behaviour not recognised: %v", t.shutdown.Pending)
+	}
+
 	// Constructors
 	if t.inputLayer, err = input.New(
 		t.conf.Input, t.manager, t.logger, t.stats, t.complementaryInputPipes...,
@@ -155,35 +209,119 @@ func (t *Type) start() (err error) {
 			return
 		}
 	}
+	if t.conf.Priority != nil {
+		if t.priority, err = newPriorityScheduler(
+			*t.conf.Priority, t.manager, t.logger, t.stats,
+		); err != nil {
+			return
+		}
+	}
 	if t.outputLayer, err = output.New(
 		t.conf.Output, t.manager, t.logger, t.stats, t.complementaryOutputPipes...,
 	); err != nil {
 		return
 	}
+	if t.conf.Dedupe != nil {
+		var cache types.Cache
+		if cache, err = t.manager.GetCache(t.conf.Dedupe.Cache); err != nil {
+			return fmt.Errorf("failed to obtain dedupe cache '%v': %v", t.conf.Dedupe.Cache, err)
+		}
+		t.outputLayer = newDeduper(
+			t.outputLayer, cache, t.conf.Dedupe.Key, t.logger, t.stats,
+		)
+	}
+	if t.conf.OnError != nil {
+		var errOutput output.Type
+		if errOutput, err = output.New(
+			*t.conf.OnError, t.manager, t.logger.NewModule(".on_error"),
+			metrics.Namespaced(t.stats, "on_error"),
+		); err != nil {
+			return
+		}
+		t.outputLayer = newErrorRouter(t.outputLayer, errOutput)
+	}
+	if t.conf.MessageSize != nil {
+		var quarantine output.Type
+		if t.conf.MessageSize.Policy == "quarantine" && t.conf.MessageSize.Quarantine != nil {
+			if quarantine, err = output.New(
+				*t.conf.MessageSize.Quarantine, t.manager, t.logger.NewModule(".quarantine"),
+				metrics.Namespaced(t.stats, "quarantine"),
+			); err != nil {
+				return
+			}
+		}
+		if t.sizeLimiter, err = newSizeLimiter(
+			*t.conf.MessageSize, quarantine, t.logger, t.stats,
+		); err != nil {
+			return
+		}
+	}
 
 	// Start chaining components
 	var nextTranChan <-chan types.Transaction
 
-	nextTranChan = t.inputLayer.TransactionChan()
+	var correlationKey string
+	if t.corr.Enabled {
+		correlationKey = t.corr.MetadataKey
+
+		// Components that log regarding a message, such as brokers and
+		// retries, find its correlation ID with this key.
+		types.SetCorrelationKey(correlationKey)
+	}
+	t.inFlight = newInFlight(
+		time.Duration(t.ack.TimeoutMS)*time.Millisecond, correlationKey,
+		t.logger.NewModule(".in_flight"), t.stats,
+	)
+	t.inFlight.StartReceiving(t.inputLayer.TransactionChan())
+
+	// When enabled the wait time of transactions is measured in front of each
+	// layer following the input.
+	monitor := func(stage string, tranChan <-chan types.Transaction) <-chan types.Transaction {
+		return tranChan
+	}
+	if t.backpressureConf.Enabled {
+		t.backpressure = newBackpressure(t.backpressureConf, t.logger, t.stats)
+		monitor = t.backpressure.monitor
+	}
+
+	nextTranChan = t.inFlight.TransactionChan()
+	if t.sizeLimiter != nil {
+		if err = t.sizeLimiter.StartReceiving(nextTranChan); err != nil {
+			return
+		}
+		nextTranChan = t.sizeLimiter.TransactionChan()
+	}
 	if t.bufferLayer != nil {
-		if err = t.bufferLayer.StartReceiving(nextTranChan); err != nil {
+		if err = t.bufferLayer.StartReceiving(monitor("buffer", nextTranChan)); err != nil {
 			return
 		}
 		nextTranChan = t.bufferLayer.TransactionChan()
 	}
 	if t.pipelineLayer != nil {
-		if err = t.pipelineLayer.StartReceiving(nextTranChan); err != nil {
+		if err = t.pipelineLayer.StartReceiving(monitor("pipeline", nextTranChan)); err != nil {
 			return
 		}
 		nextTranChan = t.pipelineLayer.TransactionChan()
 	}
-	if err = t.outputLayer.StartReceiving(nextTranChan); err != nil {
+	if t.priority != nil {
+		if err = t.priority.StartReceiving(nextTranChan); err != nil {
+			return
+		}
+		nextTranChan = t.priority.TransactionChan()
+	}
+	if err = t.outputLayer.StartReceiving(monitor("output", nextTranChan)); err != nil {
 		return
 	}
+	if t.backpressure != nil {
+		t.backpressure.start(t.outputLayer)
+	}
 
 	go func(out output.Type) {
 		for {
 			if err := out.WaitForClose(time.Second); err == nil {
+				if t.backpressure != nil {
+					t.backpressure.CloseAsync()
+				}
 				t.onClose()
 				return
 			}
@@ -193,22 +331,59 @@ func (t *Type) start() (err error) {
 	return nil
 }
 
+// resolvePending stops the stream from reading new messages and resolves any
+// transactions still in flight according to the shutdown configuration, they
+// are either nacked or abandoned.
+func (t *Type) resolvePending(timeout time.Duration) {
+	t.inFlight.StopReading()
+	if t.shutdown.Pending == "nack" {
+		t.inFlight.NackPending()
+		if err := t.inFlight.WaitForPending(timeout); err != nil {
+			t.logger.Warnln("Failed to nack pending transactions within target time.")
+		}
+	}
+	t.inFlight.CloseAsync()
+}
+
 // stopGracefully attempts to close the stream in the most graceful way by only
 // closing the input layer and waiting for all other layers to terminate by
 // proxy. This should guarantee that all in-flight and buffered data is resolved
 // before shutting down.
 func (t *Type) stopGracefully(timeout time.Duration) (err error) {
-	t.inputLayer.CloseAsync()
 	started := time.Now()
-	if err = t.inputLayer.WaitForClose(timeout); err != nil {
+
+	// Stop reading new messages and wait for those in flight to be resolved
+	// before closing the input.
+	t.inFlight.StopReading()
+	if err = t.inFlight.WaitForPending(timeout); err != nil {
 		return
 	}
 
-	var remaining time.Duration
+	t.inputLayer.CloseAsync()
+	remaining := timeout - time.Since(started)
+	if remaining < 0 {
+		return types.ErrTimeout
+	}
+	if err = t.inputLayer.WaitForClose(remaining); err != nil {
+		return
+	}
+
+	if t.sizeLimiter != nil {
+		remaining = timeout - time.Since(started)
+		if remaining < 0 {
+			return types.ErrTimeout
+		}
+		if err = t.sizeLimiter.WaitForClose(remaining); err != nil {
+			return
+		}
+	}
 
 	// If we have a buffer then wait right here. We want to try and allow the
 	// buffer to empty out before prompting the other layers to shut down.
 	if t.bufferLayer != nil {
+		if _, buffered := t.pendingMessages(); buffered > 0 {
+			t.logger.Infof("Draining %v buffered messages.\n", buffered)
+		}
 		t.bufferLayer.StopConsuming()
 		remaining = timeout - time.Since(started)
 		if remaining < 0 {
@@ -231,6 +406,18 @@ func (t *Type) stopGracefully(timeout time.Duration) (err error) {
 		}
 	}
 
+	// The priority scheduler closes once the pipeline layer has closed and the
+	// lanes are drained.
+	if t.priority != nil {
+		remaining = timeout - time.Since(started)
+		if remaining < 0 {
+			return types.ErrTimeout
+		}
+		if err = t.priority.WaitForClose(remaining); err != nil {
+			return
+		}
+	}
+
 	t.outputLayer.CloseAsync()
 	remaining = timeout - time.Since(started)
 	if remaining < 0 {
@@ -248,13 +435,28 @@ func (t *Type) stopGracefully(timeout time.Duration) (err error) {
 // the pipeline under certain circumstances but is less graceful than
 // stopGracefully, which should be attempted first.
 func (t *Type) stopOrdered(timeout time.Duration) (err error) {
-	t.inputLayer.CloseAsync()
 	started := time.Now()
-	if err = t.inputLayer.WaitForClose(timeout); err != nil {
+	t.resolvePending(timeout / 2)
+
+	t.inputLayer.CloseAsync()
+	remaining := timeout - time.Since(started)
+	if remaining < 0 {
+		return types.ErrTimeout
+	}
+	if err = t.inputLayer.WaitForClose(remaining); err != nil {
 		return
 	}
 
-	var remaining time.Duration
+	if t.sizeLimiter != nil {
+		t.sizeLimiter.CloseAsync()
+		remaining = timeout - time.Since(started)
+		if remaining < 0 {
+			return types.ErrTimeout
+		}
+		if err = t.sizeLimiter.WaitForClose(remaining); err != nil {
+			return
+		}
+	}
 
 	if t.bufferLayer != nil {
 		t.bufferLayer.CloseAsync()
@@ -278,6 +480,17 @@ func (t *Type) stopOrdered(timeout time.Duration) (err error) {
 		}
 	}
 
+	if t.priority != nil {
+		t.priority.CloseAsync()
+		remaining = timeout - time.Since(started)
+		if remaining < 0 {
+			return types.ErrTimeout
+		}
+		if err = t.priority.WaitForClose(remaining); err != nil {
+			return
+		}
+	}
+
 	t.outputLayer.CloseAsync()
 	remaining = timeout - time.Since(started)
 	if remaining < 0 {
@@ -294,21 +507,41 @@ func (t *Type) stopOrdered(timeout time.Duration) (err error) {
 // the stream to gracefully wind down in the order of component layers. This
 // should only be attempted if both stopGracefully and stopOrdered failed.
 func (t *Type) stopUnordered(timeout time.Duration) (err error) {
+	started := time.Now()
+	t.resolvePending(timeout / 2)
+
 	t.inputLayer.CloseAsync()
+	if t.sizeLimiter != nil {
+		t.sizeLimiter.CloseAsync()
+	}
 	if t.bufferLayer != nil {
 		t.bufferLayer.CloseAsync()
 	}
 	if t.pipelineLayer != nil {
 		t.pipelineLayer.CloseAsync()
 	}
+	if t.priority != nil {
+		t.priority.CloseAsync()
+	}
 	t.outputLayer.CloseAsync()
 
-	started := time.Now()
-	if err = t.inputLayer.WaitForClose(timeout); err != nil {
+	remaining := timeout - time.Since(started)
+	if remaining < 0 {
+		return types.ErrTimeout
+	}
+	if err = t.inputLayer.WaitForClose(remaining); err != nil {
 		return
 	}
 
-	var remaining time.Duration
+	if t.sizeLimiter != nil {
+		remaining = timeout - time.Since(started)
+		if remaining < 0 {
+			return types.ErrTimeout
+		}
+		if err = t.sizeLimiter.WaitForClose(remaining); err != nil {
+			return
+		}
+	}
 
 	if t.bufferLayer != nil {
 		remaining = timeout - time.Since(started)
@@ -330,6 +563,16 @@ func (t *Type) stopUnordered(timeout time.Duration) (err error) {
 		}
 	}
 
+	if t.priority != nil {
+		remaining = timeout - time.Since(started)
+		if remaining < 0 {
+			return types.ErrTimeout
+		}
+		if err = t.priority.WaitForClose(remaining); err != nil {
+			return
+		}
+	}
+
 	remaining = timeout - time.Since(started)
 	if remaining < 0 {
 		return types.ErrTimeout
@@ -341,19 +584,44 @@ func (t *Type) stopUnordered(timeout time.Duration) (err error) {
 	return nil
 }
 
+// pendingMessages returns the number of messages read from the input that are
+// yet to be acknowledged, and the number of messages held within the buffer
+// that are yet to be delivered.
+func (t *Type) pendingMessages() (inFlight, buffered int) {
+	inFlight = t.inFlight.PendingCount()
+	if p, ok := t.bufferLayer.(buffer.Pending); ok {
+		buffered = p.PendingMessages()
+	}
+	return
+}
+
 // Stop attempts to close the stream within the specified timeout period.
 // Initially the attempt is graceful, but as the timeout draws close the attempt
 // becomes progressively less graceful.
+//
+// The graceful attempt drains the stream by stopping the input and waiting for
+// all in-flight and buffered messages to be delivered, and is given the drain
+// timeout of the shutdown config when it is set and shorter than the overall
+// timeout.
 func (t *Type) Stop(timeout time.Duration) error {
 	tOutUnordered := timeout / 4
 	tOutGraceful := timeout - tOutUnordered
+	if tOutDrain := time.Duration(t.shutdown.DrainTimeoutMS) * time.Millisecond; tOutDrain > 0 && tOutDrain < timeout {
+		tOutGraceful = tOutDrain
+		tOutUnordered = timeout - tOutDrain
+	}
 
 	err := t.stopGracefully(tOutGraceful)
 	if err == nil {
 		return nil
 	}
 	if err == types.ErrTimeout {
-		t.logger.Infoln("Unable to fully drain buffered messages within target time.")
+		inFlight, buffered := t.pendingMessages()
+		t.stats.GetGauge("shutdown.drain.remaining").Gauge(int64(inFlight + buffered))
+		t.logger.Warnf(
+			"Unable to fully drain messages within target time, %v in flight and %v buffered messages remain.\n",
+			inFlight, buffered,
+		)
 	} else {
 		t.logger.Errorf("Encountered error whilst shutting down: %v\n", err)
 	}
@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Jeffail/benthos/lib/manager"
 	"github.com/Jeffail/benthos/lib/metrics"
 	"github.com/Jeffail/benthos/lib/processor"
 	"github.com/Jeffail/benthos/lib/types"
@@ -199,3 +200,69 @@ func TestTypeCloseUnordered(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestTypeStopDrainTimeout(t *testing.T) {
+	mgr, err := manager.New(manager.NewConfig(), nil, testLog, metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := NewConfig()
+	conf.Input.Type = "inproc"
+	conf.Input.Inproc = "in"
+	conf.Buffer.Type = "memory"
+	conf.Output.Type = "inproc"
+	conf.Output.Inproc = "out"
+
+	shutdownConf := NewShutdownConfig()
+	shutdownConf.DrainTimeoutMS = 100
+
+	strm, err := New(conf, OptSetManager(mgr), OptSetShutdown(shutdownConf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inChan := make(chan types.Transaction)
+	mgr.SetPipe("in", inChan)
+
+	// Nothing reads from the output pipe, and therefore all messages remain
+	// within the buffer.
+	resChan := make(chan types.Response)
+	for _, content := range []string{"foo", "bar", "baz"} {
+		select {
+		case inChan <- types.NewTransaction(types.NewMessage([][]byte{[]byte(content)}), resChan):
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+		select {
+		case res := <-resChan:
+			if res.Error() != nil {
+				t.Error(res.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timed out")
+		}
+	}
+
+	var inFlight, buffered int
+	for i := 0; i < 100; i++ {
+		if inFlight, buffered = strm.pendingMessages(); inFlight == 0 {
+			break
+		}
+		<-time.After(time.Millisecond * 10)
+	}
+	if exp, act := 0, inFlight; exp != act {
+		t.Errorf("Wrong count of in flight messages: %v != %v", act, exp)
+	}
+	if exp, act := 3, buffered; exp != act {
+		t.Errorf("Wrong count of buffered messages: %v != %v", act, exp)
+	}
+
+	started := time.Now()
+	if err = strm.Stop(time.Second * 5); err != nil {
+		t.Error(err)
+	}
+	if tTaken := time.Since(started); tTaken > time.Second*2 {
+		t.Errorf("Stop took too long: %v", tTaken)
+	}
+}
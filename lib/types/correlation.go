@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package types
+
+import (
+	"sync/atomic"
+)
+
+//------------------------------------------------------------------------------
+
+// correlationKey is the metadata key under which the correlation ID of messages
+// is stored, an empty key means correlation is disabled.
+var correlationKey atomic.Value
+
+// SetCorrelationKey sets the metadata key under which the correlation ID of
+// messages is stored. Correlation is configured once for a whole process, and
+// an empty key disables it.
+func SetCorrelationKey(key string) {
+	correlationKey.Store(key)
+}
+
+// CorrelationID returns the correlation ID of a message, which is the value of
+// the correlation key from the metadata of the first part that has one. An
+// empty string is returned when correlation is disabled or the message has no
+// ID.
+func CorrelationID(msg Message) string {
+	key, _ := correlationKey.Load().(string)
+	if len(key) == 0 {
+		return ""
+	}
+	for i := 0; i < msg.Len(); i++ {
+		if id := msg.GetMetadata(i).Get(key); len(id) > 0 {
+			return id
+		}
+	}
+	return ""
+}
+
+// CorrelationLogTag returns a string to be appended to the subject of a log
+// message regarding a message, e.g. "Failed to send message%v: %v", which
+// identifies the message by its correlation ID. An empty string is returned
+// when the message has no correlation ID.
+func CorrelationLogTag(msg Message) string {
+	if id := CorrelationID(msg); len(id) > 0 {
+		return " (correlation ID: " + id + ")"
+	}
+	return ""
+}
+
+//------------------------------------------------------------------------------
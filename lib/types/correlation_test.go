@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package types
+
+import (
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestCorrelationID(t *testing.T) {
+	defer SetCorrelationKey("")
+
+	msg := NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	msg.GetMetadata(1).Set("trace_id", "abc")
+
+	if exp, act := "", CorrelationID(msg); exp != act {
+		t.Errorf("Wrong ID without key: %v != %v", act, exp)
+	}
+
+	SetCorrelationKey("trace_id")
+	if exp, act := "abc", CorrelationID(msg); exp != act {
+		t.Errorf("Wrong ID: %v != %v", act, exp)
+	}
+	if exp, act := " (correlation ID: abc)", CorrelationLogTag(msg); exp != act {
+		t.Errorf("Wrong log tag: %v != %v", act, exp)
+	}
+	if exp, act := "", CorrelationLogTag(NewMessage([][]byte{[]byte("foo")})); exp != act {
+		t.Errorf("Wrong log tag without ID: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -35,9 +35,14 @@ var (
 
 	ErrNotConnected = errors.New("not connected to target source or sink")
 
+	// ErrCheckNotSupported is returned when a connection check is attempted
+	// against a component type that does not support it.
+	ErrCheckNotSupported = errors.New("connection checks are not supported by this type")
+
 	ErrInvalidProcessorType = errors.New("processor type was not recognised")
 	ErrInvalidCacheType     = errors.New("cache type was not recognised")
 	ErrInvalidConditionType = errors.New("condition type was not recognised")
+	ErrInvalidRateLimitType = errors.New("rate limit type was not recognised")
 	ErrInvalidBufferType    = errors.New("buffer type was not recognised")
 	ErrInvalidInputType     = errors.New("input type was not recognised")
 	ErrInvalidOutputType    = errors.New("output type was not recognised")
@@ -62,8 +67,10 @@ var (
 var (
 	ErrCacheNotFound     = errors.New("cache not found")
 	ErrConditionNotFound = errors.New("condition not found")
+	ErrRateLimitNotFound = errors.New("rate limit not found")
 	ErrKeyAlreadyExists  = errors.New("key already exists")
 	ErrKeyNotFound       = errors.New("key does not exist")
+	ErrPipeNotFound      = errors.New("pipe not found")
 )
 
 //------------------------------------------------------------------------------
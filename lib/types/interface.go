@@ -57,6 +57,18 @@ type Condition interface {
 
 //------------------------------------------------------------------------------
 
+// RateLimit is a strategy for limiting access to a shared resource, this
+// strategy can be safely used by components in parallel.
+type RateLimit interface {
+	// Access the rate limited resource. Returns a duration or an error if the
+	// rate limit check fails. The returned duration is either zero (meaning
+	// the resource can be accessed) or a reasonable length of time to wait
+	// before requesting again.
+	Access() (time.Duration, error)
+}
+
+//------------------------------------------------------------------------------
+
 // Manager is an interface expected by Benthos components that allows them to
 // register their service wide behaviours such as HTTP endpoints and event
 // listeners, and obtain service wide shared resources such as caches.
@@ -69,6 +81,19 @@ type Manager interface {
 
 	// GetCondition attempts to find a service wide condition by its name.
 	GetCondition(name string) (Condition, error)
+
+	// GetRateLimit attempts to find a service wide rate limit by its name.
+	GetRateLimit(name string) (RateLimit, error)
+
+	// GetPipe attempts to find a service wide transaction chan by its name.
+	GetPipe(name string) (<-chan Transaction, error)
+
+	// SetPipe registers a transaction chan under a name.
+	SetPipe(name string, t <-chan Transaction)
+
+	// UnsetPipe removes a named transaction chan, but only if it is the chan
+	// that is currently registered under the name.
+	UnsetPipe(name string, t <-chan Transaction)
 }
 
 //------------------------------------------------------------------------------
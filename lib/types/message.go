@@ -38,8 +38,9 @@ type Message interface {
 	// not safe to edit the contents of a message directly.
 	Get(p int) []byte
 
-	// GetAll returns all message parts as a two-dimensional byte array. It is
-	// NOT safe to edit the contents of the result.
+	// GetAll returns all message parts as a two-dimensional byte array. The
+	// result is the underlying storage of the message and is not copied, it is
+	// therefore NOT safe to edit the contents of the result.
 	GetAll() [][]byte
 
 	// Set edits the contents of an existing message part. If the index is
@@ -47,15 +48,35 @@ type Message interface {
 	// starting at -1. If the index is out of bounds then nothing is done.
 	Set(p int, b []byte)
 
-	// SetAll replaces all parts of a message with a new set.
+	// SetAll replaces all parts of a message with a new set, the metadata of
+	// all parts is also reset.
 	SetAll(p [][]byte)
 
+	// GetMetadata returns the metadata of a message part. Changes made to the
+	// returned metadata are reflected in the message part. If the index is
+	// negative then the part is found by counting backwards from the last part
+	// starting at -1. If the index is out of bounds then an empty metadata
+	// object is returned that is not attached to the message.
+	GetMetadata(p int) Metadata
+
+	// SetMetadata sets the metadata of message parts by their index to a copy
+	// of the metadata argument. If no indexes are specified then the metadata
+	// of all message parts is set. Negative indexes are found by counting
+	// backwards from the last part starting at -1 and indexes that are out of
+	// bounds are ignored. A nil metadata argument resets the metadata to empty.
+	SetMetadata(m Metadata, p ...int)
+
 	// GetJSON returns a message part parsed as JSON into an `interface{}` type.
 	// This is lazily evaluated and the result is cached. If multiple layers of
 	// a pipeline extract the same part as JSON it will only be unmarshalled
 	// once, unless the content of the part has changed. If the index is
 	// negative then the part is found by counting backwards from the last part
 	// starting at -1.
+	//
+	// Since the result is cached it must not be modified unless it is then
+	// set back to the same message with SetJSON. Components that modify the
+	// result should therefore call GetJSON on a ShallowCopy of the message,
+	// which does not share the cache of the original.
 	GetJSON(p int) (interface{}, error)
 
 	// SetJSON sets a message part to the marshalled bytes of a JSON object, but
@@ -80,7 +101,8 @@ type Message interface {
 	// parsed back into a multipart message with `FromBytes`. The result of this
 	// call can itself be the part of a new message, which is a useful way of
 	// transporting multiple part messages across protocols that only support
-	// single parts.
+	// single parts. The result is a single allocation of the exact size
+	// required and is owned by the caller.
 	Bytes() []byte
 
 	// LazyCondition lazily evaluates conditions on the message by caching the
@@ -113,7 +135,18 @@ func NewMessage(parts [][]byte) Message {
 	}
 }
 
-// FromBytes deserialises a Message from a byte array.
+// ExtractPart returns a new single part message containing a copy of the
+// contents and metadata of a message part. If the index is negative then the
+// part is found by counting backwards from the last part starting at -1.
+func ExtractPart(msg Message, p int) Message {
+	newMsg := NewMessage([][]byte{msg.Get(p)})
+	newMsg.SetMetadata(msg.GetMetadata(p))
+	return newMsg
+}
+
+// FromBytes deserialises a Message from a byte array. Metadata is restored when
+// the byte array was created with BytesWithMetadata, otherwise the metadata of
+// each part is empty.
 func FromBytes(b []byte) (Message, error) {
 	if len(b) < 4 {
 		return nil, ErrBadMessageBytes
@@ -140,9 +173,96 @@ func FromBytes(b []byte) (Message, error) {
 		m.Append(b[:partSize])
 		b = b[partSize:]
 	}
+
+	// Any remaining bytes are the metadata of each part.
+	if len(b) == 0 {
+		return m, nil
+	}
+	for i := 0; i < int(numParts); i++ {
+		numKeys, rest, err := readUint32(b)
+		if err != nil {
+			return nil, err
+		}
+		b = rest
+		md := NewMetadata()
+		for j := uint32(0); j < numKeys; j++ {
+			var k, v []byte
+			if k, b, err = readSized(b); err != nil {
+				return nil, err
+			}
+			if v, b, err = readSized(b); err != nil {
+				return nil, err
+			}
+			md.Set(string(k), string(v))
+		}
+		m.SetMetadata(md, i)
+	}
+	if len(b) > 0 {
+		return nil, ErrBadMessageBytes
+	}
 	return m, nil
 }
 
+// BytesWithMetadata serialises a message into a single byte array in the same
+// format as the Bytes method of a message, followed by the metadata of each
+// part. The result can be parsed back into a message, including its metadata,
+// with FromBytes.
+//
+// The metadata of each part is written as the number of keys as four bytes in
+// big endian, followed by each key and value as four bytes of length in big
+// endian and then the content. When no part has metadata the result is
+// identical to the Bytes method.
+func BytesWithMetadata(msg Message) []byte {
+	var mdBytes []byte
+	hasMetadata := false
+	for i := 0; i < msg.Len(); i++ {
+		var numKeys uint32
+		var partBytes []byte
+		msg.GetMetadata(i).Iter(func(k, v string) error {
+			numKeys++
+			partBytes = appendSized(partBytes, []byte(k))
+			partBytes = appendSized(partBytes, []byte(v))
+			return nil
+		})
+		if numKeys > 0 {
+			hasMetadata = true
+		}
+		mdBytes = append(appendUint32(mdBytes, numKeys), partBytes...)
+	}
+
+	b := msg.Bytes()
+	if !hasMetadata {
+		return b
+	}
+	return append(b, mdBytes...)
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendSized(b, content []byte) []byte {
+	return append(appendUint32(b, uint32(len(content))), content...)
+}
+
+func readUint32(b []byte) (uint32, []byte, error) {
+	if len(b) < 4 {
+		return 0, nil, ErrBadMessageBytes
+	}
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), b[4:], nil
+}
+
+func readSized(b []byte) ([]byte, []byte, error) {
+	size, b, err := readUint32(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if uint32(len(b)) < size {
+		return nil, nil, ErrBadMessageBytes
+	}
+	return b[:size], b[size:], nil
+}
+
 //------------------------------------------------------------------------------
 
 // partCache is a cache of operations performed on message parts, a part cache
@@ -154,11 +274,17 @@ type partCache struct {
 //------------------------------------------------------------------------------
 
 // messageImpl is a struct containing any relevant fields of a benthos message
-// and
-// helper functions.
+// and helper functions.
+//
+// Parts are not drawn from a pool as they are shared between the layers of a
+// pipeline, and between shallow copies of a message, without any point at
+// which ownership of a part is released. Allocations are instead reduced by
+// pooling the scratch buffers of components that process parts, such as the
+// compress and decompress processors.
 type messageImpl struct {
 	createdAt   time.Time
 	parts       [][]byte
+	metadata    []Metadata
 	partCaches  []*partCache
 	resultCache map[string]bool
 }
@@ -190,7 +316,8 @@ v                                        v           v
 // Reserve bytes for our length counter (4 * 8 = 32 bit)
 var intLen uint32 = 4
 
-// Bytes serialises the message into a single byte array.
+// Bytes serialises the message into a single byte array, metadata is not
+// included.
 func (m *messageImpl) Bytes() []byte {
 	lenParts := uint32(len(m.parts))
 
@@ -235,6 +362,7 @@ func (m *messageImpl) ShallowCopy() Message {
 	return &messageImpl{
 		createdAt:   m.createdAt,
 		parts:       append([][]byte(nil), m.parts...),
+		metadata:    m.copyMetadata(),
 		resultCache: m.resultCache,
 	}
 }
@@ -242,16 +370,38 @@ func (m *messageImpl) ShallowCopy() Message {
 // DeepCopy creates a new deep copy of the message. This can be considered an
 // entirely new object that is safe to use anywhere.
 func (m *messageImpl) DeepCopy() Message {
+	// All parts are copied into a single allocation, with the capacity of each
+	// part capped so that appending to one cannot overwrite the next.
+	size := 0
+	for _, p := range m.parts {
+		size += len(p)
+	}
+	backing := make([]byte, size)
+
 	newParts := make([][]byte, len(m.parts))
 	for i, p := range m.parts {
-		np := make([]byte, len(p))
-		copy(np, p)
-		newParts[i] = np
+		n := copy(backing, p)
+		newParts[i] = backing[:n:n]
+		backing = backing[n:]
 	}
 	return &messageImpl{
 		createdAt: m.createdAt,
 		parts:     newParts,
+		metadata:  m.copyMetadata(),
+	}
+}
+
+func (m *messageImpl) copyMetadata() []Metadata {
+	if len(m.metadata) == 0 {
+		return nil
 	}
+	newMetadata := make([]Metadata, len(m.metadata))
+	for i, md := range m.metadata {
+		if md != nil {
+			newMetadata[i] = md.Copy()
+		}
+	}
+	return newMetadata
 }
 
 //------------------------------------------------------------------------------
@@ -287,9 +437,49 @@ func (m *messageImpl) Set(index int, b []byte) {
 
 func (m *messageImpl) SetAll(p [][]byte) {
 	m.parts = p
+	m.metadata = nil
 	m.clearAllCaches()
 }
 
+func (m *messageImpl) GetMetadata(index int) Metadata {
+	if index < 0 {
+		index = len(m.parts) + index
+	}
+	if index < 0 || index >= len(m.parts) {
+		return NewMetadata()
+	}
+	m.expandMetadata()
+	md := m.metadata[index]
+	if md == nil {
+		md = NewMetadata()
+		m.metadata[index] = md
+	}
+	return md
+}
+
+func (m *messageImpl) SetMetadata(md Metadata, indexes ...int) {
+	if md == nil {
+		md = NewMetadata()
+	}
+	if len(indexes) == 0 {
+		m.metadata = make([]Metadata, len(m.parts))
+		for i := range m.metadata {
+			m.metadata[i] = md.Copy()
+		}
+		return
+	}
+	for _, index := range indexes {
+		if index < 0 {
+			index = len(m.parts) + index
+		}
+		if index < 0 || index >= len(m.parts) {
+			continue
+		}
+		m.expandMetadata()
+		m.metadata[index] = md.Copy()
+	}
+}
+
 func (m *messageImpl) Append(b ...[]byte) int {
 	for _, p := range b {
 		m.parts = append(m.parts, p)
@@ -320,6 +510,15 @@ func (m *messageImpl) expandCache(index int) {
 	m.partCaches = cParts
 }
 
+func (m *messageImpl) expandMetadata() {
+	if len(m.metadata) >= len(m.parts) {
+		return
+	}
+	newMetadata := make([]Metadata, len(m.parts))
+	copy(newMetadata, m.metadata)
+	m.metadata = newMetadata
+}
+
 func (m *messageImpl) clearGeneralCaches() {
 	m.resultCache = nil
 }
@@ -337,14 +536,14 @@ func (m *messageImpl) GetJSON(part int) (interface{}, error) {
 		return nil, ErrMessagePartNotExist
 	}
 	m.expandCache(part)
-	cPart := m.partCaches[part]
-	if cPart == nil {
-		cPart = &partCache{}
-		m.partCaches[part] = cPart
+	if cPart := m.partCaches[part]; cPart != nil {
+		return cPart.json, nil
 	}
+	cPart := &partCache{}
 	if err := json.Unmarshal(m.Get(part), &cPart.json); err != nil {
 		return nil, err
 	}
+	m.partCaches[part] = cPart
 	return cPart.json, nil
 }
 
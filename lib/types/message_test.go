@@ -46,6 +46,89 @@ func TestMessageSerialization(t *testing.T) {
 	}
 }
 
+func TestMessageGetAllNoCopy(t *testing.T) {
+	parts := [][]byte{[]byte("hello"), []byte("world")}
+	m := NewMessage(parts)
+
+	for i, p := range m.GetAll() {
+		if &p[0] != &parts[i][0] {
+			t.Errorf("Part %v was copied", i)
+		}
+	}
+	if exp, act := 4*3+10, len(m.Bytes()); exp != act {
+		t.Errorf("Wrong serialised length: %v != %v", act, exp)
+	}
+	if exp, act := 4*3+10, cap(m.Bytes()); exp != act {
+		t.Errorf("Wrong serialised capacity: %v != %v", act, exp)
+	}
+}
+
+func TestMessageDeepCopyParts(t *testing.T) {
+	parts := [][]byte{[]byte("hello"), []byte(""), []byte("world")}
+	m := NewMessage(parts)
+	m2 := m.DeepCopy()
+
+	if !reflect.DeepEqual(m.GetAll(), m2.GetAll()) {
+		t.Errorf("Messages not equal: %s != %s", m2.GetAll(), m.GetAll())
+	}
+	for i, p := range m2.GetAll() {
+		if len(p) > 0 && &p[0] == &parts[i][0] {
+			t.Errorf("Part %v was not copied", i)
+		}
+	}
+
+	m2.Set(0, append(m2.Get(0), []byte(" there")...))
+	if exp, act := "world", string(m2.Get(2)); exp != act {
+		t.Errorf("Appending to a part overwrote the next: %v != %v", act, exp)
+	}
+	if exp, act := "hello", string(m.Get(0)); exp != act {
+		t.Errorf("Deep copy modified the original: %v != %v", act, exp)
+	}
+}
+
+func TestMessageSerializationMetadata(t *testing.T) {
+	m := NewMessage([][]byte{
+		[]byte("hello"),
+		[]byte("world"),
+		[]byte("12345"),
+	})
+	m.GetMetadata(0).Set("foo", "bar").Set("baz", "")
+	m.GetMetadata(2).Set("foo", "qux")
+
+	b := BytesWithMetadata(m)
+	m2, err := FromBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m.GetAll(), m2.GetAll()) {
+		t.Errorf("Messages not equal: %s != %s", m2.GetAll(), m.GetAll())
+	}
+	for i, exp := range []map[string]string{
+		{"foo": "bar", "baz": ""},
+		{},
+		{"foo": "qux"},
+	} {
+		act := map[string]string{}
+		m2.GetMetadata(i).Iter(func(k, v string) error {
+			act[k] = v
+			return nil
+		})
+		if !reflect.DeepEqual(exp, act) {
+			t.Errorf("Wrong metadata at part %v: %v != %v", i, act, exp)
+		}
+	}
+
+	for i := len(m.Bytes()) + 1; i < len(b); i++ {
+		if _, err = FromBytes(b[:i]); err != ErrBadMessageBytes {
+			t.Errorf("Expected error from truncated metadata at %v: %v", i, err)
+		}
+	}
+
+	if exp, act := m.Bytes(), BytesWithMetadata(NewMessage(m.GetAll())); !reflect.DeepEqual(exp, act) {
+		t.Error("Expected message without metadata to match Bytes")
+	}
+}
+
 func TestNewMessage(t *testing.T) {
 	m := NewMessage(nil)
 	if act := m.Len(); act > 0 {
@@ -111,6 +194,47 @@ func TestMessageJSONGet(t *testing.T) {
 	}
 }
 
+func TestMessageJSONGetCached(t *testing.T) {
+	msg := NewMessage(
+		[][]byte{[]byte(`{"foo":"bar"}`), []byte(`not json`)},
+	)
+
+	jObj1, err := msg.GetJSON(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jObj2, err := msg.GetJSON(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.ValueOf(jObj1).Pointer() != reflect.ValueOf(jObj2).Pointer() {
+		t.Error("Expected cached JSON object to be returned")
+	}
+
+	copied := msg.ShallowCopy()
+	jObj3, err := copied.GetJSON(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.ValueOf(jObj1).Pointer() == reflect.ValueOf(jObj3).Pointer() {
+		t.Error("Expected shallow copy not to share the JSON cache")
+	}
+
+	msg.Set(0, []byte(`{"foo":"baz"}`))
+	if jObj2, err = msg.GetJSON(0); err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := map[string]interface{}{"foo": "baz"}, jObj2; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong JSON after set: %v != %v", act, exp)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err = msg.GetJSON(1); err == nil {
+			t.Error("Expected error from invalid JSON")
+		}
+	}
+}
+
 func TestMessageJSONSet(t *testing.T) {
 	msg := messageImpl{
 		parts: [][]byte{[]byte(`hello world`)},
@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package types
+
+import "sort"
+
+//------------------------------------------------------------------------------
+
+// Metadata keys that inputs use in order to identify the origin of a message
+// part. The source ID identifies a stream of messages, such as a partition of a
+// topic, and the offset identifies a message within that stream. Together they
+// remain stable when a message is delivered more than once.
+const (
+	SourceIDKey     = "source_id"
+	SourceOffsetKey = "source_offset"
+)
+
+//------------------------------------------------------------------------------
+
+// Metadata is an interface representing the metadata of a message part. Each
+// message part has its own metadata, which is a map of string keys to string
+// values.
+type Metadata interface {
+	// Get returns a metadata value if a key exists, otherwise an empty string.
+	Get(key string) string
+
+	// Set sets the value of a metadata key.
+	Set(key, value string) Metadata
+
+	// Delete removes the value of a metadata key.
+	Delete(key string) Metadata
+
+	// Iter iterates each metadata key/value pair in order of keys, calling
+	// the closure argument for each. If the closure returns an error the
+	// iteration is stopped and the error is returned.
+	Iter(f func(k, v string) error) error
+
+	// Copy returns a copy of the metadata object that can be edited without
+	// changing the original.
+	Copy() Metadata
+}
+
+//------------------------------------------------------------------------------
+
+// NewMetadata returns a new and empty metadata object.
+func NewMetadata() Metadata {
+	return &metadataImpl{}
+}
+
+// metadataImpl is the default implementation of Metadata, which is a map of
+// keys to values.
+type metadataImpl struct {
+	m map[string]string
+}
+
+func (m *metadataImpl) Get(key string) string {
+	return m.m[key]
+}
+
+func (m *metadataImpl) Set(key, value string) Metadata {
+	if m.m == nil {
+		m.m = map[string]string{}
+	}
+	m.m[key] = value
+	return m
+}
+
+func (m *metadataImpl) Delete(key string) Metadata {
+	delete(m.m, key)
+	return m
+}
+
+func (m *metadataImpl) Iter(f func(k, v string) error) error {
+	keys := make([]string, 0, len(m.m))
+	for k := range m.m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := f(k, m.m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *metadataImpl) Copy() Metadata {
+	var newMap map[string]string
+	if len(m.m) > 0 {
+		newMap = make(map[string]string, len(m.m))
+		for k, v := range m.m {
+			newMap[k] = v
+		}
+	}
+	return &metadataImpl{m: newMap}
+}
+
+//------------------------------------------------------------------------------
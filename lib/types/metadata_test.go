@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package types
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMetadataBasic(t *testing.T) {
+	md := NewMetadata()
+	md.Set("foo", "foo1").Set("bar", "bar1").Set("baz", "baz1")
+
+	if exp, act := "foo1", md.Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "", md.Get("nope"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	md.Delete("bar")
+	if exp, act := "", md.Get("bar"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	var keys []string
+	if err := md.Iter(func(k, v string) error {
+		keys = append(keys, k+":"+v)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	if exp, act := []string{"baz:baz1", "foo:foo1"}, keys; !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong iterated values: %v != %v", act, exp)
+	}
+
+	errTest := errors.New("test err")
+	if err := md.Iter(func(k, v string) error {
+		return errTest
+	}); err != errTest {
+		t.Errorf("Wrong error returned: %v != %v", err, errTest)
+	}
+}
+
+func TestMetadataCopy(t *testing.T) {
+	md := NewMetadata().Set("foo", "foo1")
+	mdCopy := md.Copy()
+	mdCopy.Set("foo", "foo2").Set("bar", "bar2")
+
+	if exp, act := "foo1", md.Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "", md.Get("bar"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "foo2", mdCopy.Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+}
+
+func TestMessageMetadata(t *testing.T) {
+	msg := NewMessage([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+	})
+
+	msg.GetMetadata(0).Set("foo", "foo1")
+	msg.GetMetadata(-1).Set("foo", "foo2")
+	msg.GetMetadata(5).Set("foo", "foo3")
+
+	if exp, act := "foo1", msg.GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "foo2", msg.GetMetadata(1).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "", msg.GetMetadata(5).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	msg.Append([]byte("baz"))
+	if exp, act := "", msg.GetMetadata(2).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	msg.SetMetadata(NewMetadata().Set("bar", "bar1"), 0, -1, 10)
+	if exp, act := "", msg.GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "bar1", msg.GetMetadata(0).Get("bar"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "foo2", msg.GetMetadata(1).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "bar1", msg.GetMetadata(2).Get("bar"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	msg.GetMetadata(0).Set("bar", "bar2")
+	if exp, act := "bar1", msg.GetMetadata(2).Get("bar"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	msg.SetMetadata(NewMetadata().Set("baz", "baz1"))
+	for i := 0; i < msg.Len(); i++ {
+		if exp, act := "baz1", msg.GetMetadata(i).Get("baz"); exp != act {
+			t.Errorf("Wrong value: %v != %v", act, exp)
+		}
+		if exp, act := "", msg.GetMetadata(i).Get("bar"); exp != act {
+			t.Errorf("Wrong value: %v != %v", act, exp)
+		}
+	}
+
+	msg.SetAll([][]byte{[]byte("new")})
+	if exp, act := "", msg.GetMetadata(0).Get("baz"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+}
+
+func TestMessageMetadataCopies(t *testing.T) {
+	msg := NewMessage([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+	})
+	msg.GetMetadata(0).Set("foo", "foo1")
+
+	shallow := msg.ShallowCopy()
+	deep := msg.DeepCopy()
+	part := ExtractPart(msg, 0)
+
+	for _, m := range []Message{shallow, deep, part} {
+		if exp, act := "foo1", m.GetMetadata(0).Get("foo"); exp != act {
+			t.Errorf("Wrong value: %v != %v", act, exp)
+		}
+		m.GetMetadata(0).Set("foo", "changed")
+	}
+
+	if exp, act := "foo1", msg.GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+}
+
+func TestMessageSetNilMetadata(t *testing.T) {
+	msg := NewMessage([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+	})
+	msg.GetMetadata(0).Set("foo", "foo1")
+	msg.GetMetadata(1).Set("foo", "foo2")
+
+	msg.SetMetadata(nil, 0)
+	if exp, act := "", msg.GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+	if exp, act := "foo2", msg.GetMetadata(1).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	msg.SetMetadata(nil)
+	if exp, act := "", msg.GetMetadata(1).Get("foo"); exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+}
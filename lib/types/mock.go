@@ -42,3 +42,21 @@ func (f DudMgr) GetCache(name string) (Cache, error) {
 func (f DudMgr) GetCondition(name string) (Condition, error) {
 	return nil, ErrConditionNotFound
 }
+
+// GetRateLimit always returns ErrRateLimitNotFound.
+func (f DudMgr) GetRateLimit(name string) (RateLimit, error) {
+	return nil, ErrRateLimitNotFound
+}
+
+// GetPipe always returns ErrPipeNotFound.
+func (f DudMgr) GetPipe(name string) (<-chan Transaction, error) {
+	return nil, ErrPipeNotFound
+}
+
+// SetPipe is a noop.
+func (f DudMgr) SetPipe(name string, t <-chan Transaction) {
+}
+
+// UnsetPipe is a noop.
+func (f DudMgr) UnsetPipe(name string, t <-chan Transaction) {
+}
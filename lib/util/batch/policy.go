@@ -0,0 +1,227 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor/condition"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// PolicyConfig contains configuration parameters for a batch policy.
+type PolicyConfig struct {
+	Count     int              `json:"count" yaml:"count"`
+	ByteSize  int              `json:"byte_size" yaml:"byte_size"`
+	PeriodMS  int              `json:"period_ms" yaml:"period_ms"`
+	Condition condition.Config `json:"condition" yaml:"condition"`
+}
+
+// NewPolicyConfig creates a default PolicyConfig, where batching is disabled.
+func NewPolicyConfig() PolicyConfig {
+	cond := condition.NewConfig()
+	cond.Type = "static"
+	cond.Static = false
+	return PolicyConfig{
+		Count:     0,
+		ByteSize:  0,
+		PeriodMS:  0,
+		Condition: cond,
+	}
+}
+
+// IsNoop returns true if this batch policy configuration does nothing, in
+// which case each message is flushed as soon as it is added.
+func (p PolicyConfig) IsNoop() bool {
+	if p.Count > 1 || p.ByteSize > 0 || p.PeriodMS > 0 {
+		return false
+	}
+	if p.Condition.Type != "static" {
+		return false
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+// Policy implements a batching policy by buffering message parts until one of
+// its triggers fires: a count of parts, a total size in bytes, a period of time
+// since the first part of a batch was added, or a condition resolving to true
+// for an added part.
+type Policy struct {
+	noop     bool
+	byteSize int
+	count    int64
+	maxCount int
+	period   time.Duration
+	cond     condition.Type
+
+	sizeTally int
+	parts     [][]byte
+	metadata  []types.Metadata
+
+	triggered  bool
+	firstAdded time.Time
+
+	mSizeBatch   metrics.StatCounter
+	mCountBatch  metrics.StatCounter
+	mPeriodBatch metrics.StatCounter
+	mCondBatch   metrics.StatCounter
+}
+
+// NewPolicy creates a new batch policy.
+func NewPolicy(
+	conf PolicyConfig,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (*Policy, error) {
+	cond, err := condition.New(conf.Condition, mgr, log.NewModule(".batch.condition"), stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch condition: %v", err)
+	}
+	return &Policy{
+		noop:     conf.IsNoop(),
+		byteSize: conf.ByteSize,
+		count:    int64(conf.Count),
+		maxCount: conf.Count,
+		period:   time.Duration(conf.PeriodMS) * time.Millisecond,
+		cond:     cond,
+
+		mSizeBatch:   stats.GetCounter("batch.on_size"),
+		mCountBatch:  stats.GetCounter("batch.on_count"),
+		mPeriodBatch: stats.GetCounter("batch.on_period"),
+		mCondBatch:   stats.GetCounter("batch.on_condition"),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Add a new message part to this batch policy. Returns true if this part
+// triggers the conditions of the policy and the batch should be flushed.
+func (p *Policy) Add(part []byte, md types.Metadata) bool {
+	if len(p.parts) == 0 {
+		p.firstAdded = time.Now()
+	}
+
+	p.sizeTally += len(part)
+	p.parts = append(p.parts, part)
+	p.metadata = append(p.metadata, md)
+
+	if count := int(atomic.LoadInt64(&p.count)); !p.triggered && count > 0 && len(p.parts) >= count {
+		p.triggered = true
+		p.mCountBatch.Incr(1)
+	}
+	if !p.triggered && p.byteSize > 0 && p.sizeTally >= p.byteSize {
+		p.triggered = true
+		p.mSizeBatch.Incr(1)
+	}
+	if !p.triggered && p.period > 0 && time.Since(p.firstAdded) >= p.period {
+		p.triggered = true
+		p.mPeriodBatch.Incr(1)
+	}
+	if !p.triggered {
+		partMsg := types.NewMessage([][]byte{part})
+		if md != nil {
+			partMsg.SetMetadata(md)
+		}
+		if p.cond.Check(partMsg) {
+			p.triggered = true
+			p.mCondBatch.Incr(1)
+		}
+	}
+	return p.triggered || p.noop
+}
+
+// Flush clears all parts stored by this batch policy and returns them as a
+// single message. Returns nil if the policy is empty.
+func (p *Policy) Flush() types.Message {
+	if len(p.parts) == 0 {
+		return nil
+	}
+	if !p.triggered && p.period > 0 && time.Since(p.firstAdded) >= p.period {
+		p.mPeriodBatch.Incr(1)
+	}
+
+	newMsg := types.NewMessage(p.parts)
+	for i, md := range p.metadata {
+		if md != nil {
+			newMsg.SetMetadata(md, i)
+		}
+	}
+
+	p.parts = nil
+	p.metadata = nil
+	p.sizeTally = 0
+	p.triggered = false
+	return newMsg
+}
+
+// Count returns the number of message parts currently stored by this policy.
+func (p *Policy) Count() int {
+	return len(p.parts)
+}
+
+// CountLimit returns the current count of message parts that triggers a batch
+// along with the maximum, which is the configured count. A count of zero means
+// batches are not triggered by count.
+func (p *Policy) CountLimit() (limit, max int) {
+	return int(atomic.LoadInt64(&p.count)), p.maxCount
+}
+
+// SetCountLimit changes the count of message parts that triggers a batch, the
+// value is bounded between one and the configured count. This has no effect
+// when batches are not triggered by count. It is safe to call concurrently with
+// the other methods of the policy.
+func (p *Policy) SetCountLimit(limit int) {
+	if p.maxCount <= 0 {
+		return
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > p.maxCount {
+		limit = p.maxCount
+	}
+	atomic.StoreInt64(&p.count, int64(limit))
+}
+
+// UntilNext returns a duration indicating how long until the current batch
+// should be flushed due to its period. A negative duration indicates that a
+// period is not configured or that the policy is empty.
+func (p *Policy) UntilNext() time.Duration {
+	if p.period <= 0 || len(p.parts) == 0 {
+		return -1
+	}
+	until := p.period - time.Since(p.firstAdded)
+	if until < 0 {
+		return 0
+	}
+	return until
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+var logConfig = log.LoggerConfig{
+	LogLevel: "NONE",
+}
+
+func newTestPolicy(t *testing.T, conf PolicyConfig) *Policy {
+	pol, err := NewPolicy(conf, types.DudMgr{}, log.NewLogger(os.Stdout, logConfig), metrics.DudType{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pol
+}
+
+func TestPolicyNoop(t *testing.T) {
+	conf := NewPolicyConfig()
+	if !conf.IsNoop() {
+		t.Error("Expected default policy to be noop")
+	}
+
+	pol := newTestPolicy(t, conf)
+	if !pol.Add([]byte("foo"), nil) {
+		t.Error("Expected noop policy to trigger on every part")
+	}
+	if exp, act := [][]byte{[]byte("foo")}, pol.Flush().GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if pol.Flush() != nil {
+		t.Error("Expected nil flush from empty policy")
+	}
+}
+
+func TestPolicyCount(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Count = 3
+	if conf.IsNoop() {
+		t.Error("Expected policy not to be noop")
+	}
+
+	pol := newTestPolicy(t, conf)
+	for i, trigger := range []bool{false, false, true} {
+		if act := pol.Add([]byte("foo"), nil); act != trigger {
+			t.Errorf("Wrong trigger at %v: %v != %v", i, act, trigger)
+		}
+	}
+	if exp, act := 3, pol.Flush().Len(); exp != act {
+		t.Errorf("Wrong batch size: %v != %v", act, exp)
+	}
+	if exp, act := 0, pol.Count(); exp != act {
+		t.Errorf("Wrong count after flush: %v != %v", act, exp)
+	}
+}
+
+func TestPolicyCountLimit(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Count = 4
+
+	pol := newTestPolicy(t, conf)
+	pol.SetCountLimit(2)
+	if limit, max := pol.CountLimit(); limit != 2 || max != 4 {
+		t.Errorf("Wrong count limit: %v, %v != 2, 4", limit, max)
+	}
+	for i, trigger := range []bool{false, true} {
+		if act := pol.Add([]byte("foo"), nil); act != trigger {
+			t.Errorf("Wrong trigger at %v: %v != %v", i, act, trigger)
+		}
+	}
+	pol.Flush()
+
+	pol.SetCountLimit(10)
+	if limit, _ := pol.CountLimit(); limit != 4 {
+		t.Errorf("Wrong bounded count limit: %v != 4", limit)
+	}
+	pol.SetCountLimit(0)
+	if limit, _ := pol.CountLimit(); limit != 1 {
+		t.Errorf("Wrong bounded count limit: %v != 1", limit)
+	}
+
+	conf = NewPolicyConfig()
+	conf.ByteSize = 10
+	pol = newTestPolicy(t, conf)
+	pol.SetCountLimit(2)
+	if limit, max := pol.CountLimit(); limit != 0 || max != 0 {
+		t.Errorf("Count limit set on policy without count: %v, %v", limit, max)
+	}
+}
+
+func TestPolicyByteSize(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.ByteSize = 10
+
+	pol := newTestPolicy(t, conf)
+	for i, trigger := range []bool{false, false, true} {
+		if act := pol.Add([]byte("hello"[:i+3]), nil); act != trigger {
+			t.Errorf("Wrong trigger at %v: %v != %v", i, act, trigger)
+		}
+	}
+}
+
+func TestPolicyCondition(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.Condition.Type = "content"
+	conf.Condition.Content.Operator = "equals"
+	conf.Condition.Content.Arg = "end"
+
+	pol := newTestPolicy(t, conf)
+	for i, part := range []string{"foo", "bar", "end"} {
+		md := types.NewMetadata().Set("index", part)
+		if exp, act := part == "end", pol.Add([]byte(part), md); act != exp {
+			t.Errorf("Wrong trigger at %v: %v != %v", i, act, exp)
+		}
+	}
+
+	msg := pol.Flush()
+	if exp, act := 3, msg.Len(); exp != act {
+		t.Fatalf("Wrong batch size: %v != %v", act, exp)
+	}
+	if exp, act := "bar", msg.GetMetadata(1).Get("index"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+}
+
+func TestPolicyPeriod(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.PeriodMS = 10
+
+	pol := newTestPolicy(t, conf)
+	if pol.UntilNext() >= 0 {
+		t.Error("Expected negative duration from empty policy")
+	}
+	if pol.Add([]byte("foo"), nil) {
+		t.Error("Unexpected trigger")
+	}
+	if until := pol.UntilNext(); until < 0 || until > time.Millisecond*10 {
+		t.Errorf("Unexpected duration: %v", until)
+	}
+
+	<-time.After(time.Millisecond * 20)
+	if exp, act := time.Duration(0), pol.UntilNext(); exp != act {
+		t.Errorf("Wrong duration: %v != %v", act, exp)
+	}
+	if !pol.Add([]byte("bar"), nil) {
+		t.Error("Expected trigger after period")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,268 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// OverrideFromEnv sets fields within a config struct from a list of environment
+// variables of the form `KEY=value`, where only keys beginning with the prefix
+// are used.
+//
+// The remainder of each key is matched against the fields of the config, where
+// each field name is upper cased and nested fields are separated by
+// underscores. For example, with the prefix `BENTHOS_` the variable
+// `BENTHOS_INPUT_KAFKA_ADDRESSES` sets the field `input.kafka.addresses`.
+// Numerical segments are treated as array indexes. Setting a field that
+// contains a `type` field (such as `BENTHOS_INPUT`) sets the type.
+//
+// An index equal to the length of an array appends a new element with default
+// values, which means processors can be added with variables such as
+// `BENTHOS_PIPELINE_PROCESSORS_0=jmespath` followed by
+// `BENTHOS_PIPELINE_PROCESSORS_0_JMESPATH_QUERY=foo`.
+//
+// Values are parsed as YAML, and variables that do not match a field of the
+// config are ignored.
+func OverrideFromEnv(config interface{}, prefix string, env []string) error {
+	configBytes, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	var root interface{}
+	if err = yaml.Unmarshal(configBytes, &root); err != nil {
+		return err
+	}
+
+	// Sorting ensures that parent fields and lower array indexes are set
+	// before their children.
+	sortedEnv := make([]string, len(env))
+	copy(sortedEnv, env)
+	sort.Slice(sortedEnv, func(i, j int) bool {
+		return lessEnvKey(envKey(sortedEnv[i]), envKey(sortedEnv[j]))
+	})
+
+	for _, kv := range sortedEnv {
+		if !strings.HasPrefix(kv, prefix) {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		key, value := kv[len(prefix):i], kv[i+1:]
+		if len(key) == 0 {
+			continue
+		}
+		tokens := strings.Split(key, "_")
+
+		var parsedValue interface{}
+		if parsedValue, err = parseValue(value); err != nil {
+			return fmt.Errorf("failed to set '%v': %v", kv[:i], err)
+		}
+
+		// Objects replace a field entirely, whereas other values set the type
+		// of fields that have one.
+		_, isObj := parsedValue.(map[interface{}]interface{})
+		setType := !isObj
+
+		working := root
+		path, matched, newElem := resolveEnvPath(working, tokens, setType)
+		for newElem != nil {
+			// The tokens index an array element that does not yet exist, so
+			// attempt the match against a copy with a default element added.
+			grown, gErr := addDefaultElement(config, working, newElem)
+			if gErr != nil {
+				break
+			}
+			gPath, gMatched, gElem := resolveEnvPath(grown, tokens, setType)
+			if !gMatched && gElem == nil {
+				break
+			}
+			working, path, matched, newElem = grown, gPath, gMatched, gElem
+		}
+		if !matched {
+			continue
+		}
+		root = working
+
+		if root, err = setPath(root, path, parsedValue); err != nil {
+			return fmt.Errorf("failed to set '%v': %v", kv[:i], err)
+		}
+	}
+
+	if configBytes, err = yaml.Marshal(root); err != nil {
+		return err
+	}
+	return yaml.Unmarshal(configBytes, config)
+}
+
+// addDefaultElement returns a copy of a generic structure with an array
+// element added at a path, where the element is populated with the default
+// values of the config type.
+func addDefaultElement(config, root interface{}, path []string) (interface{}, error) {
+	rootCopy, err := normaliseConfig(config, root)
+	if err != nil {
+		return nil, err
+	}
+	if rootCopy, err = setPath(rootCopy, path, map[interface{}]interface{}{}); err != nil {
+		return nil, err
+	}
+	return normaliseConfig(config, rootCopy)
+}
+
+// normaliseConfig returns a copy of a generic structure after decoding it into
+// a fresh value of the config type, which populates any missing fields with
+// their default values.
+func normaliseConfig(config, root interface{}) (interface{}, error) {
+	configBytes, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	typed := reflect.New(reflect.TypeOf(config).Elem()).Interface()
+	if err = yaml.Unmarshal(configBytes, typed); err != nil {
+		return nil, err
+	}
+	if configBytes, err = yaml.Marshal(typed); err != nil {
+		return nil, err
+	}
+	var normalised interface{}
+	if err = yaml.Unmarshal(configBytes, &normalised); err != nil {
+		return nil, err
+	}
+	return normalised, nil
+}
+
+// envKey returns the key of an environment variable of the form `KEY=value`.
+func envKey(kv string) string {
+	if i := strings.Index(kv, "="); i >= 0 {
+		return kv[:i]
+	}
+	return kv
+}
+
+// lessEnvKey compares two environment variable keys segment by segment, where
+// numerical segments are compared by value so that, for example, `FOO_2` is
+// ordered before `FOO_10`.
+func lessEnvKey(a, b string) bool {
+	aTokens, bTokens := strings.Split(a, "_"), strings.Split(b, "_")
+	for i := 0; i < len(aTokens) && i < len(bTokens); i++ {
+		if aTokens[i] == bTokens[i] {
+			continue
+		}
+		aIndex, aErr := strconv.Atoi(aTokens[i])
+		bIndex, bErr := strconv.Atoi(bTokens[i])
+		if aErr == nil && bErr == nil {
+			return aIndex < bIndex
+		}
+		return aTokens[i] < bTokens[i]
+	}
+	return len(aTokens) < len(bTokens)
+}
+
+// resolveEnvPath attempts to match a list of upper cased tokens against the
+// fields of a generic structure, returning the matched path. When the tokens
+// index an array element that does not yet exist the path of that element is
+// also returned as newElem. When setType is true a path ending at a field that
+// contains a `type` field resolves to the `type` field.
+func resolveEnvPath(target interface{}, tokens []string, setType bool) (path []string, matched bool, newElem []string) {
+	if len(tokens) == 0 {
+		if m, isMap := target.(map[interface{}]interface{}); isMap && setType {
+			if _, hasType := m["type"]; hasType {
+				return []string{"type"}, true, nil
+			}
+		}
+		return nil, true, nil
+	}
+
+	switch t := target.(type) {
+	case map[interface{}]interface{}:
+		keys := []string{}
+		for k := range t {
+			if kStr, isStr := k.(string); isStr {
+				keys = append(keys, kStr)
+			}
+		}
+		// Try keys with the most segments first so that, for example,
+		// `kafka_balanced` is preferred over `kafka`.
+		sort.Slice(keys, func(i, j int) bool {
+			iLen, jLen := strings.Count(keys[i], "_"), strings.Count(keys[j], "_")
+			if iLen == jLen {
+				return keys[i] < keys[j]
+			}
+			return iLen > jLen
+		})
+		for _, k := range keys {
+			kTokens := strings.Split(strings.ToUpper(k), "_")
+			if !hasTokenPrefix(tokens, kTokens) {
+				continue
+			}
+			childPath, childMatched, childElem := resolveEnvPath(t[k], tokens[len(kTokens):], setType)
+			if childElem != nil {
+				childElem = append([]string{k}, childElem...)
+			}
+			if childMatched {
+				return append([]string{k}, childPath...), true, childElem
+			}
+			if newElem == nil {
+				newElem = childElem
+			}
+		}
+	case []interface{}:
+		index, err := strconv.Atoi(tokens[0])
+		if err != nil || index < 0 || index > len(t) {
+			return nil, false, nil
+		}
+		if index == len(t) {
+			return []string{tokens[0]}, len(tokens) == 1, []string{tokens[0]}
+		}
+		childPath, childMatched, childElem := resolveEnvPath(t[index], tokens[1:], setType)
+		if childElem != nil {
+			newElem = append([]string{tokens[0]}, childElem...)
+		}
+		if childMatched {
+			return append([]string{tokens[0]}, childPath...), true, newElem
+		}
+	}
+	return nil, false, newElem
+}
+
+func hasTokenPrefix(tokens, prefix []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i, p := range prefix {
+		if tokens[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
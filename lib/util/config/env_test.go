@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+type testEnvInput struct {
+	Type          string            `yaml:"type"`
+	Kafka         testEnvKafka      `yaml:"kafka"`
+	KafkaBalanced testEnvKafka      `yaml:"kafka_balanced"`
+	Processors    []testEnvInputRef `yaml:"processors"`
+}
+
+type testEnvKafka struct {
+	Addresses     []string `yaml:"addresses"`
+	ConsumerGroup string   `yaml:"consumer_group"`
+	MaxBatchCount int      `yaml:"max_batch_count"`
+}
+
+type testEnvInputRef struct {
+	Type string `yaml:"type"`
+	Name string `yaml:"name"`
+}
+
+type testEnvConfig struct {
+	Input testEnvInput `yaml:"input"`
+}
+
+func TestOverrideFromEnv(t *testing.T) {
+	conf := testEnvConfig{
+		Input: testEnvInput{
+			Type: "stdin",
+			Processors: []testEnvInputRef{
+				{Type: "noop"},
+			},
+		},
+	}
+
+	env := []string{
+		"BENTHOS_INPUT=kafka_balanced",
+		"BENTHOS_INPUT_KAFKA_BALANCED_ADDRESSES=[foo:9092,bar:9092]",
+		"BENTHOS_INPUT_KAFKA_BALANCED_CONSUMER_GROUP=baz",
+		"BENTHOS_INPUT_KAFKA_MAX_BATCH_COUNT=10",
+		"BENTHOS_INPUT_PROCESSORS_0_NAME=first",
+		"BENTHOS_INPUT_PROCESSORS_1={type: jmespath, name: second}",
+		"BENTHOS_INPUT_DOES_NOT_EXIST=nope",
+		"BENTHOS_INPUTS=2",
+		"OTHER_INPUT=ignored",
+	}
+	if err := OverrideFromEnv(&conf, "BENTHOS_", env); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := testEnvConfig{
+		Input: testEnvInput{
+			Type: "kafka_balanced",
+			Kafka: testEnvKafka{
+				Addresses:     []string{},
+				MaxBatchCount: 10,
+			},
+			KafkaBalanced: testEnvKafka{
+				Addresses:     []string{"foo:9092", "bar:9092"},
+				ConsumerGroup: "baz",
+			},
+			Processors: []testEnvInputRef{
+				{Type: "noop", Name: "first"},
+				{Type: "jmespath", Name: "second"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, conf) {
+		t.Errorf("Wrong result: %+v != %+v", conf, exp)
+	}
+}
+
+func TestOverrideFromEnvArrayOrder(t *testing.T) {
+	conf := testEnvConfig{}
+
+	env := []string{}
+	for i := 11; i >= 0; i-- {
+		env = append(env, fmt.Sprintf("BENTHOS_INPUT_PROCESSORS_%v_NAME=%v", i, i))
+	}
+	if err := OverrideFromEnv(&conf, "BENTHOS_", env); err != nil {
+		t.Fatal(err)
+	}
+
+	if exp, act := 12, len(conf.Input.Processors); exp != act {
+		t.Fatalf("Wrong count of processors: %v != %v", act, exp)
+	}
+	for i, p := range conf.Input.Processors {
+		if exp, act := strconv.Itoa(i), p.Name; exp != act {
+			t.Errorf("Wrong processor name at index %v: %v != %v", i, act, exp)
+		}
+	}
+}
+
+func TestOverrideFromEnvNewElements(t *testing.T) {
+	conf := testEnvConfig{}
+
+	env := []string{
+		"BENTHOS_INPUT_PROCESSORS_0=jmespath",
+		"BENTHOS_INPUT_PROCESSORS_0_NAME=first",
+		"BENTHOS_INPUT_PROCESSORS_1_NAME=second",
+		"BENTHOS_INPUT_KAFKA_ADDRESSES_0=foo:9092",
+		"BENTHOS_INPUT_PROCESSORS_3_NAME=out_of_bounds",
+		"BENTHOS_INPUT_PROCESSORS_2_DOES_NOT_EXIST=nope",
+	}
+	if err := OverrideFromEnv(&conf, "BENTHOS_", env); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := testEnvConfig{
+		Input: testEnvInput{
+			Kafka: testEnvKafka{
+				Addresses: []string{"foo:9092"},
+			},
+			KafkaBalanced: testEnvKafka{
+				Addresses: []string{},
+			},
+			Processors: []testEnvInputRef{
+				{Type: "jmespath", Name: "first"},
+				{Name: "second"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(exp, conf) {
+		t.Errorf("Wrong result: %+v != %+v", conf, exp)
+	}
+}
+
+func TestOverrideFromEnvBadValue(t *testing.T) {
+	conf := testEnvConfig{}
+	if err := OverrideFromEnv(&conf, "BENTHOS_", []string{
+		"BENTHOS_INPUT_TYPE=[foo",
+	}); err == nil {
+		t.Error("Expected error from bad value")
+	}
+}
+
+//------------------------------------------------------------------------------
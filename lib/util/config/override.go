@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//------------------------------------------------------------------------------
+
+// ParseOverride splits an override string of the form `path.to.field=value`
+// into its path and value.
+func ParseOverride(override string) (path, value string, err error) {
+	i := strings.Index(override, "=")
+	if i <= 0 {
+		return "", "", fmt.Errorf("expected override of the form path.to.field=value, got: %v", override)
+	}
+	return override[:i], override[i+1:], nil
+}
+
+// Override sets a field within a config struct, identified by a dot separated
+// path, to a value. The value is parsed as YAML, which means numbers, booleans
+// and lists (e.g. `[foo,bar]`) are supported. Numerical path segments are
+// treated as array indexes, where an index equal to the length of an array
+// appends a new element. An error is returned if the path does not identify a
+// field of the config struct.
+func Override(config interface{}, path, value string) error {
+	if len(path) == 0 {
+		return errors.New("override path must not be empty")
+	}
+
+	configBytes, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	var root interface{}
+	if err = yaml.Unmarshal(configBytes, &root); err != nil {
+		return err
+	}
+
+	var parsedValue interface{}
+	if parsedValue, err = parseValue(value); err != nil {
+		return err
+	}
+
+	if root, err = setPath(root, strings.Split(path, "."), parsedValue); err != nil {
+		return fmt.Errorf("failed to set '%v': %v", path, err)
+	}
+
+	if configBytes, err = yaml.Marshal(root); err != nil {
+		return err
+	}
+
+	// Decode strictly into a fresh value so that paths which do not identify a
+	// field are rejected, and only apply the result when it is valid.
+	configValue := reflect.ValueOf(config)
+	if configValue.Kind() != reflect.Ptr || configValue.IsNil() {
+		return fmt.Errorf("expected a non-nil pointer to a config, got: %T", config)
+	}
+	result := reflect.New(configValue.Elem().Type())
+	if err = yaml.UnmarshalStrict(configBytes, result.Interface()); err != nil {
+		return fmt.Errorf("failed to set '%v': %v", path, err)
+	}
+	configValue.Elem().Set(result.Elem())
+	return nil
+}
+
+// parseValue parses a string value as YAML.
+func parseValue(value string) (interface{}, error) {
+	var parsedValue interface{} = value
+	if len(value) > 0 {
+		if err := yaml.Unmarshal([]byte(value), &parsedValue); err != nil {
+			return nil, fmt.Errorf("failed to parse value '%v': %v", value, err)
+		}
+	}
+	return parsedValue, nil
+}
+
+// setPath sets a value within a generic structure at the path provided and
+// returns the (potentially new) structure.
+func setPath(target interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	key := path[0]
+
+	switch t := target.(type) {
+	case nil:
+		child, err := setPath(nil, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return map[interface{}]interface{}{key: child}, nil
+	case map[interface{}]interface{}:
+		child, err := setPath(t[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		t[key] = child
+		return t, nil
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("expected array index, got: %v", key)
+		}
+		if index < 0 || index > len(t) {
+			return nil, fmt.Errorf("array index out of bounds: %v", index)
+		}
+		if index == len(t) {
+			t = append(t, nil)
+		}
+		if t[index], err = setPath(t[index], path[1:], value); err != nil {
+			return nil, err
+		}
+		return t, nil
+	}
+	return nil, fmt.Errorf("field '%v' cannot be set on a value of type %T", key, target)
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+type testOverrideChild struct {
+	Name string   `yaml:"name"`
+	Tags []string `yaml:"tags"`
+}
+
+type testOverrideConfig struct {
+	Count    int                 `yaml:"count"`
+	Enabled  bool                `yaml:"enabled"`
+	Child    testOverrideChild   `yaml:"child"`
+	Children []testOverrideChild `yaml:"children"`
+}
+
+func TestParseOverride(t *testing.T) {
+	path, value, err := ParseOverride("foo.bar=baz=qux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp, act := "foo.bar", path; exp != act {
+		t.Errorf("Wrong path: %v != %v", act, exp)
+	}
+	if exp, act := "baz=qux", value; exp != act {
+		t.Errorf("Wrong value: %v != %v", act, exp)
+	}
+
+	for _, bad := range []string{"foo", "=bar", ""} {
+		if _, _, err = ParseOverride(bad); err == nil {
+			t.Errorf("Expected error from override: %v", bad)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	conf := testOverrideConfig{
+		Count: 1,
+		Child: testOverrideChild{
+			Name: "foo",
+			Tags: []string{"a"},
+		},
+		Children: []testOverrideChild{
+			{Name: "bar", Tags: []string{"x"}},
+		},
+	}
+
+	overrides := [][2]string{
+		{"count", "5"},
+		{"enabled", "true"},
+		{"child.tags", "[b,c]"},
+		{"children.0.name", "baz"},
+		{"children.1", "{name: qux, tags: [z]}"},
+	}
+	for _, o := range overrides {
+		if err := Override(&conf, o[0], o[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exp := testOverrideConfig{
+		Count:   5,
+		Enabled: true,
+		Child: testOverrideChild{
+			Name: "foo",
+			Tags: []string{"b", "c"},
+		},
+		Children: []testOverrideChild{
+			{Name: "baz", Tags: []string{"x"}},
+			{Name: "qux", Tags: []string{"z"}},
+		},
+	}
+	if !reflect.DeepEqual(exp, conf) {
+		t.Errorf("Wrong result: %+v != %+v", conf, exp)
+	}
+}
+
+func TestOverrideErrors(t *testing.T) {
+	conf := testOverrideConfig{}
+
+	if err := Override(&conf, "children.5.name", "foo"); err == nil {
+		t.Error("Expected error from out of bounds index")
+	}
+	if err := Override(&conf, "count.foo", "bar"); err == nil {
+		t.Error("Expected error from setting field of scalar")
+	}
+	if err := Override(&conf, "chlid.name", "bar"); err == nil {
+		t.Error("Expected error from unknown field")
+	}
+	if err := Override(&conf, "children.0.nmae", "bar"); err == nil {
+		t.Error("Expected error from unknown array element field")
+	}
+	if err := Override(&conf, "", "bar"); err == nil {
+		t.Error("Expected error from empty path")
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, sub to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// ComponentSpec describes a registered component type along with the default
+// values of its config fields.
+type ComponentSpec struct {
+	Name        string      `json:"name" yaml:"name"`
+	Description string      `json:"description" yaml:"description"`
+	Fields      interface{} `json:"fields" yaml:"fields"`
+}
+
+// NewComponentSpecs creates a list of component specs, ordered by name, from a
+// map of type names to their descriptions and a config populated with default
+// values. The fields of each type are taken from the section of the config
+// keyed by the type name.
+func NewComponentSpecs(descriptions map[string]string, defaultConf interface{}) []ComponentSpec {
+	sections := map[string]interface{}{}
+	if confBytes, err := json.Marshal(defaultConf); err == nil {
+		json.Unmarshal(confBytes, &sections)
+	}
+
+	names := []string{}
+	for name := range descriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	specs := make([]ComponentSpec, len(names))
+	for i, name := range names {
+		specs[i] = ComponentSpec{
+			Name:        name,
+			Description: strings.TrimSpace(descriptions[name]),
+			Fields:      sections[name],
+		}
+	}
+	return specs
+}
+
+//------------------------------------------------------------------------------
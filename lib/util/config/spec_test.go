@@ -0,0 +1,63 @@
+// Copyright (c) 2017 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, sub to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestNewComponentSpecs(t *testing.T) {
+	type fooConfig struct {
+		Bar string `json:"bar"`
+	}
+	conf := struct {
+		Type string    `json:"type"`
+		Foo  fooConfig `json:"foo"`
+	}{
+		Type: "foo",
+		Foo:  fooConfig{Bar: "baz"},
+	}
+
+	specs := NewComponentSpecs(map[string]string{
+		"foo": "\nFoo description.\n",
+		"bar": "Bar description.",
+	}, conf)
+
+	exp := []ComponentSpec{
+		{
+			Name:        "bar",
+			Description: "Bar description.",
+		},
+		{
+			Name:        "foo",
+			Description: "Foo description.",
+			Fields:      map[string]interface{}{"bar": "baz"},
+		},
+	}
+	if !reflect.DeepEqual(exp, specs) {
+		t.Errorf("Wrong specs: %+v != %+v", specs, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
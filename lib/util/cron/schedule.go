@@ -0,0 +1,225 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// Schedule is a parsed cron expression.
+type Schedule interface {
+	// Next returns the first time after t at which the schedule activates, or
+	// a zero time if the schedule never activates again.
+	Next(t time.Time) time.Time
+}
+
+// Parse parses a cron expression into a schedule. Expressions consist of five
+// space separated fields: minute, hour, day of month, month and day of week.
+// Each field is either a wildcard (*), a value, a range (a-b), or a list of
+// these separated by commas, and may be followed by a step (/n).
+//
+// The descriptors @yearly, @monthly, @weekly, @daily and @hourly are also
+// supported, as is @every <duration> for schedules that activate at a fixed
+// interval, where the duration is parsed with time.ParseDuration.
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(expr[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse interval: %v", err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("interval must be positive: %v", d)
+		}
+		return intervalSchedule(d), nil
+	}
+	if desc, exists := descriptors[expr]; exists {
+		expr = desc
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected five fields, found %v: %v", len(fields), expr)
+	}
+
+	s := &fieldSchedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], bounds{0, 59}); err != nil {
+		return nil, fmt.Errorf("failed to parse minute field: %v", err)
+	}
+	if s.hour, err = parseField(fields[1], bounds{0, 23}); err != nil {
+		return nil, fmt.Errorf("failed to parse hour field: %v", err)
+	}
+	if s.dom, err = parseField(fields[2], bounds{1, 31}); err != nil {
+		return nil, fmt.Errorf("failed to parse day of month field: %v", err)
+	}
+	if s.month, err = parseField(fields[3], bounds{1, 12}); err != nil {
+		return nil, fmt.Errorf("failed to parse month field: %v", err)
+	}
+	if s.dow, err = parseField(fields[4], bounds{0, 7}); err != nil {
+		return nil, fmt.Errorf("failed to parse day of week field: %v", err)
+	}
+
+	// Both zero and seven represent Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+//------------------------------------------------------------------------------
+
+type bounds struct {
+	min, max int
+}
+
+// parseField parses a single field of a cron expression into a bit set of the
+// values that it matches.
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step: %v", item[i+1:])
+			}
+			item = item[:i]
+		}
+
+		start, end := b.min, b.max
+		switch {
+		case item == "*":
+		case strings.Contains(item, "-"):
+			parts := strings.SplitN(item, "-", 2)
+			var err error
+			if start, err = parseValue(parts[0], b); err != nil {
+				return 0, err
+			}
+			if end, err = parseValue(parts[1], b); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range: %v", item)
+			}
+		default:
+			var err error
+			if start, err = parseValue(item, b); err != nil {
+				return 0, err
+			}
+			// A single value with a step, such as 5/15, runs to the maximum.
+			if step == 1 {
+				end = start
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(str string, b bounds) (int, error) {
+	v, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %v", str)
+	}
+	if v < b.min || v > b.max {
+		return 0, fmt.Errorf("value %v out of range [%v, %v]", v, b.min, b.max)
+	}
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
+
+// fieldSchedule is a schedule parsed from the five fields of a cron
+// expression, each represented as a bit set of matching values.
+type fieldSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	domAny, dowAny bool
+}
+
+// dayMatches follows the convention of cron where, if both the day of month
+// and day of week fields are restricted, a day matches when either does.
+func (s *fieldSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (s *fieldSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	yearLimit := t.Year() + 5
+
+	for t.Year() <= yearLimit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+//------------------------------------------------------------------------------
+
+// intervalSchedule is a schedule that activates at a fixed interval.
+type intervalSchedule time.Duration
+
+func (i intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func TestScheduleNext(t *testing.T) {
+	from := time.Date(2018, time.June, 14, 10, 22, 31, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		exp  time.Time
+	}{
+		{"* * * * *", time.Date(2018, time.June, 14, 10, 23, 0, 0, time.UTC)},
+		{"30 * * * *", time.Date(2018, time.June, 14, 10, 30, 0, 0, time.UTC)},
+		{"15 * * * *", time.Date(2018, time.June, 14, 11, 15, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2018, time.June, 14, 10, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2018, time.June, 14, 10, 25, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2018, time.June, 14, 13, 0, 0, 0, time.UTC)},
+		{"0,45 8,22 * * *", time.Date(2018, time.June, 14, 22, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2018, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2018, time.June, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2018, time.June, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 20 * 1", time.Date(2018, time.June, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2018, time.June, 14, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2018, time.June, 15, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2018, time.June, 14, 10, 24, 1, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		s, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Failed to parse '%v': %v", test.expr, err)
+			continue
+		}
+		if act := s.Next(from); !act.Equal(test.exp) {
+			t.Errorf("Wrong next time for '%v': %v != %v", test.expr, act, test.exp)
+		}
+	}
+}
+
+func TestScheduleNever(t *testing.T) {
+	s, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := s.Next(time.Now()); !act.IsZero() {
+		t.Errorf("Expected zero time, received: %v", act)
+	}
+}
+
+func TestScheduleParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@every nope",
+		"@every -1s",
+		"@sometimes",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Expected error from '%v'", expr)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// OAuth2Config holds the configuration parameters for acquiring access tokens
+// with the OAuth2 client credentials flow.
+type OAuth2Config struct {
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+	ClientKey    string   `json:"client_key" yaml:"client_key"`
+	ClientSecret string   `json:"client_secret" yaml:"client_secret"`
+	TokenURL     string   `json:"token_url" yaml:"token_url"`
+	Scopes       []string `json:"scopes" yaml:"scopes"`
+	TimeoutMS    int64    `json:"timeout_ms" yaml:"timeout_ms"`
+}
+
+// NewOAuth2Config returns a new OAuth2Config with default values.
+func NewOAuth2Config() OAuth2Config {
+	return OAuth2Config{
+		Enabled:      false,
+		ClientKey:    "",
+		ClientSecret: "",
+		TokenURL:     "",
+		Scopes:       []string{},
+		TimeoutMS:    5000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// oauth2ExpiryDelta is how long before the reported expiry of a token that it
+// is considered expired, in order to avoid using a token that expires in
+// flight.
+const oauth2ExpiryDelta = time.Second * 10
+
+type oauth2Token struct {
+	accessToken string
+	tokenType   string
+	expiry      time.Time
+}
+
+func (t *oauth2Token) valid() bool {
+	if t == nil || len(t.accessToken) == 0 {
+		return false
+	}
+	if t.expiry.IsZero() {
+		return true
+	}
+	return time.Now().Add(oauth2ExpiryDelta).Before(t.expiry)
+}
+
+// Tokens are cached by their credentials and shared across all copies of a
+// config, as configs are passed around by value.
+var (
+	oauth2Tokens   = map[string]*oauth2Token{}
+	oauth2TokensMu sync.Mutex
+)
+
+func (oauth OAuth2Config) cacheKey() string {
+	return strings.Join([]string{
+		oauth.TokenURL,
+		oauth.ClientKey,
+		oauth.ClientSecret,
+		strings.Join(oauth.Scopes, " "),
+	}, "\x00")
+}
+
+//------------------------------------------------------------------------------
+
+// Sign method to sign an HTTP request with an OAuth2 access token, a new token
+// is requested when no token has yet been acquired or the current one has
+// expired.
+func (oauth OAuth2Config) Sign(req *http.Request) error {
+	if !oauth.Enabled {
+		return nil
+	}
+
+	key := oauth.cacheKey()
+
+	oauth2TokensMu.Lock()
+	defer oauth2TokensMu.Unlock()
+
+	token := oauth2Tokens[key]
+	if !token.valid() {
+		var err error
+		if token, err = oauth.requestToken(); err != nil {
+			return fmt.Errorf("failed to acquire oauth2 token: %v", err)
+		}
+		oauth2Tokens[key] = token
+	}
+
+	tokenType := token.tokenType
+	if len(tokenType) == 0 || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	req.Header.Set("Authorization", tokenType+" "+token.accessToken)
+	return nil
+}
+
+// requestToken performs a client credentials grant against the token URL.
+func (oauth OAuth2Config) requestToken() (*oauth2Token, error) {
+	if len(oauth.TokenURL) == 0 {
+		return nil, errors.New("token_url must not be empty")
+	}
+
+	params := url.Values{}
+	params.Set("grant_type", "client_credentials")
+	if len(oauth.Scopes) > 0 {
+		params.Set("scope", strings.Join(oauth.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(
+		"POST", oauth.TokenURL, strings.NewReader(params.Encode()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(oauth.ClientKey), url.QueryEscape(oauth.ClientSecret))
+
+	client := http.Client{
+		Timeout: time.Duration(oauth.TimeoutMS) * time.Millisecond,
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status %v: %s", res.Status, body)
+	}
+
+	var tRes struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err = json.Unmarshal(body, &tRes); err != nil {
+		return nil, fmt.Errorf("failed to parse token response: %v", err)
+	}
+	if len(tRes.AccessToken) == 0 {
+		return nil, errors.New("token response did not contain an access_token")
+	}
+
+	token := &oauth2Token{
+		accessToken: tRes.AccessToken,
+		tokenType:   tRes.TokenType,
+	}
+	if tRes.ExpiresIn > 0 {
+		token.expiry = time.Now().Add(time.Duration(tRes.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	var reqCount int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&reqCount, 1)
+		if user, pass, _ := r.BasicAuth(); user != "foo" || pass != "bar" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		if exp, act := "client_credentials", r.PostForm.Get("grant_type"); exp != act {
+			t.Errorf("Wrong grant type: %v != %v", act, exp)
+		}
+		if exp, act := "read write", r.PostForm.Get("scope"); exp != act {
+			t.Errorf("Wrong scope: %v != %v", act, exp)
+		}
+		// The first token expires within the expiry delta and is therefore
+		// refreshed on next use.
+		expiresIn := 3600
+		if n == 1 {
+			expiresIn = 1
+		}
+		fmt.Fprintf(w, `{"access_token":"token%v","token_type":"bearer","expires_in":%v}`, n, expiresIn)
+	}))
+	defer ts.Close()
+
+	conf := NewOAuth2Config()
+	conf.Enabled = true
+	conf.ClientKey = "foo"
+	conf.ClientSecret = "bar"
+	conf.TokenURL = ts.URL
+	conf.Scopes = []string{"read", "write"}
+
+	exp := []string{"Bearer token1", "Bearer token2", "Bearer token2"}
+	for i, e := range exp {
+		req, _ := http.NewRequest("GET", "http://localhost/", nil)
+		if err := conf.Sign(req); err != nil {
+			t.Fatal(err)
+		}
+		if act := req.Header.Get("Authorization"); e != act {
+			t.Errorf("Wrong auth header %v: %v != %v", i, act, e)
+		}
+	}
+	if exp, act := int32(2), atomic.LoadInt32(&reqCount); exp != act {
+		t.Errorf("Wrong count of token requests: %v != %v", act, exp)
+	}
+}
+
+func TestOAuth2BadCredentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	conf := NewOAuth2Config()
+	conf.Enabled = true
+	conf.TokenURL = ts.URL
+
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	if err := conf.Sign(req); err == nil {
+		t.Error("Expected error from rejected token request")
+	}
+}
+
+func TestOAuth2Disabled(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://localhost/", nil)
+	if err := NewOAuth2Config().Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if act := req.Header.Get("Authorization"); len(act) > 0 {
+		t.Errorf("Unexpected auth header: %v", act)
+	}
+}
+
+//------------------------------------------------------------------------------
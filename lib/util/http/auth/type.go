@@ -27,6 +27,7 @@ import "net/http"
 // Config contains configuration params for various HTTP auth strategies.
 type Config struct {
 	OAuth     OAuthConfig     `json:"oauth" yaml:"oauth"`
+	OAuth2    OAuth2Config    `json:"oauth2" yaml:"oauth2"`
 	BasicAuth BasicAuthConfig `json:"basic_auth" yaml:"basic_auth"`
 }
 
@@ -34,6 +35,7 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		OAuth:     NewOAuthConfig(),
+		OAuth2:    NewOAuth2Config(),
 		BasicAuth: NewBasicAuthConfig(),
 	}
 }
@@ -45,6 +47,9 @@ func (c Config) Sign(req *http.Request) error {
 	if err := c.OAuth.Sign(req); err != nil {
 		return err
 	}
+	if err := c.OAuth2.Sign(req); err != nil {
+		return err
+	}
 	return c.BasicAuth.Sign(req)
 }
 
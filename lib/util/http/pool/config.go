@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pool
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration params for the connection pool of an HTTP
+// client.
+type Config struct {
+	MaxIdleConns        int `json:"max_idle_conns" yaml:"max_idle_conns"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int `json:"max_conns_per_host" yaml:"max_conns_per_host"`
+	IdleConnTimeoutMS   int `json:"idle_conn_timeout_ms" yaml:"idle_conn_timeout_ms"`
+	KeepAliveMS         int `json:"tcp_keep_alive_ms" yaml:"tcp_keep_alive_ms"`
+}
+
+// NewConfig creates a new Config with default values, which match the defaults
+// of the Go standard library.
+func NewConfig() Config {
+	return Config{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: http.DefaultMaxIdleConnsPerHost,
+		MaxConnsPerHost:     0,
+		IdleConnTimeoutMS:   90000,
+		KeepAliveMS:         30000,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Transport creates an HTTP transport from the config by cloning
+// http.DefaultTransport and overriding only the connection pool fields, all
+// other fields retain the standard library defaults. A non-nil TLS config is
+// applied to the transport.
+func (c Config) Transport(tlsConf *tls.Config) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: time.Duration(c.KeepAliveMS) * time.Millisecond,
+	}).DialContext
+	tr.MaxIdleConns = c.MaxIdleConns
+	tr.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	tr.MaxConnsPerHost = c.MaxConnsPerHost
+	tr.IdleConnTimeout = time.Duration(c.IdleConnTimeoutMS) * time.Millisecond
+	if tlsConf != nil {
+		tr.TLSClientConfig = tlsConf
+	}
+	return tr
+}
+
+//------------------------------------------------------------------------------
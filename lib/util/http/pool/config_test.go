@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pool
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func TestTransport(t *testing.T) {
+	conf := NewConfig()
+	conf.MaxIdleConns = 10
+	conf.MaxIdleConnsPerHost = 5
+	conf.MaxConnsPerHost = 20
+	conf.IdleConnTimeoutMS = 1000
+
+	tlsConf := &tls.Config{InsecureSkipVerify: true}
+	tr := conf.Transport(tlsConf)
+
+	if exp, act := 10, tr.MaxIdleConns; exp != act {
+		t.Errorf("Wrong max idle conns: %v != %v", act, exp)
+	}
+	if exp, act := 5, tr.MaxIdleConnsPerHost; exp != act {
+		t.Errorf("Wrong max idle conns per host: %v != %v", act, exp)
+	}
+	if exp, act := 20, tr.MaxConnsPerHost; exp != act {
+		t.Errorf("Wrong max conns per host: %v != %v", act, exp)
+	}
+	if exp, act := time.Second, tr.IdleConnTimeout; exp != act {
+		t.Errorf("Wrong idle conn timeout: %v != %v", act, exp)
+	}
+	if tr.TLSClientConfig != tlsConf {
+		t.Error("TLS config not applied")
+	}
+	if tr.DialContext == nil {
+		t.Error("Dialer not set")
+	}
+
+	def := http.DefaultTransport.(*http.Transport)
+	if tr.Proxy == nil {
+		t.Error("Proxy not inherited from default transport")
+	}
+	if exp, act := def.TLSHandshakeTimeout, tr.TLSHandshakeTimeout; exp != act {
+		t.Errorf("Wrong TLS handshake timeout: %v != %v", act, exp)
+	}
+	if exp, act := def.ExpectContinueTimeout, tr.ExpectContinueTimeout; exp != act {
+		t.Errorf("Wrong expect continue timeout: %v != %v", act, exp)
+	}
+	if exp, act := def.ForceAttemptHTTP2, tr.ForceAttemptHTTP2; exp != act {
+		t.Errorf("Wrong force attempt HTTP2: %v != %v", act, exp)
+	}
+	if def.MaxIdleConns == tr.MaxIdleConns {
+		t.Error("Default transport was modified")
+	}
+}
+
+//------------------------------------------------------------------------------
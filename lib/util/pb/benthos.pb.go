@@ -0,0 +1,426 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.24.0
+// 	protoc        v3.12.3
+// source: benthos.proto
+
+package pb
+
+import (
+	context "context"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// Part is a single part of a message, consisting of its raw contents and
+// metadata.
+type Part struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Content  []byte            `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Metadata map[string]string `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (x *Part) Reset() {
+	*x = Part{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_benthos_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Part) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Part) ProtoMessage() {}
+
+func (x *Part) ProtoReflect() protoreflect.Message {
+	mi := &file_benthos_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Part.ProtoReflect.Descriptor instead.
+func (*Part) Descriptor() ([]byte, []int) {
+	return file_benthos_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Part) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *Part) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+// Message is a batch of one or more parts that is processed as a single
+// transaction.
+type Message struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Parts []*Part `protobuf:"bytes,1,rep,name=parts,proto3" json:"parts,omitempty"`
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_benthos_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_benthos_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_benthos_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Message) GetParts() []*Part {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+// Response acknowledges a message, where a non-empty error indicates that the
+// message was not delivered and should be sent again.
+type Response struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_benthos_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_benthos_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_benthos_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Response) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_benthos_proto protoreflect.FileDescriptor
+
+var file_benthos_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x62, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x07, 0x62, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73, 0x22, 0x96, 0x01, 0x0a, 0x04, 0x50, 0x61, 0x72,
+	0x74, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x37, 0x0a, 0x08, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1b, 0x2e,
+	0x62, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x2e, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x1a, 0x3b, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x2e, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x23, 0x0a, 0x05,
+	0x70, 0x61, 0x72, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x62, 0x65,
+	0x6e, 0x74, 0x68, 0x6f, 0x73, 0x2e, 0x50, 0x61, 0x72, 0x74, 0x52, 0x05, 0x70, 0x61, 0x72, 0x74,
+	0x73, 0x22, 0x20, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x32, 0x3a, 0x0a, 0x07, 0x42, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73, 0x12, 0x2f,
+	0x0a, 0x04, 0x53, 0x65, 0x6e, 0x64, 0x12, 0x10, 0x2e, 0x62, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73,
+	0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x1a, 0x11, 0x2e, 0x62, 0x65, 0x6e, 0x74, 0x68,
+	0x6f, 0x73, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42,
+	0x2b, 0x5a, 0x29, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x4a, 0x65,
+	0x66, 0x66, 0x61, 0x69, 0x6c, 0x2f, 0x62, 0x65, 0x6e, 0x74, 0x68, 0x6f, 0x73, 0x2f, 0x6c, 0x69,
+	0x62, 0x2f, 0x75, 0x74, 0x69, 0x6c, 0x2f, 0x70, 0x62, 0x3b, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_benthos_proto_rawDescOnce sync.Once
+	file_benthos_proto_rawDescData = file_benthos_proto_rawDesc
+)
+
+func file_benthos_proto_rawDescGZIP() []byte {
+	file_benthos_proto_rawDescOnce.Do(func() {
+		file_benthos_proto_rawDescData = protoimpl.X.CompressGZIP(file_benthos_proto_rawDescData)
+	})
+	return file_benthos_proto_rawDescData
+}
+
+var file_benthos_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_benthos_proto_goTypes = []interface{}{
+	(*Part)(nil),     // 0: benthos.Part
+	(*Message)(nil),  // 1: benthos.Message
+	(*Response)(nil), // 2: benthos.Response
+	nil,              // 3: benthos.Part.MetadataEntry
+}
+var file_benthos_proto_depIdxs = []int32{
+	3, // 0: benthos.Part.metadata:type_name -> benthos.Part.MetadataEntry
+	0, // 1: benthos.Message.parts:type_name -> benthos.Part
+	1, // 2: benthos.Benthos.Send:input_type -> benthos.Message
+	2, // 3: benthos.Benthos.Send:output_type -> benthos.Response
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_benthos_proto_init() }
+func file_benthos_proto_init() {
+	if File_benthos_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_benthos_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Part); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_benthos_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Message); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_benthos_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Response); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_benthos_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_benthos_proto_goTypes,
+		DependencyIndexes: file_benthos_proto_depIdxs,
+		MessageInfos:      file_benthos_proto_msgTypes,
+	}.Build()
+	File_benthos_proto = out.File
+	file_benthos_proto_rawDesc = nil
+	file_benthos_proto_goTypes = nil
+	file_benthos_proto_depIdxs = nil
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// BenthosClient is the client API for Benthos service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BenthosClient interface {
+	// Send streams messages into Benthos. A response is returned for each message,
+	// in order, once it has been processed, and the next message of the stream is
+	// not consumed until then.
+	Send(ctx context.Context, opts ...grpc.CallOption) (Benthos_SendClient, error)
+}
+
+type benthosClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBenthosClient(cc grpc.ClientConnInterface) BenthosClient {
+	return &benthosClient{cc}
+}
+
+func (c *benthosClient) Send(ctx context.Context, opts ...grpc.CallOption) (Benthos_SendClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Benthos_serviceDesc.Streams[0], "/benthos.Benthos/Send", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &benthosSendClient{stream}
+	return x, nil
+}
+
+type Benthos_SendClient interface {
+	Send(*Message) error
+	Recv() (*Response, error)
+	grpc.ClientStream
+}
+
+type benthosSendClient struct {
+	grpc.ClientStream
+}
+
+func (x *benthosSendClient) Send(m *Message) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *benthosSendClient) Recv() (*Response, error) {
+	m := new(Response)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BenthosServer is the server API for Benthos service.
+type BenthosServer interface {
+	// Send streams messages into Benthos. A response is returned for each message,
+	// in order, once it has been processed, and the next message of the stream is
+	// not consumed until then.
+	Send(Benthos_SendServer) error
+}
+
+// UnimplementedBenthosServer can be embedded to have forward compatible implementations.
+type UnimplementedBenthosServer struct {
+}
+
+func (*UnimplementedBenthosServer) Send(Benthos_SendServer) error {
+	return status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+
+func RegisterBenthosServer(s *grpc.Server, srv BenthosServer) {
+	s.RegisterService(&_Benthos_serviceDesc, srv)
+}
+
+func _Benthos_Send_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BenthosServer).Send(&benthosSendServer{stream})
+}
+
+type Benthos_SendServer interface {
+	Send(*Response) error
+	Recv() (*Message, error)
+	grpc.ServerStream
+}
+
+type benthosSendServer struct {
+	grpc.ServerStream
+}
+
+func (x *benthosSendServer) Send(m *Response) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *benthosSendServer) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _Benthos_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "benthos.Benthos",
+	HandlerType: (*BenthosServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Send",
+			Handler:       _Benthos_Send_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "benthos.proto",
+}
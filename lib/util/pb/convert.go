@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pb
+
+import (
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+// FromMessage converts a Benthos message into a protobuf message, where the
+// metadata of each part is copied.
+func FromMessage(msg types.Message) *Message {
+	pbMsg := &Message{
+		Parts: make([]*Part, msg.Len()),
+	}
+	for i := range pbMsg.Parts {
+		var meta map[string]string
+		msg.GetMetadata(i).Iter(func(k, v string) error {
+			if meta == nil {
+				meta = map[string]string{}
+			}
+			meta[k] = v
+			return nil
+		})
+		pbMsg.Parts[i] = &Part{
+			Content:  msg.Get(i),
+			Metadata: meta,
+		}
+	}
+	return pbMsg
+}
+
+// ToMessage converts a protobuf message into a Benthos message.
+func ToMessage(pbMsg *Message) types.Message {
+	msg := types.NewMessage(nil)
+	for _, p := range pbMsg.GetParts() {
+		i := msg.Append(p.GetContent())
+		meta := msg.GetMetadata(i)
+		for k, v := range p.GetMetadata() {
+			meta.Set(k, v)
+		}
+	}
+	return msg
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pb
+
+import (
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestConvertRoundTrip(t *testing.T) {
+	msg := types.NewMessage([][]byte{[]byte("foo"), []byte("bar")})
+	msg.GetMetadata(0).Set("baz", "qux")
+
+	pbMsg := FromMessage(msg)
+	if exp, act := 2, len(pbMsg.Parts); exp != act {
+		t.Fatalf("Wrong count of parts: %v != %v", act, exp)
+	}
+	if exp, act := "qux", pbMsg.Parts[0].Metadata["baz"]; exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if pbMsg.Parts[1].Metadata != nil {
+		t.Errorf("Unexpected metadata: %v", pbMsg.Parts[1].Metadata)
+	}
+
+	resMsg := ToMessage(pbMsg)
+	if exp, act := 2, resMsg.Len(); exp != act {
+		t.Fatalf("Wrong count of parts: %v != %v", act, exp)
+	}
+	for i, exp := range []string{"foo", "bar"} {
+		if act := string(resMsg.Get(i)); exp != act {
+			t.Errorf("Wrong contents of part %v: %v != %v", i, act, exp)
+		}
+	}
+	if exp, act := "qux", resMsg.GetMetadata(0).Get("baz"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if exp, act := "", resMsg.GetMetadata(1).Get("baz"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,209 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package probe
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/go-sql-driver/mysql"
+)
+
+//------------------------------------------------------------------------------
+
+// defaultPorts maps URL schemes to the port that is dialled when a URL does not
+// specify one.
+var defaultPorts = map[string]string{
+	"amqp":       "5672",
+	"amqps":      "5671",
+	"ftp":        "21",
+	"http":       "80",
+	"https":      "443",
+	"mongodb":    "27017",
+	"nats":       "4222",
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"redis":      "6379",
+	"rediss":     "6379",
+	"sftp":       "22",
+	"ws":         "80",
+	"wss":        "443",
+}
+
+// splitList splits any comma separated items of a list and removes empty items.
+func splitList(items []string) []string {
+	var split []string
+	for _, item := range items {
+		for _, s := range strings.Split(item, ",") {
+			if s = strings.TrimSpace(s); len(s) > 0 {
+				split = append(split, s)
+			}
+		}
+	}
+	return split
+}
+
+// splitURLs splits any comma separated URLs of a list, where items following a
+// comma that do not contain a scheme are treated as further hosts of the
+// previous URL.
+func splitURLs(items []string) []string {
+	var split []string
+	for _, item := range splitList(items) {
+		if len(split) > 0 && !strings.Contains(item, "://") {
+			split[len(split)-1] += "," + item
+			continue
+		}
+		split = append(split, item)
+	}
+	return split
+}
+
+// withPort adds a port to an address when it does not already specify one.
+func withPort(address, port string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil || len(port) == 0 {
+		return address
+	}
+	return net.JoinHostPort(address, port)
+}
+
+//------------------------------------------------------------------------------
+
+// Dial attempts a connection to each address over the network and closes it
+// immediately, returning the first error encountered. Addresses without a port
+// are given the default port, and items of comma separated addresses are
+// dialled individually.
+func Dial(timeout time.Duration, network, defaultPort string, addresses ...string) error {
+	addresses = splitList(addresses)
+	if len(addresses) == 0 {
+		return errors.New("no addresses to dial")
+	}
+	for _, addr := range addresses {
+		conn, err := net.DialTimeout(network, withPort(addr, defaultPort), timeout)
+		if err != nil {
+			return err
+		}
+		conn.Close()
+	}
+	return nil
+}
+
+// DialURLs attempts a TCP connection to the hosts of each URL, where URLs
+// without a port are given the default port of their scheme. A URL may contain
+// multiple comma separated hosts, and items of comma separated URLs are dialled
+// individually.
+func DialURLs(timeout time.Duration, urls ...string) error {
+	urls = splitURLs(urls)
+	if len(urls) == 0 {
+		return errors.New("no URLs to dial")
+	}
+	var addresses []string
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return fmt.Errorf("failed to parse URL: %v", err)
+		}
+		if len(parsed.Host) == 0 {
+			return fmt.Errorf("URL does not contain a host: %v", u)
+		}
+		if parsed.Scheme != "tcp" {
+			if _, exists := defaultPorts[parsed.Scheme]; !exists && len(parsed.Port()) == 0 {
+				return types.ErrCheckNotSupported
+			}
+		}
+		for _, host := range strings.Split(parsed.Host, ",") {
+			addresses = append(addresses, withPort(host, defaultPorts[parsed.Scheme]))
+		}
+	}
+	return Dial(timeout, "tcp", "", addresses...)
+}
+
+// PathExists returns an error if a file or directory does not exist at a path.
+func PathExists(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+//------------------------------------------------------------------------------
+
+// DialAWS attempts a TCP connection to the regional endpoint of an AWS service.
+func DialAWS(timeout time.Duration, service, region string) error {
+	return Dial(timeout, "tcp", "443", fmt.Sprintf("%v.%v.amazonaws.com", service, region))
+}
+
+// DialAzure attempts a TCP connection to the AMQP endpoint of an Azure Service
+// Bus or Event Hubs connection string.
+func DialAzure(timeout time.Duration, connectionString string) error {
+	for _, field := range strings.Split(connectionString, ";") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "Endpoint") {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return fmt.Errorf("failed to parse endpoint: %v", err)
+		}
+		return Dial(timeout, "tcp", "5671", u.Host)
+	}
+	return errors.New("connection string does not contain an endpoint")
+}
+
+// DialSQL attempts a TCP connection to the server of a SQL data source name for
+// the mysql and postgres drivers.
+func DialSQL(timeout time.Duration, driver, dsn string) error {
+	switch driver {
+	case "mysql":
+		conf, err := mysql.ParseDSN(dsn)
+		if err != nil {
+			return err
+		}
+		return Dial(timeout, conf.Net, "", conf.Addr)
+	case "postgres":
+		if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+			return DialURLs(timeout, dsn)
+		}
+		host, port := "localhost", "5432"
+		for _, field := range strings.Fields(dsn) {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "host":
+				host = kv[1]
+			case "port":
+				port = kv[1]
+			}
+		}
+		if strings.HasPrefix(host, "/") {
+			return types.ErrCheckNotSupported
+		}
+		return Dial(timeout, "tcp", "", net.JoinHostPort(host, port))
+	}
+	return fmt.Errorf("sql driver not recognised: %v", driver)
+}
+
+//------------------------------------------------------------------------------
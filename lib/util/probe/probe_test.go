@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package probe
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func listen(t *testing.T) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String(), func() {
+		ln.Close()
+	}
+}
+
+func closedAddress(t *testing.T) string {
+	addr, done := listen(t)
+	done()
+	return addr
+}
+
+//------------------------------------------------------------------------------
+
+func TestDial(t *testing.T) {
+	addr, done := listen(t)
+	defer done()
+
+	if err := Dial(time.Second, "tcp", "", addr); err != nil {
+		t.Error(err)
+	}
+	if err := Dial(time.Second, "tcp", "", addr+","+addr); err != nil {
+		t.Error(err)
+	}
+
+	_, port, _ := net.SplitHostPort(addr)
+	if err := Dial(time.Second, "tcp", port, "127.0.0.1"); err != nil {
+		t.Error(err)
+	}
+
+	if err := Dial(time.Second, "tcp", "", addr, closedAddress(t)); err == nil {
+		t.Error("Expected error from closed address")
+	}
+	if err := Dial(time.Second, "tcp", ""); err == nil {
+		t.Error("Expected error from empty addresses")
+	}
+}
+
+func TestDialURLs(t *testing.T) {
+	addr, done := listen(t)
+	defer done()
+
+	goodURLs := []string{
+		fmt.Sprintf("tcp://%v", addr),
+		fmt.Sprintf("amqp://guest:guest@%v/", addr),
+		fmt.Sprintf("mongodb://%v,%v/db", addr, addr),
+		fmt.Sprintf("nats://%v,nats://%v", addr, addr),
+	}
+	for _, u := range goodURLs {
+		if err := DialURLs(time.Second, u); err != nil {
+			t.Errorf("%v: %v", u, err)
+		}
+	}
+
+	if err := DialURLs(time.Second, "tcp://"+closedAddress(t)); err == nil {
+		t.Error("Expected error from closed address")
+	}
+	if err := DialURLs(time.Second, "ipc:///tmp/foo"); err == nil {
+		t.Error("Expected error from URL without host")
+	}
+	if exp, act := types.ErrCheckNotSupported, DialURLs(time.Second, "nope://foo"); exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+}
+
+func TestDialAzure(t *testing.T) {
+	addr, done := listen(t)
+	defer done()
+
+	connStr := fmt.Sprintf("Endpoint=sb://%v/;SharedAccessKeyName=foo;SharedAccessKey=bar", addr)
+	if err := DialAzure(time.Second, connStr); err != nil {
+		t.Error(err)
+	}
+	if err := DialAzure(time.Second, "SharedAccessKeyName=foo"); err == nil {
+		t.Error("Expected error from missing endpoint")
+	}
+}
+
+func TestDialSQL(t *testing.T) {
+	addr, done := listen(t)
+	defer done()
+
+	host, port, _ := net.SplitHostPort(addr)
+
+	tests := map[string][]string{
+		"mysql": {
+			fmt.Sprintf("user:pass@tcp(%v)/db", addr),
+		},
+		"postgres": {
+			fmt.Sprintf("postgres://user:pass@%v/db?sslmode=disable", addr),
+			fmt.Sprintf("host=%v port=%v user=foo dbname=bar", host, port),
+		},
+	}
+	for driver, dsns := range tests {
+		for _, dsn := range dsns {
+			if err := DialSQL(time.Second, driver, dsn); err != nil {
+				t.Errorf("%v: %v: %v", driver, dsn, err)
+			}
+		}
+	}
+
+	if err := DialSQL(time.Second, "mysql", fmt.Sprintf("user:pass@tcp(%v)/db", closedAddress(t))); err == nil {
+		t.Error("Expected error from closed address")
+	}
+	if exp, act := types.ErrCheckNotSupported, DialSQL(time.Second, "postgres", "host=/tmp/sock"); exp != act {
+		t.Errorf("Wrong error: %v != %v", act, exp)
+	}
+	if err := DialSQL(time.Second, "nope", ""); err == nil {
+		t.Error("Expected error from bad driver")
+	}
+}
+
+//------------------------------------------------------------------------------
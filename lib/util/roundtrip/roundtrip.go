@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package roundtrip
+
+import (
+	"sync"
+
+	"github.com/Jeffail/benthos/lib/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+//------------------------------------------------------------------------------
+
+// MetadataKey is the metadata key used for tagging the parts of a message with
+// the ID of the result store that they belong to.
+const MetadataKey = "benthos_roundtrip_id"
+
+//------------------------------------------------------------------------------
+
+// ResultStore collects the messages that are set as the response of the
+// message it was added to.
+type ResultStore interface {
+	// Add a message to the store.
+	Add(msg types.Message)
+
+	// Get the messages that have been added to the store.
+	Get() []types.Message
+}
+
+type resultStoreImpl struct {
+	sync.Mutex
+	results []types.Message
+}
+
+func (r *resultStoreImpl) Add(msg types.Message) {
+	r.Lock()
+	r.results = append(r.results, msg)
+	r.Unlock()
+}
+
+func (r *resultStoreImpl) Get() []types.Message {
+	r.Lock()
+	defer r.Unlock()
+	return r.results
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	storesMut sync.RWMutex
+	stores    = map[string]ResultStore{}
+)
+
+// AddResultStore creates a new result store and tags each part of a message
+// with its ID, returning the store and a function that must be called once the
+// store is no longer needed.
+func AddResultStore(msg types.Message) (ResultStore, func()) {
+	id := uuid.NewV4().String()
+	store := &resultStoreImpl{}
+
+	storesMut.Lock()
+	stores[id] = store
+	storesMut.Unlock()
+
+	for i := 0; i < msg.Len(); i++ {
+		msg.GetMetadata(i).Set(MetadataKey, id)
+	}
+	return store, func() {
+		storesMut.Lock()
+		delete(stores, id)
+		storesMut.Unlock()
+	}
+}
+
+// SetAsResponse adds the parts of a message to the result stores that they are
+// tagged with, where parts that are tagged with the same store are added as a
+// single message. Returns the number of stores that were found.
+func SetAsResponse(msg types.Message) int {
+	var ids []string
+	parts := map[string][]int{}
+	for i := 0; i < msg.Len(); i++ {
+		id := msg.GetMetadata(i).Get(MetadataKey)
+		if len(id) == 0 {
+			continue
+		}
+		if _, exists := parts[id]; !exists {
+			ids = append(ids, id)
+		}
+		parts[id] = append(parts[id], i)
+	}
+
+	found := 0
+	for _, id := range ids {
+		storesMut.RLock()
+		store, exists := stores[id]
+		storesMut.RUnlock()
+		if !exists {
+			continue
+		}
+		found++
+
+		result := types.NewMessage(nil)
+		for _, i := range parts[id] {
+			result.Append(msg.Get(i))
+			meta := msg.GetMetadata(i).Copy()
+			meta.Delete(MetadataKey)
+			result.SetMetadata(meta, -1)
+		}
+		store.Add(result)
+	}
+	return found
+}
+
+//------------------------------------------------------------------------------
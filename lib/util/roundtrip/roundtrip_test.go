@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package roundtrip
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Jeffail/benthos/lib/types"
+)
+
+//------------------------------------------------------------------------------
+
+func TestRoundtripResults(t *testing.T) {
+	msgA := types.NewMessage([][]byte{[]byte("foo")})
+	msgB := types.NewMessage([][]byte{[]byte("bar")})
+
+	storeA, releaseA := AddResultStore(msgA)
+	defer releaseA()
+	storeB, releaseB := AddResultStore(msgB)
+
+	idA := msgA.GetMetadata(0).Get(MetadataKey)
+	idB := msgB.GetMetadata(0).Get(MetadataKey)
+	if len(idA) == 0 || idA == idB {
+		t.Fatalf("Expected unique IDs: %v, %v", idA, idB)
+	}
+
+	result := types.NewMessage([][]byte{
+		[]byte("first"), []byte("second"), []byte("third"), []byte("untagged"),
+	})
+	result.GetMetadata(0).Set(MetadataKey, idA).Set("foo", "a")
+	result.GetMetadata(1).Set(MetadataKey, idB)
+	result.GetMetadata(2).Set(MetadataKey, idA)
+
+	if exp, act := 2, SetAsResponse(result); exp != act {
+		t.Errorf("Wrong count of stores: %v != %v", act, exp)
+	}
+
+	resA := storeA.Get()
+	if exp, act := 1, len(resA); exp != act {
+		t.Fatalf("Wrong count of results: %v != %v", act, exp)
+	}
+	if exp, act := [][]byte{[]byte("first"), []byte("third")}, resA[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+	if exp, act := "a", resA[0].GetMetadata(0).Get("foo"); exp != act {
+		t.Errorf("Wrong metadata: %v != %v", act, exp)
+	}
+	if act := resA[0].GetMetadata(0).Get(MetadataKey); len(act) > 0 {
+		t.Errorf("Expected ID to be removed from metadata: %v", act)
+	}
+
+	resB := storeB.Get()
+	if exp, act := 1, len(resB); exp != act {
+		t.Fatalf("Wrong count of results: %v != %v", act, exp)
+	}
+	if exp, act := [][]byte{[]byte("second")}, resB[0].GetAll(); !reflect.DeepEqual(exp, act) {
+		t.Errorf("Wrong result: %s != %s", act, exp)
+	}
+
+	releaseB()
+	if exp, act := 1, SetAsResponse(result); exp != act {
+		t.Errorf("Wrong count of stores: %v != %v", act, exp)
+	}
+	if exp, act := 1, len(storeB.Get()); exp != act {
+		t.Errorf("Wrong count of results: %v != %v", act, exp)
+	}
+}
+
+//------------------------------------------------------------------------------
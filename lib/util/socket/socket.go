@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package socket
+
+import (
+	"fmt"
+	"strings"
+)
+
+//------------------------------------------------------------------------------
+
+// UnixPrefix is the prefix of addresses that are paths to unix domain sockets.
+const UnixPrefix = "unix://"
+
+// Resolve returns the network and address to use for a socket from the network
+// and address fields of a config. The network can be tcp, udp, unix or
+// unixgram. When the address is prefixed with unix:// it is treated as a path
+// to a unix domain socket, where a stream socket is used for the tcp network and
+// a datagram socket for the udp network.
+func Resolve(network, address string) (string, string, error) {
+	switch network {
+	case "tcp", "udp", "unix", "unixgram":
+	default:
+		return "", "", fmt.Errorf("socket network not recognised: %v", network)
+	}
+	if !strings.HasPrefix(address, UnixPrefix) {
+		return network, address, nil
+	}
+	address = strings.TrimPrefix(address, UnixPrefix)
+	switch network {
+	case "tcp":
+		network = "unix"
+	case "udp":
+		network = "unixgram"
+	}
+	return network, address, nil
+}
+
+// IsPacket returns true if a network is datagram based.
+func IsPacket(network string) bool {
+	return network == "udp" || network == "unixgram"
+}
+
+//------------------------------------------------------------------------------
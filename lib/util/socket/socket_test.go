@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package socket
+
+import (
+	"testing"
+)
+
+//------------------------------------------------------------------------------
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		network, address       string
+		expNetwork, expAddress string
+	}{
+		{"tcp", "localhost:4198", "tcp", "localhost:4198"},
+		{"udp", "localhost:4198", "udp", "localhost:4198"},
+		{"tcp", "unix:///tmp/foo.sock", "unix", "/tmp/foo.sock"},
+		{"udp", "unix:///tmp/foo.sock", "unixgram", "/tmp/foo.sock"},
+		{"unix", "/tmp/foo.sock", "unix", "/tmp/foo.sock"},
+		{"unixgram", "unix://foo.sock", "unixgram", "foo.sock"},
+	}
+
+	for _, test := range tests {
+		network, address, err := Resolve(test.network, test.address)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if network != test.expNetwork {
+			t.Errorf("Wrong network for %v %v: %v != %v", test.network, test.address, network, test.expNetwork)
+		}
+		if address != test.expAddress {
+			t.Errorf("Wrong address for %v %v: %v != %v", test.network, test.address, address, test.expAddress)
+		}
+	}
+
+	if _, _, err := Resolve("nope", "localhost:4198"); err == nil {
+		t.Error("Expected error from bad network")
+	}
+}
+
+//------------------------------------------------------------------------------
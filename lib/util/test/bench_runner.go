@@ -0,0 +1,315 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/buffer"
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/pipeline"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/stream"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+// benchTimestampKey is the metadata key used for carrying the generation time
+// of a benchmark message through a pipeline.
+const benchTimestampKey = "benthos_bench_timestamp_ns"
+
+// BenchConfig contains the parameters of a benchmark run.
+type BenchConfig struct {
+	Duration    time.Duration
+	MessageSize int
+	Parts       int
+	InFlight    int
+}
+
+// NewBenchConfig returns a BenchConfig with default values.
+func NewBenchConfig() BenchConfig {
+	return BenchConfig{
+		Duration:    time.Second * 10,
+		MessageSize: 1024,
+		Parts:       1,
+		InFlight:    1,
+	}
+}
+
+// BenchLatencies is a summary of the end-to-end latencies of messages measured
+// during a benchmark run, in nanoseconds.
+type BenchLatencies struct {
+	Mean int64 `json:"mean"`
+	P50  int64 `json:"p50"`
+	P90  int64 `json:"p90"`
+	P99  int64 `json:"p99"`
+	Max  int64 `json:"max"`
+}
+
+// BenchReport is a summary of the results of a benchmark run.
+type BenchReport struct {
+	Duration    string         `json:"duration"`
+	DurationNS  int64          `json:"duration_ns"`
+	Total       int            `json:"total"`
+	TotalBytes  int            `json:"total_bytes"`
+	Errors      int            `json:"errors"`
+	MessageRate float64        `json:"msgs_per_s"`
+	ByteRate    float64        `json:"bytes_per_s"`
+	LatencyNS   BenchLatencies `json:"latency_ns"`
+}
+
+//------------------------------------------------------------------------------
+
+// benchLayer is a component of a stream that both consumes and produces
+// transactions.
+type benchLayer interface {
+	types.Producer
+	types.Consumer
+	types.Closable
+}
+
+// RunBenchmark drives the processing layers of a stream config (input and
+// output processors, the buffer and the pipeline) with generated messages for
+// the configured duration and returns a report of the sustained throughput and
+// end-to-end latencies measured. The input and output of the stream config are
+// not used, which means no data is consumed from or produced to third parties.
+func RunBenchmark(
+	conf BenchConfig,
+	streamConf stream.Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) (BenchReport, error) {
+	layers, err := newBenchLayers(streamConf, mgr, log, stats)
+	if err != nil {
+		return BenchReport{}, err
+	}
+	defer func() {
+		for _, l := range layers {
+			l.CloseAsync()
+		}
+		for _, l := range layers {
+			l.WaitForClose(time.Second * 5)
+		}
+	}()
+
+	tranChan := make(chan types.Transaction)
+	var nextChan <-chan types.Transaction = tranChan
+	for _, l := range layers {
+		if err = l.StartReceiving(nextChan); err != nil {
+			return BenchReport{}, err
+		}
+		nextChan = l.TransactionChan()
+	}
+
+	stopChan := make(chan struct{})
+	var wg sync.WaitGroup
+	var errCount int64
+
+	payload := make([]byte, conf.MessageSize)
+	for i := range payload {
+		payload[i] = byte('a' + i%26)
+	}
+	parts := conf.Parts
+	if parts < 1 {
+		parts = 1
+	}
+
+	inFlight := conf.InFlight
+	if inFlight < 1 {
+		inFlight = 1
+	}
+
+	// Generate messages, each generator keeps a single transaction in flight
+	// and so the total number in flight is bounded by inFlight.
+	genFunc := func() {
+		defer wg.Done()
+		resChan := make(chan types.Response)
+		for {
+			msgParts := make([][]byte, parts)
+			for i := range msgParts {
+				msgParts[i] = make([]byte, len(payload))
+				copy(msgParts[i], payload)
+			}
+			msg := types.NewMessage(msgParts)
+			msg.SetMetadata(types.NewMetadata().Set(
+				benchTimestampKey, strconv.FormatInt(time.Now().UnixNano(), 10),
+			))
+			select {
+			case tranChan <- types.NewTransaction(msg, resChan):
+			case <-stopChan:
+				return
+			}
+			select {
+			case res := <-resChan:
+				if res.Error() != nil {
+					atomic.AddInt64(&errCount, 1)
+				}
+			case <-stopChan:
+				return
+			}
+		}
+	}
+	for i := 0; i < inFlight; i++ {
+		wg.Add(1)
+		go genFunc()
+	}
+
+	// Measure messages as they reach the end of the pipeline.
+	var total, totalBytes int
+	var latencies []int64
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			var tran types.Transaction
+			var open bool
+			select {
+			case tran, open = <-nextChan:
+				if !open {
+					return
+				}
+			case <-stopChan:
+				return
+			}
+			now := time.Now().UnixNano()
+			total++
+			for _, p := range tran.Payload.GetAll() {
+				totalBytes += len(p)
+			}
+			if tran.Payload.Len() > 0 {
+				if ts, perr := strconv.ParseInt(
+					tran.Payload.GetMetadata(0).Get(benchTimestampKey), 10, 64,
+				); perr == nil {
+					latencies = append(latencies, now-ts)
+				}
+			}
+			select {
+			case tran.ResponseChan <- types.NewSimpleResponse(nil):
+			case <-stopChan:
+				return
+			}
+		}
+	}()
+
+	startedAt := time.Now()
+	<-time.After(conf.Duration)
+	close(stopChan)
+	elapsed := time.Since(startedAt)
+	wg.Wait()
+
+	report := BenchReport{
+		Duration:    elapsed.String(),
+		DurationNS:  int64(elapsed),
+		Total:       total,
+		TotalBytes:  totalBytes,
+		Errors:      int(atomic.LoadInt64(&errCount)),
+		MessageRate: float64(total) / elapsed.Seconds(),
+		ByteRate:    float64(totalBytes) / elapsed.Seconds(),
+		LatencyNS:   summariseLatencies(latencies),
+	}
+	return report, nil
+}
+
+func newBenchLayers(
+	conf stream.Config,
+	mgr types.Manager,
+	log log.Modular,
+	stats metrics.Type,
+) ([]benchLayer, error) {
+	layers := []benchLayer{}
+	procsLayer := func(procs []processor.Config) error {
+		if len(procs) == 0 {
+			return nil
+		}
+		pipeConf := pipeline.NewConfig()
+		pipeConf.Processors = procs
+		p, err := pipeline.New(pipeConf, mgr, log, stats)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, p)
+		return nil
+	}
+
+	if err := procsLayer(conf.Input.Processors); err != nil {
+		return nil, err
+	}
+	if conf.Buffer.Type != "none" {
+		b, err := buffer.New(conf.Buffer, log, stats)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, b)
+	}
+	if len(conf.Pipeline.Processors) > 0 {
+		p, err := pipeline.New(conf.Pipeline, mgr, log, stats)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, p)
+	}
+	if err := procsLayer(conf.Output.Processors); err != nil {
+		return nil, err
+	}
+	return layers, nil
+}
+
+// summariseLatencies calculates the mean and percentiles of a slice of
+// latencies.
+func summariseLatencies(latencies []int64) BenchLatencies {
+	var l BenchLatencies
+	if len(latencies) == 0 {
+		return l
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+
+	var sum int64
+	for _, v := range latencies {
+		sum += v
+	}
+	percentile := func(p float64) int64 {
+		index := int(math.Ceil(p*float64(len(latencies)))) - 1
+		if index < 0 {
+			index = 0
+		}
+		return latencies[index]
+	}
+
+	l.Mean = sum / int64(len(latencies))
+	l.P50 = percentile(0.5)
+	l.P90 = percentile(0.9)
+	l.P99 = percentile(0.99)
+	l.Max = latencies[len(latencies)-1]
+	return l
+}
+
+//------------------------------------------------------------------------------
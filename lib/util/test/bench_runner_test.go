@@ -0,0 +1,97 @@
+// Copyright (c) 2014 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Jeffail/benthos/lib/metrics"
+	"github.com/Jeffail/benthos/lib/processor"
+	"github.com/Jeffail/benthos/lib/stream"
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/benthos/lib/util/service/log"
+)
+
+//------------------------------------------------------------------------------
+
+func TestRunBenchmark(t *testing.T) {
+	procConf := processor.NewConfig()
+	procConf.Type = "insert_part"
+	procConf.InsertPart.Content = "foo"
+
+	streamConf := stream.NewConfig()
+	streamConf.Buffer.Type = "memory"
+	streamConf.Pipeline.Processors = append(streamConf.Pipeline.Processors, procConf)
+
+	benchConf := NewBenchConfig()
+	benchConf.Duration = time.Millisecond * 200
+	benchConf.MessageSize = 10
+	benchConf.Parts = 2
+	benchConf.InFlight = 4
+
+	report, err := RunBenchmark(
+		benchConf, streamConf, types.DudMgr{},
+		log.NewLogger(os.Stdout, log.LoggerConfig{LogLevel: "NONE"}),
+		metrics.DudType{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Total == 0 {
+		t.Fatal("No messages measured")
+	}
+	if exp, act := report.Total*23, report.TotalBytes; exp != act {
+		t.Errorf("Wrong total bytes: %v != %v", act, exp)
+	}
+	if report.Errors != 0 {
+		t.Errorf("Unexpected errors: %v", report.Errors)
+	}
+	if report.MessageRate <= 0 {
+		t.Errorf("Wrong message rate: %v", report.MessageRate)
+	}
+	if report.LatencyNS.Max <= 0 || report.LatencyNS.P50 > report.LatencyNS.Max {
+		t.Errorf("Wrong latencies: %+v", report.LatencyNS)
+	}
+}
+
+func TestSummariseLatencies(t *testing.T) {
+	latencies := []int64{}
+	for i := 100; i > 0; i-- {
+		latencies = append(latencies, int64(i))
+	}
+	exp := BenchLatencies{
+		Mean: 50,
+		P50:  50,
+		P90:  90,
+		P99:  99,
+		Max:  100,
+	}
+	if act := summariseLatencies(latencies); exp != act {
+		t.Errorf("Wrong latencies: %+v != %+v", act, exp)
+	}
+	if act := summariseLatencies(nil); (BenchLatencies{}) != act {
+		t.Errorf("Wrong empty latencies: %+v", act)
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -22,11 +22,17 @@ package text
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
+	"github.com/Jeffail/gabs"
+	uuid "github.com/satori/go.uuid"
 )
 
 //------------------------------------------------------------------------------
@@ -35,7 +41,7 @@ var functionRegex *regexp.Regexp
 
 func init() {
 	var err error
-	functionRegex, err = regexp.Compile(`\${![a-z_]+(:[^}]+)?}`)
+	functionRegex, err = regexp.Compile(`\${![a-z0-9_]+(:[^}]+)?}`)
 	if err != nil {
 		panic(err)
 	}
@@ -44,11 +50,68 @@ func init() {
 var counters = map[string]uint64{}
 var countersMux = &sync.Mutex{}
 
-var functionVars = map[string]func(arg string) []byte{
-	"timestamp_unix_nano": func(arg string) []byte {
+// parsePartIndex splits a function argument that is optionally followed by a
+// comma and the index of a message part, returning the remaining argument and
+// the index, which defaults to zero.
+func parsePartIndex(arg string) (string, int) {
+	if i := strings.LastIndex(arg, ","); i >= 0 {
+		if p, err := strconv.Atoi(arg[i+1:]); err == nil {
+			return arg[:i], p
+		}
+	}
+	return arg, 0
+}
+
+// metadataFunction returns the value of a metadata key of a message part, where
+// the argument is the key optionally followed by a comma and the index of the
+// message part, e.g. `kafka_key,1`.
+func metadataFunction(msg types.Message, arg string) []byte {
+	if msg == nil {
+		return []byte{}
+	}
+	key, part := parsePartIndex(arg)
+	return []byte(msg.GetMetadata(part).Get(key))
+}
+
+// jsonFieldFunction extracts a field from a JSON message part, where the
+// argument is a dot path optionally followed by a comma and the index of the
+// message part, e.g. `foo.bar,1`.
+func jsonFieldFunction(msg types.Message, arg string) []byte {
+	if msg == nil {
+		return []byte("null")
+	}
+	var part int
+	arg, part = parsePartIndex(arg)
+	jObj, err := msg.GetJSON(part)
+	if err != nil {
+		return []byte("null")
+	}
+	gObj, err := gabs.Consume(jObj)
+	if err != nil {
+		return []byte("null")
+	}
+	if len(arg) > 0 {
+		gObj = gObj.Path(arg)
+	}
+	switch t := gObj.Data().(type) {
+	case string:
+		return []byte(t)
+	case nil:
+		return []byte("null")
+	default:
+		rawBytes, err := json.Marshal(t)
+		if err != nil {
+			return []byte("null")
+		}
+		return rawBytes
+	}
+}
+
+var functionVars = map[string]func(msg types.Message, arg string) []byte{
+	"timestamp_unix_nano": func(_ types.Message, arg string) []byte {
 		return []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
 	},
-	"timestamp_unix": func(arg string) []byte {
+	"timestamp_unix": func(_ types.Message, arg string) []byte {
 		tNow := time.Now()
 		precision, _ := strconv.ParseInt(arg, 10, 64)
 		tStr := strconv.FormatInt(tNow.Unix(), 10)
@@ -61,20 +124,28 @@ var functionVars = map[string]func(arg string) []byte{
 		}
 		return []byte(tStr)
 	},
-	"timestamp": func(arg string) []byte {
+	"timestamp": func(_ types.Message, arg string) []byte {
 		if len(arg) == 0 {
 			arg = "Mon Jan 2 15:04:05 -0700 MST 2006"
 		}
 		return []byte(time.Now().Format(arg))
 	},
-	"hostname": func(arg string) []byte {
+	"hostname": func(_ types.Message, arg string) []byte {
 		hn, _ := os.Hostname()
 		return []byte(hn)
 	},
-	"echo": func(arg string) []byte {
+	"echo": func(_ types.Message, arg string) []byte {
 		return []byte(arg)
 	},
-	"count": func(arg string) []byte {
+	"env": func(_ types.Message, arg string) []byte {
+		return []byte(os.Getenv(arg))
+	},
+	"uuid_v4": func(_ types.Message, arg string) []byte {
+		return []byte(uuid.NewV4().String())
+	},
+	"json_field": jsonFieldFunction,
+	"metadata":   metadataFunction,
+	"count": func(_ types.Message, arg string) []byte {
 		countersMux.Lock()
 		defer countersMux.Unlock()
 
@@ -104,18 +175,28 @@ func ContainsFunctionVariables(inBytes []byte) bool {
 // For each aforementioned pattern found in the blob the contents of the
 // respective function will be run and will replace the pattern.
 func ReplaceFunctionVariables(inBytes []byte) []byte {
+	return ReplaceFunctionVariablesFor(nil, inBytes)
+}
+
+// ReplaceFunctionVariablesFor will search a blob of data for the pattern
+// `${!foo}`, where `foo` is a function name, and replace it with the result of
+// the function, which may be resolved using the contents of a message.
+//
+// Functions that depend on a message, such as `json_field` and `metadata`,
+// resolve to empty values when the message is nil.
+func ReplaceFunctionVariablesFor(msg types.Message, inBytes []byte) []byte {
 	return functionRegex.ReplaceAllFunc(inBytes, func(content []byte) []byte {
 		if len(content) > 4 {
 			if colonIndex := bytes.IndexByte(content, ':'); colonIndex == -1 {
 				targetFunc := string(content[3 : len(content)-1])
 				if ftor, exists := functionVars[targetFunc]; exists {
-					return ftor("")
+					return ftor(msg, "")
 				}
 			} else {
 				targetFunc := string(content[3:colonIndex])
 				argVal := string(content[colonIndex+1 : len(content)-1])
 				if ftor, exists := functionVars[targetFunc]; exists {
-					return ftor(argVal)
+					return ftor(msg, argVal)
 				}
 			}
 		}
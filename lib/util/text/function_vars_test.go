@@ -26,6 +26,8 @@ import (
 	"strconv"
 	"testing"
 	"time"
+
+	"github.com/Jeffail/benthos/lib/types"
 )
 
 func TestFunctionVarDetection(t *testing.T) {
@@ -159,3 +161,91 @@ func TestCountersFunction(t *testing.T) {
 		}
 	}
 }
+
+func TestEnvFunction(t *testing.T) {
+	os.Setenv("BENTHOS_TEST_FUNCTION_VAR", "foobar")
+	defer os.Unsetenv("BENTHOS_TEST_FUNCTION_VAR")
+
+	tests := map[string]string{
+		"foo ${!env:BENTHOS_TEST_FUNCTION_VAR}":         "foo foobar",
+		"foo ${!env:BENTHOS_TEST_FUNCTION_VAR_NOPE}":    "foo ",
+		"foo ${!env:BENTHOS_TEST_FUNCTION_VAR} ${!env}": "foo foobar ",
+	}
+
+	for input, exp := range tests {
+		act := string(ReplaceFunctionVariables([]byte(input)))
+		if exp != act {
+			t.Errorf("Wrong results for input (%v): %v != %v", input, act, exp)
+		}
+	}
+}
+
+func TestUUIDV4Function(t *testing.T) {
+	results := map[string]struct{}{}
+
+	for i := 0; i < 100; i++ {
+		result := string(ReplaceFunctionVariables([]byte("${!uuid_v4}")))
+		if len(result) != 36 {
+			t.Errorf("Wrong length of result: %v", result)
+		}
+		if _, exists := results[result]; exists {
+			t.Errorf("Duplicate UUID generated: %v", result)
+		}
+		results[result] = struct{}{}
+	}
+}
+
+func TestJSONFieldFunction(t *testing.T) {
+	msg := types.NewMessage([][]byte{
+		[]byte(`{"foo":{"bar":"hello world"}}`),
+		[]byte(`{"foo":{"bar":{"baz":10}}}`),
+		[]byte(`not json`),
+	})
+
+	tests := map[string]string{
+		"foo ${!json_field:foo.bar}":                                 "foo hello world",
+		"foo ${!json_field:foo.bar,0}":                               "foo hello world",
+		"foo ${!json_field:foo.bar,1}":                               `foo {"baz":10}`,
+		"foo ${!json_field:foo.bar.baz,1}":                           "foo 10",
+		"foo ${!json_field:foo.bar.baz}":                             "foo null",
+		"foo ${!json_field:foo,2}":                                   "foo null",
+		"foo ${!json_field:foo,3}":                                   "foo null",
+		"${!json_field:foo.bar} ${!echo:and}":                        "hello world and",
+		"foo ${!json_field:foo.bar.baz,1} ${!json_field:foo.bar,-3}": "foo 10 hello world",
+	}
+
+	for input, exp := range tests {
+		act := string(ReplaceFunctionVariablesFor(msg, []byte(input)))
+		if exp != act {
+			t.Errorf("Wrong results for input (%v): %v != %v", input, act, exp)
+		}
+	}
+
+	if exp, act := "foo null", string(ReplaceFunctionVariables([]byte("foo ${!json_field:foo}"))); exp != act {
+		t.Errorf("Wrong results for nil message: %v != %v", act, exp)
+	}
+}
+
+func TestMetadataFunction(t *testing.T) {
+	msg := types.NewMessage([][]byte{
+		[]byte(`foo`),
+		[]byte(`bar`),
+	})
+	msg.GetMetadata(0).Set("foo", "foo1")
+	msg.GetMetadata(1).Set("foo", "foo2").Set("bar", "bar2")
+
+	tests := map[string]string{
+		"foo ${!metadata:foo}":                     "foo foo1",
+		"foo ${!metadata:foo,1}":                   "foo foo2",
+		"foo ${!metadata:bar}":                     "foo ",
+		"foo ${!metadata:bar,-1} ${!metadata:foo}": "foo bar2 foo1",
+		"foo ${!metadata:foo,5}":                   "foo ",
+	}
+
+	for input, exp := range tests {
+		act := string(ReplaceFunctionVariablesFor(msg, []byte(input)))
+		if exp != act {
+			t.Errorf("Wrong results for input (%v): %v != %v", input, act, exp)
+		}
+	}
+}
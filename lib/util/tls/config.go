@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+//------------------------------------------------------------------------------
+
+// Config contains configuration params for TLS connections made by a client.
+type Config struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	RootCAsFile string `json:"root_cas_file" yaml:"root_cas_file"`
+	CertFile    string `json:"cert_file" yaml:"cert_file"`
+	KeyFile     string `json:"key_file" yaml:"key_file"`
+	SkipVerify  bool   `json:"skip_verify" yaml:"skip_verify"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		Enabled:     false,
+		RootCAsFile: "",
+		CertFile:    "",
+		KeyFile:     "",
+		SkipVerify:  false,
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Get returns a TLS config built from the fields of the config. Certificate
+// authorities are read from RootCAsFile when set, otherwise the system pool is
+// used. A client certificate is loaded when both CertFile and KeyFile are set.
+func (c Config) Get() (*tls.Config, error) {
+	tlsConf := &tls.Config{
+		InsecureSkipVerify: c.SkipVerify,
+	}
+
+	if len(c.RootCAsFile) > 0 {
+		caCert, err := ioutil.ReadFile(c.RootCAsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root CAs file: %v", err)
+		}
+		tlsConf.RootCAs = x509.NewCertPool()
+		if !tlsConf.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("failed to parse any certificates from root CAs file")
+		}
+	}
+
+	if len(c.CertFile) > 0 || len(c.KeyFile) > 0 {
+		if len(c.CertFile) == 0 || len(c.KeyFile) == 0 {
+			return nil, errors.New("both a cert file and key file must be specified for a client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, nil
+}
+
+//------------------------------------------------------------------------------
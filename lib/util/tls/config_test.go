@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func writeTestCert(t *testing.T, dir string) (certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "benthos-test"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPath = filepath.Join(dir, "cert.pem")
+	keyPath = filepath.Join(dir, "key.pem")
+	if err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{
+		Type: "CERTIFICATE", Bytes: certBytes,
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type: "EC PRIVATE KEY", Bytes: keyBytes,
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestConfigDefault(t *testing.T) {
+	tlsConf, err := NewConfig().Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConf.InsecureSkipVerify {
+		t.Error("Expected verification by default")
+	}
+	if tlsConf.RootCAs != nil {
+		t.Error("Expected system root CAs by default")
+	}
+	if len(tlsConf.Certificates) > 0 {
+		t.Error("Expected no client certificates by default")
+	}
+}
+
+func TestConfigFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_tls_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	certPath, keyPath := writeTestCert(t, tmpDir)
+
+	conf := NewConfig()
+	conf.RootCAsFile = certPath
+	conf.CertFile = certPath
+	conf.KeyFile = keyPath
+	conf.SkipVerify = true
+
+	tlsConf, err := conf.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tlsConf.InsecureSkipVerify {
+		t.Error("Expected verification to be skipped")
+	}
+	if tlsConf.RootCAs == nil || len(tlsConf.RootCAs.Subjects()) != 1 {
+		t.Error("Expected a single root CA")
+	}
+	if len(tlsConf.Certificates) != 1 {
+		t.Error("Expected a single client certificate")
+	}
+}
+
+func TestConfigBadFiles(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "benthos_tls_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	certPath, keyPath := writeTestCert(t, tmpDir)
+
+	tests := map[string]Config{
+		"missing root CAs file": {
+			RootCAsFile: filepath.Join(tmpDir, "nope.pem"),
+		},
+		"root CAs file without certs": {
+			RootCAsFile: keyPath,
+		},
+		"cert file without key file": {
+			CertFile: certPath,
+		},
+		"key file without cert file": {
+			KeyFile: keyPath,
+		},
+		"mismatched cert and key files": {
+			CertFile: keyPath,
+			KeyFile:  certPath,
+		},
+	}
+
+	for name, conf := range tests {
+		if _, err := conf.Get(); err == nil {
+			t.Errorf("%v: Expected error", name)
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,326 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zmtp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+//------------------------------------------------------------------------------
+
+const (
+	flagMore    = 0x01
+	flagLong    = 0x02
+	flagCommand = 0x04
+
+	greetingSize = 64
+
+	// maxFrameSize is the largest frame that is accepted from a peer.
+	maxFrameSize = 1 << 30
+)
+
+var (
+	errBadGreeting = errors.New("peer sent an invalid ZMTP greeting")
+	errFrameSize   = errors.New("peer sent a frame that exceeds the maximum size")
+)
+
+// greeting returns the ZMTP 3.0 greeting for the NULL mechanism.
+func greeting() []byte {
+	g := make([]byte, greetingSize)
+	g[0] = 0xFF
+	g[9] = 0x7F
+	g[10] = 3
+	g[11] = 0
+	copy(g[12:32], "NULL")
+	return g
+}
+
+//------------------------------------------------------------------------------
+
+// conn is a single connection to a peer that has completed the handshake.
+type conn struct {
+	netConn    net.Conn
+	reader     *bufio.Reader
+	peerType   string
+	writeMut   sync.Mutex
+	closeOnce  sync.Once
+	closedChan chan struct{}
+}
+
+// handshake exchanges greetings and READY commands with a peer, failing if the
+// peer is not a compatible socket type.
+func handshake(netConn net.Conn, socketType string) (*conn, error) {
+	c := &conn{
+		netConn:    netConn,
+		reader:     bufio.NewReader(netConn),
+		closedChan: make(chan struct{}),
+	}
+
+	if _, err := netConn.Write(greeting()); err != nil {
+		return nil, err
+	}
+
+	peerGreeting := make([]byte, greetingSize)
+	if _, err := io.ReadFull(c.reader, peerGreeting); err != nil {
+		return nil, err
+	}
+	if peerGreeting[0] != 0xFF || peerGreeting[9]&0x01 != 0x01 || peerGreeting[10] < 3 {
+		return nil, errBadGreeting
+	}
+	if mechanism := string(bytes.TrimRight(peerGreeting[12:32], "\x00")); mechanism != "NULL" {
+		return nil, fmt.Errorf("security mechanism not supported: %v", mechanism)
+	}
+
+	ready := encodeCommand("READY", map[string]string{"Socket-Type": socketType})
+	if err := c.writeFrame(ready, false, true); err != nil {
+		return nil, err
+	}
+
+	body, isCommand, _, err := c.readFrame()
+	if err != nil {
+		return nil, err
+	}
+	if !isCommand {
+		return nil, errors.New("expected READY command from peer")
+	}
+	name, props, err := decodeCommand(body)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "READY":
+	case "ERROR":
+		return nil, fmt.Errorf("peer rejected handshake: %s", props["reason"])
+	default:
+		return nil, fmt.Errorf("expected READY command from peer, received: %v", name)
+	}
+
+	c.peerType = props["Socket-Type"]
+	if !compatible(socketType, c.peerType) {
+		return nil, fmt.Errorf("socket type %v is not compatible with peer type %v", socketType, c.peerType)
+	}
+	return c, nil
+}
+
+// compatible returns whether two socket types may be connected.
+func compatible(a, b string) bool {
+	switch a {
+	case "PUSH":
+		return b == "PULL"
+	case "PULL":
+		return b == "PUSH"
+	case "PUB":
+		return b == "SUB" || b == "XSUB"
+	case "SUB":
+		return b == "PUB" || b == "XPUB"
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// encodeCommand encodes a command with a set of properties.
+func encodeCommand(name string, props map[string]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(len(name)))
+	buf.WriteString(name)
+	for k, v := range props {
+		buf.WriteByte(byte(len(k)))
+		buf.WriteString(k)
+		binary.Write(&buf, binary.BigEndian, uint32(len(v)))
+		buf.WriteString(v)
+	}
+	return buf.Bytes()
+}
+
+// decodeCommand decodes the name and properties of a command. The ERROR command
+// has a single reason string rather than properties, which is returned under
+// the key "reason".
+func decodeCommand(body []byte) (string, map[string]string, error) {
+	if len(body) < 1 || len(body) < int(body[0])+1 {
+		return "", nil, errors.New("peer sent a malformed command")
+	}
+	name := string(body[1 : int(body[0])+1])
+	body = body[int(body[0])+1:]
+
+	props := map[string]string{}
+	if name == "ERROR" {
+		if len(body) > 0 && len(body) >= int(body[0])+1 {
+			props["reason"] = string(body[1 : int(body[0])+1])
+		}
+		return name, props, nil
+	}
+	if name != "READY" {
+		return name, props, nil
+	}
+
+	for len(body) > 0 {
+		kLen := int(body[0])
+		if len(body) < kLen+5 {
+			return "", nil, errors.New("peer sent a malformed command property")
+		}
+		k := string(body[1 : kLen+1])
+		vLen := int(binary.BigEndian.Uint32(body[kLen+1 : kLen+5]))
+		body = body[kLen+5:]
+		if len(body) < vLen {
+			return "", nil, errors.New("peer sent a malformed command property")
+		}
+		props[k] = string(body[:vLen])
+		body = body[vLen:]
+	}
+	return name, props, nil
+}
+
+//------------------------------------------------------------------------------
+
+// writeFrame writes a single frame without locking.
+func (c *conn) writeFrame(body []byte, more, command bool) error {
+	var flags byte
+	if more {
+		flags |= flagMore
+	}
+	if command {
+		flags |= flagCommand
+	}
+
+	var header []byte
+	if len(body) > 255 {
+		header = make([]byte, 9)
+		header[0] = flags | flagLong
+		binary.BigEndian.PutUint64(header[1:], uint64(len(body)))
+	} else {
+		header = []byte{flags, byte(len(body))}
+	}
+	if _, err := c.netConn.Write(append(header, body...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readFrame reads a single frame.
+func (c *conn) readFrame() (body []byte, isCommand, more bool, err error) {
+	var flags byte
+	if flags, err = c.reader.ReadByte(); err != nil {
+		return
+	}
+
+	var size uint64
+	if flags&flagLong != 0 {
+		sizeBytes := make([]byte, 8)
+		if _, err = io.ReadFull(c.reader, sizeBytes); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(sizeBytes)
+	} else {
+		var sizeByte byte
+		if sizeByte, err = c.reader.ReadByte(); err != nil {
+			return
+		}
+		size = uint64(sizeByte)
+	}
+	if size > maxFrameSize {
+		err = errFrameSize
+		return
+	}
+
+	body = make([]byte, size)
+	if _, err = io.ReadFull(c.reader, body); err != nil {
+		return
+	}
+	return body, flags&flagCommand != 0, flags&flagMore != 0, nil
+}
+
+// send writes a multiple part message to the peer.
+func (c *conn) send(parts [][]byte) error {
+	c.writeMut.Lock()
+	defer c.writeMut.Unlock()
+
+	for i, p := range parts {
+		if err := c.writeFrame(p, i < len(parts)-1, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recv reads the next multiple part message from the peer, handling any
+// commands received in between.
+func (c *conn) recv() ([][]byte, error) {
+	var parts [][]byte
+	for {
+		body, isCommand, more, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		if isCommand {
+			if err = c.handleCommand(body); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		parts = append(parts, body)
+		if !more {
+			return parts, nil
+		}
+	}
+}
+
+// handleCommand responds to commands that may be received after the handshake.
+func (c *conn) handleCommand(body []byte) error {
+	name, props, err := decodeCommand(body)
+	if err != nil {
+		return err
+	}
+	switch name {
+	case "PING":
+		// The body of a PING is the command name, a two byte TTL and a context
+		// that must be returned in the PONG.
+		var context []byte
+		if rest := body[int(body[0])+1:]; len(rest) > 2 {
+			context = rest[2:]
+		}
+		pong := append([]byte{4}, "PONG"...)
+		c.writeMut.Lock()
+		err = c.writeFrame(append(pong, context...), false, true)
+		c.writeMut.Unlock()
+		return err
+	case "ERROR":
+		return fmt.Errorf("peer sent error: %v", props["reason"])
+	}
+	return nil
+}
+
+// close closes the connection.
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		c.netConn.Close()
+		close(c.closedChan)
+	})
+}
+
+//------------------------------------------------------------------------------
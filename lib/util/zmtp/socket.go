@@ -0,0 +1,430 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zmtp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+// Errors returned by sockets.
+var (
+	ErrTimeout        = errors.New("action timed out")
+	ErrClosed         = errors.New("socket closed")
+	ErrInvalidType    = errors.New("socket type not supported")
+	ErrInvalidAction  = errors.New("action not supported by socket type")
+	ErrUnsupportedURL = errors.New("only tcp URLs are supported")
+)
+
+const (
+	handshakeTimeout   = time.Second * 10
+	reconnectInterval  = time.Millisecond * 100
+	defaultRecvBufSize = 1000
+)
+
+//------------------------------------------------------------------------------
+
+// peer is a connection along with the subscriptions it has made, which are only
+// relevant to PUB sockets.
+type peer struct {
+	*conn
+
+	subsMut sync.RWMutex
+	subs    map[string]struct{}
+}
+
+func (p *peer) subscribed(topic []byte) bool {
+	p.subsMut.RLock()
+	defer p.subsMut.RUnlock()
+	for s := range p.subs {
+		if bytes.HasPrefix(topic, []byte(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// Socket is a ZeroMQ socket of type PUSH, PULL, PUB or SUB, which can be bound
+// to or connected with any number of addresses.
+type Socket struct {
+	socketType string
+
+	mut       sync.Mutex
+	peers     []*peer
+	next      int
+	peerAdded chan struct{}
+	filters   [][]byte
+	listeners []net.Listener
+
+	recvChan chan [][]byte
+
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+// NewSocket creates a socket of a type, where hwm is the number of received
+// messages that may be buffered before reading from peers is paused. A hwm of
+// zero or less uses a default.
+func NewSocket(socketType string, hwm int) (*Socket, error) {
+	switch socketType {
+	case "PUSH", "PULL", "PUB", "SUB":
+	default:
+		return nil, ErrInvalidType
+	}
+	if hwm <= 0 {
+		hwm = defaultRecvBufSize
+	}
+	return &Socket{
+		socketType: socketType,
+		peerAdded:  make(chan struct{}),
+		recvChan:   make(chan [][]byte, hwm),
+		closeChan:  make(chan struct{}),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// parseURL returns the TCP address of a URL such as tcp://localhost:5555, where
+// a wildcard host (tcp://*:5555) is interpreted as all interfaces.
+func parseURL(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Scheme != "tcp" {
+		return "", ErrUnsupportedURL
+	}
+	host := parsed.Host
+	if strings.HasPrefix(host, "*:") {
+		host = host[1:]
+	}
+	return host, nil
+}
+
+// Bind listens for peers on a URL.
+func (s *Socket) Bind(u string) error {
+	addr, err := parseURL(u)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.mut.Lock()
+	s.listeners = append(s.listeners, listener)
+	s.mut.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.serve(netConn)
+			}()
+		}
+	}()
+	return nil
+}
+
+// Addrs returns the addresses of bound listeners.
+func (s *Socket) Addrs() []net.Addr {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	addrs := make([]net.Addr, 0, len(s.listeners))
+	for _, l := range s.listeners {
+		addrs = append(addrs, l.Addr())
+	}
+	return addrs
+}
+
+// Connect to a peer at a URL. The connection is established in the background
+// and is reestablished whenever it is lost until the socket is closed.
+func (s *Socket) Connect(u string) error {
+	addr, err := parseURL(u)
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			if netConn, err := net.DialTimeout("tcp", addr, handshakeTimeout); err == nil {
+				s.serve(netConn)
+			}
+			select {
+			case <-time.After(reconnectInterval):
+			case <-s.closeChan:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Subscribe adds a topic prefix filter to a SUB socket.
+func (s *Socket) Subscribe(filter []byte) error {
+	if s.socketType != "SUB" {
+		return ErrInvalidAction
+	}
+
+	s.mut.Lock()
+	s.filters = append(s.filters, filter)
+	peers := append([]*peer(nil), s.peers...)
+	s.mut.Unlock()
+
+	for _, p := range peers {
+		if err := p.send([][]byte{append([]byte{1}, filter...)}); err != nil {
+			p.close()
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (s *Socket) addPeer(p *peer) {
+	s.mut.Lock()
+	s.peers = append(s.peers, p)
+	close(s.peerAdded)
+	s.peerAdded = make(chan struct{})
+	s.mut.Unlock()
+}
+
+func (s *Socket) removePeer(p *peer) {
+	s.mut.Lock()
+	for i, existing := range s.peers {
+		if existing == p {
+			s.peers = append(s.peers[:i], s.peers[i+1:]...)
+			break
+		}
+	}
+	s.mut.Unlock()
+}
+
+// serve performs the handshake with a peer and then reads from it until the
+// connection is lost or the socket is closed.
+func (s *Socket) serve(netConn net.Conn) {
+	netConn.SetDeadline(time.Now().Add(handshakeTimeout))
+	c, err := handshake(netConn, s.socketType)
+	if err != nil {
+		netConn.Close()
+		return
+	}
+	netConn.SetDeadline(time.Time{})
+
+	p := &peer{conn: c, subs: map[string]struct{}{}}
+	defer func() {
+		s.removePeer(p)
+		p.close()
+	}()
+
+	select {
+	case <-s.closeChan:
+		return
+	default:
+	}
+
+	if s.socketType == "SUB" {
+		s.mut.Lock()
+		filters := append([][]byte(nil), s.filters...)
+		s.mut.Unlock()
+		for _, f := range filters {
+			if err = p.send([][]byte{append([]byte{1}, f...)}); err != nil {
+				return
+			}
+		}
+	}
+	s.addPeer(p)
+
+	go func() {
+		select {
+		case <-s.closeChan:
+			p.close()
+		case <-p.closedChan:
+		}
+	}()
+
+	for {
+		parts, err := p.recv()
+		if err != nil {
+			return
+		}
+		switch s.socketType {
+		case "PULL", "SUB":
+			if s.socketType == "SUB" && !s.matches(parts[0]) {
+				continue
+			}
+			select {
+			case s.recvChan <- parts:
+			case <-s.closeChan:
+				return
+			}
+		case "PUB":
+			// ZMTP 3.0 subscriptions are messages prefixed with a byte that
+			// indicates subscribe (1) or unsubscribe (0).
+			if len(parts) != 1 || len(parts[0]) == 0 {
+				continue
+			}
+			p.subsMut.Lock()
+			switch parts[0][0] {
+			case 1:
+				p.subs[string(parts[0][1:])] = struct{}{}
+			case 0:
+				delete(p.subs, string(parts[0][1:]))
+			}
+			p.subsMut.Unlock()
+		}
+	}
+}
+
+// matches returns whether a topic matches the filters of a SUB socket.
+func (s *Socket) matches(topic []byte) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for _, f := range s.filters {
+		if bytes.HasPrefix(topic, f) {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// Send a message of one or more parts. A PUSH socket sends the message to the
+// next peer in a round robin, blocking until a peer is available or the timeout
+// occurs. A PUB socket sends the message to all peers subscribed to its first
+// part and never blocks.
+func (s *Socket) Send(parts [][]byte, timeout time.Duration) error {
+	if len(parts) == 0 {
+		return nil
+	}
+	switch s.socketType {
+	case "PUSH":
+		return s.push(parts, timeout)
+	case "PUB":
+		return s.publish(parts)
+	}
+	return ErrInvalidAction
+}
+
+func (s *Socket) push(parts [][]byte, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		s.mut.Lock()
+		var p *peer
+		if len(s.peers) > 0 {
+			s.next = (s.next + 1) % len(s.peers)
+			p = s.peers[s.next]
+		}
+		peerAdded := s.peerAdded
+		s.mut.Unlock()
+
+		if p != nil {
+			if err := p.send(parts); err == nil {
+				return nil
+			}
+			p.close()
+			s.removePeer(p)
+			continue
+		}
+
+		select {
+		case <-peerAdded:
+		case <-deadline:
+			return ErrTimeout
+		case <-s.closeChan:
+			return ErrClosed
+		}
+	}
+}
+
+func (s *Socket) publish(parts [][]byte) error {
+	s.mut.Lock()
+	peers := append([]*peer(nil), s.peers...)
+	s.mut.Unlock()
+
+	for _, p := range peers {
+		if !p.subscribed(parts[0]) {
+			continue
+		}
+		if err := p.send(parts); err != nil {
+			p.close()
+			s.removePeer(p)
+		}
+	}
+	return nil
+}
+
+// Recv reads a message of one or more parts from a PULL or SUB socket, blocking
+// until a message arrives or the timeout occurs.
+func (s *Socket) Recv(timeout time.Duration) ([][]byte, error) {
+	if s.socketType != "PULL" && s.socketType != "SUB" {
+		return nil, ErrInvalidAction
+	}
+	select {
+	case parts := <-s.recvChan:
+		return parts, nil
+	case <-time.After(timeout):
+		return nil, ErrTimeout
+	case <-s.closeChan:
+		return nil, ErrClosed
+	}
+}
+
+// Close the socket along with all of its listeners and peer connections.
+func (s *Socket) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+
+		s.mut.Lock()
+		for _, l := range s.listeners {
+			l.Close()
+		}
+		for _, p := range s.peers {
+			p.close()
+		}
+		s.mut.Unlock()
+	})
+	s.wg.Wait()
+	return nil
+}
+
+//------------------------------------------------------------------------------
@@ -0,0 +1,232 @@
+// Copyright (c) 2018 Ashley Jeffs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zmtp
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//------------------------------------------------------------------------------
+
+func bindSocket(t *testing.T, socketType string) (*Socket, string) {
+	t.Helper()
+	s, err := NewSocket(socketType, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Bind("tcp://127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	return s, fmt.Sprintf("tcp://%v", s.Addrs()[0])
+}
+
+func connectSocket(t *testing.T, socketType, u string) *Socket {
+	t.Helper()
+	s, err := NewSocket(socketType, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Connect(u); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPushPull(t *testing.T) {
+	pull, u := bindSocket(t, "PULL")
+	defer pull.Close()
+
+	push := connectSocket(t, "PUSH", u)
+	defer push.Close()
+
+	large := bytes.Repeat([]byte("a"), 1000)
+	exp := [][][]byte{
+		{[]byte("foo")},
+		{[]byte("bar"), []byte("baz"), {}},
+		{large},
+	}
+
+	for _, parts := range exp {
+		if err := push.Send(parts, time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, e := range exp {
+		act, err := pull.Recv(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(e, act) {
+			t.Errorf("Wrong message: %s != %s", act, e)
+		}
+	}
+
+	if _, err := pull.Recv(time.Millisecond * 10); err != ErrTimeout {
+		t.Errorf("Wrong error: %v != %v", err, ErrTimeout)
+	}
+}
+
+func TestPushRoundRobin(t *testing.T) {
+	push, u := bindSocket(t, "PUSH")
+	defer push.Close()
+
+	pullA := connectSocket(t, "PULL", u)
+	defer pullA.Close()
+	pullB := connectSocket(t, "PULL", u)
+	defer pullB.Close()
+
+	for i := 0; i < 100; i++ {
+		push.mut.Lock()
+		n := len(push.peers)
+		push.mut.Unlock()
+		if n == 2 {
+			break
+		}
+		<-time.After(time.Millisecond * 10)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := push.Send([][]byte{[]byte("foo")}, time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, pull := range []*Socket{pullA, pullB} {
+		for i := 0; i < 2; i++ {
+			if _, err := pull.Recv(time.Second); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestPushTimeout(t *testing.T) {
+	push, err := NewSocket("PUSH", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer push.Close()
+
+	if err = push.Send([][]byte{[]byte("foo")}, time.Millisecond*10); err != ErrTimeout {
+		t.Errorf("Wrong error: %v != %v", err, ErrTimeout)
+	}
+}
+
+func TestPubSub(t *testing.T) {
+	pub, u := bindSocket(t, "PUB")
+	defer pub.Close()
+
+	sub, err := NewSocket("SUB", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+	if err = sub.Subscribe([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if err = sub.Connect(u); err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish until the subscription has been received.
+	var parts [][]byte
+	for i := 0; i < 100 && parts == nil; i++ {
+		if err = pub.Send([][]byte{[]byte("bar"), []byte("nope")}, time.Second); err != nil {
+			t.Fatal(err)
+		}
+		if err = pub.Send([][]byte{[]byte("foo bar"), []byte("yep")}, time.Second); err != nil {
+			t.Fatal(err)
+		}
+		parts, _ = sub.Recv(time.Millisecond * 10)
+	}
+
+	if exp := [][]byte{[]byte("foo bar"), []byte("yep")}; !reflect.DeepEqual(exp, parts) {
+		t.Errorf("Wrong message: %s != %s", parts, exp)
+	}
+}
+
+func TestSocketTypes(t *testing.T) {
+	if _, err := NewSocket("REQ", 0); err != ErrInvalidType {
+		t.Errorf("Wrong error: %v != %v", err, ErrInvalidType)
+	}
+
+	pull, u := bindSocket(t, "PULL")
+	defer pull.Close()
+
+	// A SUB socket is not compatible with a PULL socket and is never added.
+	sub := connectSocket(t, "SUB", u)
+	defer sub.Close()
+
+	<-time.After(time.Millisecond * 50)
+	pull.mut.Lock()
+	n := len(pull.peers)
+	pull.mut.Unlock()
+	if n != 0 {
+		t.Errorf("Expected incompatible peer to be rejected")
+	}
+
+	if err := pull.Send([][]byte{[]byte("foo")}, time.Second); err != ErrInvalidAction {
+		t.Errorf("Wrong error: %v != %v", err, ErrInvalidAction)
+	}
+	if err := pull.Subscribe([]byte("foo")); err != ErrInvalidAction {
+		t.Errorf("Wrong error: %v != %v", err, ErrInvalidAction)
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	pull, u := bindSocket(t, "PULL")
+
+	push := connectSocket(t, "PUSH", u)
+	defer push.Close()
+
+	if err := push.Send([][]byte{[]byte("foo")}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pull.Recv(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	pull.Close()
+
+	pull, err := NewSocket("PULL", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pull.Close()
+	if err = pull.Bind(u); err != nil {
+		t.Fatal(err)
+	}
+
+	var parts [][]byte
+	for i := 0; i < 100 && parts == nil; i++ {
+		if err = push.Send([][]byte{[]byte("bar")}, time.Second); err != nil {
+			t.Fatal(err)
+		}
+		parts, _ = pull.Recv(time.Millisecond * 10)
+	}
+	if exp := [][]byte{[]byte("bar")}; !reflect.DeepEqual(exp, parts) {
+		t.Errorf("Wrong message: %s != %s", parts, exp)
+	}
+}
+
+//------------------------------------------------------------------------------